@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const usage = `usage: admin <command> [flags]
+
+commands:
+  create-admin-user        create a user with the admin role
+  assign-role              assign a role to a user
+  reset-password           set a new password for a user and sign them out everywhere
+  reindex                  build missing database indexes and rebuild the search index
+  refresh-recommendations  retrain the matrix factorization recommender
+  export-data              export users, products or interaction events as NDJSON
+  check-integrity          report orphaned documents and optionally repair or quarantine them
+
+every command takes -tenant to run against one tenant when tenancy is enabled
+run "admin <command> -h" for the flags of a command`
+
+// exportBatchSize is how many users or events export-data fetches per query
+const exportBatchSize = 1000
+
+// Operational tasks run through the service layer, so they apply the same rules as the API.
+// Example: go run ./cmd/admin create-admin-user -email ops@example.com -password 'S3cure!pass'
+// Example: go run ./cmd/admin assign-role -user ops@example.com -role moderator
+// Example: go run ./cmd/admin export-data -data users -out users.ndjson
+// Example: go run ./cmd/admin check-integrity -repair -checks view_product,like_product
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	switch os.Args[1] {
+	case "create-admin-user":
+		createAdminUser(ctx, os.Args[2:])
+	case "assign-role":
+		assignRole(ctx, os.Args[2:])
+	case "reset-password":
+		resetPassword(ctx, os.Args[2:])
+	case "reindex":
+		reindex(ctx, os.Args[2:])
+	case "refresh-recommendations":
+		refreshRecommendations(ctx, os.Args[2:])
+	case "export-data":
+		exportData(ctx, os.Args[2:])
+	case "check-integrity":
+		checkIntegrity(ctx, os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q\n%s", os.Args[1], usage)
+	}
+}
+
+func createAdminUser(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("create-admin-user", flag.ExitOnError)
+	emailFlag := flags.String("email", "", "email of the new user")
+	passwordFlag := flags.String("password", "", "password of the new user; read from stdin if empty")
+	tenantFlag := flags.String("tenant", "", "tenant to create the user in")
+	flags.Parse(args)
+
+	if *emailFlag == "" {
+		log.Fatal("-email is required")
+	}
+	password := readPassword(*passwordFlag)
+
+	app := connect(ctx, *tenantFlag)
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	user, err := app.services.UserService.CreateUser(app.ctx, *emailFlag, password)
+	if err != nil {
+		adminLogger.WithError(err).Fatal("Failed to create user")
+	}
+	if err := app.services.PermissionService.AssignRole(app.ctx, user.ID, "admin"); err != nil {
+		adminLogger.WithError(err).WithFields(logger.Fields{"user_id": user.ID}).Fatal("Failed to assign admin role")
+	}
+
+	adminLogger.WithFields(logger.Fields{"user_id": user.ID, "email": user.Email}).Info("Admin user created")
+}
+
+func assignRole(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("assign-role", flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of the user")
+	roleFlag := flags.String("role", "", "name of the role")
+	tenantFlag := flags.String("tenant", "", "tenant of the user")
+	flags.Parse(args)
+
+	if *userFlag == "" || *roleFlag == "" {
+		log.Fatal("-user and -role are required")
+	}
+
+	app := connect(ctx, *tenantFlag)
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	user := app.findUser(*userFlag)
+	if err := app.services.PermissionService.AssignRole(app.ctx, user.ID, *roleFlag); err != nil {
+		adminLogger.WithError(err).Fatal("Failed to assign role")
+	}
+
+	adminLogger.WithFields(logger.Fields{"user_id": user.ID, "role": *roleFlag}).
+		Info("Role assigned; it applies from the user's next access token")
+}
+
+func resetPassword(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	userFlag := flags.String("user", "", "id or email of the user")
+	passwordFlag := flags.String("password", "", "new password; read from stdin if empty")
+	tenantFlag := flags.String("tenant", "", "tenant of the user")
+	flags.Parse(args)
+
+	if *userFlag == "" {
+		log.Fatal("-user is required")
+	}
+	password := readPassword(*passwordFlag)
+
+	app := connect(ctx, *tenantFlag)
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	user := app.findUser(*userFlag)
+	if err := app.services.UserService.ResetPassword(app.ctx, user.ID, password); err != nil {
+		adminLogger.WithError(err).Fatal("Failed to reset password")
+	}
+
+	adminLogger.WithFields(logger.Fields{"user_id": user.ID}).Info("Password reset and sessions revoked")
+}
+
+func reindex(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	indexesFlag := flags.Bool("indexes", true, "build the missing database indexes")
+	searchFlag := flags.Bool("search", true, "rebuild the search index when search.provider is elasticsearch")
+	tenantFlag := flags.String("tenant", "", "tenant whose products are reindexed")
+	flags.Parse(args)
+
+	app := connect(ctx, *tenantFlag)
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	if *indexesFlag {
+		started, err := app.services.IndexService.BuildMissingIndexes(app.ctx)
+		if err != nil {
+			adminLogger.WithError(err).Fatal("Failed to build indexes")
+		}
+		adminLogger.WithFields(logger.Fields{"indexes": started}).
+			Info("Index builds started; they finish in the background")
+	}
+
+	if *searchFlag {
+		start := time.Now()
+		if err := app.services.SearchService.Reindex(app.ctx); err != nil {
+			adminLogger.WithError(err).Fatal("Failed to reindex products")
+		}
+		adminLogger.WithDuration(time.Since(start)).Info("Search index rebuilt")
+	}
+}
+
+func refreshRecommendations(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("refresh-recommendations", flag.ExitOnError)
+	tenantFlag := flags.String("tenant", "", "tenant to train the model of")
+	flags.Parse(args)
+
+	app := connect(ctx, *tenantFlag)
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	start := time.Now()
+	stats, err := app.services.RecommendationService.TrainFactorModel(app.ctx)
+	if err != nil {
+		adminLogger.WithError(err).Fatal("Failed to train model")
+	}
+
+	adminLogger.WithDuration(time.Since(start)).
+		WithFields(logger.Fields{
+			"users":        stats.Users,
+			"items":        stats.Items,
+			"interactions": stats.Interactions,
+		}).
+		Info("Model trained")
+}
+
+func exportData(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("export-data", flag.ExitOnError)
+	dataFlag := flags.String("data", "", "what to export: users, products or events")
+	outFlag := flags.String("out", "", "output file; stdout by default")
+	fromFlag := flags.String("from", "", "events: start of the time range, inclusive (RFC3339)")
+	toFlag := flags.String("to", "", "events: end of the time range, exclusive (RFC3339)")
+	inactiveFlag := flags.Bool("inactive", false, "products: include inactive products")
+	tenantFlag := flags.String("tenant", "", "tenant to export")
+	flags.Parse(args)
+
+	var filter domain.InteractionExportFilter
+	if *fromFlag != "" {
+		from, err := time.Parse(time.RFC3339, *fromFlag)
+		if err != nil {
+			log.Fatalf("invalid -from: %v", err)
+		}
+		filter.From = &from
+	}
+	if *toFlag != "" {
+		to, err := time.Parse(time.RFC3339, *toFlag)
+		if err != nil {
+			log.Fatalf("invalid -to: %v", err)
+		}
+		filter.To = &to
+	}
+
+	var export func(app *app, encoder *json.Encoder) (int, error)
+	switch *dataFlag {
+	case "users":
+		export = exportUsers
+	case "products":
+		export = func(app *app, encoder *json.Encoder) (int, error) {
+			return exportProducts(app, encoder, *inactiveFlag)
+		}
+	case "events":
+		export = func(app *app, encoder *json.Encoder) (int, error) {
+			return exportEvents(app, encoder, filter)
+		}
+	default:
+		log.Fatalf("-data must be users, products or events, got %q", *dataFlag)
+	}
+
+	app := connect(ctx, *tenantFlag, func(cfg *config.Config) {
+		// Keep stdout clean for the exported data
+		if *outFlag == "" && cfg.Logger.Output == "stdout" {
+			cfg.Logger.Output = "stderr"
+		}
+	})
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	var out io.Writer = os.Stdout
+	if *outFlag != "" {
+		file, err := os.Create(*outFlag)
+		if err != nil {
+			adminLogger.WithError(err).Fatal("Failed to create output file")
+		}
+		defer file.Close()
+		out = file
+	}
+	writer := bufio.NewWriter(out)
+
+	start := time.Now()
+	total, err := export(app, json.NewEncoder(writer))
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		adminLogger.WithError(err).WithFields(logger.Fields{"exported": total}).Fatal("Failed to export data")
+	}
+
+	adminLogger.WithDuration(time.Since(start)).
+		WithFields(logger.Fields{"data": *dataFlag, "records": total}).
+		Info("Export finished")
+}
+
+func checkIntegrity(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("check-integrity", flag.ExitOnError)
+	checksFlag := flags.String("checks", "", "comma separated checks to repair; all that found orphans if empty")
+	repairFlag := flags.Bool("repair", false, "unset dangling references and delete dangling views, likes and role assignments")
+	quarantineFlag := flags.Bool("quarantine", false, "move orphaned documents to the quarantine collection")
+	tenantFlag := flags.String("tenant", "", "tenant to check")
+	flags.Parse(args)
+
+	if *repairFlag && *quarantineFlag {
+		log.Fatal("-repair and -quarantine are exclusive")
+	}
+
+	app := connect(ctx, *tenantFlag)
+	defer app.close()
+	adminLogger := app.logger.WithComponent("admin")
+
+	if *repairFlag || *quarantineFlag {
+		action := domain.IntegrityActionRepair
+		if *quarantineFlag {
+			action = domain.IntegrityActionQuarantine
+		}
+		var checks []string
+		if *checksFlag != "" {
+			for _, check := range strings.Split(*checksFlag, ",") {
+				checks = append(checks, strings.TrimSpace(check))
+			}
+		}
+
+		repairs, err := app.services.IntegrityService.RepairIntegrity(app.ctx, checks, action)
+		for _, repair := range repairs {
+			adminLogger.WithFields(logger.Fields{"check": repair.Check, "action": repair.Action, "affected": repair.Affected}).
+				Info("Handled orphaned documents")
+		}
+		if err != nil {
+			adminLogger.WithError(err).Fatal("Failed to repair orphaned documents")
+		}
+	}
+
+	issues, err := app.services.IntegrityService.CheckIntegrity(app.ctx)
+	if err != nil {
+		adminLogger.WithError(err).Fatal("Failed to check integrity")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tREFERENCE\tORPHANS\tREPAIR\tSAMPLE")
+	var orphans int64
+	for _, issue := range issues {
+		repair := issue.Repair
+		if repair == "" {
+			repair = "quarantine only"
+		}
+		sample := make([]string, len(issue.Sample))
+		for i, id := range issue.Sample {
+			sample[i] = fmt.Sprint(id)
+		}
+		fmt.Fprintf(w, "%s\t%s.%s -> %s\t%d\t%s\t%s\n", issue.Check, issue.Collection, issue.Field,
+			issue.References, issue.Count, repair, strings.Join(sample, ","))
+		orphans += issue.Count
+	}
+	w.Flush()
+
+	// A non-zero exit lets scheduled runs alert on orphans
+	if orphans > 0 {
+		app.close()
+		os.Exit(1)
+	}
+}
+
+// exportUsers writes every user with their lifetime value, in id order
+func exportUsers(app *app, encoder *json.Encoder) (int, error) {
+	filter := domain.UserFilter{Sort: []domain.SortField{{Field: "id"}}, Limit: exportBatchSize}
+	total := 0
+	for {
+		users, _, err := app.services.UserService.ListUsers(app.ctx, filter)
+		if err != nil {
+			return total, err
+		}
+		for _, user := range users {
+			if err := encoder.Encode(user); err != nil {
+				return total, err
+			}
+		}
+		total += len(users)
+
+		if len(users) < filter.Limit {
+			return total, nil
+		}
+		filter.Offset += len(users)
+	}
+}
+
+// exportProducts writes the active products with their categories, followed by the inactive
+// ones if inactive is set
+func exportProducts(app *app, encoder *json.Encoder, inactive bool) (int, error) {
+	statuses := []bool{true}
+	if inactive {
+		statuses = append(statuses, false)
+	}
+	total := 0
+	for _, active := range statuses {
+		filter := domain.ProductFilter{IsActive: &active}
+		err := app.services.ProductService.StreamProductsWithCategories(app.ctx, filter, func(product *domain.ProductWithCategory) error {
+			total++
+			return encoder.Encode(product)
+		})
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// exportEvents writes the interaction events of the filter's time range
+func exportEvents(app *app, encoder *json.Encoder, filter domain.InteractionExportFilter) (int, error) {
+	filter.Limit = exportBatchSize
+	total := 0
+	for {
+		batch, err := app.services.InteractionService.ExportEvents(app.ctx, filter)
+		if err != nil {
+			return total, err
+		}
+		for _, event := range batch.Events {
+			if err := encoder.Encode(event); err != nil {
+				return total, err
+			}
+		}
+		total += batch.Count
+
+		if batch.NextCursor == "" {
+			return total, nil
+		}
+		filter.Cursor = batch.NextCursor
+	}
+}
+
+// app holds what every command needs, with ctx carrying the tenant
+type app struct {
+	ctx      context.Context
+	logger   *logger.Logger
+	db       *mongodb.MongoDB
+	services *service.Service
+}
+
+// connect loads the config, applies the overrides to it and connects to the database
+func connect(ctx context.Context, tenantID string, overrides ...func(*config.Config)) *app {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// Commands may scan whole collections, so the request-sized timeouts do not apply
+	cfg.Mongo.QueryTimeout = -1
+	cfg.Mongo.AggregateTimeout = -1
+	for _, override := range overrides {
+		override(cfg)
+	}
+
+	if tenantID != "" {
+		commandTenant := cfg.Tenancy.Tenant(tenantID)
+		if !cfg.Tenancy.Enabled || commandTenant == nil {
+			log.Fatalf("unknown tenant %q", tenantID)
+		}
+		ctx = tenant.NewContext(ctx, commandTenant)
+	}
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+
+	return &app{
+		ctx:    ctx,
+		logger: appLogger,
+		db:     db,
+		services: service.NewServices(service.Deps{
+			Repos:  repository.NewRepositories(db, cfg),
+			Config: cfg,
+		}),
+	}
+}
+
+func (a *app) close() {
+	a.db.Close(context.Background())
+	a.logger.Close()
+}
+
+// findUser returns the user with the id or email, exiting if there is none
+func (a *app) findUser(idOrEmail string) *domain.User {
+	var (
+		user *domain.User
+		err  error
+	)
+	if id, convErr := strconv.Atoi(idOrEmail); convErr == nil {
+		user, _, err = a.services.UserService.GetProfile(a.ctx, id, nil)
+	} else {
+		user, err = a.services.UserService.GetUserByEmail(a.ctx, idOrEmail)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			a.logger.WithComponent("admin").WithFields(logger.Fields{"user": idOrEmail}).Fatal("User not found")
+		}
+		a.logger.WithComponent("admin").WithError(err).Fatal("Failed to find user")
+	}
+	return user
+}
+
+// readPassword returns the password, or the first line of stdin if it is empty, so it doesn't
+// have to show up in the shell history
+func readPassword(password string) string {
+	if password != "" {
+		return password
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Fatalf("failed to read password: %v", err)
+	}
+	if password = strings.TrimRight(line, "\r\n"); password == "" {
+		log.Fatal("a password is required, with -password or on stdin")
+	}
+	return password
+}
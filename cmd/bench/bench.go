@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/repository/memory"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+)
+
+// benchmark is one operation measured with testing.Benchmark
+type benchmark struct {
+	name string
+	run  func(b *testing.B)
+}
+
+// workload is the seeded data the benchmarks sample from
+type workload struct {
+	userIDs     []int // users with at least one interaction
+	categoryIDs []int
+	products    int
+}
+
+// snapshot copies the seeded catalog and interactions into memory, so the benchmarks measure
+// the services' own work rather than MongoDB. Users and profiles are not copied; profiles only
+// add favorite category boosts.
+func snapshot(ctx context.Context, repos *repository.Repository) (*memory.Snapshot, error) {
+	categories, err := repos.Product.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	products, _, err := repos.Product.List(ctx, domain.ProductFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list products: %w", err)
+	}
+	views, err := repos.Interaction.GetAllUserViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get views: %w", err)
+	}
+	likes, err := repos.Interaction.GetAllUserLikes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get likes: %w", err)
+	}
+	purchases, err := repos.Interaction.GetAllUserPurchases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get purchases: %w", err)
+	}
+
+	data := &memory.Snapshot{Views: views, Likes: likes, Purchases: purchases}
+	for _, category := range categories {
+		data.Categories = append(data.Categories, *category)
+	}
+	for _, product := range products {
+		data.Products = append(data.Products, *product)
+	}
+	return data, nil
+}
+
+// loadWorkload collects the IDs the benchmarks pick from
+func loadWorkload(ctx context.Context, repos *repository.Repository, sample int, rng *rand.Rand) (*workload, error) {
+	views, err := repos.Interaction.GetAllUserViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get views: %w", err)
+	}
+	likes, err := repos.Interaction.GetAllUserLikes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get likes: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	for _, view := range views {
+		seen[view.UserID] = true
+	}
+	for _, like := range likes {
+		seen[like.UserID] = true
+	}
+	userIDs := make([]int, 0, len(seen))
+	for userID := range seen {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Ints(userIDs)
+	rng.Shuffle(len(userIDs), func(i, j int) { userIDs[i], userIDs[j] = userIDs[j], userIDs[i] })
+	if sample > 0 && len(userIDs) > sample {
+		userIDs = userIDs[:sample]
+	}
+
+	counts, err := repos.Product.CountActiveProductsByCategory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count products: %w", err)
+	}
+	w := &workload{userIDs: userIDs}
+	for categoryID, count := range counts {
+		w.categoryIDs = append(w.categoryIDs, categoryID)
+		w.products += int(count)
+	}
+	sort.Ints(w.categoryIDs)
+
+	if len(w.userIDs) == 0 || w.products == 0 {
+		return nil, fmt.Errorf("no interactions or active products, seed the database first")
+	}
+	return w, nil
+}
+
+// benchmarks measures recommendations for the sampled users and the first pages of the
+// product listing, with and without a category filter
+func benchmarks(repos *repository.Repository, w *workload, cfg *config.Config) []benchmark {
+	ctx := context.Background()
+	// Measure generating recommendations, not cache hits
+	cfg.Recommendation.CacheTTL = "0s"
+	recommendations, err := service.NewRecommendationService(repos.Interaction, repos.Product, repos.Recommendation, repos.Profile, nil, cfg)
+	if err != nil {
+		log.Fatalf("failed to create recommendation service: %v", err)
+	}
+	outbox, err := service.NewOutbox(repos.Outbox, nil, cfg)
+	if err != nil {
+		log.Fatalf("failed to create outbox: %v", err)
+	}
+	products := service.NewProductService(repos.Product, repos.Stock, repos.Warehouse, nil, repos.Transactor, outbox, "USD", nil)
+
+	const pageSize = 20
+	pages := (w.products + pageSize - 1) / pageSize
+	if pages > 50 {
+		pages = 50 // deep pages are rare and skip-bound
+	}
+
+	return []benchmark{
+		{"GetRecommendations", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := recommendations.GetRecommendations(ctx, w.userIDs[i%len(w.userIDs)], 10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+		{"GetSimilarUsers", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := recommendations.GetSimilarUsers(ctx, w.userIDs[i%len(w.userIDs)], 10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+		{"ListProducts", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				filter := domain.ProductFilter{Limit: pageSize, Offset: (i % pages) * pageSize}
+				if _, _, err := products.ListProductsWithCategories(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+		{"ListProductsByCategory", func(b *testing.B) {
+			if len(w.categoryIDs) == 0 {
+				b.Skip("no categories")
+			}
+			for i := 0; i < b.N; i++ {
+				categoryID := w.categoryIDs[i%len(w.categoryIDs)]
+				filter := domain.ProductFilter{CategoryID: &categoryID, Limit: pageSize}
+				if _, _, err := products.ListProductsWithCategories(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+	}
+}
+
+// runBenchmarks prints results in the `go test -bench` format, so runs can be compared
+// with benchstat
+func runBenchmarks(out io.Writer, benches []benchmark, count int) {
+	fmt.Fprintf(out, "goos: %s\ngoarch: %s\npkg: github.com/PrimeraAizen/e-comm/cmd/bench\n", runtime.GOOS, runtime.GOARCH)
+	for _, bench := range benches {
+		for i := 0; i < count; i++ {
+			result := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				bench.run(b)
+			})
+			if result.N == 0 {
+				fmt.Fprintf(out, "--- SKIP: Benchmark%s (skipped or failed)\n", bench.name)
+				break
+			}
+			fmt.Fprintf(out, "Benchmark%s-%d\t%s\t%s\n", bench.name, runtime.GOMAXPROCS(0), result.String(), result.MemString())
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/repository/memory"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Benchmarks recommendations and product listing on a database seeded by cmd/seed, or writes a
+// k6 or vegeta load test against the running API for the same data. The flash-sale scenario
+// has every user race for the units of a product's flash sale, set beforehand.
+// Example:
+//
+//	go run ./cmd/seed -users 100000 -products 10000 -views 800000 -likes 120000 -purchases 80000
+//	go run ./cmd/bench -count 5 > before.txt
+//	go run ./cmd/bench -scenario k6 -out load.js
+//	go run ./cmd/bench -scenario flash-sale -product 42 -users 2000 -out flash-sale.js
+func main() {
+	storeFlag := flag.String("store", "memory", "memory copies the seeded data into the in-memory repositories; mongo benchmarks against MongoDB")
+	sampleFlag := flag.Int("sample", 1000, "number of users with interactions to request recommendations for")
+	countFlag := flag.Int("count", 1, "run each benchmark this many times")
+	benchtimeFlag := flag.String("benchtime", "1s", "run each benchmark for this long, or Nx for N iterations")
+	seedFlag := flag.Int64("seed", 1, "random seed for sampling users and requests")
+	scenarioFlag := flag.String("scenario", "", "write a load test instead of benchmarking: k6, vegeta or flash-sale")
+	outFlag := flag.String("out", "", "file to write results or the load test to (default stdout)")
+	baseURLFlag := flag.String("base-url", "http://localhost:8080", "API address the load test targets")
+	usersFlag := flag.Int("users", 50, "number of users the load test signs in as")
+	passwordFlag := flag.String("password", "password123", "password of the seeded users")
+	rateFlag := flag.Int("rate", 50, "k6 requests per second per endpoint")
+	durationFlag := flag.Duration("duration", time.Minute, "k6 test duration, shorter than jwt.access_token_duration")
+	targetsFlag := flag.Int("targets", 10000, "number of vegeta targets to write")
+	productFlag := flag.Int("product", 0, "product whose flash sale the flash-sale load test buys from")
+	flag.Parse()
+
+	// testing.Benchmark reads its settings from the test flags, registered after ours so they
+	// stay out of -h
+	testing.Init()
+	if err := flag.Set("test.benchtime", *benchtimeFlag); err != nil {
+		log.Fatalf("invalid -benchtime: %v", err)
+	}
+	if *storeFlag != "memory" && *storeFlag != "mongo" {
+		log.Fatal("-store must be memory or mongo")
+	}
+	if *scenarioFlag != "" && *scenarioFlag != "k6" && *scenarioFlag != "vegeta" && *scenarioFlag != "flash-sale" {
+		log.Fatal("-scenario must be k6, vegeta or flash-sale")
+	}
+	if *scenarioFlag == "flash-sale" && *productFlag < 1 {
+		log.Fatal("-product is required with -scenario flash-sale")
+	}
+	if *countFlag < 1 || *usersFlag < 1 || *rateFlag < 1 || *targetsFlag < 1 || *durationFlag < time.Second {
+		log.Fatal("-count, -users, -rate, -targets and -duration must be positive")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+	defer db.Close(context.Background())
+
+	benchLogger := appLogger.WithComponent("bench")
+	repos := repository.NewRepositories(db, cfg)
+	rng := rand.New(rand.NewSource(*seedFlag))
+
+	var out io.Writer = os.Stdout
+	if *outFlag != "" {
+		file, err := os.Create(*outFlag)
+		if err != nil {
+			benchLogger.WithError(err).Fatal("Failed to create output file")
+		}
+		defer file.Close()
+		out = file
+	}
+
+	sample := *sampleFlag
+	if *scenarioFlag != "" {
+		sample = *usersFlag
+	}
+	w, err := loadWorkload(ctx, repos, sample, rng)
+	if err != nil {
+		benchLogger.WithError(err).Fatal("Failed to load workload")
+	}
+
+	if *scenarioFlag != "" {
+		services := service.NewServices(service.Deps{Repos: repos, Config: cfg})
+		tokens, err := signIn(ctx, repos, services.AuthService, w.userIDs, *passwordFlag)
+		if err != nil {
+			benchLogger.WithError(err).Fatal("Failed to sign in users")
+		}
+
+		s := &scenario{
+			BaseURL:    *baseURLFlag,
+			Tokens:     tokens,
+			Categories: w.categoryIDs,
+			Pages:      min((w.products+19)/20, 50),
+			Rate:       *rateFlag,
+			Duration:   *durationFlag,
+		}
+		switch *scenarioFlag {
+		case "k6":
+			err = writeK6(out, s)
+		case "flash-sale":
+			var product *domain.Product
+			product, err = repos.Product.GetByID(ctx, *productFlag)
+			if err == nil && product.FlashSale == nil {
+				err = fmt.Errorf("product %d has no flash sale", *productFlag)
+			}
+			if err == nil {
+				err = writeFlashSaleK6(out, s, flashSale{ProductID: product.ID, Units: product.FlashSale.Remaining})
+			}
+		default:
+			err = writeVegeta(out, s, *targetsFlag, rng)
+		}
+		if err != nil {
+			benchLogger.WithError(err).Fatal("Failed to write load test")
+		}
+
+		benchLogger.WithFields(logger.Fields{
+			"scenario": *scenarioFlag,
+			"users":    len(tokens),
+		}).Info("Load test written")
+		return
+	}
+
+	if *storeFlag == "memory" {
+		start := time.Now()
+		data, err := snapshot(ctx, repos)
+		if err != nil {
+			benchLogger.WithError(err).Fatal("Failed to copy data into memory")
+		}
+		store := memory.NewStore()
+		store.Load(data)
+		repos = store.Repositories()
+
+		benchLogger.WithDuration(time.Since(start)).WithFields(logger.Fields{
+			"products":     len(data.Products),
+			"interactions": len(data.Views) + len(data.Likes) + len(data.Purchases),
+		}).Info("Copied seeded data into memory")
+	}
+
+	benchLogger.WithFields(logger.Fields{
+		"store": *storeFlag,
+		"users": len(w.userIDs),
+	}).Info("Running benchmarks")
+	runBenchmarks(out, benchmarks(repos, w, cfg), *countFlag)
+}
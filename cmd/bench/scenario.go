@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"text/template"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+)
+
+// scenario is the load test written for k6 or vegeta
+type scenario struct {
+	BaseURL    string
+	Tokens     []string
+	Categories []int
+	Pages      int
+	Rate       int
+	Duration   time.Duration
+}
+
+// flashSale is the product a flash sale load test buys from and the units its sale has left
+type flashSale struct {
+	ProductID int
+	Units     int
+}
+
+// signIn logs in the sampled users with the seed password. Access tokens expire after
+// jwt.access_token_duration, so a load test should not run longer.
+func signIn(ctx context.Context, repos *repository.Repository, auth service.AuthService, userIDs []int, password string) ([]string, error) {
+	tokens := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		user, err := repos.User.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("get user %d: %w", userID, err)
+		}
+		token, err := auth.Login(ctx, &domain.LoginRequest{Email: user.Email, Password: password}, domain.ClientInfo{UserAgent: "e-comm-bench"})
+		if err != nil {
+			return nil, fmt.Errorf("log in %s: %w", user.Email, err)
+		}
+		tokens = append(tokens, token.AccessToken)
+	}
+	return tokens, nil
+}
+
+// writeVegeta writes an HTTP-format target list mixing recommendation and listing requests
+// evenly. vegeta replays it in a loop:
+//
+//	vegeta attack -targets=targets.txt -rate=50 -duration=1m | vegeta report
+func writeVegeta(out io.Writer, s *scenario, targets int, rng *rand.Rand) error {
+	for i := 0; i < targets; i++ {
+		var err error
+		if i%2 == 0 {
+			token := s.Tokens[rng.Intn(len(s.Tokens))]
+			_, err = fmt.Fprintf(out, "GET %s/api/v1/profiles/me/recommendations?limit=10\nAuthorization: Bearer %s\n\n", s.BaseURL, token)
+		} else {
+			_, err = fmt.Fprintf(out, "GET %s\n\n", s.listURL(rng))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listURL is a random product listing page, filtered by category half of the time
+func (s *scenario) listURL(rng *rand.Rand) string {
+	if len(s.Categories) > 0 && rng.Intn(2) == 0 {
+		return fmt.Sprintf("%s/api/v1/products?limit=20&category_id=%d", s.BaseURL, s.Categories[rng.Intn(len(s.Categories))])
+	}
+	return fmt.Sprintf("%s/api/v1/products?limit=20&page=%d", s.BaseURL, 1+rng.Intn(s.Pages))
+}
+
+// writeK6 writes a k6 script running both endpoints at a constant arrival rate
+func writeK6(out io.Writer, s *scenario) error {
+	tokens, err := json.Marshal(s.Tokens)
+	if err != nil {
+		return err
+	}
+	categories, err := json.Marshal(s.Categories)
+	if err != nil {
+		return err
+	}
+	baseURL, err := json.Marshal(s.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	return k6Template.Execute(out, map[string]interface{}{
+		"BaseURL":    string(baseURL),
+		"Tokens":     string(tokens),
+		"Categories": string(categories),
+		"Pages":      s.Pages,
+		"Rate":       s.Rate,
+		"Duration":   fmt.Sprintf("%ds", int(s.Duration.Seconds())),
+		"VUs":        s.Rate * 2,
+	})
+}
+
+var k6Template = template.Must(template.New("k6").Parse(`// Generated by cmd/bench; run with: k6 run script.js
+import http from 'k6/http';
+import { check } from 'k6';
+
+const baseURL = __ENV.BASE_URL || {{.BaseURL}};
+const tokens = {{.Tokens}};
+const categories = {{.Categories}};
+const pages = {{.Pages}};
+
+const pick = (items) => items[Math.floor(Math.random() * items.length)];
+
+export const options = {
+  scenarios: {
+    recommendations: {
+      executor: 'constant-arrival-rate',
+      exec: 'recommendations',
+      rate: {{.Rate}},
+      timeUnit: '1s',
+      duration: '{{.Duration}}',
+      preAllocatedVUs: {{.VUs}},
+    },
+    list_products: {
+      executor: 'constant-arrival-rate',
+      exec: 'listProducts',
+      rate: {{.Rate}},
+      timeUnit: '1s',
+      duration: '{{.Duration}}',
+      preAllocatedVUs: {{.VUs}},
+    },
+  },
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    'http_req_duration{scenario:recommendations}': ['p(95)<1000'],
+    'http_req_duration{scenario:list_products}': ['p(95)<200'],
+  },
+};
+
+export function recommendations() {
+  const res = http.get(` + "`${baseURL}/api/v1/profiles/me/recommendations?limit=10`" + `, {
+    headers: { Authorization: ` + "`Bearer ${pick(tokens)}`" + ` },
+    tags: { name: 'GetRecommendations' },
+  });
+  check(res, { 'status is 200': (r) => r.status === 200 });
+}
+
+export function listProducts() {
+  let url = ` + "`${baseURL}/api/v1/products?limit=20&page=${1 + Math.floor(Math.random() * pages)}`" + `;
+  if (categories.length > 0 && Math.random() < 0.5) {
+    url = ` + "`${baseURL}/api/v1/products?limit=20&category_id=${pick(categories)}`" + `;
+  }
+  const res = http.get(url, { tags: { name: 'ListProducts' } });
+  check(res, { 'status is 200': (r) => r.status === 200 });
+}
+`))
+
+// writeFlashSaleK6 writes a k6 script where every user joins the waiting room of the product's
+// flash sale at once, waits to be admitted and buys a unit, retrying while the API asks it to.
+// It fails if more units are sold than the sale had, or if a request fails other than by being
+// sold out or not admitted yet.
+func writeFlashSaleK6(out io.Writer, s *scenario, sale flashSale) error {
+	tokens, err := json.Marshal(s.Tokens)
+	if err != nil {
+		return err
+	}
+	baseURL, err := json.Marshal(s.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	return flashSaleK6Template.Execute(out, map[string]interface{}{
+		"BaseURL":   string(baseURL),
+		"Tokens":    string(tokens),
+		"ProductID": sale.ProductID,
+		"Units":     sale.Units,
+		"VUs":       len(s.Tokens),
+		"Duration":  fmt.Sprintf("%ds", int(s.Duration.Seconds())),
+	})
+}
+
+var flashSaleK6Template = template.Must(template.New("k6-flash-sale").Parse(`// Generated by cmd/bench; run with: k6 run script.js
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { Counter } from 'k6/metrics';
+import exec from 'k6/execution';
+
+const baseURL = __ENV.BASE_URL || {{.BaseURL}};
+const tokens = {{.Tokens}};
+const sale = ` + "`${baseURL}/api/v1/products/{{.ProductID}}/flash-sale`" + `;
+
+const unitsSold = new Counter('flash_sale_units_sold');
+const soldOut = new Counter('flash_sale_sold_out');
+
+// Sold out and not admitted yet are answers, not failures
+http.setResponseCallback(http.expectedStatuses(200, 409, 429));
+
+export const options = {
+  scenarios: {
+    flash_sale: {
+      executor: 'per-vu-iterations',
+      vus: {{.VUs}},
+      iterations: 1,
+      maxDuration: '{{.Duration}}',
+    },
+  },
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    checks: ['rate>0.99'],
+    flash_sale_units_sold: ['count<={{.Units}}'],
+    'http_req_duration{name:PurchaseFlashSale}': ['p(95)<500'],
+  },
+};
+
+export default function () {
+  const params = {
+    headers: {
+      Authorization: ` + "`Bearer ${tokens[exec.vu.idInTest - 1]}`" + `,
+      'Content-Type': 'application/json',
+    },
+  };
+
+  const joined = http.post(` + "`${sale}/queue`" + `, null, Object.assign({ tags: { name: 'JoinFlashSaleQueue' } }, params));
+  check(joined, { 'joined or sold out': (r) => r.status === 200 || r.status === 409 });
+  if (joined.status !== 200) {
+    if (joined.status === 409) {
+      soldOut.add(1);
+    }
+    return;
+  }
+  const ticket = joined.json();
+
+  const body = JSON.stringify({ quantity: 1, token: ticket.token });
+  sleep(Math.max(0, (Date.parse(ticket.admit_at) - Date.now()) / 1000));
+  for (;;) {
+    const res = http.post(` + "`${sale}/purchase`" + `, body, Object.assign({ tags: { name: 'PurchaseFlashSale' } }, params));
+    if (res.status === 429) {
+      sleep(Number(res.headers['Retry-After'] || 1));
+      continue;
+    }
+    check(res, { 'purchased or sold out': (r) => r.status === 200 || r.status === 409 });
+    if (res.status === 200) {
+      unitsSold.add(1);
+    } else if (res.status === 409) {
+      soldOut.add(1);
+    }
+    return;
+  }
+}
+`))
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+const (
+	// dedupBatchSize is how many duplicate interactions are deleted per query
+	dedupBatchSize = 1000
+
+	// errCodeIndexNotFound is returned for dropping an index that does not exist
+	errCodeIndexNotFound = 27
+)
+
+// interactionTimeFields are the collections of interaction events and the field with their time
+var interactionTimeFields = map[string]string{
+	"user_product_views":     "viewed_at",
+	"user_product_purchases": "purchased_at",
+}
+
+// oldInteractionIndexes are the indexes, without and with tenancy, that the migration drops: the
+// user_id and product_id index on views, which their unique index replaces
+var oldInteractionIndexes = map[string][]string{
+	"user_product_views": {"user_id_1_product_id_1", "tenant_id_1_user_id_1_product_id_1"},
+}
+
+// migrateInteractionIndexes prepares views for their unique index, then builds the missing
+// indexes. Views and purchases without a time get the creation time of their ObjectID, and of
+// views recorded more than once, at the same time by the same user or guest, the first is kept.
+// Purchases are never deleted: two at the same time are two purchases. Run it before starting a
+// version that expects the unique index; it can be run again.
+func migrateInteractionIndexes(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("migrate-interaction-indexes", flag.ExitOnError)
+	dryRunFlag := flags.Bool("dry-run", false, "count the events to backfill and delete without changing them")
+	flags.Parse(args)
+
+	_, appLogger, db := connect(ctx)
+	defer appLogger.Close()
+	defer db.Close(context.Background())
+	migrateLogger := appLogger.WithComponent("migrate-interaction-indexes")
+
+	start := time.Now()
+	backfilled := make(map[string]int64)
+	deleted := make(map[string]int64)
+	for name, timeField := range interactionTimeFields {
+		collection := db.Database.Collection(name)
+		fields := logger.Fields{"collection": name}
+
+		count, err := backfillInteractionTimes(ctx, collection, timeField, *dryRunFlag)
+		if err != nil {
+			migrateLogger.WithError(err).WithFields(fields).Fatal("Failed to backfill event times")
+		}
+		backfilled[name] = count
+	}
+
+	views := db.Database.Collection("user_product_views")
+	count, err := deleteDuplicateInteractions(ctx, views, interactionTimeFields["user_product_views"], *dryRunFlag)
+	if err != nil {
+		migrateLogger.WithError(err).WithFields(logger.Fields{"collection": "user_product_views"}).Fatal("Failed to delete duplicate views")
+	}
+	deleted["user_product_views"] = count
+
+	fields := logger.Fields{"backfilled": backfilled, "duplicates": deleted, "dry_run": *dryRunFlag}
+	if *dryRunFlag {
+		migrateLogger.WithDuration(time.Since(start)).WithFields(fields).Info("Interaction index dry run finished")
+		return
+	}
+
+	var dropped []string
+	for name, indexes := range oldInteractionIndexes {
+		collection := db.Database.Collection(name)
+		for _, index := range indexes {
+			if _, err := collection.Indexes().DropOne(ctx, index); err != nil {
+				if isIndexNotFound(err) {
+					continue
+				}
+				migrateLogger.WithError(err).WithFields(logger.Fields{"collection": name, "index": index}).Fatal("Failed to drop an old interaction index")
+			}
+			dropped = append(dropped, name+"."+index)
+		}
+	}
+	fields["dropped_indexes"] = dropped
+
+	built, err := db.BuildMissingIndexes(ctx)
+	if err != nil {
+		migrateLogger.WithError(err).WithFields(logger.Fields{"built": built}).Fatal("Failed to build indexes")
+	}
+	fields["built_indexes"] = built
+
+	migrateLogger.WithDuration(time.Since(start)).WithFields(fields).Info("Interaction index migration finished")
+}
+
+// backfillInteractionTimes sets the time of the events without one to the creation time of
+// their ObjectID, returning how many there are
+func backfillInteractionTimes(ctx context.Context, collection *mongo.Collection, timeField string, dryRun bool) (int64, error) {
+	filter := bson.M{timeField: nil, "_id": bson.M{"$type": "objectId"}}
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{timeField: bson.M{"$toDate": "$_id"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// deleteDuplicateInteractions deletes all but the first of each group of views with the same
+// tenant, user, guest, product and time, returning how many there are
+func deleteDuplicateInteractions(ctx context.Context, collection *mongo.Collection, timeField string, dryRun bool) (int64, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"tenant_id":    "$tenant_id",
+				"user_id":      "$user_id",
+				"anonymous_id": "$anonymous_id",
+				"product_id":   "$product_id",
+				"at":           "$" + timeField,
+			},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var deleted int64
+	batch := make(bson.A, 0, dedupBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if dryRun {
+			deleted += int64(len(batch))
+		} else {
+			result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": batch}})
+			if err != nil {
+				return err
+			}
+			deleted += result.DeletedCount
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs bson.A `bson:"ids"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return deleted, err
+		}
+		// The first event recorded stays
+		for _, id := range group.IDs[1:] {
+			batch = append(batch, id)
+			if len(batch) == dedupBatchSize {
+				if err := flush(); err != nil {
+					return deleted, err
+				}
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// isIndexNotFound reports whether dropping an index failed because there is none by its name
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == errCodeIndexNotFound
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/s3"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+const usage = `usage: dbtool <command> [flags]
+
+commands:
+  backup   dump collections to a gzip-compressed NDJSON file
+  restore  insert the documents of a backup
+  migrate-money  convert floating point amounts to money in minor units
+  migrate-purchase-snapshots  copy product names, images and categories onto past purchases
+  migrate-interaction-indexes  deduplicate views and build the interaction indexes
+
+run "dbtool <command> -h" for the flags of a command`
+
+// Backs up and restores the database as gzip-compressed NDJSON, optionally through S3, and
+// migrates stored data.
+// Example: go run ./cmd/dbtool backup -s3
+// Example: go run ./cmd/dbtool restore -in backups/backup-20250101T120000Z.ndjson.gz -drop
+// Example: go run ./cmd/dbtool migrate-money
+// Example: go run ./cmd/dbtool migrate-purchase-snapshots
+// Example: go run ./cmd/dbtool migrate-interaction-indexes -dry-run
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	switch os.Args[1] {
+	case "backup":
+		backup(ctx, os.Args[2:])
+	case "restore":
+		restore(ctx, os.Args[2:])
+	case "migrate-money":
+		migrateMoney(ctx, os.Args[2:])
+	case "migrate-purchase-snapshots":
+		migratePurchaseSnapshots(ctx, os.Args[2:])
+	case "migrate-interaction-indexes":
+		migrateInteractionIndexes(ctx, os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q\n%s", os.Args[1], usage)
+	}
+}
+
+func backup(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	outFlag := flags.String("out", "", "output file; a timestamped file in backup.dir by default")
+	collectionsFlag := flags.String("collections", "", "comma-separated collections; backup.collections by default")
+	s3Flag := flags.Bool("s3", false, "upload the backup to the configured S3 bucket")
+	flags.Parse(args)
+
+	cfg, appLogger, db := connect(ctx)
+	defer appLogger.Close()
+	defer db.Close(context.Background())
+	backupLogger := appLogger.WithComponent("backup")
+
+	storage := s3.New(&cfg.Backup.S3)
+	if *s3Flag && storage == nil {
+		backupLogger.Fatal("No S3 bucket configured")
+	}
+
+	start := time.Now()
+	out := *outFlag
+	if out == "" {
+		out = filepath.Join(cfg.Backup.Dir, domain.BackupName(start))
+	}
+	collections := cfg.Backup.Collections
+	if *collectionsFlag != "" {
+		collections = splitList(*collectionsFlag)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		backupLogger.WithError(err).Fatal("Failed to create backup directory")
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		backupLogger.WithError(err).Fatal("Failed to create backup file")
+	}
+	defer file.Close()
+
+	counts, err := db.Backup(ctx, file, collections)
+	if err != nil {
+		backupLogger.WithError(err).Fatal("Failed to back up database")
+	}
+
+	fields := logger.Fields{"file": out, "collections": counts}
+	if *s3Flag {
+		size, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			backupLogger.WithError(err).Fatal("Failed to measure backup file")
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			backupLogger.WithError(err).Fatal("Failed to rewind backup file")
+		}
+
+		key := storage.Key(filepath.Base(out))
+		if err := storage.Put(ctx, key, file, size); err != nil {
+			backupLogger.WithError(err).Fatal("Failed to upload backup")
+		}
+		fields["s3_key"] = key
+	}
+
+	backupLogger.WithDuration(time.Since(start)).WithFields(fields).Info("Backup finished")
+}
+
+func restore(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	inFlag := flags.String("in", "", "backup file to restore")
+	s3Flag := flags.String("s3", "", "key of a backup in the configured S3 bucket to restore instead of a file")
+	collectionsFlag := flags.String("collections", "", "comma-separated collections to restore; all in the backup by default")
+	dropFlag := flags.Bool("drop", false, "drop each collection before restoring it")
+	flags.Parse(args)
+
+	if (*inFlag == "") == (*s3Flag == "") {
+		log.Fatal("exactly one of -in and -s3 is required")
+	}
+
+	cfg, appLogger, db := connect(ctx)
+	defer appLogger.Close()
+	defer db.Close(context.Background())
+	restoreLogger := appLogger.WithComponent("restore")
+
+	var in io.ReadCloser
+	var err error
+	if *s3Flag != "" {
+		storage := s3.New(&cfg.Backup.S3)
+		if storage == nil {
+			restoreLogger.Fatal("No S3 bucket configured")
+		}
+		in, err = storage.Get(ctx, *s3Flag)
+	} else {
+		in, err = os.Open(*inFlag)
+	}
+	if err != nil {
+		restoreLogger.WithError(err).Fatal("Failed to open backup")
+	}
+	defer in.Close()
+
+	start := time.Now()
+	opts := mongodb.RestoreOptions{Drop: *dropFlag}
+	if *collectionsFlag != "" {
+		opts.Collections = splitList(*collectionsFlag)
+	}
+
+	counts, err := db.Restore(ctx, in, opts)
+	if err != nil {
+		restoreLogger.WithError(err).WithFields(logger.Fields{"collections": counts}).Fatal("Failed to restore backup")
+	}
+
+	// Dropped collections lost their indexes
+	built, err := db.BuildMissingIndexes(ctx)
+	if err != nil {
+		restoreLogger.WithError(err).Fatal("Failed to build missing indexes")
+	}
+
+	restoreLogger.WithDuration(time.Since(start)).WithFields(logger.Fields{
+		"collections": counts,
+		"indexes":     built,
+	}).Info("Restore finished")
+}
+
+// connect loads the config and connects to the database; the caller closes both
+func connect(ctx context.Context) (*config.Config, *logger.Logger, *mongodb.MongoDB) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// Whole collections are read and written, so the request-sized timeouts do not apply, and
+	// a restore builds the indexes after inserting, which is faster
+	cfg.Mongo.QueryTimeout = -1
+	cfg.Mongo.AggregateTimeout = -1
+	cfg.Mongo.SkipIndexCreation = true
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+
+	return cfg, appLogger, db
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// moneyFields are the fields holding money, by collection. Before money was kept in minor units
+// they were floating point amounts of the storefront currency.
+var moneyFields = map[string][]string{
+	"products":               {"price", "previous_price", "cost_price"},
+	"user_product_purchases": {"price_at_purchase"},
+	"subscription_plans":     {"price"},
+	"subscriptions":          {"price"},
+	"bundles":                {"price"},
+	"abandoned_carts":        {"value", "recovered_value"},
+	"risk_reviews":           {"amount"},
+}
+
+// moneyArrayFields are the money fields of the documents of an array, by collection and array
+var moneyArrayFields = map[string]map[string]string{
+	"risk_reviews": {"lines": "price"},
+}
+
+// migrateMoney converts the floating point amounts of every tenant, or of the whole database
+// without tenancy, to money documents in its currency. Converted fields are left alone, so it
+// can be run again.
+func migrateMoney(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("migrate-money", flag.ExitOnError)
+	flags.Parse(args)
+
+	cfg, appLogger, db := connect(ctx)
+	defer appLogger.Close()
+	defer db.Close(context.Background())
+	migrateLogger := appLogger.WithComponent("migrate-money")
+
+	scopes := map[string]bson.M{cfg.Payment.Currency: {}}
+	if cfg.Tenancy.Enabled {
+		scopes = make(map[string]bson.M)
+		for _, tenant := range cfg.Tenancy.Tenants {
+			ids, _ := scopes[tenant.Currency][mongodb.TenantField].(bson.M)
+			if ids == nil {
+				ids = bson.M{"$in": bson.A{}}
+				scopes[tenant.Currency] = bson.M{mongodb.TenantField: ids}
+			}
+			ids["$in"] = append(ids["$in"].(bson.A), tenant.ID)
+		}
+	}
+
+	start := time.Now()
+	converted := make(map[string]int64)
+	for currency, scope := range scopes {
+		for name, fields := range moneyFields {
+			collection := db.Database.Collection(name)
+			for _, field := range fields {
+				count, err := convertMoney(ctx, collection, scope, field, currency)
+				if err != nil {
+					migrateLogger.WithError(err).WithFields(logger.Fields{"collection": name, "field": field}).Fatal("Failed to convert amounts")
+				}
+				converted[name+"."+field] += count
+			}
+			for array, field := range moneyArrayFields[name] {
+				count, err := convertMoneyArray(ctx, collection, scope, array, field, currency)
+				if err != nil {
+					migrateLogger.WithError(err).WithFields(logger.Fields{"collection": name, "field": array + "." + field}).Fatal("Failed to convert amounts")
+				}
+				converted[name+"."+array+"."+field] += count
+			}
+		}
+	}
+
+	// The price index is on price.amount now; the application builds it on its next start
+	dropped, err := dropIndexesOn(ctx, db.Database.Collection("products"), "price")
+	if err != nil {
+		migrateLogger.WithError(err).Fatal("Failed to drop the old price index")
+	}
+
+	migrateLogger.WithDuration(time.Since(start)).WithFields(logger.Fields{
+		"documents":       converted,
+		"dropped_indexes": dropped,
+	}).Info("Money migration finished")
+}
+
+// convertMoney converts the field of the documents in scope where it is still a number,
+// returning the number of documents changed
+func convertMoney(ctx context.Context, collection *mongo.Collection, scope bson.M, field, currency string) (int64, error) {
+	filter := bson.M{field: bson.M{"$type": "number"}}
+	for key, value := range scope {
+		filter[key] = value
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{field: moneyExpr("$"+field, currency)}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// convertMoneyArray converts the field of each document of the array
+func convertMoneyArray(ctx context.Context, collection *mongo.Collection, scope bson.M, array, field, currency string) (int64, error) {
+	filter := bson.M{array + "." + field: bson.M{"$type": "number"}}
+	for key, value := range scope {
+		filter[key] = value
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{array: bson.M{"$map": bson.M{
+			"input": "$" + array,
+			"in": bson.M{"$mergeObjects": bson.A{
+				"$$this",
+				bson.M{field: moneyExpr("$$this."+field, currency)},
+			}},
+		}}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// moneyExpr is the money document of the amount at path if it is a number, rounded to the minor
+// unit of the currency as domain.MoneyFromFloat does, and the value at path otherwise
+func moneyExpr(path, currency string) bson.M {
+	scale := math.Pow10(domain.CurrencyExponent(currency))
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isNumber": path},
+		bson.M{
+			"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{path, scale}}, 0}}},
+			"currency": currency,
+		},
+		path,
+	}}
+}
+
+// dropIndexesOn drops the indexes of the collection with a key on field, returning their names
+func dropIndexesOn(ctx context.Context, collection *mongo.Collection, field string) ([]string, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []struct {
+		Name string `bson:"name"`
+		Key  bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+
+	var dropped []string
+	for _, index := range indexes {
+		for _, key := range index.Key {
+			if key.Key != field {
+				continue
+			}
+			if _, err := collection.Indexes().DropOne(ctx, index.Name); err != nil {
+				return dropped, err
+			}
+			dropped = append(dropped, index.Name)
+			break
+		}
+	}
+	return dropped, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// migratePurchaseSnapshots copies the name, image and category of the purchased product onto
+// the purchases recorded before they were kept. They get the product as it is now, the closest
+// there is to how it was bought; purchases of deleted products are left without. Purchases
+// that have a snapshot are left alone, so it can be run again.
+func migratePurchaseSnapshots(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("migrate-purchase-snapshots", flag.ExitOnError)
+	flags.Parse(args)
+
+	_, appLogger, db := connect(ctx)
+	defer appLogger.Close()
+	defer db.Close(context.Background())
+	migrateLogger := appLogger.WithComponent("migrate-purchase-snapshots")
+
+	purchases := db.Database.Collection("user_product_purchases")
+	missing := bson.M{"product_name": bson.M{"$exists": false}}
+
+	before, err := purchases.CountDocuments(ctx, missing)
+	if err != nil {
+		migrateLogger.WithError(err).Fatal("Failed to count purchases")
+	}
+
+	// $merge into the collection being read needs MongoDB 4.4 or later
+	start := time.Now()
+	cursor, err := purchases.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: missing}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "product_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "categories",
+			"localField":   "product.category_id",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"product_name":      "$product.name",
+			"product_image_url": "$product.image_url",
+			"category_id":       "$product.category_id",
+			"category_name":     bson.M{"$arrayElemAt": bson.A{"$category.name", 0}},
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           "user_product_purchases",
+			"on":             "_id",
+			"whenMatched":    "merge",
+			"whenNotMatched": "discard",
+		}}},
+	})
+	if err != nil {
+		migrateLogger.WithError(err).Fatal("Failed to snapshot purchased products")
+	}
+	cursor.Close(ctx)
+
+	after, err := purchases.CountDocuments(ctx, missing)
+	if err != nil {
+		migrateLogger.WithError(err).Fatal("Failed to count purchases")
+	}
+
+	migrateLogger.WithDuration(time.Since(start)).WithFields(logger.Fields{
+		"snapshotted":      before - after,
+		"deleted_products": after,
+	}).Info("Purchase snapshot migration finished")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// Exports interaction events as NDJSON for external ML pipelines.
+// Example: go run cmd/export/main.go -from 2025-01-01T00:00:00Z -types view,purchase -out events.ndjson
+func main() {
+	fromFlag := flag.String("from", "", "start of the time range, inclusive (RFC3339)")
+	toFlag := flag.String("to", "", "end of the time range, exclusive (RFC3339)")
+	typesFlag := flag.String("types", "", "comma-separated event types (view,like,purchase); all by default")
+	outFlag := flag.String("out", "", "output file; stdout by default")
+	batchFlag := flag.Int("batch", 5000, "number of events fetched per query")
+	cursorFlag := flag.String("cursor", "", "resume from a cursor printed by a previous run")
+	tenantFlag := flag.String("tenant", "", "export only this tenant's events when tenancy is enabled; all tenants by default")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	filter := domain.InteractionExportFilter{
+		Limit:  *batchFlag,
+		Cursor: *cursorFlag,
+	}
+	if *fromFlag != "" {
+		from, err := time.Parse(time.RFC3339, *fromFlag)
+		if err != nil {
+			log.Fatalf("invalid -from: %v", err)
+		}
+		filter.From = &from
+	}
+	if *toFlag != "" {
+		to, err := time.Parse(time.RFC3339, *toFlag)
+		if err != nil {
+			log.Fatalf("invalid -to: %v", err)
+		}
+		filter.To = &to
+	}
+	if *typesFlag != "" {
+		for _, eventType := range strings.Split(*typesFlag, ",") {
+			filter.EventTypes = append(filter.EventTypes, strings.TrimSpace(eventType))
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// Offline jobs scan whole collections, so the request-sized timeouts do not apply
+	cfg.Mongo.QueryTimeout = -1
+	cfg.Mongo.AggregateTimeout = -1
+
+	if *tenantFlag != "" {
+		exportTenant := cfg.Tenancy.Tenant(*tenantFlag)
+		if !cfg.Tenancy.Enabled || exportTenant == nil {
+			log.Fatalf("unknown tenant %q", *tenantFlag)
+		}
+		ctx = tenant.NewContext(ctx, exportTenant)
+	}
+
+	// Keep stdout clean for the exported data
+	if *outFlag == "" && cfg.Logger.Output == "stdout" {
+		cfg.Logger.Output = "stderr"
+	}
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+	defer db.Close(context.Background())
+
+	services := service.NewServices(service.Deps{
+		Repos:  repository.NewRepositories(db, cfg),
+		Config: cfg,
+	})
+
+	out := os.Stdout
+	if *outFlag != "" {
+		out, err = os.Create(*outFlag)
+		if err != nil {
+			appLogger.WithComponent("export").WithError(err).Fatal("Failed to create output file")
+		}
+		defer out.Close()
+	}
+
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+	encoder := json.NewEncoder(writer)
+
+	start := time.Now()
+	total := 0
+	for {
+		batch, err := services.InteractionService.ExportEvents(ctx, filter)
+		if err != nil {
+			appLogger.WithComponent("export").
+				WithError(err).
+				WithFields(logger.Fields{"cursor": filter.Cursor}).
+				Fatal("Failed to export events")
+		}
+
+		for _, event := range batch.Events {
+			if err := encoder.Encode(event); err != nil {
+				appLogger.WithComponent("export").WithError(err).Fatal("Failed to write event")
+			}
+		}
+		total += batch.Count
+
+		if batch.NextCursor == "" {
+			break
+		}
+		filter.Cursor = batch.NextCursor
+	}
+
+	appLogger.WithComponent("export").
+		WithDuration(time.Since(start)).
+		WithFields(logger.Fields{"events": total}).
+		Info("Export finished")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Lists the indexes of each collection with their sizes and the expected ones that are missing,
+// and optionally builds the missing ones.
+// Example: go run cmd/indexes/main.go -build
+func main() {
+	buildFlag := flag.Bool("build", false, "build the missing expected indexes")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// Report the indexes as they are rather than creating them on connect; builds may take long
+	cfg.Mongo.SkipIndexCreation = true
+	cfg.Mongo.QueryTimeout = -1
+	cfg.Mongo.AggregateTimeout = -1
+
+	// Keep stdout for the report
+	if cfg.Logger.Output == "stdout" {
+		cfg.Logger.Output = "stderr"
+	}
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+	defer db.Close(context.Background())
+
+	indexRepo := repository.NewIndexRepository(db)
+	indexLogger := appLogger.WithComponent("indexes")
+
+	if *buildFlag {
+		built, err := indexRepo.BuildMissing(ctx)
+		if err != nil {
+			indexLogger.WithError(err).WithFields(logger.Fields{"built": built}).Fatal("Failed to build missing indexes")
+		}
+		indexLogger.WithFields(logger.Fields{"built": built}).Info("Built missing indexes")
+	}
+
+	collections, err := indexRepo.List(ctx)
+	if err != nil {
+		indexLogger.WithError(err).Fatal("Failed to list indexes")
+	}
+
+	if err := printReport(collections); err != nil {
+		indexLogger.WithError(err).Fatal("Failed to print report")
+	}
+
+	for _, collection := range collections {
+		if len(collection.Missing) > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// printReport writes a row per index, followed by the missing ones
+func printReport(collections []domain.CollectionIndexes) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COLLECTION\tINDEX\tKEYS\tSIZE\tSTATUS")
+
+	var total int64
+	for _, collection := range collections {
+		unexpected := make(map[string]bool, len(collection.Unexpected))
+		for _, name := range collection.Unexpected {
+			unexpected[name] = true
+		}
+
+		for _, index := range collection.Indexes {
+			keys := make([]string, len(index.Keys))
+			for i, key := range index.Keys {
+				keys[i] = key.Field + ":" + key.Type
+			}
+			status := "ok"
+			if unexpected[index.Name] {
+				status = "unexpected"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", collection.Collection, index.Name, strings.Join(keys, ","), formatSize(index.Size), status)
+		}
+		for _, name := range collection.Missing {
+			fmt.Fprintf(w, "%s\t%s\t\t\tmissing\n", collection.Collection, name)
+		}
+		total += collection.TotalSize
+	}
+
+	fmt.Fprintf(w, "\t\t\t%s\ttotal\n", formatSize(total))
+	return w.Flush()
+}
+
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
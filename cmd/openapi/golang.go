@@ -0,0 +1,404 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// generateGo writes a Go client of a v2 document: a type per schema and a Client method per
+// operation returning the envelope's data and meta
+func generateGo(doc *document, pkg string) ([]byte, error) {
+	names := typeNames(doc)
+	g := &goGenerator{names: names}
+
+	var types strings.Builder
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		g.declaration(&types, name, names[name], doc.Components.Schemas[name])
+	}
+
+	var methods strings.Builder
+	for _, op := range sdkOperations(doc) {
+		g.operation(&methods, op)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by cmd/openapi from %s %s. DO NOT EDIT.\n\n", doc.Info.Title, doc.Info.Version)
+	fmt.Fprintf(&b, "// Package %s is a client of the v2 API.\npackage %s\n\n", pkg, pkg)
+	b.WriteString("import (\n")
+	if g.usesBytes {
+		b.WriteString("\t\"bytes\"\n")
+	}
+	b.WriteString("\t\"context\"\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n\t\"net/url\"\n\t\"strings\"\n")
+	if g.usesTime {
+		b.WriteString("\t\"time\"\n")
+	}
+	b.WriteString(")\n\n")
+	meta, apiError := names[metaSchema], names[apiErrorSchema]
+	fmt.Fprintf(&b, goRuntime, meta, apiError, meta, apiError, meta)
+	b.WriteString(types.String())
+	b.WriteString(methods.String())
+
+	return format.Source([]byte(b.String()))
+}
+
+type goGenerator struct {
+	names     map[string]string
+	usesTime  bool // a field is a time.Time
+	usesBytes bool // a request has a JSON body
+}
+
+func (g *goGenerator) declaration(b *strings.Builder, component, name string, s *schema) {
+	goComment(b, "", append([]string{name + " is the " + component + " schema."}, commentLines(s.Description)...))
+	fmt.Fprintf(b, "type %s %s\n\n", name, g.typeOf(s))
+}
+
+// typeOf returns the Go type of a schema
+func (g *goGenerator) typeOf(s *schema) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		return g.names[refName(s.Ref)]
+	}
+	if len(s.AllOf) > 0 {
+		return g.allOf(s)
+	}
+
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			g.usesTime = true
+			return "time.Time"
+		}
+		if s.ContentMediaType != "" {
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.typeOf(s.Items)
+	case "object":
+		if len(s.Properties) > 0 {
+			return g.structOf(s)
+		}
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.typeOf(s.AdditionalProperties)
+		}
+		return "map[string]any"
+	default:
+		return "any"
+	}
+}
+
+// allOf embeds the referenced parts and adds the fields of the inline ones
+func (g *goGenerator) allOf(s *schema) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	merged := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for _, part := range s.AllOf {
+		if part.Ref != "" {
+			b.WriteString(g.names[refName(part.Ref)] + "\n")
+			continue
+		}
+		for name, property := range part.Properties {
+			merged.Properties[name] = property
+		}
+		merged.Required = append(merged.Required, part.Required...)
+	}
+	g.fields(&b, merged)
+	b.WriteString("}")
+	return b.String()
+}
+
+func (g *goGenerator) structOf(s *schema) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	g.fields(&b, s)
+	b.WriteString("}")
+	return b.String()
+}
+
+// fields writes the struct fields of the properties. Optional fields are pointers, so a zero
+// value can be told from a missing one, except for slices, maps and any, which are nil anyway.
+func (g *goGenerator) fields(b *strings.Builder, s *schema) {
+	used := make(map[string]bool)
+	for _, property := range sortedKeys(s.Properties) {
+		schema := s.Properties[property]
+
+		name := pascalCase(property)
+		for n := 2; used[name]; n++ {
+			name = pascalCase(property) + strconv.Itoa(n)
+		}
+		used[name] = true
+
+		required := false
+		for _, field := range s.Required {
+			required = required || field == property
+		}
+
+		fieldType := g.typeOf(schema)
+		tag := property
+		if !required {
+			tag += ",omitempty"
+			if !strings.HasPrefix(fieldType, "[]") && !strings.HasPrefix(fieldType, "map[") && fieldType != "any" {
+				fieldType = "*" + fieldType
+			}
+		}
+
+		goComment(b, "", commentLines(schema.Description))
+		fmt.Fprintf(b, "%s %s `json:%q`\n", name, fieldType, tag)
+	}
+}
+
+func (g *goGenerator) operation(b *strings.Builder, op *sdkOperation) {
+	name := pascalCase(op.Name)
+	if len(op.Name) > 0 {
+		name = strings.ToUpper(op.Name[:1]) + op.Name[1:]
+	}
+
+	params := []string{"ctx context.Context"}
+	for _, param := range op.PathParams {
+		params = append(params, goParamName(param.Name)+" "+g.typeOf(param.Schema))
+	}
+	body := "nil"
+	contentType := `""`
+	if op.Body != nil {
+		if op.BodyType == "application/json" {
+			params = append(params, "body "+g.typeOf(op.Body))
+		} else {
+			params = append(params, "body io.Reader", "contentType string")
+			body, contentType = "body", "contentType"
+		}
+	}
+
+	var queryType string
+	if len(op.QueryParams) > 0 {
+		queryType = name + "Params"
+		params = append(params, "params *"+queryType)
+
+		// Every parameter is optional, so the server applies its defaults to the ones left out
+		query := queryObject(op.QueryParams)
+		query.Required = nil
+		goComment(b, "", []string{queryType + " are the query parameters of " + name + "."})
+		fmt.Fprintf(b, "type %s %s\n\n", queryType, g.typeOf(query))
+	}
+
+	result := "error"
+	switch {
+	case op.Raw:
+		result = "(*http.Response, error)"
+	case op.Result != nil:
+		result = "(*Response[" + g.typeOf(op.Result) + "], error)"
+	}
+
+	goComment(b, "", append([]string{name + " calls " + op.Method + " " + op.Path + "."}, commentLines(op.Summary, op.Description)...))
+	fmt.Fprintf(b, "func (c *Client) %s(%s) %s {\n", name, strings.Join(params, ", "), result)
+
+	failure := "return nil, err"
+	if result == "error" {
+		failure = "return err"
+	}
+
+	path := strconv.Quote(op.Path)
+	for _, param := range op.PathParams {
+		path = strings.ReplaceAll(path, "{"+param.Name+"}", `" + url.PathEscape(fmt.Sprint(`+goParamName(param.Name)+`)) + "`)
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, `"" + `), ` + ""`)
+
+	b.WriteString("query := url.Values{}\n")
+	if queryType != "" {
+		b.WriteString("if params != nil {\n")
+		used := make(map[string]bool)
+		for _, param := range sortedQueryParams(op.QueryParams) {
+			field := pascalCase(param.Name)
+			for n := 2; used[field]; n++ {
+				field = pascalCase(param.Name) + strconv.Itoa(n)
+			}
+			used[field] = true
+
+			if param.Schema.Type == "array" {
+				fmt.Fprintf(b, "if len(params.%s) > 0 {\nquery.Set(%q, joinValues(params.%s))\n}\n", field, param.Name, field)
+				continue
+			}
+			fmt.Fprintf(b, "if params.%s != nil {\nquery.Set(%q, fmt.Sprint(*params.%s))\n}\n", field, param.Name, field)
+		}
+		b.WriteString("}\n")
+	}
+
+	if op.Body != nil && op.BodyType == "application/json" {
+		g.usesBytes = true
+		fmt.Fprintf(b, "data, err := json.Marshal(body)\nif err != nil {\n%s\n}\n", failure)
+		body, contentType = "bytes.NewReader(data)", `"application/json"`
+	}
+
+	fmt.Fprintf(b, "resp, err := c.send(ctx, %q, %s, query, %s, %s)\nif err != nil {\n%s\n}\n", op.Method, path, body, contentType, failure)
+	switch {
+	case op.Raw:
+		b.WriteString("return resp, nil\n")
+	case op.Result != nil:
+		fmt.Fprintf(b, "return decode[%s](resp)\n", g.typeOf(op.Result))
+	default:
+		b.WriteString("return resp.Body.Close()\n")
+	}
+	b.WriteString("}\n\n")
+}
+
+// sortedQueryParams orders query parameters by name, the order of the fields of their struct
+func sortedQueryParams(params []*parameter) []*parameter {
+	byName := make(map[string]*parameter, len(params))
+	for _, param := range params {
+		byName[param.Name] = param
+	}
+	sorted := make([]*parameter, 0, len(params))
+	for _, name := range sortedKeys(byName) {
+		sorted = append(sorted, byName[name])
+	}
+	return sorted
+}
+
+// goParamName makes a path parameter name a Go identifier, e.g. "product_id" becomes
+// "productId", without clashing with keywords or the names the methods use
+func goParamName(name string) string {
+	name = camelCase(name)
+	if token.IsKeyword(name) || goLocalNames[name] {
+		name += "Param"
+	}
+	return name
+}
+
+// goLocalNames are the parameters and variables of the generated methods
+var goLocalNames = map[string]bool{
+	"ctx": true, "body": true, "contentType": true, "params": true,
+	"query": true, "data": true, "resp": true, "err": true, "c": true,
+}
+
+func goComment(b *strings.Builder, indent string, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// goRuntime is the start of the client, formatted with the meta and error type names
+const goRuntime = `// Client calls the v2 API
+type Client struct {
+	BaseURL    string       // e.g. "https://api.example.com/api/v2"
+	HTTPClient *http.Client // http.DefaultClient if nil
+	Token      string       // access token sent as a bearer token, if set
+	Header     http.Header  // sent with every request, e.g. Accept-Language
+}
+
+// New returns a client of the API at baseURL
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Response is the body of a successful response
+type Response[T any] struct {
+	Data T  ` + "`json:\"data\"`" + `
+	Meta %s ` + "`json:\"meta\"`" + `
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Errors     []%s
+	Meta       %s
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Message
+	}
+	if len(messages) == 0 {
+		return fmt.Sprintf("api: HTTP %%d", e.StatusCode)
+	}
+	return fmt.Sprintf("api: HTTP %%d: %%s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+// send makes a request and returns the response, or an *Error for an error status
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	data, err := io.ReadAll(resp.Body)
+	if err == nil {
+		_ = json.Unmarshal(data, apiErr)
+	}
+	return nil, apiErr
+}
+
+// UnmarshalJSON reads the errors and meta of an error envelope
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var envelope struct {
+		Errors []%s ` + "`json:\"errors\"`" + `
+		Meta   %s   ` + "`json:\"meta\"`" + `
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	e.Errors, e.Meta = envelope.Errors, envelope.Meta
+	return nil
+}
+
+func decode[T any](resp *http.Response) (*Response[T], error) {
+	defer resp.Body.Close()
+
+	var out Response[T]
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %%w", err)
+	}
+	return &out, nil
+}
+
+// joinValues writes an array query parameter as comma-separated values
+func joinValues[T any](values []T) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, ",")
+}
+
+`
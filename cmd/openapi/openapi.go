@@ -0,0 +1,578 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+)
+
+// document is an OpenAPI 3.1 document, with just the parts swag produces
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       info                             `json:"info"`
+	Servers    []server                         `json:"servers"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type server struct {
+	URL string `json:"url"`
+}
+
+type components struct {
+	Schemas         map[string]*schema         `json:"schemas"`
+	SecuritySchemes map[string]*securityScheme `json:"securitySchemes,omitempty"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+// schema is a JSON Schema (2020-12) as used by OpenAPI 3.1
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentMediaType     string             `json:"contentMediaType,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*schema          `json:"allOf,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int64             `json:"minLength,omitempty"`
+	MaxLength            *int64             `json:"maxLength,omitempty"`
+	Examples             []any              `json:"examples,omitempty"`
+}
+
+// Component names of the v2 envelope, reflected from the dto types
+var (
+	envelopeSchema = schemaName(reflect.TypeFor[dto.Envelope]())
+	metaSchema     = schemaName(reflect.TypeFor[dto.Meta]())
+	apiErrorSchema = schemaName(reflect.TypeFor[dto.APIError]())
+)
+
+// methods are the operations of a path item in the order they are listed
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// convert turns the swag spec into an OpenAPI 3.1 document of an API version. The paths are
+// limited to routes unless it is nil. In v2 documents responses are wrapped in the envelope.
+func convert(swagger *spec.Swagger, version string, routes map[string]bool) *document {
+	doc := &document{
+		OpenAPI: "3.1.0",
+		Servers: []server{{URL: "/api/" + version}},
+		Paths:   make(map[string]map[string]*operation),
+		Components: components{
+			Schemas:         make(map[string]*schema),
+			SecuritySchemes: make(map[string]*securityScheme),
+		},
+	}
+	if swagger.Info != nil {
+		doc.Info = info{Title: swagger.Info.Title, Description: swagger.Info.Description, Version: swagger.Info.Version}
+	}
+
+	for name, definition := range swagger.Definitions {
+		doc.Components.Schemas[name] = convertSchema(&definition)
+	}
+
+	// The middleware takes the token with or without the "Bearer " prefix, so an HTTP bearer
+	// scheme describes every API key scheme swag knows about
+	for name, scheme := range swagger.SecurityDefinitions {
+		doc.Components.SecuritySchemes[name] = &securityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  scheme.Description,
+		}
+	}
+
+	envelope := version == "v2"
+	if envelope {
+		reflectSchema(reflect.TypeFor[dto.Envelope](), doc.Components.Schemas)
+	}
+
+	operationIDs := make(map[string]bool)
+	for _, path := range sortedKeys(swagger.Paths.Paths) {
+		item := swagger.Paths.Paths[path]
+		operations := map[string]*spec.Operation{
+			"get": item.Get, "put": item.Put, "post": item.Post, "delete": item.Delete,
+			"options": item.Options, "head": item.Head, "patch": item.Patch,
+		}
+
+		for _, method := range methods {
+			op := operations[method]
+			if op == nil {
+				continue
+			}
+			if routes != nil && !routes[strings.ToUpper(method)+" "+path] {
+				continue
+			}
+
+			converted := convertOperation(swagger, op, item.Parameters)
+			converted.OperationID = uniqueOperationID(op, method, path, operationIDs)
+			if envelope {
+				wrapResponses(converted, doc.Components.Schemas)
+			}
+
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(map[string]*operation)
+			}
+			doc.Paths[path][method] = converted
+		}
+	}
+
+	return doc
+}
+
+func convertOperation(swagger *spec.Swagger, op *spec.Operation, shared []spec.Parameter) *operation {
+	converted := &operation{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*response),
+		Security:    op.Security,
+	}
+
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = swagger.Consumes
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = swagger.Produces
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	var form *schema
+	for _, param := range slices.Concat(shared, op.Parameters) {
+		switch param.In {
+		case "body":
+			content := make(map[string]*mediaType, len(consumes))
+			for _, contentType := range consumes {
+				content[contentType] = &mediaType{Schema: convertSchema(param.Schema)}
+			}
+			converted.RequestBody = &requestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Content:     content,
+			}
+
+		case "formData":
+			if form == nil {
+				form = &schema{Type: "object", Properties: make(map[string]*schema)}
+			}
+			property := parameterSchema(&param)
+			property.Description = param.Description
+			form.Properties[param.Name] = property
+			if param.Required {
+				form.Required = append(form.Required, param.Name)
+			}
+
+		default:
+			converted.Parameters = append(converted.Parameters, convertParameter(&param))
+		}
+	}
+	if form != nil {
+		contentType := "multipart/form-data"
+		if len(consumes) > 0 && consumes[0] == "application/x-www-form-urlencoded" {
+			contentType = consumes[0]
+		}
+		converted.RequestBody = &requestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]*mediaType{contentType: {Schema: form}},
+		}
+	}
+
+	if op.Responses != nil {
+		for code, resp := range op.Responses.StatusCodeResponses {
+			converted.Responses[strconv.Itoa(code)] = convertResponse(&resp, produces)
+		}
+		if op.Responses.Default != nil {
+			converted.Responses["default"] = convertResponse(op.Responses.Default, produces)
+		}
+	}
+
+	return converted
+}
+
+func convertParameter(param *spec.Parameter) *parameter {
+	converted := &parameter{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required || param.In == "path",
+		Schema:      parameterSchema(param),
+	}
+
+	// Swagger's csv arrays are OpenAPI's form style without explode
+	if param.Type == "array" && (param.CollectionFormat == "" || param.CollectionFormat == "csv") {
+		explode := false
+		converted.Style = "form"
+		converted.Explode = &explode
+	}
+	return converted
+}
+
+func parameterSchema(param *spec.Parameter) *schema {
+	converted := simpleSchema(&param.SimpleSchema)
+	converted.Enum = param.Enum
+	converted.Minimum = param.Minimum
+	converted.Maximum = param.Maximum
+	converted.MinLength = param.MinLength
+	converted.MaxLength = param.MaxLength
+	if param.Example != nil {
+		converted.Examples = []any{param.Example}
+	}
+	return converted
+}
+
+func simpleSchema(simple *spec.SimpleSchema) *schema {
+	if simple.Type == "file" {
+		return &schema{Type: "string", ContentMediaType: "application/octet-stream"}
+	}
+
+	converted := &schema{Type: simple.Type, Format: simple.Format, Default: simple.Default}
+	if simple.Items != nil {
+		converted.Items = simpleSchema(&simple.Items.SimpleSchema)
+		converted.Items.Enum = simple.Items.Enum
+	}
+	return converted
+}
+
+func convertResponse(resp *spec.Response, produces []string) *response {
+	converted := &response{Description: resp.Description}
+	if converted.Description == "" {
+		converted.Description = "OK"
+	}
+	if resp.Schema != nil {
+		converted.Content = make(map[string]*mediaType, len(produces))
+		for _, contentType := range produces {
+			converted.Content[contentType] = &mediaType{Schema: convertSchema(resp.Schema)}
+		}
+	}
+	return converted
+}
+
+func convertSchema(s *spec.Schema) *schema {
+	if s == nil {
+		return &schema{}
+	}
+	if ref := s.Ref.String(); ref != "" {
+		return &schema{Ref: componentRef(ref), Description: s.Description}
+	}
+
+	converted := &schema{
+		Format:      s.Format,
+		Description: s.Description,
+		Required:    s.Required,
+		Enum:        s.Enum,
+		Default:     s.Default,
+		Minimum:     s.Minimum,
+		Maximum:     s.Maximum,
+		MinLength:   s.MinLength,
+		MaxLength:   s.MaxLength,
+	}
+	if len(s.Type) > 0 {
+		converted.Type = s.Type[0]
+	}
+	if s.Example != nil {
+		converted.Examples = []any{s.Example}
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		converted.Items = convertSchema(s.Items.Schema)
+	}
+	if len(s.Properties) > 0 {
+		converted.Properties = make(map[string]*schema, len(s.Properties))
+		for name, property := range s.Properties {
+			converted.Properties[name] = convertSchema(&property)
+		}
+	}
+	if s.AdditionalProperties != nil {
+		if s.AdditionalProperties.Schema != nil {
+			converted.AdditionalProperties = convertSchema(s.AdditionalProperties.Schema)
+		} else if s.AdditionalProperties.Allows {
+			converted.AdditionalProperties = &schema{}
+		}
+	}
+	for _, part := range s.AllOf {
+		converted.AllOf = append(converted.AllOf, convertSchema(&part))
+	}
+	return converted
+}
+
+// componentRef points a Swagger definition reference at the component of the same name
+func componentRef(ref string) string {
+	return "#/components/schemas/" + strings.TrimPrefix(ref, "#/definitions/")
+}
+
+// wrapResponses describes the v2 envelope around the JSON responses of an operation: the body
+// of a success becomes data (the items, for list responses) next to meta, and errors are
+// reported in errors
+func wrapResponses(op *operation, schemas map[string]*schema) {
+	for code, resp := range op.Responses {
+		status, _ := strconv.Atoi(code)
+
+		if status >= http.StatusBadRequest || code == "default" {
+			resp.Content = map[string]*mediaType{
+				"application/json": {Schema: &schema{Ref: componentRef(envelopeSchema)}},
+			}
+			continue
+		}
+
+		content, ok := resp.Content["application/json"]
+		if !ok {
+			continue
+		}
+		data := content.Schema
+		if items := paginatedItems(data, schemas); items != nil {
+			data = items
+		}
+		content.Schema = &schema{
+			Type: "object",
+			Properties: map[string]*schema{
+				"data": data,
+				"meta": {Ref: componentRef(metaSchema)},
+			},
+			Required: []string{"data", "meta"},
+		}
+	}
+}
+
+// paginatedItems returns the schema of the items of a v1 list response, the shape the envelope
+// moves into meta.pagination, or nil if s is not one
+func paginatedItems(s *schema, schemas map[string]*schema) *schema {
+	if s.Ref != "" {
+		s = schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if s == nil {
+		return nil
+	}
+
+	properties := resolveProperties(s, schemas)
+	for _, field := range []string{"total", "page", "limit"} {
+		if properties[field] == nil {
+			return nil
+		}
+	}
+
+	var items *schema
+	for name, property := range properties {
+		switch name {
+		case "total", "page", "limit", "total_pages", "next_cursor", "prev_cursor":
+			continue
+		}
+		if items != nil || property.Type != "array" {
+			return nil
+		}
+		items = property
+	}
+	return items
+}
+
+// resolveProperties collects the properties of a schema, including the ones of its allOf parts
+func resolveProperties(s *schema, schemas map[string]*schema) map[string]*schema {
+	properties := make(map[string]*schema, len(s.Properties))
+	for _, part := range s.AllOf {
+		if part.Ref != "" {
+			part = schemas[strings.TrimPrefix(part.Ref, "#/components/schemas/")]
+		}
+		if part != nil {
+			for name, property := range resolveProperties(part, schemas) {
+				properties[name] = property
+			}
+		}
+	}
+	for name, property := range s.Properties {
+		properties[name] = property
+	}
+	return properties
+}
+
+// reflectSchema describes a Go type by its JSON encoding, adding named structs to schemas under
+// the names swag gives them ("dto.Meta")
+func reflectSchema(t reflect.Type, schemas map[string]*schema) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[time.Time]() {
+		return &schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := schemas[name]; !ok {
+			object := &schema{Type: "object", Properties: make(map[string]*schema)}
+			schemas[name] = object
+			addFields(object, t, schemas)
+		}
+		return &schema{Ref: componentRef(name)}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: reflectSchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: reflectSchema(t.Elem(), schemas)}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	default:
+		return &schema{}
+	}
+}
+
+// addFields adds the JSON fields of a struct to object; embedded structs are flattened like
+// encoding/json does
+func addFields(object *schema, t reflect.Type, schemas map[string]*schema) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(object, field.Type, schemas)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		object.Properties[name] = reflectSchema(field.Type, schemas)
+		// swag's override of a field encoded differently from its Go type, e.g. money amounts
+		if override := field.Tag.Get("swaggertype"); override != "" && !strings.Contains(override, ",") {
+			object.Properties[name] = &schema{Type: override}
+		}
+		if !strings.Contains(options, "omitempty") {
+			object.Required = append(object.Required, name)
+		}
+	}
+}
+
+// schemaName is the component name of a named type: its package name and type name
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	return pkg[strings.LastIndex(pkg, "/")+1:] + "." + t.Name()
+}
+
+// uniqueOperationID returns the operation's ID, or one made from its summary ("Get product
+// details" becomes "getProductDetails") or, without one, its method and path. IDs used before
+// get a number appended.
+func uniqueOperationID(op *spec.Operation, method, path string, used map[string]bool) string {
+	id := op.ID
+	if id == "" {
+		id = camelCase(op.Summary)
+	}
+	if id == "" {
+		id = camelCase(method + " " + strings.NewReplacer("{", "by ", "}", "").Replace(path))
+	}
+
+	unique := id
+	for n := 2; used[unique]; n++ {
+		unique = id + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// camelCase joins the words of s, which are separated by anything but ASCII letters and digits
+func camelCase(s string) string {
+	words := strings.FieldsFunc(strings.ReplaceAll(s, "'", ""), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+
+	var b strings.Builder
+	for i, word := range words {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word[:1]) + word[1:])
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	id := b.String()
+	if id != "" && '0' <= id[0] && id[0] <= '9' {
+		id = "op" + id
+	}
+	return id
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reservedNames are the names the client runtimes declare themselves
+var reservedNames = map[string]bool{
+	"Client": true, "ClientOptions": true, "New": true,
+	"Response": true, "ApiResponse": true, "Error": true, "ApiError": true,
+}
+
+// sdkOperation is an operation of the document as the clients expose it
+type sdkOperation struct {
+	Name        string // operation ID
+	Method      string // upper case
+	Path        string
+	Summary     string
+	Description string
+	PathParams  []*parameter // in the order they appear in the path
+	QueryParams []*parameter
+	Body        *schema // nil without a request body
+	BodyType    string  // content type of the body
+	Result      *schema // data of the envelope; nil when the response has no JSON body
+	Raw         bool    // the success response is not JSON, e.g. CSV or an event stream
+}
+
+// sdkOperations lists the operations of the document ordered by path and method
+func sdkOperations(doc *document) []*sdkOperation {
+	var operations []*sdkOperation
+	for _, path := range sortedKeys(doc.Paths) {
+		for _, method := range methods {
+			op := doc.Paths[path][method]
+			if op == nil {
+				continue
+			}
+
+			converted := &sdkOperation{
+				Name:        op.OperationID,
+				Method:      strings.ToUpper(method),
+				Path:        path,
+				Summary:     op.Summary,
+				Description: op.Description,
+			}
+
+			for _, param := range op.Parameters {
+				switch param.In {
+				case "path":
+					converted.PathParams = append(converted.PathParams, param)
+				case "query":
+					converted.QueryParams = append(converted.QueryParams, param)
+				}
+			}
+			sort.SliceStable(converted.PathParams, func(i, j int) bool {
+				return strings.Index(path, "{"+converted.PathParams[i].Name+"}") <
+					strings.Index(path, "{"+converted.PathParams[j].Name+"}")
+			})
+
+			if op.RequestBody != nil {
+				for _, contentType := range sortedKeys(op.RequestBody.Content) {
+					converted.Body = op.RequestBody.Content[contentType].Schema
+					converted.BodyType = contentType
+					if contentType == "application/json" {
+						break
+					}
+				}
+			}
+
+			if resp := successResponse(op); resp != nil && len(resp.Content) > 0 {
+				if content, ok := resp.Content["application/json"]; ok {
+					converted.Result = content.Schema.Properties["data"]
+				} else {
+					converted.Raw = true
+				}
+			}
+
+			operations = append(operations, converted)
+		}
+	}
+	return operations
+}
+
+// queryObject describes the query parameters of an operation as an object, documented by the
+// descriptions of the parameters
+func queryObject(params []*parameter) *schema {
+	query := &schema{Type: "object", Properties: make(map[string]*schema, len(params))}
+	for _, param := range params {
+		property := *param.Schema
+		property.Description = param.Description
+		query.Properties[param.Name] = &property
+		if param.Required {
+			query.Required = append(query.Required, param.Name)
+		}
+	}
+	return query
+}
+
+// successResponse returns the response of the lowest 2xx status, or nil if there is none
+func successResponse(op *operation) *response {
+	best := 0
+	for code := range op.Responses {
+		status, err := strconv.Atoi(code)
+		if err == nil && status >= 200 && status < 300 && (best == 0 || status < best) {
+			best = status
+		}
+	}
+	if best == 0 {
+		return nil
+	}
+	return op.Responses[strconv.Itoa(best)]
+}
+
+// typeNames names the types of the components: the type name without its package ("Meta" for
+// dto.Meta), or the package and type name where that is ambiguous ("DtoProduct")
+func typeNames(doc *document) map[string]string {
+	counts := make(map[string]int)
+	for name := range doc.Components.Schemas {
+		counts[shortName(name)]++
+	}
+
+	names := make(map[string]string, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		short := shortName(name)
+		if counts[short] > 1 || reservedNames[short] {
+			short = pascalCase(name)
+		}
+		names[name] = short
+	}
+	return names
+}
+
+// shortName is a component name without its package, e.g. "ProductWithCategory"
+func shortName(name string) string {
+	return pascalCase(name[strings.LastIndex(name, ".")+1:])
+}
+
+// refName returns the component name a reference points to
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+// pascalCase turns a name made of words into an exported identifier, e.g. "user_id" into
+// "UserID", keeping the common initialisms upper case
+func pascalCase(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+
+	var b strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToUpper(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	name := b.String()
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		name = "X" + name
+	}
+	return name
+}
+
+// initialisms are written in upper case in Go names
+var initialisms = map[string]bool{
+	"API": true, "CSV": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "JWT": true, "SKU": true, "SMS": true, "SSE": true, "TTL": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+// commentLines splits text into comment lines, dropping empty ones
+func commentLines(text ...string) []string {
+	var lines []string
+	for _, part := range text {
+		for _, line := range strings.Split(part, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
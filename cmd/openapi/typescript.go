@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// tsIdentifier matches property names that need no quotes in TypeScript
+	tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+	// tsInvalid matches the characters TypeScript identifiers can't have
+	tsInvalid = regexp.MustCompile(`[^A-Za-z0-9_$]`)
+)
+
+// generateTypeScript writes a fetch-based TypeScript client of a v2 document: an interface per
+// schema and a method per operation resolving to the envelope's data and meta
+func generateTypeScript(doc *document) ([]byte, error) {
+	names := typeNames(doc)
+	g := &tsGenerator{names: names}
+
+	fmt.Fprintf(&g.b, "// Code generated by cmd/openapi from %s %s. DO NOT EDIT.\n\n", doc.Info.Title, doc.Info.Version)
+
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		g.declaration(names[name], doc.Components.Schemas[name])
+	}
+
+	meta, apiError := names[metaSchema], names[apiErrorSchema]
+	fmt.Fprintf(&g.b, tsRuntime, meta, apiError, apiError, meta, apiError, meta)
+
+	for _, op := range sdkOperations(doc) {
+		g.operation(op)
+	}
+
+	g.b.WriteString(tsRuntimeEnd)
+	return []byte(g.b.String()), nil
+}
+
+type tsGenerator struct {
+	b     strings.Builder
+	names map[string]string
+}
+
+func (g *tsGenerator) declaration(name string, s *schema) {
+	g.comment("", commentLines(s.Description))
+	if s.Type != "object" || len(s.Properties) == 0 || len(s.AllOf) > 0 {
+		fmt.Fprintf(&g.b, "export type %s = %s;\n\n", name, g.typeOf(s, ""))
+		return
+	}
+
+	fmt.Fprintf(&g.b, "export interface %s %s\n\n", name, g.object(s, ""))
+}
+
+// object writes the body of an object type, indented by indent
+func (g *tsGenerator) object(s *schema, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, property := range sortedKeys(s.Properties) {
+		schema := s.Properties[property]
+		for _, line := range commentLines(schema.Description) {
+			fmt.Fprintf(&b, "%s  /** %s */\n", indent, line)
+		}
+
+		optional := "?"
+		for _, required := range s.Required {
+			if required == property {
+				optional = ""
+			}
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsPropertyName(property), optional, g.typeOf(schema, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// typeOf returns the TypeScript type of a schema
+func (g *tsGenerator) typeOf(s *schema, indent string) string {
+	if s == nil {
+		return "unknown"
+	}
+	if s.Ref != "" {
+		return g.names[refName(s.Ref)]
+	}
+	if len(s.AllOf) > 0 {
+		parts := make([]string, len(s.AllOf))
+		for i, part := range s.AllOf {
+			parts[i] = g.typeOf(part, indent)
+		}
+		return strings.Join(parts, " & ")
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, value := range s.Enum {
+			literal, _ := json.Marshal(value)
+			values[i] = string(literal)
+		}
+		return strings.Join(values, " | ")
+	}
+
+	switch s.Type {
+	case "string":
+		if s.ContentMediaType != "" {
+			return "Blob"
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		return "Array<" + g.typeOf(s.Items, indent) + ">"
+	case "object":
+		if len(s.Properties) > 0 {
+			return g.object(s, indent)
+		}
+		if s.AdditionalProperties != nil {
+			return "Record<string, " + g.typeOf(s.AdditionalProperties, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+}
+
+func (g *tsGenerator) operation(op *sdkOperation) {
+	var params []string
+	for _, param := range op.PathParams {
+		params = append(params, fmt.Sprintf("%s: %s", tsParamName(param.Name), g.typeOf(param.Schema, "  ")))
+	}
+	if op.Body != nil {
+		bodyType := g.typeOf(op.Body, "  ")
+		if op.BodyType != "application/json" {
+			bodyType = "FormData"
+		}
+		params = append(params, "body: "+bodyType)
+	}
+	if len(op.QueryParams) > 0 {
+		params = append(params, "query: "+g.object(queryObject(op.QueryParams), "  ")+" = {}")
+	}
+	params = append(params, "init: RequestInit = {}")
+
+	result := "void"
+	switch {
+	case op.Raw:
+		result = "Response"
+	case op.Result != nil:
+		result = "ApiResponse<" + g.typeOf(op.Result, "  ") + ">"
+	}
+
+	path := op.Path
+	for _, param := range op.PathParams {
+		path = strings.ReplaceAll(path, "{"+param.Name+"}", "${encodeURIComponent(String("+tsParamName(param.Name)+"))}")
+	}
+
+	body := "undefined"
+	if op.Body != nil {
+		body = "body"
+	}
+	query := "undefined"
+	if len(op.QueryParams) > 0 {
+		query = "query"
+	}
+
+	g.b.WriteString("\n")
+	g.comment("  ", commentLines(op.Summary, op.Description))
+	fmt.Fprintf(&g.b, "  async %s(%s): Promise<%s> {\n", op.Name, strings.Join(params, ", "), result)
+	fmt.Fprintf(&g.b, "    const response = await this.send(%q, `%s`, %s, %s, init);\n", op.Method, path, query, body)
+	switch result {
+	case "Response":
+		g.b.WriteString("    return response;\n")
+	case "void":
+		g.b.WriteString("    await response.body?.cancel();\n")
+	default:
+		g.b.WriteString("    return (await response.json()) as " + result + ";\n")
+	}
+	g.b.WriteString("  }\n")
+}
+
+func (g *tsGenerator) comment(indent string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(&g.b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(&g.b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(&g.b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(&g.b, "%s */\n", indent)
+}
+
+func tsPropertyName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	quoted, _ := json.Marshal(name)
+	return string(quoted)
+}
+
+// tsParamName makes a parameter name a valid identifier, e.g. "product_id" stays as it is
+func tsParamName(name string) string {
+	name = tsInvalid.ReplaceAllString(name, "_")
+	if !tsIdentifier.MatchString(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// tsRuntime is the start of the client, formatted with the meta and error type names
+const tsRuntime = `export interface ClientOptions {
+  /** Base URL of the v2 API, e.g. "https://api.example.com/api/v2" */
+  baseUrl: string;
+  /** Returns the access token sent as a bearer token, if any */
+  token?: () => string | undefined | Promise<string | undefined>;
+  /** Headers sent with every request, e.g. Accept-Language */
+  headers?: Record<string, string>;
+  /** fetch implementation; the global one by default */
+  fetch?: typeof fetch;
+}
+
+/** Body of a successful response */
+export interface ApiResponse<T> {
+  data: T;
+  meta: %s;
+}
+
+/** Error responses are thrown as ApiError */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly errors: %s[];
+
+  constructor(status: number, errors: %s[], readonly meta?: %s) {
+    super(errors.map((error) => error.message).join("; ") || ` + "`HTTP ${status}`" + `);
+    this.name = "ApiError";
+    this.status = status;
+    this.errors = errors;
+  }
+}
+
+type Query = Record<string, string | number | boolean | Array<string | number | boolean> | undefined>;
+
+export class Client {
+  private readonly options: ClientOptions;
+
+  constructor(options: ClientOptions) {
+    this.options = { ...options, baseUrl: options.baseUrl.replace(/\/+$/, "") };
+  }
+
+  private async send(method: string, path: string, query: Query | undefined, body: unknown, init: RequestInit): Promise<Response> {
+    const url = new URL(this.options.baseUrl + path, (globalThis as { location?: { href: string } }).location?.href);
+    for (const [name, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) {
+        url.searchParams.set(name, Array.isArray(value) ? value.join(",") : String(value));
+      }
+    }
+
+    const headers = new Headers(this.options.headers);
+    new Headers(init.headers).forEach((value, name) => headers.set(name, value));
+    const token = await this.options.token?.();
+    if (token) {
+      headers.set("Authorization", ` + "`Bearer ${token}`" + `);
+    }
+    if (body !== undefined && !(body instanceof FormData)) {
+      headers.set("Content-Type", "application/json");
+      body = JSON.stringify(body);
+    }
+
+    const response = await (this.options.fetch ?? fetch)(url, { ...init, method, headers, body: body as BodyInit | undefined });
+    if (!response.ok) {
+      const envelope = (await response.json().catch(() => undefined)) as { errors?: %s[]; meta?: %s } | undefined;
+      throw new ApiError(response.status, envelope?.errors ?? [], envelope?.meta);
+    }
+    return response;
+  }
+`
+
+const tsRuntimeEnd = "}\n"
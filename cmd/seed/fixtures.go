@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// The fixtures are the roles, permissions, demo accounts, categories and products that the
+// application and its documentation rely on. They have fixed IDs, so each kind is only inserted
+// into an empty collection.
+
+// seedAccounts inserts the roles, permissions and demo accounts. All demo accounts share
+// passwordHash.
+func seedAccounts(ctx context.Context, db *mongo.Database, passwordHash string) error {
+	// Seed Roles
+	rolesCollection := db.Collection("roles")
+	roles := []interface{}{
+		bson.M{"_id": 1, "name": "admin", "description": "System administrator", "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 2, "name": "user", "description": "Regular user", "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 3, "name": "moderator", "description": "Content moderator", "inherits": []int{2}, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 4, "name": "student", "description": "Student user", "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 5, "name": "teacher", "description": "Teacher user", "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+	}
+	_, err := rolesCollection.InsertMany(ctx, roles)
+	if err != nil {
+		return fmt.Errorf("insert roles: %w", err)
+	}
+
+	// Seed Permissions
+	permissionsCollection := db.Collection("permissions")
+	permissions := []interface{}{
+		bson.M{"_id": 1, "resource": "*", "action": "*", "description": "Full access", "created_at": time.Now().UTC()},
+		bson.M{"_id": 2, "resource": "products", "action": "write", "description": "Create, update and delete products", "created_at": time.Now().UTC()},
+		bson.M{"_id": 3, "resource": "categories", "action": "write", "description": "Create, update and delete categories", "created_at": time.Now().UTC()},
+		bson.M{"_id": 4, "resource": "interactions", "action": "export", "description": "Export interaction events", "created_at": time.Now().UTC()},
+		bson.M{"_id": 5, "resource": "permissions", "action": "manage", "description": "Manage permissions and role grants", "created_at": time.Now().UTC()},
+		bson.M{"_id": 6, "resource": "metrics", "action": "read", "description": "View live dashboard metrics", "created_at": time.Now().UTC()},
+		bson.M{"_id": 7, "resource": "support_notes", "action": "manage", "description": "Read and write internal support notes on users and orders", "created_at": time.Now().UTC()},
+		bson.M{"_id": 8, "resource": "users", "action": "read", "description": "List users and view their lifetime value", "created_at": time.Now().UTC()},
+		bson.M{"_id": 9, "resource": "users", "action": "manage", "description": "Suspend, delete and reactivate users", "created_at": time.Now().UTC()},
+		bson.M{"_id": 10, "resource": "media", "action": "sign", "description": "Issue signed links to private media", "created_at": time.Now().UTC()},
+		bson.M{"_id": 11, "resource": "content", "action": "moderate", "description": "Approve or reject quarantined user-generated text", "created_at": time.Now().UTC()},
+		bson.M{"_id": 12, "resource": "promotions", "action": "manage", "description": "Manage birthday and anniversary promotions", "created_at": time.Now().UTC()},
+		bson.M{"_id": 13, "resource": "customer_groups", "action": "manage", "description": "Manage customer groups, their pricing and members", "created_at": time.Now().UTC()},
+	}
+	_, err = permissionsCollection.InsertMany(ctx, permissions)
+	if err != nil {
+		return fmt.Errorf("insert permissions: %w", err)
+	}
+
+	// Seed Role Permissions
+	rolePermissionsCollection := db.Collection("role_permissions")
+	rolePermissions := []interface{}{
+		bson.M{"role_id": 1, "permission_id": 1, "created_at": time.Now().UTC()},  // admin: *:*
+		bson.M{"role_id": 3, "permission_id": 2, "created_at": time.Now().UTC()},  // moderator: products:write
+		bson.M{"role_id": 3, "permission_id": 3, "created_at": time.Now().UTC()},  // moderator: categories:write
+		bson.M{"role_id": 3, "permission_id": 7, "created_at": time.Now().UTC()},  // moderator: support_notes:manage
+		bson.M{"role_id": 3, "permission_id": 8, "created_at": time.Now().UTC()},  // moderator: users:read
+		bson.M{"role_id": 3, "permission_id": 11, "created_at": time.Now().UTC()}, // moderator: content:moderate
+	}
+	_, err = rolePermissionsCollection.InsertMany(ctx, rolePermissions)
+	if err != nil {
+		return fmt.Errorf("insert role permissions: %w", err)
+	}
+
+	// Seed Users
+	usersCollection := db.Collection("users")
+
+	users := []interface{}{
+		bson.M{
+			"_id":           1,
+			"email":         "admin@example.com",
+			"password_hash": passwordHash,
+			"status":        "active",
+			"created_at":    time.Now().UTC(),
+			"updated_at":    time.Now().UTC(),
+		},
+		bson.M{
+			"_id":           2,
+			"email":         "moderator@example.com",
+			"password_hash": passwordHash,
+			"status":        "active",
+			"created_at":    time.Now().UTC(),
+			"updated_at":    time.Now().UTC(),
+		},
+		bson.M{
+			"_id":           3,
+			"email":         "user1@example.com",
+			"password_hash": passwordHash,
+			"status":        "active",
+			"created_at":    time.Now().UTC(),
+			"updated_at":    time.Now().UTC(),
+		},
+		bson.M{
+			"_id":           4,
+			"email":         "user2@example.com",
+			"password_hash": passwordHash,
+			"status":        "active",
+			"created_at":    time.Now().UTC(),
+			"updated_at":    time.Now().UTC(),
+		},
+		bson.M{
+			"_id":           5,
+			"email":         "student@example.com",
+			"password_hash": passwordHash,
+			"status":        "active",
+			"created_at":    time.Now().UTC(),
+			"updated_at":    time.Now().UTC(),
+		},
+		bson.M{
+			"_id":           6,
+			"email":         "teacher@example.com",
+			"password_hash": passwordHash,
+			"status":        "active",
+			"created_at":    time.Now().UTC(),
+			"updated_at":    time.Now().UTC(),
+		},
+	}
+	_, err = usersCollection.InsertMany(ctx, users)
+	if err != nil {
+		return fmt.Errorf("insert users: %w", err)
+	}
+
+	// Seed User Roles
+	userRolesCollection := db.Collection("user_roles")
+	userRoles := []interface{}{
+		bson.M{"user_id": 1, "role_id": 1}, // admin
+		bson.M{"user_id": 2, "role_id": 3}, // moderator
+		bson.M{"user_id": 3, "role_id": 2}, // user
+		bson.M{"user_id": 4, "role_id": 2}, // user
+		bson.M{"user_id": 5, "role_id": 4}, // student
+		bson.M{"user_id": 6, "role_id": 5}, // teacher
+	}
+	_, err = userRolesCollection.InsertMany(ctx, userRoles)
+	if err != nil {
+		return fmt.Errorf("insert user roles: %w", err)
+	}
+
+	return nil
+}
+
+// seedCategories inserts the demo category tree
+func seedCategories(ctx context.Context, db *mongo.Database) error {
+	// Seed Categories
+	categoriesCollection := db.Collection("categories")
+	categories := []interface{}{
+		bson.M{"_id": 1, "name": "Electronics", "description": "Electronic devices and accessories", "parent_id": nil, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 2, "name": "Smartphones", "description": "Mobile phones", "parent_id": 1, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 3, "name": "Tablets", "description": "Tablet devices", "parent_id": 1, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 4, "name": "Laptops", "description": "Notebook computers", "parent_id": 1, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 5, "name": "Accessories", "description": "Tech accessories", "parent_id": 1, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+	}
+	_, err := categoriesCollection.InsertMany(ctx, categories)
+	if err != nil {
+		return fmt.Errorf("insert categories: %w", err)
+	}
+
+	return nil
+}
+
+// seedCatalog inserts the demo products into the demo categories, priced in currency
+func seedCatalog(ctx context.Context, db *mongo.Database, currency string) error {
+	// Seed Products
+	productsCollection := db.Collection("products")
+	categorySmartphones := 2
+	categoryTablets := 3
+	categoryLaptops := 4
+	categoryAccessories := 5
+
+	products := []interface{}{
+		// Smartphones
+		bson.M{"_id": 1, "name": "iPhone 15 Pro", "description": "Latest Apple flagship", "category_id": categorySmartphones, "price": domain.MoneyFromFloat(999.99, currency), "stock": 100, "image_url": "https://via.placeholder.com/300x300?text=iPhone+15+Pro", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 2, "name": "Samsung Galaxy S24", "description": "Samsung flagship phone", "category_id": categorySmartphones, "price": domain.MoneyFromFloat(899.99, currency), "stock": 80, "image_url": "https://via.placeholder.com/300x300?text=Galaxy+S24", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 3, "name": "Google Pixel 8", "description": "Google's latest smartphone", "category_id": categorySmartphones, "price": domain.MoneyFromFloat(699.99, currency), "stock": 60, "image_url": "https://via.placeholder.com/300x300?text=Pixel+8", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+
+		// Tablets
+		bson.M{"_id": 4, "name": "iPad Pro 12.9", "description": "Apple's premium tablet", "category_id": categoryTablets, "price": domain.MoneyFromFloat(1099.99, currency), "stock": 50, "image_url": "https://via.placeholder.com/300x300?text=iPad+Pro", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 5, "name": "Samsung Galaxy Tab S9", "description": "Samsung premium tablet", "category_id": categoryTablets, "price": domain.MoneyFromFloat(849.99, currency), "stock": 45, "image_url": "https://via.placeholder.com/300x300?text=Galaxy+Tab", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+
+		// Laptops
+		bson.M{"_id": 6, "name": "MacBook Air M3", "description": "Apple M3, 8GB RAM, 256GB SSD", "category_id": categoryLaptops, "price": domain.MoneyFromFloat(1199.99, currency), "stock": 30, "image_url": "https://via.placeholder.com/300x300?text=MacBook+Air", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 7, "name": "MacBook Pro 16", "description": "Apple M3 Pro, 18GB RAM, 512GB SSD", "category_id": categoryLaptops, "price": domain.MoneyFromFloat(2499.99, currency), "stock": 40, "image_url": "https://via.placeholder.com/300x300?text=MacBook+Pro", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 8, "name": "Dell XPS 15", "description": "Intel i7, 16GB RAM, 512GB SSD", "category_id": categoryLaptops, "price": domain.MoneyFromFloat(1799.99, currency), "stock": 60, "image_url": "https://via.placeholder.com/300x300?text=Dell+XPS+15", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+
+		// Accessories
+		bson.M{"_id": 9, "name": "AirPods Pro", "description": "Apple wireless earbuds with ANC", "category_id": categoryAccessories, "price": domain.MoneyFromFloat(249.99, currency), "stock": 150, "image_url": "https://via.placeholder.com/300x300?text=AirPods", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+		bson.M{"_id": 10, "name": "USB-C Hub", "description": "7-in-1 USB-C adapter", "category_id": categoryAccessories, "price": domain.MoneyFromFloat(49.99, currency), "stock": 200, "image_url": "https://via.placeholder.com/300x300?text=USB-C+Hub", "is_active": true, "created_at": time.Now().UTC(), "updated_at": time.Now().UTC()},
+	}
+	_, err := productsCollection.InsertMany(ctx, products)
+	if err != nil {
+		return fmt.Errorf("insert products: %w", err)
+	}
+
+	return nil
+}
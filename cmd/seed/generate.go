@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// generator produces random but reproducible users, products and interactions. With the same
+// seed it yields the same data, shifted to the time the seeder runs.
+type generator struct {
+	rng   *rand.Rand
+	now   time.Time
+	days  int
+	skew  float64
+	batch int
+
+	currency string // of product prices
+}
+
+var (
+	firstNamesMale   = []string{"Alikhan", "Arman", "Daniyar", "Yerlan", "Nurlan", "Timur", "Ivan", "Dmitry", "Alexey", "Sergey", "John", "Michael", "David", "Lucas", "Omar"}
+	firstNamesFemale = []string{"Aigerim", "Dana", "Madina", "Aruzhan", "Kamila", "Zarina", "Anna", "Maria", "Elena", "Olga", "Emma", "Sophia", "Laura", "Sara", "Leila"}
+	lastNames        = []string{"Akhmetov", "Bekov", "Serikbayev", "Nurpeisov", "Zhakupov", "Ivanov", "Petrov", "Smirnov", "Kuznetsov", "Popov", "Smith", "Johnson", "Brown", "Garcia", "Miller"}
+	streets          = []string{"Abay Ave", "Dostyk Ave", "Tole Bi St", "Satpayev St", "Lenin St", "Main St", "Park Ave", "Baker St", "Green St", "Central Sq"}
+)
+
+type city struct {
+	Name, Country, Phone string
+}
+
+var cities = []city{
+	{"Almaty", "Kazakhstan", "+7 7"}, {"Astana", "Kazakhstan", "+7 7"}, {"Shymkent", "Kazakhstan", "+7 7"},
+	{"Karaganda", "Kazakhstan", "+7 7"}, {"Aktobe", "Kazakhstan", "+7 7"}, {"Moscow", "Russia", "+7 9"},
+	{"Novosibirsk", "Russia", "+7 9"}, {"Tashkent", "Uzbekistan", "+998 9"}, {"Berlin", "Germany", "+49 15"},
+	{"London", "United Kingdom", "+44 7"},
+}
+
+// catalog describes how products of a category are named and priced. Categories without an
+// entry use genericCatalog.
+type catalog struct {
+	Brands   []string
+	Nouns    []string
+	MinPrice float64
+	MaxPrice float64
+}
+
+var catalogs = map[string]catalog{
+	"Smartphones": {[]string{"Apple", "Samsung", "Google", "Xiaomi", "OnePlus", "Nothing"}, []string{"Phone", "Smartphone"}, 149, 1599},
+	"Tablets":     {[]string{"Apple", "Samsung", "Lenovo", "Xiaomi", "Huawei"}, []string{"Tablet", "Pad"}, 129, 1499},
+	"Laptops":     {[]string{"Apple", "Dell", "Lenovo", "HP", "Asus", "Acer"}, []string{"Laptop", "Notebook", "Ultrabook"}, 399, 3499},
+	"Accessories": {[]string{"Anker", "Logitech", "Belkin", "Sony", "JBL", "Baseus"}, []string{"Charger", "Cable", "Headphones", "Mouse", "Keyboard", "Speaker", "Power Bank"}, 9, 399},
+}
+
+var genericCatalog = catalog{[]string{"Acme", "Nova", "Vertex", "Orion", "Zenith"}, []string{"Gadget", "Device", "Kit"}, 19, 999}
+
+var adjectives = []string{"Pro", "Max", "Lite", "Plus", "Air", "Mini", "Ultra", "Neo", "S", "X"}
+
+// timeInWindow returns a random time within the last g.days days
+func (g *generator) timeInWindow() time.Time {
+	return g.now.Add(-time.Duration(g.rng.Int63n(int64(g.days) * int64(24*time.Hour))))
+}
+
+func pick[T any](rng *rand.Rand, values []T) T {
+	return values[rng.Intn(len(values))]
+}
+
+// powerLaw returns a function drawing indexes in [0, n) whose frequencies follow a Zipf
+// distribution. The ranks are shuffled so popularity does not follow insertion order.
+func (g *generator) powerLaw(n int) func() int {
+	ranks := g.rng.Perm(n)
+	if n == 1 {
+		return func() int { return 0 }
+	}
+	zipf := rand.NewZipf(g.rng, g.skew, 1, uint64(n-1))
+	return func() int {
+		return ranks[zipf.Uint64()]
+	}
+}
+
+// user returns a user with the given ID and a matching profile
+func (g *generator) user(id, profileID int, passwordHash string) (*domain.User, *domain.Profile) {
+	gender := "male"
+	firstName := pick(g.rng, firstNamesMale)
+	if g.rng.Intn(2) == 0 {
+		gender = "female"
+		firstName = pick(g.rng, firstNamesFemale)
+	}
+	lastName := pick(g.rng, lastNames)
+	if gender == "female" && (strings.HasSuffix(lastName, "ov") || strings.HasSuffix(lastName, "ev")) {
+		lastName += "a"
+	}
+
+	createdAt := g.timeInWindow()
+	user := &domain.User{
+		ID:           id,
+		Email:        fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(firstName), strings.ToLower(lastName), id),
+		PasswordHash: passwordHash,
+		Status:       "active",
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+
+	home := pick(g.rng, cities)
+	dateOfBirth := g.now.AddDate(-18-g.rng.Intn(50), -g.rng.Intn(12), -g.rng.Intn(28)).Truncate(24 * time.Hour)
+	phone := fmt.Sprintf("%s%02d %03d %04d", home.Phone, g.rng.Intn(100), g.rng.Intn(1000), g.rng.Intn(10000))
+	address := fmt.Sprintf("%d %s", 1+g.rng.Intn(200), pick(g.rng, streets))
+	postalCode := fmt.Sprintf("%06d", g.rng.Intn(1000000))
+	profile := &domain.Profile{
+		ID:          profileID,
+		UserID:      id,
+		FirstName:   firstName,
+		LastName:    lastName,
+		DateOfBirth: &dateOfBirth,
+		Gender:      &gender,
+		Phone:       &phone,
+		Address:     &address,
+		City:        &home.Name,
+		Country:     &home.Country,
+		PostalCode:  &postalCode,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+
+	return user, profile
+}
+
+// product returns a product with the given ID in a random one of the categories
+func (g *generator) product(id int, categories []*domain.Category) *domain.Product {
+	category := pick(g.rng, categories)
+	spec, ok := catalogs[category.Name]
+	if !ok {
+		spec = genericCatalog
+	}
+
+	brand := pick(g.rng, spec.Brands)
+	noun := pick(g.rng, spec.Nouns)
+	name := fmt.Sprintf("%s %s %s %d", brand, noun, pick(g.rng, adjectives), 1+g.rng.Intn(20))
+
+	// Prices are log-uniform so cheap items are as common as expensive ones per order of magnitude
+	price := math.Exp(math.Log(spec.MinPrice) + g.rng.Float64()*(math.Log(spec.MaxPrice)-math.Log(spec.MinPrice)))
+	price = math.Floor(price) + 0.99
+
+	createdAt := g.timeInWindow()
+	categoryID := category.ID
+	return &domain.Product{
+		ID:          id,
+		Name:        name,
+		Description: fmt.Sprintf("%s %s by %s", strings.ToLower(category.Name), strings.ToLower(noun), brand),
+		CategoryID:  &categoryID,
+		Price:       domain.MoneyFromFloat(price, g.currency),
+		Stock:       g.rng.Intn(500),
+		ImageURL:    "https://via.placeholder.com/300x300?text=" + strings.ReplaceAll(name, " ", "+"),
+		IsActive:    g.rng.Intn(20) != 0,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+}
+
+// quantity returns a purchase quantity; most orders contain a single item
+func (g *generator) quantity() int {
+	switch n := g.rng.Intn(100); {
+	case n < 80:
+		return 1
+	case n < 95:
+		return 2
+	default:
+		return 3 + g.rng.Intn(3)
+	}
+}
+
+// batchWriter inserts documents into a collection in unordered batches. Documents that
+// already exist are skipped, so interactions can be added to a database that was not dropped.
+type batchWriter struct {
+	ctx        context.Context
+	collection *mongo.Collection
+	size       int
+	pending    []interface{}
+	inserted   int
+}
+
+func (g *generator) writer(ctx context.Context, collection *mongo.Collection) *batchWriter {
+	return &batchWriter{ctx: ctx, collection: collection, size: g.batch}
+}
+
+func (w *batchWriter) add(document interface{}) error {
+	w.pending = append(w.pending, document)
+	if len(w.pending) >= w.size {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *batchWriter) flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	_, err := w.collection.InsertMany(w.ctx, w.pending, options.InsertMany().SetOrdered(false))
+	skipped := 0
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			return fmt.Errorf("insert into %s: %w", w.collection.Name(), err)
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(writeErr) {
+				return fmt.Errorf("insert into %s: %w", w.collection.Name(), err)
+			}
+		}
+		skipped = len(bulkErr.WriteErrors)
+	}
+
+	w.inserted += len(w.pending) - skipped
+	w.pending = w.pending[:0]
+	return nil
+}
+
+// nextID returns one more than the highest integer _id in a collection
+func nextID(ctx context.Context, collection *mongo.Collection) (int, error) {
+	var last struct {
+		ID int `bson:"_id"`
+	}
+	err := collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"_id": -1})).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("find last %s id: %w", collection.Name(), err)
+	}
+	return last.ID + 1, nil
+}
+
+// reserveProfileIDs advances the profile counter used by the application by n and returns
+// the first reserved ID
+func reserveProfileIDs(ctx context.Context, db *mongo.Database, n int) (int, error) {
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := db.Collection("counters").FindOneAndUpdate(ctx,
+		bson.M{"_id": "profile_id"},
+		bson.M{"$inc": bson.M{"seq": n}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true),
+	).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("reserve profile ids: %w", err)
+	}
+	return counter.Seq - n + 1, nil
+}
+
+// generateUsers inserts n users with profiles and returns how many were inserted
+func (g *generator) generateUsers(ctx context.Context, db *mongo.Database, n int, passwordHash string) (int, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	firstID, err := nextID(ctx, db.Collection("users"))
+	if err != nil {
+		return 0, err
+	}
+	firstProfileID, err := reserveProfileIDs(ctx, db, n)
+	if err != nil {
+		return 0, err
+	}
+
+	users := g.writer(ctx, db.Collection("users"))
+	profiles := g.writer(ctx, db.Collection("profiles"))
+	userRoles := g.writer(ctx, db.Collection("user_roles"))
+	for i := 0; i < n; i++ {
+		user, profile := g.user(firstID+i, firstProfileID+i, passwordHash)
+		if err := users.add(user); err != nil {
+			return 0, err
+		}
+		if err := profiles.add(profile); err != nil {
+			return 0, err
+		}
+		if err := userRoles.add(bson.M{"user_id": user.ID, "role_id": 2}); err != nil {
+			return 0, err
+		}
+	}
+	for _, w := range []*batchWriter{users, profiles, userRoles} {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	return users.inserted, nil
+}
+
+// generateProducts inserts n products into the leaf categories and returns how many were inserted
+func (g *generator) generateProducts(ctx context.Context, db *mongo.Database, n int) (int, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	leaves, err := leafCategories(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if len(leaves) == 0 {
+		return 0, fmt.Errorf("no categories to put products in")
+	}
+
+	firstID, err := nextID(ctx, db.Collection("products"))
+	if err != nil {
+		return 0, err
+	}
+
+	products := g.writer(ctx, db.Collection("products"))
+	for i := 0; i < n; i++ {
+		if err := products.add(g.product(firstID+i, leaves)); err != nil {
+			return 0, err
+		}
+	}
+	if err := products.flush(); err != nil {
+		return 0, err
+	}
+
+	return products.inserted, nil
+}
+
+// leafCategories returns the categories without subcategories, sorted by ID
+func leafCategories(ctx context.Context, db *mongo.Database) ([]*domain.Category, error) {
+	cursor, err := db.Collection("categories").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("find categories: %w", err)
+	}
+	var categories []*domain.Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, fmt.Errorf("decode categories: %w", err)
+	}
+
+	parents := make(map[int]bool)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			parents[*category.ParentID] = true
+		}
+	}
+	var leaves []*domain.Category
+	for _, category := range categories {
+		if !parents[category.ID] {
+			leaves = append(leaves, category)
+		}
+	}
+	return leaves, nil
+}
+
+// interactionCounts is how many interactions of each kind to generate
+type interactionCounts struct {
+	Views, Likes, Purchases int
+}
+
+// tastes groups users into clusters that share a taste for the products of some categories, so
+// collaborative filtering finds similar users and recommends what the rest of the cluster likes
+type tastes struct {
+	Clusters int     // 0 for no shared tastes
+	Affinity float64 // share of a user's interactions with the products of their cluster
+}
+
+// tasteClusters assigns every product and user to one of the clusters. Products of a category
+// share a cluster unless there are fewer categories than clusters. It returns the cluster of
+// each user and the products of each cluster, as indexes.
+func (g *generator) tasteClusters(clusters, users int, productCategories []int) ([]int, [][]int) {
+	categories := make(map[int]int)
+	for _, categoryID := range productCategories {
+		if _, ok := categories[categoryID]; !ok {
+			categories[categoryID] = len(categories)
+		}
+	}
+	clusterProducts := make([][]int, clusters)
+	for i, categoryID := range productCategories {
+		cluster := i % clusters
+		if len(categories) >= clusters {
+			cluster = categories[categoryID] % clusters
+		}
+		clusterProducts[cluster] = append(clusterProducts[cluster], i)
+	}
+
+	userClusters := make([]int, users)
+	for i := range userClusters {
+		userClusters[i] = g.rng.Intn(clusters)
+	}
+	return userClusters, clusterProducts
+}
+
+// generateInteractions inserts views, likes and purchases between the existing users and active
+// products. Both how active a user is and how popular a product is follow a power law, so a few
+// users and products account for most of the interactions, as on a real storefront. With taste
+// clusters, users mostly interact with the products of their cluster.
+func (g *generator) generateInteractions(ctx context.Context, db *mongo.Database, counts interactionCounts, taste tastes) (interactionCounts, error) {
+	var inserted interactionCounts
+	if counts.Views+counts.Likes+counts.Purchases == 0 {
+		return inserted, nil
+	}
+
+	userIDs, err := db.Collection("users").Distinct(ctx, "_id", bson.M{"status": "active"})
+	if err != nil {
+		return inserted, fmt.Errorf("find users: %w", err)
+	}
+	cursor, err := db.Collection("products").Find(ctx, bson.M{"is_active": true},
+		options.Find().SetSort(bson.M{"_id": 1}).SetProjection(bson.M{"_id": 1, "name": 1, "image_url": 1, "price": 1, "category_id": 1}))
+	if err != nil {
+		return inserted, fmt.Errorf("find products: %w", err)
+	}
+	var products []struct {
+		ID         int          `bson:"_id"`
+		Name       string       `bson:"name"`
+		ImageURL   string       `bson:"image_url"`
+		Price      domain.Money `bson:"price"`
+		CategoryID int          `bson:"category_id"`
+	}
+	if err := cursor.All(ctx, &products); err != nil {
+		return inserted, fmt.Errorf("decode products: %w", err)
+	}
+
+	// Purchases keep the product's category name as it was bought
+	cursor, err = db.Collection("categories").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "name": 1}))
+	if err != nil {
+		return inserted, fmt.Errorf("find categories: %w", err)
+	}
+	var categories []domain.Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return inserted, fmt.Errorf("decode categories: %w", err)
+	}
+	categoryNames := make(map[int]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+	if len(userIDs) == 0 || len(products) == 0 {
+		return inserted, fmt.Errorf("interactions need at least one active user and product")
+	}
+
+	users := make([]int, 0, len(userIDs))
+	for _, value := range userIDs {
+		switch id := value.(type) {
+		case int32:
+			users = append(users, int(id))
+		case int64:
+			users = append(users, int(id))
+		}
+	}
+	// Distinct does not guarantee an order, and the same seed must give the same data
+	sort.Ints(users)
+	nextUser := g.powerLaw(len(users))
+	nextProduct := g.powerLaw(len(products))
+
+	// A user's product comes from their cluster with the affinity, and from all products otherwise
+	productFor := func(int) int { return nextProduct() }
+	if taste.Clusters > 0 {
+		productCategories := make([]int, len(products))
+		for i, product := range products {
+			productCategories[i] = product.CategoryID
+		}
+		userClusters, clusterProducts := g.tasteClusters(taste.Clusters, len(users), productCategories)
+		nextInCluster := make([]func() int, len(clusterProducts))
+		for cluster, indexes := range clusterProducts {
+			if len(indexes) > 0 {
+				nextInCluster[cluster] = g.powerLaw(len(indexes))
+			}
+		}
+		productFor = func(user int) int {
+			cluster := userClusters[user]
+			if nextInCluster[cluster] == nil || g.rng.Float64() >= taste.Affinity {
+				return nextProduct()
+			}
+			return clusterProducts[cluster][nextInCluster[cluster]()]
+		}
+	}
+
+	views := g.writer(ctx, db.Collection("user_product_views"))
+	for i := 0; i < counts.Views; i++ {
+		user := nextUser()
+		err := views.add(domain.UserProductView{
+			UserID:    users[user],
+			ProductID: products[productFor(user)].ID,
+			ViewedAt:  g.timeInWindow(),
+		})
+		if err != nil {
+			return inserted, err
+		}
+	}
+	if err := views.flush(); err != nil {
+		return inserted, err
+	}
+	inserted.Views = views.inserted
+
+	// A user likes a product at most once; give up on pairs after enough collisions
+	likes := g.writer(ctx, db.Collection("user_product_likes"))
+	liked := make(map[[2]int]bool, counts.Likes)
+	for attempts := 0; len(liked) < counts.Likes && attempts < counts.Likes*10; attempts++ {
+		user := nextUser()
+		pair := [2]int{users[user], products[productFor(user)].ID}
+		if liked[pair] {
+			continue
+		}
+		liked[pair] = true
+		err := likes.add(domain.UserProductLike{UserID: pair[0], ProductID: pair[1], LikedAt: g.timeInWindow()})
+		if err != nil {
+			return inserted, err
+		}
+	}
+	if err := likes.flush(); err != nil {
+		return inserted, err
+	}
+	inserted.Likes = likes.inserted
+
+	purchases := g.writer(ctx, db.Collection("user_product_purchases"))
+	for i := 0; i < counts.Purchases; i++ {
+		user := nextUser()
+		product := products[productFor(user)]
+		purchase := domain.UserProductPurchase{
+			UserID:          users[user],
+			ProductID:       product.ID,
+			Quantity:        g.quantity(),
+			PriceAtPurchase: product.Price,
+			PurchasedAt:     g.timeInWindow(),
+		}
+		snapshot := &domain.ProductWithCategory{Name: product.Name, ImageURL: product.ImageURL}
+		if product.CategoryID != 0 {
+			snapshot.CategoryID = &product.CategoryID
+			snapshot.CategoryName = categoryNames[product.CategoryID]
+		}
+		purchase.SnapshotProduct(snapshot)
+		if err := purchases.add(purchase); err != nil {
+			return inserted, err
+		}
+	}
+	if err := purchases.flush(); err != nil {
+		return inserted, err
+	}
+	inserted.Purchases = purchases.inserted
+
+	return inserted, nil
+}
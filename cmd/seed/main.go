@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// seedParts are what can be seeded on its own with -only, in the order they are seeded
+var seedParts = []string{"users", "categories", "products", "interactions"}
+
+// partCollections are the collections of each part, dropped before it is seeded unless -append
+// is given
+var partCollections = map[string][]string{
+	"users": {
+		"users", "roles", "user_roles", "permissions", "role_permissions", "profiles", "profile_changes", "sessions",
+		"email_change_requests", "phone_verifications",
+	},
+	"categories": {"categories"},
+	"products":   {"products"},
+	"interactions": {
+		"user_product_views", "user_product_likes", "user_product_purchases", "recommendation_feedback",
+		"recommendation_clicks", "user_factors", "item_factors", "orders", "order_items",
+	},
+}
+
+// Seeds MongoDB with the demo accounts and catalog plus generated users, products and interactions.
+// Example: go run ./cmd/seed -users 1000 -products 500 -views 200000 -seed 7
+func main() {
+	usersFlag := flag.Int("users", 50, "number of generated users, each with a profile")
+	productsFlag := flag.Int("products", 100, "number of generated products")
+	viewsFlag := flag.Int("views", 5000, "number of generated product views")
+	likesFlag := flag.Int("likes", 500, "number of generated likes")
+	purchasesFlag := flag.Int("purchases", 300, "number of generated purchases")
+	seedFlag := flag.Int64("seed", 1, "random seed; the same seed generates the same data")
+	daysFlag := flag.Int("days", 90, "spread generated timestamps over this many past days")
+	skewFlag := flag.Float64("skew", 1.2, "power-law exponent of user activity and product popularity, greater than 1")
+	clustersFlag := flag.Int("clusters", 4, "number of user clusters sharing a taste for some categories; 0 for none")
+	affinityFlag := flag.Float64("affinity", 0.8, "share of a clustered user's interactions with the products of their cluster")
+	batchFlag := flag.Int("batch", 1000, "number of documents per insert")
+	appendFlag := flag.Bool("append", false, "keep existing data and add the generated data to it")
+	noDropFlag := flag.Bool("no-drop", false, "deprecated, same as -append")
+	onlyFlag := flag.String("only", "", "comma-separated parts to seed: users, categories, products, interactions; all if empty")
+	dryRunFlag := flag.Bool("dry-run", false, "log what would be dropped and inserted without writing anything")
+	tenantFlag := flag.String("tenant", "", "tenant the data is seeded for when tenancy is enabled; defaults to tenancy.default")
+	flag.Parse()
+
+	if *usersFlag < 0 || *productsFlag < 0 || *viewsFlag < 0 || *likesFlag < 0 || *purchasesFlag < 0 {
+		log.Fatal("counts must not be negative")
+	}
+	if *daysFlag < 1 || *batchFlag < 1 {
+		log.Fatal("-days and -batch must be positive")
+	}
+	if *skewFlag <= 1 {
+		log.Fatal("-skew must be greater than 1")
+	}
+	if *clustersFlag < 0 {
+		log.Fatal("-clusters must not be negative")
+	}
+	if *affinityFlag < 0 || *affinityFlag > 1 {
+		log.Fatal("-affinity must be between 0 and 1")
+	}
+	parts, err := parseParts(*onlyFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	drop := !*appendFlag && !*noDropFlag
+	dryRun := *dryRunFlag
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+
+	tenantID := *tenantFlag
+	if tenantID == "" {
+		tenantID = cfg.Tenancy.Default
+	}
+	if cfg.Tenancy.Enabled && cfg.Tenancy.Tenant(tenantID) == nil {
+		log.Fatalf("unknown tenant %q; pass -tenant", tenantID)
+	}
+
+	currency := cfg.Payment.Currency
+	if cfg.Tenancy.Enabled {
+		currency = cfg.Tenancy.Tenant(tenantID).Currency
+	}
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+	defer db.Close(context.Background())
+
+	seedLogger := appLogger.WithComponent("seed")
+	if dryRun {
+		seedLogger = seedLogger.WithFields(logger.Fields{"dry_run": true})
+	}
+	start := time.Now()
+
+	// Indexes are recreated by the application on its next start. The counters are shared by
+	// every part, so they are only dropped with all of them.
+	dropped := make(map[string]bool)
+	if drop {
+		var collections []string
+		for _, part := range seedParts {
+			if parts[part] {
+				collections = append(collections, partCollections[part]...)
+			}
+		}
+		if len(parts) == len(seedParts) {
+			collections = append(collections, "counters")
+		}
+		for _, name := range collections {
+			dropped[name] = true
+			if dryRun {
+				seedLogger.WithFields(logger.Fields{"collection": name}).Info("Would drop collection")
+				continue
+			}
+			if err := db.Database.Collection(name).Drop(ctx); err != nil {
+				seedLogger.WithError(err).WithFields(logger.Fields{"collection": name}).Fatal("Failed to drop collection")
+			}
+		}
+		if !dryRun {
+			seedLogger.WithFields(logger.Fields{"collections": len(collections)}).Info("Dropped existing data")
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	if err != nil {
+		seedLogger.WithError(err).Fatal("Failed to hash password")
+	}
+	passwordHash := string(hash)
+
+	// The fixtures have fixed IDs, so each kind is only inserted into an empty collection
+	fixtures := []struct {
+		part, collection, name string
+		seed                   func() error
+	}{
+		{"users", "roles", "roles, permissions and demo accounts", func() error { return seedAccounts(ctx, db.Database, passwordHash) }},
+		{"categories", "categories", "demo categories", func() error { return seedCategories(ctx, db.Database) }},
+		{"products", "products", "demo products", func() error { return seedCatalog(ctx, db.Database, currency) }},
+	}
+	for _, fixture := range fixtures {
+		if !parts[fixture.part] {
+			continue
+		}
+		fields := logger.Fields{"collection": fixture.collection}
+		if !dropped[fixture.collection] {
+			existing, err := db.Collection(fixture.collection).CountDocuments(ctx, bson.M{})
+			if err != nil {
+				seedLogger.WithError(err).WithFields(fields).Fatal("Failed to count existing documents")
+			}
+			if existing > 0 {
+				seedLogger.WithFields(fields).Info("Collection not empty, skipping " + fixture.name)
+				continue
+			}
+		}
+		if dryRun {
+			seedLogger.WithFields(fields).Info("Would insert " + fixture.name)
+			continue
+		}
+		if err := fixture.seed(); err != nil {
+			seedLogger.WithError(err).WithFields(fields).Fatal("Failed to seed " + fixture.name)
+		}
+		seedLogger.WithFields(fields).Info("Seeded " + fixture.name)
+	}
+
+	counts := interactionCounts{Views: *viewsFlag, Likes: *likesFlag, Purchases: *purchasesFlag}
+	if dryRun {
+		planned := logger.Fields{}
+		if parts["users"] {
+			planned["users"] = *usersFlag
+		}
+		if parts["products"] {
+			planned["products"] = *productsFlag
+		}
+		if parts["interactions"] {
+			planned["views"] = counts.Views
+			planned["likes"] = counts.Likes
+			planned["purchases"] = counts.Purchases
+			planned["clusters"] = *clustersFlag
+		}
+		seedLogger.WithFields(planned).Info("Would generate data; nothing was written")
+		return
+	}
+
+	gen := &generator{
+		rng:      rand.New(rand.NewSource(*seedFlag)),
+		now:      time.Now().UTC(),
+		days:     *daysFlag,
+		skew:     *skewFlag,
+		batch:    *batchFlag,
+		currency: currency,
+	}
+
+	var users, products int
+	var interactions interactionCounts
+	if parts["users"] {
+		if users, err = gen.generateUsers(ctx, db.Database, *usersFlag, passwordHash); err != nil {
+			seedLogger.WithError(err).Fatal("Failed to generate users")
+		}
+	}
+	if parts["products"] {
+		if products, err = gen.generateProducts(ctx, db.Database, *productsFlag); err != nil {
+			seedLogger.WithError(err).Fatal("Failed to generate products")
+		}
+	}
+	if parts["interactions"] {
+		interactions, err = gen.generateInteractions(ctx, db.Database, counts, tastes{Clusters: *clustersFlag, Affinity: *affinityFlag})
+		if err != nil {
+			seedLogger.WithError(err).Fatal("Failed to generate interactions")
+		}
+	}
+
+	// Seeded documents are written without a tenant, so they are assigned to one afterwards
+	if cfg.Tenancy.Enabled {
+		if _, err := db.AssignTenant(ctx, tenantID); err != nil {
+			seedLogger.WithError(err).Fatal("Failed to assign seeded data to tenant")
+		}
+	}
+
+	seedLogger.
+		WithDuration(time.Since(start)).
+		WithFields(logger.Fields{
+			"users":     users,
+			"products":  products,
+			"views":     interactions.Views,
+			"likes":     interactions.Likes,
+			"purchases": interactions.Purchases,
+			"clusters":  *clustersFlag,
+			"seed":      *seedFlag,
+		}).
+		Info("Database seeded; every account's password is password123")
+}
+
+// parseParts returns the parts named in only, or all of them if it is empty
+func parseParts(only string) (map[string]bool, error) {
+	parts := make(map[string]bool, len(seedParts))
+	if strings.TrimSpace(only) == "" {
+		for _, part := range seedParts {
+			parts[part] = true
+		}
+		return parts, nil
+	}
+
+	for _, part := range strings.Split(only, ",") {
+		part = strings.TrimSpace(part)
+		if _, ok := partCollections[part]; !ok {
+			return nil, fmt.Errorf("unknown part %q in -only; use %s", part, strings.Join(seedParts, ", "))
+		}
+		parts[part] = true
+	}
+	return parts, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Assigns the documents without a tenant to a tenant, for enabling tenancy on an existing database.
+// Example: go run cmd/tenant/main.go -assign default
+func main() {
+	assignFlag := flag.String("assign", "", "tenant the documents without a tenant are assigned to")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if !cfg.Tenancy.Enabled {
+		log.Fatal("tenancy is not enabled")
+	}
+	if cfg.Tenancy.Tenant(*assignFlag) == nil {
+		log.Fatalf("unknown tenant %q", *assignFlag)
+	}
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+	defer db.Close(context.Background())
+
+	assigned, err := db.AssignTenant(ctx, *assignFlag)
+	if err != nil {
+		appLogger.WithComponent("tenant").WithError(err).Fatal("Failed to assign documents")
+	}
+
+	appLogger.WithComponent("tenant").WithFields(logger.Fields{
+		"tenant":    *assignFlag,
+		"documents": assigned,
+	}).Info("Assigned documents without a tenant")
+}
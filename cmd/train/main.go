@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Offline training job for the matrix factorization recommender.
+// Run it periodically (e.g. from cron) when recommendation.algorithm is matrix_factorization.
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// Offline jobs scan whole collections, so the request-sized timeouts do not apply
+	cfg.Mongo.QueryTimeout = -1
+	cfg.Mongo.AggregateTimeout = -1
+
+	appLogger, err := logger.New(&cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize logger: %v", err)
+	}
+	defer appLogger.Close()
+
+	db, err := mongodb.New(ctx, &cfg.Mongo)
+	if err != nil {
+		appLogger.WithComponent("database").WithError(err).Fatal("Failed to initialize MongoDB connection")
+	}
+	defer db.Close(context.Background())
+
+	services := service.NewServices(service.Deps{
+		Repos:  repository.NewRepositories(db, cfg),
+		Config: cfg,
+	})
+
+	appLogger.WithComponent("train").WithFields(logger.Fields{
+		"factors":        cfg.Recommendation.ALS.Factors,
+		"iterations":     cfg.Recommendation.ALS.Iterations,
+		"regularization": cfg.Recommendation.ALS.Regularization,
+		"alpha":          cfg.Recommendation.ALS.Alpha,
+	}).Info("Training matrix factorization model")
+
+	start := time.Now()
+	stats, err := services.RecommendationService.TrainFactorModel(ctx)
+	if err != nil {
+		appLogger.WithComponent("train").WithError(err).Fatal("Failed to train model")
+	}
+
+	appLogger.WithComponent("train").
+		WithDuration(time.Since(start)).
+		WithFields(logger.Fields{
+			"users":        stats.Users,
+			"items":        stats.Items,
+			"interactions": stats.Interactions,
+		}).
+		Info("Model trained")
+}
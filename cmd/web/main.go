@@ -6,12 +6,17 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/PrimeraAizen/e-comm/config"
 	"github.com/PrimeraAizen/e-comm/internal/app"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/reporting"
 	"github.com/PrimeraAizen/e-comm/pkg/logger"
 )
 
+// reportFlushTimeout bounds waiting for queued error reports on exit
+const reportFlushTimeout = 2 * time.Second
+
 // @title E-Commerce API
 // @version 1.0
 // @description E-Commerce API with MongoDB, JWT Authentication, Product Catalog, User Interactions, and Recommendation System
@@ -41,6 +46,14 @@ func main() {
 	// Set as global logger
 	appLogger.SetGlobal()
 
+	// Send logged errors and recovered panics to the error tracker
+	reporter, err := reporting.New(&cfg.ErrorReporting, &cfg.Logger)
+	if err != nil {
+		log.Fatalf("failed to initialize error reporting: %v", err)
+	}
+	logger.SetHook(reporter.Report)
+	defer reporter.Flush(reportFlushTimeout)
+
 	// Log application startup
 	appLogger.WithFields(logger.Fields{
 		"service":     cfg.Logger.Service,
@@ -49,6 +62,8 @@ func main() {
 	}).Info("Application starting")
 
 	if err := app.StartWebServer(ctx, cfg, appLogger); err != nil {
-		appLogger.WithError(err).Fatal("Failed to start web server")
+		appLogger.WithError(err).Error("Failed to start web server")
+		reporter.Flush(reportFlushTimeout)
+		os.Exit(1)
 	}
 }
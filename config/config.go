@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -24,6 +26,41 @@ type Config struct {
 	Mongo  MongoDB       `mapstructure:"mongodb"`
 	Logger logger.Config `mapstructure:"logger"`
 	JWT    JWT           `mapstructure:"jwt"`
+	Roles  Roles         `mapstructure:"roles"`
+
+	Password    Password    `mapstructure:"password"`
+	Mail        Mail        `mapstructure:"mail"`
+	EmailChange EmailChange `mapstructure:"email_change"`
+
+	SMS               SMS               `mapstructure:"sms"`
+	PhoneVerification PhoneVerification `mapstructure:"phone_verification"`
+
+	Recommendation Recommendation `mapstructure:"recommendation"`
+	Feed           Feed           `mapstructure:"feed"`
+	Realtime       Realtime       `mapstructure:"realtime"`
+	Search         Search         `mapstructure:"search"`
+	Listing        Listing        `mapstructure:"listing"`
+	Interactions   Interactions   `mapstructure:"interactions"`
+
+	Payment       Payment       `mapstructure:"payment"`
+	Subscriptions Subscriptions `mapstructure:"subscriptions"`
+	Carts         Carts         `mapstructure:"carts"`
+	Segments      Segments      `mapstructure:"segments"`
+	Promotions    Promotions    `mapstructure:"promotions"`
+	FlashSales    FlashSales    `mapstructure:"flash_sales"`
+	Reports       Reports       `mapstructure:"reports"`
+	Risk          Risk          `mapstructure:"risk"`
+	Moderation    Moderation    `mapstructure:"moderation"`
+	Tenancy       Tenancy       `mapstructure:"tenancy"`
+
+	Backup      Backup      `mapstructure:"backup"`
+	Media       Media       `mapstructure:"media"`
+	Maintenance Maintenance `mapstructure:"maintenance"`
+	Quotas      Quotas      `mapstructure:"quotas"`
+	Outbox      Outbox      `mapstructure:"outbox"`
+	Streaming   Streaming   `mapstructure:"streaming"`
+
+	ErrorReporting ErrorReporting `mapstructure:"error_reporting"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -64,6 +101,24 @@ func (cfg *Config) Validate() error {
 	if cfg.Http.Port == "" {
 		return fmt.Errorf("missing http port")
 	}
+	if cfg.Http.MaxBodySize == 0 {
+		cfg.Http.MaxBodySize = 1 << 20 // 1 MiB
+	}
+	if cfg.Http.MaxUploadSize == 0 {
+		cfg.Http.MaxUploadSize = 10 << 20 // 10 MiB
+	}
+	if cfg.Http.MaxUploadSize < cfg.Http.MaxBodySize {
+		return fmt.Errorf("http max_upload_size must not be less than max_body_size")
+	}
+	if cfg.Http.ProductCache.FreshFor == "" {
+		cfg.Http.ProductCache.FreshFor = "5s"
+	}
+	if cfg.Http.ProductCache.StaleFor == "" {
+		cfg.Http.ProductCache.StaleFor = "1m"
+	}
+	if cfg.Http.ProductCache.MaxEntries <= 0 {
+		cfg.Http.ProductCache.MaxEntries = 1000
+	}
 	if cfg.Mongo.URI == "" && (cfg.Mongo.Host == "" || cfg.Mongo.Port == "" || cfg.Mongo.Database == "") {
 		return fmt.Errorf("missing mongodb connection settings")
 	}
@@ -88,6 +143,43 @@ func (cfg *Config) Validate() error {
 	if cfg.Mongo.MaxConnIdleTime == 0 {
 		cfg.Mongo.MaxConnIdleTime = 60
 	}
+	if cfg.Mongo.QueryTimeout == 0 {
+		cfg.Mongo.QueryTimeout = 5
+	}
+	if cfg.Mongo.AggregateTimeout == 0 {
+		cfg.Mongo.AggregateTimeout = 30
+	}
+	if cfg.Mongo.Breaker.Window <= 0 {
+		cfg.Mongo.Breaker.Window = 10
+	}
+	if cfg.Mongo.Breaker.MinRequests <= 0 {
+		cfg.Mongo.Breaker.MinRequests = 20
+	}
+	if cfg.Mongo.Breaker.FailureRate == 0 {
+		cfg.Mongo.Breaker.FailureRate = 0.5
+	}
+	if cfg.Mongo.Breaker.FailureRate < 0 || cfg.Mongo.Breaker.FailureRate > 1 {
+		return fmt.Errorf("mongodb.breaker.failure_rate must be between 0 and 1")
+	}
+	if cfg.Mongo.Breaker.OpenFor <= 0 {
+		cfg.Mongo.Breaker.OpenFor = 5
+	}
+	switch cfg.Mongo.AnalyticsReadPreference {
+	case "":
+		cfg.Mongo.AnalyticsReadPreference = "primary"
+	case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+	default:
+		return fmt.Errorf("invalid analytics read preference %q", cfg.Mongo.AnalyticsReadPreference)
+	}
+	if cfg.Mongo.AnalyticsMaxStaleness != 0 {
+		// Staleness needs secondaries and the server rejects limits under 90 seconds
+		if cfg.Mongo.AnalyticsReadPreference == "primary" {
+			return fmt.Errorf("analytics max staleness requires a read preference other than primary")
+		}
+		if cfg.Mongo.AnalyticsMaxStaleness < 90 {
+			return fmt.Errorf("analytics max staleness must be at least 90 seconds")
+		}
+	}
 
 	// Set default logger config if not provided
 	if cfg.Logger.Level == "" {
@@ -109,9 +201,34 @@ func (cfg *Config) Validate() error {
 		cfg.Logger.Environment = "development"
 	}
 
+	// Error reporting config
+	if cfg.ErrorReporting.SampleRate == 0 {
+		cfg.ErrorReporting.SampleRate = 1
+	}
+	if cfg.ErrorReporting.SampleRate < 0 || cfg.ErrorReporting.SampleRate > 1 {
+		return fmt.Errorf("error_reporting.sample_rate must be between 0 and 1")
+	}
+	for environment, rate := range cfg.ErrorReporting.SampleRates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("error_reporting.sample_rates.%s must be between 0 and 1", environment)
+		}
+	}
+
 	// JWT config validation
-	if cfg.JWT.Secret == "" {
-		return fmt.Errorf("missing jwt secret")
+	switch cfg.JWT.Algorithm {
+	case "":
+		cfg.JWT.Algorithm = "HS256"
+		fallthrough
+	case "HS256":
+		if cfg.JWT.Secret == "" {
+			return fmt.Errorf("missing jwt secret")
+		}
+	case "RS256", "EdDSA":
+		if len(cfg.JWT.Keys) == 0 {
+			return fmt.Errorf("missing jwt keys for %s", cfg.JWT.Algorithm)
+		}
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %s", cfg.JWT.Algorithm)
 	}
 	if cfg.JWT.AccessTokenDuration == "" {
 		cfg.JWT.AccessTokenDuration = "15m"
@@ -119,6 +236,454 @@ func (cfg *Config) Validate() error {
 	if cfg.JWT.RefreshTokenDuration == "" {
 		cfg.JWT.RefreshTokenDuration = "168h"
 	}
+	if cfg.JWT.ReauthWindow == "" {
+		cfg.JWT.ReauthWindow = "5m"
+	}
+
+	// Password policy config
+	if cfg.Password.MinLength == 0 {
+		cfg.Password.MinLength = 8
+	}
+	if cfg.Password.MinLength < 8 {
+		return fmt.Errorf("password min_length must be at least 8")
+	}
+	if cfg.Password.BreachCheck.Timeout == "" {
+		cfg.Password.BreachCheck.Timeout = "2s"
+	}
+	if cfg.Password.BreachCheck.MinCount == 0 {
+		cfg.Password.BreachCheck.MinCount = 1
+	}
+
+	// Mail config
+	switch cfg.Mail.Provider {
+	case "":
+		cfg.Mail.Provider = "log"
+	case "log":
+	case "smtp":
+		if cfg.Mail.SMTP.Host == "" {
+			return fmt.Errorf("missing smtp host")
+		}
+		if cfg.Mail.SMTP.Port == "" {
+			cfg.Mail.SMTP.Port = "587"
+		}
+	default:
+		return fmt.Errorf("unknown mail provider: %s", cfg.Mail.Provider)
+	}
+	if cfg.Mail.From == "" {
+		cfg.Mail.From = "no-reply@localhost"
+	}
+
+	// Email change config
+	if cfg.EmailChange.ConfirmURL == "" {
+		cfg.EmailChange.ConfirmURL = "http://localhost:3000/confirm-email"
+	}
+	if cfg.EmailChange.TokenTTL == "" {
+		cfg.EmailChange.TokenTTL = "24h"
+	}
+
+	// SMS config
+	switch cfg.SMS.Provider {
+	case "":
+		cfg.SMS.Provider = "noop"
+	case "noop":
+	case "twilio":
+		if cfg.SMS.Twilio.AccountSID == "" || cfg.SMS.Twilio.AuthToken == "" || cfg.SMS.Twilio.From == "" {
+			return fmt.Errorf("missing twilio settings")
+		}
+	default:
+		return fmt.Errorf("unknown sms provider: %s", cfg.SMS.Provider)
+	}
+
+	// Phone verification config
+	if cfg.PhoneVerification.CodeTTL == "" {
+		cfg.PhoneVerification.CodeTTL = "10m"
+	}
+	if cfg.PhoneVerification.ResendInterval == "" {
+		cfg.PhoneVerification.ResendInterval = "60s"
+	}
+	if cfg.PhoneVerification.MaxAttempts == 0 {
+		cfg.PhoneVerification.MaxAttempts = 5
+	}
+
+	// Recommendation config
+	switch cfg.Recommendation.Algorithm {
+	case "":
+		cfg.Recommendation.Algorithm = "collaborative_filtering"
+	case "collaborative_filtering", "matrix_factorization":
+	default:
+		return fmt.Errorf("unknown recommendation algorithm: %s", cfg.Recommendation.Algorithm)
+	}
+	if cfg.Recommendation.ALS.Factors == 0 {
+		cfg.Recommendation.ALS.Factors = 20
+	}
+	if cfg.Recommendation.ALS.Iterations == 0 {
+		cfg.Recommendation.ALS.Iterations = 15
+	}
+	if cfg.Recommendation.ALS.Regularization == 0 {
+		cfg.Recommendation.ALS.Regularization = 0.1
+	}
+	if cfg.Recommendation.ALS.Alpha == 0 {
+		cfg.Recommendation.ALS.Alpha = 40
+	}
+	if cfg.Recommendation.Engagement.DeepDwellSeconds == 0 {
+		cfg.Recommendation.Engagement.DeepDwellSeconds = 30
+	}
+	if cfg.Recommendation.Engagement.DeepScrollDepth == 0 {
+		cfg.Recommendation.Engagement.DeepScrollDepth = 75
+	}
+	if cfg.Recommendation.Engagement.DeepViewWeight == 0 {
+		cfg.Recommendation.Engagement.DeepViewWeight = 3
+	}
+	if cfg.Recommendation.Engagement.DeepDwellSeconds < 0 {
+		return fmt.Errorf("recommendation.engagement.deep_dwell_seconds must be positive")
+	}
+	if cfg.Recommendation.Engagement.DeepScrollDepth < 0 || cfg.Recommendation.Engagement.DeepScrollDepth > 100 {
+		return fmt.Errorf("recommendation.engagement.deep_scroll_depth must be between 1 and 100")
+	}
+	if cfg.Recommendation.Engagement.DeepViewWeight < 1 {
+		return fmt.Errorf("recommendation.engagement.deep_view_weight must be at least 1")
+	}
+	if cfg.Recommendation.CacheTTL == "" {
+		cfg.Recommendation.CacheTTL = "5m"
+	}
+	if cfg.Recommendation.PostPurchase.Window == "" {
+		cfg.Recommendation.PostPurchase.Window = "168h"
+	}
+	if cfg.Recommendation.PostPurchase.Accessories == 0 {
+		cfg.Recommendation.PostPurchase.Accessories = 3
+	}
+	if cfg.Recommendation.PostPurchase.Accessories < 0 {
+		return fmt.Errorf("recommendation.post_purchase.accessories must be positive")
+	}
+	if cfg.Recommendation.BoughtTogether.RefreshInterval == "" {
+		cfg.Recommendation.BoughtTogether.RefreshInterval = "1h"
+	}
+	if cfg.Recommendation.BoughtTogether.MinBuyers <= 0 {
+		cfg.Recommendation.BoughtTogether.MinBuyers = 2
+	}
+	if cfg.Recommendation.BoughtTogether.MaxItems <= 0 {
+		cfg.Recommendation.BoughtTogether.MaxItems = 20
+	}
+
+	// Feed config
+	if cfg.Feed.SectionSize <= 0 {
+		cfg.Feed.SectionSize = 10
+	}
+	if cfg.Feed.TrendingWindow == "" {
+		cfg.Feed.TrendingWindow = "72h"
+	}
+	if cfg.Feed.NewProductsWindow == "" {
+		cfg.Feed.NewProductsWindow = "720h"
+	}
+	if cfg.Feed.PriceDropWindow == "" {
+		cfg.Feed.PriceDropWindow = "720h"
+	}
+
+	// Realtime config
+	switch cfg.Realtime.Source {
+	case "":
+		cfg.Realtime.Source = "local"
+	case "local", "change_stream":
+	default:
+		return fmt.Errorf("unknown realtime source: %s", cfg.Realtime.Source)
+	}
+	if cfg.Realtime.Heartbeat == "" {
+		cfg.Realtime.Heartbeat = "15s"
+	}
+	if cfg.Realtime.MetricsInterval == "" {
+		cfg.Realtime.MetricsInterval = "5s"
+	}
+
+	// Search config
+	switch cfg.Search.Provider {
+	case "":
+		cfg.Search.Provider = "mongo"
+	case "mongo":
+	case "elasticsearch":
+		if cfg.Search.Elasticsearch.URL == "" {
+			return fmt.Errorf("missing elasticsearch url")
+		}
+	default:
+		return fmt.Errorf("unknown search provider: %s", cfg.Search.Provider)
+	}
+	if cfg.Search.Elasticsearch.Index == "" {
+		cfg.Search.Elasticsearch.Index = "products"
+	}
+	if cfg.Search.Elasticsearch.Timeout == "" {
+		cfg.Search.Elasticsearch.Timeout = "5s"
+	}
+	if cfg.Search.Elasticsearch.ReindexInterval == "" {
+		cfg.Search.Elasticsearch.ReindexInterval = "1h"
+	}
+	if cfg.Search.Elasticsearch.PopularityBoost == 0 {
+		cfg.Search.Elasticsearch.PopularityBoost = 1
+	}
+
+	// Listing config
+	if err := cfg.validateListing(); err != nil {
+		return err
+	}
+
+	// Payment config
+	switch cfg.Payment.Provider {
+	case "":
+		cfg.Payment.Provider = "noop"
+	case "noop":
+	default:
+		return fmt.Errorf("unknown payment provider: %s", cfg.Payment.Provider)
+	}
+	if cfg.Payment.Currency == "" {
+		cfg.Payment.Currency = "USD"
+	}
+
+	// Subscriptions config
+	if cfg.Subscriptions.CheckInterval == "" {
+		cfg.Subscriptions.CheckInterval = "1m"
+	}
+	if cfg.Subscriptions.RetryDelay == "" {
+		cfg.Subscriptions.RetryDelay = "24h"
+	}
+	if cfg.Subscriptions.MaxAttempts == 0 {
+		cfg.Subscriptions.MaxAttempts = 3
+	}
+
+	// Interactions config
+	if cfg.Interactions.BatchSize <= 0 {
+		cfg.Interactions.BatchSize = 500
+	}
+	if cfg.Interactions.FlushInterval <= 0 {
+		cfg.Interactions.FlushInterval = 1000
+	}
+	if cfg.Interactions.BufferSize < cfg.Interactions.BatchSize {
+		cfg.Interactions.BufferSize = 10 * cfg.Interactions.BatchSize
+	}
+
+	// Carts config
+	if cfg.Carts.AbandonAfter == "" {
+		cfg.Carts.AbandonAfter = "24h"
+	}
+	if cfg.Carts.CheckInterval == "" {
+		cfg.Carts.CheckInterval = "15m"
+	}
+	if cfg.Carts.RecoveryURL == "" {
+		cfg.Carts.RecoveryURL = "http://localhost:3000/cart"
+	}
+	if cfg.Carts.RecoveryWindow == "" {
+		cfg.Carts.RecoveryWindow = "168h"
+	}
+
+	// Segments config
+	if cfg.Segments.EvaluateInterval == "" {
+		cfg.Segments.EvaluateInterval = "1h"
+	}
+	if cfg.Segments.NotifyBatchSize <= 0 {
+		cfg.Segments.NotifyBatchSize = 500
+	}
+
+	// Promotions config
+	if cfg.Promotions.CheckInterval == "" {
+		cfg.Promotions.CheckInterval = "1h"
+	}
+	if cfg.Promotions.Timezone == "" {
+		cfg.Promotions.Timezone = "UTC"
+	}
+
+	// Flash sales config
+	if cfg.FlashSales.SigningSecret != "" && len(cfg.FlashSales.SigningSecret) < 32 {
+		return fmt.Errorf("flash_sales signing_secret must be at least 32 characters")
+	}
+	if cfg.FlashSales.AdmitPerSecond <= 0 {
+		cfg.FlashSales.AdmitPerSecond = 50
+	}
+	if cfg.FlashSales.TokenTTL == "" {
+		cfg.FlashSales.TokenTTL = "5m"
+	}
+
+	// Reports config
+	if cfg.Reports.Timezone == "" {
+		cfg.Reports.Timezone = "UTC"
+	}
+	if cfg.Reports.CheckInterval == "" {
+		cfg.Reports.CheckInterval = "5m"
+	}
+	for name, schedule := range map[string]*ReportSchedule{
+		"daily_sales":         &cfg.Reports.DailySales,
+		"weekly_top_products": &cfg.Reports.WeeklyTopProducts,
+		"low_stock":           &cfg.Reports.LowStock,
+	} {
+		if schedule.At == "" {
+			schedule.At = "07:00"
+		}
+		if !reportTimePattern.MatchString(schedule.At) {
+			return fmt.Errorf("reports.%s.at must be a time of day (HH:MM)", name)
+		}
+	}
+	if cfg.Reports.WeeklyTopProducts.Weekday == "" {
+		cfg.Reports.WeeklyTopProducts.Weekday = "monday"
+	}
+	if cfg.Reports.TopProductsLimit <= 0 {
+		cfg.Reports.TopProductsLimit = 20
+	}
+	if cfg.Reports.LowStockThreshold <= 0 {
+		cfg.Reports.LowStockThreshold = 5
+	}
+	if cfg.Reports.DeadStockDays <= 0 {
+		cfg.Reports.DeadStockDays = 90
+	}
+	if cfg.Reports.DeadStockLimit <= 0 {
+		cfg.Reports.DeadStockLimit = 100
+	}
+
+	// Risk config
+	if cfg.Risk.ReviewScore == 0 {
+		cfg.Risk.ReviewScore = 50
+	}
+	if cfg.Risk.CountryHeader == "" {
+		cfg.Risk.CountryHeader = "CF-IPCountry"
+	}
+	if cfg.Risk.Velocity.Window == "" {
+		cfg.Risk.Velocity.Window = "1h"
+	}
+	if cfg.Risk.Velocity.MaxPurchases == 0 {
+		cfg.Risk.Velocity.MaxPurchases = 5
+	}
+	if cfg.Risk.Velocity.Score == 0 {
+		cfg.Risk.Velocity.Score = 50
+	}
+	if cfg.Risk.LargeQuantity.Quantity == 0 {
+		cfg.Risk.LargeQuantity.Quantity = 20
+	}
+	if cfg.Risk.LargeQuantity.Score == 0 {
+		cfg.Risk.LargeQuantity.Score = 30
+	}
+	if cfg.Risk.CountryMismatchScore == 0 {
+		cfg.Risk.CountryMismatchScore = 30
+	}
+
+	// Moderation config
+	switch cfg.Moderation.Provider {
+	case "":
+		cfg.Moderation.Provider = "none"
+	case "none":
+	case "webhook":
+		if cfg.Moderation.Webhook.URL == "" {
+			return fmt.Errorf("moderation webhook url is required")
+		}
+		if cfg.Moderation.Webhook.Timeout == "" {
+			cfg.Moderation.Webhook.Timeout = "3s"
+		}
+	default:
+		return fmt.Errorf("unknown moderation provider: %s", cfg.Moderation.Provider)
+	}
+
+	// Tenancy config
+	if err := cfg.validateTenancy(); err != nil {
+		return err
+	}
+
+	// Roles config
+	if cfg.Roles.RefreshInterval == "" {
+		cfg.Roles.RefreshInterval = "30s"
+	}
+
+	// Maintenance config
+	if cfg.Maintenance.RetryAfter <= 0 {
+		cfg.Maintenance.RetryAfter = 300
+	}
+	if cfg.Maintenance.RefreshInterval == "" {
+		cfg.Maintenance.RefreshInterval = "5s"
+	}
+
+	// Quotas config
+	for role, limit := range cfg.Quotas.Roles {
+		if limit <= 0 {
+			return fmt.Errorf("quotas.roles.%s must be positive", role)
+		}
+	}
+	if cfg.Quotas.FlushInterval == "" {
+		cfg.Quotas.FlushInterval = "10s"
+	}
+
+	// Outbox config
+	if cfg.Outbox.PollInterval == "" {
+		cfg.Outbox.PollInterval = "1s"
+	}
+	if cfg.Outbox.BatchSize <= 0 {
+		cfg.Outbox.BatchSize = 100
+	}
+	if cfg.Outbox.MaxAttempts <= 0 {
+		cfg.Outbox.MaxAttempts = 10
+	}
+	if cfg.Outbox.Retention == "" {
+		cfg.Outbox.Retention = "168h"
+	}
+
+	// Streaming config
+	switch cfg.Streaming.Provider {
+	case "":
+		cfg.Streaming.Provider = "noop"
+	case "noop":
+	case "kafka":
+		if len(cfg.Streaming.Kafka.Brokers) == 0 {
+			return fmt.Errorf("streaming.kafka.brokers is required")
+		}
+	case "nats":
+		if cfg.Streaming.NATS.URL == "" {
+			return fmt.Errorf("streaming.nats.url is required")
+		}
+	default:
+		return fmt.Errorf("unknown streaming provider: %s", cfg.Streaming.Provider)
+	}
+	if cfg.Streaming.Topics.Views == "" {
+		cfg.Streaming.Topics.Views = "interactions.views"
+	}
+	if cfg.Streaming.Topics.Likes == "" {
+		cfg.Streaming.Topics.Likes = "interactions.likes"
+	}
+	if cfg.Streaming.Topics.Purchases == "" {
+		cfg.Streaming.Topics.Purchases = "interactions.purchases"
+	}
+
+	// Backup config
+	if cfg.Backup.Dir == "" {
+		cfg.Backup.Dir = "backups"
+	}
+	if cfg.Backup.S3.Bucket != "" {
+		if cfg.Backup.S3.AccessKey == "" || cfg.Backup.S3.SecretKey == "" {
+			return fmt.Errorf("backup.s3 access_key and secret_key are required with a bucket")
+		}
+		if cfg.Backup.S3.Region == "" {
+			cfg.Backup.S3.Region = "us-east-1"
+		}
+		if cfg.Backup.S3.Endpoint == "" {
+			cfg.Backup.S3.Endpoint = "https://s3." + cfg.Backup.S3.Region + ".amazonaws.com"
+		}
+	}
+
+	// Media config
+	if cfg.Media.PrivateDir == "" {
+		cfg.Media.PrivateDir = "media/private"
+	}
+	if cfg.Media.SigningSecret != "" && len(cfg.Media.SigningSecret) < 32 {
+		return fmt.Errorf("media signing_secret must be at least 32 characters")
+	}
+	if cfg.Media.URLTTL == "" {
+		cfg.Media.URLTTL = "15m"
+	}
+	if cfg.Media.MaxURLTTL == "" {
+		cfg.Media.MaxURLTTL = "24h"
+	}
+	cfg.Media.BaseURL = strings.TrimSuffix(cfg.Media.BaseURL, "/")
+	if cfg.Media.PublicDir == "" {
+		cfg.Media.PublicDir = "media/public"
+	}
+	if cfg.Media.PublicMaxAge == "" {
+		cfg.Media.PublicMaxAge = "168h"
+	}
+	if cfg.Media.PublicURL == "" {
+		cfg.Media.PublicURL = "/media"
+	}
+	cfg.Media.PublicURL = strings.TrimSuffix(cfg.Media.PublicURL, "/")
 
 	return nil
 }
@@ -126,6 +691,25 @@ func (cfg *Config) Validate() error {
 type Http struct {
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
+
+	// Request body limits in bytes; uploads (multipart/form-data) use MaxUploadSize
+	MaxBodySize   int64 `mapstructure:"max_body_size"`
+	MaxUploadSize int64 `mapstructure:"max_upload_size"`
+
+	ProductCache ResponseCache `mapstructure:"product_cache"`
+
+	// Serve the embedded admin UI under /admin-ui
+	AdminUI bool `mapstructure:"admin_ui"`
+}
+
+// ResponseCache configures the in-memory cache of anonymous GET /products responses. A response
+// is served as is for fresh_for; for stale_for after that it is still served, while a request in
+// the background refreshes it.
+type ResponseCache struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	FreshFor   string `mapstructure:"fresh_for"`
+	StaleFor   string `mapstructure:"stale_for"`
+	MaxEntries int    `mapstructure:"max_entries"` // cached query strings per instance
 }
 
 type MongoDB struct {
@@ -138,10 +722,606 @@ type MongoDB struct {
 	MaxPoolSize     int    `mapstructure:"max_pool_size"`
 	MinPoolSize     int    `mapstructure:"min_pool_size"`
 	MaxConnIdleTime int    `mapstructure:"max_conn_idle_time"` // in seconds
+
+	// AnalyticsReadPreference is where analytics and recommendation aggregations read from:
+	// primary, primaryPreferred, secondary, secondaryPreferred or nearest. Other reads always
+	// go to the primary.
+	AnalyticsReadPreference string `mapstructure:"analytics_read_preference"`
+	AnalyticsMaxStaleness   int    `mapstructure:"analytics_max_staleness"` // in seconds, at least 90; 0 for no limit
+
+	// Timeouts of a single operation, in seconds; negative for none. Aggregations get their own
+	// since reports and recommendations legitimately scan much more than other queries.
+	QueryTimeout     int `mapstructure:"query_timeout"`
+	AggregateTimeout int `mapstructure:"aggregate_timeout"`
+
+	// SkipIndexCreation leaves creating indexes to the index tool or admin endpoint instead of
+	// creating them on start
+	SkipIndexCreation bool `mapstructure:"skip_index_creation"`
+
+	// TenantScoped prefixes indexes with tenant_id; set from tenancy.enabled
+	TenantScoped bool `mapstructure:"-"`
+
+	Breaker MongoBreaker `mapstructure:"breaker"`
+}
+
+// MongoBreaker stops sending operations to a degraded database. Once enough of the recent
+// operations failed with timeouts or network errors, operations fail at once for a while, and
+// then a single one is let through to test whether the database has recovered.
+type MongoBreaker struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Window      int     `mapstructure:"window"`       // seconds of operations the health score covers
+	MinRequests int     `mapstructure:"min_requests"` // operations in the window before the breaker may open
+	FailureRate float64 `mapstructure:"failure_rate"` // share of failed operations that opens it
+	OpenFor     int     `mapstructure:"open_for"`     // seconds operations fail at once before it is tested
 }
 
 type JWT struct {
-	Secret               string `mapstructure:"secret"`
-	AccessTokenDuration  string `mapstructure:"access_token_duration"`
-	RefreshTokenDuration string `mapstructure:"refresh_token_duration"`
+	Algorithm            string   `mapstructure:"algorithm"` // HS256, RS256, EdDSA
+	Secret               string   `mapstructure:"secret"`    // HS256 only
+	Keys                 []JWTKey `mapstructure:"keys"`      // RS256/EdDSA key set
+	SigningKeyID         string   `mapstructure:"signing_key_id"`
+	AccessTokenDuration  string   `mapstructure:"access_token_duration"`
+	RefreshTokenDuration string   `mapstructure:"refresh_token_duration"`
+	ReauthWindow         string   `mapstructure:"reauth_window"` // how long after entering the password sensitive operations are allowed
+}
+
+// Roles configures the cache of the role hierarchy and role permissions used to authorize requests
+type Roles struct {
+	RefreshInterval string `mapstructure:"refresh_interval"` // how often each instance picks up role changes made elsewhere
+}
+
+// JWTKey is an asymmetric key identified by kid. Keys without a private key are used
+// only to verify tokens signed before a rotation. PEM can be given inline or as a file.
+type JWTKey struct {
+	ID             string `mapstructure:"id"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+// Password holds complexity rules enforced on registration and password change
+type Password struct {
+	MinLength       int         `mapstructure:"min_length"`
+	RequireUpper    bool        `mapstructure:"require_upper"`
+	RequireLower    bool        `mapstructure:"require_lower"`
+	RequireDigit    bool        `mapstructure:"require_digit"`
+	RequireSpecial  bool        `mapstructure:"require_special"`
+	BannedPasswords []string    `mapstructure:"banned_passwords"` // in addition to the built-in common password list
+	BreachCheck     BreachCheck `mapstructure:"breach_check"`
+}
+
+// BreachCheck configures the Have I Been Pwned k-anonymity password lookup
+type BreachCheck struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Timeout  string `mapstructure:"timeout"`
+	MinCount int    `mapstructure:"min_count"` // reject passwords seen in at least this many breaches
+}
+
+type Mail struct {
+	Provider string `mapstructure:"provider"` // log, smtp
+	From     string `mapstructure:"from"`
+	SMTP     SMTP   `mapstructure:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// EmailChange configures confirmation of email address changes
+type EmailChange struct {
+	ConfirmURL string `mapstructure:"confirm_url"` // frontend page; the token is appended as ?token=
+	TokenTTL   string `mapstructure:"token_ttl"`
+}
+
+type SMS struct {
+	Provider string `mapstructure:"provider"` // noop, twilio
+	Twilio   Twilio `mapstructure:"twilio"`
+}
+
+type Twilio struct {
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
+	From       string `mapstructure:"from"` // sender phone number in E.164 format
+}
+
+// PhoneVerification configures SMS verification codes
+type PhoneVerification struct {
+	CodeTTL        string `mapstructure:"code_ttl"`
+	ResendInterval string `mapstructure:"resend_interval"`
+	MaxAttempts    int    `mapstructure:"max_attempts"`
+}
+
+type Recommendation struct {
+	Algorithm      string         `mapstructure:"algorithm"` // collaborative_filtering, matrix_factorization
+	CacheTTL       string         `mapstructure:"cache_ttl"` // how long a user's recommendations are reused; 0 disables the cache
+	ALS            ALS            `mapstructure:"als"`
+	Engagement     Engagement     `mapstructure:"engagement"`
+	PostPurchase   PostPurchase   `mapstructure:"post_purchase"`
+	BoughtTogether BoughtTogether `mapstructure:"bought_together"`
+}
+
+// BoughtTogether configures the frequently bought together lists, recomputed from purchase
+// history every RefreshInterval. A product is listed with another when at least MinBuyers users
+// bought both, keeping the MaxItems bought together most often.
+type BoughtTogether struct {
+	RefreshInterval string `mapstructure:"refresh_interval"`
+	MinBuyers       int    `mapstructure:"min_buyers"`
+	MaxItems        int    `mapstructure:"max_items"`
+}
+
+// Feed configures the sections of the personalized home feed
+type Feed struct {
+	SectionSize       int    `mapstructure:"section_size"`        // products per section
+	TrendingWindow    string `mapstructure:"trending_window"`     // interactions trending products are ranked by
+	NewProductsWindow string `mapstructure:"new_products_window"` // how recently new products were added
+	PriceDropWindow   string `mapstructure:"price_drop_window"`   // how recently prices dropped
+}
+
+// PostPurchase adjusts recommendations after a purchase. For Window after buying a product, other
+// products of its category are not recommended and up to Accessories products other buyers
+// bought with it are.
+type PostPurchase struct {
+	Window      string `mapstructure:"window"`
+	Accessories int    `mapstructure:"accessories"`
+}
+
+// Engagement decides which product views count as deep engagement. A view is deep when the
+// client reports it was shown at least DeepDwellSeconds or scrolled DeepScrollDepth percent down.
+type Engagement struct {
+	DeepDwellSeconds int     `mapstructure:"deep_dwell_seconds"`
+	DeepScrollDepth  int     `mapstructure:"deep_scroll_depth"`
+	DeepViewWeight   float64 `mapstructure:"deep_view_weight"` // a deep view counts as this many plain views
+}
+
+// ALS holds training parameters for the implicit-feedback matrix factorization model
+type ALS struct {
+	Factors        int     `mapstructure:"factors"`
+	Iterations     int     `mapstructure:"iterations"`
+	Regularization float64 `mapstructure:"regularization"`
+	Alpha          float64 `mapstructure:"alpha"` // confidence scaling for implicit feedback
+}
+
+// Realtime configures live updates pushed to clients. With source "local" events are published
+// by this instance when it changes data; "change_stream" follows MongoDB change streams instead,
+// which also picks up changes made by other instances but requires a replica set.
+type Realtime struct {
+	Source    string `mapstructure:"source"`    // local, change_stream
+	Heartbeat string `mapstructure:"heartbeat"` // keep-alive interval of open streams
+
+	MetricsInterval string `mapstructure:"metrics_interval"` // how often admin dashboard metrics are pushed
+}
+
+// Interactions configures how interactions are written. With BatchViews, product views are
+// buffered and inserted in batches instead of one insert per request.
+type Interactions struct {
+	BatchViews    bool `mapstructure:"batch_views"`
+	BatchSize     int  `mapstructure:"batch_size"`     // views per insert
+	FlushInterval int  `mapstructure:"flush_interval"` // in milliseconds; longest a view waits in the buffer
+	BufferSize    int  `mapstructure:"buffer_size"`    // views held before requests insert their own
+}
+
+// Search selects the catalog search backend
+type Search struct {
+	Provider      string        `mapstructure:"provider"` // mongo, elasticsearch
+	Elasticsearch Elasticsearch `mapstructure:"elasticsearch"`
+}
+
+// Listing sets the page size and order of product listings when a request sets none. Endpoints
+// override any of them for one listing: products (GET /products), admin_products
+// (GET /admin/products) or search (GET /products/search, which ranks by relevance and ignores
+// default_sort). Larger pages than max_limit fall back to the default.
+//
+// sort_fields narrows the keys ?sort= accepts (id, name, price, stock, created_at, updated_at);
+// all of them if empty. Each key maps to a fixed document field, so no other field can be sorted by.
+//
+// With estimate_counts_above set, GET /products counts the matches of a filter exactly only
+// until it has more than that many; the count is then reused for a minute as an estimate.
+type Listing struct {
+	DefaultLimit        int                        `mapstructure:"default_limit"`
+	MaxLimit            int                        `mapstructure:"max_limit"`
+	DefaultSort         string                     `mapstructure:"default_sort"` // sort spec such as "-created_at,name"
+	SortFields          []string                   `mapstructure:"sort_fields"`
+	EstimateCountsAbove int64                      `mapstructure:"estimate_counts_above"` // 0 always counts exactly
+	Endpoints           map[string]ListingEndpoint `mapstructure:"endpoints"`
+}
+
+// ListingEndpoint overrides the listing settings of one endpoint; zero values inherit them
+type ListingEndpoint struct {
+	DefaultLimit        int      `mapstructure:"default_limit"`
+	MaxLimit            int      `mapstructure:"max_limit"`
+	DefaultSort         string   `mapstructure:"default_sort"`
+	SortFields          []string `mapstructure:"sort_fields"`
+	EstimateCountsAbove int64    `mapstructure:"estimate_counts_above"`
+}
+
+// Elasticsearch configures the Elasticsearch/OpenSearch search backend
+type Elasticsearch struct {
+	URL             string  `mapstructure:"url"`
+	Username        string  `mapstructure:"username"`
+	Password        string  `mapstructure:"password"`
+	Index           string  `mapstructure:"index"`
+	Timeout         string  `mapstructure:"timeout"`
+	ReindexInterval string  `mapstructure:"reindex_interval"` // full reindex, refreshes popularity and drops stale documents
+	PopularityBoost float64 `mapstructure:"popularity_boost"` // weight of log(1 + popularity) added to the text score
+}
+
+// Payment selects the payment provider charging customers
+type Payment struct {
+	Provider string `mapstructure:"provider"` // noop
+	Currency string `mapstructure:"currency"` // ISO 4217 code prices are charged in
+}
+
+// Subscriptions configures renewal of recurring purchases
+type Subscriptions struct {
+	CheckInterval string `mapstructure:"check_interval"` // how often due subscriptions are renewed
+	RetryDelay    string `mapstructure:"retry_delay"`    // wait before retrying a failed renewal
+	MaxAttempts   int    `mapstructure:"max_attempts"`   // failed renewals in a row before cancelling
+}
+
+// Carts configures abandoned cart detection and recovery reminders
+type Carts struct {
+	AbandonAfter   string `mapstructure:"abandon_after"`   // idle time after which a cart is abandoned
+	CheckInterval  string `mapstructure:"check_interval"`  // how often idle carts are looked for
+	RecoveryURL    string `mapstructure:"recovery_url"`    // frontend cart page linked in reminders
+	RecoveryWindow string `mapstructure:"recovery_window"` // checkouts within this of abandoning count as recovered
+}
+
+// Segments configures the evaluation of user segments and notifications sent to them
+type Segments struct {
+	EvaluateInterval string `mapstructure:"evaluate_interval"` // how often segment membership is recomputed
+	NotifyBatchSize  int    `mapstructure:"notify_batch_size"` // members loaded at a time when notifying a segment
+}
+
+// Promotions configures the job granting birthday and anniversary promotions
+type Promotions struct {
+	CheckInterval string `mapstructure:"check_interval"` // how often users with an occasion today are looked for
+	Timezone      string `mapstructure:"timezone"`       // IANA time zone of users who have not set one
+}
+
+// FlashSales configures the waiting room of flash sales. Queue tokens are signed with
+// SigningSecret; without one a random key is generated on start, so tokens are only accepted by
+// the instance that issued them.
+type FlashSales struct {
+	SigningSecret  string `mapstructure:"signing_secret"`
+	AdmitPerSecond int    `mapstructure:"admit_per_second"` // queue positions admitted per second of a sale that sets none
+	TokenTTL       string `mapstructure:"token_ttl"`        // how long an admitted token can purchase
+}
+
+// Reports configures the reports emailed to admins on a schedule. Each report covers the
+// period before it is due, e.g. the daily sales report sent on a morning covers the day before.
+type Reports struct {
+	Recipients        []string       `mapstructure:"recipients"`          // admin addresses; nothing is sent without any
+	Timezone          string         `mapstructure:"timezone"`            // IANA time zone of the schedules and report periods
+	CheckInterval     string         `mapstructure:"check_interval"`      // how often due reports are looked for
+	DailySales        ReportSchedule `mapstructure:"daily_sales"`         // revenue and units per product the day before
+	WeeklyTopProducts ReportSchedule `mapstructure:"weekly_top_products"` // best selling products the week before
+	LowStock          ReportSchedule `mapstructure:"low_stock"`           // active products at or below the threshold, daily
+	TopProductsLimit  int            `mapstructure:"top_products_limit"`
+	LowStockThreshold int            `mapstructure:"low_stock_threshold"`
+	DeadStockDays     int            `mapstructure:"dead_stock_days"`  // the inventory report flags stocked products unsold this long
+	DeadStockLimit    int            `mapstructure:"dead_stock_limit"` // most dead stock products listed, highest value first
+}
+
+// ReportSchedule sets when a report is sent
+type ReportSchedule struct {
+	Enabled bool   `mapstructure:"enabled"`
+	At      string `mapstructure:"at"`      // time of day, HH:MM
+	Weekday string `mapstructure:"weekday"` // day of weekly reports, e.g. monday
+}
+
+// Risk configures the fraud signals checkouts are scored on. Orders scoring at least
+// ReviewScore are queued for manual review.
+type Risk struct {
+	ReviewScore          int               `mapstructure:"review_score"`
+	CountryHeader        string            `mapstructure:"country_header"` // request header with the client's ISO country code, set by the CDN or proxy
+	CountryMismatchScore int               `mapstructure:"country_mismatch_score"`
+	Velocity             RiskVelocity      `mapstructure:"velocity"`
+	LargeQuantity        RiskLargeQuantity `mapstructure:"large_quantity"`
+}
+
+// RiskVelocity scores buyers purchasing more than MaxPurchases times within Window
+type RiskVelocity struct {
+	Window       string `mapstructure:"window"`
+	MaxPurchases int    `mapstructure:"max_purchases"`
+	Score        int    `mapstructure:"score"`
+}
+
+// RiskLargeQuantity scores orders of at least Quantity units of one product
+type RiskLargeQuantity struct {
+	Quantity int `mapstructure:"quantity"`
+	Score    int `mapstructure:"score"`
+}
+
+// Moderation screens user-generated text. Text with a banned term, or flagged by the moderation
+// API of the provider, is quarantined until a moderator approves or rejects it.
+type Moderation struct {
+	BannedTerms     []string          `mapstructure:"banned_terms"`      // whole words or phrases; a trailing * matches any word starting with the term
+	BannedTermsFile string            `mapstructure:"banned_terms_file"` // more terms, one per line; # starts a comment
+	Provider        string            `mapstructure:"provider"`          // none, webhook
+	Webhook         ModerationWebhook `mapstructure:"webhook"`
+}
+
+// ModerationWebhook is an HTTP moderation API. Text is POSTed as {"text": "..."} and the API
+// answers {"flagged": true, "categories": ["harassment"]}.
+type ModerationWebhook struct {
+	URL      string `mapstructure:"url"`
+	APIKey   string `mapstructure:"api_key"` // sent as a bearer token if set
+	Timeout  string `mapstructure:"timeout"`
+	FailOpen bool   `mapstructure:"fail_open"` // accept text when the API fails instead of quarantining it
+}
+
+// Tenancy runs several storefronts from one deployment. Each request is resolved to a tenant
+// from the Header or its host, and all data is scoped to that tenant.
+type Tenancy struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Header  string   `mapstructure:"header"`  // request header naming the tenant, checked before the host
+	Default string   `mapstructure:"default"` // tenant of requests matching none; empty rejects them
+	Tenants []Tenant `mapstructure:"tenants"`
+
+	// AdminTenant is the tenant whose admins change the roles and permissions all tenants share;
+	// defaults to Default
+	AdminTenant string `mapstructure:"admin_tenant"`
+}
+
+// Maintenance is a read-only mode that rejects writes with 503 while reads keep working, for
+// migrations and backups. Admins switch it at runtime; Enabled keeps it on regardless.
+type Maintenance struct {
+	Enabled         bool   `mapstructure:"enabled"`
+	RetryAfter      int    `mapstructure:"retry_after"`      // seconds clients are told to wait when the switch does not say
+	RefreshInterval string `mapstructure:"refresh_interval"` // how often each instance picks up the admin switch
+}
+
+// Quotas caps the API requests users make each calendar month (UTC), for machine clients
+// such as integrations signing in as users with a role of their own. Users with several
+// quota roles get the largest quota; users with none are unlimited.
+type Quotas struct {
+	Roles         map[string]int64 `mapstructure:"roles"`          // monthly requests by role name
+	FlushInterval string           `mapstructure:"flush_interval"` // how often counted requests are saved; an instance may go over by what it counted since
+}
+
+// Outbox configures the relay publishing events recorded with the changes they report
+type Outbox struct {
+	PollInterval string `mapstructure:"poll_interval"` // how often pending events are looked up
+	BatchSize    int    `mapstructure:"batch_size"`    // events claimed per poll
+	MaxAttempts  int    `mapstructure:"max_attempts"`  // failed events are kept for inspection after this many
+	Retention    string `mapstructure:"retention"`     // how long delivered events are kept
+}
+
+// ErrorReporting configures sending logged errors and recovered panics to Sentry or a compatible
+// service. Reports are tagged with the logger service, version and environment.
+type ErrorReporting struct {
+	DSN         string             `mapstructure:"dsn"`          // reporting is off without a DSN
+	SampleRate  float64            `mapstructure:"sample_rate"`  // share of errors reported, 0-1
+	SampleRates map[string]float64 `mapstructure:"sample_rates"` // per logger environment, overriding sample_rate; 0 turns reporting off
+}
+
+// Streaming configures the message broker interaction events are published to for external
+// analytics and ML consumers
+type Streaming struct {
+	Provider string          `mapstructure:"provider"` // noop, kafka, nats
+	Topics   StreamingTopics `mapstructure:"topics"`
+	Kafka    Kafka           `mapstructure:"kafka"`
+	NATS     NATS            `mapstructure:"nats"`
+}
+
+// StreamingTopics names the topic (Kafka) or subject (NATS) of each interaction type
+type StreamingTopics struct {
+	Views     string `mapstructure:"views"`
+	Likes     string `mapstructure:"likes"`
+	Purchases string `mapstructure:"purchases"`
+}
+
+type Kafka struct {
+	Brokers []string `mapstructure:"brokers"` // host:port of the bootstrap brokers
+}
+
+type NATS struct {
+	URL    string `mapstructure:"url"`
+	Stream string `mapstructure:"stream"` // JetStream stream created over the topics; leave empty to manage it yourself
+}
+
+// Backup configures database snapshots taken by the admin endpoint and the dbtool command
+type Backup struct {
+	Dir         string   `mapstructure:"dir"`         // directory backups are written to
+	Collections []string `mapstructure:"collections"` // collections backed up; all by default
+	S3          S3       `mapstructure:"s3"`
+}
+
+// Media configures private files (invoices, digital downloads, original uploads), served only
+// through signed, time-limited URLs. Signed URLs are off without a signing secret.
+type Media struct {
+	PrivateDir    string `mapstructure:"private_dir"`    // files are kept under <dir>/<tenant>/<kind>/
+	SigningSecret string `mapstructure:"signing_secret"` // HMAC key of the URL signatures
+	BaseURL       string `mapstructure:"base_url"`       // prepended to signed URLs; relative URLs if empty
+	URLTTL        string `mapstructure:"url_ttl"`        // validity of a signed URL when none is asked for
+	MaxURLTTL     string `mapstructure:"max_url_ttl"`
+
+	// Public files such as product images can be served under /media, so small deployments
+	// don't need a separate web server
+	ServePublic  bool   `mapstructure:"serve_public"`
+	PublicDir    string `mapstructure:"public_dir"`
+	PublicMaxAge string `mapstructure:"public_max_age"` // Cache-Control max-age of public files
+	PublicURL    string `mapstructure:"public_url"`     // URL public_dir is served at, e.g. a CDN
+
+	// Command converting an image to WebP, run as <encoder> <input> -o <output> like cwebp;
+	// uploaded product images get no WebP variants without one
+	WebPEncoder string `mapstructure:"webp_encoder"`
+}
+
+// S3 is an S3-compatible bucket backups are uploaded to; uploads are off without a bucket
+type S3 struct {
+	Endpoint  string `mapstructure:"endpoint"` // defaults to AWS in Region; set for MinIO and other providers
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	Prefix    string `mapstructure:"prefix"` // prepended to the object keys
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+// Tenant is a storefront with its own catalog, users and orders
+type Tenant struct {
+	ID              string   `mapstructure:"id"`
+	Name            string   `mapstructure:"name"`
+	Hosts           []string `mapstructure:"hosts"`
+	Currency        string   `mapstructure:"currency"`          // defaults to payment.currency
+	CartRecoveryURL string   `mapstructure:"cart_recovery_url"` // defaults to carts.recovery_url
+	Branding        Branding `mapstructure:"branding"`
+}
+
+// Branding is how a storefront presents itself to customers
+type Branding struct {
+	LogoURL      string `mapstructure:"logo_url"`
+	PrimaryColor string `mapstructure:"primary_color"`
+	SupportEmail string `mapstructure:"support_email"`
+}
+
+var reportTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Tenant returns the tenant with the given ID, or nil if there is none
+func (t *Tenancy) Tenant(id string) *Tenant {
+	for i := range t.Tenants {
+		if t.Tenants[i].ID == id {
+			return &t.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// TenantByHost returns the tenant serving the given host, or nil if there is none
+func (t *Tenancy) TenantByHost(host string) *Tenant {
+	for i := range t.Tenants {
+		for _, h := range t.Tenants[i].Hosts {
+			if strings.EqualFold(h, host) {
+				return &t.Tenants[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) validateTenancy() error {
+	tenancy := &cfg.Tenancy
+	cfg.Mongo.TenantScoped = tenancy.Enabled
+	if !tenancy.Enabled {
+		return nil
+	}
+
+	if tenancy.Header == "" {
+		tenancy.Header = "X-Tenant-ID"
+	}
+	if len(tenancy.Tenants) == 0 {
+		return fmt.Errorf("tenancy enabled without tenants")
+	}
+	if cfg.Search.Provider != "mongo" {
+		return fmt.Errorf("search provider %s does not support tenancy", cfg.Search.Provider)
+	}
+
+	hosts := make(map[string]string)
+	for i := range tenancy.Tenants {
+		tenant := &tenancy.Tenants[i]
+		if !tenantIDPattern.MatchString(tenant.ID) {
+			return fmt.Errorf("invalid tenant id %q", tenant.ID)
+		}
+		if tenancy.Tenant(tenant.ID) != tenant {
+			return fmt.Errorf("duplicate tenant id %q", tenant.ID)
+		}
+		for _, host := range tenant.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := hosts[host]; ok {
+				return fmt.Errorf("host %s is used by tenants %s and %s", host, other, tenant.ID)
+			}
+			hosts[host] = tenant.ID
+		}
+
+		if tenant.Name == "" {
+			tenant.Name = tenant.ID
+		}
+		if tenant.Currency == "" {
+			tenant.Currency = cfg.Payment.Currency
+		}
+		if tenant.CartRecoveryURL == "" {
+			tenant.CartRecoveryURL = cfg.Carts.RecoveryURL
+		}
+	}
+
+	if tenancy.Default != "" && tenancy.Tenant(tenancy.Default) == nil {
+		return fmt.Errorf("unknown default tenant %q", tenancy.Default)
+	}
+	if tenancy.AdminTenant == "" {
+		tenancy.AdminTenant = tenancy.Default
+	}
+	if tenancy.AdminTenant != "" && tenancy.Tenant(tenancy.AdminTenant) == nil {
+		return fmt.Errorf("unknown admin tenant %q", tenancy.AdminTenant)
+	}
+
+	return nil
+}
+
+// listingEndpoints are the product listings with their own settings
+var listingEndpoints = []string{"products", "admin_products", "search"}
+
+// maxListingLimit caps max_limit, so a misconfigured listing can't load the whole catalog
+const maxListingLimit = 1000
+
+// validateListing fills in the listing defaults and gives every endpoint its settings, inheriting
+// those it doesn't override
+func (cfg *Config) validateListing() error {
+	listing := &cfg.Listing
+	if listing.DefaultLimit == 0 {
+		listing.DefaultLimit = 20
+	}
+	if listing.MaxLimit == 0 {
+		listing.MaxLimit = 100
+	}
+	if listing.DefaultSort == "" {
+		listing.DefaultSort = "-created_at"
+	}
+	if listing.EstimateCountsAbove < 0 {
+		return fmt.Errorf("listing estimate_counts_above must not be negative")
+	}
+
+	for name := range listing.Endpoints {
+		if !slices.Contains(listingEndpoints, name) {
+			return fmt.Errorf("unknown listing endpoint %q", name)
+		}
+	}
+	endpoints := make(map[string]ListingEndpoint, len(listingEndpoints))
+	for _, name := range listingEndpoints {
+		endpoint := listing.Endpoints[name]
+		if endpoint.DefaultLimit == 0 {
+			endpoint.DefaultLimit = listing.DefaultLimit
+		}
+		if endpoint.MaxLimit == 0 {
+			endpoint.MaxLimit = listing.MaxLimit
+		}
+		if endpoint.DefaultSort == "" {
+			endpoint.DefaultSort = listing.DefaultSort
+		}
+		if len(endpoint.SortFields) == 0 {
+			endpoint.SortFields = listing.SortFields
+		}
+		if endpoint.EstimateCountsAbove == 0 {
+			endpoint.EstimateCountsAbove = listing.EstimateCountsAbove
+		}
+
+		if endpoint.DefaultLimit < 1 || endpoint.MaxLimit < 1 {
+			return fmt.Errorf("listing %s limits must be positive", name)
+		}
+		if endpoint.DefaultLimit > endpoint.MaxLimit {
+			return fmt.Errorf("listing %s default_limit %d exceeds max_limit %d", name, endpoint.DefaultLimit, endpoint.MaxLimit)
+		}
+		if endpoint.MaxLimit > maxListingLimit {
+			return fmt.Errorf("listing %s max_limit must be at most %d", name, maxListingLimit)
+		}
+		endpoints[name] = endpoint
+	}
+	listing.Endpoints = endpoints
+
+	return nil
 }
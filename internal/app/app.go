@@ -10,6 +10,7 @@ import (
 	"github.com/PrimeraAizen/e-comm/internal/repository"
 	"github.com/PrimeraAizen/e-comm/internal/server"
 	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/broker"
 	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
 	"github.com/PrimeraAizen/e-comm/pkg/logger"
 )
@@ -26,18 +27,111 @@ func StartWebServer(ctx context.Context, cfg *config.Config, appLogger *logger.L
 	}
 
 	appLogger.WithComponent("database").Info("MongoDB connection established")
+	if !db.SupportsTransactions() {
+		appLogger.WithComponent("outbox").Warn("MongoDB is a standalone server without transactions: outbox events are written after their change, " +
+			"so a crash between the two loses the event. Run a replica set, even a single-node one, to write them atomically")
+	}
 
 	// Initialize repositories
 	appLogger.WithComponent("repository").Info("Initializing repositories")
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, cfg)
+
+	// Connect to the message broker interactions are streamed to
+	var publisher broker.Publisher
+	if cfg.Streaming.Provider != "noop" {
+		appLogger.WithComponent("streaming").WithFields(logger.Fields{"provider": cfg.Streaming.Provider}).Info("Connecting to message broker")
+		publisher, err = broker.New(ctx, &cfg.Streaming)
+		if err != nil {
+			appLogger.WithComponent("streaming").WithError(err).Error("Failed to connect to message broker")
+			return fmt.Errorf("could not init message broker: %w", err)
+		}
+	}
 
 	// Initialize services
 	appLogger.WithComponent("service").Info("Initializing services")
+	if cfg.FlashSales.SigningSecret == "" {
+		appLogger.WithComponent("flash_sale").Warn("flash_sales.signing_secret is not set: queue tokens are signed with a random key of this instance, " +
+			"so they are rejected by other instances and after a restart. Set the same secret on every instance")
+	}
 	services := service.NewServices(service.Deps{
-		Repos:  repos,
-		Config: cfg,
+		Repos:     repos,
+		Config:    cfg,
+		Publisher: publisher,
 	})
 
+	// Start live update feeds
+	go func() {
+		if err := services.StockFeed.Run(ctx); err != nil {
+			appLogger.WithComponent("realtime").WithError(err).Error("Stock feed stopped")
+		}
+	}()
+	go func() {
+		if err := services.LiveMetrics.Run(ctx); err != nil {
+			appLogger.WithComponent("realtime").WithError(err).Error("Live metrics stopped")
+		}
+	}()
+	go func() {
+		if err := services.SearchService.Run(ctx); err != nil {
+			appLogger.WithComponent("search").WithError(err).Error("Search indexer stopped")
+		}
+	}()
+	go func() {
+		if err := services.RecommendationService.Run(ctx); err != nil {
+			appLogger.WithComponent("recommendations").WithError(err).Error("Recommendation cache invalidation stopped")
+		}
+	}()
+	go func() {
+		if err := services.BoughtTogetherService.Run(ctx); err != nil {
+			appLogger.WithComponent("bought_together").WithError(err).Error("Frequently bought together refresh stopped")
+		}
+	}()
+	go func() {
+		if err := services.SubscriptionService.Run(ctx); err != nil {
+			appLogger.WithComponent("subscriptions").WithError(err).Error("Subscription renewals stopped")
+		}
+	}()
+	go func() {
+		if err := services.CartService.Run(ctx); err != nil {
+			appLogger.WithComponent("carts").WithError(err).Error("Abandoned cart detection stopped")
+		}
+	}()
+	go func() {
+		if err := services.SegmentService.Run(ctx); err != nil {
+			appLogger.WithComponent("segments").WithError(err).Error("Segment evaluation stopped")
+		}
+	}()
+	go func() {
+		if err := services.PromotionService.Run(ctx); err != nil {
+			appLogger.WithComponent("promotions").WithError(err).Error("Promotion granting stopped")
+		}
+	}()
+	go func() {
+		if err := services.ReportService.Run(ctx); err != nil {
+			appLogger.WithComponent("reports").WithError(err).Error("Scheduled reports stopped")
+		}
+	}()
+
+	go func() {
+		if err := services.Outbox.Run(ctx); err != nil {
+			appLogger.WithComponent("outbox").WithError(err).Error("Outbox relay stopped")
+		}
+	}()
+	go func() {
+		if err := services.RoleCatalog.Run(ctx); err != nil {
+			appLogger.WithComponent("roles").WithError(err).Error("Role refresh stopped")
+		}
+	}()
+	go func() {
+		if err := services.MaintenanceService.Run(ctx); err != nil {
+			appLogger.WithComponent("maintenance").WithError(err).Error("Maintenance mode refresh stopped")
+		}
+	}()
+	go func() {
+		if err := services.UsageService.Run(ctx); err != nil {
+			appLogger.WithComponent("usage").WithError(err).Error("API usage flushing stopped")
+		}
+	}()
+
 	// Initialize handlers
 	appLogger.WithComponent("handler").Info("Initializing handlers")
 	handlers := delivery.NewHandler(services, appLogger)
@@ -69,6 +163,20 @@ func StartWebServer(ctx context.Context, cfg *config.Config, appLogger *logger.L
 		appLogger.WithComponent("server").WithError(err).Error("Error stopping HTTP server")
 	}
 
+	// Write out interactions buffered by the repositories
+	appLogger.WithComponent("repository").Info("Flushing buffered writes")
+	if err := repos.Close(shutdownCtx); err != nil {
+		appLogger.WithComponent("repository").WithError(err).Error("Error flushing buffered writes")
+	}
+
+	// Close the broker connection after the last events were published
+	if publisher != nil {
+		appLogger.WithComponent("streaming").Info("Closing message broker connection")
+		if err := publisher.Close(); err != nil {
+			appLogger.WithComponent("streaming").WithError(err).Error("Error closing message broker connection")
+		}
+	}
+
 	// Close database connection
 	appLogger.WithComponent("database").Info("Closing MongoDB connection")
 	if err := db.Close(shutdownCtx); err != nil {
@@ -0,0 +1,72 @@
+package delivery
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminUIFiles is the admin single-page app. It only calls the admin API, so it works with any
+// account that has the permissions of the pages it opens.
+//
+//go:embed adminui
+var adminUIFiles embed.FS
+
+// adminUIPolicy keeps the admin UI to its own scripts and styles, and out of frames
+const adminUIPolicy = "default-src 'self'; img-src 'self' data: https:; frame-ancestors 'none'"
+
+type adminUIAsset struct {
+	body        []byte
+	contentType string
+	etag        string
+}
+
+// serveAdminUI creates a handler serving the embedded admin UI under the path parameter. Paths
+// without a file are routes of the app, answered with index.html. Embedded files have no
+// modification time, so they are revalidated by a hash of their content.
+func serveAdminUI() gin.HandlerFunc {
+	assets := make(map[string]adminUIAsset)
+	err := fs.WalkDir(adminUIFiles, "adminui", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		body, err := adminUIFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		assets[strings.TrimPrefix(name, "adminui")] = adminUIAsset{
+			body:        body,
+			contentType: mime.TypeByExtension(path.Ext(name)),
+			etag:        `"` + hex.EncodeToString(sum[:8]) + `"`,
+		}
+		return nil
+	})
+	if err != nil {
+		panic("failed to load admin ui: " + err.Error())
+	}
+
+	return func(c *gin.Context) {
+		asset, ok := assets[path.Clean(c.Param("path"))]
+		if !ok {
+			asset = assets["/index.html"]
+		}
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("ETag", asset.etag)
+		c.Header("Content-Security-Policy", adminUIPolicy)
+		c.Header("X-Content-Type-Options", "nosniff")
+		if c.GetHeader("If-None-Match") == asset.etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, asset.contentType, asset.body)
+	}
+}
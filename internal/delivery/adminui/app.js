@@ -0,0 +1,495 @@
+// Admin UI for products, categories, users and orders. Plain DOM code without a build step, so it can be
+// embedded in the binary as is. Tokens are kept in sessionStorage and refreshed once on a 401.
+"use strict";
+
+const API = "/api/v1";
+const PAGE_SIZE = 20;
+
+const session = {
+  get access() { return sessionStorage.getItem("access_token"); },
+  get refresh() { return sessionStorage.getItem("refresh_token"); },
+  get email() { return sessionStorage.getItem("email"); },
+  save(token) {
+    sessionStorage.setItem("access_token", token.access_token);
+    sessionStorage.setItem("refresh_token", token.refresh_token);
+    if (token.user) sessionStorage.setItem("email", token.user.email);
+  },
+  clear() { sessionStorage.clear(); },
+};
+
+class APIError extends Error {
+  constructor(status, message) {
+    super(message);
+    this.status = status;
+  }
+}
+
+async function request(method, path, body) {
+  const headers = { Accept: "application/json" };
+  if (body !== undefined) headers["Content-Type"] = "application/json";
+  if (session.access) headers.Authorization = "Bearer " + session.access;
+  return fetch(API + path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+}
+
+// api calls the API and returns the decoded body, refreshing the access token once if it expired
+async function api(method, path, body) {
+  let response = await request(method, path, body);
+  if (response.status === 401 && session.refresh && await refresh()) {
+    response = await request(method, path, body);
+  }
+  if (response.status === 401) {
+    session.clear();
+    render();
+    throw new APIError(401, "Your session expired, sign in again");
+  }
+  if (response.status === 204) return null;
+
+  const data = await response.json().catch(() => null);
+  if (!response.ok) {
+    throw new APIError(response.status, (data && data.error) || response.statusText);
+  }
+  return data;
+}
+
+// download saves the response of a GET as a file, refreshing the access token once if it expired
+async function download(path) {
+  let response = await request("GET", path);
+  if (response.status === 401 && session.refresh && await refresh()) {
+    response = await request("GET", path);
+  }
+  if (!response.ok) {
+    const data = await response.json().catch(() => null);
+    throw new APIError(response.status, (data && data.error) || response.statusText);
+  }
+
+  const disposition = response.headers.get("Content-Disposition") || "";
+  const match = disposition.match(/filename="([^"]+)"/);
+  const link = el("a", { href: URL.createObjectURL(await response.blob()), download: match ? match[1] : "export" });
+  link.click();
+  URL.revokeObjectURL(link.href);
+}
+
+async function refresh() {
+  const response = await fetch(API + "/auth/refresh", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ refresh_token: session.refresh }),
+  });
+  if (!response.ok) return false;
+  session.save(await response.json());
+  return true;
+}
+
+// el creates an element with the attributes and children; strings become text nodes
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs || {})) {
+    if (name.startsWith("on")) node.addEventListener(name.slice(2), value);
+    else if (value === true) node.setAttribute(name, "");
+    else if (value !== false && value != null) node.setAttribute(name, value);
+  }
+  for (const child of children.flat()) {
+    if (child != null) node.append(child);
+  }
+  return node;
+}
+
+function showError(err) {
+  document.getElementById("error").textContent = err ? err.message : "";
+}
+
+function query(params) {
+  const search = new URLSearchParams();
+  for (const [name, value] of Object.entries(params)) {
+    if (value !== "" && value != null) search.set(name, value);
+  }
+  return search.toString();
+}
+
+function money(value) {
+  return value ? value.amount + " " + value.currency : "";
+}
+
+function table(columns, rows) {
+  return el("table", null,
+    el("thead", null, el("tr", null, columns.map((column) => el("th", null, column)))),
+    el("tbody", null, rows));
+}
+
+function pager(pagination, onPage) {
+  const page = pagination.page;
+  const pages = Math.max(pagination.total_pages, 1);
+  const total = pagination.total_is_estimate ? "about " + pagination.total : pagination.total;
+  return el("div", { class: "pager" },
+    el("button", { type: "button", disabled: page <= 1, onclick: () => onPage(page - 1) }, "Previous"),
+    el("span", null, `Page ${page} of ${pages} (${total})`),
+    el("button", { type: "button", disabled: page >= pages, onclick: () => onPage(page + 1) }, "Next"));
+}
+
+// edit opens the editor dialog with the fields, and calls save with the form values until it
+// succeeds or the dialog is cancelled
+function edit(title, fields, save) {
+  const dialog = document.getElementById("editor");
+  const form = document.getElementById("editor-form");
+  const errorText = document.getElementById("editor-error");
+  document.getElementById("editor-title").textContent = title;
+  document.getElementById("editor-fields").replaceChildren(...fields);
+  errorText.textContent = "";
+
+  form.onsubmit = async (event) => {
+    event.preventDefault();
+    try {
+      await save(new FormData(form));
+      dialog.close();
+      route();
+    } catch (err) {
+      errorText.textContent = err.message;
+    }
+  };
+  document.getElementById("editor-cancel").onclick = () => dialog.close();
+  dialog.showModal();
+}
+
+function field(label, name, value, attrs) {
+  return el("label", null, label, el("input", { name, value: value ?? "", ...attrs }));
+}
+
+function checkbox(label, name, checked) {
+  return el("label", { class: "inline" }, el("input", { name, type: "checkbox", checked }), label);
+}
+
+function select(label, name, options, selected) {
+  return el("label", null, label, el("select", { name },
+    options.map(([value, text]) => el("option", { value, selected: String(value) === String(selected ?? "") }, text))));
+}
+
+// startOfDay turns the value of a date input into an RFC3339 time, or "" if it is empty
+function startOfDay(date) {
+  return date ? date + "T00:00:00Z" : "";
+}
+
+function optionalInt(value) {
+  return value === "" ? null : Number(value);
+}
+
+let categoriesCache = null;
+
+async function loadCategories() {
+  if (!categoriesCache) {
+    const data = await api("GET", "/categories?limit=500");
+    categoriesCache = data.categories;
+  }
+  return categoriesCache;
+}
+
+function categoryOptions(categories, empty) {
+  return [["", empty], ...categories.map((category) => [category.id, category.name])];
+}
+
+// Pages
+
+const pages = {
+  async products(params) {
+    const page = Number(params.get("page") || 1);
+    const filters = { search: params.get("search") || "", is_active: params.get("is_active") || "" };
+    const [data, categories] = await Promise.all([
+      api("GET", "/admin/products?" + query({ ...filters, page, limit: PAGE_SIZE })),
+      loadCategories(),
+    ]);
+
+    const navigate = (changes) => {
+      location.hash = "#/products?" + query({ ...filters, page, ...changes });
+    };
+
+    const search = el("input", { type: "search", placeholder: "Search products", value: filters.search });
+    const status = el("select", null,
+      [["", "Any status"], ["true", "Active"], ["false", "Inactive"]].map(([value, text]) =>
+        el("option", { value, selected: value === filters.is_active }, text)));
+
+    const rows = data.products.map((product) => el("tr", { class: product.is_active ? null : "muted" },
+      el("td", null, String(product.id)),
+      el("td", null, product.name),
+      el("td", null, product.category_name || ""),
+      el("td", null, money(product.price)),
+      el("td", null, money(product.cost_price)),
+      el("td", null, String(product.stock)),
+      el("td", null, product.is_active ? "Active" : "Inactive"),
+      el("td", { class: "actions" },
+        el("button", { type: "button", onclick: () => editProduct(product, categories) }, "Edit"),
+        " ",
+        el("button", { type: "button", onclick: () => deleteProduct(product) }, "Delete"))));
+
+    return [
+      el("div", { class: "toolbar" },
+        search,
+        status,
+        el("button", { type: "button", onclick: () => navigate({ search: search.value, is_active: status.value, page: 1 }) }, "Filter"),
+        el("span", { class: "spacer" }),
+        el("button", { type: "button", onclick: () => editProduct(null, categories) }, "New product")),
+      table(["ID", "Name", "Category", "Price", "Cost", "Stock", "Status", ""], rows),
+      pager(data, (next) => navigate({ page: next })),
+    ];
+  },
+
+  async categories() {
+    categoriesCache = null;
+    const categories = await loadCategories();
+    const names = new Map(categories.map((category) => [category.id, category.name]));
+
+    const rows = categories.map((category) => el("tr", null,
+      el("td", null, String(category.id)),
+      el("td", null, category.name),
+      el("td", null, category.parent_id ? names.get(category.parent_id) || String(category.parent_id) : ""),
+      el("td", null, category.description || ""),
+      el("td", { class: "actions" },
+        el("button", { type: "button", onclick: () => editCategory(category, categories) }, "Edit"),
+        " ",
+        el("button", { type: "button", onclick: () => deleteCategory(category) }, "Delete"))));
+
+    return [
+      el("div", { class: "toolbar" },
+        el("span", { class: "spacer" }),
+        el("button", { type: "button", onclick: () => editCategory(null, categories) }, "New category")),
+      table(["ID", "Name", "Parent", "Description", ""], rows),
+    ];
+  },
+
+  async users(params) {
+    const page = Number(params.get("page") || 1);
+    const filters = { email: params.get("email") || "", status: params.get("status") || "" };
+    const data = await api("GET", "/admin/users?" + query({ ...filters, page, limit: PAGE_SIZE }));
+
+    const navigate = (changes) => {
+      location.hash = "#/users?" + query({ ...filters, page, ...changes });
+    };
+
+    const email = el("input", { type: "search", placeholder: "Email contains", value: filters.email });
+    const status = el("select", null,
+      [["", "Any status"], ["active", "Active"], ["suspended", "Suspended"], ["deleted", "Deleted"]].map(([value, text]) =>
+        el("option", { value, selected: value === filters.status }, text)));
+
+    const rows = data.users.map((user) => el("tr", { class: user.status === "active" ? null : "muted" },
+      el("td", null, String(user.id)),
+      el("td", null, user.email),
+      el("td", null, user.status),
+      el("td", null, user.status_reason || ""),
+      el("td", null, user.last_login_at ? new Date(user.last_login_at).toLocaleString() : ""),
+      el("td", null, user.ltv ? money(user.ltv.total_spend) : ""),
+      el("td", { class: "actions" },
+        el("button", { type: "button", onclick: () => changeUserStatus(user) }, "Change status"))));
+
+    return [
+      el("div", { class: "toolbar" },
+        email,
+        status,
+        el("button", { type: "button", onclick: () => navigate({ email: email.value, status: status.value, page: 1 }) }, "Filter")),
+      table(["ID", "Email", "Status", "Reason", "Last sign-in", "Spend", ""], rows),
+      pager(data, (next) => navigate({ page: next })),
+    ];
+  },
+
+  async orders(params) {
+    const page = Number(params.get("page") || 1);
+    const filters = { user_id: params.get("user_id") || "", from: params.get("from") || "", to: params.get("to") || "" };
+    const range = { user_id: filters.user_id, from: startOfDay(filters.from), to: startOfDay(filters.to) };
+    const data = await api("GET", "/admin/orders?" + query({ ...range, page, limit: PAGE_SIZE }));
+
+    const navigate = (changes) => {
+      location.hash = "#/orders?" + query({ ...filters, page, ...changes });
+    };
+
+    const user = el("input", { type: "number", min: 1, placeholder: "User ID", value: filters.user_id });
+    const from = el("input", { type: "date", title: "Purchased on or after", value: filters.from });
+    const to = el("input", { type: "date", title: "Purchased before", value: filters.to });
+
+    const rows = data.orders.map((order) => el("tr", null,
+      el("td", null, new Date(order.purchased_at).toLocaleString()),
+      el("td", null, order.id),
+      el("td", null, order.user_id ? String(order.user_id) : "Guest"),
+      el("td", null, order.product_name || "Product " + order.product_id),
+      el("td", null, String(order.quantity)),
+      el("td", null, money(order.price)),
+      el("td", null, money(order.total)),
+      el("td", { class: "actions" },
+        el("button", { type: "button", onclick: () => orderNotes(order) }, "Notes"))));
+
+    return [
+      el("div", { class: "toolbar" },
+        user,
+        from,
+        to,
+        el("button", { type: "button", onclick: () => navigate({ user_id: user.value, from: from.value, to: to.value, page: 1 }) }, "Filter"),
+        el("span", { class: "spacer" }),
+        el("button", { type: "button", onclick: () => download("/admin/orders?" + query({ ...range, format: "csv" })).catch(showError) }, "Export CSV")),
+      table(["Purchased", "Order", "User", "Product", "Quantity", "Price", "Total", ""], rows),
+      pager(data, (next) => navigate({ page: next })),
+    ];
+  },
+};
+
+// Editors
+
+function editProduct(product, categories) {
+  const p = product || { is_active: true, stock: 0 };
+  const fields = [
+    field("Name", "name", p.name, { required: true }),
+    el("label", null, "Description", el("textarea", { name: "description", rows: 3 }, p.description || "")),
+    select("Category", "category_id", categoryOptions(categories, "No category"), p.category_id),
+    field("Price", "price", p.price && p.price.amount, { required: true, inputmode: "decimal" }),
+    field("Cost price", "cost_price", p.cost_price && p.cost_price.amount, { inputmode: "decimal" }),
+    field("Stock", "stock", p.stock, { type: "number", min: 0 }),
+    product ? checkbox("Active", "is_active", p.is_active) : null,
+    checkbox("Allow a price below cost", "allow_below_cost", false),
+  ];
+
+  edit(product ? "Edit product " + product.id : "New product", fields, (form) => {
+    const body = {
+      name: form.get("name"),
+      description: form.get("description"),
+      category_id: optionalInt(form.get("category_id")),
+      price: form.get("price"),
+      stock: Number(form.get("stock") || 0),
+      allow_below_cost: form.has("allow_below_cost"),
+    };
+    if (form.get("cost_price")) body.cost_price = form.get("cost_price");
+    if (!product) return api("POST", "/products", body);
+    body.is_active = form.has("is_active");
+    return api("PUT", "/products/" + product.id, body);
+  });
+}
+
+async function deleteProduct(product) {
+  if (!confirm(`Delete product "${product.name}"?`)) return;
+  try {
+    await api("DELETE", "/products/" + product.id);
+    route();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function editCategory(category, categories) {
+  const c = category || {};
+  const parents = categories.filter((other) => !category || other.id !== category.id);
+  const fields = [
+    field("Name", "name", c.name, { required: true }),
+    field("Description", "description", c.description),
+    select("Parent", "parent_id", categoryOptions(parents, "None (top level)"), c.parent_id),
+  ];
+
+  edit(category ? "Edit category " + category.id : "New category", fields, (form) => {
+    const body = {
+      name: form.get("name"),
+      description: form.get("description"),
+      parent_id: optionalInt(form.get("parent_id")),
+    };
+    return category ? api("PUT", "/categories/" + category.id, body) : api("POST", "/categories", body);
+  });
+}
+
+async function deleteCategory(category) {
+  if (!confirm(`Delete category "${category.name}"?`)) return;
+  try {
+    await api("DELETE", "/categories/" + category.id);
+    route();
+  } catch (err) {
+    // Categories with products or subcategories are kept unless their contents are moved
+    showError(err.status === 409 ? new Error(err.message + "; merge it into another category through the API instead") : err);
+  }
+}
+
+function changeUserStatus(user) {
+  const fields = [
+    select("Status", "status", [["active", "Active"], ["suspended", "Suspended"], ["deleted", "Deleted"]], user.status),
+    field("Reason", "reason", "", { required: true, maxlength: 500 }),
+  ];
+
+  edit("Change status of " + user.email, fields, (form) =>
+    api("PUT", `/admin/users/${user.id}/status`, { status: form.get("status"), reason: form.get("reason") }));
+}
+
+// orderNotes lists the support notes on the order in the editor dialog, which adds a new one
+async function orderNotes(order) {
+  let data;
+  try {
+    data = await api("GET", `/admin/orders/${order.id}/notes?limit=100`);
+  } catch (err) {
+    showError(err.status === 403 ? new Error("Your account doesn't have permission to read support notes") : err);
+    return;
+  }
+
+  const notes = data.notes.map((note) => el("li", null,
+    el("small", null, `${new Date(note.created_at).toLocaleString()} by user ${note.author_id}${note.pinned ? ", pinned" : ""}`),
+    el("p", null, note.text)));
+  const fields = [
+    notes.length ? el("ul", { class: "notes" }, notes) : el("p", null, "No notes yet."),
+    el("label", null, "New note", el("textarea", { name: "text", rows: 3, required: true, maxlength: 5000 })),
+    checkbox("Pinned", "pinned", false),
+  ];
+
+  edit("Notes on order " + order.id, fields, (form) =>
+    api("POST", `/admin/orders/${order.id}/notes`, { text: form.get("text"), pinned: form.has("pinned") }));
+}
+
+// Routing
+
+async function route() {
+  showError(null);
+  const [name, search] = location.hash.replace(/^#\//, "").split("?");
+  const page = pages[name] ? name : "products";
+  for (const link of document.querySelectorAll("header nav a")) {
+    link.classList.toggle("active", link.getAttribute("href") === "#/" + page);
+  }
+
+  try {
+    const content = await pages[page](new URLSearchParams(search || ""));
+    document.getElementById("view").replaceChildren(...content);
+  } catch (err) {
+    document.getElementById("view").replaceChildren();
+    showError(err.status === 403 ? new Error("Your account doesn't have permission to open this page") : err);
+  }
+}
+
+function render() {
+  const signedIn = Boolean(session.access);
+  document.getElementById("login").hidden = signedIn;
+  document.getElementById("app").hidden = !signedIn;
+  if (signedIn) {
+    document.getElementById("whoami").textContent = session.email || "";
+    route();
+  }
+}
+
+document.getElementById("login-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = new FormData(event.target);
+  const errorText = document.getElementById("login-error");
+  errorText.textContent = "";
+
+  const response = await fetch(API + "/auth/login", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ email: form.get("email"), password: form.get("password") }),
+  });
+  const data = await response.json().catch(() => null);
+  if (!response.ok) {
+    errorText.textContent = (data && data.error) || "Sign-in failed";
+    return;
+  }
+  session.save(data);
+  event.target.reset();
+  render();
+});
+
+document.getElementById("logout").addEventListener("click", () => {
+  session.clear();
+  categoriesCache = null;
+  render();
+});
+
+window.addEventListener("hashchange", route);
+render();
@@ -3,7 +3,6 @@ package dto
 import (
 	"fmt"
 
-	"github.com/go-playground/validator/v10"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/PrimeraAizen/e-comm/internal/domain"
@@ -16,9 +15,9 @@ type RegisterRequest struct {
 }
 
 func (r *RegisterRequest) Validate() error {
-	validate := validator.New()
+	validate := newValidator()
 	if err := validate.Struct(r); err != nil {
-		return domain.ErrValidation
+		return validationError(err)
 	}
 	return nil
 }
@@ -41,9 +40,9 @@ type LoginRequest struct {
 }
 
 func (l *LoginRequest) Validate() error {
-	validate := validator.New()
+	validate := newValidator()
 	if err := validate.Struct(l); err != nil {
-		return domain.ErrValidation
+		return validationError(err)
 	}
 	return nil
 }
@@ -60,24 +59,33 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// SudoRequest re-authenticates the signed-in user for sensitive operations
+type SudoRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
 // ProfileResponse represents user profile information
 type ProfileResponse struct {
-	ID          int    `json:"id"`
-	UserID      int    `json:"user_id"`
-	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	MiddleName  string `json:"middle_name,omitempty"`
-	DateOfBirth string `json:"date_of_birth,omitempty"`
-	Gender      string `json:"gender,omitempty"`
-	Phone       string `json:"phone,omitempty"`
-	Address     string `json:"address,omitempty"`
-	City        string `json:"city,omitempty"`
-	Country     string `json:"country,omitempty"`
-	PostalCode  string `json:"postal_code,omitempty"`
-	Email       string `json:"email"`
-	Status      string `json:"status"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID            int    `json:"id"`
+	UserID        int    `json:"user_id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	MiddleName    string `json:"middle_name,omitempty"`
+	DateOfBirth   string `json:"date_of_birth,omitempty"`
+	Gender        string `json:"gender,omitempty"`
+	Phone         string `json:"phone,omitempty"`
+	PhoneVerified bool   `json:"phone_verified"`
+	Address       string `json:"address,omitempty"`
+	City          string `json:"city,omitempty"`
+	Country       string `json:"country,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Email         string `json:"email"`
+	Status        string `json:"status"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+
+	// QuarantinedFields are the fields of an update held for moderation instead of saved
+	QuarantinedFields []string `json:"quarantined_fields,omitempty"`
 }
 
 // UpdateProfileRequest represents profile update request
@@ -95,13 +103,42 @@ type UpdateProfileRequest struct {
 }
 
 func (u *UpdateProfileRequest) Validate() error {
-	validate := validator.New()
+	validate := newValidator()
 	if err := validate.Struct(u); err != nil {
-		return domain.ErrValidation
+		return validationError(err)
 	}
 	return nil
 }
 
+// UpdatePreferencesRequest represents a partial preferences update; omitted fields are unchanged
+type UpdatePreferencesRequest struct {
+	Locale              *string `json:"locale" example:"en-US"`
+	Currency            *string `json:"currency" example:"USD"`
+	Timezone            *string `json:"timezone" example:"Asia/Almaty"`
+	FavoriteCategoryIDs *[]int  `json:"favorite_category_ids"`
+	MarketingOptIns     *struct {
+		Email *bool `json:"email"`
+		SMS   *bool `json:"sms"`
+	} `json:"marketing_opt_ins"`
+	PromotionsOptOut *bool `json:"promotions_opt_out"` // opt out of birthday and anniversary promotions
+}
+
+// ToDomain converts the request into a domain preferences update
+func (r *UpdatePreferencesRequest) ToDomain() domain.PreferencesUpdate {
+	update := domain.PreferencesUpdate{
+		Locale:              r.Locale,
+		Currency:            r.Currency,
+		Timezone:            r.Timezone,
+		FavoriteCategoryIDs: r.FavoriteCategoryIDs,
+		PromotionsOptOut:    r.PromotionsOptOut,
+	}
+	if r.MarketingOptIns != nil {
+		update.MarketingEmail = r.MarketingOptIns.Email
+		update.MarketingSMS = r.MarketingOptIns.SMS
+	}
+	return update
+}
+
 // ChangePasswordRequest represents password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" validate:"required"`
@@ -110,22 +147,58 @@ type ChangePasswordRequest struct {
 }
 
 func (c *ChangePasswordRequest) Validate() error {
-	validate := validator.New()
+	validate := newValidator()
 	if err := validate.Struct(c); err != nil {
-		return domain.ErrValidation
+		return validationError(err)
 	}
 	if c.NewPassword != c.ConfirmPassword {
-		return fmt.Errorf("passwords do not match")
+		return fmt.Errorf("passwords do not match: %w", domain.ErrValidation)
 	}
 	return nil
 }
 
+// ChangeEmailRequest represents an email change request
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (c *ChangeEmailRequest) Validate() error {
+	validate := newValidator()
+	if err := validate.Struct(c); err != nil {
+		return validationError(err)
+	}
+	return nil
+}
+
+// ConfirmEmailRequest represents an email change confirmation
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyPhoneRequest represents a phone verification code submission
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// SessionResponse represents a device the user is logged in from
+type SessionResponse struct {
+	ID         int    `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"` // added to every error response
 }
 
 // SuccessResponse represents a success response
 type SuccessResponse struct {
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
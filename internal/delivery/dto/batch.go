@@ -0,0 +1,17 @@
+package dto
+
+import "encoding/json"
+
+// BatchRequest is a single sub-request of a batch call
+type BatchRequest struct {
+	Method string          `json:"method" binding:"required" example:"GET"`
+	Path   string          `json:"path" binding:"required" example:"/api/v1/products/1"`
+	Body   json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+}
+
+// BatchResponse is the response of a single sub-request, in the same position as its request
+type BatchResponse struct {
+	Status  int               `json:"status" example:"200"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty" swaggertype:"object"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// CreateBundleRequest represents a request to create a bundle of products
+type CreateBundleRequest struct {
+	Name        string                   `json:"name" binding:"required"`
+	Description string                   `json:"description"`
+	Price       Amount                   `json:"price" binding:"required" swaggertype:"string" example:"49.99"`
+	Components  []domain.BundleComponent `json:"components" binding:"required"`
+	SegmentIDs  []int                    `json:"segment_ids"` // offer only to members of these segments
+}
+
+// UpdateBundleRequest updates the given bundle fields; components replace the current ones
+type UpdateBundleRequest struct {
+	Name        *string                  `json:"name"`
+	Description *string                  `json:"description"`
+	Price       *Amount                  `json:"price" swaggertype:"string" example:"49.99"`
+	Components  []domain.BundleComponent `json:"components"`
+	IsActive    *bool                    `json:"is_active"`
+	SegmentIDs  *[]int                   `json:"segment_ids"` // [] offers the bundle to everyone
+}
+
+// PurchaseBundleRequest represents a request to purchase a bundle
+type PurchaseBundleRequest struct {
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+	Source   string `json:"source" binding:"omitempty,oneof=search recommendation category" example:"search"` // page the purchase was made from
+}
+
+// BundleListResponse is a page of bundles, newest first
+type BundleListResponse struct {
+	Bundles []*domain.Bundle `json:"bundles"`
+	Pagination
+}
@@ -0,0 +1,12 @@
+package dto
+
+// SetCartItemRequest sets the quantity of a product in the cart; 0 removes it
+type SetCartItemRequest struct {
+	Quantity *int   `json:"quantity" binding:"required,min=0"`
+	Source   string `json:"source" binding:"omitempty,oneof=search recommendation category" example:"search"` // page the product was added from, kept for its purchase
+}
+
+// CheckoutRequest optionally redeems a coupon at checkout
+type CheckoutRequest struct {
+	Coupon string `json:"coupon" binding:"omitempty,max=32" example:"MFRGGZDF"` // code of one of the user's coupons
+}
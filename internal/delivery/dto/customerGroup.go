@@ -0,0 +1,53 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// CustomerGroupRequest represents a request to create a customer group or replace it
+type CustomerGroupRequest struct {
+	Name        string                  `json:"name" binding:"required,max=100" example:"wholesale"`
+	Description string                  `json:"description" binding:"max=500" example:"Resellers buying in bulk"`
+	PercentOff  int                     `json:"percent_off" binding:"min=0,max=100" example:"10"` // discount on products no rule applies to
+	Rules       []GroupPriceRuleRequest `json:"rules" binding:"dive"`
+
+	AllowBelowCost bool `json:"allow_below_cost"` // price products below their cost_price
+}
+
+// GroupPriceRuleRequest sets a product's price, or a discount on a product or category, for a group
+type GroupPriceRuleRequest struct {
+	ProductID  *int    `json:"product_id" example:"3"`
+	CategoryID *int    `json:"category_id"`
+	Price      *Amount `json:"price" swaggertype:"string" example:"849.00"` // products only, in the storefront currency
+	PercentOff int     `json:"percent_off" example:"0"`
+}
+
+// ToDomain converts the request to a customer group with prices in currency
+func (r *CustomerGroupRequest) ToDomain(currency string) (*domain.CustomerGroup, error) {
+	group := &domain.CustomerGroup{
+		Name:        r.Name,
+		Description: r.Description,
+		PercentOff:  r.PercentOff,
+		Rules:       make([]domain.GroupPriceRule, len(r.Rules)),
+
+		AllowBelowCost: r.AllowBelowCost,
+	}
+	for i, rule := range r.Rules {
+		group.Rules[i] = domain.GroupPriceRule{
+			ProductID:  rule.ProductID,
+			CategoryID: rule.CategoryID,
+			PercentOff: rule.PercentOff,
+		}
+		if rule.Price != nil {
+			price, err := rule.Price.Money(currency)
+			if err != nil {
+				return nil, err
+			}
+			group.Rules[i].Price = &price
+		}
+	}
+	return group, nil
+}
+
+// AssignCustomerGroupRequest assigns a user to a customer group; null assigns them to none
+type AssignCustomerGroupRequest struct {
+	CustomerGroupID *int `json:"customer_group_id" example:"2"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+// Envelope is the response body of every /api/v2 endpoint. Successful responses carry
+// Data, failed ones carry Errors; Meta is always present.
+type Envelope struct {
+	Data   interface{} `json:"data,omitempty"`
+	Meta   Meta        `json:"meta"`
+	Errors []APIError  `json:"errors,omitempty"`
+}
+
+// Meta holds response metadata
+type Meta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// APIError describes a single error of a v2 response
+type APIError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// FlashSaleRequest sets a product's flash sale: quantity units at price from starts_at
+type FlashSaleRequest struct {
+	StartsAt       time.Time  `json:"starts_at" binding:"required"`
+	EndsAt         *time.Time `json:"ends_at"` // omit to sell until sold out
+	Price          Amount     `json:"price" binding:"required" swaggertype:"string" example:"499.00"`
+	Quantity       int        `json:"quantity" binding:"required,min=1" example:"100"`
+	AdmitPerSecond int        `json:"admit_per_second" binding:"min=0" example:"20"` // 0 for the configured default
+	AllowBelowCost bool       `json:"allow_below_cost"`                              // sell below the product's cost_price
+}
+
+// ToDomain converts the request to a flash sale in currency
+func (r *FlashSaleRequest) ToDomain(currency string) (*domain.FlashSale, error) {
+	price, err := r.Price.Money(currency)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.FlashSale{
+		StartsAt:       r.StartsAt,
+		EndsAt:         r.EndsAt,
+		Price:          price,
+		Quantity:       r.Quantity,
+		AdmitPerSecond: r.AdmitPerSecond,
+		AllowBelowCost: r.AllowBelowCost,
+	}, nil
+}
+
+// FlashSalePurchaseRequest buys from a flash sale with the token of an admitted queue ticket
+type FlashSalePurchaseRequest struct {
+	Quantity int    `json:"quantity" binding:"required,min=1" example:"1"`
+	Token    string `json:"token" binding:"required"`
+	Source   string `json:"source" binding:"omitempty,oneof=search recommendation category" example:"search"` // page the purchase was made from
+}
+
+// QueueWaitResponse rejects a flash sale purchase made before its queue position is admitted
+type QueueWaitResponse struct {
+	Error   string    `json:"error"`
+	Code    string    `json:"code" example:"not_admitted"`
+	AdmitAt time.Time `json:"admit_at"`
+}
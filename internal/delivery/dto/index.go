@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// IndexListResponse lists the indexes of each collection against the expected ones
+type IndexListResponse struct {
+	Collections []domain.CollectionIndexes `json:"collections"`
+	Missing     int                        `json:"missing" example:"0"` // expected indexes missing across all collections
+	TotalSize   int64                      `json:"total_size"`          // bytes used by all indexes
+}
+
+// BuildIndexesResponse lists the indexes being built, as collection.index
+type BuildIndexesResponse struct {
+	Building []string `json:"building" example:"users.email_1"`
+}
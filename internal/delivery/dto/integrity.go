@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// IntegrityReportResponse lists the orphaned documents found by each integrity check
+type IntegrityReportResponse struct {
+	Issues  []domain.IntegrityIssue `json:"issues"`
+	Orphans int64                   `json:"orphans" example:"0"` // orphaned documents across all checks
+}
+
+// IntegrityRepairRequest names the checks whose orphaned documents to repair or quarantine.
+// Without checks, every check that found orphans is handled.
+type IntegrityRepairRequest struct {
+	Checks []string `json:"checks" example:"view_product,like_product"`
+	Action string   `json:"action" binding:"required,oneof=repair quarantine" example:"repair"`
+}
+
+// IntegrityRepairResponse reports how many documents were changed per check
+type IntegrityRepairResponse struct {
+	Repairs []domain.IntegrityRepair `json:"repairs"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// ExportColumn describes a column of a columnar export batch using Parquet physical types
+type ExportColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// InteractionExportColumnarResponse is a column-oriented batch of interaction events.
+// Each entry in Columns holds the values of one schema column, so the batch can be
+// written to Parquet (or loaded into a dataframe) without reshaping.
+type InteractionExportColumnarResponse struct {
+	Schema     []ExportColumn         `json:"schema"`
+	RowCount   int                    `json:"row_count"`
+	Columns    map[string]interface{} `json:"columns"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+// ActivityResponse is a page of the user's activity timeline, newest first
+type ActivityResponse struct {
+	Items []domain.ActivityItem `json:"items"`
+	Pagination
+}
+
+// AdminActivityResponse is a page of the admin activity feed, newest first
+type AdminActivityResponse struct {
+	Items []domain.AdminActivityItem `json:"items"`
+	Pagination
+}
+
+// ViewHistoryResponse is a page of the products the user viewed, most recent first
+type ViewHistoryResponse struct {
+	Views []domain.ProductInteraction `json:"views"`
+	Pagination
+}
+
+// LikedProductsResponse is a page of the products the user liked, most recent first
+type LikedProductsResponse struct {
+	Likes []domain.ProductInteraction `json:"likes"`
+	Pagination
+}
+
+// PurchaseHistoryResponse is a page of the products the user purchased, most recent first
+type PurchaseHistoryResponse struct {
+	Purchases []domain.ProductInteraction `json:"purchases"`
+	Pagination
+}
+
+// RecordViewRequest is the optional engagement a client reports with a product view, and the page
+// the user came from
+type RecordViewRequest struct {
+	DwellSeconds int    `json:"dwell_seconds" binding:"min=0,max=3600" example:"42"`
+	ScrollDepth  int    `json:"scroll_depth" binding:"min=0,max=100" example:"80"` // percent of the page
+	Source       string `json:"source" binding:"omitempty,oneof=search recommendation category" example:"search"`
+	SearchID     string `json:"search_id" example:"6710a3c2e4b0a1b2c3d4e5f6"` // of the search the product was opened from
+}
@@ -0,0 +1,7 @@
+package dto
+
+// SetMaintenanceRequest switches maintenance mode
+type SetMaintenanceRequest struct {
+	Enabled    *bool `json:"enabled" binding:"required"`
+	RetryAfter int   `json:"retry_after" binding:"min=0,max=86400" example:"600"` // seconds; the configured default when 0
+}
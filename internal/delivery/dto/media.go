@@ -0,0 +1,7 @@
+package dto
+
+// SignMediaRequest asks for a signed URL to a private file
+type SignMediaRequest struct {
+	Path string `json:"path" binding:"required" example:"invoices/2025/0001.pdf"`
+	TTL  string `json:"ttl" example:"1h"` // Go duration; the configured default if empty
+}
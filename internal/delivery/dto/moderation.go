@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// ResolveModerationItemRequest represents a moderator's decision on quarantined content
+type ResolveModerationItemRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// ModerationItemListResponse is a page of quarantined content, oldest first
+type ModerationItemListResponse struct {
+	Items []*domain.ModerationItem `json:"items"`
+	Pagination
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// Amount is a decimal amount of the storefront currency in a request, e.g. "999.99" or 999.99.
+// It is kept as written and parsed into minor units, so it is never rounded through a float.
+type Amount string
+
+// UnmarshalJSON accepts the amount as a JSON string or number
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text, err := domain.DecimalText(data)
+	if err != nil {
+		return err
+	}
+	*a = Amount(text)
+	return nil
+}
+
+// Money parses the amount in the currency; an empty amount is zero
+func (a Amount) Money(currency string) (domain.Money, error) {
+	if a == "" {
+		return domain.NewMoney(0, currency), nil
+	}
+	return domain.ParseMoney(string(a), currency)
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// CreateSupportNoteRequest represents a support note to attach to a user or order
+type CreateSupportNoteRequest struct {
+	Text   string `json:"text" binding:"required,max=5000" example:"Called about a late delivery, promised a follow-up on Friday"`
+	Pinned bool   `json:"pinned" example:"false"`
+}
+
+// UpdateSupportNoteRequest represents changes to a support note; omitted fields are kept
+type UpdateSupportNoteRequest struct {
+	Text   *string `json:"text" binding:"omitempty,max=5000"`
+	Pinned *bool   `json:"pinned" example:"true"`
+}
+
+// SupportNoteListResponse is a page of the notes on a user or order, pinned ones first
+type SupportNoteListResponse struct {
+	Notes []*domain.SupportNote `json:"notes"`
+	Pagination
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// OrderListResponse is a page of orders for staff
+type OrderListResponse struct {
+	Orders []*domain.Order `json:"orders"`
+	Pagination
+}
@@ -0,0 +1,18 @@
+package dto
+
+// Pagination describes the page of a list response. v1 list responses embed it next to their
+// items; v2 moves it into meta.pagination.
+//
+// The cursors are opaque: pass one as the cursor query parameter, in place of page and limit,
+// to get the next or previous page. They are omitted on the last and first page.
+type Pagination struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+
+	// Set when total is an estimate, reused from an earlier count of a large listing
+	TotalIsEstimate bool `json:"total_is_estimate,omitempty"`
+}
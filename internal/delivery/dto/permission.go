@@ -0,0 +1,21 @@
+package dto
+
+// CreatePermissionRequest represents a request to create a resource:action permission
+type CreatePermissionRequest struct {
+	Resource    string `json:"resource" binding:"required" example:"products"`
+	Action      string `json:"action" binding:"required" example:"write"`
+	Description string `json:"description" example:"Create, update and delete products"`
+}
+
+// CreateRoleRequest represents a request to create a role
+type CreateRoleRequest struct {
+	Name        string `json:"name" binding:"required" example:"support"`
+	Description string `json:"description" example:"Customer support agent"`
+	Inherits    []int  `json:"inherits" example:"2"` // IDs of the roles whose permissions it inherits
+}
+
+// UpdateRoleRequest represents a request to change a role; its name is fixed
+type UpdateRoleRequest struct {
+	Description string `json:"description" example:"Customer support agent"`
+	Inherits    []int  `json:"inherits" example:"2"`
+}
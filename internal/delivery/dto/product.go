@@ -1,33 +1,208 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 )
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	CategoryID  *int    `json:"category_id"`
-	Price       float64 `json:"price" binding:"required,min=0"`
-	Stock       int     `json:"stock" binding:"min=0"`
-	ImageURL    string  `json:"image_url"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	CategoryID  *int   `json:"category_id"`
+	Price       Amount `json:"price" binding:"required" swaggertype:"string" example:"999.99"`
+	CostPrice   Amount `json:"cost_price" swaggertype:"string" example:"640.00"` // never returned, see the inventory report
+	Stock       int    `json:"stock" binding:"min=0"`
+	ImageURL    string `json:"image_url"`
+
+	AllowBelowCost bool `json:"allow_below_cost"` // save a price below cost_price
+
+	PriceTiers []PriceTierRequest `json:"price_tiers" binding:"dive"`
+
+	// Order quantity rules, 0 for none
+	MinOrderQty int `json:"min_order_qty" binding:"min=0" example:"2"`
+	MaxOrderQty int `json:"max_order_qty" binding:"min=0" example:"20"`
+	QtyStep     int `json:"qty_step" binding:"min=0" example:"2"`
+
+	PurchaseLimit *PurchaseLimitRequest `json:"purchase_limit"` // per signed-in user; guests can't buy the product
 }
 
 type UpdateProductRequest struct {
-	Name        *string  `json:"name"`
-	Description *string  `json:"description"`
-	CategoryID  *int     `json:"category_id"`
-	Price       *float64 `json:"price"`
-	Stock       *int     `json:"stock"`
-	ImageURL    *string  `json:"image_url"`
-	IsActive    *bool    `json:"is_active"`
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	CategoryID  *int    `json:"category_id"`
+	Price       *Amount `json:"price" swaggertype:"string" example:"999.99"`
+	CostPrice   *Amount `json:"cost_price" swaggertype:"string" example:"640.00"`
+	Stock       *int    `json:"stock"`
+	ImageURL    *string `json:"image_url"`
+	IsActive    *bool   `json:"is_active"`
+
+	AllowBelowCost bool `json:"allow_below_cost"` // save a price below cost_price
+
+	PriceTiers *[]PriceTierRequest `json:"price_tiers" binding:"omitempty,dive"` // replaces the tiers; [] removes them
+
+	MinOrderQty *int `json:"min_order_qty" binding:"omitempty,min=0"` // 0 removes the rule
+	MaxOrderQty *int `json:"max_order_qty" binding:"omitempty,min=0"`
+	QtyStep     *int `json:"qty_step" binding:"omitempty,min=0"`
+
+	PurchaseLimit *PurchaseLimitRequest `json:"purchase_limit"` // quantity 0 removes the limit
+}
+
+// PurchaseLimitRequest caps the units of a product each user can buy per window of window_hours
+type PurchaseLimitRequest struct {
+	Quantity    int `json:"quantity" binding:"min=0" example:"2"`
+	WindowHours int `json:"window_hours" binding:"min=0" example:"24"`
+}
+
+// ToDomain converts the request to a purchase limit, nil for quantity 0
+func (r *PurchaseLimitRequest) ToDomain() *domain.PurchaseLimit {
+	if r == nil || r.Quantity == 0 {
+		return nil
+	}
+	return &domain.PurchaseLimit{Quantity: r.Quantity, WindowHours: r.WindowHours}
+}
+
+// PriceTierRequest sets the unit price of a product bought at least min_quantity at a time
+type PriceTierRequest struct {
+	MinQuantity int    `json:"min_quantity" binding:"required,min=2" example:"5"`
+	Price       Amount `json:"price" binding:"required" swaggertype:"string" example:"899.00"`
+}
+
+// ToPriceTiers converts price tier requests to price tiers in currency
+func ToPriceTiers(tiers []PriceTierRequest, currency string) ([]domain.PriceTier, error) {
+	if len(tiers) == 0 {
+		return nil, nil
+	}
+	result := make([]domain.PriceTier, len(tiers))
+	for i, tier := range tiers {
+		price, err := tier.Price.Money(currency)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = domain.PriceTier{MinQuantity: tier.MinQuantity, Price: price}
+	}
+	return result, nil
+}
+
+// QuantityErrorResponse rejects a quantity the product's order quantity rules don't allow,
+// with the quantities they do
+type QuantityErrorResponse struct {
+	Error       string `json:"error"`
+	Code        string `json:"code" example:"invalid_quantity"`
+	ProductID   int    `json:"product_id" example:"3"`
+	Quantity    int    `json:"quantity" example:"3"`
+	MinOrderQty int    `json:"min_order_qty" example:"2"`
+	MaxOrderQty int    `json:"max_order_qty,omitempty" example:"20"` // left out without a maximum
+	QtyStep     int    `json:"qty_step" example:"2"`
+}
+
+// PurchaseLimitErrorResponse rejects a purchase over the user's purchase limit of a product
+type PurchaseLimitErrorResponse struct {
+	Error       string    `json:"error"`
+	Code        string    `json:"code" example:"purchase_limit_exceeded"`
+	ProductID   int       `json:"product_id" example:"3"`
+	Quantity    int       `json:"quantity" example:"2"` // requested
+	Limit       int       `json:"limit" example:"2"`
+	WindowHours int       `json:"window_hours" example:"24"`
+	Remaining   int       `json:"remaining" example:"1"`
+	ResetsAt    time.Time `json:"resets_at"`
+}
+
+// NewPurchaseLimitErrorResponse describes a purchase limit error
+func NewPurchaseLimitErrorResponse(err *domain.PurchaseLimitError) PurchaseLimitErrorResponse {
+	return PurchaseLimitErrorResponse{
+		Error:       err.Error(),
+		Code:        "purchase_limit_exceeded",
+		ProductID:   err.ProductID,
+		Quantity:    err.Quantity,
+		Limit:       err.Limit.Quantity,
+		WindowHours: err.Limit.WindowHours,
+		Remaining:   err.Remaining,
+		ResetsAt:    err.ResetsAt,
+	}
+}
+
+// NewQuantityErrorResponse describes a quantity error
+func NewQuantityErrorResponse(err *domain.QuantityError) QuantityErrorResponse {
+	return QuantityErrorResponse{
+		Error:       err.Error(),
+		Code:        "invalid_quantity",
+		ProductID:   err.ProductID,
+		Quantity:    err.Quantity,
+		MinOrderQty: err.Min,
+		MaxOrderQty: err.Max,
+		QtyStep:     err.Step,
+	}
+}
+
+// ProductImageResponse acknowledges an uploaded product image. The image and its variants are set
+// on the product once generated.
+type ProductImageResponse struct {
+	ProductID  int       `json:"product_id" example:"1"`
+	Status     string    `json:"status" example:"processing"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// ProductTranslationRequest is the localized text of a product for one locale
+type ProductTranslationRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
 }
 
 type ProductListResponse struct {
 	Products []*domain.ProductWithCategory `json:"products"`
-	Total    int64                         `json:"total"`
-	Page     int                           `json:"page"`
-	Limit    int                           `json:"limit"`
+	Pagination
+}
+
+// AdminProductListResponse is a page of products with their cost prices and margins
+type AdminProductListResponse struct {
+	Products []*domain.AdminProduct `json:"products"`
+	Pagination
+}
+
+// ProductSearchResponse is a page of search results, best match first. Correction is set when
+// the query found nothing as typed. SearchID identifies the search in analytics; clients send it
+// with later pages and with the view of a product opened from the results.
+type ProductSearchResponse struct {
+	Results    []domain.SearchHit       `json:"results"`
+	Correction *domain.SearchCorrection `json:"correction,omitempty"`
+	SearchID   string                   `json:"search_id,omitempty"`
+	Pagination
+}
+
+// GlobalSearchResponse groups the best matches of each kind of entity for a search box
+type GlobalSearchResponse struct {
+	Query  string                     `json:"query"`
+	Groups []domain.GlobalSearchGroup `json:"groups"`
+}
+
+// CategoryListResponse is a page of categories ordered by name
+type CategoryListResponse struct {
+	Categories []*domain.Category `json:"categories"`
+	Pagination
+}
+
+// StockAdjustmentRequest is a manual change to a product's stock. The signed-in user is
+// recorded as the actor.
+type StockAdjustmentRequest struct {
+	Delta       int    `json:"delta" binding:"required"`
+	Reason      string `json:"reason" binding:"required"` // return, restock, damage or correction
+	Note        string `json:"note"`
+	WarehouseID int    `json:"warehouse_id"` // unallocated stock if 0
+}
+
+// StockTransferRequest moves stock of a product between warehouses
+type StockTransferRequest struct {
+	FromWarehouseID int    `json:"from_warehouse_id"` // unallocated stock if 0
+	ToWarehouseID   int    `json:"to_warehouse_id" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"required,min=1"`
+	Note            string `json:"note"`
+}
+
+// StockAdjustmentListResponse is a page of a product's stock ledger, newest first
+type StockAdjustmentListResponse struct {
+	Adjustments []domain.StockAdjustment `json:"adjustments"`
+	Pagination
 }
 
 type CreateCategoryRequest struct {
@@ -42,6 +217,13 @@ type UpdateCategoryRequest struct {
 	ParentID    *int    `json:"parent_id"`
 }
 
+// MergeCategoryRequest names the category that receives the merged category's contents
+type MergeCategoryRequest struct {
+	TargetID int `json:"target_id" binding:"required"`
+}
+
 type PurchaseProductRequest struct {
-	Quantity int `json:"quantity" binding:"required,min=1"`
+	Quantity    int    `json:"quantity" binding:"required,min=1"`
+	WarehouseID int    `json:"warehouse_id"`                                                                     // the warehouse with the most stock if 0
+	Source      string `json:"source" binding:"omitempty,oneof=search recommendation category" example:"search"` // page the purchase was made from
 }
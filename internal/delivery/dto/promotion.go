@@ -0,0 +1,13 @@
+package dto
+
+// PromotionRequest represents a request to create a promotion template or replace it
+type PromotionRequest struct {
+	Name       string `json:"name" binding:"required,max=100" example:"Birthday 15%"`
+	Occasion   string `json:"occasion" binding:"required,oneof=birthday anniversary" example:"birthday"`
+	PercentOff int    `json:"percent_off" binding:"required" example:"15"`
+	ValidDays  int    `json:"valid_days" binding:"required" example:"14"` // days the coupon can be redeemed after it is granted
+	Channel    string `json:"channel" binding:"required,oneof=email sms" example:"email"`
+	Subject    string `json:"subject" binding:"max=200" example:"Happy birthday, {first_name}!"`
+	Body       string `json:"body" binding:"required,max=5000" example:"Enjoy {percent_off}% off with code {code} until {expires_on}."`
+	IsActive   bool   `json:"is_active" example:"true"`
+}
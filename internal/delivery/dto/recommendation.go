@@ -0,0 +1,12 @@
+package dto
+
+// RecommendationFeedbackRequest represents feedback on a recommended product
+type RecommendationFeedbackRequest struct {
+	Type string `json:"type" binding:"required,oneof=dismissed not_interested already_own"`
+}
+
+// RecommendationClickRequest represents a click on a recommended product
+type RecommendationClickRequest struct {
+	Algorithm string `json:"algorithm"`
+	Position  int    `json:"position" binding:"min=0"`
+}
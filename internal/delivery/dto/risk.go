@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// ResolveRiskReviewRequest represents a reviewer's decision on a flagged order
+type ResolveRiskReviewRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// RiskReviewListResponse is a page of flagged orders, oldest first
+type RiskReviewListResponse struct {
+	Reviews []*domain.RiskReview `json:"reviews"`
+	Pagination
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// SegmentRequest represents a request to create a segment or replace its definition
+type SegmentRequest struct {
+	Name        string               `json:"name" binding:"required" example:"recent_buyers_kz"`
+	Description string               `json:"description" example:"Bought something in the last 30 days, living in Kazakhstan"`
+	Rules       []domain.SegmentRule `json:"rules" binding:"required"` // a user is a member when all rules match
+}
+
+// SegmentMembersResponse is a page of the IDs of a segment's members
+type SegmentMembersResponse struct {
+	UserIDs []int `json:"user_ids"`
+	Pagination
+}
+
+// NotifySegmentRequest represents a marketing message to the members of a segment
+type NotifySegmentRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=email sms" example:"email"`
+	Subject string `json:"subject" binding:"max=200" example:"A gift for you"`
+	Body    string `json:"body" binding:"required,max=5000" example:"Enjoy 10% off your next order this week."`
+}
+
+// NotifySegmentResponse reports how many members a segment notification is sent to
+type NotifySegmentResponse struct {
+	SegmentID int   `json:"segment_id"`
+	Members   int64 `json:"members"`
+}
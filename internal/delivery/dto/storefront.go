@@ -0,0 +1,31 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/config"
+
+// StorefrontResponse is how the storefront of a request presents itself to customers
+type StorefrontResponse struct {
+	ID       string             `json:"id" example:"default"`
+	Name     string             `json:"name" example:"E-Comm"`
+	Currency string             `json:"currency" example:"USD"`
+	Branding StorefrontBranding `json:"branding"`
+}
+
+// StorefrontBranding is the look of a storefront
+type StorefrontBranding struct {
+	LogoURL      string `json:"logo_url,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty" example:"#0057b8"`
+	SupportEmail string `json:"support_email,omitempty"`
+}
+
+func NewStorefrontResponse(tenant *config.Tenant) StorefrontResponse {
+	return StorefrontResponse{
+		ID:       tenant.ID,
+		Name:     tenant.Name,
+		Currency: tenant.Currency,
+		Branding: StorefrontBranding{
+			LogoURL:      tenant.Branding.LogoURL,
+			PrimaryColor: tenant.Branding.PrimaryColor,
+			SupportEmail: tenant.Branding.SupportEmail,
+		},
+	}
+}
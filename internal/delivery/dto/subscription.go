@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// CreateSubscriptionPlanRequest represents a request to offer a product as a subscription
+type CreateSubscriptionPlanRequest struct {
+	Interval string `json:"interval" binding:"required,oneof=week month year"`
+	Price    Amount `json:"price" binding:"required" swaggertype:"string" example:"19.99"`
+}
+
+// SubscribeRequest represents a request to subscribe to a plan
+type SubscribeRequest struct {
+	PlanID   int `json:"plan_id" binding:"required"`
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+// SubscriptionListResponse is a page of subscriptions, newest first
+type SubscriptionListResponse struct {
+	Subscriptions []*domain.Subscription `json:"subscriptions"`
+	Pagination
+}
@@ -0,0 +1,17 @@
+package dto
+
+import (
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// AdminUserListResponse is a page of users with their lifetime value
+type AdminUserListResponse struct {
+	Users []domain.AdminUser `json:"users"`
+	Pagination
+}
+
+// ChangeUserStatusRequest moves a user to another status
+type ChangeUserStatusRequest struct {
+	Status string `json:"status" binding:"required" example:"suspended"`
+	Reason string `json:"reason" binding:"required" example:"Chargeback fraud under investigation"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// newValidator creates a validator that reports fields by their JSON names
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return validate
+}
+
+// validationError describes the first failed rule of a validator error, e.g.
+// "password must be at least 8 characters long: validation failed". The messages have
+// entries in the i18n catalogs, keep them in sync when adding rules.
+func validationError(err error) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) || len(fieldErrors) == 0 {
+		return domain.ErrValidation
+	}
+
+	field := fieldErrors[0]
+	var message string
+	switch field.Tag() {
+	case "required":
+		message = fmt.Sprintf("%s is required", field.Field())
+	case "email":
+		message = fmt.Sprintf("%s must be a valid email address", field.Field())
+	case "min":
+		message = fmt.Sprintf("%s must be at least %s characters long", field.Field(), field.Param())
+	case "max":
+		message = fmt.Sprintf("%s must be at most %s characters long", field.Field(), field.Param())
+	default:
+		message = fmt.Sprintf("%s is invalid", field.Field())
+	}
+
+	return fmt.Errorf("%s: %w", message, domain.ErrValidation)
+}
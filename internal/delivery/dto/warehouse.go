@@ -0,0 +1,24 @@
+package dto
+
+import "github.com/PrimeraAizen/e-comm/internal/domain"
+
+// CreateWarehouseRequest represents a request to create a warehouse
+type CreateWarehouseRequest struct {
+	Code    string `json:"code" binding:"required" example:"ALA-1"`
+	Name    string `json:"name" binding:"required" example:"Almaty central"`
+	Address string `json:"address"`
+}
+
+// UpdateWarehouseRequest updates the given warehouse fields
+type UpdateWarehouseRequest struct {
+	Code     *string `json:"code"`
+	Name     *string `json:"name"`
+	Address  *string `json:"address"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// InventoryListResponse is a page of the products held at a warehouse, ordered by product ID
+type InventoryListResponse struct {
+	Inventory []domain.InventoryLevel `json:"inventory"`
+	Pagination
+}
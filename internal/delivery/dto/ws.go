@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// WebSocket message actions and types
+const (
+	WSActionSubscribe   = "subscribe"
+	WSActionUnsubscribe = "unsubscribe"
+
+	WSTypeSubscribed   = "subscribed"
+	WSTypeUnsubscribed = "unsubscribed"
+	WSTypeUpdate       = "update"
+	WSTypeError        = "error"
+)
+
+// WSClientMessage is a message sent by a WebSocket client
+type WSClientMessage struct {
+	Action string   `json:"action" example:"subscribe"`
+	Topics []string `json:"topics" example:"orders,revenue"`
+}
+
+// WSServerMessage is a message sent to a WebSocket client
+type WSServerMessage struct {
+	Type    string      `json:"type"`
+	Topic   string      `json:"topic,omitempty"`
+	Topics  []string    `json:"topics,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	At      *time.Time  `json:"at,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
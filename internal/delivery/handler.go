@@ -10,13 +10,20 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
 	v1 "github.com/PrimeraAizen/e-comm/internal/delivery/rest/v1"
+	v2 "github.com/PrimeraAizen/e-comm/internal/delivery/rest/v2"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/ws"
 	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/pkg/i18n"
 	"github.com/PrimeraAizen/e-comm/pkg/logger"
 
 	_ "github.com/PrimeraAizen/e-comm/docs" // Import generated docs
 )
 
+// allowedOrigins are the browser origins allowed to call the API (CORS and WebSocket handshakes)
+var allowedOrigins = []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:8080"}
+
 type Handler struct {
 	services *service.Service
 	logger   *logger.Logger
@@ -32,12 +39,22 @@ func NewHandler(services *service.Service, appLogger *logger.Logger) *Handler {
 func (h *Handler) Init(cfg *config.Config) *gin.Engine {
 	router := gin.New()
 
+	messages, err := i18n.New()
+	if err != nil {
+		panic("failed to load message catalogs: " + err.Error())
+	}
+
+	allowHeaders := []string{"Origin", "Content-Type", "Accept", "Authorization", "If-None-Match", "If-Modified-Since", "Range", "If-Range", "X-Anonymous-ID", logger.RequestIDHeader}
+	if cfg.Tenancy.Enabled {
+		allowHeaders = append(allowHeaders, cfg.Tenancy.Header)
+	}
+
 	// CORS configuration
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:5173", "http://localhost:8080"},
+		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     allowHeaders,
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Accept-Ranges", "X-Next-Cursor", "ETag", "Last-Modified", "X-Anonymous-ID", "Retry-After", "X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset", "Age", logger.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -46,8 +63,16 @@ func (h *Handler) Init(cfg *config.Config) *gin.Engine {
 	router.Use(
 		logger.RequestIDMiddleware(),
 		logger.LoggingMiddleware(h.logger),
+		middleware.ErrorRequestID(),
 		logger.RecoveryMiddleware(h.logger),
 		logger.ContextMiddleware(h.logger),
+		middleware.RequestMetrics(h.services.LiveMetrics),
+		middleware.QueryTimeout(),
+		middleware.DatabaseBreaker(h.services.HealthService),
+		middleware.BodyLimit(cfg.Http.MaxBodySize, cfg.Http.MaxUploadSize),
+		middleware.Compress(),
+		middleware.Localize(messages),
+		middleware.ClientCountry(cfg.Risk.CountryHeader),
 	)
 
 	// Health check endpoint
@@ -55,18 +80,76 @@ func (h *Handler) Init(cfg *config.Config) *gin.Engine {
 		ctx.String(http.StatusOK, "pong")
 	})
 
+	// Public keys for verifying issued JWTs
+	router.GET("/.well-known/jwks.json", func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.JSON(http.StatusOK, h.services.AuthService.GetJWKS())
+	})
+
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	h.initAPI(router)
+	// Public media such as product images, shared by all storefronts
+	if cfg.Media.ServePublic {
+		maxAge, err := time.ParseDuration(cfg.Media.PublicMaxAge)
+		if err != nil {
+			panic("failed to parse media public max age: " + err.Error())
+		}
+		static := serveStatic(cfg.Media.PublicDir, maxAge)
+		router.GET("/media/*path", static)
+		router.HEAD("/media/*path", static)
+	}
+
+	// Admin UI, calling the API of the storefront it is opened on
+	if cfg.Http.AdminUI {
+		adminUI := serveAdminUI()
+		router.GET("/admin-ui/*path", adminUI)
+		router.HEAD("/admin-ui/*path", adminUI)
+	}
+
+	// Routes below are scoped to the storefront the request is for
+	router.Use(middleware.Tenant(&cfg.Tenancy))
+
+	// Writes are rejected during maintenance
+	router.Use(middleware.Maintenance(h.services.MaintenanceService,
+		"/api/v1/auth/login",
+		"/api/v1/auth/refresh",
+		"/api/v1/batch",
+		"/api/v1/admin/maintenance",
+	))
+
+	// Requests of signed-in users count against their monthly quota
+	router.Use(middleware.Quota(h.services.AuthService, h.services.UsageService,
+		"/api/v1/batch",
+		"/api/v1/profiles/me/usage",
+	))
+
+	// Live admin dashboard
+	ws.NewHandler(h.services, h.logger, allowedOrigins).Init(router)
+
+	h.initAPI(router, cfg)
 
 	return router
 }
 
-func (h *Handler) initAPI(router *gin.Engine) {
+func (h *Handler) initAPI(router *gin.Engine, cfg *config.Config) {
 	handlerV1 := v1.NewHandler(h.services, h.logger)
+	handlerV1.SetRouter(router)
+	if productCache := cfg.Http.ProductCache; productCache.Enabled {
+		freshFor, err := time.ParseDuration(productCache.FreshFor)
+		if err != nil {
+			panic("failed to parse product cache fresh_for: " + err.Error())
+		}
+		staleFor, err := time.ParseDuration(productCache.StaleFor)
+		if err != nil {
+			panic("failed to parse product cache stale_for: " + err.Error())
+		}
+		handlerV1.SetProductCache(freshFor, staleFor, productCache.MaxEntries)
+	}
+	handlerV2 := v2.NewHandler(h.services, h.logger)
 	api := router.Group("/api")
 	{
 		handlerV1.Init(api)
+		handlerV2.Init(api)
 	}
 }
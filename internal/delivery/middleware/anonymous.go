@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// AnonymousIDHeader carries the anonymous session ID for clients without cookies
+	AnonymousIDHeader = "X-Anonymous-ID"
+	// AnonymousIDCookie carries the anonymous session ID for browsers
+	AnonymousIDCookie = "anonymous_id"
+
+	anonymousCtxKey = "anonymousId"
+
+	anonymousIDMaxAge = 30 * 24 * 60 * 60 // 30 days, in seconds
+)
+
+var anonymousIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{16,64}$`)
+
+// AnonymousSession creates a middleware that identifies guests across requests. The ID is read
+// from the X-Anonymous-ID header or the anonymous_id cookie and issued (as both) when missing.
+// Authenticated requests are left alone, so use it after OptionalAuth.
+func AnonymousSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := GetUserID(c); err == nil {
+			c.Next()
+			return
+		}
+
+		anonymousID := ReadAnonymousID(c)
+		if anonymousID == "" {
+			anonymousID = newAnonymousID()
+			c.Header(AnonymousIDHeader, anonymousID)
+			c.SetSameSite(http.SameSiteLaxMode)
+			c.SetCookie(AnonymousIDCookie, anonymousID, anonymousIDMaxAge, "/", "", c.Request.TLS != nil, true)
+		}
+
+		c.Set(anonymousCtxKey, anonymousID)
+		c.Next()
+	}
+}
+
+// ReadAnonymousID returns the anonymous session ID sent with the request, or an empty string
+// when none (or a malformed one) is sent
+func ReadAnonymousID(c *gin.Context) string {
+	anonymousID := c.GetHeader(AnonymousIDHeader)
+	if anonymousID == "" {
+		anonymousID, _ = c.Cookie(AnonymousIDCookie)
+	}
+	if !anonymousIDPattern.MatchString(anonymousID) {
+		return ""
+	}
+	return anonymousID
+}
+
+// ClearAnonymousID expires the anonymous session cookie once the guest has signed in
+func ClearAnonymousID(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(AnonymousIDCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+}
+
+// GetAnonymousID retrieves the anonymous session ID set by AnonymousSession
+func GetAnonymousID(c *gin.Context) string {
+	anonymousID, _ := c.Get(anonymousCtxKey)
+	id, _ := anonymousID.(string)
+	return id
+}
+
+func newAnonymousID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
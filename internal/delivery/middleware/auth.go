@@ -3,20 +3,28 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
 )
 
 const (
 	authorizationHeader = "Authorization"
 	userCtxKey          = "userId"
 	emailCtxKey         = "userEmail"
+	sessionCtxKey       = "sessionId"
+	rolesCtxKey         = "userRoles"
+	scopesCtxKey        = "userScopes"
+	authTimeCtxKey      = "authTime"
 )
 
-// AuthMiddleware creates a middleware that validates JWT tokens
+// AuthMiddleware creates a middleware that validates JWT access tokens; refresh tokens are
+// rejected
 func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractToken(c)
@@ -35,14 +43,87 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// Set user info in context
-		c.Set(userCtxKey, claims.UserID)
-		c.Set(emailCtxKey, claims.Email)
+		// Tokens are only valid on the storefront they were issued by
+		if claims.TenantID != tenant.ID(c.Request.Context()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "token issued for another tenant",
+			})
+			return
+		}
+
+		setClaims(c, claims)
+
+		c.Next()
+	}
+}
+
+// OptionalAuth creates a middleware for public endpoints that personalize their response for
+// signed-in users. Requests without a token pass through anonymously; a token that is sent
+// but invalid or expired is still rejected so clients know to refresh it.
+func OptionalAuth(authService service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(authorizationHeader) == "" {
+			c.Next()
+			return
+		}
+
+		token := extractToken(c)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid authorization header",
+			})
+			return
+		}
+
+		claims, err := authService.ValidateToken(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid or expired token",
+			})
+			return
+		}
+
+		// Tokens are only valid on the storefront they were issued by
+		if claims.TenantID != tenant.ID(c.Request.Context()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "token issued for another tenant",
+			})
+			return
+		}
+
+		setClaims(c, claims)
 
 		c.Next()
 	}
 }
 
+// setClaims stores the user info of a validated token in the context
+func setClaims(c *gin.Context, claims *domain.TokenClaims) {
+	c.Set(userCtxKey, claims.UserID)
+	c.Set(emailCtxKey, claims.Email)
+	c.Set(sessionCtxKey, claims.SessionID)
+	c.Set(rolesCtxKey, claims.Roles)
+	c.Set(scopesCtxKey, claims.Scopes)
+	if claims.AuthTime != 0 {
+		c.Set(authTimeCtxKey, time.Unix(claims.AuthTime, 0))
+	}
+
+	// Logs and error reports of the request carry the user ID
+	c.Request = c.Request.WithContext(logger.SetUserID(c.Request.Context(), claims.UserID))
+}
+
+// TokenFromQuery creates a middleware that accepts the access token from a query parameter
+// when no Authorization header is sent. Use it only for endpoints browsers can't send
+// headers to, such as WebSocket handshakes, before AuthMiddleware.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query(param); token != "" && c.GetHeader(authorizationHeader) == "" {
+			c.Request.Header.Set(authorizationHeader, "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
 // extractToken extracts JWT token from Authorization header
 func extractToken(c *gin.Context) string {
 	bearerToken := c.GetHeader(authorizationHeader)
@@ -88,3 +169,53 @@ func GetUserEmail(c *gin.Context) (string, error) {
 
 	return e, nil
 }
+
+// GetSessionID retrieves the session ID of the access token from the context.
+// Returns 0 for tokens issued without a session.
+func GetSessionID(c *gin.Context) int {
+	sessionID, _ := c.Get(sessionCtxKey)
+	id, _ := sessionID.(int)
+	return id
+}
+
+// GetAuthTime retrieves when the user last entered their password, or the zero time if the
+// access token doesn't say
+func GetAuthTime(c *gin.Context) time.Time {
+	authTime, _ := c.Get(authTimeCtxKey)
+	t, _ := authTime.(time.Time)
+	return t
+}
+
+// GetUserRoles retrieves the role names carried by the access token
+func GetUserRoles(c *gin.Context) []string {
+	roles, _ := c.Get(rolesCtxKey)
+	r, _ := roles.([]string)
+	return r
+}
+
+// GetScopes retrieves the scopes carried by the access token
+func GetScopes(c *gin.Context) []string {
+	scopes, _ := c.Get(scopesCtxKey)
+	s, _ := scopes.([]string)
+	return s
+}
+
+// HasRole reports whether the authenticated user has the role
+func HasRole(c *gin.Context, role string) bool {
+	for _, r := range GetUserRoles(c) {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the access token grants the scope
+func HasScope(c *gin.Context, scope string) bool {
+	for _, s := range GetScopes(c) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit creates a middleware that rejects request bodies larger than maxBytes with 413.
+// Uploads (multipart/form-data requests) are allowed up to maxUploadBytes instead.
+//
+// Bodies with a declared Content-Length are rejected before anything is read. Bodies of
+// unknown length (chunked) are buffered up to the limit, so an oversized body is reported
+// as 413 rather than surfacing later as a JSON decoding or form parsing error.
+func BodyLimit(maxBytes, maxUploadBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := maxBytes
+		if isUpload(c.Request) {
+			limit = maxUploadBytes
+		}
+
+		if c.Request.ContentLength > limit {
+			abortBodyTooLarge(c, limit)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		if c.Request.ContentLength < 0 {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					abortBodyTooLarge(c, limit)
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error": "failed to read request body",
+				})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		}
+
+		c.Next()
+	}
+}
+
+// isUpload reports whether the request carries a multipart/form-data body
+func isUpload(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+func abortBodyTooLarge(c *gin.Context, limit int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "request body too large",
+		"max_bytes": limit,
+	})
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// CircuitStatus reports the state of the database circuit breaker
+type CircuitStatus interface {
+	Circuit() mongodb.Circuit
+}
+
+// DatabaseBreaker creates a middleware for when the database circuit breaker is open. Writes
+// (anything but GET, HEAD and OPTIONS) are rejected at once with 503 Service Unavailable and a
+// Retry-After header. Reads run, so those that can be answered from cached data still are; a
+// read that failed because the breaker refused its queries gets 503 instead of 500.
+func DatabaseBreaker(status CircuitStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		circuit := status.Circuit()
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if circuit.State == mongodb.CircuitOpen {
+				c.Header("Retry-After", retryAfter(circuit))
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+					"error": "database is unavailable",
+				})
+				return
+			}
+		}
+
+		ctx, rejected := mongodb.TrackRejections(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &breakerWriter{ResponseWriter: c.Writer, rejected: rejected, status: status}
+
+		c.Next()
+	}
+}
+
+// retryAfter is the Retry-After of a response refused by the breaker: the seconds until it lets
+// an operation through, at least one
+func retryAfter(circuit mongodb.Circuit) string {
+	return strconv.Itoa(max(int(time.Until(circuit.RetryAt).Seconds())+1, 1))
+}
+
+// breakerWriter turns a 500 status into 503 once the breaker has refused an operation of the
+// request
+type breakerWriter struct {
+	gin.ResponseWriter
+	rejected *atomic.Bool
+	status   CircuitStatus
+}
+
+func (w *breakerWriter) WriteHeader(code int) {
+	if code == http.StatusInternalServerError && w.rejected.Load() {
+		code = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", retryAfter(w.status.Circuit()))
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *breakerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const countryCtxKey = "clientCountry"
+
+var countryPattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// ClientCountry creates a middleware that reads the ISO country code of the client from the
+// given header, set by the CDN or proxy in front of the API. Values that are not two-letter
+// codes are ignored. Clients can set the header themselves when the API is reached directly,
+// so the country is only good as a risk signal.
+func ClientCountry(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if country := c.GetHeader(header); countryPattern.MatchString(country) {
+			c.Set(countryCtxKey, strings.ToUpper(country))
+		}
+		c.Next()
+	}
+}
+
+// GetClientCountry returns the country the request comes from, or an empty string if unknown
+func GetClientCountry(c *gin.Context) string {
+	return c.GetString(countryCtxKey)
+}
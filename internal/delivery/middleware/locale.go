@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/pkg/i18n"
+)
+
+const localeKey = "locale"
+
+// maxLocalizedBodySize skips translating large bodies; messages come in small ones
+const maxLocalizedBodySize = 64 << 10
+
+// Localize creates a middleware that picks the response locale from Accept-Language and
+// translates the messages of JSON responses: the "error" and "message" fields of v1 bodies
+// and of the v2 envelope (errors[].message, data.message). Other fields are left as they are.
+func Localize(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := bundle.Match(c.GetHeader("Accept-Language"))
+		c.Set(localeKey, locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Header("Content-Language", locale)
+
+		if locale == i18n.DefaultLocale {
+			c.Next()
+			return
+		}
+
+		writer := &localeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if !writer.buffering {
+			return
+		}
+
+		body := writer.body.Bytes()
+		if translated, ok := localizeBody(bundle, locale, body); ok {
+			body = translated
+		}
+		writer.Header().Del("Content-Length")
+		_, _ = writer.ResponseWriter.Write(body)
+	}
+}
+
+// GetLocale returns the locale picked for the request, or the default locale
+func GetLocale(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	return i18n.DefaultLocale
+}
+
+// localeWriter buffers JSON responses so their messages can be translated
+type localeWriter struct {
+	gin.ResponseWriter
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *localeWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		w.buffering = mediaType == "application/json"
+	}
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *localeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *localeWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *localeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// localizeBody translates the messages of a JSON object. It reports false if the body
+// has nothing to translate, so it can be written unchanged.
+func localizeBody(bundle *i18n.Bundle, locale string, body []byte) ([]byte, bool) {
+	if len(body) > maxLocalizedBodySize || !bytes.Contains(body, []byte(`"error`)) && !bytes.Contains(body, []byte(`"message"`)) {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	if !localizeFields(bundle, locale, fields) {
+		return nil, false
+	}
+
+	translated, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return translated, true
+}
+
+func localizeFields(bundle *i18n.Bundle, locale string, fields map[string]json.RawMessage) bool {
+	changed := false
+	for _, key := range []string{"error", "message"} {
+		if translated, ok := localizeString(bundle, locale, fields[key]); ok {
+			fields[key] = translated
+			changed = true
+		}
+	}
+
+	// v2 envelope
+	var errs []map[string]json.RawMessage
+	if raw, ok := fields["errors"]; ok && json.Unmarshal(raw, &errs) == nil {
+		errsChanged := false
+		for _, e := range errs {
+			if translated, ok := localizeString(bundle, locale, e["message"]); ok {
+				e["message"] = translated
+				errsChanged = true
+			}
+		}
+		if errsChanged {
+			if raw, err := json.Marshal(errs); err == nil {
+				fields["errors"] = raw
+				changed = true
+			}
+		}
+	}
+
+	var data map[string]json.RawMessage
+	if raw, ok := fields["data"]; ok && json.Unmarshal(raw, &data) == nil {
+		if translated, ok := localizeString(bundle, locale, data["message"]); ok {
+			data["message"] = translated
+			if raw, err := json.Marshal(data); err == nil {
+				fields["data"] = raw
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// localizeString translates a JSON string value
+func localizeString(bundle *i18n.Bundle, locale string, raw json.RawMessage) (json.RawMessage, bool) {
+	var message string
+	if len(raw) == 0 || json.Unmarshal(raw, &message) != nil {
+		return nil, false
+	}
+
+	translated := bundle.Translate(locale, message)
+	if translated == message {
+		return nil, false
+	}
+
+	encoded, err := json.Marshal(translated)
+	if err != nil {
+		return nil, false
+	}
+	return encoded, true
+}
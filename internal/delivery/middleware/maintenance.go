@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// MaintenanceStatus reports whether the API is in maintenance mode
+type MaintenanceStatus interface {
+	Status() domain.Maintenance
+}
+
+// Maintenance creates a middleware that rejects writes with 503 Service Unavailable and a
+// Retry-After header while maintenance mode is on. Reads (GET, HEAD, OPTIONS) keep working, as do
+// the routes in exempt, given as full route paths: signing in, so admins can switch the mode off,
+// and batches, whose sub-requests are checked one by one.
+func Maintenance(status MaintenanceStatus, exempt ...string) gin.HandlerFunc {
+	exemptRoutes := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		exemptRoutes[route] = true
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		maintenance := status.Status()
+		if !maintenance.Enabled || exemptRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(maintenance.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "service is in maintenance mode",
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RequestRecorder receives the status of every handled request
+type RequestRecorder interface {
+	RecordRequest(status int)
+}
+
+// RequestMetrics creates a middleware that reports response statuses to recorder
+func RequestMetrics(recorder RequestRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		recorder.RecordRequest(c.Writer.Status())
+	}
+}
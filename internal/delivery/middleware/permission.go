@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// HasPermission reports whether the access token grants the permission,
+// taking wildcard permissions such as "products:*" into account
+func HasPermission(c *gin.Context, permission string) bool {
+	for _, granted := range GetScopes(c) {
+		if domain.PermissionGrants(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission creates a middleware that allows the request only if the access token
+// grants all of the given permissions. Must be used after AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": "insufficient permissions",
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// UsageCounter counts the API requests of users against their quotas
+type UsageCounter interface {
+	Consume(ctx context.Context, userID int, roles []string) (*domain.APIUsage, error)
+}
+
+// Quota creates a middleware that counts the requests of signed-in users and rejects them with
+// 429 Too Many Requests and a Retry-After header once their monthly quota is used up. Requests
+// of users with a quota get X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset headers.
+// Anonymous requests and invalid tokens pass through uncounted, for the route's own auth to
+// handle, as do the routes in exempt, given as full route paths: batches, whose sub-requests
+// are counted one by one, and checking one's usage.
+func Quota(authService service.AuthService, usage UsageCounter, exempt ...string) gin.HandlerFunc {
+	exemptRoutes := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		exemptRoutes[route] = true
+	}
+
+	return func(c *gin.Context) {
+		token := extractToken(c)
+		if token == "" || exemptRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		claims, err := authService.ValidateToken(token)
+		if err != nil || claims.TenantID != tenant.ID(c.Request.Context()) {
+			c.Next()
+			return
+		}
+		userID, err := strconv.Atoi(claims.UserID)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		current, err := usage.Consume(c.Request.Context(), userID, claims.Roles)
+		if err != nil && !errors.Is(err, domain.ErrRateLimited) {
+			// Requests aren't refused because they couldn't be counted
+			logger.GetLoggerFromContext(c.Request.Context()).WithComponent("usage").WithError(err).Warn("Failed to count API usage")
+			c.Next()
+			return
+		}
+
+		if current.Limit != nil {
+			c.Header("X-Quota-Limit", strconv.FormatInt(*current.Limit, 10))
+			c.Header("X-Quota-Remaining", strconv.FormatInt(*current.Remaining, 10))
+			c.Header("X-Quota-Reset", current.ResetsAt.Format(time.RFC3339))
+		}
+
+		if err != nil {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(current.ResetsAt).Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "monthly API quota exceeded",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecentAuth reports whether a password entered at a time still allows sensitive operations
+type RecentAuth interface {
+	RecentlyAuthenticated(authTime time.Time) bool
+}
+
+// RequireRecentAuth creates a middleware that allows the request only if the user entered their
+// password recently, either to sign in or to get a sudo token. Must be used after AuthMiddleware.
+func RequireRecentAuth(auth RecentAuth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.RecentlyAuthenticated(GetAuthTime(c)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "re-authentication required",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
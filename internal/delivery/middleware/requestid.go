@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// ErrorRequestID creates a middleware that adds the request ID to v1 error bodies
+// ({"error": "..."}), so a client reporting an error can point at the logs of its request.
+// Only error responses are buffered; the v2 envelope carries the ID in meta instead.
+func ErrorRequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &errorBodyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if !writer.buffering {
+			return
+		}
+
+		body := writer.body.Bytes()
+		if tagged, ok := addRequestID(body, c.GetString(logger.RequestIDKey)); ok {
+			body = tagged
+		}
+		writer.Header().Del("Content-Length")
+		_, _ = writer.ResponseWriter.Write(body)
+	}
+}
+
+// errorBodyWriter buffers JSON error responses so the request ID can be added
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *errorBodyWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		w.buffering = w.Status() >= http.StatusBadRequest && mediaType == "application/json"
+	}
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *errorBodyWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *errorBodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// addRequestID sets request_id in a JSON error object. It reports false if the body is not
+// one or already has the ID, so it can be written unchanged.
+func addRequestID(body []byte, requestID string) ([]byte, bool) {
+	if requestID == "" || !bytes.Contains(body, []byte(`"error"`)) {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	if _, ok := fields["error"]; !ok {
+		return nil, false
+	}
+	if _, ok := fields["request_id"]; ok {
+		return nil, false
+	}
+
+	id, err := json.Marshal(requestID)
+	if err != nil {
+		return nil, false
+	}
+	fields["request_id"] = id
+
+	tagged, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return tagged, true
+}
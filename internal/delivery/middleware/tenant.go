@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// Tenant creates a middleware that resolves the storefront a request is for: the tenant named
+// by the tenancy header, else the one serving the request's host, else the default tenant.
+// The tenant is put in the request context, which scopes all data access to it. Requests
+// already scoped to a tenant, such as batch sub-requests, keep it. It does nothing when
+// tenancy is disabled.
+func Tenant(cfg *config.Tenancy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Enabled || tenant.FromContext(c.Request.Context()) != nil {
+			c.Next()
+			return
+		}
+
+		var resolved *config.Tenant
+		if id := c.GetHeader(cfg.Header); id != "" {
+			resolved = cfg.Tenant(id)
+		} else {
+			host, _, err := net.SplitHostPort(c.Request.Host)
+			if err != nil {
+				host = c.Request.Host
+			}
+			resolved = cfg.TenantByHost(host)
+			if resolved == nil && cfg.Default != "" {
+				resolved = cfg.Tenant(cfg.Default)
+			}
+		}
+		if resolved == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"error": "unknown tenant",
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), resolved))
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// QueryTimeout creates a middleware that answers 504 Gateway Timeout instead of 500 when a
+// database operation of the request ran out of time, so clients and load balancers can tell
+// a slow query, which may succeed on retry, from a failure.
+func QueryTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, timedOut := mongodb.TrackTimeouts(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &timeoutWriter{ResponseWriter: c.Writer, timedOut: timedOut}
+
+		c.Next()
+	}
+}
+
+// timeoutWriter turns a 500 status into 504 once an operation of the request has timed out
+type timeoutWriter struct {
+	gin.ResponseWriter
+	timedOut *atomic.Bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if code == http.StatusInternalServerError && w.timedOut.Load() {
+		code = http.StatusGatewayTimeout
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
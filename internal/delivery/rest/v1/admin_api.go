@@ -0,0 +1,424 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+const (
+	exportFormatNDJSON   = "ndjson"
+	exportFormatColumnar = "columnar"
+)
+
+// InitAdminRoutes sets up admin-only endpoints
+func (h *Handler) InitAdminRoutes(rg *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	admin := rg.Group("/admin")
+	admin.Use(authMiddleware)
+	{
+		admin.GET("/interactions/export", middleware.RequirePermission(domain.PermissionInteractionsExport), h.ExportInteractions)
+		admin.GET("/analytics/abandoned-carts", middleware.RequirePermission(domain.PermissionMetricsRead), h.GetCartRecoveryStats)
+		admin.GET("/reports/sales", middleware.RequirePermission(domain.PermissionMetricsRead), h.GetSalesReport)
+		admin.GET("/reports/retention", middleware.RequirePermission(domain.PermissionMetricsRead), h.GetRetentionReport)
+		admin.GET("/reports/inventory", middleware.RequirePermission(domain.PermissionMetricsRead), h.GetInventoryReport)
+		admin.GET("/reports/search", middleware.RequirePermission(domain.PermissionMetricsRead), h.GetSearchReport)
+		admin.GET("/activity", middleware.RequirePermission(domain.PermissionMetricsRead), h.GetAdminActivity)
+		admin.GET("/products", middleware.RequirePermission(domain.PermissionProductsWrite), h.ListAdminProducts)
+		admin.GET("/orders", middleware.RequirePermission(domain.PermissionOrdersReview), h.ListOrders)
+	}
+
+	stock := admin.Group("/products/:id")
+	stock.Use(middleware.RequirePermission(domain.PermissionProductsWrite))
+	{
+		stock.POST("/stock-adjustments", h.CreateStockAdjustment)
+		stock.GET("/stock-adjustments", h.ListStockAdjustments)
+		stock.GET("/stock-reconciliation", h.GetStockReconciliation)
+		stock.POST("/stock-transfers", h.CreateStockTransfer)
+		stock.POST("/subscription-plans", h.CreateSubscriptionPlan)
+	}
+
+	warehouses := admin.Group("/warehouses")
+	warehouses.Use(middleware.RequirePermission(domain.PermissionProductsWrite))
+	{
+		warehouses.GET("", h.ListWarehouses)
+		warehouses.POST("", h.CreateWarehouse)
+		warehouses.PUT("/:id", h.UpdateWarehouse)
+		warehouses.DELETE("/:id", h.DeleteWarehouse)
+		warehouses.GET("/:id/inventory", h.ListWarehouseInventory)
+	}
+
+	bundles := admin.Group("/bundles")
+	bundles.Use(middleware.RequirePermission(domain.PermissionProductsWrite))
+	{
+		bundles.GET("", h.ListAllBundles)
+		bundles.POST("", h.CreateBundle)
+		bundles.PUT("/:id", h.UpdateBundle)
+		bundles.DELETE("/:id", h.DeleteBundle)
+	}
+
+	plans := admin.Group("/subscription-plans")
+	plans.Use(middleware.RequirePermission(domain.PermissionProductsWrite))
+	{
+		plans.DELETE("/:id", h.DeactivateSubscriptionPlan)
+	}
+
+	reviews := admin.Group("/risk-reviews")
+	reviews.Use(middleware.RequirePermission(domain.PermissionOrdersReview))
+	{
+		reviews.GET("", h.ListRiskReviews)
+		reviews.POST("/:id/approve", h.ApproveRiskReview)
+		reviews.POST("/:id/reject", h.RejectRiskReview)
+	}
+
+	moderation := admin.Group("/moderation")
+	moderation.Use(middleware.RequirePermission(domain.PermissionContentModerate))
+	{
+		moderation.GET("", h.ListModerationItems)
+		moderation.POST("/:id/approve", h.ApproveModerationItem)
+		moderation.POST("/:id/reject", h.RejectModerationItem)
+	}
+
+	segments := admin.Group("/segments")
+	segments.Use(middleware.RequirePermission(domain.PermissionSegmentsManage))
+	{
+		segments.GET("", h.ListSegments)
+		segments.POST("", h.CreateSegment)
+		segments.GET("/:id", h.GetSegment)
+		segments.PUT("/:id", h.UpdateSegment)
+		segments.DELETE("/:id", h.DeleteSegment)
+		segments.POST("/:id/evaluate", h.EvaluateSegment)
+		segments.GET("/:id/members", h.ListSegmentMembers)
+		segments.POST("/:id/notifications", h.NotifySegment)
+	}
+
+	promotions := admin.Group("/promotions")
+	promotions.Use(middleware.RequirePermission(domain.PermissionPromotionsManage))
+	{
+		promotions.GET("", h.ListPromotions)
+		promotions.POST("", h.CreatePromotion)
+		promotions.GET("/:id", h.GetPromotion)
+		promotions.PUT("/:id", h.UpdatePromotion)
+		promotions.DELETE("/:id", h.DeletePromotion)
+	}
+
+	customerGroups := admin.Group("")
+	customerGroups.Use(middleware.RequirePermission(domain.PermissionCustomerGroupsManage))
+	{
+		customerGroups.GET("/customer-groups", h.ListCustomerGroups)
+		customerGroups.POST("/customer-groups", h.CreateCustomerGroup)
+		customerGroups.GET("/customer-groups/:id", h.GetCustomerGroup)
+		customerGroups.PUT("/customer-groups/:id", h.UpdateCustomerGroup)
+		customerGroups.DELETE("/customer-groups/:id", h.DeleteCustomerGroup)
+		customerGroups.PUT("/users/:id/customer-group", h.AssignCustomerGroup)
+	}
+
+	notes := admin.Group("")
+	notes.Use(middleware.RequirePermission(domain.PermissionSupportNotes))
+	{
+		notes.GET("/users/:id/notes", h.ListUserNotes)
+		notes.POST("/users/:id/notes", h.CreateUserNote)
+		notes.GET("/orders/:id/notes", h.ListOrderNotes)
+		notes.POST("/orders/:id/notes", h.CreateOrderNote)
+		notes.PUT("/notes/:id", h.UpdateSupportNote)
+		notes.DELETE("/notes/:id", h.DeleteSupportNote)
+	}
+
+	users := admin.Group("/users")
+	users.Use(middleware.RequirePermission(domain.PermissionUsersRead))
+	{
+		users.GET("", h.ListUsers)
+		users.GET("/:id/ltv", h.GetUserLTV)
+	}
+
+	userStatus := admin.Group("/users")
+	userStatus.Use(middleware.RequirePermission(domain.PermissionUsersManage))
+	{
+		userStatus.PUT("/:id/status", h.ChangeUserStatus)
+	}
+
+	indexes := admin.Group("/indexes")
+	indexes.Use(middleware.RequirePermission(domain.PermissionDatabaseManage))
+	{
+		indexes.GET("", h.ListIndexes)
+		indexes.POST("/build", h.BuildIndexes)
+	}
+
+	integrity := admin.Group("/integrity")
+	integrity.Use(middleware.RequirePermission(domain.PermissionDatabaseManage))
+	{
+		integrity.GET("", h.CheckIntegrity)
+		integrity.POST("/repair", h.RepairIntegrity)
+	}
+
+	media := admin.Group("/media")
+	media.Use(middleware.RequirePermission(domain.PermissionMediaSign))
+	{
+		media.POST("/sign", h.SignMediaURL)
+	}
+
+	backups := admin.Group("/backups")
+	backups.Use(middleware.RequirePermission(domain.PermissionDatabaseManage))
+	{
+		backups.POST("", h.CreateBackup)
+	}
+
+	maintenance := admin.Group("/maintenance")
+	maintenance.Use(middleware.RequirePermission(domain.PermissionDatabaseManage))
+	{
+		maintenance.GET("", h.GetMaintenance)
+		maintenance.PUT("", h.SetMaintenance)
+	}
+
+	permissions := admin.Group("")
+	permissions.Use(middleware.RequirePermission(domain.PermissionPermissionsManage))
+	{
+		permissions.GET("/permissions", h.ListPermissions)
+		permissions.POST("/permissions", h.CreatePermission)
+		permissions.DELETE("/permissions/:id", h.DeletePermission)
+		permissions.GET("/roles", h.ListRoles)
+		permissions.POST("/roles", h.CreateRole)
+		permissions.GET("/roles/:id", h.GetRole)
+		permissions.PUT("/roles/:id", h.UpdateRole)
+		permissions.DELETE("/roles/:id", h.DeleteRole)
+		permissions.GET("/roles/:id/permissions", h.GetRolePermissions)
+		permissions.POST("/roles/:id/permissions/:permission_id", h.GrantRolePermission)
+		permissions.DELETE("/roles/:id/permissions/:permission_id", h.RevokeRolePermission)
+	}
+}
+
+// ExportInteractions godoc
+// @Summary Export interaction events
+// @Description Export view, like and purchase events in time order for offline processing. Requires the interactions:export permission.
+// @Description format=ndjson streams one event per line; format=columnar returns a Parquet-compatible column batch.
+// @Description Pass the X-Next-Cursor header (or next_cursor field) as cursor to fetch the next batch.
+// @Description Each event carries its source, request_id and session_id where they were recorded, empty otherwise.
+// @Tags admin
+// @Produce json
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param from query string false "Start of the time range, inclusive (RFC3339)"
+// @Param to query string false "End of the time range, exclusive (RFC3339)"
+// @Param types query string false "Comma-separated event types (view,like,purchase)"
+// @Param limit query int false "Batch size" default(1000)
+// @Param cursor query string false "Cursor returned by the previous batch"
+// @Param format query string false "Output format (ndjson, columnar)" default(ndjson)
+// @Success 200 {object} dto.InteractionExportColumnarResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/interactions/export [get]
+func (h *Handler) ExportInteractions(c *gin.Context) {
+	format := c.DefaultQuery("format", exportFormatNDJSON)
+	if format != exportFormatNDJSON && format != exportFormatColumnar {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid format"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+
+	filter := domain.InteractionExportFilter{
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		filter.To = &to
+	}
+
+	if typesStr := c.Query("types"); typesStr != "" {
+		for _, eventType := range strings.Split(typesStr, ",") {
+			filter.EventTypes = append(filter.EventTypes, strings.TrimSpace(eventType))
+		}
+	}
+
+	batch, err := h.services.InteractionService.ExportEvents(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to export interactions")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to export interactions"})
+		return
+	}
+
+	if batch.NextCursor != "" {
+		c.Header("X-Next-Cursor", batch.NextCursor)
+	}
+
+	if format == exportFormatColumnar {
+		c.JSON(http.StatusOK, toColumnarExport(batch))
+		return
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(c.Writer)
+	for _, event := range batch.Events {
+		if err := encoder.Encode(event); err != nil {
+			h.logger.WithComponent("admin").WithError(err).Error("Failed to write export")
+			return
+		}
+	}
+}
+
+// toColumnarExport converts a batch of events into column-oriented form
+func toColumnarExport(batch *domain.InteractionExportBatch) dto.InteractionExportColumnarResponse {
+	n := len(batch.Events)
+	eventIDs := make([]string, n)
+	eventTypes := make([]string, n)
+	userIDs := make([]int, n)
+	productIDs := make([]int, n)
+	quantities := make([]int, n)
+	prices := make([]int64, n)
+	currencies := make([]string, n)
+	occurredAt := make([]int64, n)
+	sources := make([]string, n)
+	requestIDs := make([]string, n)
+	sessionIDs := make([]int, n)
+
+	for i, event := range batch.Events {
+		eventIDs[i] = event.EventID
+		eventTypes[i] = event.EventType
+		userIDs[i] = event.UserID
+		productIDs[i] = event.ProductID
+		quantities[i] = event.Quantity
+		prices[i] = event.Price.Amount
+		currencies[i] = event.Price.Currency
+		occurredAt[i] = event.OccurredAt.UnixMilli()
+		sources[i] = event.Source
+		requestIDs[i] = event.RequestID
+		sessionIDs[i] = event.SessionID
+	}
+
+	return dto.InteractionExportColumnarResponse{
+		Schema: []dto.ExportColumn{
+			{Name: "event_id", Type: "BYTE_ARRAY (UTF8)"},
+			{Name: "event_type", Type: "BYTE_ARRAY (UTF8)"},
+			{Name: "user_id", Type: "INT64"},
+			{Name: "product_id", Type: "INT64"},
+			{Name: "quantity", Type: "INT32"},
+			{Name: "price", Type: "INT64"}, // minor units of currency
+			{Name: "currency", Type: "BYTE_ARRAY (UTF8)"},
+			{Name: "occurred_at", Type: "INT64 (TIMESTAMP_MILLIS)"},
+			{Name: "source", Type: "BYTE_ARRAY (UTF8)"},
+			{Name: "request_id", Type: "BYTE_ARRAY (UTF8)"},
+			{Name: "session_id", Type: "INT64"},
+		},
+		RowCount: n,
+		Columns: map[string]interface{}{
+			"event_id":    eventIDs,
+			"event_type":  eventTypes,
+			"user_id":     userIDs,
+			"product_id":  productIDs,
+			"quantity":    quantities,
+			"price":       prices,
+			"currency":    currencies,
+			"occurred_at": occurredAt,
+			"source":      sources,
+			"request_id":  requestIDs,
+			"session_id":  sessionIDs,
+		},
+		NextCursor: batch.NextCursor,
+	}
+}
+
+// GetAdminActivity godoc
+// @Summary Get admin activity feed
+// @Description Get staff changes (audit: manual stock adjustments and transfers, support notes, content and fraud reviews), purchases (order) and alerts (checkouts flagged for fraud review, quarantined content) as one feed, newest first. The actor is the staff member for audit entries and the customer otherwise; entity_type is product, user, order, profile or risk_review.
+// @Tags admin
+// @Produce json
+// @Param actor_id query int false "Only entries of this actor"
+// @Param entity_type query string false "Only entries about this type of entity"
+// @Param category query string false "audit, order or alert"
+// @Param from query string false "Start of the range (RFC3339); defaults to 30 days before to"
+// @Param to query string false "End of the range, exclusive (RFC3339); defaults to now"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Security BearerAuth
+// @Success 200 {object} dto.AdminActivityResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/activity [get]
+func (h *Handler) GetAdminActivity(c *gin.Context) {
+	filter := domain.AdminActivityFilter{
+		EntityType: c.Query("entity_type"),
+		Category:   c.Query("category"),
+		To:         time.Now().UTC(),
+	}
+
+	if actorStr := c.Query("actor_id"); actorStr != "" {
+		actorID, err := strconv.Atoi(actorStr)
+		if err != nil || actorID <= 0 {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid actor_id"})
+			return
+		}
+		filter.ActorID = actorID
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		filter.To = parsed
+	}
+	filter.From = filter.To.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		filter.From = parsed
+	}
+
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	items, total, err := h.services.ActivityService.GetAdminActivity(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("activity").WithError(err).Error("Failed to get admin activity")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get admin activity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AdminActivityResponse{
+		Items:      items,
+		Pagination: newPagination(page, limit, total),
+	})
+}
@@ -6,28 +6,33 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 )
 
-// InitAuthRoutes initializes auth routes
-func (h *Handler) InitAuthRoutes(api *gin.RouterGroup) {
+// InitAuthRoutes initializes auth routes. They are public except sudo mode, which re-authenticates
+// a signed-in user.
+func (h *Handler) InitAuthRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
 	auth := api.Group("/auth")
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
 		auth.POST("/refresh", h.RefreshToken)
+		auth.POST("/email/confirm", h.ConfirmEmail)
+		auth.POST("/sudo", authMiddleware, h.Sudo)
 	}
 }
 
 // Register handles user registration
 // @Summary Register a new user
-// @Description Create a new user account with email and password
+// @Description Create a new user account with email and password. Views and guest checkouts of the
+// @Description caller's anonymous session (X-Anonymous-ID header or anonymous_id cookie) are moved to the new account.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body dto.RegisterRequest true "Registration details"
 // @Success 201 {object} dto.AuthResponse "User registered successfully with tokens"
-// @Failure 400 {object} dto.ErrorResponse "Invalid request body or validation error"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body, validation error or password does not meet the policy"
 // @Failure 409 {object} dto.ErrorResponse "User with this email already exists"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /auth/register [post]
@@ -48,13 +53,20 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	if err := h.services.PasswordPolicy.Validate(c.Request.Context(), req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
 	user, err := req.ToDomain()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{})
 		return
 	}
 
-	resp, err := h.services.AuthService.Register(c.Request.Context(), user)
+	resp, err := h.services.AuthService.Register(c.Request.Context(), user, clientInfo(c))
 	if err != nil {
 		if err == domain.ErrAlreadyExists {
 			c.JSON(http.StatusConflict, dto.ErrorResponse{
@@ -70,12 +82,15 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	h.mergeAnonymousSession(c, resp)
+
 	c.JSON(http.StatusCreated, resp)
 }
 
 // Login handles user login
 // @Summary Login user
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Views and guest checkouts of the
+// @Description caller's anonymous session (X-Anonymous-ID header or anonymous_id cookie) are moved to the account.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -109,7 +124,7 @@ func (h *Handler) Login(c *gin.Context) {
 		Password: req.Password,
 	}
 
-	resp, err := h.services.AuthService.Login(c.Request.Context(), domainReq)
+	resp, err := h.services.AuthService.Login(c.Request.Context(), domainReq, clientInfo(c))
 	if err != nil {
 		if err == domain.ErrInvalidCredentials {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
@@ -132,9 +147,30 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	h.mergeAnonymousSession(c, resp)
+
 	c.JSON(http.StatusOK, resp)
 }
 
+// mergeAnonymousSession reassigns the views and guest checkouts of the caller's anonymous session
+// to the user who just signed in. Failures don't fail the sign-in; the session is kept so the
+// merge is retried on the next login.
+func (h *Handler) mergeAnonymousSession(c *gin.Context, token *domain.Token) {
+	anonymousID := middleware.ReadAnonymousID(c)
+	if anonymousID == "" || token.User == nil {
+		return
+	}
+
+	merged, err := h.services.InteractionService.MergeAnonymousSession(c.Request.Context(), anonymousID, token.User.ID)
+	if err != nil {
+		h.logger.WithComponent("auth").WithError(err).Warn("Failed to merge anonymous session")
+		return
+	}
+
+	middleware.ClearAnonymousID(c)
+	h.logger.WithComponent("auth").Debug("Merged anonymous session", "user_id", token.User.ID, "interactions", merged)
+}
+
 // RefreshToken handles token refresh
 // @Summary Refresh access token
 // @Description Get a new access token using a valid refresh token
@@ -165,7 +201,7 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.services.AuthService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	resp, err := h.services.AuthService.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		if err == domain.ErrInvalidToken {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
@@ -190,3 +226,115 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// Sudo handles re-authentication for sensitive operations
+// @Summary Enter sudo mode
+// @Description Check the password again and get a short-lived access token for the current session that
+// @Description sensitive operations, such as deleting the account, accept. Tokens from a sign-in within the same window are accepted too.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SudoRequest true "Current password"
+// @Success 200 {object} domain.SudoToken "Elevated access token"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body"
+// @Failure 401 {object} dto.ErrorResponse "Invalid password or session"
+// @Failure 403 {object} dto.ErrorResponse "User account is inactive"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /auth/sudo [post]
+func (h *Handler) Sudo(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SudoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	token, err := h.services.AuthService.Sudo(c.Request.Context(), userID, middleware.GetSessionID(c), req.Password)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCredentials:
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "invalid password",
+			})
+		case domain.ErrInvalidToken:
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Error: "session is no longer active",
+			})
+		case domain.ErrUserInactive:
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{
+				Error: "user account is inactive",
+			})
+		default:
+			h.logger.WithComponent("auth").WithError(err).Error("Failed to enter sudo mode")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Error: "failed to enter sudo mode",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// ConfirmEmail handles email change confirmation
+// @Summary Confirm email change
+// @Description Switch the account to the new email using the token from the confirmation email. All sessions are revoked, so the user has to log in again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ConfirmEmailRequest true "Confirmation token"
+// @Success 200 {object} dto.SuccessResponse "Email changed"
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired token"
+// @Failure 409 {object} dto.ErrorResponse "Email already in use"
+// @Failure 500 {object} dto.ErrorResponse "Internal server error"
+// @Router /auth/email/confirm [post]
+func (h *Handler) ConfirmEmail(c *gin.Context) {
+	var req dto.ConfirmEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "invalid request body",
+		})
+		return
+	}
+
+	if err := h.services.UserService.ConfirmEmailChange(c.Request.Context(), req.Token); err != nil {
+		if err == domain.ErrInvalidToken {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Error: "invalid or expired token",
+			})
+			return
+		}
+
+		if err == domain.ErrAlreadyExists {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Error: "email already in use",
+			})
+			return
+		}
+
+		h.logger.WithComponent("auth").WithError(err).Error("Failed to confirm email change")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Error: "failed to confirm email change",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "email changed successfully"})
+}
+
+// clientInfo describes the device the request comes from
+func clientInfo(c *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		Country:   middleware.GetClientCountry(c),
+	}
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// CreateBackup godoc
+// @Summary Back up the database
+// @Description Start backing up the database to a gzip-compressed NDJSON file in the backup directory, uploaded to
+// @Description S3 when configured. The backup continues in the background and its outcome is logged. Requires the
+// @Description database:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} domain.Backup
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "A backup is already running"
+// @Router /admin/backups [post]
+func (h *Handler) CreateBackup(c *gin.Context) {
+	backup, err := h.services.BackupService.StartBackup(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, domain.ErrBackupInProgress) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "a backup is already running"})
+			return
+		}
+		h.logger.WithComponent("backup").WithError(err).Error("Failed to start backup")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to start backup"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, backup)
+}
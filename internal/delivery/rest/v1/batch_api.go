@@ -0,0 +1,194 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// maxBatchRequests caps the number of sub-requests in one batch call
+const maxBatchRequests = 20
+
+// batchHeaders are the request headers forwarded from the batch call to every sub-request
+var batchHeaders = []string{"Authorization", "Accept", "Accept-Language", logger.RequestIDHeader}
+
+var batchMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// InitBatchRoutes sets up the batch endpoint. Sub-requests are dispatched to router,
+// so it must be set with SetRouter before serving.
+func (h *Handler) InitBatchRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	api.POST("/batch", authMiddleware, h.Batch)
+}
+
+// SetRouter sets the router that batch sub-requests are dispatched to
+func (h *Handler) SetRouter(router http.Handler) {
+	h.router = router
+}
+
+// Batch godoc
+// @Summary Batch requests
+// @Description Execute up to 20 API requests in one roundtrip. Sub-requests run in order with the caller's
+// @Description Authorization, Accept and Accept-Language headers, so a later request sees the writes of an earlier one.
+// @Description Responses are returned in the same order; a failing sub-request does not stop the rest.
+// @Description Streaming endpoints and nested batches are not allowed.
+// @Tags batch
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body []dto.BatchRequest true "Sub-requests"
+// @Success 200 {array} dto.BatchResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /batch [post]
+func (h *Handler) Batch(c *gin.Context) {
+	var requests []dto.BatchRequest
+	if err := c.ShouldBindJSON(&requests); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if len(requests) == 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "batch must contain at least one request"})
+		return
+	}
+	if len(requests) > maxBatchRequests {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: fmt.Sprintf("batch must not contain more than %d requests", maxBatchRequests)})
+		return
+	}
+
+	subRequests := make([]*http.Request, len(requests))
+	for i, request := range requests {
+		subRequest, err := h.newBatchRequest(c, request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: fmt.Sprintf("request %d: %s", i, err.Error())})
+			return
+		}
+		subRequests[i] = subRequest
+	}
+
+	responses := make([]dto.BatchResponse, len(subRequests))
+	for i, subRequest := range subRequests {
+		recorder := newBatchRecorder()
+		h.router.ServeHTTP(recorder, subRequest)
+		responses[i] = recorder.response()
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// newBatchRequest validates a sub-request and builds it with the caller's context and headers
+func (h *Handler) newBatchRequest(c *gin.Context, request dto.BatchRequest) (*http.Request, error) {
+	method := strings.ToUpper(request.Method)
+	if !batchMethods[method] {
+		return nil, fmt.Errorf("unsupported method %q", request.Method)
+	}
+
+	target, err := url.Parse(request.Path)
+	if err != nil || target.IsAbs() || target.Host != "" {
+		return nil, fmt.Errorf("invalid path %q", request.Path)
+	}
+	cleanPath := path.Clean(target.Path)
+	if !strings.HasPrefix(cleanPath, "/api/") {
+		return nil, fmt.Errorf("path must start with /api/")
+	}
+	if strings.HasSuffix(cleanPath, "/batch") {
+		return nil, fmt.Errorf("nested batch requests are not allowed")
+	}
+	if strings.HasSuffix(cleanPath, "/stream") {
+		return nil, fmt.Errorf("streaming endpoints are not allowed")
+	}
+	target.Path = cleanPath
+
+	var body io.Reader = http.NoBody
+	hasBody := len(request.Body) > 0 && string(request.Body) != "null"
+	if hasBody {
+		body = bytes.NewReader(request.Body)
+	}
+
+	subRequest, err := http.NewRequestWithContext(c.Request.Context(), method, target.RequestURI(), body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	for _, name := range batchHeaders {
+		if value := c.GetHeader(name); value != "" {
+			subRequest.Header.Set(name, value)
+		}
+	}
+	if hasBody {
+		subRequest.Header.Set("Content-Type", "application/json")
+	}
+	subRequest.RemoteAddr = c.Request.RemoteAddr
+	subRequest.Host = c.Request.Host
+
+	return subRequest, nil
+}
+
+// batchRecorder captures a sub-request response in memory
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: make(http.Header)}
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+// Flush is a no-op; the whole response is returned at once
+func (r *batchRecorder) Flush() {}
+
+// response converts the recorded response, embedding JSON bodies as-is and others as a JSON string
+func (r *batchRecorder) response() dto.BatchResponse {
+	response := dto.BatchResponse{Status: r.status}
+	if response.Status == 0 {
+		response.Status = http.StatusOK
+	}
+
+	if len(r.header) > 0 {
+		response.Headers = make(map[string]string, len(r.header))
+		for name := range r.header {
+			response.Headers[name] = r.header.Get(name)
+		}
+	}
+
+	if r.body.Len() == 0 {
+		return response
+	}
+	if json.Valid(r.body.Bytes()) {
+		response.Body = r.body.Bytes()
+		return response
+	}
+	encoded, _ := json.Marshal(r.body.String())
+	response.Body = encoded
+	return response
+}
@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// GetFrequentlyBoughtTogether godoc
+// @Summary Get frequently bought together products
+// @Description Get the active products most often bought by the users who bought a product, for cart upsells.
+// @Description confidence is the share of the product's buyers who bought the other one too. The lists are
+// @Description recomputed from purchase history periodically; computed_at is missing until the first run.
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param limit query int false "Number of products" default(5)
+// @Success 200 {object} domain.FrequentlyBoughtTogether
+// @Success 304 "Not modified"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/frequently-bought-together [get]
+func (h *Handler) GetFrequentlyBoughtTogether(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit <= 0 {
+		limit = 5
+	}
+
+	result, err := h.services.BoughtTogetherService.Get(c.Request.Context(), id, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("bought_together").WithError(err).Error("Failed to get frequently bought together products")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get frequently bought together products"})
+		return
+	}
+
+	var lastModified time.Time
+	if result.ComputedAt != nil {
+		lastModified = *result.ComputedAt
+	}
+	h.respondConditional(c, lastModified, result)
+}
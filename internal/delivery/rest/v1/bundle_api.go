@@ -0,0 +1,365 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// InitBundleRoutes sets up the public bundle endpoints; bundles are managed under /admin/bundles
+func (h *Handler) InitBundleRoutes(api *gin.RouterGroup) {
+	bundles := api.Group("/bundles")
+	bundles.Use(middleware.OptionalAuth(h.services.AuthService))
+	{
+		bundles.GET("", h.ListBundles)
+		bundles.GET("/:id", h.GetBundle)
+
+		// Guests are tracked by an anonymous session, merged into their account on sign-in
+		bundles.POST("/:id/purchase", middleware.AnonymousSession(), h.PurchaseBundle)
+	}
+}
+
+// ListBundles godoc
+// @Summary List bundles
+// @Description Get a page of active bundles, newest first. stock is how many bundles the components' stock makes up.
+// @Description Bundles targeted at segments are only listed to their signed-in members.
+// @Tags bundles
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Success 200 {object} dto.BundleListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /bundles [get]
+func (h *Handler) ListBundles(c *gin.Context) {
+	segmentIDs, ok := h.userSegmentIDs(c, "failed to list bundles")
+	if !ok {
+		return
+	}
+
+	active := true
+	h.listBundles(c, domain.BundleFilter{IsActive: &active, Targeted: true, SegmentIDs: segmentIDs})
+}
+
+// ListAllBundles godoc
+// @Summary List all bundles
+// @Description Get a page of bundles, active and inactive, newest first. Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Success 200 {object} dto.BundleListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/bundles [get]
+func (h *Handler) ListAllBundles(c *gin.Context) {
+	h.listBundles(c, domain.BundleFilter{})
+}
+
+func (h *Handler) listBundles(c *gin.Context, filter domain.BundleFilter) {
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	bundles, total, err := h.services.BundleService.ListBundles(c.Request.Context(), filter, limit, (page-1)*limit)
+	if err != nil {
+		h.respondBundleError(c, err, "failed to list bundles")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BundleListResponse{
+		Bundles:    bundles,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// GetBundle godoc
+// @Summary Get bundle by ID
+// @Description Get an active bundle with its components. stock is how many bundles the components' stock makes up.
+// @Description Bundles targeted at segments are only shown to their signed-in members.
+// @Tags bundles
+// @Produce json
+// @Param id path int true "Bundle ID"
+// @Success 200 {object} domain.Bundle
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /bundles/{id} [get]
+func (h *Handler) GetBundle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid bundle id"})
+		return
+	}
+
+	bundle, err := h.services.BundleService.GetBundle(c.Request.Context(), id)
+	if err != nil {
+		h.respondBundleError(c, err, "failed to get bundle")
+		return
+	}
+	if !bundle.IsActive {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "bundle not found"})
+		return
+	}
+
+	if len(bundle.SegmentIDs) > 0 {
+		segmentIDs, ok := h.userSegmentIDs(c, "failed to get bundle")
+		if !ok {
+			return
+		}
+		if !bundle.OfferedTo(segmentIDs) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "bundle not found"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// CreateBundle godoc
+// @Summary Create bundle
+// @Description Create a bundle of existing products sold together at its own price. A bundle has at least two items
+// @Description and each product is listed once. With segment_ids it is only offered to members of those segments.
+// @Description Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateBundleRequest true "Bundle data"
+// @Success 201 {object} domain.Bundle
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/bundles [post]
+func (h *Handler) CreateBundle(c *gin.Context) {
+	var req dto.CreateBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+	price, err := req.Price.Money(h.services.ProductService.Currency(c.Request.Context()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	bundle := &domain.Bundle{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       price,
+		Components:  req.Components,
+		IsActive:    true,
+		SegmentIDs:  req.SegmentIDs,
+	}
+
+	if err := h.services.BundleService.CreateBundle(c.Request.Context(), bundle); err != nil {
+		h.respondBundleError(c, err, "failed to create bundle")
+		return
+	}
+
+	c.JSON(http.StatusCreated, bundle)
+}
+
+// UpdateBundle godoc
+// @Summary Update bundle
+// @Description Update a bundle (supports partial updates; components replace the current ones).
+// @Description Inactive bundles are hidden and cannot be purchased. Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bundle ID"
+// @Param request body dto.UpdateBundleRequest true "Updated bundle data"
+// @Success 200 {object} domain.Bundle
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/bundles/{id} [put]
+func (h *Handler) UpdateBundle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid bundle id"})
+		return
+	}
+
+	var req dto.UpdateBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	bundle, err := h.services.BundleService.GetBundle(c.Request.Context(), id)
+	if err != nil {
+		h.respondBundleError(c, err, "failed to get bundle")
+		return
+	}
+
+	// Update only provided fields
+	if req.Name != nil {
+		bundle.Name = *req.Name
+	}
+	if req.Description != nil {
+		bundle.Description = *req.Description
+	}
+	if req.Price != nil {
+		if bundle.Price, err = req.Price.Money(h.services.ProductService.Currency(c.Request.Context())); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if req.Components != nil {
+		bundle.Components = req.Components
+	}
+	if req.IsActive != nil {
+		bundle.IsActive = *req.IsActive
+	}
+	if req.SegmentIDs != nil {
+		bundle.SegmentIDs = *req.SegmentIDs
+	}
+
+	if err := h.services.BundleService.UpdateBundle(c.Request.Context(), bundle); err != nil {
+		h.respondBundleError(c, err, "failed to update bundle")
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// DeleteBundle godoc
+// @Summary Delete bundle
+// @Description Delete a bundle. Its products and past purchases are kept. Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Bundle ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/bundles/{id} [delete]
+func (h *Handler) DeleteBundle(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid bundle id"})
+		return
+	}
+
+	if err := h.services.BundleService.DeleteBundle(c.Request.Context(), id); err != nil {
+		h.respondBundleError(c, err, "failed to delete bundle")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "bundle deleted successfully"})
+}
+
+// PurchaseBundle godoc
+// @Summary Purchase a bundle
+// @Description Purchase a bundle, taking the stock of all its products at once: if any product is short, nothing is taken.
+// @Description A purchase of each product is recorded at its share of the bundle price. Without a token this is a guest
+// @Description checkout, recorded for the anonymous session and reassigned to the account when the guest signs in.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Param id path int true "Bundle ID"
+// @Param X-Anonymous-ID header string false "Anonymous session ID of a guest"
+// @Param purchase body dto.PurchaseBundleRequest true "Purchase details"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /bundles/{id}/purchase [post]
+func (h *Handler) PurchaseBundle(c *gin.Context) {
+	bundleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid bundle id"})
+		return
+	}
+
+	var req dto.PurchaseBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	ctx, ok := tracedContext(c, req.Source)
+	if !ok {
+		return
+	}
+
+	if anonymousID := middleware.GetAnonymousID(c); anonymousID != "" {
+		err = h.services.BundleService.PurchaseBundleAsGuest(ctx, anonymousID, bundleID, req.Quantity, clientInfo(c))
+	} else {
+		userIDStr, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+			return
+		}
+
+		userID, convErr := strconv.Atoi(userIDStr.(string))
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+			return
+		}
+
+		err = h.services.BundleService.PurchaseBundle(ctx, userID, bundleID, req.Quantity, clientInfo(c))
+	}
+	if err != nil {
+		h.logger.WithComponent("bundle").WithError(err).Error("Failed to purchase bundle")
+		if err.Error() == "bundle not found" {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "bundle purchased successfully"})
+}
+
+// userSegmentIDs returns the segments of the signed-in user, or none for guests. It writes an
+// error response with message and reports false if they can't be loaded.
+func (h *Handler) userSegmentIDs(c *gin.Context, message string) ([]int, bool) {
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		return nil, true
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return nil, false
+	}
+
+	segmentIDs, err := h.services.SegmentService.UserSegmentIDs(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithComponent("bundle").WithError(err).Error("Failed to get user segments")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+		return nil, false
+	}
+
+	return segmentIDs, true
+}
+
+// respondBundleError maps bundle errors to a response, with message for unexpected ones
+func (h *Handler) respondBundleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "bundle not found"})
+	default:
+		h.logger.WithComponent("bundle").WithError(err).Error("Failed to manage bundle")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// InitCartRoutes sets up the endpoints of the signed-in user's cart
+func (h *Handler) InitCartRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	cart := api.Group("/cart")
+	cart.Use(authMiddleware)
+	{
+		cart.GET("", h.GetCart)
+		cart.DELETE("", h.ClearCart)
+		cart.PUT("/items/:product_id", h.SetCartItem)
+		cart.DELETE("/items/:product_id", h.RemoveCartItem)
+		cart.POST("/checkout", h.CheckoutCart)
+	}
+}
+
+// GetCart godoc
+// @Summary Get my cart
+// @Description Get the current user's cart with its total at current prices
+// @Tags cart
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.Cart
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /cart [get]
+func (h *Handler) GetCart(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	cart, err := h.services.CartService.GetCart(c.Request.Context(), userID)
+	if err != nil {
+		h.respondCartError(c, err, "failed to get cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// SetCartItem godoc
+// @Summary Set cart item
+// @Description Add a product to the cart or change its quantity; quantity 0 removes it. Stock is checked at checkout.
+// @Description A quantity the product's order quantity rules don't allow is rejected with the allowed range (dto.QuantityErrorResponse).
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param product_id path int true "Product ID"
+// @Param request body dto.SetCartItemRequest true "Quantity"
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /cart/items/{product_id} [put]
+func (h *Handler) SetCartItem(c *gin.Context) {
+	var req dto.SetCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	h.setCartItem(c, *req.Quantity, req.Source)
+}
+
+// RemoveCartItem godoc
+// @Summary Remove cart item
+// @Description Remove a product from the cart
+// @Tags cart
+// @Produce json
+// @Security BearerAuth
+// @Param product_id path int true "Product ID"
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /cart/items/{product_id} [delete]
+func (h *Handler) RemoveCartItem(c *gin.Context) {
+	h.setCartItem(c, 0, "")
+}
+
+func (h *Handler) setCartItem(c *gin.Context, quantity int, source string) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	ctx, ok := tracedContext(c, source)
+	if !ok {
+		return
+	}
+
+	cart, err := h.services.CartService.SetItem(ctx, userID, productID, quantity)
+	if err != nil {
+		h.respondCartError(c, err, "failed to update cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// ClearCart godoc
+// @Summary Clear my cart
+// @Description Remove all products from the current user's cart
+// @Tags cart
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /cart [delete]
+func (h *Handler) ClearCart(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.CartService.ClearCart(c.Request.Context(), userID); err != nil {
+		h.respondCartError(c, err, "failed to clear cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "cart cleared successfully"})
+}
+
+// CheckoutCart godoc
+// @Summary Check out my cart
+// @Description Purchase everything in the cart at current prices and empty it. The stock of all products is taken at once:
+// @Description if any product is short, nothing is taken. Each purchase is attributed to the source its product was added from.
+// @Description The body is optional; a coupon code takes the coupon's discount off every product and redeems the coupon.
+// @Description An item whose quantity the product's order quantity rules no longer allow is rejected as by PUT /cart/items/{product_id}.
+// @Description An item over the user's purchase limit of its product is rejected with 409 and what is left of the limit.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param source query string false "Page the checkout was made from, for products added without one: search, recommendation, category"
+// @Param request body dto.CheckoutRequest false "Coupon to redeem"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /cart/checkout [post]
+func (h *Handler) CheckoutCart(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, ok := tracedContext(c, "")
+	if !ok {
+		return
+	}
+
+	// The coupon is optional, so an empty body is allowed
+	var req dto.CheckoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+			return
+		}
+	}
+
+	if err := h.services.CartService.Checkout(ctx, userID, req.Coupon, clientInfo(c)); err != nil {
+		h.respondCartError(c, err, "failed to check out cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "cart checked out successfully"})
+}
+
+// GetCartRecoveryStats godoc
+// @Summary Abandoned cart recovery
+// @Description Count the carts abandoned in a time range, how many owners were reminded and how many carts were
+// @Description checked out afterwards, with their values and conversion rates. Requires the metrics:read permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start of the time range, inclusive (RFC3339); 30 days before to if omitted"
+// @Param to query string false "End of the time range, exclusive (RFC3339); now if omitted"
+// @Success 200 {object} domain.CartRecoveryStats
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/analytics/abandoned-carts [get]
+func (h *Handler) GetCartRecoveryStats(c *gin.Context) {
+	to := time.Now().UTC()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		from = parsed
+	}
+
+	stats, err := h.services.CartService.RecoveryStats(c.Request.Context(), from, to)
+	if err != nil {
+		h.respondCartError(c, err, "failed to get cart recovery stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// respondCartError maps cart errors to a response, with message for unexpected ones
+func (h *Handler) respondCartError(c *gin.Context, err error, message string) {
+	if respondPurchaseError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+	case errors.Is(err, domain.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+	default:
+		h.logger.WithComponent("carts").WithError(err).Error("Failed to manage cart")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
@@ -1,24 +1,33 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/gin-gonic/gin"
 )
 
 func (h *Handler) InitCategoryRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// Browsing is public
+	catalog := api.Group("/categories")
+	catalog.Use(middleware.OptionalAuth(h.services.AuthService))
+	{
+		catalog.GET("", h.ListCategories)
+		catalog.GET("/:id", h.GetCategory)
+	}
+
 	categories := api.Group("/categories")
 	categories.Use(authMiddleware)
 	{
-		categories.GET("", h.ListCategories)
-		categories.GET("/:id", h.GetCategory)
-
-		categories.POST("", h.CreateCategory)
-		categories.PUT("/:id", h.UpdateCategory)
-		categories.DELETE("/:id", h.DeleteCategory)
+		categories.POST("", middleware.RequirePermission(domain.PermissionCategoriesWrite), h.CreateCategory)
+		categories.PUT("/:id", middleware.RequirePermission(domain.PermissionCategoriesWrite), h.UpdateCategory)
+		categories.DELETE("/:id", middleware.RequirePermission(domain.PermissionCategoriesWrite), h.DeleteCategory)
+		categories.POST("/:id/merge", middleware.RequirePermission(domain.PermissionCategoriesWrite), h.MergeCategory)
 	}
 }
 
@@ -26,14 +35,22 @@ func (h *Handler) InitCategoryRoutes(api *gin.RouterGroup, authMiddleware gin.Ha
 
 // ListCategories godoc
 // @Summary List categories
-// @Description Get all product categories
+// @Description Get all product categories with their product counts (including subcategories) and breadcrumbs
 // @Tags categories
 // @Accept json
 // @Produce json
-// @Security BearerAuth
-// @Success 200 {array} domain.Category
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(100)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Success 200 {object} dto.CategoryListResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Router /categories [get]
 func (h *Handler) ListCategories(c *gin.Context) {
+	page, limit, ok := parsePage(c, 100, 500)
+	if !ok {
+		return
+	}
+
 	categories, err := h.services.ProductService.ListCategories(c.Request.Context())
 	if err != nil {
 		h.logger.WithComponent("product").WithError(err).Error("Failed to list categories")
@@ -41,16 +58,25 @@ func (h *Handler) ListCategories(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, categories)
+	var lastModified time.Time
+	for _, category := range categories {
+		if category.UpdatedAt.After(lastModified) {
+			lastModified = category.UpdatedAt
+		}
+	}
+
+	h.respondConditional(c, lastModified, dto.CategoryListResponse{
+		Categories: paginate(categories, page, limit),
+		Pagination: newPagination(page, limit, int64(len(categories))),
+	})
 }
 
 // GetCategory godoc
 // @Summary Get category by ID
-// @Description Get detailed information about a specific category
+// @Description Get detailed information about a specific category, including its product count and breadcrumbs
 // @Tags categories
 // @Accept json
 // @Produce json
-// @Security BearerAuth
 // @Param id path string true "Category ID"
 // @Success 200 {object} domain.Category
 // @Router /categories/{id} [get]
@@ -73,7 +99,7 @@ func (h *Handler) GetCategory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, category)
+	h.respondConditional(c, category.UpdatedAt, category)
 }
 
 // CreateCategory godoc
@@ -94,8 +120,6 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// TODO: Check if user has admin role
-
 	category := &domain.Category{
 		Name:        req.Name,
 		Description: req.Description,
@@ -140,8 +164,6 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	// TODO: Check if user has admin role
-
 	// Get existing category first
 	existingCategory, err := h.services.ProductService.GetCategory(c.Request.Context(), id)
 	if err != nil {
@@ -180,13 +202,18 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 
 // DeleteCategory godoc
 // @Summary Delete category
-// @Description Delete a category 
+// @Description Delete a category. A category with products or subcategories is only deleted when
+// @Description force_move_to is given; its contents are then moved to that category first.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Category ID"
+// @Param force_move_to query int false "Category that receives the products and subcategories"
 // @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
 // @Router /categories/{id} [delete]
 func (h *Handler) DeleteCategory(c *gin.Context) {
 	idStr := c.Param("id")
@@ -196,11 +223,29 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.ProductService.DeleteCategory(c.Request.Context(), id); err != nil {
-		if err == domain.ErrNotFound {
+	var moveTo *int
+	if moveToStr := c.Query("force_move_to"); moveToStr != "" {
+		targetID, err := strconv.Atoi(moveToStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid force_move_to"})
+			return
+		}
+		moveTo = &targetID
+	}
+
+	if err := h.services.ProductService.DeleteCategory(c.Request.Context(), id, moveTo); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "category not found"})
 			return
 		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrCategoryNotEmpty) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		h.logger.WithComponent("product").WithError(err).Error("Failed to delete category")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to delete category"})
 		return
@@ -208,3 +253,48 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// MergeCategory godoc
+// @Summary Merge category
+// @Description Move all products and subcategories of a category into another one and delete it.
+// @Description Runs in a transaction on replica sets.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Category ID to merge"
+// @Param merge body dto.MergeCategoryRequest true "Target category"
+// @Success 200 {object} domain.CategoryMergeResult
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /categories/{id}/merge [post]
+func (h *Handler) MergeCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid category id"})
+		return
+	}
+
+	var req dto.MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	result, err := h.services.ProductService.MergeCategory(c.Request.Context(), id, req.TargetID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "category not found"})
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to merge category")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to merge category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
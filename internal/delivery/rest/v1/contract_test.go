@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository/memory"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/internal/testutil"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// TestProductContract checks the public product routes against docs/swagger.json, run on
+// products in memory
+func TestProductContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	contract := testutil.NewContract(t)
+
+	store := memory.NewStore()
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	categoryID := 1
+	store.Load(&memory.Snapshot{
+		Categories: []domain.Category{{ID: 1, Name: "Electronics", CreatedAt: created, UpdatedAt: created}},
+		Products: []domain.Product{{
+			ID:         1,
+			Name:       "Laptop",
+			CategoryID: &categoryID,
+			Price:      domain.NewMoney(99999, "USD"),
+			Stock:      5,
+			CreatedAt:  created,
+			UpdatedAt:  created,
+		}},
+	})
+	repos := store.Repositories()
+
+	cfg := &config.Config{Outbox: config.Outbox{PollInterval: "1s", Retention: "168h"}}
+	outbox, err := service.NewOutbox(repos.Outbox, nil, cfg)
+	if err != nil {
+		t.Fatalf("new outbox: %v", err)
+	}
+	services := &service.Service{
+		ProductService:   service.NewProductService(repos.Product, repos.Stock, repos.Warehouse, nil, repos.Transactor, outbox, "USD", nil),
+		InventoryService: service.NewInventoryService(repos.Warehouse, repos.Stock, repos.Product),
+	}
+
+	appLogger, err := logger.New(nil)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	handler := NewHandler(services, appLogger)
+
+	router := gin.New()
+	handler.InitProductRoutes(router.Group("/api/v1"), middleware.AuthMiddleware(nil))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"found", "/api/v1/products/1", http.StatusOK},
+		{"selected fields", "/api/v1/products/1?fields=id,name,price", http.StatusOK},
+		{"not found", "/api/v1/products/999", http.StatusNotFound},
+		{"invalid id", "/api/v1/products/laptop", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := contract.Serve(t, router, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+// contractGroups are the route groups walked by TestRouteContract, by spec path prefix
+var contractGroups = []struct {
+	name  string
+	paths []string
+}{
+	{"auth", []string{"/auth"}},
+	{"cart", []string{"/cart"}},
+	{"interactions", []string{"/products/{id}/like", "/products/{id}/liked", "/products/{id}/purchase", "/products/{id}/purchased", "/products/{id}/view"}},
+	{"admin", []string{"/admin"}},
+	{"search", []string{"/search"}},
+	{"batch", []string{"/batch"}},
+}
+
+// contractGroup returns the name of the walked group the spec path belongs to, or "" if none
+func contractGroup(path string) string {
+	for _, group := range contractGroups {
+		for _, prefix := range group.paths {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return group.name
+			}
+		}
+	}
+	return ""
+}
+
+// customerToken is the access token contractAuth accepts, of a customer without permissions
+const customerToken = "customer"
+
+// contractAuth validates access tokens without keys: customerToken signs in a customer, any
+// other token is invalid
+type contractAuth struct {
+	service.AuthService
+}
+
+func (contractAuth) ValidateToken(token string) (*domain.TokenClaims, error) {
+	if token != customerToken {
+		return nil, domain.ErrInvalidToken
+	}
+	return &domain.TokenClaims{Type: domain.TokenTypeAccess, UserID: "1", Email: "customer@example.com", Roles: []string{"customer"}}, nil
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}`)
+
+// TestRouteContract walks the spec operations of the route groups above on the full v1 router
+// and checks every response against docs/swagger.json: secured operations reject an invalid
+// token, admin operations a customer, and public operations a malformed body. The requests
+// stop in middleware or request parsing, so no services are needed.
+func TestRouteContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	contract := testutil.NewContract(t)
+
+	appLogger, err := logger.New(nil)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	handler := NewHandler(&service.Service{AuthService: contractAuth{}}, appLogger)
+
+	router := gin.New()
+	handler.Init(router.Group("/api"))
+	handler.SetRouter(router)
+
+	for _, route := range contract.Undocumented(router.Routes()) {
+		t.Errorf("route %s is missing from the spec", route)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		body    string
+		applies func(group string, operation testutil.Operation) bool
+		status  int
+	}{
+		{
+			name:    "invalid token",
+			token:   "expired",
+			applies: func(_ string, operation testutil.Operation) bool { return operation.Secure },
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "without permission",
+			token:   customerToken,
+			applies: func(group string, _ testutil.Operation) bool { return group == "admin" },
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "malformed body",
+			body:    "{",
+			applies: func(_ string, operation testutil.Operation) bool { return !operation.Secure },
+			status:  http.StatusBadRequest,
+		},
+	}
+
+	for _, operation := range contract.Operations() {
+		group := contractGroup(operation.Path)
+		if group == "" {
+			continue
+		}
+		for _, tt := range tests {
+			if !tt.applies(group, operation) {
+				continue
+			}
+			t.Run(group+"/"+tt.name+"/"+operation.Method+" "+operation.Path, func(t *testing.T) {
+				req := httptest.NewRequest(operation.Method, "/api/v1"+pathParam.ReplaceAllString(operation.Path, "1"), strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				if tt.token != "" {
+					req.Header.Set("Authorization", "Bearer "+tt.token)
+				}
+
+				rec := contract.Serve(t, router, req)
+				if rec.Code != tt.status {
+					t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListCustomerGroups godoc
+// @Summary List customer groups
+// @Description Get all customer groups with their pricing. Requires the customer_groups:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.CustomerGroup
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/customer-groups [get]
+func (h *Handler) ListCustomerGroups(c *gin.Context) {
+	groups, err := h.services.CustomerGroupService.ListGroups(c.Request.Context())
+	if err != nil {
+		h.respondCustomerGroupError(c, err, "failed to list customer groups")
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// CreateCustomerGroup godoc
+// @Summary Create customer group
+// @Description Create a customer group such as retail, wholesale or VIP. Its users pay a product's price from the
+// @Description product's rule (a price or percent_off), else the percent_off of the rule for the product's category,
+// @Description else the group's percent_off. Prices apply to product listings, purchases and cart checkout.
+// @Description A price lowering a product below its cost_price is rejected unless allow_below_cost is set.
+// @Description Requires the customer_groups:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CustomerGroupRequest true "Customer group"
+// @Success 201 {object} domain.CustomerGroup
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/customer-groups [post]
+func (h *Handler) CreateCustomerGroup(c *gin.Context) {
+	group, ok := h.bindCustomerGroup(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.CustomerGroupService.CreateGroup(c.Request.Context(), group); err != nil {
+		h.respondCustomerGroupError(c, err, "failed to create customer group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetCustomerGroup godoc
+// @Summary Get customer group
+// @Description Get a customer group with its pricing. Requires the customer_groups:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer group ID"
+// @Success 200 {object} domain.CustomerGroup
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/customer-groups/{id} [get]
+func (h *Handler) GetCustomerGroup(c *gin.Context) {
+	id, ok := customerGroupID(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.services.CustomerGroupService.GetGroup(c.Request.Context(), id)
+	if err != nil {
+		h.respondCustomerGroupError(c, err, "failed to get customer group")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateCustomerGroup godoc
+// @Summary Update customer group
+// @Description Replace the name, description and pricing of a customer group. Its users pay the new prices from
+// @Description their next request. A price lowering a product below its cost_price is rejected unless
+// @Description allow_below_cost is set. Requires the customer_groups:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer group ID"
+// @Param request body dto.CustomerGroupRequest true "Customer group"
+// @Success 200 {object} domain.CustomerGroup
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/customer-groups/{id} [put]
+func (h *Handler) UpdateCustomerGroup(c *gin.Context) {
+	id, ok := customerGroupID(c)
+	if !ok {
+		return
+	}
+
+	group, ok := h.bindCustomerGroup(c)
+	if !ok {
+		return
+	}
+	group.ID = id
+
+	if err := h.services.CustomerGroupService.UpdateGroup(c.Request.Context(), group); err != nil {
+		h.respondCustomerGroupError(c, err, "failed to update customer group")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteCustomerGroup godoc
+// @Summary Delete customer group
+// @Description Delete a customer group. Its users go back to list prices. Requires the customer_groups:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Customer group ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/customer-groups/{id} [delete]
+func (h *Handler) DeleteCustomerGroup(c *gin.Context) {
+	id, ok := customerGroupID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.CustomerGroupService.DeleteGroup(c.Request.Context(), id); err != nil {
+		h.respondCustomerGroupError(c, err, "failed to delete customer group")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AssignCustomerGroup godoc
+// @Summary Assign user to customer group
+// @Description Assign a user to a customer group, whose prices they pay from their next request, or to none with null.
+// @Description Requires the customer_groups:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.AssignCustomerGroupRequest true "Customer group"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/customer-group [put]
+func (h *Handler) AssignCustomerGroup(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req dto.AssignCustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	user, err := h.services.CustomerGroupService.AssignUser(c.Request.Context(), userID, req.CustomerGroupID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "user not found"})
+		default:
+			h.logger.WithComponent("customer_groups").WithError(err).Error("Failed to assign customer group")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to assign customer group"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// bindCustomerGroup parses a customer group request with prices in the storefront currency,
+// writing a 400 response if it is invalid
+func (h *Handler) bindCustomerGroup(c *gin.Context) (*domain.CustomerGroup, bool) {
+	var req dto.CustomerGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return nil, false
+	}
+
+	group, err := req.ToDomain(h.services.ProductService.Currency(c.Request.Context()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return group, true
+}
+
+// customerGroupID parses the customer group ID path parameter, writing a 400 response if it is invalid
+func customerGroupID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid customer group id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondCustomerGroupError maps customer group errors to a response, with message for unexpected ones
+func (h *Handler) respondCustomerGroupError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "customer group not found"})
+	case errors.Is(err, domain.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "customer group already exists"})
+	default:
+		h.logger.WithComponent("customer_groups").WithError(err).Error("Failed to manage customer group")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
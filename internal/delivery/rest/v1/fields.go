@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// parseFields reads the ?fields= query parameter, responding with 400 if it names unknown fields
+func parseFields(c *gin.Context, allowed map[string]string) ([]string, bool) {
+	fields, err := domain.ParseFields(c.Query("fields"), allowed)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid fields"})
+		return nil, false
+	}
+	return fields, true
+}
+
+// wantsField reports whether field is selected; all fields are when none are given
+func wantsField(fields []string, field string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields returns v (an object or a slice of objects) reduced to the given JSON fields.
+// v is returned unchanged when no fields are selected.
+func selectFields(v interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]json.RawMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i] = pickFields(items[i], fields)
+		}
+		return items, nil
+	}
+
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return pickFields(item, fields), nil
+}
+
+func pickFields(item map[string]json.RawMessage, fields []string) map[string]json.RawMessage {
+	picked := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := item[field]; ok {
+			picked[field] = value
+		}
+	}
+	return picked
+}
@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+const (
+	maxFilterConditions = 20
+	maxFilterInValues   = 100
+)
+
+// parseFilterExpression parses a listing filter such as
+//
+//	price>=100,stock>0,category_id in (2,3),name="Phone, 128GB"
+//
+// into conditions on the allowed fields. Conditions are joined with AND. Values are converted
+// to the field's type here, so only typed scalars ever reach the database query; amounts are
+// converted to minor units of currency.
+func parseFilterExpression(raw string, allowed map[string]domain.FilterField, currency string) ([]domain.FilterCondition, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	clauses, err := splitTopLevel(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(clauses) > maxFilterConditions {
+		return nil, fmt.Errorf("filter has more than %d conditions: %w", maxFilterConditions, domain.ErrValidation)
+	}
+
+	conditions := make([]domain.FilterCondition, 0, len(clauses))
+	for _, clause := range clauses {
+		condition, err := parseFilterClause(clause, allowed, currency)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, nil
+}
+
+// splitTopLevel splits s on commas that are outside parentheses and quotes
+func splitTopLevel(s string) ([]string, error) {
+	var parts []string
+	var quote rune
+	depth, start := 0, 0
+
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in filter: %w", domain.ErrValidation)
+			}
+		case r == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in filter: %w", domain.ErrValidation)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in filter: %w", domain.ErrValidation)
+	}
+
+	return append(parts, strings.TrimSpace(s[start:])), nil
+}
+
+func parseFilterClause(clause string, allowed map[string]domain.FilterField, currency string) (domain.FilterCondition, error) {
+	end := 0
+	for end < len(clause) && (clause[end] == '_' || (clause[end] >= 'a' && clause[end] <= 'z')) {
+		end++
+	}
+	name := clause[:end]
+	rest := strings.TrimSpace(clause[end:])
+
+	if name == "" {
+		return domain.FilterCondition{}, fmt.Errorf("invalid filter condition %q: %w", clause, domain.ErrValidation)
+	}
+	field, ok := allowed[name]
+	if !ok {
+		return domain.FilterCondition{}, fmt.Errorf("field %q cannot be filtered: %w", name, domain.ErrValidation)
+	}
+
+	condition := domain.FilterCondition{Field: name}
+	var rawValues []string
+
+	if lower := strings.ToLower(rest); strings.HasPrefix(lower, "in") && strings.HasPrefix(strings.TrimSpace(rest[2:]), "(") {
+		list := strings.TrimSpace(rest[2:])
+		if !strings.HasSuffix(list, ")") {
+			return domain.FilterCondition{}, fmt.Errorf("invalid value list for %q: %w", name, domain.ErrValidation)
+		}
+		values, err := splitTopLevel(list[1 : len(list)-1])
+		if err != nil {
+			return domain.FilterCondition{}, err
+		}
+		if len(values) > maxFilterInValues {
+			return domain.FilterCondition{}, fmt.Errorf("too many values for %q: %w", name, domain.ErrValidation)
+		}
+		condition.Operator = domain.FilterIn
+		rawValues = values
+	} else {
+		for _, op := range []domain.FilterOperator{domain.FilterGte, domain.FilterLte, domain.FilterNe, domain.FilterGt, domain.FilterLt, domain.FilterEq} {
+			if strings.HasPrefix(rest, string(op)) {
+				condition.Operator = op
+				rawValues = []string{strings.TrimSpace(rest[len(op):])}
+				break
+			}
+		}
+		if condition.Operator == "" {
+			return domain.FilterCondition{}, fmt.Errorf("invalid operator in filter condition %q: %w", clause, domain.ErrValidation)
+		}
+	}
+
+	if !field.Type.AllowsOperator(condition.Operator) {
+		return domain.FilterCondition{}, fmt.Errorf("operator %s is not supported for %q: %w", condition.Operator, name, domain.ErrValidation)
+	}
+
+	for _, rawValue := range rawValues {
+		value, err := parseFilterValue(rawValue, field.Type, currency)
+		if err != nil {
+			return domain.FilterCondition{}, fmt.Errorf("invalid value %q for %q: %w", rawValue, name, domain.ErrValidation)
+		}
+		condition.Values = append(condition.Values, value)
+	}
+
+	return condition, nil
+}
+
+func parseFilterValue(raw string, valueType domain.FilterValueType, currency string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		raw = raw[1 : len(raw)-1]
+	} else if raw == "" {
+		return nil, fmt.Errorf("empty value")
+	}
+
+	switch valueType {
+	case domain.FilterInt:
+		return strconv.Atoi(raw)
+	case domain.FilterNumber:
+		return strconv.ParseFloat(raw, 64)
+	case domain.FilterBool:
+		return strconv.ParseBool(raw)
+	case domain.FilterTime:
+		if t, err := parseTimestamp(raw); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", raw)
+	case domain.FilterMoney:
+		amount, err := domain.ParseMoney(raw, currency)
+		return amount.Amount, err
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,219 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// SetFlashSale godoc
+// @Summary Set a product's flash sale
+// @Description Start or replace the product's flash sale: quantity units at price from starts_at, until ends_at or sold out.
+// @Description The price must be below the product's and the quantity at most its unallocated stock, which the sale ships from.
+// @Description A price below the product's cost_price is rejected unless allow_below_cost is set.
+// @Description While the sale is on the product can only be bought through its waiting room (admin only).
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param sale body dto.FlashSaleRequest true "Flash sale"
+// @Success 200 {object} domain.Product
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/flash-sale [put]
+func (h *Handler) SetFlashSale(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	var req dto.FlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	sale, err := req.ToDomain(h.services.ProductService.Currency(c.Request.Context()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	product, err := h.services.FlashSaleService.SetSale(c.Request.Context(), productID, sale)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("flash_sale").WithError(err).Error("Failed to set flash sale")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to set flash sale"})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// ClearFlashSale godoc
+// @Summary End a product's flash sale
+// @Description Remove the product's flash sale; its unsold units go back to regular sale (admin only)
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 204
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/flash-sale [delete]
+func (h *Handler) ClearFlashSale(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	if err := h.services.FlashSaleService.ClearSale(c.Request.Context(), productID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("flash_sale").WithError(err).Error("Failed to clear flash sale")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to clear flash sale"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// JoinFlashSaleQueue godoc
+// @Summary Join a flash sale's waiting room
+// @Description Take the next place in the waiting room of the product's flash sale. Places are admitted in order from the start
+// @Description of the sale; the ticket's token can purchase from admit_at until expires_at. The queue can be joined before the sale starts.
+// @Description Each user has one place: joining again returns it, and moves the user to the back once its token has expired.
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.QueueTicket
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /products/{id}/flash-sale/queue [post]
+func (h *Handler) JoinFlashSaleQueue(c *gin.Context) {
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	ticket, err := h.services.FlashSaleService.JoinQueue(c.Request.Context(), userID, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrSoldOut):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "flash sale not found"})
+		default:
+			h.logger.WithComponent("flash_sale").WithError(err).Error("Failed to join flash sale queue")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to join queue"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+// PurchaseFlashSale godoc
+// @Summary Purchase from a flash sale
+// @Description Buy units of the product at its flash sale price with the token of an admitted queue ticket.
+// @Description Each ticket buys once; a used token is rejected with 403. Before the ticket is admitted the purchase is
+// @Description rejected with 429 and a Retry-After header; once the units are gone with 409.
+// @Description The product's order quantity rules and purchase limit apply as to any purchase.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param purchase body dto.FlashSalePurchaseRequest true "Purchase details"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.QuantityErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.PurchaseLimitErrorResponse
+// @Failure 429 {object} dto.QueueWaitResponse
+// @Router /products/{id}/flash-sale/purchase [post]
+func (h *Handler) PurchaseFlashSale(c *gin.Context) {
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	var req dto.FlashSalePurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	ctx, ok := tracedContext(c, req.Source)
+	if !ok {
+		return
+	}
+
+	err = h.services.FlashSaleService.Purchase(ctx, userID, productID, req.Quantity, req.Token, clientInfo(c))
+	if err != nil {
+		if respondPurchaseError(c, err) {
+			return
+		}
+		var waitErr *domain.QueueWaitError
+		switch {
+		case errors.As(err, &waitErr):
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(waitErr.AdmitAt).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, dto.QueueWaitResponse{Error: err.Error(), Code: "not_admitted", AdmitAt: waitErr.AdmitAt})
+		case errors.Is(err, domain.ErrInvalidSignature):
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "invalid, expired or used queue token"})
+		case errors.Is(err, domain.ErrSoldOut):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "flash sale not found"})
+		default:
+			h.logger.WithComponent("flash_sale").WithError(err).Error("Failed to purchase from flash sale")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to purchase"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "product purchased successfully"})
+}
@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatXML  = "xml"
+
+	mimeCSV = "text/csv"
+)
+
+// responseFormat picks the representation of a list response from ?format= or, if absent,
+// the Accept header. It responds with 400 for an unknown format.
+func responseFormat(c *gin.Context) (string, bool) {
+	switch format := c.Query("format"); format {
+	case formatJSON, formatCSV, formatXML:
+		return format, true
+	case "":
+	default:
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid format"})
+		return "", false
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, mimeCSV, gin.MIMEXML, gin.MIMEXML2) {
+	case mimeCSV:
+		return formatCSV, true
+	case gin.MIMEXML, gin.MIMEXML2:
+		return formatXML, true
+	default:
+		return formatJSON, true
+	}
+}
+
+// flushEvery is how many rows are written between flushes of a streamed export
+const flushEvery = 500
+
+// exportWriter streams the rows of a CSV or XML export to the client as they are read, so
+// exports of any size are never buffered
+type exportWriter struct {
+	c       *gin.Context
+	columns []string
+	rows    int
+
+	csv     *csv.Writer
+	xml     *xml.Encoder
+	root    xml.StartElement
+	element xml.StartElement
+}
+
+// newExportWriter starts a CSV export named file with a header of the columns, or an XML
+// export of root holding an element per row. Once it is started errors can only be logged.
+func newExportWriter(c *gin.Context, format, file, root, element string, columns []string) (*exportWriter, error) {
+	w := &exportWriter{c: c, columns: columns}
+
+	switch format {
+	case formatCSV:
+		c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+file+`.csv"`)
+		c.Status(http.StatusOK)
+
+		w.csv = csv.NewWriter(c.Writer)
+		if err := w.csv.Write(columns); err != nil {
+			return nil, err
+		}
+
+	case formatXML:
+		c.Header("Content-Type", gin.MIMEXML+"; charset=utf-8")
+		c.Status(http.StatusOK)
+
+		w.xml = xml.NewEncoder(c.Writer)
+		w.root = xml.StartElement{Name: xml.Name{Local: root}}
+		w.element = xml.StartElement{Name: xml.Name{Local: element}}
+		if _, err := c.Writer.WriteString(xml.Header); err != nil {
+			return nil, err
+		}
+		if err := w.xml.EncodeToken(w.root); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// Write writes a row with the value of each column
+func (w *exportWriter) Write(value func(column string) string) error {
+	if w.csv != nil {
+		record := make([]string, len(w.columns))
+		for i, column := range w.columns {
+			record[i] = csvSafe(value(column))
+		}
+		if err := w.csv.Write(record); err != nil {
+			return err
+		}
+	} else {
+		if err := w.xml.EncodeToken(w.element); err != nil {
+			return err
+		}
+		for _, column := range w.columns {
+			if err := w.xml.EncodeElement(value(column), xml.StartElement{Name: xml.Name{Local: column}}); err != nil {
+				return err
+			}
+		}
+		if err := w.xml.EncodeToken(w.element.End()); err != nil {
+			return err
+		}
+	}
+
+	if w.rows++; w.rows%flushEvery == 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+		w.c.Writer.Flush()
+	}
+	return nil
+}
+
+// Close ends the export and writes out what is buffered
+func (w *exportWriter) Close() error {
+	if w.xml != nil {
+		if err := w.xml.EncodeToken(w.root.End()); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.xml.Flush()
+}
+
+// csvSafe prefixes text that a spreadsheet would evaluate as a formula with a quote
+func csvSafe(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
@@ -11,6 +13,12 @@ import (
 type Handler struct {
 	services *service.Service
 	logger   *logger.Logger
+
+	// router serves batch sub-requests and product cache refreshes
+	router http.Handler
+
+	// productCache holds anonymous product lists; nil when disabled
+	productCache *responseCache
 }
 
 func NewHandler(services *service.Service, appLogger *logger.Logger) *Handler {
@@ -23,12 +31,21 @@ func NewHandler(services *service.Service, appLogger *logger.Logger) *Handler {
 func (h *Handler) Init(api *gin.RouterGroup) {
 	v1 := api.Group("/v1")
 
+	authMiddleware := middleware.AuthMiddleware(h.services.AuthService)
+
 	// Public routes
-	h.InitAuthRoutes(v1)
+	h.InitAuthRoutes(v1, authMiddleware)
+	h.InitStorefrontRoutes(v1)
+	h.InitMediaRoutes(v1)
+	h.InitSearchRoutes(v1)
 	
 	// Protected routes (require authentication)
-	authMiddleware := middleware.AuthMiddleware(h.services.AuthService)
 	h.InitCategoryRoutes(v1, authMiddleware)
 	h.InitProductRoutes(v1, authMiddleware)
+	h.InitBundleRoutes(v1)
+	h.InitSubscriptionRoutes(v1, authMiddleware)
+	h.InitCartRoutes(v1, authMiddleware)
 	h.InitProfileRoutes(v1, authMiddleware)
+	h.InitAdminRoutes(v1, authMiddleware)
+	h.InitBatchRoutes(v1, authMiddleware)
 }
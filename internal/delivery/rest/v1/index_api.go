@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+)
+
+// ListIndexes godoc
+// @Summary List database indexes
+// @Description List the indexes of each collection with their sizes, the expected indexes that are missing and
+// @Description the indexes the application did not create. Requires the database:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.IndexListResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/indexes [get]
+func (h *Handler) ListIndexes(c *gin.Context) {
+	collections, err := h.services.IndexService.ListIndexes(c.Request.Context())
+	if err != nil {
+		h.logger.WithComponent("indexes").WithError(err).Error("Failed to list indexes")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list indexes"})
+		return
+	}
+
+	response := dto.IndexListResponse{Collections: collections}
+	for _, collection := range collections {
+		response.Missing += len(collection.Missing)
+		response.TotalSize += collection.TotalSize
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BuildIndexes godoc
+// @Summary Build missing indexes
+// @Description Start building the expected indexes that are missing. The build continues in the background;
+// @Description list the indexes to see when it is done. Requires the database:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} dto.BuildIndexesResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/indexes/build [post]
+func (h *Handler) BuildIndexes(c *gin.Context) {
+	building, err := h.services.IndexService.BuildMissingIndexes(c.Request.Context())
+	if err != nil {
+		h.logger.WithComponent("indexes").WithError(err).Error("Failed to build indexes")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to build indexes"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.BuildIndexesResponse{Building: building})
+}
@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// CheckIntegrity godoc
+// @Summary Check referential integrity
+// @Description Find orphaned documents: products and categories referring to missing categories, views and likes
+// @Description of deleted products or users, purchases of missing users and role assignments of missing roles or
+// @Description users. Each check reports a count and a sample of IDs. Requires the database:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.IntegrityReportResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/integrity [get]
+func (h *Handler) CheckIntegrity(c *gin.Context) {
+	issues, err := h.services.IntegrityService.CheckIntegrity(c.Request.Context())
+	if err != nil {
+		h.logger.WithComponent("integrity").WithError(err).Error("Failed to check integrity")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to check integrity"})
+		return
+	}
+
+	response := dto.IntegrityReportResponse{Issues: issues}
+	for _, issue := range issues {
+		response.Orphans += issue.Count
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RepairIntegrity godoc
+// @Summary Repair orphaned documents
+// @Description Repair or quarantine the orphaned documents of the given checks, or of every check that found some.
+// @Description repair unsets a dangling category or parent and deletes dangling views, likes and role assignments;
+// @Description purchases can only be quarantined. quarantine moves the documents to the quarantine collection.
+// @Description Requires the database:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.IntegrityRepairRequest true "Checks and action"
+// @Success 200 {object} dto.IntegrityRepairResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/integrity/repair [post]
+func (h *Handler) RepairIntegrity(c *gin.Context) {
+	var req dto.IntegrityRepairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	repairs, err := h.services.IntegrityService.RepairIntegrity(c.Request.Context(), req.Checks, req.Action)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("integrity").WithError(err).Error("Failed to repair integrity")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to repair orphaned documents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IntegrityRepairResponse{Repairs: repairs})
+}
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+)
+
+// GetMaintenance godoc
+// @Summary Get maintenance mode
+// @Description Show whether the API is in read-only maintenance mode. Requires the database:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.Maintenance
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/maintenance [get]
+func (h *Handler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.services.MaintenanceService.Status())
+}
+
+// SetMaintenance godoc
+// @Summary Switch maintenance mode
+// @Description Switch read-only maintenance mode on or off for all instances. While it is on, writes are rejected
+// @Description with 503 and a Retry-After header; reads keep working. Instances follow within
+// @Description maintenance.refresh_interval. Maintenance enabled in the configuration stays on. Requires the
+// @Description database:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SetMaintenanceRequest true "Maintenance mode"
+// @Success 200 {object} domain.Maintenance
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/maintenance [put]
+func (h *Handler) SetMaintenance(c *gin.Context) {
+	var req dto.SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	maintenance, err := h.services.MaintenanceService.SetMaintenance(c.Request.Context(), *req.Enabled, req.RetryAfter)
+	if err != nil {
+		h.logger.WithComponent("maintenance").WithError(err).Error("Failed to switch maintenance mode")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to switch maintenance mode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, maintenance)
+}
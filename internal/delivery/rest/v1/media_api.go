@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// InitMediaRoutes sets up the serving of private files. The signature in the URL is the
+// authorization, so the route is public.
+func (h *Handler) InitMediaRoutes(api *gin.RouterGroup) {
+	media := api.Group("/media")
+	{
+		media.GET("/private/*path", h.GetPrivateMedia)
+	}
+}
+
+// GetPrivateMedia godoc
+// @Summary Get a private file
+// @Description Serve an invoice, digital download or original upload through a signed URL issued by the API.
+// @Description Invoices and downloads are sent as attachments, originals inline.
+// @Tags media
+// @Produce octet-stream
+// @Param path path string true "File path, e.g. invoices/2025/0001.pdf"
+// @Param expires query int true "Expiry of the URL (Unix seconds)"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} dto.ErrorResponse "Invalid or expired link"
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /media/private/{path} [get]
+func (h *Handler) GetPrivateMedia(c *gin.Context) {
+	path := strings.TrimPrefix(c.Param("path"), "/")
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "invalid or expired link"})
+		return
+	}
+
+	location, err := h.services.MediaService.Open(c.Request.Context(), path, expires, c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidSignature):
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "invalid or expired link"})
+		case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrMediaDisabled):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "file not found"})
+		default:
+			h.logger.WithComponent("media").WithError(err).Error("Failed to open private file")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get file"})
+		}
+		return
+	}
+
+	// Shared caches must not keep the file, and browsers only until the link expires
+	maxAge := max(expires-time.Now().Unix(), 0)
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if strings.HasPrefix(path, domain.MediaOriginals+"/") {
+		c.File(location)
+		return
+	}
+	c.FileAttachment(location, filepath.Base(location))
+}
+
+// SignMediaURL godoc
+// @Summary Sign a private file URL
+// @Description Issue a time-limited URL to a private file. Paths start with the kind of file: invoices/, downloads/ or originals/.
+// @Description The URL is valid on this storefront only, for ttl (default media.url_ttl, at most media.max_url_ttl).
+// @Description Requires the media:sign permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SignMediaRequest true "File and validity"
+// @Success 200 {object} domain.SignedURL
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse "Signed URLs are not configured"
+// @Router /admin/media/sign [post]
+func (h *Handler) SignMediaURL(c *gin.Context) {
+	var req dto.SignMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid ttl"})
+			return
+		}
+	}
+
+	signed, err := h.services.MediaService.SignURL(c.Request.Context(), req.Path, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "file not found"})
+		case errors.Is(err, domain.ErrMediaDisabled):
+			c.JSON(http.StatusServiceUnavailable, dto.ErrorResponse{Error: "signed urls are not configured"})
+		default:
+			h.logger.WithComponent("media").WithError(err).Error("Failed to sign media url")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to sign url"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, signed)
+}
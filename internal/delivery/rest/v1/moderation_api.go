@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListModerationItems godoc
+// @Summary List quarantined content
+// @Description Get a page of user-generated text flagged by the banned terms or the moderation API, oldest first,
+// @Description with why each field was flagged. Requires the content:moderate permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Status: quarantined, approved or rejected; empty for all" default(quarantined)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.ModerationItemListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/moderation [get]
+func (h *Handler) ListModerationItems(c *gin.Context) {
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", domain.ModerationQuarantined)
+
+	items, total, err := h.services.ModerationService.ListItems(c.Request.Context(), status, limit, (page-1)*limit)
+	if err != nil {
+		h.respondModerationError(c, err, "failed to list moderation items")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ModerationItemListResponse{
+		Items:      items,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// ApproveModerationItem godoc
+// @Summary Approve quarantined content
+// @Description Save the quarantined text to its subject as it was written. Requires the content:moderate permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Moderation item ID"
+// @Param request body dto.ResolveModerationItemRequest false "Moderator note"
+// @Success 200 {object} domain.ModerationItem
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/moderation/{id}/approve [post]
+func (h *Handler) ApproveModerationItem(c *gin.Context) {
+	h.resolveModerationItem(c, domain.ModerationApproved)
+}
+
+// RejectModerationItem godoc
+// @Summary Reject quarantined content
+// @Description Discard the quarantined text; the subject keeps its previous text. Requires the content:moderate permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Moderation item ID"
+// @Param request body dto.ResolveModerationItemRequest false "Moderator note"
+// @Success 200 {object} domain.ModerationItem
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/moderation/{id}/reject [post]
+func (h *Handler) RejectModerationItem(c *gin.Context) {
+	h.resolveModerationItem(c, domain.ModerationRejected)
+}
+
+// resolveModerationItem records the current user's decision on a quarantined item
+func (h *Handler) resolveModerationItem(c *gin.Context, status string) {
+	reviewerID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid moderation item id"})
+		return
+	}
+
+	// The note is optional, so an empty body is allowed
+	var req dto.ResolveModerationItemRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+			return
+		}
+	}
+
+	item, err := h.services.ModerationService.ResolveItem(c.Request.Context(), id, status, reviewerID, req.Note)
+	if err != nil {
+		h.respondModerationError(c, err, "failed to resolve moderation item")
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// respondModerationError maps moderation errors to a response, with message for unexpected ones
+func (h *Handler) respondModerationError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "moderation item not found"})
+	case errors.Is(err, domain.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "moderation item already resolved"})
+	default:
+		h.logger.WithComponent("moderation").WithError(err).Error("Failed to manage moderation item")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListOrders godoc
+// @Summary List orders
+// @Description Get a page of orders (purchase records), newest first. Each order is one product bought; its id is the event ID
+// @Description support notes and the interaction export use. With Accept: text/csv or application/xml (or ?format=csv|xml) all
+// @Description matching orders are streamed unless limit is set. Requires the orders:review permission.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Produce xml
+// @Security BearerAuth
+// @Param user_id query int false "Orders of a user"
+// @Param from query string false "Purchased at or after, RFC3339"
+// @Param to query string false "Purchased before, RFC3339"
+// @Param format query string false "Response format" Enums(json, csv, xml)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.OrderListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/orders [get]
+func (h *Handler) ListOrders(c *gin.Context) {
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	filter := domain.OrderFilter{
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user_id"})
+			return
+		}
+		filter.UserID = &userID
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		filter.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		filter.To = &to
+	}
+
+	// CSV and XML are streamed; they include all matching orders unless limit is given
+	format, ok := responseFormat(c)
+	if !ok {
+		return
+	}
+	if format != formatJSON {
+		if err := filter.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if c.Query("limit") == "" {
+			filter.Limit, filter.Offset = 0, 0
+		}
+		h.streamOrders(c, format, filter)
+		return
+	}
+
+	orders, total, err := h.services.OrderService.ListOrders(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("orders").WithError(err).Error("Failed to list orders")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.OrderListResponse{
+		Orders:     orders,
+		Pagination: newPagination(page, limit, total),
+	})
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// orderColumns is the column order of CSV and XML order exports
+var orderColumns = []string{
+	"id", "user_id", "anonymous_id", "product_id", "product_name", "category_name",
+	"quantity", "price", "total", "purchased_at", "source",
+}
+
+// orderColumnValue formats an order field for CSV and XML output
+func orderColumnValue(order *domain.Order, column string) string {
+	switch column {
+	case "id":
+		return order.ID
+	case "user_id":
+		if order.UserID == 0 {
+			return ""
+		}
+		return strconv.Itoa(order.UserID)
+	case "anonymous_id":
+		return order.AnonymousID
+	case "product_id":
+		return strconv.Itoa(order.ProductID)
+	case "product_name":
+		return order.ProductName
+	case "category_name":
+		return order.CategoryName
+	case "quantity":
+		return strconv.Itoa(order.Quantity)
+	case "price":
+		return order.Price.String()
+	case "total":
+		return order.Total.String()
+	case "purchased_at":
+		return order.PurchasedAt.UTC().Format(time.RFC3339)
+	case "source":
+		return order.Source
+	}
+	return ""
+}
+
+// streamOrders writes the orders matching the filter as CSV or XML, row by row as they are
+// read from the database. Once streaming has started errors can only be logged.
+func (h *Handler) streamOrders(c *gin.Context, format string, filter domain.OrderFilter) {
+	writer, err := newExportWriter(c, format, "orders", "orders", "order", orderColumns)
+	if err != nil {
+		h.logger.WithComponent("orders").WithError(err).Error("Failed to write orders export")
+		return
+	}
+
+	write := func(order *domain.Order) error {
+		return writer.Write(func(column string) string {
+			return orderColumnValue(order, column)
+		})
+	}
+
+	if err := h.services.OrderService.StreamOrders(c.Request.Context(), filter, write); err != nil {
+		h.logger.WithComponent("orders").WithError(err).Error("Failed to stream orders export")
+		return
+	}
+	if err := writer.Close(); err != nil {
+		h.logger.WithComponent("orders").WithError(err).Error("Failed to write orders export")
+	}
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+)
+
+// parsePage reads the page and limit query parameters, or the cursor of a previous response,
+// responding with 400 if the cursor is invalid. A missing or out-of-range limit falls back to
+// defaultLimit.
+func parsePage(c *gin.Context, defaultLimit, maxLimit int) (page, limit int, ok bool) {
+	if cursor := c.Query("cursor"); cursor != "" {
+		page, limit, err := decodePageCursor(cursor)
+		if err != nil || page < 1 || limit < 1 || limit > maxLimit {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid cursor"})
+			return 0, 0, false
+		}
+		return page, limit, true
+	}
+
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	return page, limit, true
+}
+
+// newPagination describes the page of total items, with cursors to the neighbouring pages
+func newPagination(page, limit int, total int64) dto.Pagination {
+	pagination := dto.Pagination{
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}
+	if limit > 0 {
+		pagination.TotalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+
+	if page < pagination.TotalPages {
+		pagination.NextCursor = encodePageCursor(page+1, limit)
+	}
+	if page > 1 && pagination.TotalPages > 0 {
+		// Past the end, step back to the last page
+		pagination.PrevCursor = encodePageCursor(min(page-1, pagination.TotalPages), limit)
+	}
+	return pagination
+}
+
+// paginate returns the page of items, for lists the services return whole
+func paginate[T any](items []T, page, limit int) []T {
+	start := (page - 1) * limit
+	if start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+limit, len(items))]
+}
+
+func encodePageCursor(page, limit int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", page, limit)))
+}
+
+func decodePageCursor(cursor string) (page, limit int, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(string(data), "%d:%d", &page, &limit); err != nil {
+		return 0, 0, err
+	}
+	return page, limit, nil
+}
@@ -0,0 +1,448 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Description Get all permissions. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Permission
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/permissions [get]
+func (h *Handler) ListPermissions(c *gin.Context) {
+	permissions, err := h.services.PermissionService.ListPermissions(c.Request.Context())
+	if err != nil {
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to list permissions")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list permissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// CreatePermission godoc
+// @Summary Create permission
+// @Description Create a new resource:action permission. Requires the permissions:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreatePermissionRequest true "Permission data"
+// @Success 201 {object} domain.Permission
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/permissions [post]
+func (h *Handler) CreatePermission(c *gin.Context) {
+	var req dto.CreatePermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	permission := &domain.Permission{
+		Resource:    req.Resource,
+		Action:      req.Action,
+		Description: req.Description,
+	}
+
+	if err := h.services.PermissionService.CreatePermission(c.Request.Context(), permission); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrAlreadyExists {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "permission already exists"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to create permission")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to create permission"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, permission)
+}
+
+// DeletePermission godoc
+// @Summary Delete permission
+// @Description Delete a permission and revoke it from all roles. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Permission ID"
+// @Success 204
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/permissions/{id} [delete]
+func (h *Handler) DeletePermission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid permission id"})
+		return
+	}
+
+	if err := h.services.PermissionService.DeletePermission(c.Request.Context(), id); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "permission not found"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to delete permission")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to delete permission"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description Get all roles. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Role
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/roles [get]
+func (h *Handler) ListRoles(c *gin.Context) {
+	roles, err := h.services.PermissionService.ListRoles(c.Request.Context())
+	if err != nil {
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to list roles")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole godoc
+// @Summary Get role
+// @Description Get a role with the IDs of the roles it inherits. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} domain.Role
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/roles/{id} [get]
+func (h *Handler) GetRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid role id"})
+		return
+	}
+
+	role, err := h.services.PermissionService.GetRole(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "role not found"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to get role")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Create a role that also grants the permissions of the roles it inherits. Requires the permissions:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateRoleRequest true "Role data"
+// @Success 201 {object} domain.Role
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/roles [post]
+func (h *Handler) CreateRole(c *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	role := &domain.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Inherits:    req.Inherits,
+	}
+
+	if err := h.services.PermissionService.CreateRole(c.Request.Context(), role); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrAlreadyExists {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "role already exists"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to create role")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to create role"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Change the description and inherited roles of a role. Permission changes apply to signed-in users at once. Requires the permissions:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body dto.UpdateRoleRequest true "Role data"
+// @Success 200 {object} domain.Role
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/roles/{id} [put]
+func (h *Handler) UpdateRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid role id"})
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	role := &domain.Role{
+		ID:          id,
+		Description: req.Description,
+		Inherits:    req.Inherits,
+	}
+
+	if err := h.services.PermissionService.UpdateRole(c.Request.Context(), role); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "role not found"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to update role")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to update role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Delete a role and its permission grants. Roles assigned to users or inherited by other roles, and the built-in roles, can't be deleted. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/roles/{id} [delete]
+func (h *Handler) DeleteRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid role id"})
+		return
+	}
+
+	if err := h.services.PermissionService.DeleteRole(c.Request.Context(), id); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "role not found"})
+			return
+		}
+		if err == domain.ErrRoleInUse {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "role is assigned to users or inherited by other roles"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to delete role")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to delete role"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetRolePermissions godoc
+// @Summary Get role permissions
+// @Description Get the permissions granted to a role. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {array} domain.Permission
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/roles/{id}/permissions [get]
+func (h *Handler) GetRolePermissions(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid role id"})
+		return
+	}
+
+	permissions, err := h.services.PermissionService.GetRolePermissions(c.Request.Context(), roleID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "role not found"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to get role permissions")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get role permissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// GrantRolePermission godoc
+// @Summary Grant permission to role
+// @Description Grant a permission to a role and the roles inheriting it. Applies to signed-in users at once. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param permission_id path int true "Permission ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/roles/{id}/permissions/{permission_id} [post]
+func (h *Handler) GrantRolePermission(c *gin.Context) {
+	roleID, permissionID, ok := parseRolePermissionIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.PermissionService.GrantPermission(c.Request.Context(), roleID, permissionID); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "role or permission not found"})
+			return
+		}
+		if err == domain.ErrAlreadyExists {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "permission already granted"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to grant permission")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to grant permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "permission granted"})
+}
+
+// RevokeRolePermission godoc
+// @Summary Revoke permission from role
+// @Description Revoke a permission from a role. Requires the permissions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param permission_id path int true "Permission ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/roles/{id}/permissions/{permission_id} [delete]
+func (h *Handler) RevokeRolePermission(c *gin.Context) {
+	roleID, permissionID, ok := parseRolePermissionIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.PermissionService.RevokePermission(c.Request.Context(), roleID, permissionID); err != nil {
+		if err == domain.ErrAdminTenantOnly {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "permission not granted to role"})
+			return
+		}
+		h.logger.WithComponent("admin").WithError(err).Error("Failed to revoke permission")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to revoke permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "permission revoked"})
+}
+
+// parseRolePermissionIDs reads the role and permission IDs from the path,
+// responding with 400 if either is invalid
+func parseRolePermissionIDs(c *gin.Context) (int, int, bool) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid role id"})
+		return 0, 0, false
+	}
+
+	permissionID, err := strconv.Atoi(c.Param("permission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid permission id"})
+		return 0, 0, false
+	}
+
+	return roleID, permissionID, true
+}
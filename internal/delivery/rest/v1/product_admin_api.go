@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListAdminProducts godoc
+// @Summary List products with margins
+// @Description Get a page of products, active and inactive, with their cost price, margin (price less cost price) and margin rate
+// @Description (margin as a share of the price). Products without a cost price have none of the three. Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param category_id query int false "Filter by category ID"
+// @Param is_active query bool false "Only active or only inactive products"
+// @Param search query string false "Search in name and description"
+// @Param sort query string false "Comma-separated sort keys, prefix with - for descending: id, name, price, stock, created_at, updated_at" default(-created_at)
+// @Param filter query string false "Filter expression, e.g. price>=100,stock>0"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page, at most the listing max_limit (100 by default)" default(20)
+// @Success 200 {object} dto.AdminProductListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/products [get]
+func (h *Handler) ListAdminProducts(c *gin.Context) {
+	listing := h.services.ProductService.Listing(domain.ListingAdminProducts)
+	page, limit, ok := parsePage(c, listing.DefaultLimit, listing.MaxLimit)
+	if !ok {
+		return
+	}
+
+	filter := domain.ProductFilter{
+		SearchQuery: c.Query("search"),
+		Limit:       limit,
+		Offset:      (page - 1) * limit,
+	}
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		categoryID, err := strconv.Atoi(categoryIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid category_id"})
+			return
+		}
+		filter.CategoryID = &categoryID
+	}
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		isActive, err := strconv.ParseBool(isActiveStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid is_active"})
+			return
+		}
+		filter.IsActive = &isActive
+	}
+
+	sort, err := domain.ParseSort(c.Query("sort"), listing.SortFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter.Sort = sort
+
+	currency := h.services.ProductService.Currency(c.Request.Context())
+	conditions, err := parseFilterExpression(c.Query("filter"), domain.ProductFilterFields, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter.Conditions = conditions
+
+	products, total, err := h.services.ProductService.ListAdminProducts(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to list admin products")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AdminProductListResponse{
+		Products:   products,
+		Pagination: newPagination(page, limit, total),
+	})
+}
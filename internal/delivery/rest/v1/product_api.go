@@ -1,69 +1,109 @@
 package v1
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
 )
 
 // InitProductRoutes initializes product routes
 func (h *Handler) InitProductRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// Browsing is public; liked and purchased status is added for signed-in users
+	catalog := api.Group("/products")
+	catalog.Use(middleware.OptionalAuth(h.services.AuthService))
+	{
+		catalog.GET("", h.cacheProductList, h.ListProducts)
+		catalog.GET("/search", h.SearchProducts)
+		catalog.GET("/:id", h.GetProduct)
+		catalog.GET("/:id/availability", h.GetProductAvailability)
+		catalog.GET("/:id/subscription-plans", h.ListSubscriptionPlans)
+		catalog.GET("/:id/frequently-bought-together", h.GetFrequentlyBoughtTogether)
+
+		// Guests are tracked by an anonymous session, merged into their account on sign-in
+		catalog.POST("/:id/view", middleware.AnonymousSession(), h.RecordProductView)
+		catalog.POST("/:id/purchase", middleware.AnonymousSession(), h.PurchaseProduct)
+	}
+
 	products := api.Group("/products")
 	products.Use(authMiddleware)
 	{
-		products.GET("", h.ListProducts)
-		products.GET("/:id", h.GetProduct)
 		products.GET("/:id/statistics", h.GetProductStatistics)
-		products.POST("", h.CreateProduct)
-		products.PUT("/:id", h.UpdateProduct)
-		products.DELETE("/:id", h.DeleteProduct)
+		products.GET("/:id/stream", h.StreamProductStock)
+		products.POST("", middleware.RequirePermission(domain.PermissionProductsWrite), h.CreateProduct)
+		products.PUT("/:id", middleware.RequirePermission(domain.PermissionProductsWrite), h.UpdateProduct)
+		products.DELETE("/:id", middleware.RequirePermission(domain.PermissionProductsWrite), h.DeleteProduct)
+		products.POST("/:id/image", middleware.RequirePermission(domain.PermissionProductsWrite), h.UploadProductImage)
+		products.GET("/:id/translations", middleware.RequirePermission(domain.PermissionProductsWrite), h.GetProductTranslations)
+		products.PUT("/:id/translations/:locale", middleware.RequirePermission(domain.PermissionProductsWrite), h.SetProductTranslation)
+		products.DELETE("/:id/translations/:locale", middleware.RequirePermission(domain.PermissionProductsWrite), h.DeleteProductTranslation)
+		products.PUT("/:id/flash-sale", middleware.RequirePermission(domain.PermissionProductsWrite), h.SetFlashSale)
+		products.DELETE("/:id/flash-sale", middleware.RequirePermission(domain.PermissionProductsWrite), h.ClearFlashSale)
+		products.POST("/:id/flash-sale/queue", h.JoinFlashSaleQueue)
+		products.POST("/:id/flash-sale/purchase", h.PurchaseFlashSale)
 
-		products.POST("/:id/view", h.RecordProductView)
 		products.POST("/:id/like", h.LikeProduct)
 		products.DELETE("/:id/like", h.UnlikeProduct)
 		products.GET("/:id/liked", h.CheckProductLiked)
-		products.POST("/:id/purchase", h.PurchaseProduct)
 		products.GET("/:id/purchased", h.CheckProductPurchased)
 	}
 }
 
 // ListProducts godoc
 // @Summary List products
-// @Description Get a paginated list of products with optional filters
+// @Description Get a paginated list of products with optional filters.
+// @Description With Accept: text/csv or application/xml (or ?format=csv|xml) all matching products are streamed unless limit is set.
+// @Description Authentication is optional; signed-in users also get liked and purchased per product.
 // @Tags products
 // @Accept json
 // @Produce json
+// @Produce text/csv
+// @Produce xml
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
-// @Param limit query int false "Items per page" default(20)
+// @Param limit query int false "Items per page, at most the listing max_limit (100 by default)" default(20)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
 // @Param category_id query string false "Filter by category ID"
 // @Param min_price query number false "Minimum price"
 // @Param max_price query number false "Maximum price"
 // @Param search query string false "Search in name and description"
-// @Param sort_by query string false "Sort by: name, price, created_at" default(created_at)
-// @Param sort_order query string false "Sort order: asc, desc" default(desc)
+// @Param sort query string false "Comma-separated sort keys, prefix with - for descending: id, name, price, stock, created_at, updated_at" default(-created_at)
+// @Param sort_by query string false "Deprecated, use sort" default(created_at)
+// @Param sort_order query string false "Deprecated, use sort: asc, desc" default(desc)
+// @Param filter query string false "Filter expression, e.g. price>=100,stock>0,category_id in (2,3)"
+// @Param fields query string false "Comma-separated fields to return, e.g. id,name,price"
+// @Param format query string false "Response format (json, csv, xml); overrides Accept"
 // @Success 200 {object} dto.ProductListResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Router /products [get]
 func (h *Handler) ListProducts(c *gin.Context) {
 	// Parse pagination
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-
-	if page < 1 {
-		page = 1
+	listing := h.services.ProductService.Listing(domain.ListingProducts)
+	page, limit, ok := parsePage(c, listing.DefaultLimit, listing.MaxLimit)
+	if !ok {
+		return
 	}
 	offset := (page - 1) * limit
 
+	fields, ok := parseFields(c, domain.ProductFields)
+	if !ok {
+		return
+	}
+
 	// Build filter
 	filter := domain.ProductFilter{
+		Fields:      fields,
 		Limit:       limit,
 		Offset:      offset,
-		SortBy:      c.Query("sort_by"),
-		SortOrder:   c.Query("sort_order"),
 		SearchQuery: c.Query("search"),
 	}
 
@@ -77,9 +117,10 @@ func (h *Handler) ListProducts(c *gin.Context) {
 		filter.CategoryID = &categoryID
 	}
 
-	// Price filters
+	// Price filters, in the storefront currency
+	currency := h.services.ProductService.Currency(c.Request.Context())
 	if minPriceStr := c.Query("min_price"); minPriceStr != "" {
-		minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+		minPrice, err := domain.ParseMoney(minPriceStr, currency)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid min_price"})
 			return
@@ -88,7 +129,7 @@ func (h *Handler) ListProducts(c *gin.Context) {
 	}
 
 	if maxPriceStr := c.Query("max_price"); maxPriceStr != "" {
-		maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+		maxPrice, err := domain.ParseMoney(maxPriceStr, currency)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid max_price"})
 			return
@@ -96,31 +137,204 @@ func (h *Handler) ListProducts(c *gin.Context) {
 		filter.MaxPrice = &maxPrice
 	}
 
+	// Sort; sort_by/sort_order are kept for older clients
+	sortSpec := c.Query("sort")
+	if sortSpec == "" && c.Query("sort_by") != "" {
+		sortSpec = c.Query("sort_by")
+		if c.Query("sort_order") != "asc" {
+			sortSpec = "-" + sortSpec
+		}
+	}
+	sort, err := domain.ParseSort(sortSpec, listing.SortFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter.Sort = sort
+
+	// Filter expression
+	conditions, err := parseFilterExpression(c.Query("filter"), domain.ProductFilterFields, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	filter.Conditions = conditions
+
+	// CSV and XML are streamed; they include all matching products unless limit is given
+	format, ok := responseFormat(c)
+	if !ok {
+		return
+	}
+	if format != formatJSON {
+		if c.Query("limit") == "" {
+			filter.Limit, filter.Offset = 0, 0
+		}
+		h.streamProducts(c, format, filter)
+		return
+	}
+
 	// Get products with categories
 	products, total, err := h.services.ProductService.ListProductsWithCategories(c.Request.Context(), filter)
 	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		h.logger.WithComponent("product").WithError(err).Error("Failed to list products")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list products"})
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.ProductListResponse{
-		Products: products,
-		Total:    total,
-		Page:     page,
-		Limit:    limit,
+	localizeProducts(c, products...)
+	personalized, err := h.personalizeProducts(c, products...)
+	if err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to personalize products")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list products"})
+		return
+	}
+
+	pagination := newPagination(page, limit, total.Count)
+	pagination.TotalIsEstimate = total.Estimated
+
+	// Personalized fields change without touching the products, so only the ETag can validate them
+	var lastModified time.Time
+	for _, product := range products {
+		if !personalized && product.UpdatedAt.After(lastModified) {
+			lastModified = product.UpdatedAt
+		}
+	}
+
+	if len(fields) == 0 {
+		h.respondConditional(c, lastModified, dto.ProductListResponse{
+			Products:   products,
+			Pagination: pagination,
+		})
+		return
+	}
+
+	items, err := selectFields(products, fields)
+	if err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to select product fields")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list products"})
+		return
+	}
+
+	h.respondConditional(c, lastModified, struct {
+		Products interface{} `json:"products"`
+		dto.Pagination
+	}{items, pagination})
+}
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Relevance-ranked search over active products. With the Elasticsearch backend matching is
+// @Description typo tolerant, popular products rank higher and results carry highlighted fragments
+// @Description (matches wrapped in <em>). Authentication is optional; signed-in users also get liked and purchased.
+// @Description A query that finds nothing is retried with its best did-you-mean suggestion and then relaxed to
+// @Description products with a name word starting like a query word; correction describes what was done.
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search text"
+// @Param category_id query int false "Filter by category ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page, at most the listing max_limit (100 by default)" default(20)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Param search_id query string false "search_id of the first page, so later pages aren't logged as new searches"
+// @Success 200 {object} dto.ProductSearchResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /products/search [get]
+func (h *Handler) SearchProducts(c *gin.Context) {
+	listing := h.services.ProductService.Listing(domain.ListingSearch)
+	page, limit, ok := parsePage(c, listing.DefaultLimit, listing.MaxLimit)
+	if !ok {
+		return
+	}
+
+	query := domain.SearchQuery{
+		Query:  c.Query("q"),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		categoryID, err := strconv.Atoi(categoryIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid category id"})
+			return
+		}
+		query.CategoryID = &categoryID
+	}
+
+	result, err := h.services.SearchService.Search(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("search").WithError(err).Error("Failed to search products")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to search products"})
+		return
+	}
+
+	products := make([]*domain.ProductWithCategory, len(result.Hits))
+	for i, hit := range result.Hits {
+		products[i] = hit.Product
+	}
+	localizeProducts(c, products...)
+	if _, err := h.personalizeProducts(c, products...); err != nil {
+		h.logger.WithComponent("search").WithError(err).Error("Failed to personalize products")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to search products"})
+		return
+	}
+
+	searchID := c.Query("search_id")
+	if searchID == "" {
+		searchID = h.logSearch(c, query, result)
+	}
+
+	c.JSON(http.StatusOK, dto.ProductSearchResponse{
+		Results:    result.Hits,
+		Correction: result.Correction,
+		SearchID:   searchID,
+		Pagination: newPagination(page, limit, result.Total),
 	})
 }
 
+// logSearch records the search for analytics, returning its ID. A search that can't be logged
+// is still answered, without an ID.
+func (h *Handler) logSearch(c *gin.Context, query domain.SearchQuery, result *domain.SearchResult) string {
+	log := &domain.SearchLog{
+		Query:       strings.TrimSpace(query.Query),
+		CategoryID:  query.CategoryID,
+		ResultCount: result.Total,
+		Corrected:   result.Correction != nil && result.Total > 0,
+		RequestID:   c.GetString(logger.RequestIDKey),
+	}
+	if userIDStr, err := middleware.GetUserID(c); err == nil {
+		log.UserID, _ = strconv.Atoi(userIDStr)
+	}
+
+	searchID, err := h.services.SearchLogService.Record(c.Request.Context(), log)
+	if err != nil {
+		h.logger.WithComponent("search").WithError(err).Warn("Failed to log search")
+		return ""
+	}
+	return searchID
+}
+
 // GetProduct godoc
 // @Summary Get product by ID
-// @Description Get detailed information about a specific product
+// @Description Get detailed information about a specific product.
+// @Description Authentication is optional; signed-in users also get liked and purchased.
+// @Description availability breaks the stock down by active warehouse.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Product ID"
+// @Param fields query string false "Comma-separated fields to return, e.g. id,name,price"
 // @Success 200 {object} domain.ProductWithCategory
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Router /products/{id} [get]
 func (h *Handler) GetProduct(c *gin.Context) {
@@ -131,7 +345,12 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.services.ProductService.GetProductWithCategory(c.Request.Context(), id)
+	fields, ok := parseFields(c, domain.ProductFields)
+	if !ok {
+		return
+	}
+
+	product, err := h.services.ProductService.GetProductWithCategory(c.Request.Context(), id, fields)
 	if err != nil {
 		if err == domain.ErrNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
@@ -142,12 +361,75 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	localizeProducts(c, product)
+
+	personalized, err := h.personalizeProducts(c, product)
+	if err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to personalize product")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get product"})
+		return
+	}
+
+	if wantsField(fields, "availability") {
+		product.Availability, err = h.services.InventoryService.GetAvailability(c.Request.Context(), id)
+		if err != nil {
+			h.logger.WithComponent("product").WithError(err).Error("Failed to get product availability")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get product"})
+			return
+		}
+	}
+
+	body, err := selectFields(product, fields)
+	if err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to select product fields")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get product"})
+		return
+	}
+
+	// Transfers between warehouses do not touch the product, so availability has no
+	// modification time and only the ETag applies
+	lastModified := product.UpdatedAt
+	if personalized || product.Availability != nil {
+		lastModified = time.Time{}
+	}
+	h.respondConditional(c, lastModified, body)
+}
+
+// localizeProducts puts the products' name and description in the request locale
+func localizeProducts(c *gin.Context, products ...*domain.ProductWithCategory) {
+	locale := middleware.GetLocale(c)
+	for _, product := range products {
+		product.Localize(locale)
+	}
+}
+
+// personalizeProducts sets the liked and purchased status of the products and the prices of the
+// user's customer group when the request is authenticated, reporting whether it did. Anonymous
+// requests are left untouched.
+func (h *Handler) personalizeProducts(c *gin.Context, products ...*domain.ProductWithCategory) (bool, error) {
+	userIDStr, err := middleware.GetUserID(c)
+	if err != nil {
+		return false, nil
+	}
+
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return false, nil
+	}
+
+	if err := h.services.InteractionService.PersonalizeProducts(c.Request.Context(), userID, products); err != nil {
+		return false, err
+	}
+	if err := h.services.CustomerGroupService.PriceProducts(c.Request.Context(), userID, products); err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Create a new product (admin only)
+// @Description Create a new product (admin only), optionally with volume price tiers
 // @Tags products
 // @Accept json
 // @Produce json
@@ -165,18 +447,45 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// TODO: Check if user has admin role
+	currency := h.services.ProductService.Currency(c.Request.Context())
+	price, err := req.Price.Money(currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	costPrice, err := req.CostPrice.Money(currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	priceTiers, err := dto.ToPriceTiers(req.PriceTiers, currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
 
 	product := &domain.Product{
 		Name:        req.Name,
 		Description: req.Description,
 		CategoryID:  req.CategoryID,
-		Price:       req.Price,
-		Stock:       req.Stock,
-		ImageURL:    req.ImageURL,
+		Price:       price,
+		CostPrice:   costPrice,
+
+		AllowBelowCost: req.AllowBelowCost,
+		Stock:          req.Stock,
+		ImageURL:       req.ImageURL,
+		PriceTiers:     priceTiers,
+		MinOrderQty:    req.MinOrderQty,
+		MaxOrderQty:    req.MaxOrderQty,
+		QtyStep:        req.QtyStep,
+		PurchaseLimit:  req.PurchaseLimit.ToDomain(),
 	}
 
 	if err := h.services.ProductService.CreateProduct(c.Request.Context(), product); err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		h.logger.WithComponent("product").WithError(err).Error("Failed to create product")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
 		return
@@ -187,7 +496,8 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 
 // UpdateProduct godoc
 // @Summary Update a product
-// @Description Update product information (admin only)
+// @Description Update product information (admin only). A stock change is recorded in the stock ledger as a correction;
+// @Description prefer POST /admin/products/{id}/stock-adjustments, which records the reason.
 // @Tags products
 // @Accept json
 // @Produce json
@@ -213,8 +523,6 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// TODO: Check if user has admin role
-
 	// Get existing product first
 	existingProduct, err := h.services.ProductService.GetProduct(c.Request.Context(), id)
 	if err != nil {
@@ -237,24 +545,77 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	if req.CategoryID != nil {
 		existingProduct.CategoryID = req.CategoryID
 	}
+	currency := h.services.ProductService.Currency(c.Request.Context())
 	if req.Price != nil {
-		existingProduct.Price = *req.Price
+		if existingProduct.Price, err = req.Price.Money(currency); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if req.CostPrice != nil {
+		if existingProduct.CostPrice, err = req.CostPrice.Money(currency); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if req.PriceTiers != nil {
+		if existingProduct.PriceTiers, err = dto.ToPriceTiers(*req.PriceTiers, currency); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if req.MinOrderQty != nil {
+		existingProduct.MinOrderQty = *req.MinOrderQty
+	}
+	if req.MaxOrderQty != nil {
+		existingProduct.MaxOrderQty = *req.MaxOrderQty
+	}
+	if req.QtyStep != nil {
+		existingProduct.QtyStep = *req.QtyStep
+	}
+	if req.PurchaseLimit != nil {
+		existingProduct.PurchaseLimit = req.PurchaseLimit.ToDomain()
 	}
-	if req.Stock != nil {
-		existingProduct.Stock = *req.Stock
+	if req.Stock != nil && *req.Stock < 0 {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "product stock cannot be negative"})
+		return
+	}
+	if req.Stock != nil && *req.Stock != existingProduct.Stock {
+		// Stock changes are recorded in the stock ledger as corrections
+		actorID, _ := strconv.Atoi(c.GetString("userId"))
+
+		updated, err := h.services.ProductService.AdjustStock(c.Request.Context(), &domain.StockAdjustment{
+			ProductID: id,
+			Delta:     *req.Stock - existingProduct.Stock,
+			Reason:    domain.StockReasonCorrection,
+			Note:      "set by product update",
+			ActorID:   actorID,
+		})
+		if err != nil {
+			h.respondStockError(c, err)
+			return
+		}
+		existingProduct.Stock = updated.Stock
 	}
-	if req.ImageURL != nil {
+	if req.ImageURL != nil && *req.ImageURL != existingProduct.ImageURL {
+		// The variants of an uploaded image don't match an image set by URL
 		existingProduct.ImageURL = *req.ImageURL
+		existingProduct.ImageVariants = nil
 	}
 	if req.IsActive != nil {
 		existingProduct.IsActive = *req.IsActive
 	}
+	existingProduct.AllowBelowCost = req.AllowBelowCost
 
 	if err := h.services.ProductService.UpdateProduct(c.Request.Context(), existingProduct); err != nil {
 		if err == domain.ErrNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
 			return
 		}
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		h.logger.WithComponent("product").WithError(err).Error("Failed to update product")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: err.Error()})
 		return
@@ -263,6 +624,69 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, existingProduct)
 }
 
+// UploadProductImage godoc
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG or GIF image of the product (admin only). Thumb, medium and large variants, plus WebP copies
+// @Description when media.webp_encoder is set, are generated in the background; the product's image_url and image_variants
+// @Description are set once they are ready. The original is kept under originals/ in the private media directory.
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Param image formData file true "Image file"
+// @Success 202 {object} dto.ProductImageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Router /products/{id}/image [post]
+func (h *Handler) UploadProductImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	header, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "image file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "failed to read image file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "failed to read image file"})
+		return
+	}
+
+	job, err := h.services.ProductImageService.Upload(c.Request.Context(), id, data)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to upload product image")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to upload product image"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.ProductImageResponse{
+		ProductID:  job.ProductID,
+		Status:     "processing",
+		UploadedAt: job.UploadedAt,
+	})
+}
+
 // DeleteProduct godoc
 // @Summary Delete a product
 // @Description Delete a product (admin only)
@@ -283,8 +707,6 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// TODO: Check if user has admin role
-
 	if err := h.services.ProductService.DeleteProduct(c.Request.Context(), id); err != nil {
 		if err == domain.ErrNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
@@ -334,41 +756,85 @@ func (h *Handler) GetProductStatistics(c *gin.Context) {
 
 // RecordProductView godoc
 // @Summary Record product view
-// @Description Record that a user has viewed a product
+// @Description Record that a user has viewed a product. Without a token the view is recorded for the
+// @Description guest's anonymous session (X-Anonymous-ID header or anonymous_id cookie, issued when missing).
+// @Description The body is optional: clients can report how long the page was shown, how far it was scrolled and
+// @Description where the user came from. Deeply engaged views count for more in recommendations.
+// @Description Views of products opened from search results should send the search_id of the search (also ?search_id=).
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param X-Anonymous-ID header string false "Anonymous session ID of a guest"
+// @Param request body dto.RecordViewRequest false "View engagement"
 // @Security BearerAuth
 // @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
 // @Router /products/{id}/view [post]
 func (h *Handler) RecordProductView(c *gin.Context) {
-	// Get user ID from context
-	userIDStr, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+	idStr := c.Param("id")
+	productID, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+	// Engagement is optional, so an empty body is allowed
+	var req dto.RecordViewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+			return
+		}
+	}
+	engagement := domain.ViewEngagement{
+		DwellSeconds: req.DwellSeconds,
+		ScrollDepth:  req.ScrollDepth,
+	}
+	ctx, ok := tracedContext(c, req.Source)
+	if !ok {
 		return
 	}
 
-	idStr := c.Param("id")
-	productID, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+	if anonymousID := middleware.GetAnonymousID(c); anonymousID != "" {
+		err = h.services.InteractionService.RecordAnonymousView(ctx, anonymousID, productID, engagement)
+	} else {
+		// Get user ID from context
+		userIDStr, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+			return
+		}
+
+		userID, convErr := strconv.Atoi(userIDStr.(string))
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+			return
+		}
+
+		err = h.services.InteractionService.RecordProductView(ctx, userID, productID, engagement)
+	}
+	if errors.Is(err, domain.ErrValidation) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	if err := h.services.InteractionService.RecordProductView(c.Request.Context(), userID, productID); err != nil {
+	if err != nil {
 		h.logger.WithComponent("interaction").WithError(err).Error("Failed to record view")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to record view"})
 		return
 	}
 
+	searchID := req.SearchID
+	if searchID == "" {
+		searchID = c.Query("search_id")
+	}
+	if searchID != "" {
+		if err := h.services.SearchLogService.RecordClick(ctx, searchID, productID); err != nil {
+			h.logger.WithComponent("search").WithError(err).Warn("Failed to record search click")
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "view recorded"})
 }
 
@@ -379,8 +845,10 @@ func (h *Handler) RecordProductView(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param source query string false "Page the product was liked on: search, recommendation, category"
 // @Security BearerAuth
 // @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} dto.ErrorResponse
 // @Router /products/{id}/like [post]
 func (h *Handler) LikeProduct(c *gin.Context) {
 	// Get user ID from context
@@ -403,7 +871,12 @@ func (h *Handler) LikeProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.InteractionService.LikeProduct(c.Request.Context(), userID, productID); err != nil {
+	ctx, ok := tracedContext(c, "")
+	if !ok {
+		return
+	}
+
+	if err := h.services.InteractionService.LikeProduct(ctx, userID, productID); err != nil {
 		h.logger.WithComponent("interaction").WithError(err).Error("Failed to like product")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to like product"})
 		return
@@ -421,6 +894,7 @@ func (h *Handler) LikeProduct(c *gin.Context) {
 // @Param id path int true "Product ID"
 // @Security BearerAuth
 // @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} dto.ErrorResponse
 // @Router /products/{id}/like [delete]
 func (h *Handler) UnlikeProduct(c *gin.Context) {
 	// Get user ID from context
@@ -461,6 +935,7 @@ func (h *Handler) UnlikeProduct(c *gin.Context) {
 // @Param id path int true "Product ID"
 // @Security BearerAuth
 // @Success 200 {object} map[string]bool
+// @Failure 401 {object} dto.ErrorResponse
 // @Router /products/{id}/liked [get]
 func (h *Handler) CheckProductLiked(c *gin.Context) {
 	// Get user ID from context
@@ -495,29 +970,26 @@ func (h *Handler) CheckProductLiked(c *gin.Context) {
 
 // PurchaseProduct godoc
 // @Summary Purchase a product
-// @Description Record a product purchase and update stock
+// @Description Record a product purchase and update stock. Without a token this is a guest checkout,
+// @Description recorded for the anonymous session and reassigned to the account when the guest signs in.
+// @Description The purchase ships from warehouse_id, or else from the active warehouse with the most stock that can fulfil it.
+// @Description A quantity the product's order quantity rules don't allow is rejected with the allowed range.
+// @Description A purchase over the user's purchase limit of the product is rejected with 409 and what is left of the limit;
+// @Description guests can't buy products with a purchase limit.
+// @Description Products on an active flash sale can only be bought through its waiting room.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param X-Anonymous-ID header string false "Anonymous session ID of a guest"
 // @Param purchase body dto.PurchaseProductRequest true "Purchase details"
 // @Security BearerAuth
 // @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.QuantityErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.PurchaseLimitErrorResponse
 // @Router /products/{id}/purchase [post]
 func (h *Handler) PurchaseProduct(c *gin.Context) {
-	// Get user ID from context
-	userIDStr, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
-		return
-	}
-
-	userID, err := strconv.Atoi(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
-		return
-	}
-
 	idStr := c.Param("id")
 	productID, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -536,7 +1008,33 @@ func (h *Handler) PurchaseProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.InteractionService.PurchaseProduct(c.Request.Context(), userID, productID, req.Quantity); err != nil {
+	ctx, ok := tracedContext(c, req.Source)
+	if !ok {
+		return
+	}
+
+	if anonymousID := middleware.GetAnonymousID(c); anonymousID != "" {
+		err = h.services.InteractionService.PurchaseProductAsGuest(ctx, anonymousID, productID, req.Quantity, req.WarehouseID, clientInfo(c))
+	} else {
+		// Get user ID from context
+		userIDStr, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+			return
+		}
+
+		userID, convErr := strconv.Atoi(userIDStr.(string))
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+			return
+		}
+
+		err = h.services.InteractionService.PurchaseProduct(ctx, userID, productID, req.Quantity, req.WarehouseID, clientInfo(c))
+	}
+	if err != nil {
+		if respondPurchaseError(c, err) {
+			return
+		}
 		h.logger.WithComponent("interaction").WithError(err).Error("Failed to purchase product")
 		if err.Error() == "product not found" {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: err.Error()})
@@ -549,6 +1047,26 @@ func (h *Handler) PurchaseProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "product purchased successfully"})
 }
 
+// respondPurchaseError responds to a purchase the rules of a product don't allow: a quantity
+// outside its order quantity rules with the allowed range, one over the buyer's purchase limit
+// with what is left of it, or a guest purchase of a limited product. It reports whether err was
+// one of these.
+func respondPurchaseError(c *gin.Context, err error) bool {
+	var quantityErr *domain.QuantityError
+	var limitErr *domain.PurchaseLimitError
+	switch {
+	case errors.As(err, &quantityErr):
+		c.JSON(http.StatusBadRequest, dto.NewQuantityErrorResponse(quantityErr))
+	case errors.As(err, &limitErr):
+		c.JSON(http.StatusConflict, dto.NewPurchaseLimitErrorResponse(limitErr))
+	case errors.Is(err, domain.ErrSignInRequired):
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
 // CheckProductPurchased godoc
 // @Summary Check if product is purchased
 // @Description Check if the current user has purchased a product
@@ -558,6 +1076,7 @@ func (h *Handler) PurchaseProduct(c *gin.Context) {
 // @Param id path int true "Product ID"
 // @Security BearerAuth
 // @Success 200 {object} map[string]bool
+// @Failure 401 {object} dto.ErrorResponse
 // @Router /products/{id}/purchased [get]
 func (h *Handler) CheckProductPurchased(c *gin.Context) {
 	// Get user ID from context
@@ -589,3 +1108,114 @@ func (h *Handler) CheckProductPurchased(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"purchased": purchased})
 }
+
+// GetProductTranslations godoc
+// @Summary Get product translations
+// @Description Get the product's name and description translations by locale (admin only)
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Success 200 {object} map[string]domain.ProductTranslation
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/translations [get]
+func (h *Handler) GetProductTranslations(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	translations, err := h.services.ProductService.GetTranslations(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to get product translations")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get product translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translations)
+}
+
+// SetProductTranslation godoc
+// @Summary Set a product translation
+// @Description Create or replace the product's name and description in a locale (admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Param locale path string true "Locale, e.g. ru"
+// @Param translation body dto.ProductTranslationRequest true "Translation"
+// @Success 200 {object} domain.ProductTranslation
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/translations/{locale} [put]
+func (h *Handler) SetProductTranslation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	var req dto.ProductTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	translation := domain.ProductTranslation{Name: req.Name, Description: req.Description}
+	if err := h.services.ProductService.SetTranslation(c.Request.Context(), id, c.Param("locale"), translation); err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to set product translation")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to set product translation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+// DeleteProductTranslation godoc
+// @Summary Delete a product translation
+// @Description Remove the product's translation into a locale; responses fall back to the default text (admin only)
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Product ID"
+// @Param locale path string true "Locale, e.g. ru"
+// @Success 204
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/translations/{locale} [delete]
+func (h *Handler) DeleteProductTranslation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	if err := h.services.ProductService.DeleteTranslation(c.Request.Context(), id, c.Param("locale")); err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "translation not found"})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to delete product translation")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to delete product translation"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,236 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// cachedHeaders are the response headers stored with a cached response
+var cachedHeaders = []string{"Content-Type", "Cache-Control", "ETag", "Last-Modified"}
+
+// responseCache keeps successful responses of a GET endpoint in memory by tenant, locale and
+// query string. A response younger than freshFor is served as is. One younger than staleFor
+// past that is served too, while the request is replayed through the router in the background
+// to refresh it; older ones are dropped.
+type responseCache struct {
+	freshFor   time.Duration
+	staleFor   time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+type cachedResponse struct {
+	header     http.Header
+	body       []byte
+	storedAt   time.Time
+	refreshing bool
+}
+
+func newResponseCache(freshFor, staleFor time.Duration, maxEntries int) *responseCache {
+	return &responseCache{
+		freshFor:   freshFor,
+		staleFor:   staleFor,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*cachedResponse),
+	}
+}
+
+// SetProductCache caches anonymous JSON responses of GET /products. Stale responses are refreshed
+// through the router set with SetRouter.
+func (h *Handler) SetProductCache(freshFor, staleFor time.Duration, maxEntries int) {
+	h.productCache = newResponseCache(freshFor, staleFor, maxEntries)
+}
+
+type revalidateKey struct{}
+
+// cacheProductList serves GET /products from the product cache when it is enabled. Requests of
+// signed-in users and for CSV or XML are personalized or streamed, so they aren't cached.
+func (h *Handler) cacheProductList(c *gin.Context) {
+	cache := h.productCache
+	if cache == nil || !cacheableProductList(c) {
+		c.Next()
+		return
+	}
+	key := productCacheKey(c)
+
+	// A background refresh stores what the handler returns
+	if c.Request.Context().Value(revalidateKey{}) != nil {
+		cache.capture(c, key)
+		return
+	}
+
+	entry, age, stale := cache.lookup(key)
+	if entry == nil {
+		cache.capture(c, key)
+		return
+	}
+	if stale {
+		h.revalidate(c, key)
+	}
+	cache.serve(c, entry, age)
+}
+
+// cacheableProductList reports whether the request is anonymous and asks for JSON
+func cacheableProductList(c *gin.Context) bool {
+	if _, err := middleware.GetUserID(c); err == nil {
+		return false
+	}
+	switch c.Query("format") {
+	case formatJSON:
+		return true
+	case "":
+		return c.NegotiateFormat(gin.MIMEJSON, mimeCSV, gin.MIMEXML, gin.MIMEXML2) == gin.MIMEJSON
+	default:
+		return false
+	}
+}
+
+// productCacheKey identifies the response of the request: the tenant, the locale and the query
+// string without empty parameters, sorted by name
+func productCacheKey(c *gin.Context) string {
+	query := c.Request.URL.Query()
+	for name, values := range query {
+		kept := values[:0]
+		for _, value := range values {
+			if value != "" {
+				kept = append(kept, value)
+			}
+		}
+		if len(kept) == 0 {
+			delete(query, name)
+		} else {
+			query[name] = kept
+		}
+	}
+	return tenant.ID(c.Request.Context()) + "|" + middleware.GetLocale(c) + "|" + query.Encode()
+}
+
+// lookup returns the entry of the key with its age, and whether it is stale, or nil if there is
+// none young enough to serve
+func (r *responseCache) lookup(key string) (*cachedResponse, time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.entries[key]
+	if entry == nil {
+		return nil, 0, false
+	}
+	age := time.Since(entry.storedAt)
+	if age >= r.freshFor+r.staleFor {
+		delete(r.entries, key)
+		return nil, 0, false
+	}
+	return entry, age, age >= r.freshFor
+}
+
+// serve writes a cached response, or 304 Not Modified when the request already has it
+func (r *responseCache) serve(c *gin.Context, entry *cachedResponse, age time.Duration) {
+	for name, values := range entry.header {
+		c.Header(name, values[0])
+	}
+	c.Header("Age", strconv.Itoa(int(age.Seconds())))
+
+	lastModified, _ := http.ParseTime(entry.header.Get("Last-Modified"))
+	if notModified(c.Request, entry.header.Get("ETag"), lastModified) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, entry.header.Get("Content-Type"), entry.body)
+	c.Abort()
+}
+
+// capture runs the handler and stores its response under the key if it is a 200
+func (r *responseCache) capture(c *gin.Context, key string) {
+	writer := &capturingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	if writer.Status() != http.StatusOK {
+		return
+	}
+	header := make(http.Header, len(cachedHeaders))
+	for _, name := range cachedHeaders {
+		if value := writer.Header().Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	r.store(key, &cachedResponse{header: header, body: writer.body.Bytes(), storedAt: time.Now()})
+}
+
+// store sets the entry of the key. When the cache is full, expired entries are dropped first and
+// then any other, so it never holds more than maxEntries.
+func (r *responseCache) store(key string, entry *cachedResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[key]; !ok && len(r.entries) >= r.maxEntries {
+		for other, cached := range r.entries {
+			if time.Since(cached.storedAt) >= r.freshFor+r.staleFor {
+				delete(r.entries, other)
+			}
+		}
+		for other := range r.entries {
+			if len(r.entries) < r.maxEntries {
+				break
+			}
+			delete(r.entries, other)
+		}
+	}
+	r.entries[key] = entry
+}
+
+// revalidate replays the request through the router in the background to refresh the entry of
+// the key, unless a refresh of it is already running
+func (h *Handler) revalidate(c *gin.Context, key string) {
+	cache := h.productCache
+	cache.mu.Lock()
+	entry := cache.entries[key]
+	if entry == nil || entry.refreshing || h.router == nil {
+		cache.mu.Unlock()
+		return
+	}
+	entry.refreshing = true
+	cache.mu.Unlock()
+
+	ctx := context.WithValue(context.WithoutCancel(c.Request.Context()), revalidateKey{}, true)
+	request := c.Request.Clone(ctx)
+	request.Header.Del("If-None-Match")
+	request.Header.Del("If-Modified-Since")
+
+	go func() {
+		h.router.ServeHTTP(newBatchRecorder(), request)
+
+		// A failed refresh leaves the entry to be served until it expires
+		cache.mu.Lock()
+		entry.refreshing = false
+		cache.mu.Unlock()
+	}()
+}
+
+// capturingWriter keeps a copy of the response body
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
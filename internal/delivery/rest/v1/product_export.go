@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// productColumns is the column order of CSV and XML product exports
+var productColumns = []string{
+	"id", "name", "description", "category_id", "category_name", "price",
+	"stock", "image_url", "is_active", "created_at", "updated_at",
+}
+
+// productColumnValue formats a product field for CSV and XML output
+func productColumnValue(product *domain.ProductWithCategory, column string) string {
+	switch column {
+	case "id":
+		return strconv.Itoa(product.ID)
+	case "name":
+		return product.Name
+	case "description":
+		return product.Description
+	case "category_id":
+		if product.CategoryID == nil {
+			return ""
+		}
+		return strconv.Itoa(*product.CategoryID)
+	case "category_name":
+		return product.CategoryName
+	case "price":
+		return product.Price.String()
+	case "stock":
+		return strconv.Itoa(product.Stock)
+	case "image_url":
+		return product.ImageURL
+	case "is_active":
+		return strconv.FormatBool(product.IsActive)
+	case "created_at":
+		return product.CreatedAt.UTC().Format(time.RFC3339)
+	case "updated_at":
+		return product.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+// streamProducts writes the products matching the filter as CSV or XML, row by row as they
+// are read from the database. Once streaming has started errors can only be logged.
+func (h *Handler) streamProducts(c *gin.Context, format string, filter domain.ProductFilter) {
+	columns := productColumns
+	if len(filter.Fields) > 0 {
+		columns = filter.Fields
+	}
+
+	writer, err := newExportWriter(c, format, "products", "products", "product", columns)
+	if err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to write products export")
+		return
+	}
+
+	write := func(product *domain.ProductWithCategory) error {
+		localizeProducts(c, product)
+		return writer.Write(func(column string) string {
+			return productColumnValue(product, column)
+		})
+	}
+
+	if err := h.services.ProductService.StreamProductsWithCategories(c.Request.Context(), filter, write); err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to stream products export")
+		return
+	}
+	if err := writer.Close(); err != nil {
+		h.logger.WithComponent("product").WithError(err).Error("Failed to write products export")
+	}
+}
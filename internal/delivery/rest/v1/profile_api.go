@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 )
 
@@ -19,13 +21,27 @@ func (h *Handler) InitProfileRoutes(rg *gin.RouterGroup, authMiddleware gin.Hand
 		profiles.GET("/me", h.GetProfile)
 		profiles.PUT("/me", h.UpdateProfile)
 		profiles.PUT("/me/password", h.ChangePassword)
-		profiles.DELETE("/me/account", h.DeleteAccount)
+		profiles.GET("/me/preferences", h.GetMyPreferences)
+		profiles.PUT("/me/preferences", h.UpdateMyPreferences)
+		profiles.PUT("/me/email", h.ChangeEmail)
+		profiles.POST("/me/phone/verification", h.SendPhoneVerification)
+		profiles.POST("/me/phone/verify", h.VerifyPhone)
+		profiles.DELETE("/me/account", middleware.RequireRecentAuth(h.services.AuthService), h.DeleteAccount)
+		profiles.GET("/me/sessions", h.GetMySessions)
+		profiles.DELETE("/me/sessions", h.RevokeAllMySessions)
+		profiles.DELETE("/me/sessions/:id", h.RevokeMySession)
 		profiles.GET("/me/interactions", h.GetMyInteractions)
 		profiles.GET("/me/views", h.GetMyViewHistory)
 		profiles.GET("/me/likes", h.GetMyLikedProducts)
 		profiles.GET("/me/purchases", h.GetMyPurchases)
+		profiles.GET("/me/activity", h.GetMyActivity)
 		profiles.GET("/me/recommendations", h.GetRecommendations)
+		profiles.GET("/me/feed", h.GetMyFeed)
+		profiles.POST("/me/recommendations/:product_id/feedback", h.SubmitRecommendationFeedback)
+		profiles.POST("/me/recommendations/:product_id/click", h.RecordRecommendationClick)
 		profiles.GET("/me/similar", h.GetSimilarUsers)
+		profiles.GET("/me/usage", h.GetMyUsage)
+		profiles.GET("/me/coupons", h.GetMyCoupons)
 	}
 }
 
@@ -35,6 +51,7 @@ func (h *Handler) InitProfileRoutes(rg *gin.RouterGroup, authMiddleware gin.Hand
 // @Tags profiles
 // @Produce json
 // @Security BearerAuth
+// @Param fields query string false "Comma-separated fields to return, e.g. first_name,email"
 // @Success 200 {object} dto.ProfileResponse
 // @Router /profiles/me [get]
 func (h *Handler) GetProfile(c *gin.Context) {
@@ -51,8 +68,13 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		return
 	}
 
+	fields, ok := parseFields(c, domain.ProfileFields)
+	if !ok {
+		return
+	}
+
 	// Get user and profile
-	user, profile, err := h.services.UserService.GetProfile(c.Request.Context(), userID)
+	user, profile, err := h.services.UserService.GetProfile(c.Request.Context(), userID, fields)
 	if err != nil {
 		h.logger.WithComponent("profile").WithError(err).Error("Failed to get profile")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get profile"})
@@ -63,8 +85,8 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	response := dto.ProfileResponse{
 		Email:     user.Email,
 		Status:    user.Status,
-		CreatedAt: user.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+		CreatedAt: user.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: user.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 
 	if profile != nil {
@@ -84,6 +106,7 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		if profile.Phone != nil {
 			response.Phone = *profile.Phone
 		}
+		response.PhoneVerified = profile.PhoneVerified
 		if profile.Address != nil {
 			response.Address = *profile.Address
 		}
@@ -98,12 +121,20 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	body, err := selectFields(response, fields)
+	if err != nil {
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to select profile fields")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
 }
 
 // UpdateProfile godoc
 // @Summary Update user profile
-// @Description Update current user's detailed profile information
+// @Description Update current user's detailed profile information. Names, address and city that moderation flags
+// @Description are held for review instead of saved and listed in quarantined_fields; the rest of the update is saved.
 // @Tags profiles
 // @Accept json
 // @Produce json
@@ -166,7 +197,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	profileData.PostalCode = req.PostalCode
 
 	// Update profile
-	profile, err := h.services.UserService.UpdateProfile(c.Request.Context(), userID, profileData)
+	profile, quarantined, err := h.services.UserService.UpdateProfile(c.Request.Context(), userID, profileData)
 	if err != nil {
 		h.logger.WithComponent("profile").WithError(err).Error("Failed to update profile")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to update profile"})
@@ -174,7 +205,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	}
 
 	// Get user for response
-	user, _, err := h.services.UserService.GetProfile(c.Request.Context(), userID)
+	user, _, err := h.services.UserService.GetProfile(c.Request.Context(), userID, nil)
 	if err != nil {
 		h.logger.WithComponent("profile").WithError(err).Error("Failed to get user")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get user"})
@@ -189,8 +220,8 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		LastName:  profile.LastName,
 		Email:     user.Email,
 		Status:    user.Status,
-		CreatedAt: profile.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: profile.UpdatedAt.Format(time.RFC3339),
+		CreatedAt: profile.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: profile.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 
 	if profile.MiddleName != nil {
@@ -205,6 +236,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	if profile.Phone != nil {
 		response.Phone = *profile.Phone
 	}
+	response.PhoneVerified = profile.PhoneVerified
 	if profile.Address != nil {
 		response.Address = *profile.Address
 	}
@@ -217,6 +249,11 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	if profile.PostalCode != nil {
 		response.PostalCode = *profile.PostalCode
 	}
+	if quarantined != nil {
+		for _, flag := range quarantined.Flags {
+			response.QuarantinedFields = append(response.QuarantinedFields, flag.Field)
+		}
+	}
 
 	c.JSON(http.StatusOK, response)
 }
@@ -260,6 +297,10 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 	// Change password
 	if err := h.services.UserService.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, domain.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
 		h.logger.WithComponent("profile").WithError(err).Error("Failed to change password")
 		if err.Error() == "invalid current password" {
 			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: err.Error()})
@@ -272,13 +313,158 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "password changed successfully"})
 }
 
+// ChangeEmail godoc
+// @Summary Change email
+// @Description Send a confirmation link to the new email address. The account email is switched only after confirmation via /auth/email/confirm.
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ChangeEmailRequest true "New email and current password"
+// @Success 202 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse "Invalid password"
+// @Failure 409 {object} dto.ErrorResponse "Email already in use"
+// @Router /profiles/me/email [put]
+func (h *Handler) ChangeEmail(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	// Parse request
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	// Validate
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := h.services.UserService.RequestEmailChange(c.Request.Context(), userID, req.Password, req.NewEmail); err != nil {
+		switch {
+		case err == domain.ErrInvalidCredentials:
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "invalid password"})
+		case err == domain.ErrAlreadyExists:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "email already in use"})
+		case errors.Is(err, domain.ErrValidation):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.WithComponent("profile").WithError(err).Error("Failed to request email change")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to change email"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.SuccessResponse{Message: "confirmation sent to the new email address"})
+}
+
+// SendPhoneVerification godoc
+// @Summary Send phone verification code
+// @Description Send an SMS code to the phone number on the current user's profile
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse "Phone number not set or already verified"
+// @Failure 429 {object} dto.ErrorResponse "Code was sent recently"
+// @Router /profiles/me/phone/verification [post]
+func (h *Handler) SendPhoneVerification(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	if err := h.services.PhoneVerification.SendCode(c.Request.Context(), userID); err != nil {
+		switch {
+		case err == domain.ErrRateLimited:
+			c.JSON(http.StatusTooManyRequests, dto.ErrorResponse{Error: "verification code was sent recently, try again later"})
+		case errors.Is(err, domain.ErrValidation):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.WithComponent("profile").WithError(err).Error("Failed to send phone verification")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to send verification code"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.SuccessResponse{Message: "verification code sent"})
+}
+
+// VerifyPhone godoc
+// @Summary Verify phone number
+// @Description Confirm the phone number on the current user's profile with the SMS code
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.VerifyPhoneRequest true "Verification code"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse "Invalid or expired code"
+// @Router /profiles/me/phone/verify [post]
+func (h *Handler) VerifyPhone(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req dto.VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	if err := h.services.PhoneVerification.VerifyCode(c.Request.Context(), userID, req.Code); err != nil {
+		if err == domain.ErrInvalidToken {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid or expired code"})
+			return
+		}
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to verify phone")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to verify phone"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "phone number verified"})
+}
+
 // DeleteAccount godoc
 // @Summary Delete account
-// @Description Soft delete current user's account
+// @Description Soft delete current user's account and revoke all of its sessions. Admins can reactivate it later.
+// @Description Requires a token from a recent sign-in or from POST /auth/sudo.
 // @Tags profiles
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} dto.SuccessResponse
+// @Failure 403 {object} dto.ErrorResponse "Re-authentication required"
+// @Failure 409 {object} dto.ErrorResponse
 // @Router /profiles/me/account [delete]
 func (h *Handler) DeleteAccount(c *gin.Context) {
 	// Get user ID from context
@@ -296,6 +482,10 @@ func (h *Handler) DeleteAccount(c *gin.Context) {
 
 	// Delete account
 	if err := h.services.UserService.DeleteAccount(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "account is not active"})
+			return
+		}
 		h.logger.WithComponent("profile").WithError(err).Error("Failed to delete account")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to delete account"})
 		return
@@ -304,6 +494,127 @@ func (h *Handler) DeleteAccount(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "account deleted successfully"})
 }
 
+// GetMySessions godoc
+// @Summary Get active sessions
+// @Description Get devices the current user is logged in from. The session of the current token is marked as current.
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.SessionResponse
+// @Router /profiles/me/sessions [get]
+func (h *Handler) GetMySessions(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	sessions, err := h.services.AuthService.GetSessions(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to get sessions")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get sessions"})
+		return
+	}
+
+	currentSessionID := middleware.GetSessionID(c)
+
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, dto.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt.UTC().Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.UTC().Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.UTC().Format(time.RFC3339),
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeMySession godoc
+// @Summary Revoke a session
+// @Description Log out a single device. Its refresh token stops working; issued access tokens remain valid until they expire.
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /profiles/me/sessions/{id} [delete]
+func (h *Handler) RevokeMySession(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid session id"})
+		return
+	}
+
+	if err := h.services.AuthService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "session not found"})
+			return
+		}
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to revoke session")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "session revoked successfully"})
+}
+
+// RevokeAllMySessions godoc
+// @Summary Log out everywhere
+// @Description Revoke all sessions of the current user, including the current one
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Router /profiles/me/sessions [delete]
+func (h *Handler) RevokeAllMySessions(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	if _, err := h.services.AuthService.RevokeAllSessions(c.Request.Context(), userID); err != nil {
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to revoke sessions")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "logged out from all devices"})
+}
+
 // GetMyInteractions godoc
 // @Summary Get my interactions
 // @Description Get summary of current user's product interactions
@@ -343,9 +654,12 @@ func (h *Handler) GetMyInteractions(c *gin.Context) {
 // @Tags profiles
 // @Accept json
 // @Produce json
-// @Param limit query int false "Limit" default(50)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} dto.ViewHistoryResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Router /profiles/me/views [get]
 func (h *Handler) GetMyViewHistory(c *gin.Context) {
 	// Get user ID from context
@@ -361,18 +675,21 @@ func (h *Handler) GetMyViewHistory(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	page, limit, ok := parsePage(c, 50, 100)
+	if !ok {
+		return
+	}
 
-	views, err := h.services.InteractionService.GetUserViewHistory(c.Request.Context(), userID, limit)
+	views, total, err := h.services.InteractionService.GetUserViewHistory(c.Request.Context(), userID, limit, (page-1)*limit)
 	if err != nil {
 		h.logger.WithComponent("interaction").WithError(err).Error("Failed to get view history")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get view history"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"views": views,
-		"count": len(views),
+	c.JSON(http.StatusOK, dto.ViewHistoryResponse{
+		Views:      views,
+		Pagination: newPagination(page, limit, total),
 	})
 }
 
@@ -382,9 +699,12 @@ func (h *Handler) GetMyViewHistory(c *gin.Context) {
 // @Tags profiles
 // @Accept json
 // @Produce json
-// @Param limit query int false "Limit" default(50)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} dto.LikedProductsResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Router /profiles/me/likes [get]
 func (h *Handler) GetMyLikedProducts(c *gin.Context) {
 	// Get user ID from context
@@ -400,18 +720,21 @@ func (h *Handler) GetMyLikedProducts(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	page, limit, ok := parsePage(c, 50, 100)
+	if !ok {
+		return
+	}
 
-	likes, err := h.services.InteractionService.GetUserLikedProducts(c.Request.Context(), userID, limit)
+	likes, total, err := h.services.InteractionService.GetUserLikedProducts(c.Request.Context(), userID, limit, (page-1)*limit)
 	if err != nil {
 		h.logger.WithComponent("interaction").WithError(err).Error("Failed to get liked products")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get liked products"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"likes": likes,
-		"count": len(likes),
+	c.JSON(http.StatusOK, dto.LikedProductsResponse{
+		Likes:      likes,
+		Pagination: newPagination(page, limit, total),
 	})
 }
 
@@ -453,6 +776,149 @@ func (h *Handler) GetRecommendations(c *gin.Context) {
 	c.JSON(http.StatusOK, recommendations)
 }
 
+// GetMyFeed godoc
+// @Summary Get personalized home feed
+// @Description Get the home screen sections (recommended for you, trending, new in favorite categories, price drops on liked products) in one response. Sections without products are left out.
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.Feed
+// @Router /profiles/me/feed [get]
+func (h *Handler) GetMyFeed(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	feed, err := h.services.FeedService.GetFeed(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithComponent("feed").WithError(err).Error("Failed to get feed")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// SubmitRecommendationFeedback godoc
+// @Summary Submit recommendation feedback
+// @Description Mark a recommended product as dismissed, not interesting or already owned so it is excluded from future recommendations
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Param product_id path int true "Product ID"
+// @Param feedback body dto.RecommendationFeedbackRequest true "Feedback type"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /profiles/me/recommendations/{product_id}/feedback [post]
+func (h *Handler) SubmitRecommendationFeedback(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	var req dto.RecommendationFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "type must be one of: dismissed, not_interested, already_own"})
+		return
+	}
+
+	if err := h.services.RecommendationService.SubmitFeedback(c.Request.Context(), userID, productID, req.Type); err != nil {
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		if err == domain.ErrValidation {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid feedback type"})
+			return
+		}
+		h.logger.WithComponent("recommendation").WithError(err).Error("Failed to submit recommendation feedback")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to submit feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "feedback recorded"})
+}
+
+// RecordRecommendationClick godoc
+// @Summary Track recommendation click
+// @Description Record that the current user clicked a recommended product, used to measure recommendation quality
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Param product_id path int true "Product ID"
+// @Param click body dto.RecommendationClickRequest false "Click context"
+// @Security BearerAuth
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /profiles/me/recommendations/{product_id}/click [post]
+func (h *Handler) RecordRecommendationClick(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	// Body is optional
+	var req dto.RecommendationClickRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+			return
+		}
+	}
+
+	if err := h.services.RecommendationService.RecordClick(c.Request.Context(), userID, productID, req.Algorithm, req.Position); err != nil {
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("recommendation").WithError(err).Error("Failed to record recommendation click")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to record click"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "click recorded"})
+}
+
 // GetSimilarUsers godoc
 // @Summary Get similar users
 // @Description Get users with similar interaction patterns
@@ -501,9 +967,12 @@ func (h *Handler) GetSimilarUsers(c *gin.Context) {
 // @Tags profiles
 // @Accept json
 // @Produce json
-// @Param limit query int false "Limit" default(50)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{}
+// @Success 200 {object} dto.PurchaseHistoryResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Router /profiles/me/purchases [get]
 func (h *Handler) GetMyPurchases(c *gin.Context) {
 	// Get user ID from context
@@ -519,17 +988,177 @@ func (h *Handler) GetMyPurchases(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	page, limit, ok := parsePage(c, 50, 100)
+	if !ok {
+		return
+	}
 
-	purchases, err := h.services.InteractionService.GetUserPurchaseHistory(c.Request.Context(), userID, limit)
+	purchases, total, err := h.services.InteractionService.GetUserPurchaseHistory(c.Request.Context(), userID, limit, (page-1)*limit)
 	if err != nil {
 		h.logger.WithComponent("interaction").WithError(err).Error("Failed to get purchase history")
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get purchase history"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"purchases": purchases,
-		"count":     len(purchases),
+	c.JSON(http.StatusOK, dto.PurchaseHistoryResponse{
+		Purchases:  purchases,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// GetMyActivity godoc
+// @Summary Get my activity timeline
+// @Description Get the current user's views, likes, purchases and profile changes as one feed, newest first
+// @Tags profiles
+// @Produce json
+// @Param types query string false "Comma-separated activity types (view, like, purchase, profile_change)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Security BearerAuth
+// @Success 200 {object} dto.ActivityResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /profiles/me/activity [get]
+func (h *Handler) GetMyActivity(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	types, err := domain.ParseActivityTypes(c.Query("types"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	items, total, err := h.services.ActivityService.GetActivity(c.Request.Context(), domain.ActivityFilter{
+		UserID: userID,
+		Types:  types,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		h.logger.WithComponent("activity").WithError(err).Error("Failed to get activity")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get activity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ActivityResponse{
+		Items:      items,
+		Pagination: newPagination(page, limit, total),
 	})
 }
+
+// GetMyPreferences godoc
+// @Summary Get my preferences
+// @Description Get the current user's locale, currency, favorite categories and marketing opt-ins
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.Preferences
+// @Router /profiles/me/preferences [get]
+func (h *Handler) GetMyPreferences(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	preferences, err := h.services.PreferenceService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to get preferences")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdateMyPreferences godoc
+// @Summary Update my preferences
+// @Description Update the current user's preferences. Omitted fields are left unchanged; favorite_category_ids replaces the whole list.
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Param preferences body dto.UpdatePreferencesRequest true "Preferences update"
+// @Security BearerAuth
+// @Success 200 {object} domain.Preferences
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /profiles/me/preferences [put]
+func (h *Handler) UpdateMyPreferences(c *gin.Context) {
+	// Get user ID from context
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req dto.UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	preferences, err := h.services.PreferenceService.UpdatePreferences(c.Request.Context(), userID, req.ToDomain())
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to update preferences")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to update preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// GetMyUsage godoc
+// @Summary Get API usage
+// @Description Get how many API requests the current user made this calendar month (UTC) against the monthly quota of their roles. Limit and remaining are null for users without a quota. Requests made through other instances may take a few seconds to show.
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.APIUsage
+// @Router /profiles/me/usage [get]
+func (h *Handler) GetMyUsage(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.services.UsageService.Usage(c.Request.Context(), userID, middleware.GetUserRoles(c))
+	if err != nil {
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to get API usage")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get API usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListPromotions godoc
+// @Summary List promotions
+// @Description Get all birthday and anniversary promotion templates. Requires the promotions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.PromotionTemplate
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/promotions [get]
+func (h *Handler) ListPromotions(c *gin.Context) {
+	templates, err := h.services.PromotionService.ListPromotions(c.Request.Context())
+	if err != nil {
+		h.respondPromotionError(c, err, "failed to list promotions")
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreatePromotion godoc
+// @Summary Create promotion
+// @Description Create a promotion template. While it is active, every user gets a coupon of percent_off on their
+// @Description birthday or sign-up anniversary, in their time zone, redeemable for valid_days, and a marketing message
+// @Description on the channel. Subject and body may use {first_name}, {code}, {percent_off} and {expires_on}.
+// @Description Users who opted out of promotions are skipped. Requires the promotions:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PromotionRequest true "Promotion template"
+// @Success 201 {object} domain.PromotionTemplate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/promotions [post]
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	var req dto.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	template := promotionTemplate(&req)
+	if err := h.services.PromotionService.CreatePromotion(c.Request.Context(), template); err != nil {
+		h.respondPromotionError(c, err, "failed to create promotion")
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetPromotion godoc
+// @Summary Get promotion
+// @Description Get a promotion template. Requires the promotions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} domain.PromotionTemplate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/promotions/{id} [get]
+func (h *Handler) GetPromotion(c *gin.Context) {
+	id, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.services.PromotionService.GetPromotion(c.Request.Context(), id)
+	if err != nil {
+		h.respondPromotionError(c, err, "failed to get promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdatePromotion godoc
+// @Summary Update promotion
+// @Description Replace a promotion template. Coupons already granted keep their discount and expiry.
+// @Description Requires the promotions:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Promotion ID"
+// @Param request body dto.PromotionRequest true "Promotion template"
+// @Success 200 {object} domain.PromotionTemplate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/promotions/{id} [put]
+func (h *Handler) UpdatePromotion(c *gin.Context) {
+	id, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	template := promotionTemplate(&req)
+	template.ID = id
+	if err := h.services.PromotionService.UpdatePromotion(c.Request.Context(), template); err != nil {
+		h.respondPromotionError(c, err, "failed to update promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeletePromotion godoc
+// @Summary Delete promotion
+// @Description Delete a promotion template. The coupons it granted can still be redeemed.
+// @Description Requires the promotions:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Promotion ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/promotions/{id} [delete]
+func (h *Handler) DeletePromotion(c *gin.Context) {
+	id, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.PromotionService.DeletePromotion(c.Request.Context(), id); err != nil {
+		h.respondPromotionError(c, err, "failed to delete promotion")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyCoupons godoc
+// @Summary Get my coupons
+// @Description Get the coupons granted to the current user, newest first. A coupon is redeemed by passing its code to checkout.
+// @Tags profiles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Coupon
+// @Router /profiles/me/coupons [get]
+func (h *Handler) GetMyCoupons(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	coupons, err := h.services.PromotionService.ListUserCoupons(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithComponent("profile").WithError(err).Error("Failed to list coupons")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list coupons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupons)
+}
+
+func promotionTemplate(req *dto.PromotionRequest) *domain.PromotionTemplate {
+	return &domain.PromotionTemplate{
+		Name:       req.Name,
+		Occasion:   req.Occasion,
+		PercentOff: req.PercentOff,
+		ValidDays:  req.ValidDays,
+		Channel:    req.Channel,
+		Subject:    req.Subject,
+		Body:       req.Body,
+		IsActive:   req.IsActive,
+	}
+}
+
+// promotionID parses the promotion ID path parameter, writing a 400 response if it is invalid
+func promotionID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid promotion id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondPromotionError maps promotion errors to a response, with message for unexpected ones
+func (h *Handler) respondPromotionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "promotion not found"})
+	default:
+		h.logger.WithComponent("promotions").WithError(err).Error("Failed to manage promotion")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,291 @@
+package v1
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// GetSalesReport godoc
+// @Summary Get sales report
+// @Description Sum purchases, units and gross and net revenue per day, week, month, category or product. Periods start at midnight in the caller's timezone preference (or the reports time zone if they have none), weeks on Monday. Net revenue equals gross revenue until discounts and refunds are recorded. Cost of goods and gross margin use the cost price products had when purchased; purchases without one are left out of the margin and counted in uncosted_purchases. With Accept: text/csv (or ?format=csv) the groups are returned as CSV.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Param group_by query string false "day, week, month, category or product" default(day)
+// @Param from query string false "Start of the time range, inclusive (RFC3339); defaults to 30 days before to"
+// @Param to query string false "End of the time range, exclusive (RFC3339); defaults to now"
+// @Param format query string false "json or csv"
+// @Security BearerAuth
+// @Success 200 {object} domain.SalesReport
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/reports/sales [get]
+func (h *Handler) GetSalesReport(c *gin.Context) {
+	format, ok := responseFormat(c)
+	if !ok {
+		return
+	}
+	if format == formatXML {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid format"})
+		return
+	}
+
+	to := time.Now().UTC()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		from = parsed
+	}
+
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.services.ReportService.Sales(c.Request.Context(), userID, c.DefaultQuery("group_by", domain.SalesGroupDay), from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("reports").WithError(err).Error("Failed to get sales report")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get sales report"})
+		return
+	}
+
+	if format == formatCSV {
+		h.writeSalesReportCSV(c, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRetentionReport godoc
+// @Summary Get cohort retention report
+// @Description Group the users who signed up in the time range into weekly or monthly cohorts and count how many of each viewed, liked or bought something in each period since signing up. Rows are cohorts, columns periods since signup (0 is the signup period), suited for a heatmap. Periods are in the caller's timezone preference, or the reports time zone if they have none.
+// @Tags admin
+// @Produce json
+// @Param period query string false "week or month" default(week)
+// @Param from query string false "Start of the signups, moved back to the start of its period (RFC3339); defaults to 12 periods before to"
+// @Param to query string false "End of the signups and activity, exclusive (RFC3339); defaults to now"
+// @Security BearerAuth
+// @Success 200 {object} domain.RetentionReport
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/reports/retention [get]
+func (h *Handler) GetRetentionReport(c *gin.Context) {
+	period := c.DefaultQuery("period", domain.RetentionPeriodWeek)
+
+	to := time.Now().UTC()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -7*12)
+	if period == domain.RetentionPeriodMonth {
+		from = to.AddDate(0, -12, 0)
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		from = parsed
+	}
+
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.services.ReportService.Retention(c.Request.Context(), userID, period, from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("reports").WithError(err).Error("Failed to get retention report")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get retention report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetInventoryReport godoc
+// @Summary Get inventory valuation report
+// @Description Sum the products, units and stock value at the current price and at cost price per category and in total, and list the dead stock: products in stock that haven't sold in dead_stock_days days, highest value first. Products without a cost price add nothing to the cost value and are counted as uncosted.
+// @Tags admin
+// @Produce json
+// @Param dead_stock_days query int false "Days without sales after which stock is dead; defaults to reports.dead_stock_days"
+// @Security BearerAuth
+// @Success 200 {object} domain.InventoryReport
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/reports/inventory [get]
+func (h *Handler) GetInventoryReport(c *gin.Context) {
+	deadStockDays := 0
+	if daysStr := c.Query("dead_stock_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid dead_stock_days"})
+			return
+		}
+		deadStockDays = days
+	}
+
+	report, err := h.services.ReportService.Inventory(c.Request.Context(), deadStockDays)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("reports").WithError(err).Error("Failed to get inventory report")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get inventory report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSearchReport godoc
+// @Summary Get search report
+// @Description Count the catalog searches in the time range and list the most searched terms and the most searched terms without results, with how many results they found on average and their click-through rates. Terms are queries lowercased with single spaces. A search has zero results when it found nothing as typed; corrected counts those a spelling correction found something for. A click is a product view sent with the search_id of the search.
+// @Tags admin
+// @Produce json
+// @Param from query string false "Start of the range (RFC3339); defaults to 30 days before to"
+// @Param to query string false "End of the range, exclusive (RFC3339); defaults to now"
+// @Param limit query int false "Terms per list, at most 100" default(20)
+// @Security BearerAuth
+// @Success 200 {object} domain.SearchReport
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/reports/search [get]
+func (h *Handler) GetSearchReport(c *gin.Context) {
+	to := time.Now().UTC()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := parseTimestamp(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid to"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := parseTimestamp(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid from"})
+			return
+		}
+		from = parsed
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	report, err := h.services.ReportService.Search(c.Request.Context(), from, to, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("reports").WithError(err).Error("Failed to get search report")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get search report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// writeSalesReportCSV writes one row per group, keyed by period or by id and name
+func (h *Handler) writeSalesReportCSV(c *gin.Context, report *domain.SalesReport) {
+	columns := []string{"id", "name"}
+	if domain.IsTimeGrouping(report.GroupBy) {
+		columns = []string{"period"}
+	}
+	columns = append(columns, "purchases", "units", "gross_revenue", "net_revenue",
+		"cost_of_goods", "gross_margin", "margin_rate", "uncosted_purchases")
+
+	c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="sales-`+report.GroupBy+`.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(columns); err != nil {
+		h.logger.WithComponent("reports").WithError(err).Error("Failed to write sales report")
+		return
+	}
+
+	for _, group := range report.Groups {
+		var record []string
+		if domain.IsTimeGrouping(report.GroupBy) {
+			record = []string{group.Period.Format("2006-01-02")}
+		} else {
+			id := ""
+			if group.ID != nil {
+				id = strconv.Itoa(*group.ID)
+			}
+			record = []string{id, csvSafe(group.Name)}
+		}
+		record = append(record,
+			strconv.Itoa(group.Purchases),
+			strconv.Itoa(group.Units),
+			group.GrossRevenue.String(),
+			group.NetRevenue.String(),
+			group.CostOfGoods.String(),
+			group.GrossMargin.String(),
+			strconv.FormatFloat(group.MarginRate, 'f', 4, 64),
+			strconv.Itoa(group.UncostedPurchases),
+		)
+		if err := writer.Write(record); err != nil {
+			h.logger.WithComponent("reports").WithError(err).Error("Failed to write sales report")
+			return
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.WithComponent("reports").WithError(err).Error("Failed to write sales report")
+	}
+}
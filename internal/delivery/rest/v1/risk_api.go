@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListRiskReviews godoc
+// @Summary List flagged orders
+// @Description Get a page of checkouts flagged for manual review, oldest first, with the signals that flagged them.
+// @Description Flagged orders are not held; reviewers decide whether to fulfil them. Requires the orders:review permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Review status: pending, approved or rejected; empty for all" default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.RiskReviewListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/risk-reviews [get]
+func (h *Handler) ListRiskReviews(c *gin.Context) {
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", domain.RiskReviewPending)
+
+	reviews, total, err := h.services.RiskService.ListReviews(c.Request.Context(), status, limit, (page-1)*limit)
+	if err != nil {
+		h.respondRiskError(c, err, "failed to list risk reviews")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RiskReviewListResponse{
+		Reviews:    reviews,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// ApproveRiskReview godoc
+// @Summary Approve flagged order
+// @Description Clear a flagged order for fulfilment. Requires the orders:review permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body dto.ResolveRiskReviewRequest false "Reviewer note"
+// @Success 200 {object} domain.RiskReview
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/risk-reviews/{id}/approve [post]
+func (h *Handler) ApproveRiskReview(c *gin.Context) {
+	h.resolveRiskReview(c, domain.RiskReviewApproved)
+}
+
+// RejectRiskReview godoc
+// @Summary Reject flagged order
+// @Description Mark a flagged order as fraudulent. The purchase is not reversed; cancel and refund it separately.
+// @Description Requires the orders:review permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Review ID"
+// @Param request body dto.ResolveRiskReviewRequest false "Reviewer note"
+// @Success 200 {object} domain.RiskReview
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/risk-reviews/{id}/reject [post]
+func (h *Handler) RejectRiskReview(c *gin.Context) {
+	h.resolveRiskReview(c, domain.RiskReviewRejected)
+}
+
+// resolveRiskReview records the current user's decision on a pending review
+func (h *Handler) resolveRiskReview(c *gin.Context, status string) {
+	reviewerID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid review id"})
+		return
+	}
+
+	// The note is optional, so an empty body is allowed
+	var req dto.ResolveRiskReviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+			return
+		}
+	}
+
+	review, err := h.services.RiskService.ResolveReview(c.Request.Context(), id, status, reviewerID, req.Note)
+	if err != nil {
+		h.respondRiskError(c, err, "failed to resolve risk review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// respondRiskError maps risk review errors to a response, with message for unexpected ones
+func (h *Handler) respondRiskError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "risk review not found"})
+	case errors.Is(err, domain.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "risk review already resolved"})
+	default:
+		h.logger.WithComponent("risk").WithError(err).Error("Failed to manage risk review")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// InitSearchRoutes sets up the search box endpoint, public with optional authentication
+func (h *Handler) InitSearchRoutes(api *gin.RouterGroup) {
+	api.GET("/search", middleware.OptionalAuth(h.services.AuthService), h.GlobalSearch)
+}
+
+// GlobalSearch godoc
+// @Summary Search everything
+// @Description Search products, categories and, with the users:read permission, users and, with the orders:review
+// @Description permission, orders at once for a single search box.
+// @Description Results are grouped by type in the order of types, each with its total number of matches and its best hits.
+// @Description Scores rank hits within their group: products are ranked by the catalog search backend, categories and users
+// @Description by how well their name or email matches (1 exact, 0.8 prefix, 0.6 word prefix, 0.4 substring).
+// @Description Orders are found by their event ID, or by the ID or exact email of their user, newest first; their hits
+// @Description carry order_id instead of id.
+// @Tags search
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search text"
+// @Param types query string false "Comma-separated types: products, categories, users and orders (admins); all allowed types if empty"
+// @Param limit query int false "Hits per type, at most 20" default(5)
+// @Success 200 {object} dto.GlobalSearchResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /search [get]
+func (h *Handler) GlobalSearch(c *gin.Context) {
+	query := domain.GlobalSearchQuery{
+		Query:  c.Query("q"),
+		Admin:  middleware.HasPermission(c, domain.PermissionUsersRead),
+		Orders: middleware.HasPermission(c, domain.PermissionOrdersReview),
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid limit"})
+			return
+		}
+		query.Limit = limit
+	}
+	if typesStr := c.Query("types"); typesStr != "" {
+		for _, kind := range strings.Split(typesStr, ",") {
+			kind = strings.TrimSpace(kind)
+			if (kind == domain.GlobalSearchUsers && !query.Admin) || (kind == domain.GlobalSearchOrders && !query.Orders) {
+				c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "insufficient permissions"})
+				return
+			}
+			query.Types = append(query.Types, kind)
+		}
+	}
+
+	groups, err := h.services.GlobalSearchService.Search(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+			return
+		}
+		h.logger.WithComponent("search").WithError(err).Error("Failed to search")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to search"})
+		return
+	}
+
+	for _, group := range groups {
+		for i, hit := range group.Hits {
+			if product, ok := hit.Item.(*domain.ProductWithCategory); ok {
+				localizeProducts(c, product)
+				group.Hits[i].Title = product.Name
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.GlobalSearchResponse{Query: query.Query, Groups: groups})
+}
@@ -0,0 +1,306 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListSegments godoc
+// @Summary List segments
+// @Description Get all user segments with their member count at the last evaluation. Requires the segments:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Segment
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/segments [get]
+func (h *Handler) ListSegments(c *gin.Context) {
+	segments, err := h.services.SegmentService.ListSegments(c.Request.Context())
+	if err != nil {
+		h.respondSegmentError(c, err, "failed to list segments")
+		return
+	}
+
+	c.JSON(http.StatusOK, segments)
+}
+
+// CreateSegment godoc
+// @Summary Create segment
+// @Description Create a segment of the users matching all of its rules. Profile attributes (country, city, gender)
+// @Description take eq, ne with value or in with values; interaction attributes (views, likes, purchases, spent)
+// @Description take eq, gte or lte with threshold, counted over the last within_days days or all time.
+// @Description Members are evaluated in the background. Requires the segments:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SegmentRequest true "Segment definition"
+// @Success 201 {object} domain.Segment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/segments [post]
+func (h *Handler) CreateSegment(c *gin.Context) {
+	var req dto.SegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	segment := &domain.Segment{
+		Name:        req.Name,
+		Description: req.Description,
+		Rules:       req.Rules,
+	}
+
+	if err := h.services.SegmentService.CreateSegment(c.Request.Context(), segment); err != nil {
+		h.respondSegmentError(c, err, "failed to create segment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, segment)
+}
+
+// GetSegment godoc
+// @Summary Get segment
+// @Description Get a segment with its member count at the last evaluation. Requires the segments:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Segment ID"
+// @Success 200 {object} domain.Segment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/segments/{id} [get]
+func (h *Handler) GetSegment(c *gin.Context) {
+	id, ok := segmentID(c)
+	if !ok {
+		return
+	}
+
+	segment, err := h.services.SegmentService.GetSegment(c.Request.Context(), id)
+	if err != nil {
+		h.respondSegmentError(c, err, "failed to get segment")
+		return
+	}
+
+	c.JSON(http.StatusOK, segment)
+}
+
+// UpdateSegment godoc
+// @Summary Update segment
+// @Description Replace the name, description and rules of a segment. Members are evaluated again in the background;
+// @Description the previous members stay until then. Requires the segments:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Segment ID"
+// @Param request body dto.SegmentRequest true "Segment definition"
+// @Success 200 {object} domain.Segment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/segments/{id} [put]
+func (h *Handler) UpdateSegment(c *gin.Context) {
+	id, ok := segmentID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	segment := &domain.Segment{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		Rules:       req.Rules,
+	}
+
+	if err := h.services.SegmentService.UpdateSegment(c.Request.Context(), segment); err != nil {
+		h.respondSegmentError(c, err, "failed to update segment")
+		return
+	}
+
+	c.JSON(http.StatusOK, segment)
+}
+
+// DeleteSegment godoc
+// @Summary Delete segment
+// @Description Delete a segment and its members. Bundles targeted only at it are no longer offered to anyone.
+// @Description Requires the segments:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Segment ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/segments/{id} [delete]
+func (h *Handler) DeleteSegment(c *gin.Context) {
+	id, ok := segmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SegmentService.DeleteSegment(c.Request.Context(), id); err != nil {
+		h.respondSegmentError(c, err, "failed to delete segment")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// EvaluateSegment godoc
+// @Summary Evaluate segment
+// @Description Recompute the members of a segment now instead of waiting for the background evaluation.
+// @Description Requires the segments:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Segment ID"
+// @Success 200 {object} domain.Segment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/segments/{id}/evaluate [post]
+func (h *Handler) EvaluateSegment(c *gin.Context) {
+	id, ok := segmentID(c)
+	if !ok {
+		return
+	}
+
+	segment, err := h.services.SegmentService.EvaluateSegment(c.Request.Context(), id)
+	if err != nil {
+		h.respondSegmentError(c, err, "failed to evaluate segment")
+		return
+	}
+
+	c.JSON(http.StatusOK, segment)
+}
+
+// ListSegmentMembers godoc
+// @Summary List segment members
+// @Description Get a page of the IDs of the users in a segment at its last evaluation. Requires the segments:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Segment ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(100)
+// @Success 200 {object} dto.SegmentMembersResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/segments/{id}/members [get]
+func (h *Handler) ListSegmentMembers(c *gin.Context) {
+	id, ok := segmentID(c)
+	if !ok {
+		return
+	}
+
+	page, limit, ok := parsePage(c, 100, 1000)
+	if !ok {
+		return
+	}
+
+	userIDs, total, err := h.services.SegmentService.ListMembers(c.Request.Context(), id, limit, (page-1)*limit)
+	if err != nil {
+		h.respondSegmentError(c, err, "failed to list segment members")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SegmentMembersResponse{
+		UserIDs:    userIDs,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// NotifySegment godoc
+// @Summary Notify segment
+// @Description Send a marketing message to the members of a segment by email or SMS. Members who didn't opt in to the
+// @Description channel, and SMS to unverified numbers, are skipped. Sending continues in the background and its outcome
+// @Description is logged. Requires the segments:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Segment ID"
+// @Param request body dto.NotifySegmentRequest true "Message"
+// @Success 202 {object} dto.NotifySegmentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/segments/{id}/notifications [post]
+func (h *Handler) NotifySegment(c *gin.Context) {
+	id, ok := segmentID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.NotifySegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	notification := domain.Notification{
+		Channel: req.Channel,
+		Subject: req.Subject,
+		Body:    req.Body,
+	}
+
+	members, err := h.services.SegmentService.NotifySegment(c.Request.Context(), id, notification)
+	if err != nil {
+		h.respondSegmentError(c, err, "failed to notify segment")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.NotifySegmentResponse{SegmentID: id, Members: members})
+}
+
+// segmentID parses the segment ID path parameter, writing a 400 response if it is invalid
+func segmentID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid segment id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondSegmentError maps segment errors to a response, with message for unexpected ones
+func (h *Handler) respondSegmentError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "segment not found"})
+	case errors.Is(err, domain.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "segment already exists"})
+	default:
+		h.logger.WithComponent("segments").WithError(err).Error("Failed to manage segment")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,240 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// CreateStockAdjustment godoc
+// @Summary Adjust product stock
+// @Description Change a product's stock by delta and record it in the stock ledger with the reason and the signed-in user.
+// @Description With warehouse_id the stock at that warehouse changes. Stock cannot go below zero. Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param adjustment body dto.StockAdjustmentRequest true "Stock adjustment"
+// @Success 201 {object} domain.StockAdjustment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/products/{id}/stock-adjustments [post]
+func (h *Handler) CreateStockAdjustment(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req dto.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	adjustment := &domain.StockAdjustment{
+		ProductID:   productID,
+		WarehouseID: req.WarehouseID,
+		Delta:       req.Delta,
+		Reason:      req.Reason,
+		Note:        req.Note,
+		ActorID:     userID,
+	}
+	if _, err := h.services.ProductService.AdjustStock(c.Request.Context(), adjustment); err != nil {
+		h.respondStockError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, adjustment)
+}
+
+// ListStockAdjustments godoc
+// @Summary List stock adjustments
+// @Description Get a page of a product's stock ledger, newest first. Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Success 200 {object} dto.StockAdjustmentListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/products/{id}/stock-adjustments [get]
+func (h *Handler) ListStockAdjustments(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	page, limit, ok := parsePage(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	adjustments, total, err := h.services.ProductService.ListStockAdjustments(c.Request.Context(), productID, limit, (page-1)*limit)
+	if err != nil {
+		h.respondStockError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.StockAdjustmentListResponse{
+		Adjustments: adjustments,
+		Pagination:  newPagination(page, limit, total),
+	})
+}
+
+// GetStockReconciliation godoc
+// @Summary Reconcile product stock
+// @Description Compare a product's current stock with the sum of its stock ledger, broken down by reason
+// @Description (initial, purchase, return, restock, damage, correction). Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.StockReconciliation
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/products/{id}/stock-reconciliation [get]
+func (h *Handler) GetStockReconciliation(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	reconciliation, err := h.services.ProductService.ReconcileStock(c.Request.Context(), productID)
+	if err != nil {
+		h.respondStockError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reconciliation)
+}
+
+// CreateStockTransfer godoc
+// @Summary Transfer stock between warehouses
+// @Description Move stock of a product from one warehouse to another, recorded in the stock ledger as a pair of transfer adjustments.
+// @Description Without from_warehouse_id unallocated stock is assigned to the warehouse. The total stock is unchanged.
+// @Description Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param transfer body dto.StockTransferRequest true "Stock transfer"
+// @Success 201 {array} domain.StockAdjustment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/products/{id}/stock-transfers [post]
+func (h *Handler) CreateStockTransfer(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req dto.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	adjustments, err := h.services.InventoryService.TransferStock(c.Request.Context(), &domain.StockTransfer{
+		ProductID:       productID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		Note:            req.Note,
+		ActorID:         userID,
+	})
+	if err != nil {
+		h.respondStockError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, adjustments)
+}
+
+// GetProductAvailability godoc
+// @Summary Get product availability
+// @Description Get a product's total stock broken down by active warehouse, plus stock not assigned to any warehouse
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.ProductAvailability
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/availability [get]
+func (h *Handler) GetProductAvailability(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	availability, err := h.services.InventoryService.GetAvailability(c.Request.Context(), productID)
+	if err != nil {
+		h.respondStockError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// respondStockError maps stock management errors to a response
+func (h *Handler) respondStockError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+	case errors.Is(err, domain.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "insufficient stock"})
+	default:
+		h.logger.WithComponent("stock").WithError(err).Error("Failed to manage stock")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to manage stock"})
+	}
+}
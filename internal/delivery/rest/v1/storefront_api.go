@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// InitStorefrontRoutes sets up the public storefront endpoint
+func (h *Handler) InitStorefrontRoutes(api *gin.RouterGroup) {
+	api.GET("/storefront", h.GetStorefront)
+}
+
+// GetStorefront godoc
+// @Summary Get storefront
+// @Description Get the name, currency and branding of the storefront the request is for, resolved from the tenant header or host
+// @Tags storefront
+// @Produce json
+// @Param X-Tenant-ID header string false "Tenant ID; the tenant is resolved from the host without it"
+// @Success 200 {object} dto.StorefrontResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /storefront [get]
+func (h *Handler) GetStorefront(c *gin.Context) {
+	storefront := tenant.FromContext(c.Request.Context())
+	if storefront == nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "tenancy is not enabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewStorefrontResponse(storefront))
+}
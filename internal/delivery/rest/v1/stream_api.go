@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// StreamProductStock godoc
+// @Summary Stream product stock
+// @Description Server-Sent Events stream of a product's stock. The current stock is sent first as a "stock" event,
+// @Description followed by an event on every change. Comment lines are sent periodically to keep the connection open.
+// @Tags products
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} domain.StockEvent
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /products/{id}/stream [get]
+func (h *Handler) StreamProductStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	// Subscribe before reading the current stock so no change is missed in between
+	events, unsubscribe := h.services.StockFeed.Subscribe(id)
+	defer unsubscribe()
+
+	product, err := h.services.ProductService.GetProduct(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "product not found"})
+			return
+		}
+		h.logger.WithComponent("product").WithError(err).Error("Failed to get product")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get product"})
+		return
+	}
+
+	// Streams outlive the server read and write timeouts
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("stock", domain.StockEvent{
+		ProductID: product.ID,
+		Stock:     product.Stock,
+		InStock:   product.Stock > 0,
+		UpdatedAt: product.UpdatedAt,
+	})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.services.StockFeed.Heartbeat())
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("stock", event)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
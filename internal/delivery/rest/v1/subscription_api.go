@@ -0,0 +1,306 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// InitSubscriptionRoutes sets up the endpoints of a user's subscriptions; plans are listed
+// under /products/:id/subscription-plans and managed under /admin
+func (h *Handler) InitSubscriptionRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	subscriptions := api.Group("/subscriptions")
+	subscriptions.Use(authMiddleware)
+	{
+		subscriptions.GET("", h.ListMySubscriptions)
+		subscriptions.POST("", h.Subscribe)
+		subscriptions.POST("/:id/pause", h.PauseSubscription)
+		subscriptions.POST("/:id/resume", h.ResumeSubscription)
+		subscriptions.POST("/:id/cancel", h.CancelSubscription)
+	}
+}
+
+// ListSubscriptionPlans godoc
+// @Summary List subscription plans
+// @Description Get the plans a product can be subscribed to, cheapest first. price is per unit each interval.
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {array} domain.SubscriptionPlan
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /products/{id}/subscription-plans [get]
+func (h *Handler) ListSubscriptionPlans(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	plans, err := h.services.SubscriptionService.ListPlans(c.Request.Context(), productID)
+	if err != nil {
+		h.respondSubscriptionError(c, err, "product not found", "failed to list subscription plans")
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// CreateSubscriptionPlan godoc
+// @Summary Create subscription plan
+// @Description Offer a product as a recurring purchase renewed every week, month or year at the given price per unit.
+// @Description Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body dto.CreateSubscriptionPlanRequest true "Plan data"
+// @Success 201 {object} domain.SubscriptionPlan
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/products/{id}/subscription-plans [post]
+func (h *Handler) CreateSubscriptionPlan(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid product id"})
+		return
+	}
+
+	var req dto.CreateSubscriptionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+	price, err := req.Price.Money(h.services.ProductService.Currency(c.Request.Context()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	plan := &domain.SubscriptionPlan{
+		ProductID: productID,
+		Interval:  req.Interval,
+		Price:     price,
+	}
+
+	if err := h.services.SubscriptionService.CreatePlan(c.Request.Context(), plan); err != nil {
+		h.respondSubscriptionError(c, err, "product not found", "failed to create subscription plan")
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// DeactivateSubscriptionPlan godoc
+// @Summary Deactivate subscription plan
+// @Description Stop offering a plan. Existing subscriptions keep renewing at their price. Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Plan ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/subscription-plans/{id} [delete]
+func (h *Handler) DeactivateSubscriptionPlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid plan id"})
+		return
+	}
+
+	if err := h.services.SubscriptionService.DeactivatePlan(c.Request.Context(), id); err != nil {
+		h.respondSubscriptionError(c, err, "subscription plan not found", "failed to deactivate subscription plan")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "subscription plan deactivated successfully"})
+}
+
+// ListMySubscriptions godoc
+// @Summary List my subscriptions
+// @Description Get a page of the current user's subscriptions, newest first, in every status
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.SubscriptionListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /subscriptions [get]
+func (h *Handler) ListMySubscriptions(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	subscriptions, total, err := h.services.SubscriptionService.ListSubscriptions(c.Request.Context(), userID, limit, (page-1)*limit)
+	if err != nil {
+		h.respondSubscriptionError(c, err, "subscription not found", "failed to list subscriptions")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SubscriptionListResponse{
+		Subscriptions: subscriptions,
+		Pagination:    newPagination(page, limit, total),
+	})
+}
+
+// Subscribe godoc
+// @Summary Subscribe to a plan
+// @Description Subscribe to a product plan. The first period is charged and its stock taken right away; the subscription
+// @Description then renews every interval. If the first charge fails nothing is taken and the subscription is cancelled.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SubscribeRequest true "Plan and quantity"
+// @Success 201 {object} domain.Subscription
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 402 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /subscriptions [post]
+func (h *Handler) Subscribe(c *gin.Context) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	subscription, err := h.services.SubscriptionService.Subscribe(c.Request.Context(), userID, req.PlanID, req.Quantity)
+	if err != nil {
+		h.respondSubscriptionError(c, err, "subscription plan not found", "failed to subscribe")
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// PauseSubscription godoc
+// @Summary Pause subscription
+// @Description Stop renewing an active or past due subscription until it is resumed
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /subscriptions/{id}/pause [post]
+func (h *Handler) PauseSubscription(c *gin.Context) {
+	h.changeSubscription(c, h.services.SubscriptionService.Pause)
+}
+
+// ResumeSubscription godoc
+// @Summary Resume subscription
+// @Description Restart renewals of a paused subscription. A renewal that fell due while paused is made right away.
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /subscriptions/{id}/resume [post]
+func (h *Handler) ResumeSubscription(c *gin.Context) {
+	h.changeSubscription(c, h.services.SubscriptionService.Resume)
+}
+
+// CancelSubscription godoc
+// @Summary Cancel subscription
+// @Description Cancel a subscription; it is never renewed again. Periods already paid for are not refunded.
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /subscriptions/{id}/cancel [post]
+func (h *Handler) CancelSubscription(c *gin.Context) {
+	h.changeSubscription(c, h.services.SubscriptionService.Cancel)
+}
+
+// changeSubscription applies a status change to one of the current user's subscriptions
+func (h *Handler) changeSubscription(c *gin.Context, change func(ctx context.Context, userID, id int) (*domain.Subscription, error)) {
+	userID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid subscription id"})
+		return
+	}
+
+	subscription, err := change(c.Request.Context(), userID, id)
+	if err != nil {
+		h.respondSubscriptionError(c, err, "subscription not found", "failed to update subscription")
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// currentUserID returns the ID of the signed-in user, responding with an error if there is none
+func (h *Handler) currentUserID(c *gin.Context) (int, bool) {
+	userIDStr, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "user not authenticated"})
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// respondSubscriptionError maps subscription errors to a response, with notFound for missing
+// records and message for unexpected errors
+func (h *Handler) respondSubscriptionError(c *gin.Context, err error, notFound, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: notFound})
+	case errors.Is(err, domain.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "insufficient stock"})
+	case errors.Is(err, domain.ErrPaymentFailed):
+		c.JSON(http.StatusPaymentRequired, dto.ErrorResponse{Error: "payment failed"})
+	default:
+		h.logger.WithComponent("subscriptions").WithError(err).Error("Failed to manage subscription")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
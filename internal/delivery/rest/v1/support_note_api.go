@@ -0,0 +1,227 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListUserNotes godoc
+// @Summary List user notes
+// @Description Get a page of the internal support notes on a user, pinned ones first, then newest first.
+// @Description Requires the support_notes:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.SupportNoteListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/notes [get]
+func (h *Handler) ListUserNotes(c *gin.Context) {
+	h.listSupportNotes(c, domain.NoteSubjectUser)
+}
+
+// CreateUserNote godoc
+// @Summary Add user note
+// @Description Attach an internal support note to a user. The signed-in staff member is recorded as its author.
+// @Description Requires the support_notes:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.CreateSupportNoteRequest true "Note"
+// @Success 201 {object} domain.SupportNote
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/notes [post]
+func (h *Handler) CreateUserNote(c *gin.Context) {
+	h.createSupportNote(c, domain.NoteSubjectUser)
+}
+
+// ListOrderNotes godoc
+// @Summary List order notes
+// @Description Get a page of the internal support notes on an order, pinned ones first, then newest first.
+// @Description Orders are purchase records, identified by their event ID. Requires the support_notes:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order (purchase event) ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.SupportNoteListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/orders/{id}/notes [get]
+func (h *Handler) ListOrderNotes(c *gin.Context) {
+	h.listSupportNotes(c, domain.NoteSubjectOrder)
+}
+
+// CreateOrderNote godoc
+// @Summary Add order note
+// @Description Attach an internal support note to an order. The signed-in staff member is recorded as its author.
+// @Description Requires the support_notes:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order (purchase event) ID"
+// @Param request body dto.CreateSupportNoteRequest true "Note"
+// @Success 201 {object} domain.SupportNote
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/orders/{id}/notes [post]
+func (h *Handler) CreateOrderNote(c *gin.Context) {
+	h.createSupportNote(c, domain.NoteSubjectOrder)
+}
+
+// UpdateSupportNote godoc
+// @Summary Update note
+// @Description Change the text of a support note or pin and unpin it. Requires the support_notes:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Param request body dto.UpdateSupportNoteRequest true "Changes"
+// @Success 200 {object} domain.SupportNote
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/notes/{id} [put]
+func (h *Handler) UpdateSupportNote(c *gin.Context) {
+	id, ok := supportNoteID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateSupportNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	note, err := h.services.SupportNoteService.UpdateNote(c.Request.Context(), id, req.Text, req.Pinned)
+	if err != nil {
+		h.respondSupportNoteError(c, err, "failed to update note")
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// DeleteSupportNote godoc
+// @Summary Delete note
+// @Description Delete a support note. Requires the support_notes:manage permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/notes/{id} [delete]
+func (h *Handler) DeleteSupportNote(c *gin.Context) {
+	id, ok := supportNoteID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SupportNoteService.DeleteNote(c.Request.Context(), id); err != nil {
+		h.respondSupportNoteError(c, err, "failed to delete note")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// listSupportNotes writes a page of the notes on the user or order in the id path parameter
+func (h *Handler) listSupportNotes(c *gin.Context, subject string) {
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	notes, total, err := h.services.SupportNoteService.ListNotes(c.Request.Context(), subject, c.Param("id"), limit, (page-1)*limit)
+	if err != nil {
+		h.respondSupportNoteError(c, err, "failed to list notes")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SupportNoteListResponse{
+		Notes:      notes,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// createSupportNote attaches a note by the current user to the user or order in the id path parameter
+func (h *Handler) createSupportNote(c *gin.Context, subject string) {
+	authorID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateSupportNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	note := &domain.SupportNote{
+		Subject:   subject,
+		SubjectID: c.Param("id"),
+		AuthorID:  authorID,
+		Text:      req.Text,
+		Pinned:    req.Pinned,
+	}
+
+	if err := h.services.SupportNoteService.CreateNote(c.Request.Context(), note); err != nil {
+		h.respondSupportNoteError(c, err, "failed to create note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// supportNoteID parses the note ID path parameter, writing a 400 response if it is invalid
+func supportNoteID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid note id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondSupportNoteError maps support note errors to a response, with message for unexpected
+// ones. A missing user or order and a missing note both give 404.
+func (h *Handler) respondSupportNoteError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "not found"})
+	default:
+		h.logger.WithComponent("support").WithError(err).Error("Failed to manage support note")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
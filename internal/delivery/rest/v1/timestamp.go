@@ -0,0 +1,13 @@
+package v1
+
+import "time"
+
+// parseTimestamp parses an RFC3339 timestamp from a request ("2024-05-01T10:00:00+05:00")
+// and returns it in UTC, the time zone everything is stored and compared in
+func parseTimestamp(raw string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// tracedContext returns the request context with the trace the interactions recorded in the
+// request carry: the request ID, the caller's sign-in session and the page the user came from,
+// which is source if the body reported one and ?source= otherwise. It responds 400 to an
+// unknown source.
+func tracedContext(c *gin.Context, source string) (context.Context, bool) {
+	if source == "" {
+		source = c.Query("source")
+	}
+	trace := domain.InteractionTrace{
+		Source:    source,
+		RequestID: c.GetString(logger.RequestIDKey),
+		SessionID: middleware.GetSessionID(c),
+	}
+	if err := trace.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return domain.WithInteractionTrace(c.Request.Context(), trace), true
+}
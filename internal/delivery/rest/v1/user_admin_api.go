@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListUsers godoc
+// @Summary List users
+// @Description Get a page of users with their lifetime value (total spend, orders, average order value, first and last purchase).
+// @Description Sort keys: id, email, created_at, last_login_at, total_spend, orders, average_order_value, last_purchase_at ("-" for descending).
+// @Description Sorting by a lifetime value key computes it for every matching user and is slower. Requires the users:read permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "Part of the email, case-insensitive"
+// @Param status query string false "Account status"
+// @Param sort query string false "Sort keys, e.g. -total_spend,email" default(-created_at)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} dto.AdminUserListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, limit, ok := parsePage(c, 20, 100)
+	if !ok {
+		return
+	}
+
+	sort, err := domain.ParseSort(c.Query("sort"), domain.UserSortFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	filter := domain.UserFilter{
+		Email:  c.Query("email"),
+		Status: c.Query("status"),
+		Sort:   sort,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+
+	users, total, err := h.services.UserService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.WithComponent("users").WithError(err).Error("Failed to list users")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AdminUserListResponse{
+		Users:      users,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// GetUserLTV godoc
+// @Summary Get user lifetime value
+// @Description Get what a user has spent: total spend, order count, average order value and first and last purchase.
+// @Description Each purchase record counts as an order. Requires the users:read permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.CustomerValue
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/ltv [get]
+func (h *Handler) GetUserLTV(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	value, err := h.services.UserService.GetCustomerValue(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "user not found"})
+			return
+		}
+		h.logger.WithComponent("users").WithError(err).Error("Failed to get user lifetime value")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to get lifetime value"})
+		return
+	}
+
+	c.JSON(http.StatusOK, value)
+}
+
+// ChangeUserStatus godoc
+// @Summary Change user status
+// @Description Move a user to another status with a reason: active, suspended or deleted.
+// @Description Active users can be suspended or deleted, suspended ones reactivated or deleted, and deleted ones reactivated.
+// @Description Suspending or deleting a user revokes their sessions and refresh tokens; access tokens already issued expire on their own.
+// @Description Admins can't change their own status. Requires the users:manage permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body dto.ChangeUserStatusRequest true "New status and reason"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/status [put]
+func (h *Handler) ChangeUserStatus(c *gin.Context) {
+	actorID, ok := h.currentUserID(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid user id"})
+		return
+	}
+
+	var req dto.ChangeUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	user, err := h.services.UserService.ChangeStatus(c.Request.Context(), userID, actorID, req.Status, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, domain.ErrNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "user not found"})
+		case errors.Is(err, domain.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: err.Error()})
+		default:
+			h.logger.WithComponent("users").WithError(err).Error("Failed to change user status")
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to change user status"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
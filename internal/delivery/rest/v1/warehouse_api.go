@@ -0,0 +1,214 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// ListWarehouses godoc
+// @Summary List warehouses
+// @Description Get all warehouses ordered by code. Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} domain.Warehouse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/warehouses [get]
+func (h *Handler) ListWarehouses(c *gin.Context) {
+	warehouses, err := h.services.InventoryService.ListWarehouses(c.Request.Context())
+	if err != nil {
+		h.logger.WithComponent("inventory").WithError(err).Error("Failed to list warehouses")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "failed to list warehouses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouses)
+}
+
+// CreateWarehouse godoc
+// @Summary Create warehouse
+// @Description Create a new warehouse. Codes are 2-20 uppercase letters, digits and dashes. Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateWarehouseRequest true "Warehouse data"
+// @Success 201 {object} domain.Warehouse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/warehouses [post]
+func (h *Handler) CreateWarehouse(c *gin.Context) {
+	var req dto.CreateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	warehouse := &domain.Warehouse{
+		Code:    req.Code,
+		Name:    req.Name,
+		Address: req.Address,
+	}
+
+	if err := h.services.InventoryService.CreateWarehouse(c.Request.Context(), warehouse); err != nil {
+		h.respondWarehouseError(c, err, "failed to create warehouse")
+		return
+	}
+
+	c.JSON(http.StatusCreated, warehouse)
+}
+
+// UpdateWarehouse godoc
+// @Summary Update warehouse
+// @Description Update a warehouse (supports partial updates). Purchases do not ship from inactive warehouses.
+// @Description Requires the products:write permission.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Warehouse ID"
+// @Param request body dto.UpdateWarehouseRequest true "Updated warehouse data"
+// @Success 200 {object} domain.Warehouse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/warehouses/{id} [put]
+func (h *Handler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid warehouse id"})
+		return
+	}
+
+	var req dto.UpdateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid request body"})
+		return
+	}
+
+	warehouse, err := h.services.InventoryService.GetWarehouse(c.Request.Context(), id)
+	if err != nil {
+		h.respondWarehouseError(c, err, "failed to get warehouse")
+		return
+	}
+
+	// Update only provided fields
+	if req.Code != nil {
+		warehouse.Code = *req.Code
+	}
+	if req.Name != nil {
+		warehouse.Name = *req.Name
+	}
+	if req.Address != nil {
+		warehouse.Address = *req.Address
+	}
+	if req.IsActive != nil {
+		warehouse.IsActive = *req.IsActive
+	}
+
+	if err := h.services.InventoryService.UpdateWarehouse(c.Request.Context(), warehouse); err != nil {
+		h.respondWarehouseError(c, err, "failed to update warehouse")
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+// DeleteWarehouse godoc
+// @Summary Delete warehouse
+// @Description Delete a warehouse. Fails with 409 while it holds stock; transfer the stock elsewhere first.
+// @Description Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Warehouse ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /admin/warehouses/{id} [delete]
+func (h *Handler) DeleteWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid warehouse id"})
+		return
+	}
+
+	if err := h.services.InventoryService.DeleteWarehouse(c.Request.Context(), id); err != nil {
+		h.respondWarehouseError(c, err, "failed to delete warehouse")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "warehouse deleted successfully"})
+}
+
+// ListWarehouseInventory godoc
+// @Summary List warehouse inventory
+// @Description Get a page of the products held at a warehouse and their quantities, ordered by product ID.
+// @Description Requires the products:write permission.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Warehouse ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, instead of page and limit"
+// @Success 200 {object} dto.InventoryListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/warehouses/{id}/inventory [get]
+func (h *Handler) ListWarehouseInventory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "invalid warehouse id"})
+		return
+	}
+
+	page, limit, ok := parsePage(c, 50, 200)
+	if !ok {
+		return
+	}
+
+	levels, total, err := h.services.InventoryService.ListWarehouseInventory(c.Request.Context(), id, limit, (page-1)*limit)
+	if err != nil {
+		h.respondWarehouseError(c, err, "failed to list inventory")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.InventoryListResponse{
+		Inventory:  levels,
+		Pagination: newPagination(page, limit, total),
+	})
+}
+
+// respondWarehouseError maps warehouse errors to a response, with message for unexpected ones
+func (h *Handler) respondWarehouseError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "warehouse not found"})
+	case errors.Is(err, domain.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "warehouse code already exists"})
+	case errors.Is(err, domain.ErrWarehouseNotEmpty):
+		c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "warehouse still holds stock"})
+	default:
+		h.logger.WithComponent("inventory").WithError(err).Error("Failed to manage warehouse")
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: message})
+	}
+}
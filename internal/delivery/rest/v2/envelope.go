@@ -0,0 +1,173 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Envelope creates a middleware that rewrites JSON responses of v1 handlers into dto.Envelope:
+//   - 2xx bodies become data; v1 list responses ({items, total, page, limit}) are split
+//     into data (the items) and meta.pagination
+//   - error bodies ({"error": "..."}) become errors[]
+//
+// Responses that are not JSON (NDJSON exports, event streams) or have no body are passed through.
+func Envelope(appLogger *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if !writer.buffering {
+			return
+		}
+
+		envelope := buildEnvelope(writer.Status(), writer.body.Bytes())
+		envelope.Meta.RequestID = c.GetString(logger.RequestIDKey)
+
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			appLogger.WithComponent("http").WithError(err).Error("Failed to encode response envelope")
+			data = writer.body.Bytes()
+		}
+
+		writer.Header().Del("Content-Length")
+		if _, err := writer.ResponseWriter.Write(data); err != nil {
+			appLogger.WithComponent("http").WithError(err).Error("Failed to write response")
+		}
+	}
+}
+
+// envelopeWriter buffers JSON responses so they can be wrapped once the handler is done
+type envelopeWriter struct {
+	gin.ResponseWriter
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		w.buffering = mediaType == "application/json"
+	}
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *envelopeWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// buildEnvelope converts a v1 response body into the v2 envelope
+func buildEnvelope(status int, body []byte) dto.Envelope {
+	if status >= http.StatusBadRequest {
+		var errResp dto.ErrorResponse
+		message := http.StatusText(status)
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+			message = errResp.Error
+		}
+		return dto.Envelope{
+			Errors: []dto.APIError{{
+				Status:  status,
+				Code:    errorCode(status),
+				Message: message,
+			}},
+		}
+	}
+
+	if items, pagination, ok := splitPagination(body); ok {
+		return dto.Envelope{
+			Data: items,
+			Meta: dto.Meta{Pagination: pagination},
+		}
+	}
+
+	return dto.Envelope{Data: json.RawMessage(body)}
+}
+
+// splitPagination recognizes the v1 list shape: an object with the dto.Pagination fields
+// plus exactly one array field holding the items
+func splitPagination(body []byte) (json.RawMessage, *dto.Pagination, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, nil, false
+	}
+
+	var pagination dto.Pagination
+	for key, target := range map[string]interface{}{
+		"total": &pagination.Total,
+		"page":  &pagination.Page,
+		"limit": &pagination.Limit,
+	} {
+		raw, ok := fields[key]
+		if !ok || json.Unmarshal(raw, target) != nil {
+			return nil, nil, false
+		}
+		delete(fields, key)
+	}
+	for key, target := range map[string]interface{}{
+		"total_pages":       &pagination.TotalPages,
+		"next_cursor":       &pagination.NextCursor,
+		"prev_cursor":       &pagination.PrevCursor,
+		"total_is_estimate": &pagination.TotalIsEstimate,
+	} {
+		if raw, ok := fields[key]; ok {
+			if json.Unmarshal(raw, target) != nil {
+				return nil, nil, false
+			}
+			delete(fields, key)
+		}
+	}
+	if len(fields) != 1 {
+		return nil, nil, false
+	}
+
+	var items json.RawMessage
+	for _, raw := range fields {
+		trimmed := bytes.TrimSpace(raw)
+		if len(trimmed) == 0 || (trimmed[0] != '[' && !bytes.Equal(trimmed, []byte("null"))) {
+			return nil, nil, false
+		}
+		items = raw
+		if bytes.Equal(trimmed, []byte("null")) {
+			items = json.RawMessage("[]")
+		}
+	}
+
+	if pagination.TotalPages == 0 && pagination.Limit > 0 {
+		pagination.TotalPages = int((pagination.Total + int64(pagination.Limit) - 1) / int64(pagination.Limit))
+	}
+
+	return items, &pagination, true
+}
+
+// errorCode returns a machine-readable code for an HTTP status, e.g. "not_found"
+func errorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,45 @@
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	v1 "github.com/PrimeraAizen/e-comm/internal/delivery/rest/v1"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Handler serves /api/v2. Endpoints are the v1 handlers wrapped by the Envelope middleware,
+// which converts their responses into the v2 contract.
+type Handler struct {
+	services *service.Service
+	logger   *logger.Logger
+	v1       *v1.Handler
+}
+
+func NewHandler(services *service.Service, appLogger *logger.Logger) *Handler {
+	return &Handler{
+		services: services,
+		logger:   appLogger,
+		v1:       v1.NewHandler(services, appLogger),
+	}
+}
+
+func (h *Handler) Init(api *gin.RouterGroup) {
+	v2 := api.Group("/v2")
+	v2.Use(Envelope(h.logger))
+
+	authMiddleware := middleware.AuthMiddleware(h.services.AuthService)
+
+	// Public routes
+	h.v1.InitAuthRoutes(v2, authMiddleware)
+
+	// Protected routes (require authentication)
+	h.v1.InitCategoryRoutes(v2, authMiddleware)
+	h.v1.InitProductRoutes(v2, authMiddleware)
+	h.v1.InitBundleRoutes(v2)
+	h.v1.InitSubscriptionRoutes(v2, authMiddleware)
+	h.v1.InitCartRoutes(v2, authMiddleware)
+	h.v1.InitProfileRoutes(v2, authMiddleware)
+	h.v1.InitAdminRoutes(v2, authMiddleware)
+}
@@ -0,0 +1,47 @@
+package delivery
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveStatic creates a handler serving the files under root, without directory listings or
+// dotfiles. Files are cached for maxAge and revalidated by ETag or modification time; range
+// requests are supported so large files can be resumed and media can be seeked.
+func serveStatic(root string, maxAge time.Duration) gin.HandlerFunc {
+	fs := http.Dir(root)
+	cacheControl := fmt.Sprintf("public, max-age=%d", int64(maxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		name := c.Param("path")
+		for _, segment := range strings.Split(name, "/") {
+			if strings.HasPrefix(segment, ".") {
+				c.Status(http.StatusNotFound)
+				return
+			}
+		}
+
+		file, err := fs.Open(name)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil || info.IsDir() {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		// ServeContent answers If-None-Match and If-Range with the ETag set here
+		c.Header("Cache-Control", cacheControl)
+		c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+		c.Header("X-Content-Type-Options", "nosniff")
+		http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+	}
+}
@@ -0,0 +1,216 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/dto"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 4096
+	sendBufferSize = 32
+)
+
+// AdminMetrics godoc
+// @Summary Live admin dashboard metrics
+// @Description WebSocket streaming order counts, revenue and error rates. Requires the metrics:read permission.
+// @Description Send {"action":"subscribe","topics":["orders","revenue","errors"]} to receive "update" messages,
+// @Description and {"action":"unsubscribe","topics":[...]} to stop. The token may be passed as access_token.
+// @Tags admin
+// @Security BearerAuth
+// @Param access_token query string false "Access token, for clients that can't send the Authorization header"
+// @Success 101
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /ws/admin [get]
+func (h *Handler) AdminMetrics(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied with an HTTP error
+		h.logger.WithComponent("ws").WithError(err).Warn("WebSocket upgrade failed")
+		return
+	}
+
+	// The session outlives the handshake request but stays scoped to its tenant
+	ctx := context.Background()
+	if t := tenant.FromContext(c.Request.Context()); t != nil {
+		ctx = tenant.NewContext(ctx, t)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	session := &metricsSession{
+		handler:       h,
+		conn:          conn,
+		send:          make(chan dto.WSServerMessage, sendBufferSize),
+		subscriptions: make(map[string]func()),
+		ctx:           ctx,
+	}
+
+	go session.writeLoop(cancel)
+	session.readLoop()
+
+	cancel()
+	session.unsubscribeAll()
+}
+
+// metricsSession is one dashboard connection. readLoop handles client messages,
+// writeLoop is the only goroutine writing to the connection.
+type metricsSession struct {
+	handler *Handler
+	conn    *websocket.Conn
+	send    chan dto.WSServerMessage
+	ctx     context.Context
+
+	mu            sync.Mutex
+	subscriptions map[string]func()
+}
+
+func (s *metricsSession) readLoop() {
+	s.conn.SetReadLimit(maxMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg dto.WSClientMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) && s.ctx.Err() == nil {
+				s.handler.logger.WithComponent("ws").WithError(err).Debug("WebSocket read failed")
+			}
+			return
+		}
+
+		switch msg.Action {
+		case dto.WSActionSubscribe:
+			s.subscribe(msg.Topics)
+		case dto.WSActionUnsubscribe:
+			s.unsubscribe(msg.Topics)
+		default:
+			s.push(dto.WSServerMessage{Type: dto.WSTypeError, Message: "unknown action"})
+		}
+	}
+}
+
+func (s *metricsSession) writeLoop(cancel context.CancelFunc) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		cancel()
+		s.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			_ = s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+			return
+		case msg := <-s.send:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *metricsSession) subscribe(topics []string) {
+	var subscribed []string
+	for _, topic := range topics {
+		if !domain.IsMetricsTopic(topic) {
+			s.push(dto.WSServerMessage{Type: dto.WSTypeError, Topic: topic, Message: "unknown topic"})
+			continue
+		}
+
+		s.mu.Lock()
+		_, exists := s.subscriptions[topic]
+		if !exists {
+			updates, unsubscribe := s.handler.services.LiveMetrics.Subscribe(s.ctx, topic)
+			s.subscriptions[topic] = unsubscribe
+			go s.forward(updates)
+		}
+		s.mu.Unlock()
+
+		subscribed = append(subscribed, topic)
+	}
+
+	if len(subscribed) == 0 {
+		return
+	}
+	s.push(dto.WSServerMessage{Type: dto.WSTypeSubscribed, Topics: subscribed})
+
+	// Send the current values right away instead of waiting for the next tick
+	for _, topic := range subscribed {
+		update, err := s.handler.services.LiveMetrics.Snapshot(s.ctx, topic)
+		if err != nil {
+			s.handler.logger.WithComponent("ws").WithError(err).Error("Failed to get live metrics")
+			continue
+		}
+		s.push(updateMessage(*update))
+	}
+}
+
+func (s *metricsSession) unsubscribe(topics []string) {
+	s.mu.Lock()
+	for _, topic := range topics {
+		if unsubscribe, ok := s.subscriptions[topic]; ok {
+			unsubscribe()
+			delete(s.subscriptions, topic)
+		}
+	}
+	s.mu.Unlock()
+
+	s.push(dto.WSServerMessage{Type: dto.WSTypeUnsubscribed, Topics: topics})
+}
+
+func (s *metricsSession) unsubscribeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for topic, unsubscribe := range s.subscriptions {
+		unsubscribe()
+		delete(s.subscriptions, topic)
+	}
+}
+
+// forward relays updates of one subscription until it is cancelled
+func (s *metricsSession) forward(updates <-chan domain.MetricsUpdate) {
+	for update := range updates {
+		s.push(updateMessage(update))
+	}
+}
+
+// push queues a message for the client, dropping it if the client is not keeping up
+func (s *metricsSession) push(msg dto.WSServerMessage) {
+	select {
+	case s.send <- msg:
+	case <-s.ctx.Done():
+	default:
+	}
+}
+
+func updateMessage(update domain.MetricsUpdate) dto.WSServerMessage {
+	at := update.At
+	return dto.WSServerMessage{
+		Type:  dto.WSTypeUpdate,
+		Topic: update.Topic,
+		Data:  update.Data,
+		At:    &at,
+	}
+}
@@ -0,0 +1,55 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/PrimeraAizen/e-comm/internal/delivery/middleware"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/service"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Handler serves WebSocket endpoints
+type Handler struct {
+	services *service.Service
+	logger   *logger.Logger
+	upgrader websocket.Upgrader
+}
+
+// NewHandler creates a WebSocket handler accepting handshakes from the given origins
+// (and from the API's own origin)
+func NewHandler(services *service.Service, appLogger *logger.Logger, allowedOrigins []string) *Handler {
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[origin] = true
+	}
+
+	return &Handler{
+		services: services,
+		logger:   appLogger,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || origins[origin] || origin == "http://"+r.Host || origin == "https://"+r.Host
+			},
+		},
+	}
+}
+
+// Init registers WebSocket routes. Browsers can't set headers on WebSocket handshakes,
+// so the access token may also be passed as ?access_token=.
+func (h *Handler) Init(router *gin.Engine) {
+	ws := router.Group("/ws")
+	ws.Use(
+		middleware.TokenFromQuery("access_token"),
+		middleware.AuthMiddleware(h.services.AuthService),
+	)
+	{
+		ws.GET("/admin", middleware.RequirePermission(domain.PermissionMetricsRead), h.AdminMetrics)
+	}
+}
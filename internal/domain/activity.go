@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Activity types of the user activity timeline
+const (
+	ActivityView          = "view"
+	ActivityLike          = "like"
+	ActivityPurchase      = "purchase"
+	ActivityProfileChange = "profile_change"
+)
+
+// ActivityTypes lists every activity type of the timeline
+var ActivityTypes = []string{ActivityView, ActivityLike, ActivityPurchase, ActivityProfileChange}
+
+// ProfileChange records which account fields a user changed
+type ProfileChange struct {
+	UserID    int       `json:"user_id" bson:"user_id"`
+	Fields    []string  `json:"fields" bson:"fields"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+}
+
+// ActivityItem is one entry of a user's activity timeline. Product fields are set for views,
+// likes and purchases, Fields for profile changes.
+type ActivityItem struct {
+	Type        string    `json:"type" bson:"type"`
+	OccurredAt  time.Time `json:"occurred_at" bson:"occurred_at"`
+	ProductID   *int      `json:"product_id,omitempty" bson:"product_id,omitempty"`
+	ProductName string    `json:"product_name,omitempty" bson:"product_name,omitempty"`
+	Quantity    int       `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	Price       *Money    `json:"price,omitempty" bson:"price,omitempty"`
+	Fields      []string  `json:"fields,omitempty" bson:"fields,omitempty"`
+}
+
+// ActivityFilter selects a page of a user's activity timeline, newest first
+type ActivityFilter struct {
+	UserID int
+	Types  []string // all types if empty
+	Limit  int
+	Offset int
+}
+
+// ParseActivityTypes parses a comma-separated list of activity types such as "view,purchase".
+// An empty list selects all types and yields nil.
+func ParseActivityTypes(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	var types []string
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		if !isActivityType(t) {
+			return nil, fmt.Errorf("unknown activity type %q: %w", t, ErrValidation)
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+
+	return types, nil
+}
+
+func isActivityType(t string) bool {
+	for _, activityType := range ActivityTypes {
+		if activityType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Categories of the admin activity feed
+const (
+	AdminActivityAudit = "audit" // changes staff made
+	AdminActivityOrder = "order" // purchases and checkouts
+	AdminActivityAlert = "alert" // what needs a staff member's attention
+)
+
+// Entry types of the admin activity feed
+const (
+	AdminActivityStockAdjusted      = "stock_adjusted"
+	AdminActivityNoteAdded          = "support_note_added"
+	AdminActivityContentReviewed    = "content_reviewed"
+	AdminActivityRiskReviewed       = "risk_reviewed"
+	AdminActivityPurchase           = "purchase"
+	AdminActivityCheckoutFlagged    = "checkout_flagged"
+	AdminActivityContentQuarantined = "content_quarantined"
+)
+
+// AdminActivityItem is one entry of the admin activity feed. Details hold what else is known of
+// the entry, such as the reason of a stock adjustment or the outcome of a review.
+type AdminActivityItem struct {
+	Type       string                 `json:"type" bson:"type"`
+	Category   string                 `json:"category" bson:"category"`
+	OccurredAt time.Time              `json:"occurred_at" bson:"occurred_at"`
+	ActorID    int                    `json:"actor_id,omitempty" bson:"actor_id,omitempty"` // 0 for guests
+	EntityType string                 `json:"entity_type" bson:"entity_type"`
+	EntityID   string                 `json:"entity_id" bson:"entity_id"`
+	Details    map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+}
+
+// AdminActivityFilter selects a page of the admin activity feed, newest first. Zero values
+// don't filter.
+type AdminActivityFilter struct {
+	ActorID    int
+	EntityType string
+	Category   string
+	From       time.Time
+	To         time.Time // exclusive
+	Limit      int
+	Offset     int
+}
+
+// IsAdminActivityCategory reports whether c is a category of the admin activity feed
+func IsAdminActivityCategory(c string) bool {
+	return c == AdminActivityAudit || c == AdminActivityOrder || c == AdminActivityAlert
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backup is a snapshot of the database written as gzip-compressed NDJSON
+type Backup struct {
+	Name      string    `json:"name" example:"backup-20250101T120000Z.ndjson.gz"`
+	S3Key     string    `json:"s3_key,omitempty" example:"backups/backup-20250101T120000Z.ndjson.gz"` // set when uploaded to S3
+	StartedAt time.Time `json:"started_at"`
+}
+
+// BackupName returns the file name of a backup started at the given time
+func BackupName(at time.Time) string {
+	return fmt.Sprintf("backup-%s.ndjson.gz", at.UTC().Format("20060102T150405Z"))
+}
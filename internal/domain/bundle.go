@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxBundleComponents limits how many distinct products a bundle is made of
+const MaxBundleComponents = 20
+
+// BundleComponent is a product and how many of it go into one bundle
+type BundleComponent struct {
+	ProductID int `json:"product_id" bson:"product_id"`
+	Quantity  int `json:"quantity" bson:"quantity"`
+}
+
+// Bundle is a kit of products sold together at its own price. It has no stock of its own:
+// purchases take stock from the components.
+type Bundle struct {
+	ID          int               `json:"id" bson:"_id"`
+	Name        string            `json:"name" bson:"name"`
+	Description string            `json:"description" bson:"description"`
+	Price       Money             `json:"price" bson:"price"`
+	Components  []BundleComponent `json:"components" bson:"components"`
+	IsActive    bool              `json:"is_active" bson:"is_active"`
+	SegmentIDs  []int             `json:"segment_ids,omitempty" bson:"segment_ids,omitempty"` // offered only to members of these segments when set
+	CreatedAt   time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" bson:"updated_at"`
+
+	// Stock is how many bundles the component stock makes up, set when the bundle is read
+	Stock int `json:"stock" bson:"-"`
+}
+
+// Validate checks the name, price and components: at least two units in total, positive
+// quantities and each product listed once
+func (b *Bundle) Validate() error {
+	if b.Name == "" {
+		return fmt.Errorf("bundle name is required: %w", ErrValidation)
+	}
+	if b.Price.Amount <= 0 {
+		return fmt.Errorf("bundle price must be greater than 0: %w", ErrValidation)
+	}
+	if len(b.Components) > MaxBundleComponents {
+		return fmt.Errorf("a bundle has at most %d components: %w", MaxBundleComponents, ErrValidation)
+	}
+
+	units := 0
+	seen := make(map[int]bool, len(b.Components))
+	for _, component := range b.Components {
+		if component.Quantity <= 0 {
+			return fmt.Errorf("component quantity must be greater than 0: %w", ErrValidation)
+		}
+		if seen[component.ProductID] {
+			return fmt.Errorf("product %d is listed twice: %w", component.ProductID, ErrValidation)
+		}
+		seen[component.ProductID] = true
+		units += component.Quantity
+	}
+	if units < 2 {
+		return fmt.Errorf("a bundle needs at least two items: %w", ErrValidation)
+	}
+
+	targeted := make(map[int]bool, len(b.SegmentIDs))
+	for _, segmentID := range b.SegmentIDs {
+		if targeted[segmentID] {
+			return fmt.Errorf("segment %d is listed twice: %w", segmentID, ErrValidation)
+		}
+		targeted[segmentID] = true
+	}
+
+	return nil
+}
+
+// OfferedTo reports whether a user in the given segments may see and buy the bundle
+func (b *Bundle) OfferedTo(segmentIDs []int) bool {
+	if len(b.SegmentIDs) == 0 {
+		return true
+	}
+	for _, target := range b.SegmentIDs {
+		for _, segmentID := range segmentIDs {
+			if target == segmentID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BundleFilter selects the bundles to list. With Targeted set, bundles targeted at segments
+// are only listed if they target one of SegmentIDs, the segments of the user browsing.
+type BundleFilter struct {
+	IsActive   *bool
+	Targeted   bool
+	SegmentIDs []int
+}
+
+// StockFrom sets Stock to the number of whole bundles the given product stock makes up
+func (b *Bundle) StockFrom(stock map[int]int) {
+	b.Stock = 0
+	for i, component := range b.Components {
+		available := stock[component.ProductID] / component.Quantity
+		if i == 0 || available < b.Stock {
+			b.Stock = available
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxCartItems is the most distinct products a cart can hold
+const MaxCartItems = 50
+
+// CartItem is a quantity of a product in a cart
+type CartItem struct {
+	ProductID int       `json:"product_id" bson:"product_id"`
+	Quantity  int       `json:"quantity" bson:"quantity"`
+	AddedAt   time.Time `json:"added_at" bson:"added_at"`
+	Source    string    `json:"source,omitempty" bson:"source,omitempty"` // page the product was added from; its purchase is attributed to it
+}
+
+// Cart holds the products a user intends to buy. It is abandoned when left unchanged for
+// a while; the abandonment stays linked until checkout so a recovery can be attributed.
+type Cart struct {
+	UserID    int        `json:"user_id" bson:"_id"`
+	Items     []CartItem `json:"items" bson:"items"`
+	UpdatedAt time.Time  `json:"updated_at" bson:"updated_at"`
+	Total     Money      `json:"total" bson:"-"`
+
+	AbandonedCartID int        `json:"-" bson:"abandoned_cart_id,omitempty"`
+	AbandonedAt     *time.Time `json:"-" bson:"abandoned_at,omitempty"`
+}
+
+// SetItem sets the quantity of a product, removing it at 0. A product added to the cart keeps the
+// source it was first added from.
+func (c *Cart) SetItem(productID, quantity int, source string, now time.Time) error {
+	if quantity < 0 {
+		return fmt.Errorf("quantity must not be negative: %w", ErrValidation)
+	}
+
+	for i, item := range c.Items {
+		if item.ProductID != productID {
+			continue
+		}
+		if quantity == 0 {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+		} else {
+			c.Items[i].Quantity = quantity
+		}
+		return nil
+	}
+
+	if quantity == 0 {
+		return nil
+	}
+	if len(c.Items) >= MaxCartItems {
+		return fmt.Errorf("a cart holds at most %d products: %w", MaxCartItems, ErrValidation)
+	}
+	c.Items = append(c.Items, CartItem{ProductID: productID, Quantity: quantity, AddedAt: now, Source: source})
+	return nil
+}
+
+// AbandonedCart records a cart left idle, with its value at the time, and whether the user
+// was reminded and came back to check out
+type AbandonedCart struct {
+	ID             int        `json:"id" bson:"_id"`
+	UserID         int        `json:"user_id" bson:"user_id"`
+	Items          []CartItem `json:"items" bson:"items"`
+	Value          Money      `json:"value" bson:"value"`
+	AbandonedAt    time.Time  `json:"abandoned_at" bson:"abandoned_at"`
+	RemindedAt     *time.Time `json:"reminded_at,omitempty" bson:"reminded_at,omitempty"`
+	RecoveredAt    *time.Time `json:"recovered_at,omitempty" bson:"recovered_at,omitempty"`
+	RecoveredValue *Money     `json:"recovered_value,omitempty" bson:"recovered_value,omitempty"`
+}
+
+// CartEventType is the kind of change a CartEvent reports
+type CartEventType string
+
+const (
+	CartAbandoned CartEventType = "abandoned"
+	CartRecovered CartEventType = "recovered"
+)
+
+// CartEvent is published when a cart is abandoned and when an abandoned cart is checked out
+type CartEvent struct {
+	Type CartEventType
+	Cart AbandonedCart
+}
+
+// CartRecoveryStats summarizes the carts abandoned in a time range. RecoveryRate is the share
+// of abandoned carts checked out later; ReminderConversion the share of reminded ones.
+type CartRecoveryStats struct {
+	From                   time.Time `json:"from"`
+	To                     time.Time `json:"to"`
+	Abandoned              int64     `json:"abandoned"`
+	AbandonedValue         Money     `json:"abandoned_value"`
+	Reminded               int64     `json:"reminded"`
+	Recovered              int64     `json:"recovered"`
+	RecoveredValue         Money     `json:"recovered_value"`
+	RecoveredAfterReminder int64     `json:"recovered_after_reminder"`
+	RecoveryRate           float64   `json:"recovery_rate"`
+	ReminderConversion     float64   `json:"reminder_conversion"`
+}
+
+// SetRates computes the rates from the counts
+func (s *CartRecoveryStats) SetRates() {
+	s.RecoveryRate, s.ReminderConversion = 0, 0
+	if s.Abandoned > 0 {
+		s.RecoveryRate = float64(s.Recovered) / float64(s.Abandoned)
+	}
+	if s.Reminded > 0 {
+		s.ReminderConversion = float64(s.RecoveredAfterReminder) / float64(s.Reminded)
+	}
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CustomerGroup prices the catalog for the users assigned to it, such as wholesale or VIP
+// customers. Users without a group pay list prices.
+type CustomerGroup struct {
+	ID          int    `json:"id" bson:"_id"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+
+	// PercentOff discounts every product no rule applies to; 0 for list prices
+	PercentOff int `json:"percent_off" bson:"percent_off"`
+
+	// Rules override the price of products or the discount on categories
+	Rules []GroupPriceRule `json:"rules" bson:"rules"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+
+	// Set to price products below their cost price; only for the request that sets it, never stored
+	AllowBelowCost bool `json:"-" bson:"-"`
+}
+
+// GroupPriceRule sets the group's price of a product, or its discount on a product or on the
+// products of a category. A product's rule wins over its category's.
+type GroupPriceRule struct {
+	ProductID  *int   `json:"product_id,omitempty" bson:"product_id,omitempty"`
+	CategoryID *int   `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	Price      *Money `json:"price,omitempty" bson:"price,omitempty"` // products only
+	PercentOff int    `json:"percent_off,omitempty" bson:"percent_off,omitempty"`
+}
+
+// Validate checks the name, the discounts and that each rule has one target and one price
+func (g *CustomerGroup) Validate() error {
+	if strings.TrimSpace(g.Name) == "" {
+		return fmt.Errorf("customer group name is required: %w", ErrValidation)
+	}
+	if g.PercentOff < 0 || g.PercentOff > 100 {
+		return fmt.Errorf("percent_off must be between 0 and 100: %w", ErrValidation)
+	}
+
+	products := make(map[int]bool)
+	categories := make(map[int]bool)
+	for i, rule := range g.Rules {
+		switch {
+		case (rule.ProductID == nil) == (rule.CategoryID == nil):
+			return fmt.Errorf("rule %d must have either product_id or category_id: %w", i, ErrValidation)
+		case rule.ProductID != nil && products[*rule.ProductID]:
+			return fmt.Errorf("rule %d: product %d already has a rule: %w", i, *rule.ProductID, ErrValidation)
+		case rule.CategoryID != nil && categories[*rule.CategoryID]:
+			return fmt.Errorf("rule %d: category %d already has a rule: %w", i, *rule.CategoryID, ErrValidation)
+		case (rule.Price == nil) == (rule.PercentOff == 0):
+			return fmt.Errorf("rule %d must have either price or percent_off: %w", i, ErrValidation)
+		case rule.Price != nil && rule.CategoryID != nil:
+			return fmt.Errorf("rule %d: only a product can have a price, give its category a percent_off: %w", i, ErrValidation)
+		case rule.Price != nil && rule.Price.IsNegative():
+			return fmt.Errorf("rule %d: price must not be negative: %w", i, ErrValidation)
+		case rule.PercentOff < 0 || rule.PercentOff > 100:
+			return fmt.Errorf("rule %d: percent_off must be between 1 and 100: %w", i, ErrValidation)
+		}
+		if rule.ProductID != nil {
+			products[*rule.ProductID] = true
+		} else {
+			categories[*rule.CategoryID] = true
+		}
+	}
+	return nil
+}
+
+// CheckMargin rejects a group price that lowers the product's price below its cost price unless
+// AllowBelowCost is set. Products without a cost price pass.
+func (g *CustomerGroup) CheckMargin(product *ProductWithCategory) error {
+	if g.AllowBelowCost || product.CostPrice.IsZero() {
+		return nil
+	}
+
+	price := g.Price(product.ID, product.CategoryID, product.Price)
+	if price.Amount >= product.Price.Amount || price.Amount >= product.CostPrice.Amount {
+		return nil
+	}
+	return fmt.Errorf("group price %s of product %d is below cost price %s, set allow_below_cost to sell at a loss: %w", price, product.ID, product.CostPrice, ErrValidation)
+}
+
+// Price returns the group's price of a product with the list price and category: the price or
+// discount of the product's rule, else the discount of its category's rule, else the group's
+// discount. Discounts are rounded down to the minor unit.
+func (g *CustomerGroup) Price(productID int, categoryID *int, list Money) Money {
+	percentOff := g.PercentOff
+	for _, rule := range g.Rules {
+		if rule.ProductID != nil && *rule.ProductID == productID {
+			if rule.Price != nil {
+				return *rule.Price
+			}
+			percentOff = rule.PercentOff
+			break
+		}
+		if rule.CategoryID != nil && categoryID != nil && *rule.CategoryID == *categoryID {
+			percentOff = rule.PercentOff
+		}
+	}
+
+	discount := list.Amount * int64(percentOff) / 100
+	return Money{Amount: list.Amount - discount, Currency: list.Currency}
+}
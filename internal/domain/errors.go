@@ -12,4 +12,18 @@ var (
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrUserInactive       = errors.New("user inactive")
 	ErrUnauthorized       = errors.New("unauthorized")
+	ErrWeakPassword       = errors.New("weak password")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrCategoryNotEmpty   = errors.New("category is not empty")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrWarehouseNotEmpty  = errors.New("warehouse is not empty")
+	ErrPaymentFailed      = errors.New("payment failed")
+	ErrInvalidTransition  = errors.New("invalid status transition")
+	ErrBackupInProgress   = errors.New("backup in progress")
+	ErrRoleInUse          = errors.New("role is in use")
+	ErrInvalidSignature   = errors.New("invalid or expired signature")
+	ErrMediaDisabled      = errors.New("private media is disabled")
+	ErrSignInRequired     = errors.New("sign in required")
+	ErrSoldOut            = errors.New("sold out")
+	ErrAdminTenantOnly    = errors.New("shared roles and permissions can only be changed from the admin tenant")
 )
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Home feed section types, in display order
+const (
+	FeedSectionRecommended    = "recommended"
+	FeedSectionTrending       = "trending"
+	FeedSectionNewInFavorites = "new_in_favorites"
+	FeedSectionPriceDrops     = "price_drops"
+)
+
+// Feed is a user's personalized home screen. Sections without products are left out.
+type Feed struct {
+	UserID      int           `json:"user_id"`
+	Sections    []FeedSection `json:"sections"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// FeedSection is a titled list of products on the home screen
+type FeedSection struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Items    []FeedItem          `json:"items"`
+	Metadata FeedSectionMetadata `json:"metadata"`
+}
+
+// FeedSectionMetadata describes how a section was put together
+type FeedSectionMetadata struct {
+	Count       int        `json:"count"`
+	Algorithm   string     `json:"algorithm,omitempty"`    // recommended: how the products were ranked
+	Since       *time.Time `json:"since,omitempty"`        // trending, new_in_favorites, price_drops: start of the period covered
+	CategoryIDs []int      `json:"category_ids,omitempty"` // new_in_favorites: the user's favorite categories
+}
+
+// FeedItem is a product in a feed section
+type FeedItem struct {
+	Product   *Product `json:"product"`
+	Score     float64  `json:"score,omitempty"`      // recommended, trending: higher ranks first
+	Reason    string   `json:"reason,omitempty"`     // recommended: why the product is recommended
+	PriceDrop float64  `json:"price_drop,omitempty"` // price_drops: share the price went down by
+}
+
+// TrendingProduct is a product with its interactions over a period, weighted by type
+type TrendingProduct struct {
+	ProductID int     `bson:"_id"`
+	Score     float64 `bson:"score"`
+}
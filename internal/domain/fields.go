@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProductFields lists the product fields that can be selected with ?fields=,
+// mapping each JSON field name to its document field. Personalized fields that
+// are not stored on the product map to an empty string.
+var ProductFields = map[string]string{
+	"id":             "_id",
+	"name":           "name",
+	"description":    "description",
+	"category_id":    "category_id",
+	"category_name":  "category_name",
+	"price":          "price",
+	"price_tiers":    "price_tiers",
+	"min_order_qty":  "min_order_qty",
+	"max_order_qty":  "max_order_qty",
+	"qty_step":       "qty_step",
+	"purchase_limit": "purchase_limit",
+	"flash_sale":     "flash_sale",
+	"stock":          "stock",
+	"image_url":      "image_url",
+	"image_variants": "image_variants",
+	"is_active":      "is_active",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+	"liked":          "",
+	"purchased":      "",
+	"availability":   "",
+}
+
+// ProfileFields lists the profile fields that can be selected with ?fields=. Fields that come
+// from the user document rather than the profile document map to an empty string.
+var ProfileFields = map[string]string{
+	"id":             "_id",
+	"user_id":        "user_id",
+	"first_name":     "first_name",
+	"last_name":      "last_name",
+	"middle_name":    "middle_name",
+	"date_of_birth":  "date_of_birth",
+	"gender":         "gender",
+	"phone":          "phone",
+	"phone_verified": "phone_verified",
+	"address":        "address",
+	"city":           "city",
+	"country":        "country",
+	"postal_code":    "postal_code",
+	"email":          "",
+	"status":         "",
+	"created_at":     "",
+	"updated_at":     "",
+}
+
+// ParseFields parses a comma-separated field list such as "id,name,price" and checks it
+// against the allowed fields. An empty list selects all fields and yields nil.
+func ParseFields(raw string, allowed map[string]string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := allowed[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q: %w", field, ErrValidation)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+// FilterOperator is a comparison operator of the listing filter language
+type FilterOperator string
+
+const (
+	FilterEq  FilterOperator = "="
+	FilterNe  FilterOperator = "!="
+	FilterGt  FilterOperator = ">"
+	FilterGte FilterOperator = ">="
+	FilterLt  FilterOperator = "<"
+	FilterLte FilterOperator = "<="
+	FilterIn  FilterOperator = "in"
+)
+
+// FilterValueType is the type a filterable field's values are parsed as
+type FilterValueType int
+
+const (
+	FilterString FilterValueType = iota
+	FilterInt
+	FilterNumber
+	FilterBool
+	FilterTime
+	FilterMoney // a decimal amount of the storefront currency, compared in minor units
+)
+
+// FilterField describes a field that may be used in filter expressions
+type FilterField struct {
+	Path string // document field
+	Type FilterValueType
+}
+
+// FilterCondition is a single parsed condition, e.g. price >= 100.
+// Values holds one value, or several for FilterIn, already converted to the field's type.
+type FilterCondition struct {
+	Field    string
+	Operator FilterOperator
+	Values   []interface{}
+}
+
+// ProductFilterFields is the allowlist of product fields usable in ?filter= expressions
+var ProductFilterFields = map[string]FilterField{
+	"id":          {Path: "_id", Type: FilterInt},
+	"name":        {Path: "name", Type: FilterString},
+	"category_id": {Path: "category_id", Type: FilterInt},
+	"price":       {Path: "price.amount", Type: FilterMoney},
+	"stock":       {Path: "stock", Type: FilterInt},
+	"is_active":   {Path: "is_active", Type: FilterBool},
+	"created_at":  {Path: "created_at", Type: FilterTime},
+	"updated_at":  {Path: "updated_at", Type: FilterTime},
+}
+
+// AllowsOperator reports whether values of the type can be compared with the operator.
+// Strings and booleans support only equality checks.
+func (t FilterValueType) AllowsOperator(op FilterOperator) bool {
+	switch op {
+	case FilterEq, FilterNe, FilterIn:
+		return t != FilterBool || op != FilterIn
+	case FilterGt, FilterGte, FilterLt, FilterLte:
+		return t == FilterInt || t == FilterNumber || t == FilterTime || t == FilterMoney
+	}
+	return false
+}
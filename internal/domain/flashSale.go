@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// FlashSale sells a limited quantity of a product at a lower price from a start time. Buyers
+// queue in a waiting room and are admitted in order at AdmitPerSecond, so the sale isn't
+// decided by who sends the most requests.
+type FlashSale struct {
+	StartsAt time.Time  `json:"starts_at" bson:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty" bson:"ends_at,omitempty"` // nil to sell until sold out
+	Price    Money      `json:"price" bson:"price"`
+	Quantity int        `json:"quantity" bson:"quantity"`
+
+	// Units left; taken together with the product's stock in one update
+	Remaining int `json:"remaining" bson:"remaining"`
+
+	// Queue positions admitted per second from the start; 0 for the configured default
+	AdmitPerSecond int `json:"admit_per_second,omitempty" bson:"admit_per_second,omitempty"`
+
+	// Set to sell below the product's cost price; only for the request that sets it, never stored
+	AllowBelowCost bool `json:"-" bson:"-"`
+}
+
+// Validate checks the sale against the product it is set on: a price below the product's and,
+// unless AllowBelowCost is set, not below its cost price, and no more units than its
+// unallocated stock, which flash sale purchases ship from
+func (s *FlashSale) Validate(product *Product, unallocated int) error {
+	switch {
+	case s.StartsAt.IsZero():
+		return fmt.Errorf("flash sale starts_at is required: %w", ErrValidation)
+	case s.EndsAt != nil && !s.EndsAt.After(s.StartsAt):
+		return fmt.Errorf("flash sale ends_at must be after starts_at: %w", ErrValidation)
+	case s.Quantity < 1:
+		return fmt.Errorf("flash sale quantity must be at least 1: %w", ErrValidation)
+	case s.Quantity > unallocated:
+		return fmt.Errorf("flash sale quantity must not exceed the %d units of unallocated stock: %w", unallocated, ErrValidation)
+	case s.Price.IsNegative() || s.Price.Amount >= product.Price.Amount:
+		return fmt.Errorf("flash sale price must be less than the price %s: %w", product.Price, ErrValidation)
+	case !s.AllowBelowCost && product.belowCost(s.Price):
+		return fmt.Errorf("flash sale price %s is below cost price %s, set allow_below_cost to sell at a loss: %w", s.Price, product.CostPrice, ErrValidation)
+	case s.AdmitPerSecond < 0:
+		return fmt.Errorf("flash sale admit_per_second must not be negative: %w", ErrValidation)
+	}
+	return nil
+}
+
+// Active reports whether the sale has started, not ended and has units left. Products on an
+// active sale can only be bought through its waiting room.
+func (s *FlashSale) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && !s.Ended(now) && s.Remaining > 0
+}
+
+// Ended reports whether the sale's end time has passed
+func (s *FlashSale) Ended(now time.Time) bool {
+	return s.EndsAt != nil && !now.Before(*s.EndsAt)
+}
+
+// AdmitAt returns when a queue position is admitted: positions are admitted in order, rate per
+// second from the start of the sale
+func (s *FlashSale) AdmitAt(position, rate int) time.Time {
+	if s.AdmitPerSecond > 0 {
+		rate = s.AdmitPerSecond
+	}
+	return s.StartsAt.Add(time.Duration(position-1) * time.Second / time.Duration(rate))
+}
+
+// QueueTicket is a place in the waiting room of a flash sale. Its token can purchase from
+// AdmitAt until ExpiresAt.
+type QueueTicket struct {
+	ProductID int       `json:"product_id"`
+	Position  int       `json:"position"`
+	Token     string    `json:"token"`
+	AdmitAt   time.Time `json:"admit_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// QueueWaitError rejects a queue token used before its position is admitted
+type QueueWaitError struct {
+	AdmitAt time.Time
+}
+
+func (e *QueueWaitError) Error() string {
+	return fmt.Sprintf("not admitted yet, retry at %s", e.AdmitAt.UTC().Format(time.RFC3339Nano))
+}
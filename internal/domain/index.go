@@ -0,0 +1,26 @@
+package domain
+
+// CollectionIndexes compares the indexes of a collection with the ones the application expects
+type CollectionIndexes struct {
+	Collection string      `json:"collection"`
+	Indexes    []IndexInfo `json:"indexes"`
+	Missing    []string    `json:"missing"`    // expected indexes the collection lacks
+	Unexpected []string    `json:"unexpected"` // indexes not created by the application
+	TotalSize  int64       `json:"total_size"` // bytes used by all indexes of the collection
+}
+
+// IndexInfo describes an existing index
+type IndexInfo struct {
+	Name               string     `json:"name" example:"email_1"`
+	Keys               []IndexKey `json:"keys"`
+	Unique             bool       `json:"unique,omitempty"`
+	ExpireAfterSeconds *int32     `json:"expire_after_seconds,omitempty"`
+	Size               int64      `json:"size"` // in bytes
+}
+
+// IndexKey is a field of an index and its type: 1 or -1 for ascending or descending, or
+// a special type such as text
+type IndexKey struct {
+	Field string `json:"field" example:"email"`
+	Type  string `json:"type" example:"1"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+// Referential integrity checks, named after the document and the reference they follow.
+// Purchases of deleted products are not checked: they keep a snapshot of the product on purpose.
+const (
+	IntegrityProductCategory = "product_category" // products whose category is gone
+	IntegrityCategoryParent  = "category_parent"  // categories whose parent is gone
+	IntegrityViewProduct     = "view_product"     // views of deleted products
+	IntegrityViewUser        = "view_user"        // views by users that no longer exist
+	IntegrityLikeProduct     = "like_product"     // likes of deleted products
+	IntegrityLikeUser        = "like_user"        // likes by users that no longer exist
+	IntegrityPurchaseUser    = "purchase_user"    // purchases by users that no longer exist
+	IntegrityUserRoleRole    = "user_role_role"   // role assignments of deleted roles
+	IntegrityUserRoleUser    = "user_role_user"   // role assignments of users that no longer exist
+)
+
+// Ways to deal with orphaned documents
+const (
+	// IntegrityActionRepair drops the broken reference: an optional reference is unset and
+	// a document that only records the reference is deleted
+	IntegrityActionRepair = "repair"
+	// IntegrityActionQuarantine moves the documents to the quarantine collection, where they
+	// can be inspected and restored by hand
+	IntegrityActionQuarantine = "quarantine"
+)
+
+// What the repair action does to the documents of a check
+const (
+	IntegrityRepairUnset  = "unset"
+	IntegrityRepairDelete = "delete"
+)
+
+// IntegrityIssue reports the documents of a collection whose reference points at a missing document
+type IntegrityIssue struct {
+	Check      string `json:"check" example:"view_product"`
+	Collection string `json:"collection" example:"user_product_views"`
+	Field      string `json:"field" example:"product_id"`
+	References string `json:"references" example:"products"` // the collection the field refers to
+	Count      int64  `json:"count" example:"0"`
+	Sample     []any  `json:"sample"`                            // IDs of up to 10 of the documents
+	Repair     string `json:"repair,omitempty" example:"delete"` // unset or delete; empty when only quarantine is allowed
+}
+
+// IntegrityRepair reports how many documents an action changed for a check
+type IntegrityRepair struct {
+	Check    string `json:"check" example:"view_product"`
+	Action   string `json:"action" example:"repair"`
+	Affected int64  `json:"affected" example:"12"`
+}
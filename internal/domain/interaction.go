@@ -1,28 +1,121 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // UserProductView represents a user viewing a product
 type UserProductView struct {
-	UserID    int       `json:"user_id" bson:"user_id"`
-	ProductID int       `json:"product_id" bson:"product_id"`
-	ViewedAt  time.Time `json:"viewed_at" bson:"viewed_at"`
+	UserID           int       `json:"user_id" bson:"user_id"`
+	AnonymousID      string    `json:"anonymous_id,omitempty" bson:"anonymous_id,omitempty"` // set with UserID 0 for guests until they sign in
+	ProductID        int       `json:"product_id" bson:"product_id"`
+	ViewedAt         time.Time `json:"viewed_at" bson:"viewed_at"`
+	ViewEngagement   `bson:",inline"`
+	InteractionTrace `bson:",inline"`
+}
+
+// Pages an interaction can come from
+const (
+	ViewSourceSearch         = "search"
+	ViewSourceRecommendation = "recommendation"
+	ViewSourceCategory       = "category"
+)
+
+// InteractionTrace ties an interaction to the request that recorded it, so analytics can
+// attribute conversions: the page the user came from, the request ID and the sign-in session.
+// Guests have no session; their anonymous ID links their interactions instead. All of it is optional.
+type InteractionTrace struct {
+	Source    string `json:"source,omitempty" bson:"source,omitempty"`
+	RequestID string `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	SessionID int    `json:"session_id,omitempty" bson:"session_id,omitempty"`
+}
+
+// Validate checks the source the client reported
+func (t InteractionTrace) Validate() error {
+	switch t.Source {
+	case "", ViewSourceSearch, ViewSourceRecommendation, ViewSourceCategory:
+		return nil
+	default:
+		return fmt.Errorf("unknown interaction source %q: %w", t.Source, ErrValidation)
+	}
+}
+
+type interactionTraceKey struct{}
+
+// WithInteractionTrace returns a copy of ctx whose interactions are recorded with the trace
+func WithInteractionTrace(ctx context.Context, trace InteractionTrace) context.Context {
+	return context.WithValue(ctx, interactionTraceKey{}, trace)
+}
+
+// InteractionTraceFrom returns the trace interactions recorded with ctx carry, if any
+func InteractionTraceFrom(ctx context.Context) InteractionTrace {
+	trace, _ := ctx.Value(interactionTraceKey{}).(InteractionTrace)
+	return trace
+}
+
+// ViewEngagement is what the client reports about a product view: how long the page was shown
+// and how far it was scrolled. Both are optional.
+type ViewEngagement struct {
+	DwellSeconds int `json:"dwell_seconds,omitempty" bson:"dwell_seconds,omitempty"`
+	ScrollDepth  int `json:"scroll_depth,omitempty" bson:"scroll_depth,omitempty"` // percent of the page
+}
+
+// MaxViewDwellSeconds is the longest dwell time accepted for a view, an hour
+const MaxViewDwellSeconds = 3600
+
+// Validate checks the reported engagement
+func (e ViewEngagement) Validate() error {
+	if e.DwellSeconds < 0 || e.DwellSeconds > MaxViewDwellSeconds {
+		return fmt.Errorf("dwell_seconds must be between 0 and %d: %w", MaxViewDwellSeconds, ErrValidation)
+	}
+	if e.ScrollDepth < 0 || e.ScrollDepth > 100 {
+		return fmt.Errorf("scroll_depth must be between 0 and 100: %w", ErrValidation)
+	}
+	return nil
+}
+
+// IsDeep reports whether the view was read at least deepDwellSeconds or scrolled at least
+// deepScrollDepth percent down the page
+func (e ViewEngagement) IsDeep(deepDwellSeconds, deepScrollDepth int) bool {
+	return e.DwellSeconds >= deepDwellSeconds || e.ScrollDepth >= deepScrollDepth
 }
 
 // UserProductLike represents a user liking a product
 type UserProductLike struct {
-	UserID    int       `json:"user_id" bson:"user_id"`
-	ProductID int       `json:"product_id" bson:"product_id"`
-	LikedAt   time.Time `json:"liked_at" bson:"liked_at"`
+	UserID           int       `json:"user_id" bson:"user_id"`
+	ProductID        int       `json:"product_id" bson:"product_id"`
+	LikedAt          time.Time `json:"liked_at" bson:"liked_at"`
+	InteractionTrace `bson:",inline"`
 }
 
 // UserProductPurchase represents a user purchasing a product
 type UserProductPurchase struct {
 	UserID          int       `json:"user_id" bson:"user_id"`
+	AnonymousID     string    `json:"anonymous_id,omitempty" bson:"anonymous_id,omitempty"` // set with UserID 0 for guest checkouts until they sign in
 	ProductID       int       `json:"product_id" bson:"product_id"`
 	Quantity        int       `json:"quantity" bson:"quantity"`
-	PriceAtPurchase float64   `json:"price_at_purchase" bson:"price_at_purchase"`
+	PriceAtPurchase Money     `json:"price_at_purchase" bson:"price_at_purchase"`
+	CostAtPurchase  Money     `json:"-" bson:"cost_at_purchase,omitempty"` // the product's cost price; zero if unknown
 	PurchasedAt     time.Time `json:"purchased_at" bson:"purchased_at"`
+
+	// The product as it was bought, so later edits and deletions leave past purchases intact.
+	// Purchases recorded before these were kept get them from `make migrate-purchase-snapshots`.
+	ProductName     string `json:"product_name,omitempty" bson:"product_name,omitempty"`
+	ProductImageURL string `json:"product_image_url,omitempty" bson:"product_image_url,omitempty"`
+	CategoryID      *int   `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	CategoryName    string `json:"category_name,omitempty" bson:"category_name,omitempty"`
+
+	InteractionTrace `bson:",inline"`
+}
+
+// SnapshotProduct keeps the name, image and category of the product on the purchase
+func (p *UserProductPurchase) SnapshotProduct(product *ProductWithCategory) {
+	p.ProductName = product.Name
+	p.ProductImageURL = product.ImageURL
+	p.CategoryID = product.CategoryID
+	p.CategoryName = product.CategoryName
 }
 
 // UserInteractionSummary provides an overview of user's interactions
@@ -41,6 +134,83 @@ type ProductInteraction struct {
 	ProductID    int       `json:"product_id" bson:"product_id"`
 	ProductName  string    `json:"product_name" bson:"product_name"`
 	CategoryID   int       `json:"category_id" bson:"category_id"`
-	Price        float64   `json:"price" bson:"price"`
+	Price        Money     `json:"price" bson:"price"`
 	InteractedAt time.Time `json:"interacted_at" bson:"interacted_at"`
 }
+
+// Interaction event types
+const (
+	EventTypeView     = "view"
+	EventTypeLike     = "like"
+	EventTypePurchase = "purchase"
+)
+
+// InteractionEvent is a flat, fixed-schema record of a single interaction used for data export.
+// Every field is always present so batches map directly onto columnar formats such as Parquet.
+type InteractionEvent struct {
+	EventID    string    `json:"event_id" bson:"_id"`
+	EventType  string    `json:"event_type" bson:"event_type"`
+	UserID     int       `json:"user_id" bson:"user_id"`
+	ProductID  int       `json:"product_id" bson:"product_id"`
+	Quantity   int       `json:"quantity" bson:"quantity"`
+	Price      Money     `json:"price" bson:"price"` // zero without a currency for views and likes
+	OccurredAt time.Time `json:"occurred_at" bson:"occurred_at"`
+
+	// The InteractionTrace, empty when unknown
+	Source    string `json:"source" bson:"source"`
+	RequestID string `json:"request_id" bson:"request_id"`
+	SessionID int    `json:"session_id" bson:"session_id"`
+}
+
+// SetTrace sets the trace fields of the event
+func (e *InteractionEvent) SetTrace(trace InteractionTrace) {
+	e.Source, e.RequestID, e.SessionID = trace.Source, trace.RequestID, trace.SessionID
+}
+
+// StreamedInteraction is an interaction event published to the message broker. Anonymous
+// interactions carry the anonymous session ID instead of a user ID.
+type StreamedInteraction struct {
+	InteractionEvent
+	AnonymousID string          `json:"anonymous_id,omitempty"`
+	Engagement  *ViewEngagement `json:"engagement,omitempty"` // views only, when reported
+	Tenant      string          `json:"tenant,omitempty"`
+}
+
+// PurchaseEvent is published after a signed-in user's purchase is recorded
+type PurchaseEvent struct {
+	UserID    int    `json:"user_id"`
+	ProductID int    `json:"product_id"`
+	Tenant    string `json:"tenant,omitempty"`
+}
+
+// InteractionExportFilter represents options for exporting interaction events
+type InteractionExportFilter struct {
+	From       *time.Time
+	To         *time.Time
+	EventTypes []string
+	Limit      int
+	Cursor     string
+
+	// Decoded cursor position, set by the service layer
+	AfterTime *time.Time
+	AfterID   string
+}
+
+// InteractionExportBatch is a page of exported interaction events
+type InteractionExportBatch struct {
+	Events     []InteractionEvent `json:"events"`
+	Count      int                `json:"count"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// InteractionCounts is the number of views, likes and purchases of a product
+type InteractionCounts struct {
+	Views     int64 `json:"views" bson:"views"`
+	Likes     int64 `json:"likes" bson:"likes"`
+	Purchases int64 `json:"purchases" bson:"purchases"`
+}
+
+// Popularity weighs the interactions into a single score used to boost search results
+func (c InteractionCounts) Popularity() float64 {
+	return float64(c.Views) + 3*float64(c.Likes) + 5*float64(c.Purchases)
+}
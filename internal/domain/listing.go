@@ -0,0 +1,40 @@
+package domain
+
+// Product listings with their own ListingSettings
+const (
+	ListingProducts      = "products"       // the public catalog
+	ListingAdminProducts = "admin_products" // the admin catalog with cost prices
+	ListingSearch        = "search"         // relevance-ranked search, which ignores DefaultSort
+)
+
+// ListingSettings are the page size and order of a listing when a request sets none
+type ListingSettings struct {
+	DefaultLimit int
+	MaxLimit     int
+	DefaultSort  []SortField // nil for newest first
+
+	// SortFields are the sort keys requests may use, each mapped to its document field; a subset
+	// of ProductSortFields
+	SortFields map[string]string
+
+	// Totals above it may be estimated; 0 counts exactly
+	EstimateCountsAbove int64
+}
+
+// ListTotal is the number of items a listing matches. Large totals may be Estimated, from an
+// earlier count of the same listing.
+type ListTotal struct {
+	Count     int64
+	Estimated bool
+}
+
+// DefaultListingSettings apply to listings without configured settings
+var DefaultListingSettings = ListingSettings{DefaultLimit: 20, MaxLimit: 100, SortFields: ProductSortFields}
+
+// Limit returns the requested page size, the default if none was requested, capped at the maximum
+func (s ListingSettings) Limit(requested int) int {
+	if requested <= 0 {
+		return s.DefaultLimit
+	}
+	return min(requested, s.MaxLimit)
+}
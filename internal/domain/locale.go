@@ -0,0 +1,10 @@
+package domain
+
+import "regexp"
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// ValidLocale reports whether locale is a language code with an optional region, e.g. "ru" or "en-US"
+func ValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Maintenance is the read-only mode of the API: while it is enabled writes are rejected with
+// 503 Service Unavailable and reads keep working
+type Maintenance struct {
+	Enabled    bool      `json:"enabled" bson:"enabled"`
+	RetryAfter int       `json:"retry_after" bson:"retry_after" example:"300"` // seconds clients should wait before retrying writes
+	Forced     bool      `json:"forced,omitempty" bson:"-"`                    // enabled by configuration, which the API cannot switch off
+	UpdatedAt  time.Time `json:"updated_at,omitempty" bson:"updated_at"`
+}
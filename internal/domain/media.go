@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Kinds of private media, the top-level directories of the private media directory
+const (
+	MediaInvoices  = "invoices"
+	MediaDownloads = "downloads"
+	MediaOriginals = "originals"
+)
+
+// MediaKinds lists the private media kinds
+var MediaKinds = []string{MediaInvoices, MediaDownloads, MediaOriginals}
+
+// Variants generated from an uploaded product image, each fitting in a square of its size.
+// With a WebP encoder configured each also gets a WebP copy, named with ImageVariantWebPSuffix.
+var ImageVariantSizes = []struct {
+	Name string
+	Size int
+}{
+	{"thumb", 150},
+	{"medium", 600},
+	{"large", 1200},
+}
+
+// ImageVariantWebPSuffix is appended to the name of the WebP copy of a variant ("thumb_webp")
+const ImageVariantWebPSuffix = "_webp"
+
+// ProductImageJob asks for the variants of an uploaded product image to be generated. The
+// original is kept in the private media directory, so variants can be generated again.
+type ProductImageJob struct {
+	ProductID  int       `json:"product_id"`
+	Tenant     string    `json:"tenant,omitempty"`
+	Original   string    `json:"original"` // path in the private media directory of the tenant
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// SignedURL is a time-limited link to a private file
+type SignedURL struct {
+	Path      string    `json:"path" example:"invoices/2025/0001.pdf"`
+	URL       string    `json:"url" example:"/api/v1/media/private/invoices/2025/0001.pdf?expires=1735732800&signature=..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CleanMediaPath normalizes the path of a private file relative to the private media directory
+// and checks it names a file of a known kind without leaving the directory
+func CleanMediaPath(p string) (string, error) {
+	p = strings.TrimPrefix(p, "/")
+	if p == "" || strings.Contains(p, "\\") {
+		return "", fmt.Errorf("invalid media path %q: %w", p, ErrValidation)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid media path %q: %w", p, ErrValidation)
+		}
+	}
+
+	p = path.Clean(p)
+	kind, name, ok := strings.Cut(p, "/")
+	if !ok || name == "" {
+		return "", fmt.Errorf("media path must be <kind>/<file>: %w", ErrValidation)
+	}
+	for _, known := range MediaKinds {
+		if kind == known {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid media kind %q: %w", kind, ErrValidation)
+}
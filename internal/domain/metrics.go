@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// Live metrics topics of the admin dashboard
+const (
+	MetricsTopicOrders  = "orders"
+	MetricsTopicRevenue = "revenue"
+	MetricsTopicErrors  = "errors"
+)
+
+// MetricsTopics lists all live metrics topics
+var MetricsTopics = []string{MetricsTopicOrders, MetricsTopicRevenue, MetricsTopicErrors}
+
+// IsMetricsTopic reports whether topic is a known live metrics topic
+func IsMetricsTopic(topic string) bool {
+	for _, t := range MetricsTopics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// MetricsUpdate is a snapshot of one live metrics topic
+type MetricsUpdate struct {
+	Topic string      `json:"topic"`
+	Data  interface{} `json:"data"`
+	At    time.Time   `json:"at"`
+}
+
+// OrderMetrics counts purchases
+type OrderMetrics struct {
+	Today      int64 `json:"today"`
+	LastMinute int64 `json:"last_minute"`
+}
+
+// RevenueMetrics sums purchase amounts
+type RevenueMetrics struct {
+	Today      Money `json:"today"`
+	LastMinute Money `json:"last_minute"`
+}
+
+// ErrorMetrics describes HTTP server errors (5xx) over the last minute
+type ErrorMetrics struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// PurchaseTotals aggregates purchases over a period
+type PurchaseTotals struct {
+	Count   int64 `json:"count" bson:"count"`
+	Revenue Money `json:"revenue" bson:"revenue"`
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// Statuses of quarantined content
+const (
+	ModerationQuarantined = "quarantined"
+	ModerationApproved    = "approved"
+	ModerationRejected    = "rejected"
+)
+
+// Kinds of user-generated text that are moderated
+const (
+	ModerationSubjectProfile = "profile"
+)
+
+// ModerationFlag is why a field was flagged: a banned term or the categories the moderation
+// API flagged it for
+type ModerationFlag struct {
+	Field  string `json:"field" bson:"field"`
+	Reason string `json:"reason" bson:"reason"`
+}
+
+// ModerationItem is user-generated text quarantined because it was flagged. The flagged fields
+// aren't saved until a moderator approves them; rejecting them discards them.
+type ModerationItem struct {
+	ID         int               `json:"id" bson:"_id"`
+	Subject    string            `json:"subject" bson:"subject"`
+	SubjectID  int               `json:"subject_id" bson:"subject_id"`
+	UserID     int               `json:"user_id" bson:"user_id"` // the author
+	Fields     map[string]string `json:"fields" bson:"fields"`   // the flagged text by field
+	Flags      []ModerationFlag  `json:"flags" bson:"flags"`
+	Status     string            `json:"status" bson:"status"`
+	CreatedAt  time.Time         `json:"created_at" bson:"created_at"`
+	ReviewedBy int               `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time        `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	Note       string            `json:"note,omitempty" bson:"note,omitempty"`
+}
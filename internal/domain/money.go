@@ -0,0 +1,212 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencyExponents are the ISO 4217 currencies without two decimal places
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// ValidCurrency reports whether currency looks like an ISO 4217 code, e.g. "USD"
+func ValidCurrency(currency string) bool {
+	return currencyPattern.MatchString(currency)
+}
+
+// CurrencyExponent returns the number of decimal places of the currency's minor unit, e.g. 2
+// for USD (cents) and 0 for JPY
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Money is an amount in the minor units of a currency, e.g. {99999, "USD"} is $999.99. Amounts
+// are added and multiplied exactly; only ratios and scores use Float64. In JSON the amount is a
+// decimal string: {"amount": "999.99", "currency": "USD"}.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount" swaggertype:"string" example:"999.99"`
+	Currency string `json:"currency" bson:"currency" example:"USD"`
+}
+
+// NewMoney returns an amount of minor units of the currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal amount of the currency, such as "999.99" or "-5", without
+// rounding: more decimal places than the currency has are rejected
+func ParseMoney(amount, currency string) (Money, error) {
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("invalid currency %q: %w", currency, ErrValidation)
+	}
+	return parseMoney(amount, currency)
+}
+
+func parseMoney(amount, currency string) (Money, error) {
+	digits := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(strings.TrimPrefix(digits, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	exponent := CurrencyExponent(currency)
+	fraction = strings.TrimRight(fraction, "0")
+	if whole == "" || !allDigits(whole) || !allDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, ErrValidation)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%s amounts have at most %d decimal places: %w", currency, exponent, ErrValidation)
+	}
+
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, ErrValidation)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// MoneyFromFloat converts a floating point amount of the currency, rounding it to the minor unit.
+// It exists for data stored before amounts were kept in minor units.
+func MoneyFromFloat(amount float64, currency string) Money {
+	return Money{Amount: int64(math.Round(amount * math.Pow10(CurrencyExponent(currency)))), Currency: currency}
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal, e.g. "999.99"
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if exponent == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exponent, amount%scale)
+}
+
+// Float64 returns the amount in major units, for ratios and scores that don't need to be exact
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of the amounts. A zero amount without a currency takes the other's.
+func (m Money) Add(other Money) Money {
+	if m.Currency == "" {
+		m.Currency = other.Currency
+	}
+	m.Amount += other.Amount
+	return m
+}
+
+// Sub returns the difference of the amounts
+func (m Money) Sub(other Money) Money {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul returns the amount multiplied by a quantity
+func (m Money) Mul(quantity int) Money {
+	m.Amount *= int64(quantity)
+	return m
+}
+
+// Div returns the amount divided by n, rounded half to even to the minor unit as MongoDB's
+// $round does, or zero if n is zero
+func (m Money) Div(n int) Money {
+	if n == 0 {
+		return Money{Currency: m.Currency}
+	}
+	m.Amount = int64(math.RoundToEven(float64(m.Amount) / float64(n)))
+	return m
+}
+
+// MarshalJSON writes the amount as a decimal string, so clients never round it through floats
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.Currency})
+}
+
+// UnmarshalJSON reads {"amount": "999.99", "currency": "USD"}; the amount may also be a number.
+// An amount without a currency, such as an empty total, is read with two decimal places.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	amount, err := DecimalText(raw.Amount)
+	if err != nil {
+		return err
+	}
+	parse := ParseMoney
+	if raw.Currency == "" {
+		parse = parseMoney
+	}
+	parsed, err := parse(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// DecimalText returns the text of a JSON number or string holding a decimal amount, as written,
+// so it can be parsed without rounding
+func DecimalText(data json.RawMessage) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		err := json.Unmarshal(data, &s)
+		return s, err
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return "", fmt.Errorf("amount must be a decimal string or number: %w", ErrValidation)
+	}
+	if strings.ContainsAny(number.String(), "eE") {
+		return "", fmt.Errorf("amount %s must not use an exponent: %w", number, ErrValidation)
+	}
+	return number.String(), nil
+}
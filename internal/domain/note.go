@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Subjects a support note can be attached to
+const (
+	NoteSubjectUser  = "user"
+	NoteSubjectOrder = "order"
+)
+
+// MaxNoteLength is the longest note text accepted
+const MaxNoteLength = 5000
+
+// SupportNote is an internal note left by customer support on a user or an order (a purchase
+// record, identified by its event ID). Notes are only shown to staff.
+type SupportNote struct {
+	ID        int       `json:"id" bson:"_id"`
+	Subject   string    `json:"subject" bson:"subject"`
+	SubjectID string    `json:"subject_id" bson:"subject_id"`
+	AuthorID  int       `json:"author_id" bson:"author_id"`
+	Text      string    `json:"text" bson:"text"`
+	Pinned    bool      `json:"pinned" bson:"pinned"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks the note text, trimming surrounding whitespace
+func (n *SupportNote) Validate() error {
+	n.Text = strings.TrimSpace(n.Text)
+	if n.Text == "" {
+		return fmt.Errorf("note text is required: %w", ErrValidation)
+	}
+	if len(n.Text) > MaxNoteLength {
+		return fmt.Errorf("note text must be at most %d characters: %w", MaxNoteLength, ErrValidation)
+	}
+	return nil
+}
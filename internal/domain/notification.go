@@ -0,0 +1,21 @@
+package domain
+
+// Notification kinds. Marketing notifications are only delivered on channels the user opted in to.
+const (
+	NotificationTransactional = "transactional"
+	NotificationMarketing     = "marketing"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+)
+
+// Notification is a message to a user on one channel
+type Notification struct {
+	Kind    string
+	Channel string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Order is a purchase record as staff see it: one product bought by a user or guest, identified
+// by the event ID the interaction export and support notes give it
+type Order struct {
+	ID           string    `json:"id" bson:"_id"`
+	UserID       int       `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	AnonymousID  string    `json:"anonymous_id,omitempty" bson:"anonymous_id,omitempty"`
+	ProductID    int       `json:"product_id" bson:"product_id"`
+	ProductName  string    `json:"product_name,omitempty" bson:"product_name,omitempty"`
+	CategoryName string    `json:"category_name,omitempty" bson:"category_name,omitempty"`
+	Quantity     int       `json:"quantity" bson:"quantity"`
+	Price        Money     `json:"price" bson:"price_at_purchase"`
+	Total        Money     `json:"total" bson:"-"` // price times quantity
+	PurchasedAt  time.Time `json:"purchased_at" bson:"purchased_at"`
+	Source       string    `json:"source,omitempty" bson:"source,omitempty"`
+}
+
+// OrderFilter selects orders, newest first
+type OrderFilter struct {
+	ID     string // the order's event ID
+	UserID *int
+	From   *time.Time // purchased at or after
+	To     *time.Time // purchased before
+	Limit  int        // 0 for all
+	Offset int
+}
+
+// Validate checks the time range
+func (f *OrderFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("from must be before to: %w", ErrValidation)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEntry is an event recorded in the same transaction as the change it reports. The outbox
+// relay publishes it afterwards, so an event is never lost once its change is committed.
+type OutboxEntry struct {
+	ID          string
+	Topic       string
+	Payload     json.RawMessage
+	Attempts    int
+	LastError   string
+	CreatedAt   time.Time
+	DeliveredAt *time.Time
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Permission allows an action on a resource. Its name ("products:write") is what
+// access tokens carry in the scope claim.
+type Permission struct {
+	ID          int       `json:"id" bson:"_id"`
+	Resource    string    `json:"resource" bson:"resource"`
+	Action      string    `json:"action" bson:"action"`
+	Description string    `json:"description,omitempty" bson:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Name returns the permission in resource:action form
+func (p Permission) Name() string {
+	return p.Resource + ":" + p.Action
+}
+
+// PermissionWildcard matches any resource or action
+const PermissionWildcard = "*"
+
+// Built-in permissions checked by the API
+const (
+	PermissionProductsWrite        = "products:write"
+	PermissionCategoriesWrite      = "categories:write"
+	PermissionInteractionsExport   = "interactions:export"
+	PermissionPermissionsManage    = "permissions:manage"
+	PermissionMetricsRead          = "metrics:read"
+	PermissionOrdersReview         = "orders:review"
+	PermissionDatabaseManage       = "database:manage"
+	PermissionSegmentsManage       = "segments:manage"
+	PermissionSupportNotes         = "support_notes:manage"
+	PermissionUsersRead            = "users:read"
+	PermissionUsersManage          = "users:manage"
+	PermissionMediaSign            = "media:sign"
+	PermissionContentModerate      = "content:moderate"
+	PermissionPromotionsManage     = "promotions:manage"
+	PermissionCustomerGroupsManage = "customer_groups:manage"
+	PermissionAll                  = "*:*"
+)
+
+var permissionPartPattern = regexp.MustCompile(`^([a-z][a-z0-9_]*|\*)$`)
+
+// IsValidPermissionPart checks a resource or action name
+func IsValidPermissionPart(part string) bool {
+	return permissionPartPattern.MatchString(part)
+}
+
+// PermissionGrants reports whether a granted permission covers the required one.
+// Either part of the granted permission may be a wildcard, e.g. "products:*" or "*:*".
+func PermissionGrants(granted, required string) bool {
+	grantedResource, grantedAction, ok := strings.Cut(granted, ":")
+	if !ok {
+		return false
+	}
+	requiredResource, requiredAction, ok := strings.Cut(required, ":")
+	if !ok {
+		return false
+	}
+
+	return (grantedResource == PermissionWildcard || grantedResource == requiredResource) &&
+		(grantedAction == PermissionWildcard || grantedAction == requiredAction)
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxFavoriteCategories caps how many categories a user can mark as favorite
+const MaxFavoriteCategories = 20
+
+// Preferences are the per-user settings stored on the profile
+type Preferences struct {
+	Locale              string          `json:"locale,omitempty" bson:"locale,omitempty"`
+	Currency            string          `json:"currency,omitempty" bson:"currency,omitempty"`
+	Timezone            string          `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	FavoriteCategoryIDs []int           `json:"favorite_category_ids" bson:"favorite_category_ids,omitempty"`
+	MarketingOptIns     MarketingOptIns `json:"marketing_opt_ins" bson:"marketing_opt_ins"`
+	PromotionsOptOut    bool            `json:"promotions_opt_out" bson:"promotions_opt_out,omitempty"` // no birthday or anniversary promotions
+}
+
+// MarketingOptIns records which channels may receive marketing messages. Transactional
+// messages such as verification codes are always sent.
+type MarketingOptIns struct {
+	Email bool `json:"email" bson:"email"`
+	SMS   bool `json:"sms" bson:"sms"`
+}
+
+// Validate checks the locale (e.g. "en" or "en-US"), the ISO 4217 currency code, the IANA
+// timezone (e.g. "Asia/Almaty") and the number of favorite categories. Whether the categories
+// exist is checked by the service.
+func (p *Preferences) Validate() error {
+	if p.Locale != "" && !ValidLocale(p.Locale) {
+		return fmt.Errorf("invalid locale %q: %w", p.Locale, ErrValidation)
+	}
+	if p.Currency != "" && !ValidCurrency(p.Currency) {
+		return fmt.Errorf("invalid currency %q: %w", p.Currency, ErrValidation)
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+			return fmt.Errorf("invalid timezone %q: %w", p.Timezone, ErrValidation)
+		}
+	}
+	if len(p.FavoriteCategoryIDs) > MaxFavoriteCategories {
+		return fmt.Errorf("at most %d favorite categories are allowed: %w", MaxFavoriteCategories, ErrValidation)
+	}
+	return nil
+}
+
+// AllowsMarketing reports whether marketing messages may be sent on the channel
+func (p *Preferences) AllowsMarketing(channel string) bool {
+	switch channel {
+	case NotificationChannelEmail:
+		return p.MarketingOptIns.Email
+	case NotificationChannelSMS:
+		return p.MarketingOptIns.SMS
+	default:
+		return false
+	}
+}
+
+// IsFavoriteCategory reports whether the category is one of the user's favorites
+func (p *Preferences) IsFavoriteCategory(categoryID int) bool {
+	for _, id := range p.FavoriteCategoryIDs {
+		if id == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferencesUpdate is a partial preferences update; nil fields are left unchanged
+type PreferencesUpdate struct {
+	Locale              *string
+	Currency            *string
+	Timezone            *string
+	FavoriteCategoryIDs *[]int
+	MarketingEmail      *bool
+	MarketingSMS        *bool
+	PromotionsOptOut    *bool
+}
+
+// Location returns the user's timezone, or fallback if none is set
+func (p *Preferences) Location(fallback *time.Location) *time.Location {
+	if p.Timezone == "" {
+		return fallback
+	}
+	location, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fallback
+	}
+	return location
+}
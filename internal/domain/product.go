@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -10,12 +11,185 @@ type Product struct {
 	Name        string    `json:"name" bson:"name"`
 	Description string    `json:"description" bson:"description"`
 	CategoryID  *int      `json:"category_id,omitempty" bson:"category_id,omitempty"`
-	Price       float64   `json:"price" bson:"price"`
+	Price       Money     `json:"price" bson:"price"`
 	Stock       int       `json:"stock" bson:"stock"`
 	ImageURL    string    `json:"image_url,omitempty" bson:"image_url,omitempty"`
 	IsActive    bool      `json:"is_active" bson:"is_active"`
 	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+
+	// URLs of the resized copies of an uploaded image by variant name ("thumb", "medium_webp")
+	ImageVariants map[string]string `json:"image_variants,omitempty" bson:"image_variants,omitempty"`
+
+	// When the image was uploaded, so variants of an earlier upload never replace a later one
+	ImageUploadedAt *time.Time `json:"-" bson:"image_uploaded_at,omitempty"`
+
+	// The price before the last price change, and when it changed; nil if it never changed
+	PreviousPrice  *Money     `json:"previous_price,omitempty" bson:"previous_price,omitempty"`
+	PriceChangedAt *time.Time `json:"price_changed_at,omitempty" bson:"price_changed_at,omitempty"`
+
+	// What a unit cost to stock, for inventory valuation; zero if unknown. Kept out of responses,
+	// which customers see too.
+	CostPrice Money `json:"-" bson:"cost_price,omitempty"`
+
+	// Set to save a price below the cost price, such as for a clearance; only for the request
+	// that sets it, never stored
+	AllowBelowCost bool `json:"-" bson:"-"`
+
+	// Translations of name and description by locale; Name and Description are the default
+	Translations map[string]ProductTranslation `json:"translations,omitempty" bson:"translations,omitempty"`
+
+	// Volume prices for buying at least a quantity, by increasing quantity
+	PriceTiers []PriceTier `json:"price_tiers,omitempty" bson:"price_tiers,omitempty"`
+
+	// Order quantity rules, 0 for none: a purchase or cart item is at least MinOrderQty, at most
+	// MaxOrderQty and a multiple of QtyStep
+	MinOrderQty int `json:"min_order_qty,omitempty" bson:"min_order_qty,omitempty"`
+	MaxOrderQty int `json:"max_order_qty,omitempty" bson:"max_order_qty,omitempty"`
+	QtyStep     int `json:"qty_step,omitempty" bson:"qty_step,omitempty"`
+
+	// Caps the units each signed-in user can buy per window; nil for no cap. Guests can't buy
+	// a capped product.
+	PurchaseLimit *PurchaseLimit `json:"purchase_limit,omitempty" bson:"purchase_limit,omitempty"`
+
+	// The product's flash sale, set and cleared on its own so a product update never resets
+	// the units left; nil without one
+	FlashSale *FlashSale `json:"flash_sale,omitempty" bson:"flash_sale,omitempty"`
+}
+
+// QuantityError rejects a quantity the order quantity rules of a product don't allow. It
+// carries the allowed range so clients can offer a quantity that is.
+type QuantityError struct {
+	ProductID int
+	Quantity  int
+	Min       int // at least 1
+	Max       int // 0 for no maximum
+	Step      int // at least 1
+}
+
+func (e *QuantityError) Error() string {
+	allowed := fmt.Sprintf("at least %d", e.Min)
+	if e.Max > 0 {
+		allowed = fmt.Sprintf("between %d and %d", e.Min, e.Max)
+	}
+	if e.Step > 1 {
+		allowed += fmt.Sprintf(" in multiples of %d", e.Step)
+	}
+	return fmt.Sprintf("quantity %d of product %d must be %s: %v", e.Quantity, e.ProductID, allowed, ErrValidation)
+}
+
+func (e *QuantityError) Unwrap() error {
+	return ErrValidation
+}
+
+// CheckOrderQuantityRules checks that the rules allow some quantity: none is negative, the
+// minimum is at most the maximum and both are multiples of the step
+func (p *Product) CheckOrderQuantityRules() error {
+	switch {
+	case p.MinOrderQty < 0 || p.MaxOrderQty < 0 || p.QtyStep < 0:
+		return fmt.Errorf("min_order_qty, max_order_qty and qty_step must not be negative: %w", ErrValidation)
+	case p.MaxOrderQty > 0 && p.MinOrderQty > p.MaxOrderQty:
+		return fmt.Errorf("min_order_qty must not be greater than max_order_qty: %w", ErrValidation)
+	case p.QtyStep > 1 && (p.MinOrderQty%p.QtyStep != 0 || p.MaxOrderQty%p.QtyStep != 0):
+		return fmt.Errorf("min_order_qty and max_order_qty must be multiples of qty_step: %w", ErrValidation)
+	}
+	return nil
+}
+
+// CheckQuantity returns a *QuantityError if the order quantity rules don't allow quantity
+func (p *Product) CheckQuantity(quantity int) error {
+	rules := &QuantityError{
+		ProductID: p.ID,
+		Quantity:  quantity,
+		Min:       max(p.MinOrderQty, p.QtyStep, 1),
+		Max:       p.MaxOrderQty,
+		Step:      max(p.QtyStep, 1),
+	}
+	if quantity < rules.Min || (rules.Max > 0 && quantity > rules.Max) || quantity%rules.Step != 0 {
+		return rules
+	}
+	return nil
+}
+
+// PriceTier is the unit price of a product bought at least MinQuantity at a time
+type PriceTier struct {
+	MinQuantity int   `json:"min_quantity" bson:"min_quantity"`
+	Price       Money `json:"price" bson:"price"`
+}
+
+// CheckPriceTiers checks that the tiers start at 2 units and that each buys more units for less
+// than the one before it and the price. Like the price, a tier below the cost price is rejected
+// unless AllowBelowCost is set.
+func (p *Product) CheckPriceTiers() error {
+	quantity, price := 1, p.Price
+	for i, tier := range p.PriceTiers {
+		switch {
+		case tier.MinQuantity <= quantity:
+			return fmt.Errorf("price tier %d: min_quantity must be greater than %d: %w", i, quantity, ErrValidation)
+		case tier.Price.IsNegative():
+			return fmt.Errorf("price tier %d: price must not be negative: %w", i, ErrValidation)
+		case tier.Price.Amount >= price.Amount:
+			return fmt.Errorf("price tier %d: price must be less than %s: %w", i, price, ErrValidation)
+		case p.belowCost(tier.Price):
+			return fmt.Errorf("price tier %d: price %s is below cost price %s, set allow_below_cost to sell at a loss: %w", i, tier.Price, p.CostPrice, ErrValidation)
+		}
+		quantity, price = tier.MinQuantity, tier.Price
+	}
+	return nil
+}
+
+// UnitPrice returns the price of one unit bought quantity at a time: the price of the largest
+// tier the quantity reaches, if it is less than Price. Price may already be lowered, such as
+// by customer group pricing, in which case the lower price wins.
+func (p *Product) UnitPrice(quantity int) Money {
+	unit := p.Price
+	for _, tier := range p.PriceTiers {
+		if quantity < tier.MinQuantity {
+			break
+		}
+		if tier.Price.Amount < p.Price.Amount {
+			unit = tier.Price
+		}
+	}
+	return unit
+}
+
+// PriceDrop returns the share the price went down by at its last change, or 0 if it went up or
+// never changed
+func (p *Product) PriceDrop() float64 {
+	if p.PreviousPrice == nil || p.PreviousPrice.Amount <= 0 || p.Price.Amount >= p.PreviousPrice.Amount {
+		return 0
+	}
+	return float64(p.PreviousPrice.Amount-p.Price.Amount) / float64(p.PreviousPrice.Amount)
+}
+
+// CheckMargin rejects a price below the cost price unless AllowBelowCost is set. Products
+// without a cost price pass.
+func (p *Product) CheckMargin() error {
+	if !p.belowCost(p.Price) {
+		return nil
+	}
+	return fmt.Errorf("price %s is below cost price %s, set allow_below_cost to sell at a loss: %w", p.Price, p.CostPrice, ErrValidation)
+}
+
+// belowCost reports whether selling at price would be a loss the product doesn't allow
+func (p *Product) belowCost(price Money) bool {
+	return !p.CostPrice.IsZero() && price.Amount < p.CostPrice.Amount && !p.AllowBelowCost
+}
+
+// MarginRate returns the margin as a share of the price it was made at, or 0 without a price
+func MarginRate(margin, price Money) float64 {
+	if price.Amount <= 0 {
+		return 0
+	}
+	return float64(margin.Amount) / float64(price.Amount)
+}
+
+// ProductTranslation is the localized text of a product. An empty description falls back
+// to the default one.
+type ProductTranslation struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
 }
 
 // Category represents a product category
@@ -26,6 +200,25 @@ type Category struct {
 	ParentID    *int      `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
 	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+
+	// Derived from the category tree when the category is read, never stored
+	ProductCount *int64        `json:"product_count,omitempty" bson:"-"`
+	Breadcrumbs  []CategoryRef `json:"breadcrumbs,omitempty" bson:"-"`
+}
+
+// CategoryRef is one step of a category breadcrumb path
+type CategoryRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategoryMergeResult reports what a category merge moved into the target category
+type CategoryMergeResult struct {
+	SourceID        int   `json:"source_id"`
+	TargetID        int   `json:"target_id"`
+	MovedProducts   int64 `json:"moved_products"`
+	MovedCategories int64 `json:"moved_categories"`
+	MovedProductIDs []int `json:"-"`
 }
 
 // ProductWithCategory includes category details
@@ -34,26 +227,96 @@ type ProductWithCategory struct {
 	Name         string    `json:"name" bson:"name"`
 	Description  string    `json:"description" bson:"description"`
 	CategoryID   *int      `json:"category_id,omitempty" bson:"category_id,omitempty"`
-	Price        float64   `json:"price" bson:"price"`
+	Price        Money     `json:"price" bson:"price"`
 	Stock        int       `json:"stock" bson:"stock"`
 	ImageURL     string    `json:"image_url,omitempty" bson:"image_url,omitempty"`
 	IsActive     bool      `json:"is_active" bson:"is_active"`
 	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
 	CategoryName string    `json:"category_name,omitempty" bson:"category_name,omitempty"`
+
+	// Admins only, see AdminProduct
+	CostPrice Money `json:"-" bson:"cost_price,omitempty"`
+
+	ImageVariants map[string]string `json:"image_variants,omitempty" bson:"image_variants,omitempty"`
+
+	Translations map[string]ProductTranslation `json:"translations,omitempty" bson:"translations,omitempty"`
+
+	// Personalized fields, set only for authenticated requests
+	Liked     *bool `json:"liked,omitempty" bson:"-"`
+	Purchased *bool `json:"purchased,omitempty" bson:"-"`
+
+	// The price before the user's customer group pricing, when Price differs from it
+	ListPrice *Money `json:"list_price,omitempty" bson:"-"`
+
+	PriceTiers []PriceTier `json:"price_tiers,omitempty" bson:"price_tiers,omitempty"`
+
+	MinOrderQty int `json:"min_order_qty,omitempty" bson:"min_order_qty,omitempty"`
+	MaxOrderQty int `json:"max_order_qty,omitempty" bson:"max_order_qty,omitempty"`
+	QtyStep     int `json:"qty_step,omitempty" bson:"qty_step,omitempty"`
+
+	PurchaseLimit *PurchaseLimit `json:"purchase_limit,omitempty" bson:"purchase_limit,omitempty"`
+
+	FlashSale *FlashSale `json:"flash_sale,omitempty" bson:"flash_sale,omitempty"`
+
+	// Stock per warehouse, set only when a single product is fetched
+	Availability *ProductAvailability `json:"availability,omitempty" bson:"-"`
+}
+
+// Localize replaces the name and description with their translation into locale, keeping
+// the default text where there is none, and drops the translations
+func (p *ProductWithCategory) Localize(locale string) {
+	if translation, ok := p.Translations[locale]; ok {
+		if translation.Name != "" {
+			p.Name = translation.Name
+		}
+		if translation.Description != "" {
+			p.Description = translation.Description
+		}
+	}
+	p.Translations = nil
+}
+
+// AdminProduct is a product in the admin product list, with its cost price and margin
+type AdminProduct struct {
+	*ProductWithCategory
+	CostPrice  *Money   `json:"cost_price,omitempty"`
+	Margin     *Money   `json:"margin,omitempty"`      // price less cost price
+	MarginRate *float64 `json:"margin_rate,omitempty"` // margin as a share of the price
+}
+
+// NewAdminProduct adds the cost price and margin to a product; they are missing if it has no
+// cost price
+func NewAdminProduct(product *ProductWithCategory) *AdminProduct {
+	admin := &AdminProduct{ProductWithCategory: product}
+	if product.CostPrice.IsZero() {
+		return admin
+	}
+
+	cost := product.CostPrice
+	margin := product.Price.Sub(cost)
+	rate := MarginRate(margin, product.Price)
+	admin.CostPrice, admin.Margin, admin.MarginRate = &cost, &margin, &rate
+	return admin
 }
 
 // ProductFilter represents filtering options for products
 type ProductFilter struct {
 	CategoryID  *int
-	MinPrice    *float64
-	MaxPrice    *float64
+	MinPrice    *Money
+	MaxPrice    *Money
 	IsActive    *bool
 	SearchQuery string
-	Limit       int
-	Offset      int
-	SortBy      string // name, price, created_at
-	SortOrder   string // asc, desc
+	// NamePrefixes matches names with a word starting with any of the prefixes, case-insensitively
+	NamePrefixes []string
+	Limit        int
+	Offset       int
+	Sort         []SortField // keys from ProductSortFields, newest first if empty
+	Fields       []string    // selected ProductFields, all if empty
+	Conditions   []FilterCondition
+
+	// SkipCount makes ListWithCategories leave the total 0, for callers that know it already
+	SkipCount bool
 }
 
 // ProductStatistics represents aggregated product metrics
@@ -66,3 +329,11 @@ type ProductStatistics struct {
 	AverageRating float64 `bson:"average_rating" json:"average_rating"`
 	ReviewCount   int64   `bson:"review_count" json:"review_count"`
 }
+
+// StockEvent is pushed to live subscribers when a product's stock changes
+type StockEvent struct {
+	ProductID int       `json:"product_id"`
+	Stock     int       `json:"stock"`
+	InStock   bool      `json:"in_stock"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
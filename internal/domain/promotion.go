@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Occasions a promotion is granted on
+const (
+	PromotionBirthday    = "birthday"    // the user's date of birth, from the profile
+	PromotionAnniversary = "anniversary" // the day the user signed up, from the first year on
+)
+
+// MaxPromotionValidDays caps how long a promotion's coupon can be redeemed
+const MaxPromotionValidDays = 365
+
+// PromotionTemplate grants every user a coupon on their occasion and tells them about it.
+// Subject and Body may use the placeholders {first_name}, {code}, {percent_off} and {expires_on}.
+// Users who opted out of promotions get neither; the message is marketing, so it only reaches
+// users who opted in to its channel.
+type PromotionTemplate struct {
+	ID         int       `json:"id" bson:"_id"`
+	Name       string    `json:"name" bson:"name"`
+	Occasion   string    `json:"occasion" bson:"occasion"`
+	PercentOff int       `json:"percent_off" bson:"percent_off"`
+	ValidDays  int       `json:"valid_days" bson:"valid_days"` // days the coupon can be redeemed
+	Channel    string    `json:"channel" bson:"channel"`
+	Subject    string    `json:"subject" bson:"subject"`
+	Body       string    `json:"body" bson:"body"`
+	IsActive   bool      `json:"is_active" bson:"is_active"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks the occasion, the discount, the validity and the message of the template
+func (t *PromotionTemplate) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("promotion name is required: %w", ErrValidation)
+	}
+	if t.Occasion != PromotionBirthday && t.Occasion != PromotionAnniversary {
+		return fmt.Errorf("occasion must be %s or %s: %w", PromotionBirthday, PromotionAnniversary, ErrValidation)
+	}
+	if t.PercentOff < 1 || t.PercentOff > 100 {
+		return fmt.Errorf("percent_off must be between 1 and 100: %w", ErrValidation)
+	}
+	if t.ValidDays < 1 || t.ValidDays > MaxPromotionValidDays {
+		return fmt.Errorf("valid_days must be between 1 and %d: %w", MaxPromotionValidDays, ErrValidation)
+	}
+	if t.Channel != NotificationChannelEmail && t.Channel != NotificationChannelSMS {
+		return fmt.Errorf("channel must be %s or %s: %w", NotificationChannelEmail, NotificationChannelSMS, ErrValidation)
+	}
+	if strings.TrimSpace(t.Body) == "" {
+		return fmt.Errorf("promotion body is required: %w", ErrValidation)
+	}
+	return nil
+}
+
+// Notification renders the template's message for a recipient and their coupon
+func (t *PromotionTemplate) Notification(firstName string, coupon *Coupon) Notification {
+	if firstName == "" {
+		firstName = "there"
+	}
+	replacer := strings.NewReplacer(
+		"{first_name}", firstName,
+		"{code}", coupon.Code,
+		"{percent_off}", strconv.Itoa(coupon.PercentOff),
+		"{expires_on}", coupon.ExpiresAt.Format("2006-01-02"),
+	)
+	return Notification{
+		Kind:    NotificationMarketing,
+		Channel: t.Channel,
+		Subject: replacer.Replace(t.Subject),
+		Body:    replacer.Replace(t.Body),
+	}
+}
+
+// PromotionRecipient is a user whose occasion is around the current date
+type PromotionRecipient struct {
+	UserID      int         `bson:"_id"`
+	FirstName   string      `bson:"first_name"`
+	Date        time.Time   `bson:"date"` // date of birth or sign-up time
+	Preferences Preferences `bson:"preferences"`
+}
+
+// OccasionYear returns the year of the occasion the recipient has on day, or 0 if day is not
+// one. A date of birth is a calendar date and is taken as is; a sign-up time is taken in the
+// recipient's time zone, like day. Occasions on February 29 fall on February 28 in other years.
+func (r *PromotionRecipient) OccasionYear(occasion string, day time.Time) int {
+	date := r.Date
+	if occasion == PromotionAnniversary {
+		date = date.In(day.Location())
+		if date.Year() >= day.Year() {
+			return 0
+		}
+	}
+
+	month, dayOfMonth := date.Month(), date.Day()
+	if month == time.February && dayOfMonth == 29 && !isLeapYear(day.Year()) {
+		dayOfMonth = 28
+	}
+	if day.Month() != month || day.Day() != dayOfMonth {
+		return 0
+	}
+	return day.Year()
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// Coupon is a single-use discount on a checkout granted to a user. A template grants each user
+// one coupon per year.
+type Coupon struct {
+	ID         int        `json:"id" bson:"_id"`
+	Code       string     `json:"code" bson:"code"`
+	UserID     int        `json:"user_id" bson:"user_id"`
+	TemplateID int        `json:"template_id" bson:"template_id"`
+	Occasion   string     `json:"occasion" bson:"occasion"`
+	Year       int        `json:"year" bson:"year"`
+	PercentOff int        `json:"percent_off" bson:"percent_off"`
+	IssuedAt   time.Time  `json:"issued_at" bson:"issued_at"`
+	ExpiresAt  time.Time  `json:"expires_at" bson:"expires_at"`
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty" bson:"redeemed_at,omitempty"`
+}
+
+// Apply returns the price less the coupon's discount, which is rounded down to the minor unit
+func (c *Coupon) Apply(price Money) Money {
+	discount := price.Amount * int64(c.PercentOff) / 100
+	return Money{Amount: price.Amount - discount, Currency: price.Currency}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PurchaseLimit caps the units of a product each user can buy per window, such as for a
+// limited drop. Windows are consecutive fixed periods of WindowHours in UTC, so a 24-hour
+// window starts at midnight UTC.
+type PurchaseLimit struct {
+	Quantity    int `json:"quantity" bson:"quantity"`
+	WindowHours int `json:"window_hours" bson:"window_hours"`
+}
+
+// Validate checks that the limit allows a unit per window of at least an hour
+func (l *PurchaseLimit) Validate() error {
+	if l.Quantity < 1 {
+		return fmt.Errorf("purchase limit quantity must be at least 1: %w", ErrValidation)
+	}
+	if l.WindowHours < 1 {
+		return fmt.Errorf("purchase limit window_hours must be at least 1: %w", ErrValidation)
+	}
+	return nil
+}
+
+// Window returns the start and end of the window now is in
+func (l *PurchaseLimit) Window(now time.Time) (time.Time, time.Time) {
+	length := time.Duration(l.WindowHours) * time.Hour
+	start := now.UTC().Truncate(length)
+	return start, start.Add(length)
+}
+
+// PurchaseAllowance counts the units of a product a user bought in a purchase limit window.
+// It expires at the end of the window.
+type PurchaseAllowance struct {
+	ProductID   int       `bson:"product_id"`
+	UserID      int       `bson:"user_id"`
+	WindowStart time.Time `bson:"window_start"`
+	Quantity    int       `bson:"quantity"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// PurchaseLimitError rejects a purchase that would take a user over a product's purchase limit.
+// It carries what is left of the limit and when it resets.
+type PurchaseLimitError struct {
+	ProductID int
+	Quantity  int // requested
+	Limit     PurchaseLimit
+	Remaining int
+	ResetsAt  time.Time
+}
+
+func (e *PurchaseLimitError) Error() string {
+	return fmt.Sprintf("product %d is limited to %d per customer every %d hours, %d left until %s",
+		e.ProductID, e.Limit.Quantity, e.Limit.WindowHours, e.Remaining, e.ResetsAt.Format(time.RFC3339))
+}
@@ -1,13 +1,42 @@
 package domain
 
+import "time"
+
 // ProductRecommendation represents a recommended product with a score
 type ProductRecommendation struct {
-	ProductID   int     `json:"product_id" bson:"product_id"`
-	ProductName string  `json:"product_name" bson:"product_name"`
-	CategoryID  int     `json:"category_id" bson:"category_id"`
-	Price       float64 `json:"price" bson:"price"`
-	Score       float64 `json:"score" bson:"score"`   // Similarity/relevance score
-	Reason      string  `json:"reason" bson:"reason"` // Why recommended
+	ProductID   int                    `json:"product_id" bson:"product_id"`
+	ProductName string                 `json:"product_name" bson:"product_name"`
+	CategoryID  int                    `json:"category_id" bson:"category_id"`
+	Price       Money                  `json:"price" bson:"price"`
+	Score       float64                `json:"score" bson:"score"`                         // Similarity/relevance score
+	Reason      string                 `json:"reason" bson:"reason"`                       // Text of the strongest reason
+	Reasons     []RecommendationReason `json:"reasons,omitempty" bson:"reasons,omitempty"` // Strongest first
+}
+
+// Kinds of recommendation reasons
+const (
+	ReasonBoughtSimilar      = "bought_similar"      // users who bought a product the user bought chose it
+	ReasonLikedSimilar       = "liked_similar"       // users who liked a product the user liked chose it
+	ReasonViewedSimilar      = "viewed_similar"      // users who viewed a product the user viewed chose it
+	ReasonSimilarUsers       = "similar_users"       // users with similar interests chose it
+	ReasonPopularInCategory  = "popular_in_category" // popular in a category the user browses
+	ReasonPopular            = "popular"             // liked by many users
+	ReasonFavoriteCategory   = "favorite_category"   // in one of the user's favorite categories
+	ReasonInteractionPattern = "interaction_pattern" // matches the user's learned taste factors
+	ReasonAccessory          = "accessory"           // bought together with a product the user just bought
+)
+
+// RecommendationReason explains part of a recommendation's score. Weight is the share of the
+// score it contributed.
+type RecommendationReason struct {
+	Type         string  `json:"type" bson:"type"`
+	Text         string  `json:"text" bson:"text"`
+	ProductID    int     `json:"product_id,omitempty" bson:"product_id,omitempty"` // the user's product it relates to
+	ProductName  string  `json:"product_name,omitempty" bson:"product_name,omitempty"`
+	CategoryID   int     `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	CategoryName string  `json:"category_name,omitempty" bson:"category_name,omitempty"`
+	Users        int     `json:"users,omitempty" bson:"users,omitempty"` // users behind a popularity reason
+	Weight       float64 `json:"weight" bson:"weight"`
 }
 
 // RecommendationResponse is the API response structure
@@ -25,3 +54,91 @@ type UserSimilarity struct {
 	CommonLikes     int     `json:"common_likes"`
 	CommonViews     int     `json:"common_views"`
 }
+
+// Recommendation feedback types
+const (
+	FeedbackDismissed     = "dismissed"
+	FeedbackNotInterested = "not_interested"
+	FeedbackAlreadyOwn    = "already_own"
+)
+
+// RecommendationFeedback represents a user's negative feedback on a recommended product.
+// Products with feedback are excluded from the user's future recommendations.
+type RecommendationFeedback struct {
+	UserID    int       `json:"user_id" bson:"user_id"`
+	ProductID int       `json:"product_id" bson:"product_id"`
+	Type      string    `json:"type" bson:"type"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// IsValidFeedbackType checks if the feedback type is supported
+func IsValidFeedbackType(feedbackType string) bool {
+	switch feedbackType {
+	case FeedbackDismissed, FeedbackNotInterested, FeedbackAlreadyOwn:
+		return true
+	}
+	return false
+}
+
+// RecommendationClick represents a user clicking on a recommended product
+type RecommendationClick struct {
+	UserID    int       `json:"user_id" bson:"user_id"`
+	ProductID int       `json:"product_id" bson:"product_id"`
+	Algorithm string    `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
+	Position  int       `json:"position,omitempty" bson:"position,omitempty"`
+	ClickedAt time.Time `json:"clicked_at" bson:"clicked_at"`
+}
+
+// Recommendation algorithms
+const (
+	AlgorithmCollaborativeFiltering = "collaborative_filtering"
+	AlgorithmMatrixFactorization    = "matrix_factorization"
+	AlgorithmPopularityBased        = "popularity_based"
+)
+
+// FactorVector represents a latent factor vector learned by the matrix factorization model
+type FactorVector struct {
+	ID        int       `json:"id" bson:"_id"` // user ID or product ID
+	Factors   []float64 `json:"factors" bson:"factors"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// FactorModelStats summarizes a matrix factorization training run
+type FactorModelStats struct {
+	Users        int       `json:"users"`
+	Items        int       `json:"items"`
+	Interactions int       `json:"interactions"`
+	Factors      int       `json:"factors"`
+	Iterations   int       `json:"iterations"`
+	TrainedAt    time.Time `json:"trained_at"`
+}
+
+// BoughtTogether lists the products most often bought by the users who bought a product. It is
+// computed periodically from purchase history.
+type BoughtTogether struct {
+	ProductID  int                  `json:"product_id" bson:"_id"`
+	Buyers     int                  `json:"buyers" bson:"buyers"` // signed-in users who bought the product
+	Items      []BoughtTogetherItem `json:"items" bson:"items"`   // most often bought together first
+	ComputedAt time.Time            `json:"computed_at" bson:"computed_at"`
+}
+
+// BoughtTogetherItem is a product bought by Buyers of the users who bought another product
+type BoughtTogetherItem struct {
+	ProductID  int     `json:"product_id" bson:"product_id"`
+	Buyers     int     `json:"buyers" bson:"buyers"`
+	Confidence float64 `json:"confidence" bson:"confidence"` // share of the product's buyers who bought this one too
+}
+
+// FrequentlyBoughtTogether is the response of a product's frequently bought together products
+type FrequentlyBoughtTogether struct {
+	ProductID  int                     `json:"product_id"`
+	Products   []BoughtTogetherProduct `json:"products"`
+	ComputedAt *time.Time              `json:"computed_at,omitempty"` // nil until first computed
+}
+
+// BoughtTogetherProduct is an active product frequently bought together with another
+type BoughtTogetherProduct struct {
+	Product    *Product `json:"product"`
+	Buyers     int      `json:"buyers"`
+	Confidence float64  `json:"confidence"`
+}
@@ -0,0 +1,194 @@
+package domain
+
+import "time"
+
+// Scheduled reports
+const (
+	ReportDailySales        = "daily_sales"
+	ReportWeeklyTopProducts = "weekly_top_products"
+	ReportLowStock          = "low_stock"
+)
+
+// Report is a table generated for admins, emailed as HTML with a CSV copy attached
+type Report struct {
+	Name        string
+	Title       string
+	GeneratedAt time.Time
+	Summary     []ReportFigure
+	Columns     []string
+	Rows        [][]string
+}
+
+// ReportFigure is a labelled total shown above the table of a report
+type ReportFigure struct {
+	Label string
+	Value string
+}
+
+// ProductSales is what a product sold over a period. The margin is the revenue less the cost
+// price of the purchases recorded with one.
+type ProductSales struct {
+	ProductID   int    `json:"product_id" bson:"_id"`
+	Name        string `json:"name" bson:"name"`
+	Purchases   int    `json:"purchases" bson:"purchases"`
+	Units       int    `json:"units" bson:"units"`
+	Revenue     Money  `json:"revenue" bson:"revenue"`
+	GrossMargin Money  `json:"gross_margin" bson:"gross_margin"`
+}
+
+// Sales report groupings
+const (
+	SalesGroupDay      = "day"
+	SalesGroupWeek     = "week"
+	SalesGroupMonth    = "month"
+	SalesGroupCategory = "category"
+	SalesGroupProduct  = "product"
+)
+
+// IsTimeGrouping reports whether sales grouped this way are grouped by period
+func IsTimeGrouping(groupBy string) bool {
+	return groupBy == SalesGroupDay || groupBy == SalesGroupWeek || groupBy == SalesGroupMonth
+}
+
+// SalesReport is the revenue of the purchases in [From, To), grouped by period, category or
+// product
+type SalesReport struct {
+	GroupBy  string       `json:"group_by"`
+	From     time.Time    `json:"from"`
+	To       time.Time    `json:"to"`
+	Timezone string       `json:"timezone"` // periods start at midnight in this time zone, weeks on Monday
+	Groups   []SalesGroup `json:"groups"`
+	Totals   SalesTotals  `json:"totals"`
+}
+
+// SalesGroup is what sold in a period, or in a category or of a product
+type SalesGroup struct {
+	Period      *time.Time `json:"period,omitempty" bson:"period,omitempty"` // day, week, month: start of the period
+	ID          *int       `json:"id,omitempty" bson:"id,omitempty"`         // category, product; missing for uncategorized products
+	Name        string     `json:"name,omitempty" bson:"name,omitempty"`
+	SalesTotals `bson:",inline"`
+}
+
+// SalesTotals sums purchases. Net revenue is gross revenue less discounts and refunds, of
+// which there are none yet. Margins only cover the purchases recorded with a cost price; the
+// others are counted in UncostedPurchases.
+type SalesTotals struct {
+	Purchases         int     `json:"purchases" bson:"purchases"`
+	Units             int     `json:"units" bson:"units"`
+	GrossRevenue      Money   `json:"gross_revenue" bson:"gross_revenue"`
+	NetRevenue        Money   `json:"net_revenue" bson:"net_revenue"`
+	CostOfGoods       Money   `json:"cost_of_goods" bson:"cost_of_goods"`
+	GrossMargin       Money   `json:"gross_margin" bson:"gross_margin"` // revenue less cost of goods
+	MarginRate        float64 `json:"margin_rate" bson:"-"`             // gross margin as a share of its revenue
+	UncostedPurchases int     `json:"uncosted_purchases" bson:"uncosted_purchases"`
+}
+
+// SetMarginRate sets the margin rate from the gross margin and the cost of goods, whose sum is
+// the revenue of the costed purchases
+func (t *SalesTotals) SetMarginRate() {
+	t.MarginRate = MarginRate(t.GrossMargin, t.GrossMargin.Add(t.CostOfGoods))
+}
+
+// Retention report periods
+const (
+	RetentionPeriodWeek  = "week"
+	RetentionPeriodMonth = "month"
+)
+
+// RetentionReport is the share of each signup cohort active in each period after signing up,
+// laid out as a matrix for heatmaps: a row per cohort, a column per period since signup. Later
+// cohorts have fewer columns, as fewer of their periods have passed by To.
+type RetentionReport struct {
+	Period   string            `json:"period"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Timezone string            `json:"timezone"` // periods start at midnight in this time zone, weeks on Monday
+	Periods  int               `json:"periods"`  // columns of the first cohort
+	Cohorts  []RetentionCohort `json:"cohorts"`
+}
+
+// RetentionCohort is the users who signed up in a period and how many of them came back.
+// Column 0 is the signup period itself.
+type RetentionCohort struct {
+	Start     time.Time `json:"start"`
+	Size      int       `json:"size"`
+	Active    []int     `json:"active"`    // users who viewed, liked or bought in each period
+	Retention []float64 `json:"retention"` // Active as a share of Size
+}
+
+// CohortCount is the users of a signup cohort counted in the period Offset periods after it
+type CohortCount struct {
+	Cohort time.Time `bson:"cohort"`
+	Offset int       `bson:"offset"`
+	Users  int       `bson:"users"`
+}
+
+// InventoryReport values the stock on hand per category and lists the dead stock
+type InventoryReport struct {
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Categories    []InventoryCategory `json:"categories"` // highest retail value first
+	Totals        InventoryTotals     `json:"totals"`
+	DeadStockDays int                 `json:"dead_stock_days"`
+	DeadStock     DeadStock           `json:"dead_stock"`
+}
+
+// InventoryTotals sums the stock of products. Products without a cost price add nothing to
+// CostValue; UncostedProducts counts the ones in stock.
+type InventoryTotals struct {
+	Products         int   `json:"products" bson:"products"`
+	InStock          int   `json:"in_stock" bson:"in_stock"` // products with stock
+	Units            int   `json:"units" bson:"units"`
+	RetailValue      Money `json:"retail_value" bson:"retail_value"` // stock at the current price
+	CostValue        Money `json:"cost_value" bson:"cost_value"`     // stock at cost price
+	UncostedProducts int   `json:"uncosted_products" bson:"uncosted_products"`
+}
+
+// InventoryCategory is the stock of a category's products
+type InventoryCategory struct {
+	ID              *int   `json:"id,omitempty" bson:"id,omitempty"` // missing for uncategorized products
+	Name            string `json:"name,omitempty" bson:"name,omitempty"`
+	InventoryTotals `bson:",inline"`
+}
+
+// DeadStock is the products in stock that didn't sell over the dead stock period
+type DeadStock struct {
+	Products    []DeadStockProduct `json:"products"` // highest retail value first, up to the limit
+	Count       int                `json:"count" bson:"count"`
+	Units       int                `json:"units" bson:"units"`
+	RetailValue Money              `json:"retail_value" bson:"retail_value"`
+	CostValue   Money              `json:"cost_value" bson:"cost_value"`
+}
+
+// DeadStockProduct is a product in stock that didn't sell over the dead stock period. Products
+// added during it are not dead stock yet.
+type DeadStockProduct struct {
+	ProductID   int        `json:"product_id" bson:"_id"`
+	Name        string     `json:"name" bson:"name"`
+	CategoryID  *int       `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	Stock       int        `json:"stock" bson:"stock"`
+	RetailValue Money      `json:"retail_value" bson:"retail_value"`
+	CostValue   Money      `json:"cost_value" bson:"cost_value"`
+	LastSoldAt  *time.Time `json:"last_sold_at,omitempty" bson:"last_sold_at,omitempty"` // missing if never sold
+}
+
+// SearchReport sums the catalog searches in [From, To) to guide catalog improvements: what
+// customers look for, what they don't find and which searches lead them to a product
+type SearchReport struct {
+	From              time.Time         `json:"from"`
+	To                time.Time         `json:"to"`
+	Totals            SearchTermStats   `json:"totals"`              // over all terms; Term is empty
+	TopQueries        []SearchTermStats `json:"top_queries"`         // most searched first
+	ZeroResultQueries []SearchTermStats `json:"zero_result_queries"` // most searched first, counting only searches without results
+}
+
+// SearchTermStats sums the searches for a term. A search has zero results when it found nothing
+// as typed, even if a spelling correction then found something; Corrected counts those.
+type SearchTermStats struct {
+	Term             string  `json:"term,omitempty" bson:"_id"`
+	Searches         int64   `json:"searches" bson:"searches"`
+	ZeroResults      int64   `json:"zero_results" bson:"zero_results"`
+	Corrected        int64   `json:"corrected" bson:"corrected"`
+	AverageResults   float64 `json:"average_results" bson:"average_results"`
+	Clicks           int64   `json:"clicks" bson:"clicks"`        // searches a product was opened from
+	ClickThroughRate float64 `json:"click_through_rate" bson:"-"` // Clicks / Searches
+}
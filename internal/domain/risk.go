@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+// Risk signals a checkout is scored on
+const (
+	RiskSignalVelocity        = "velocity"
+	RiskSignalCountryMismatch = "country_mismatch"
+	RiskSignalLargeQuantity   = "large_quantity"
+)
+
+// Purchase sources a checkout can come from
+const (
+	PurchaseSourceProduct   = "product"
+	PurchaseSourceBundle    = "bundle"
+	PurchaseSourceCart      = "cart"
+	PurchaseSourceFlashSale = "flash_sale"
+)
+
+// Review statuses of a flagged order
+const (
+	RiskReviewPending  = "pending"
+	RiskReviewApproved = "approved"
+	RiskReviewRejected = "rejected"
+)
+
+// RiskSignal is a reason a checkout looks risky and the score it adds
+type RiskSignal struct {
+	Name   string `json:"name" bson:"name"`
+	Score  int    `json:"score" bson:"score"`
+	Detail string `json:"detail" bson:"detail"`
+}
+
+// PurchaseLine is a product bought in a checkout at its unit price
+type PurchaseLine struct {
+	ProductID int   `json:"product_id" bson:"product_id"`
+	Quantity  int   `json:"quantity" bson:"quantity"`
+	Price     Money `json:"price" bson:"price"`
+}
+
+// RiskCheck describes a completed checkout to be scored. Guests have an AnonymousID
+// instead of a UserID.
+type RiskCheck struct {
+	UserID      int
+	AnonymousID string
+	Source      string
+	SourceID    int
+	Lines       []PurchaseLine
+	Client      ClientInfo
+}
+
+// Amount returns the total of the checkout
+func (c RiskCheck) Amount() Money {
+	var amount Money
+	for _, line := range c.Lines {
+		amount = amount.Add(line.Price.Mul(line.Quantity))
+	}
+	return amount
+}
+
+// RiskReview is a checkout flagged for manual review. Flagging does not hold the order;
+// reviewers decide whether to fulfil or cancel it.
+type RiskReview struct {
+	ID          int            `json:"id" bson:"_id"`
+	UserID      int            `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	AnonymousID string         `json:"anonymous_id,omitempty" bson:"anonymous_id,omitempty"`
+	Source      string         `json:"source" bson:"source"`
+	SourceID    int            `json:"source_id,omitempty" bson:"source_id,omitempty"`
+	Lines       []PurchaseLine `json:"lines" bson:"lines"`
+	Amount      Money          `json:"amount" bson:"amount"`
+	Country     string         `json:"country,omitempty" bson:"country,omitempty"`
+	IPAddress   string         `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
+	Score       int            `json:"score" bson:"score"`
+	Signals     []RiskSignal   `json:"signals" bson:"signals"`
+	Status      string         `json:"status" bson:"status"`
+	CreatedAt   time.Time      `json:"created_at" bson:"created_at"`
+	ReviewedBy  int            `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time     `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	Note        string         `json:"note,omitempty" bson:"note,omitempty"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// Built-in role names
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+// Role represents a named set of privileges assigned to users. A role also grants the
+// permissions of the roles it inherits, and of the roles those inherit.
+type Role struct {
+	ID          int       `json:"id" bson:"_id"`
+	Name        string    `json:"name" bson:"name"`
+	Description string    `json:"description,omitempty" bson:"description,omitempty"`
+	Inherits    []int     `json:"inherits,omitempty" bson:"inherits,omitempty"` // IDs of the parent roles
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// IsValidRoleName checks a role name; names are carried in access tokens
+func IsValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// IsBuiltinRole reports whether the API relies on the role, so it can't be deleted
+func IsBuiltinRole(name string) bool {
+	return name == RoleAdmin || name == RoleModerator || name == RoleUser
+}
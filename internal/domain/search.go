@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ProductEventType is the kind of change a ProductEvent reports
+type ProductEventType string
+
+const (
+	ProductCreated ProductEventType = "created"
+	ProductUpdated ProductEventType = "updated"
+	ProductDeleted ProductEventType = "deleted"
+)
+
+// ProductEvent is published after a product is created, updated or deleted
+type ProductEvent struct {
+	Type      ProductEventType
+	ProductID int
+}
+
+// SearchQuery is a relevance-ranked catalog search
+type SearchQuery struct {
+	Query      string
+	CategoryID *int
+	Limit      int
+	Offset     int
+}
+
+// SearchHit is a matching product with its relevance score and highlighted fragments.
+// Highlights map field names to fragments with matches wrapped in <em> tags.
+type SearchHit struct {
+	Product    *ProductWithCategory `json:"product"`
+	Score      float64              `json:"score"`
+	Highlights map[string][]string  `json:"highlights,omitempty"`
+}
+
+// SearchResult is a page of search hits, best match first. Correction is set when the query
+// found nothing and was corrected or relaxed.
+type SearchResult struct {
+	Hits       []SearchHit
+	Total      int64
+	Correction *SearchCorrection
+}
+
+// SearchCorrection describes how a query without results was changed to find some. Suggestions
+// are did-you-mean queries built from the words of product names, best first; they are returned
+// even if none of them found anything either.
+type SearchCorrection struct {
+	OriginalQuery string   `json:"original_query"`
+	Query         string   `json:"query"` // the query the results are for
+	Suggestions   []string `json:"suggestions"`
+	// Relaxed is set when the results are products with a name word starting like a word of the query
+	Relaxed bool `json:"relaxed"`
+}
+
+// SearchLog records a catalog search for analytics: the query, its filters, how many products it
+// found and the first product opened from its results. Later pages of a search aren't logged again.
+type SearchLog struct {
+	ID          string `json:"id" bson:"_id"`
+	Query       string `json:"query" bson:"query"` // as typed
+	Term        string `json:"term" bson:"term"`   // the query as grouped in reports, see SearchTerm
+	CategoryID  *int   `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	ResultCount int64  `json:"result_count" bson:"result_count"`
+	// Corrected is set when the query found nothing as typed and the results are for its SearchCorrection
+	Corrected        bool       `json:"corrected" bson:"corrected"`
+	UserID           int        `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	RequestID        string     `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	ClickedProductID *int       `json:"clicked_product_id,omitempty" bson:"clicked_product_id,omitempty"`
+	ClickedAt        *time.Time `json:"clicked_at,omitempty" bson:"clicked_at,omitempty"`
+	SearchedAt       time.Time  `json:"searched_at" bson:"searched_at"`
+}
+
+// SearchTerm is the query lowercased with single spaces, so searches differing only in case
+// and spacing are counted together
+func SearchTerm(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// NameWords splits a product name into its lowercase words, on anything but letters and digits
+func NameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Kinds of results of a global search
+const (
+	GlobalSearchProducts   = "products"
+	GlobalSearchCategories = "categories"
+	GlobalSearchUsers      = "users"  // admins only
+	GlobalSearchOrders     = "orders" // admins only
+)
+
+// GlobalSearchQuery searches several kinds of entities at once, for a single search box
+type GlobalSearchQuery struct {
+	Query  string
+	Types  []string // kinds to search; all the caller may see if empty
+	Limit  int      // results per kind
+	Admin  bool     // the caller may see users
+	Orders bool     // the caller may see orders
+}
+
+// GlobalSearchHit is one match of a global search. Scores rank hits within their group; item
+// is the matching product, category, user or order.
+type GlobalSearchHit struct {
+	Type    string      `json:"type"`               // product, category, user or order
+	ID      int         `json:"id,omitempty"`       // of products, categories and users
+	OrderID string      `json:"order_id,omitempty"` // of orders, whose IDs are event IDs
+	Title   string      `json:"title"`
+	Score   float64     `json:"score"`
+	Item    interface{} `json:"item"`
+}
+
+// GlobalSearchGroup is the best matches of one kind, best first, and how many there are
+type GlobalSearchGroup struct {
+	Type  string            `json:"type"`
+	Total int64             `json:"total"`
+	Hits  []GlobalSearchHit `json:"hits"`
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxSegmentRules limits how many rules a segment combines
+const MaxSegmentRules = 20
+
+// Segment attributes. Profile attributes compare a field of the user's profile, ignoring case;
+// interaction attributes aggregate the user's interactions over the last WithinDays days, or
+// over all time when WithinDays is 0.
+const (
+	SegmentAttributeCountry = "country"
+	SegmentAttributeCity    = "city"
+	SegmentAttributeGender  = "gender"
+
+	SegmentAttributeViews     = "views"     // number of product views
+	SegmentAttributeLikes     = "likes"     // number of products liked
+	SegmentAttributePurchases = "purchases" // number of purchases
+	SegmentAttributeSpent     = "spent"     // total value of purchases
+)
+
+// Segment rule operators. Profile attributes take eq, ne and in; interaction attributes take
+// eq, gte and lte.
+const (
+	SegmentOperatorEq  = "eq"
+	SegmentOperatorNe  = "ne"
+	SegmentOperatorIn  = "in"
+	SegmentOperatorGte = "gte"
+	SegmentOperatorLte = "lte"
+)
+
+// Segment is a group of users matching all of its rules, e.g. "purchased in the last 30 days
+// and country is KZ". Membership is computed in the background and stored, so MemberCount and
+// EvaluatedAt describe the last evaluation.
+type Segment struct {
+	ID          int           `json:"id" bson:"_id"`
+	Name        string        `json:"name" bson:"name"`
+	Description string        `json:"description" bson:"description"`
+	Rules       []SegmentRule `json:"rules" bson:"rules"`
+	MemberCount int64         `json:"member_count" bson:"member_count"`
+	EvaluatedAt *time.Time    `json:"evaluated_at,omitempty" bson:"evaluated_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// SegmentRule is one condition on a user. Value is compared by eq and ne on profile attributes,
+// Values by in, and Threshold by the operators on interaction attributes.
+type SegmentRule struct {
+	Attribute  string   `json:"attribute" bson:"attribute"`
+	Operator   string   `json:"operator" bson:"operator"`
+	Value      string   `json:"value,omitempty" bson:"value,omitempty"`
+	Values     []string `json:"values,omitempty" bson:"values,omitempty"`
+	Threshold  float64  `json:"threshold,omitempty" bson:"threshold,omitempty"`
+	WithinDays int      `json:"within_days,omitempty" bson:"within_days,omitempty"`
+}
+
+// Validate checks the name and the rules of the segment
+func (s *Segment) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("segment name is required: %w", ErrValidation)
+	}
+	if len(s.Rules) == 0 {
+		return fmt.Errorf("a segment needs at least one rule: %w", ErrValidation)
+	}
+	if len(s.Rules) > MaxSegmentRules {
+		return fmt.Errorf("a segment has at most %d rules: %w", MaxSegmentRules, ErrValidation)
+	}
+	for i := range s.Rules {
+		if err := s.Rules[i].Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the operator and values suit the attribute
+func (r *SegmentRule) Validate() error {
+	switch {
+	case r.IsProfileRule():
+		if r.WithinDays != 0 || r.Threshold != 0 {
+			return fmt.Errorf("%s takes no threshold or within_days: %w", r.Attribute, ErrValidation)
+		}
+		switch r.Operator {
+		case SegmentOperatorEq, SegmentOperatorNe:
+			if r.Value == "" || len(r.Values) > 0 {
+				return fmt.Errorf("%s takes a value: %w", r.Operator, ErrValidation)
+			}
+		case SegmentOperatorIn:
+			if r.Value != "" || len(r.Values) == 0 {
+				return fmt.Errorf("in takes a list of values: %w", ErrValidation)
+			}
+		default:
+			return fmt.Errorf("invalid operator %q for %s: %w", r.Operator, r.Attribute, ErrValidation)
+		}
+
+	case r.IsInteractionRule():
+		if r.Value != "" || len(r.Values) > 0 {
+			return fmt.Errorf("%s takes a threshold: %w", r.Attribute, ErrValidation)
+		}
+		switch r.Operator {
+		case SegmentOperatorEq, SegmentOperatorGte, SegmentOperatorLte:
+		default:
+			return fmt.Errorf("invalid operator %q for %s: %w", r.Operator, r.Attribute, ErrValidation)
+		}
+		if r.Threshold < 0 {
+			return fmt.Errorf("threshold must not be negative: %w", ErrValidation)
+		}
+		if r.WithinDays < 0 {
+			return fmt.Errorf("within_days must not be negative: %w", ErrValidation)
+		}
+
+	default:
+		return fmt.Errorf("invalid attribute %q: %w", r.Attribute, ErrValidation)
+	}
+
+	return nil
+}
+
+// IsProfileRule reports whether the rule compares a profile field
+func (r *SegmentRule) IsProfileRule() bool {
+	switch r.Attribute {
+	case SegmentAttributeCountry, SegmentAttributeCity, SegmentAttributeGender:
+		return true
+	}
+	return false
+}
+
+// IsInteractionRule reports whether the rule compares an aggregate of the user's interactions
+func (r *SegmentRule) IsInteractionRule() bool {
+	switch r.Attribute {
+	case SegmentAttributeViews, SegmentAttributeLikes, SegmentAttributePurchases, SegmentAttributeSpent:
+		return true
+	}
+	return false
+}
+
+// Since returns the start of the window an interaction rule aggregates, or nil for all time
+func (r *SegmentRule) Since(now time.Time) *time.Time {
+	if r.WithinDays == 0 {
+		return nil
+	}
+	since := now.AddDate(0, 0, -r.WithinDays)
+	return &since
+}
+
+// MatchProfile reports whether a profile rule holds for the user's attributes
+func (r *SegmentRule) MatchProfile(user *SegmentUser) bool {
+	var value string
+	switch r.Attribute {
+	case SegmentAttributeCountry:
+		value = user.Country
+	case SegmentAttributeCity:
+		value = user.City
+	case SegmentAttributeGender:
+		value = user.Gender
+	}
+
+	switch r.Operator {
+	case SegmentOperatorEq:
+		return strings.EqualFold(value, r.Value)
+	case SegmentOperatorNe:
+		return !strings.EqualFold(value, r.Value)
+	case SegmentOperatorIn:
+		for _, candidate := range r.Values {
+			if strings.EqualFold(value, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchAggregate reports whether an interaction rule holds for the user's aggregate, which is
+// 0 for users without interactions in the window
+func (r *SegmentRule) MatchAggregate(aggregate float64) bool {
+	switch r.Operator {
+	case SegmentOperatorEq:
+		return aggregate == r.Threshold
+	case SegmentOperatorGte:
+		return aggregate >= r.Threshold
+	case SegmentOperatorLte:
+		return aggregate <= r.Threshold
+	}
+	return false
+}
+
+// SegmentUser holds the profile attributes segment rules are evaluated on; users without a
+// profile have them empty
+type SegmentUser struct {
+	UserID  int    `bson:"_id"`
+	Country string `bson:"country"`
+	City    string `bson:"city"`
+	Gender  string `bson:"gender"`
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+)
+
+// Session represents a device a user is logged in from.
+// Every login creates a session; refresh tokens are bound to it and rotated on use.
+type Session struct {
+	ID             int        `json:"id" bson:"_id"`
+	UserID         int        `json:"user_id" bson:"user_id"`
+	RefreshTokenID string     `json:"-" bson:"refresh_token_id"`
+	UserAgent      string     `json:"user_agent" bson:"user_agent"`
+	IPAddress      string     `json:"ip_address" bson:"ip_address"`
+	CreatedAt      time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt     time.Time  `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt      time.Time  `json:"expires_at" bson:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// ClientInfo describes the device a request comes from. Country is the ISO code of the
+// country the request comes from, when the CDN or proxy in front of the API sets it.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+	Country   string
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField is one key of a multi-field sort
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// ProductSortFields is the allowlist of product sort keys, mapping each to its document field
+var ProductSortFields = map[string]string{
+	"id":         "_id",
+	"name":       "name",
+	"price":      "price.amount",
+	"stock":      "stock",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// ParseSort parses a sort specification such as "-price,name" (a leading "-" means descending)
+// and checks every key against the allowed fields. An empty specification yields nil.
+func ParseSort(raw string, allowed map[string]string) ([]SortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	var sort []SortField
+	for _, key := range strings.Split(raw, ",") {
+		key = strings.TrimSpace(key)
+		field := SortField{Field: strings.TrimLeft(key, "+-"), Descending: strings.HasPrefix(key, "-")}
+
+		if _, ok := allowed[field.Field]; !ok {
+			return nil, fmt.Errorf("cannot sort by %q: %w", key, ErrValidation)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("duplicate sort key %q: %w", field.Field, ErrValidation)
+		}
+		seen[field.Field] = true
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stock adjustment reasons. Initial, purchase and transfer adjustments are recorded by the API
+// itself, the others are manual adjustments made by staff.
+const (
+	StockReasonInitial    = "initial"
+	StockReasonPurchase   = "purchase"
+	StockReasonTransfer   = "transfer"
+	StockReasonReturn     = "return"
+	StockReasonRestock    = "restock"
+	StockReasonDamage     = "damage"
+	StockReasonCorrection = "correction"
+)
+
+// ManualStockReasons lists the reasons a manual stock adjustment may give
+var ManualStockReasons = []string{StockReasonReturn, StockReasonRestock, StockReasonDamage, StockReasonCorrection}
+
+// MaxStockNoteLength limits the note of a stock adjustment
+const MaxStockNoteLength = 500
+
+// StockAdjustment is an entry of a product's stock ledger. Every change to a product's stock
+// is recorded, so the current stock can be reconciled against the sum of the deltas.
+// WarehouseID is the location whose quantity changed, 0 for unallocated stock; StockAfter is
+// the product's total stock either way. A transfer is recorded as a pair of adjustments.
+type StockAdjustment struct {
+	ID          int       `json:"id" bson:"_id"`
+	ProductID   int       `json:"product_id" bson:"product_id"`
+	WarehouseID int       `json:"warehouse_id,omitempty" bson:"warehouse_id,omitempty"`
+	Delta       int       `json:"delta" bson:"delta"`
+	Reason      string    `json:"reason" bson:"reason"`
+	Note        string    `json:"note,omitempty" bson:"note,omitempty"`
+	ActorID     int       `json:"actor_id,omitempty" bson:"actor_id,omitempty"` // user who made the change, 0 for guests
+	StockAfter  int       `json:"stock_after" bson:"stock_after"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ValidateManual checks an adjustment made through the stock adjustments API
+func (a *StockAdjustment) ValidateManual() error {
+	if a.Delta == 0 {
+		return fmt.Errorf("delta must not be 0: %w", ErrValidation)
+	}
+	if !isManualStockReason(a.Reason) {
+		return fmt.Errorf("invalid reason %q: %w", a.Reason, ErrValidation)
+	}
+	if len(a.Note) > MaxStockNoteLength {
+		return fmt.Errorf("note must be at most %d characters: %w", MaxStockNoteLength, ErrValidation)
+	}
+	return nil
+}
+
+func isManualStockReason(reason string) bool {
+	for _, manual := range ManualStockReasons {
+		if manual == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// StockReconciliation compares a product's stock with its ledger. Unaccounted is the stock
+// not explained by the ledger, e.g. stock set before the ledger existed.
+type StockReconciliation struct {
+	ProductID   int            `json:"product_id"`
+	Stock       int            `json:"stock"`
+	LedgerStock int            `json:"ledger_stock"`
+	Unaccounted int            `json:"unaccounted"`
+	ByReason    map[string]int `json:"by_reason"`
+}
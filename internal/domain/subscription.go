@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Subscription renewal intervals
+const (
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// Subscription statuses. A past_due subscription failed its last renewal and is retried;
+// paused ones are skipped until resumed and cancelled ones never renew again.
+const (
+	SubscriptionActive    = "active"
+	SubscriptionPastDue   = "past_due"
+	SubscriptionPaused    = "paused"
+	SubscriptionCancelled = "cancelled"
+)
+
+// SubscriptionPlan offers a product as a recurring purchase, at Price per unit each interval
+type SubscriptionPlan struct {
+	ID        int       `json:"id" bson:"_id"`
+	ProductID int       `json:"product_id" bson:"product_id"`
+	Interval  string    `json:"interval" bson:"interval"`
+	Price     Money     `json:"price" bson:"price"`
+	IsActive  bool      `json:"is_active" bson:"is_active"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Validate checks the interval and price
+func (p *SubscriptionPlan) Validate() error {
+	if !validInterval(p.Interval) {
+		return fmt.Errorf("invalid interval %q: %w", p.Interval, ErrValidation)
+	}
+	if p.Price.Amount <= 0 {
+		return fmt.Errorf("price must be greater than 0: %w", ErrValidation)
+	}
+	return nil
+}
+
+func validInterval(interval string) bool {
+	switch interval {
+	case IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// NextRenewal returns when a period of the interval starting at from ends
+func NextRenewal(interval string, from time.Time) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return from.AddDate(0, 0, 7)
+	case IntervalYear:
+		return from.AddDate(1, 0, 0)
+	default:
+		return from.AddDate(0, 1, 0)
+	}
+}
+
+// Subscription is a user's recurring purchase of a product. Interval and Price are copied
+// from the plan when subscribing, so later plan changes do not affect it.
+type Subscription struct {
+	ID             int        `json:"id" bson:"_id"`
+	UserID         int        `json:"user_id" bson:"user_id"`
+	ProductID      int        `json:"product_id" bson:"product_id"`
+	PlanID         int        `json:"plan_id" bson:"plan_id"`
+	Interval       string     `json:"interval" bson:"interval"`
+	Price          Money      `json:"price" bson:"price"`
+	Quantity       int        `json:"quantity" bson:"quantity"`
+	Status         string     `json:"status" bson:"status"`
+	NextRenewalAt  time.Time  `json:"next_renewal_at" bson:"next_renewal_at"`
+	LastRenewedAt  *time.Time `json:"last_renewed_at,omitempty" bson:"last_renewed_at,omitempty"`
+	LastChargeID   string     `json:"last_charge_id,omitempty" bson:"last_charge_id,omitempty"`
+	FailedAttempts int        `json:"failed_attempts" bson:"failed_attempts"`
+	LastError      string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" bson:"updated_at"`
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty" bson:"cancelled_at,omitempty"`
+}
+
+// Renewable reports whether the renewal job should renew the subscription when due
+func (s *Subscription) Renewable() bool {
+	return s.Status == SubscriptionActive || s.Status == SubscriptionPastDue
+}
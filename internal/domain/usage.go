@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// UsagePeriodLayout formats the calendar month (UTC) API usage is counted in
+const UsagePeriodLayout = "2006-01"
+
+// APIUsage is how many API requests a user made in a calendar month against their quota
+type APIUsage struct {
+	UserID    int       `json:"user_id" bson:"user_id"`
+	Period    string    `json:"period" bson:"period" example:"2026-10"`
+	Requests  int64     `json:"requests" bson:"requests"`
+	Limit     *int64    `json:"limit" bson:"-"`     // nil when the user has no quota
+	Remaining *int64    `json:"remaining" bson:"-"` // nil when the user has no quota
+	ResetsAt  time.Time `json:"resets_at" bson:"-"`
+}
+
+// UsagePeriod returns the period of a time and when the next one starts
+func UsagePeriod(t time.Time) (string, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.Format(UsagePeriodLayout), start.AddDate(0, 1, 0)
+}
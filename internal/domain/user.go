@@ -4,14 +4,48 @@ import (
 	"time"
 )
 
+// User statuses. Suspended and deleted users can't sign in; deleted accounts are kept, so they
+// can be reactivated.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+)
+
+// UserStatusTransitions lists the statuses a user can be moved to from each status
+var UserStatusTransitions = map[string][]string{
+	UserStatusActive:    {UserStatusSuspended, UserStatusDeleted},
+	UserStatusSuspended: {UserStatusActive, UserStatusDeleted},
+	UserStatusDeleted:   {UserStatusActive},
+}
+
+// MaxStatusReasonLength is the longest reason accepted for a status change, in characters
+const MaxStatusReasonLength = 500
+
 type User struct {
 	ID           int        `json:"id" bson:"_id"`
 	Email        string     `json:"email" bson:"email"`
 	PasswordHash string     `json:"-" bson:"password_hash"`
 	Status       string     `json:"status" bson:"status"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+	TokenVersion int        `json:"-" bson:"token_version"`
 	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at" bson:"updated_at"`
+
+	// The last status change: why, when and by whom
+	StatusReason    string     `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
+	StatusChangedBy *int       `json:"status_changed_by,omitempty" bson:"status_changed_by,omitempty"`
+
+	// The customer group whose prices the user pays; nil for list prices
+	CustomerGroupID *int `json:"customer_group_id,omitempty" bson:"customer_group_id,omitempty"`
+}
+
+// UserStatusChange moves a user to another status
+type UserStatusChange struct {
+	Status    string
+	Reason    string
+	ChangedBy int
 }
 
 type LoginRequest struct {
@@ -19,9 +53,27 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// Token types, carried in the typ claim so a refresh token can't be used as an access token or
+// the other way round
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type TokenClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	Type         string   `json:"typ"`
+	UserID       string   `json:"user_id"`
+	Email        string   `json:"email"`
+	SessionID    int      `json:"sid,omitempty"`
+	TokenID      string   `json:"jti,omitempty"`
+	TokenVersion int      `json:"ver"`
+	Roles        []string `json:"roles,omitempty"`
+	Scopes       []string `json:"scope,omitempty"`
+	TenantID     string   `json:"tid,omitempty"`
+
+	// AuthTime is when the user last entered their password (Unix seconds); tokens refreshed
+	// since keep the time of the original sign-in
+	AuthTime int64 `json:"auth_time,omitempty"`
 }
 
 type Token struct {
@@ -31,3 +83,77 @@ type Token struct {
 	ExpiresIn    int64  `json:"expires_in"`
 	User         *User  `json:"user"`
 }
+
+// SudoToken is a short-lived access token issued after the user enters their password again,
+// which sensitive operations such as deleting the account require
+type SudoToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// EmailChangeRequest is a pending change of a user's email address.
+// The address is switched only after the token sent to the new address is confirmed.
+type EmailChangeRequest struct {
+	UserID    int       `json:"user_id" bson:"_id"`
+	NewEmail  string    `json:"new_email" bson:"new_email"`
+	TokenHash string    `json:"-" bson:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// JSONWebKey is a public key published in the JWKS document (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP curve
+	X   string `json:"x,omitempty"`   // OKP public key
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// CustomerValue is what a user has spent over their lifetime. Each purchase record counts as
+// an order.
+type CustomerValue struct {
+	UserID            int        `json:"user_id" bson:"user_id"`
+	TotalSpend        Money      `json:"total_spend" bson:"total_spend"`
+	Orders            int        `json:"orders" bson:"orders"`
+	AverageOrderValue Money      `json:"average_order_value" bson:"average_order_value"`
+	FirstPurchaseAt   *time.Time `json:"first_purchase_at,omitempty" bson:"first_purchase_at,omitempty"`
+	LastPurchaseAt    *time.Time `json:"last_purchase_at,omitempty" bson:"last_purchase_at,omitempty"`
+}
+
+// AdminUser is a user in the admin user list, with their lifetime value
+type AdminUser struct {
+	User `bson:",inline"`
+	LTV  CustomerValue `json:"ltv" bson:"ltv"`
+}
+
+// UserSortFields is the allowlist of admin user list sort keys, mapping each to its document
+// field. The lifetime value keys sort by the LTV of every matching user, so they are slower.
+var UserSortFields = map[string]string{
+	"id":                  "_id",
+	"email":               "email",
+	"created_at":          "created_at",
+	"last_login_at":       "last_login_at",
+	"total_spend":         "ltv.total_spend.amount",
+	"orders":              "ltv.orders",
+	"average_order_value": "ltv.average_order_value.amount",
+	"last_purchase_at":    "ltv.last_purchase_at",
+}
+
+// UserFilter selects users for the admin user list
+type UserFilter struct {
+	Email  string // part of the email, case-insensitive
+	Status string
+	Sort   []SortField // keys from UserSortFields, newest first if empty
+	Limit  int
+	Offset int
+}
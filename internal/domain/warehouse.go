@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Warehouse is a location that holds inventory
+type Warehouse struct {
+	ID        int       `json:"id" bson:"_id"`
+	Code      string    `json:"code" bson:"code"`
+	Name      string    `json:"name" bson:"name"`
+	Address   string    `json:"address,omitempty" bson:"address,omitempty"`
+	IsActive  bool      `json:"is_active" bson:"is_active"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+var warehouseCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{1,19}$`)
+
+// Validate checks the code (2-20 uppercase letters, digits and dashes, e.g. "ALA-1") and name
+func (w *Warehouse) Validate() error {
+	if !warehouseCodePattern.MatchString(w.Code) {
+		return fmt.Errorf("invalid warehouse code %q: %w", w.Code, ErrValidation)
+	}
+	if w.Name == "" {
+		return fmt.Errorf("warehouse name is required: %w", ErrValidation)
+	}
+	return nil
+}
+
+// InventoryLevel is the quantity of a product held at a warehouse
+type InventoryLevel struct {
+	ProductID   int       `json:"product_id" bson:"product_id"`
+	WarehouseID int       `json:"warehouse_id" bson:"warehouse_id"`
+	Quantity    int       `json:"quantity" bson:"quantity"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// LocationStock is a product's quantity at one warehouse, with the warehouse details
+type LocationStock struct {
+	WarehouseID int    `json:"warehouse_id" bson:"warehouse_id"`
+	Code        string `json:"code" bson:"code"`
+	Name        string `json:"name" bson:"name"`
+	Quantity    int    `json:"quantity" bson:"quantity"`
+	IsActive    bool   `json:"-" bson:"is_active"`
+}
+
+// ProductAvailability breaks a product's stock down by location. Unallocated is stock not
+// assigned to any warehouse, such as stock set before warehouses were introduced.
+type ProductAvailability struct {
+	ProductID   int             `json:"product_id"`
+	Total       int             `json:"total"`
+	Unallocated int             `json:"unallocated"`
+	Locations   []LocationStock `json:"locations"`
+}
+
+// StockTransfer moves stock of a product between warehouses. A FromWarehouseID of 0 allocates
+// unallocated stock to the destination.
+type StockTransfer struct {
+	ProductID       int
+	FromWarehouseID int
+	ToWarehouseID   int
+	Quantity        int
+	Note            string
+	ActorID         int
+}
+
+// Validate checks the quantity, the locations and the note
+func (t *StockTransfer) Validate() error {
+	if t.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than 0: %w", ErrValidation)
+	}
+	if t.ToWarehouseID <= 0 {
+		return fmt.Errorf("to_warehouse_id is required: %w", ErrValidation)
+	}
+	if t.FromWarehouseID == t.ToWarehouseID {
+		return fmt.Errorf("cannot transfer to the same warehouse: %w", ErrValidation)
+	}
+	if len(t.Note) > MaxStockNoteLength {
+		return fmt.Errorf("note must be at most %d characters: %w", MaxStockNoteLength, ErrValidation)
+	}
+	return nil
+}
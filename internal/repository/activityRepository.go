@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type ActivityRepository interface {
+	GetActivity(ctx context.Context, filter domain.ActivityFilter) ([]domain.ActivityItem, int64, error)
+
+	// GetAdminActivity returns a page of the admin activity feed, newest first, and its total
+	GetAdminActivity(ctx context.Context, filter domain.AdminActivityFilter) ([]domain.AdminActivityItem, int64, error)
+}
+
+type activityRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewActivityRepository(db *mongodb.MongoDB) ActivityRepository {
+	return &activityRepository{db: db}
+}
+
+// activitySources describes how each collection maps onto ActivityItem
+var activitySources = map[string]struct {
+	collection string
+	timeField  string
+	project    bson.M
+}{
+	domain.ActivityView: {
+		collection: "user_product_views",
+		timeField:  "viewed_at",
+		project:    bson.M{"product_id": 1},
+	},
+	domain.ActivityLike: {
+		collection: "user_product_likes",
+		timeField:  "liked_at",
+		project:    bson.M{"product_id": 1},
+	},
+	domain.ActivityPurchase: {
+		collection: "user_product_purchases",
+		timeField:  "purchased_at",
+		project:    bson.M{"product_id": 1, "quantity": 1, "price": "$price_at_purchase"},
+	},
+	domain.ActivityProfileChange: {
+		collection: "profile_changes",
+		timeField:  "changed_at",
+		project:    bson.M{"fields": 1},
+	},
+}
+
+// GetActivity merges the user's activity from all selected sources with $unionWith into one
+// timeline, newest first, and returns a page of it with the total count
+func (r *activityRepository) GetActivity(ctx context.Context, filter domain.ActivityFilter) ([]domain.ActivityItem, int64, error) {
+	types := filter.Types
+	if len(types) == 0 {
+		types = domain.ActivityTypes
+	}
+
+	var first string
+	var pipeline bson.A
+	for _, activityType := range types {
+		source, ok := activitySources[activityType]
+		if !ok {
+			return nil, 0, fmt.Errorf("unknown activity type %q: %w", activityType, domain.ErrValidation)
+		}
+
+		stages := activityStages(filter.UserID, activityType)
+		if first == "" {
+			first = source.collection
+			pipeline = append(pipeline, stages...)
+			continue
+		}
+		pipeline = append(pipeline, bson.M{"$unionWith": bson.M{
+			"coll":     source.collection,
+			"pipeline": stages,
+		}})
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: -1}}},
+		bson.M{"$facet": bson.M{
+			"items": bson.A{
+				bson.M{"$skip": filter.Offset},
+				bson.M{"$limit": filter.Limit},
+				bson.M{"$lookup": bson.M{
+					"from":         "products",
+					"localField":   "product_id",
+					"foreignField": "_id",
+					"as":           "product",
+				}},
+				bson.M{"$addFields": bson.M{"product_name": bson.M{"$arrayElemAt": bson.A{"$product.name", 0}}}},
+				bson.M{"$project": bson.M{"product": 0, "_id": 0}},
+			},
+			"total": bson.A{
+				bson.M{"$count": "count"},
+			},
+		}},
+	)
+
+	cursor, err := r.db.Collection(first).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("aggregate activity: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Items []domain.ActivityItem `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, fmt.Errorf("decode activity: %w", err)
+	}
+
+	if len(result) == 0 {
+		return []domain.ActivityItem{}, 0, nil
+	}
+	var total int64
+	if len(result[0].Total) > 0 {
+		total = result[0].Total[0].Count
+	}
+
+	return result[0].Items, total, nil
+}
+
+// activityStages selects the user's documents of one source and shapes them as ActivityItem
+func activityStages(userID int, activityType string) bson.A {
+	source := activitySources[activityType]
+
+	project := bson.M{
+		"type":        bson.M{"$literal": activityType},
+		"occurred_at": "$" + source.timeField,
+	}
+	for field, value := range source.project {
+		project[field] = value
+	}
+
+	return bson.A{
+		bson.M{"$match": bson.M{"user_id": userID}},
+		bson.M{"$project": project},
+	}
+}
+
+// adminActivitySource describes how documents of a collection map onto AdminActivityItem.
+// entityType is a literal, or a field path when it starts with $.
+type adminActivitySource struct {
+	activityType string
+	category     string
+	collection   string
+	timeField    string
+	actorField   string
+	entityType   string
+	entityID     string
+	match        bson.M
+	details      bson.M
+}
+
+// adminActivitySources are the records the admin activity feed is made of. There is no audit
+// log of its own: staff changes are taken from the records that name who made them.
+var adminActivitySources = []adminActivitySource{
+	{
+		activityType: domain.AdminActivityStockAdjusted,
+		category:     domain.AdminActivityAudit,
+		collection:   "stock_adjustments",
+		timeField:    "created_at",
+		actorField:   "actor_id",
+		entityType:   "product",
+		entityID:     "$product_id",
+		match:        bson.M{"reason": bson.M{"$in": append([]string{domain.StockReasonTransfer}, domain.ManualStockReasons...)}},
+		details:      bson.M{"reason": "$reason", "delta": "$delta", "stock_after": "$stock_after", "warehouse_id": "$warehouse_id"},
+	},
+	{
+		activityType: domain.AdminActivityNoteAdded,
+		category:     domain.AdminActivityAudit,
+		collection:   "support_notes",
+		timeField:    "created_at",
+		actorField:   "author_id",
+		entityType:   "$subject",
+		entityID:     "$subject_id",
+		details:      bson.M{"note_id": "$_id"},
+	},
+	{
+		activityType: domain.AdminActivityContentReviewed,
+		category:     domain.AdminActivityAudit,
+		collection:   "moderation_items",
+		timeField:    "reviewed_at",
+		actorField:   "reviewed_by",
+		entityType:   "$subject",
+		entityID:     "$subject_id",
+		details:      bson.M{"item_id": "$_id", "status": "$status"},
+	},
+	{
+		activityType: domain.AdminActivityRiskReviewed,
+		category:     domain.AdminActivityAudit,
+		collection:   "risk_reviews",
+		timeField:    "reviewed_at",
+		actorField:   "reviewed_by",
+		entityType:   "risk_review",
+		entityID:     "$_id",
+		details:      bson.M{"status": "$status", "source": "$source", "source_id": "$source_id"},
+	},
+	{
+		activityType: domain.AdminActivityPurchase,
+		category:     domain.AdminActivityOrder,
+		collection:   "user_product_purchases",
+		timeField:    "purchased_at",
+		actorField:   "user_id",
+		entityType:   "product",
+		entityID:     "$product_id",
+		details:      bson.M{"quantity": "$quantity", "price": "$price_at_purchase"},
+	},
+	{
+		activityType: domain.AdminActivityCheckoutFlagged,
+		category:     domain.AdminActivityAlert,
+		collection:   "risk_reviews",
+		timeField:    "created_at",
+		actorField:   "user_id",
+		entityType:   "risk_review",
+		entityID:     "$_id",
+		details:      bson.M{"score": "$score", "amount": "$amount", "source": "$source", "source_id": "$source_id"},
+	},
+	{
+		activityType: domain.AdminActivityContentQuarantined,
+		category:     domain.AdminActivityAlert,
+		collection:   "moderation_items",
+		timeField:    "created_at",
+		actorField:   "user_id",
+		entityType:   "$subject",
+		entityID:     "$subject_id",
+		details:      bson.M{"item_id": "$_id"},
+	},
+}
+
+// GetAdminActivity merges the selected sources with $unionWith like GetActivity. Filters are
+// applied within each source so its indexes on the time field can be used.
+func (r *activityRepository) GetAdminActivity(ctx context.Context, filter domain.AdminActivityFilter) ([]domain.AdminActivityItem, int64, error) {
+	var first string
+	var pipeline bson.A
+	for _, source := range adminActivitySources {
+		if filter.Category != "" && source.category != filter.Category {
+			continue
+		}
+		if filter.EntityType != "" && !strings.HasPrefix(source.entityType, "$") && source.entityType != filter.EntityType {
+			continue
+		}
+
+		stages := adminActivityStages(source, filter)
+		if first == "" {
+			first = source.collection
+			pipeline = append(pipeline, stages...)
+			continue
+		}
+		pipeline = append(pipeline, bson.M{"$unionWith": bson.M{
+			"coll":     source.collection,
+			"pipeline": stages,
+		}})
+	}
+	if first == "" {
+		return []domain.AdminActivityItem{}, 0, nil
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "occurred_at", Value: -1}, {Key: "type", Value: 1}, {Key: "entity_id", Value: -1}}},
+		bson.M{"$facet": bson.M{
+			"items": bson.A{
+				bson.M{"$skip": filter.Offset},
+				bson.M{"$limit": filter.Limit},
+			},
+			"total": bson.A{
+				bson.M{"$count": "count"},
+			},
+		}},
+	)
+
+	cursor, err := r.db.AnalyticsCollection(first).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("aggregate admin activity: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Items []domain.AdminActivityItem `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, fmt.Errorf("decode admin activity: %w", err)
+	}
+
+	if len(result) == 0 {
+		return []domain.AdminActivityItem{}, 0, nil
+	}
+	var total int64
+	if len(result[0].Total) > 0 {
+		total = result[0].Total[0].Count
+	}
+
+	return result[0].Items, total, nil
+}
+
+// adminActivityStages selects the documents of one source matching the filter and shapes them
+// as AdminActivityItem
+func adminActivityStages(source adminActivitySource, filter domain.AdminActivityFilter) bson.A {
+	timeRange := bson.M{"$exists": true}
+	if !filter.From.IsZero() {
+		timeRange["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timeRange["$lt"] = filter.To
+	}
+
+	match := bson.M{source.timeField: timeRange}
+	for field, value := range source.match {
+		match[field] = value
+	}
+	if filter.ActorID != 0 {
+		match[source.actorField] = filter.ActorID
+	}
+	entityType := interface{}(source.entityType)
+	if field, ok := strings.CutPrefix(source.entityType, "$"); ok {
+		if filter.EntityType != "" {
+			match[field] = filter.EntityType
+		}
+	} else {
+		entityType = bson.M{"$literal": source.entityType}
+	}
+
+	return bson.A{
+		bson.M{"$match": match},
+		bson.M{"$project": bson.M{
+			"_id":         0,
+			"type":        bson.M{"$literal": source.activityType},
+			"category":    bson.M{"$literal": source.category},
+			"occurred_at": "$" + source.timeField,
+			"actor_id":    "$" + source.actorField,
+			"entity_type": entityType,
+			"entity_id":   bson.M{"$toString": source.entityID},
+			"details":     source.details,
+		}},
+	}
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type BackupRepository interface {
+	// Dump writes the documents of collections, or of all collections when none are given, to w
+	// as gzip-compressed NDJSON and returns the number of documents per collection
+	Dump(ctx context.Context, w io.Writer, collections []string) (map[string]int64, error)
+}
+
+type backupRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewBackupRepository(db *mongodb.MongoDB) BackupRepository {
+	return &backupRepository{db: db}
+}
+
+func (r *backupRepository) Dump(ctx context.Context, w io.Writer, collections []string) (map[string]int64, error) {
+	counts, err := r.db.Backup(ctx, w, collections)
+	if err != nil {
+		return counts, fmt.Errorf("dump collections: %w", err)
+	}
+	return counts, nil
+}
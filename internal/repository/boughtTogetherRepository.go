@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type BoughtTogetherRepository interface {
+	// Compute aggregates purchase history into the products bought by at least minBuyers of the
+	// users who bought each product, at most maxItems per product
+	Compute(ctx context.Context, minBuyers, maxItems int) ([]domain.BoughtTogether, error)
+
+	// Replace stores computed lists and deletes the ones left over from previous runs
+	Replace(ctx context.Context, lists []domain.BoughtTogether, computedAt time.Time) error
+
+	// Get retrieves the stored list of a product
+	Get(ctx context.Context, productID int) (*domain.BoughtTogether, error)
+
+	// LastComputedAt returns when the stored lists were computed, or the zero time if there are none
+	LastComputedAt(ctx context.Context) (time.Time, error)
+}
+
+type boughtTogetherRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewBoughtTogetherRepository(db *mongodb.MongoDB) BoughtTogetherRepository {
+	return &boughtTogetherRepository{db: db}
+}
+
+// Compute pairs the products each signed-in user bought and counts the users behind each pair
+func (r *boughtTogetherRepository) Compute(ctx context.Context, minBuyers, maxItems int) ([]domain.BoughtTogether, error) {
+	buyers, err := r.countBuyers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.AnalyticsCollection("user_product_purchases")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": bson.M{"$gt": 0}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$user_id",
+			"products": bson.M{"$addToSet": "$product_id"},
+		}}},
+		// Only users who bought at least two products have pairs
+		{{Key: "$match", Value: bson.M{"products.1": bson.M{"$exists": true}}}},
+		{{Key: "$project", Value: bson.M{"product": "$products", "other": "$products"}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$unwind", Value: "$other"}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$ne": bson.A{"$product", "$other"}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"product": "$product", "other": "$other"},
+			"buyers": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"buyers": bson.M{"$gte": minBuyers}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "buyers", Value: -1}, {Key: "_id.other", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$_id.product",
+			"items": bson.M{"$push": bson.M{"product_id": "$_id.other", "buyers": "$buyers"}},
+		}}},
+		{{Key: "$project", Value: bson.M{"items": bson.M{"$slice": bson.A{"$items", maxItems}}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("aggregate bought together: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	lists := make([]domain.BoughtTogether, 0)
+	if err := cursor.All(ctx, &lists); err != nil {
+		return nil, fmt.Errorf("decode bought together: %w", err)
+	}
+
+	for i := range lists {
+		list := &lists[i]
+		list.Buyers = buyers[list.ProductID]
+		for j := range list.Items {
+			if list.Buyers > 0 {
+				list.Items[j].Confidence = float64(list.Items[j].Buyers) / float64(list.Buyers)
+			}
+		}
+	}
+
+	return lists, nil
+}
+
+// countBuyers counts the signed-in users who bought each product
+func (r *boughtTogetherRepository) countBuyers(ctx context.Context) (map[int]int, error) {
+	collection := r.db.AnalyticsCollection("user_product_purchases")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": bson.M{"$gt": 0}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"product": "$product_id", "user": "$user_id"}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.product", "buyers": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("count buyers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ProductID int `bson:"_id"`
+		Buyers    int `bson:"buyers"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode buyers: %w", err)
+	}
+
+	buyers := make(map[int]int, len(results))
+	for _, result := range results {
+		buyers[result.ProductID] = result.Buyers
+	}
+
+	return buyers, nil
+}
+
+// Replace upserts the computed lists, then deletes the stale ones, so readers see the previous
+// lists rather than none while they are replaced
+func (r *boughtTogetherRepository) Replace(ctx context.Context, lists []domain.BoughtTogether, computedAt time.Time) error {
+	collection := r.db.Collection("bought_together")
+
+	// Mongo stores dates with millisecond precision
+	computedAt = computedAt.Truncate(time.Millisecond)
+
+	if len(lists) > 0 {
+		models := make([]mongo.WriteModel, 0, len(lists))
+		for _, list := range lists {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": list.ProductID}).
+				SetUpdate(bson.M{"$set": bson.M{
+					"buyers":      list.Buyers,
+					"items":       list.Items,
+					"computed_at": computedAt,
+				}}).
+				SetUpsert(true))
+		}
+
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("upsert bought together: %w", err)
+		}
+	}
+
+	if _, err := collection.DeleteMany(ctx, bson.M{"computed_at": bson.M{"$lt": computedAt}}); err != nil {
+		return fmt.Errorf("delete stale bought together: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the stored list of a product
+func (r *boughtTogetherRepository) Get(ctx context.Context, productID int) (*domain.BoughtTogether, error) {
+	collection := r.db.Collection("bought_together")
+
+	var list domain.BoughtTogether
+	if err := collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&list); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get bought together: %w", err)
+	}
+
+	return &list, nil
+}
+
+// LastComputedAt returns the computed_at of the most recently stored list
+func (r *boughtTogetherRepository) LastComputedAt(ctx context.Context) (time.Time, error) {
+	collection := r.db.Collection("bought_together")
+
+	opts := options.FindOne().
+		SetSort(bson.M{"computed_at": -1}).
+		SetProjection(bson.M{"computed_at": 1})
+
+	var list domain.BoughtTogether
+	if err := collection.FindOne(ctx, bson.M{}, opts).Decode(&list); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("get last computed at: %w", err)
+	}
+
+	return list.ComputedAt, nil
+}
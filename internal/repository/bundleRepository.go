@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type BundleRepository interface {
+	Create(ctx context.Context, bundle *domain.Bundle) error
+	GetByID(ctx context.Context, id int) (*domain.Bundle, error)
+	List(ctx context.Context, filter domain.BundleFilter, limit, offset int) ([]*domain.Bundle, int64, error)
+	Update(ctx context.Context, bundle *domain.Bundle) error
+	Delete(ctx context.Context, id int) error
+
+	// ComponentStock returns the stock of each of the products that exist
+	ComponentStock(ctx context.Context, productIDs []int) (map[int]int, error)
+}
+
+type bundleRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewBundleRepository(db *mongodb.MongoDB) BundleRepository {
+	return &bundleRepository{db: db}
+}
+
+// getNextID gets the next bundle ID from the counter
+func (r *bundleRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "bundle_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next bundle id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new bundle
+func (r *bundleRepository) Create(ctx context.Context, bundle *domain.Bundle) error {
+	collection := r.db.Collection("bundles")
+
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	bundle.ID = id
+	bundle.CreatedAt = now
+	bundle.UpdatedAt = now
+
+	if _, err := collection.InsertOne(ctx, bundle); err != nil {
+		return fmt.Errorf("insert bundle: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a bundle by ID
+func (r *bundleRepository) GetByID(ctx context.Context, id int) (*domain.Bundle, error) {
+	collection := r.db.Collection("bundles")
+
+	var bundle domain.Bundle
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&bundle)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find bundle: %w", err)
+	}
+
+	return &bundle, nil
+}
+
+// List retrieves a page of the bundles the filter selects, newest first, with the total count
+func (r *bundleRepository) List(ctx context.Context, bundleFilter domain.BundleFilter, limit, offset int) ([]*domain.Bundle, int64, error) {
+	collection := r.db.Collection("bundles")
+
+	filter := bson.M{}
+	if bundleFilter.IsActive != nil {
+		filter["is_active"] = *bundleFilter.IsActive
+	}
+	if bundleFilter.Targeted {
+		offered := bson.A{bson.M{"segment_ids": bson.M{"$exists": false}}}
+		if len(bundleFilter.SegmentIDs) > 0 {
+			offered = append(offered, bson.M{"segment_ids": bson.M{"$in": bundleFilter.SegmentIDs}})
+		}
+		filter["$or"] = offered
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count bundles: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find bundles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	bundles := make([]*domain.Bundle, 0)
+	if err := cursor.All(ctx, &bundles); err != nil {
+		return nil, 0, fmt.Errorf("decode bundles: %w", err)
+	}
+
+	return bundles, total, nil
+}
+
+// Update updates a bundle
+func (r *bundleRepository) Update(ctx context.Context, bundle *domain.Bundle) error {
+	collection := r.db.Collection("bundles")
+
+	bundle.UpdatedAt = time.Now().UTC()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":        bundle.Name,
+			"description": bundle.Description,
+			"price":       bundle.Price,
+			"components":  bundle.Components,
+			"is_active":   bundle.IsActive,
+			"updated_at":  bundle.UpdatedAt,
+		},
+	}
+	if len(bundle.SegmentIDs) > 0 {
+		update["$set"].(bson.M)["segment_ids"] = bundle.SegmentIDs
+	} else {
+		update["$unset"] = bson.M{"segment_ids": ""}
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": bundle.ID}, update)
+	if err != nil {
+		return fmt.Errorf("update bundle: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a bundle
+func (r *bundleRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Collection("bundles").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete bundle: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ComponentStock returns the stock of each of the products that exist
+func (r *bundleRepository) ComponentStock(ctx context.Context, productIDs []int) (map[int]int, error) {
+	opts := options.Find().SetProjection(bson.M{"stock": 1})
+
+	cursor, err := r.db.Collection("products").Find(ctx, bson.M{"_id": bson.M{"$in": productIDs}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find component products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var products []struct {
+		ID    int `bson:"_id"`
+		Stock int `bson:"stock"`
+	}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("decode component products: %w", err)
+	}
+
+	stock := make(map[int]int, len(products))
+	for _, product := range products {
+		stock[product.ID] = product.Stock
+	}
+
+	return stock, nil
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type CartRepository interface {
+	// Get retrieves a user's cart, or ErrNotFound if the user has none
+	Get(ctx context.Context, userID int) (*domain.Cart, error)
+	// Save stores the items of a cart and sets its updated time
+	Save(ctx context.Context, cart *domain.Cart) error
+	Delete(ctx context.Context, userID int) error
+
+	// ListIdle retrieves carts with items left unchanged since before and not abandoned since
+	ListIdle(ctx context.Context, before time.Time, limit int) ([]*domain.Cart, error)
+
+	// Abandon links a new abandoned cart record to the cart, reporting whether it did. It does
+	// nothing if the cart changed since it was read or another caller abandoned it first.
+	Abandon(ctx context.Context, cart *domain.Cart, abandoned *domain.AbandonedCart) (bool, error)
+	SetReminded(ctx context.Context, id int, at time.Time) error
+
+	// MarkRecovered records the checkout of an abandoned cart and returns it, or nil if it was
+	// abandoned before since or is already recovered
+	MarkRecovered(ctx context.Context, id int, since, at time.Time, value domain.Money) (*domain.AbandonedCart, error)
+
+	// RecoveryStats summarizes the carts abandoned in [from, to)
+	RecoveryStats(ctx context.Context, from, to time.Time) (*domain.CartRecoveryStats, error)
+}
+
+type cartRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewCartRepository(db *mongodb.MongoDB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+// getNextID gets the next abandoned cart ID from the counter
+func (r *cartRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "abandoned_cart_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next abandoned cart id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Get retrieves a user's cart
+func (r *cartRepository) Get(ctx context.Context, userID int) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := r.db.Collection("carts").FindOne(ctx, bson.M{"_id": userID}).Decode(&cart)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find cart: %w", err)
+	}
+
+	return &cart, nil
+}
+
+// Save stores the items of a cart, creating it if needed
+func (r *cartRepository) Save(ctx context.Context, cart *domain.Cart) error {
+	cart.UpdatedAt = time.Now().UTC()
+
+	_, err := r.db.Collection("carts").UpdateOne(ctx,
+		bson.M{"_id": cart.UserID},
+		bson.M{"$set": bson.M{"items": cart.Items, "updated_at": cart.UpdatedAt}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("save cart: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a user's cart; deleting a missing cart is not an error
+func (r *cartRepository) Delete(ctx context.Context, userID int) error {
+	if _, err := r.db.Collection("carts").DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return fmt.Errorf("delete cart: %w", err)
+	}
+	return nil
+}
+
+// notAbandonedSinceChange matches carts not abandoned since they last changed
+var notAbandonedSinceChange = bson.M{"$lt": bson.A{
+	bson.M{"$ifNull": bson.A{"$abandoned_at", time.Time{}}},
+	"$updated_at",
+}}
+
+// ListIdle retrieves idle carts, longest idle first
+func (r *cartRepository) ListIdle(ctx context.Context, before time.Time, limit int) ([]*domain.Cart, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	filter := bson.M{
+		"items.0":    bson.M{"$exists": true},
+		"updated_at": bson.M{"$lt": before},
+		"$expr":      notAbandonedSinceChange,
+	}
+
+	cursor, err := r.db.Collection("carts").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find idle carts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	carts := make([]*domain.Cart, 0)
+	if err := cursor.All(ctx, &carts); err != nil {
+		return nil, fmt.Errorf("decode idle carts: %w", err)
+	}
+
+	return carts, nil
+}
+
+// Abandon links a new abandoned cart record to the cart
+func (r *cartRepository) Abandon(ctx context.Context, cart *domain.Cart, abandoned *domain.AbandonedCart) (bool, error) {
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Claim the cart first, so only one instance records its abandonment
+	filter := bson.M{
+		"_id":        cart.UserID,
+		"updated_at": cart.UpdatedAt,
+		"$expr":      notAbandonedSinceChange,
+	}
+
+	result, err := r.db.Collection("carts").UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"abandoned_cart_id": id,
+		"abandoned_at":      abandoned.AbandonedAt,
+	}})
+	if err != nil {
+		return false, fmt.Errorf("claim abandoned cart: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return false, nil
+	}
+
+	abandoned.ID = id
+	if _, err := r.db.Collection("abandoned_carts").InsertOne(ctx, abandoned); err != nil {
+		return false, fmt.Errorf("insert abandoned cart: %w", err)
+	}
+
+	cart.AbandonedCartID = id
+	cart.AbandonedAt = &abandoned.AbandonedAt
+	return true, nil
+}
+
+// SetReminded records when the user was reminded of an abandoned cart
+func (r *cartRepository) SetReminded(ctx context.Context, id int, at time.Time) error {
+	result, err := r.db.Collection("abandoned_carts").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"reminded_at": at}},
+	)
+	if err != nil {
+		return fmt.Errorf("update abandoned cart: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkRecovered records the checkout of an abandoned cart, returning nil if it was abandoned
+// before since or is already recovered
+func (r *cartRepository) MarkRecovered(ctx context.Context, id int, since, at time.Time, value domain.Money) (*domain.AbandonedCart, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var abandoned domain.AbandonedCart
+	err := r.db.Collection("abandoned_carts").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "abandoned_at": bson.M{"$gte": since}, "recovered_at": nil},
+		bson.M{"$set": bson.M{"recovered_at": at, "recovered_value": value}},
+		opts,
+	).Decode(&abandoned)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update abandoned cart: %w", err)
+	}
+
+	return &abandoned, nil
+}
+
+// RecoveryStats summarizes the carts abandoned in [from, to)
+func (r *cartRepository) RecoveryStats(ctx context.Context, from, to time.Time) (*domain.CartRecoveryStats, error) {
+	isSet := func(field string) bson.M {
+		return bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{field, false}}, 1, 0}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"abandoned_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             nil,
+			"abandoned":       bson.M{"$sum": 1},
+			"abandoned_value": bson.M{"$sum": "$value.amount"},
+			"reminded":        bson.M{"$sum": isSet("$reminded_at")},
+			"recovered":       bson.M{"$sum": isSet("$recovered_at")},
+			"recovered_value": bson.M{"$sum": "$recovered_value.amount"},
+			"currency":        firstCurrency("value"),
+			"recovered_after_reminder": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$ifNull": bson.A{"$reminded_at", false}},
+					bson.M{"$ifNull": bson.A{"$recovered_at", false}},
+				}}, 1, 0,
+			}}},
+		}}},
+		moneyStage("abandoned_value", "recovered_value"),
+	}
+
+	cursor, err := r.db.AnalyticsCollection("abandoned_carts").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate abandoned carts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Abandoned              int64        `bson:"abandoned"`
+		AbandonedValue         domain.Money `bson:"abandoned_value"`
+		Reminded               int64        `bson:"reminded"`
+		Recovered              int64        `bson:"recovered"`
+		RecoveredValue         domain.Money `bson:"recovered_value"`
+		RecoveredAfterReminder int64        `bson:"recovered_after_reminder"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode abandoned cart stats: %w", err)
+	}
+
+	stats := &domain.CartRecoveryStats{From: from, To: to}
+	if len(results) > 0 {
+		stats.Abandoned = results[0].Abandoned
+		stats.AbandonedValue = results[0].AbandonedValue
+		stats.Reminded = results[0].Reminded
+		stats.Recovered = results[0].Recovered
+		stats.RecoveredValue = results[0].RecoveredValue
+		stats.RecoveredAfterReminder = results[0].RecoveredAfterReminder
+	}
+	stats.SetRates()
+
+	return stats, nil
+}
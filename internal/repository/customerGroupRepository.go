@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type CustomerGroupRepository interface {
+	Create(ctx context.Context, group *domain.CustomerGroup) error
+	GetByID(ctx context.Context, id int) (*domain.CustomerGroup, error)
+	List(ctx context.Context) ([]*domain.CustomerGroup, error)
+	Update(ctx context.Context, group *domain.CustomerGroup) error
+
+	// Delete removes a group and unassigns its users, who go back to list prices
+	Delete(ctx context.Context, id int) error
+
+	// GetUserGroup returns the group a user is assigned to, or nil if there is none
+	GetUserGroup(ctx context.Context, userID int) (*domain.CustomerGroup, error)
+
+	// AssignUser assigns a user to a group, or unassigns them with a nil groupID, and returns
+	// the user
+	AssignUser(ctx context.Context, userID int, groupID *int) (*domain.User, error)
+}
+
+type customerGroupRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewCustomerGroupRepository(db *mongodb.MongoDB) CustomerGroupRepository {
+	return &customerGroupRepository{db: db}
+}
+
+// getNextID gets the next customer group ID
+func (r *customerGroupRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "customer_group_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next customer group id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new customer group; the name must be unique
+func (r *customerGroupRepository) Create(ctx context.Context, group *domain.CustomerGroup) error {
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	group.ID = id
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	if _, err := r.db.Collection("customer_groups").InsertOne(ctx, group); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("insert customer group: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a customer group by ID
+func (r *customerGroupRepository) GetByID(ctx context.Context, id int) (*domain.CustomerGroup, error) {
+	var group domain.CustomerGroup
+	err := r.db.Collection("customer_groups").FindOne(ctx, bson.M{"_id": id}).Decode(&group)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find customer group: %w", err)
+	}
+
+	return &group, nil
+}
+
+// List retrieves all customer groups by name
+func (r *customerGroupRepository) List(ctx context.Context) ([]*domain.CustomerGroup, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := r.db.Collection("customer_groups").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find customer groups: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	groups := make([]*domain.CustomerGroup, 0)
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("decode customer groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// Update replaces the name, description, discount and rules of a customer group
+func (r *customerGroupRepository) Update(ctx context.Context, group *domain.CustomerGroup) error {
+	group.UpdatedAt = time.Now().UTC()
+
+	result, err := r.db.Collection("customer_groups").UpdateOne(ctx, bson.M{"_id": group.ID}, bson.M{"$set": bson.M{
+		"name":        group.Name,
+		"description": group.Description,
+		"percent_off": group.PercentOff,
+		"rules":       group.Rules,
+		"updated_at":  group.UpdatedAt,
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("update customer group: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the group and unassigns its users in one transaction
+func (r *customerGroupRepository) Delete(ctx context.Context, id int) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context) error {
+		result, err := r.db.Collection("customer_groups").DeleteOne(ctx, bson.M{"_id": id})
+		if err != nil {
+			return fmt.Errorf("delete customer group: %w", err)
+		}
+		if result.DeletedCount == 0 {
+			return domain.ErrNotFound
+		}
+
+		_, err = r.db.Collection("users").UpdateMany(ctx,
+			bson.M{"customer_group_id": id},
+			bson.M{"$unset": bson.M{"customer_group_id": ""}, "$set": bson.M{"updated_at": time.Now().UTC()}},
+		)
+		if err != nil {
+			return fmt.Errorf("unassign customer group users: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetUserGroup looks up the user's group ID, then the group
+func (r *customerGroupRepository) GetUserGroup(ctx context.Context, userID int) (*domain.CustomerGroup, error) {
+	var user struct {
+		CustomerGroupID *int `bson:"customer_group_id"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"customer_group_id": 1})
+	err := r.db.Collection("users").FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("find user customer group: %w", err)
+	}
+	if user.CustomerGroupID == nil {
+		return nil, nil
+	}
+
+	group, err := r.GetByID(ctx, *user.CustomerGroupID)
+	if err == domain.ErrNotFound {
+		return nil, nil
+	}
+	return group, err
+}
+
+// AssignUser sets or unsets the customer group of the user
+func (r *customerGroupRepository) AssignUser(ctx context.Context, userID int, groupID *int) (*domain.User, error) {
+	now := time.Now().UTC()
+	update := bson.M{"$set": bson.M{"customer_group_id": groupID, "updated_at": now}}
+	if groupID == nil {
+		update = bson.M{"$unset": bson.M{"customer_group_id": ""}, "$set": bson.M{"updated_at": now}}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user domain.User
+	err := r.db.Collection("users").FindOneAndUpdate(ctx, bson.M{"_id": userID}, update, opts).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("assign customer group: %w", err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type EmailChangeRepository interface {
+	Save(ctx context.Context, req *domain.EmailChangeRequest) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.EmailChangeRequest, error)
+	Delete(ctx context.Context, userID int) error
+}
+
+type emailChangeRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewEmailChangeRepository(db *mongodb.MongoDB) EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+// Save stores a pending email change, replacing any earlier request of the user
+func (r *emailChangeRepository) Save(ctx context.Context, req *domain.EmailChangeRequest) error {
+	collection := r.db.Collection("email_change_requests")
+
+	req.CreatedAt = time.Now().UTC()
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": req.UserID}, req, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("save email change request: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTokenHash retrieves a pending email change by its confirmation token hash
+func (r *emailChangeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.EmailChangeRequest, error) {
+	collection := r.db.Collection("email_change_requests")
+
+	var req domain.EmailChangeRequest
+	err := collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&req)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find email change request: %w", err)
+	}
+
+	return &req, nil
+}
+
+// Delete removes the user's pending email change
+func (r *emailChangeRepository) Delete(ctx context.Context, userID int) error {
+	collection := r.db.Collection("email_change_requests")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return fmt.Errorf("delete email change request: %w", err)
+	}
+
+	return nil
+}
@@ -12,6 +12,9 @@ type Example interface {
 
 type Health interface {
 	Ping(ctx context.Context) error
+
+	// Circuit returns the state of the database circuit breaker
+	Circuit() mongodb.Circuit
 }
 
 type ExampleRepository struct {
@@ -40,3 +43,7 @@ func (r *HealthRepository) Ping(ctx context.Context) error {
 	// Ping MongoDB
 	return r.db.Client.Ping(ctx, nil)
 }
+
+func (r *HealthRepository) Circuit() mongodb.Circuit {
+	return r.db.Circuit()
+}
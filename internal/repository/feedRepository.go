@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type FeedRepository interface {
+	// Trending retrieves the limit products with the highest weighted interactions since a time,
+	// highest first
+	Trending(ctx context.Context, since time.Time, limit int) ([]domain.TrendingProduct, error)
+
+	// NewProducts retrieves the active products in the categories created since a time, newest first
+	NewProducts(ctx context.Context, categoryIDs []int, since time.Time, limit int) ([]*domain.Product, error)
+
+	// LikedPriceDrops retrieves the active products the user likes whose price went down since a
+	// time, biggest drop first
+	LikedPriceDrops(ctx context.Context, userID int, since time.Time, limit int) ([]*domain.Product, error)
+}
+
+type feedRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewFeedRepository(db *mongodb.MongoDB) FeedRepository {
+	return &feedRepository{db: db}
+}
+
+// trendingSignals are the interaction collections trending products are ranked by, weighted
+// like the interactions of the recommendation system
+var trendingSignals = []struct {
+	collection string
+	timeField  string
+	weight     float64
+}{
+	{"user_product_purchases", "purchased_at", 0.5},
+	{"user_product_likes", "liked_at", 0.35},
+	{"user_product_views", "viewed_at", 0.15},
+}
+
+// Trending counts each product's interactions of every type and sums them weighted
+func (r *feedRepository) Trending(ctx context.Context, since time.Time, limit int) ([]domain.TrendingProduct, error) {
+	scores := make(map[int]float64)
+
+	for _, signal := range trendingSignals {
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{signal.timeField: bson.M{"$gte": since}}}},
+			{{Key: "$group", Value: bson.M{"_id": "$product_id", "score": bson.M{"$sum": signal.weight}}}},
+		}
+
+		cursor, err := r.db.AnalyticsCollection(signal.collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("aggregate %s: %w", signal.collection, err)
+		}
+
+		var products []domain.TrendingProduct
+		err = cursor.All(ctx, &products)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", signal.collection, err)
+		}
+
+		for _, product := range products {
+			scores[product.ProductID] += product.Score
+		}
+	}
+
+	trending := make([]domain.TrendingProduct, 0, len(scores))
+	for productID, score := range scores {
+		trending = append(trending, domain.TrendingProduct{ProductID: productID, Score: score})
+	}
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].ProductID < trending[j].ProductID
+	})
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+
+	return trending, nil
+}
+
+// NewProducts finds recently created active products of the categories
+func (r *feedRepository) NewProducts(ctx context.Context, categoryIDs []int, since time.Time, limit int) ([]*domain.Product, error) {
+	collection := r.db.Collection("products")
+
+	filter := bson.M{
+		"category_id": bson.M{"$in": categoryIDs},
+		"is_active":   true,
+		"created_at":  bson.M{"$gte": since},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find new products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	products := make([]*domain.Product, 0)
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("decode new products: %w", err)
+	}
+
+	return products, nil
+}
+
+// LikedPriceDrops joins the user's likes with their products and keeps the ones whose last price
+// change was a drop
+func (r *feedRepository) LikedPriceDrops(ctx context.Context, userID int, since time.Time, limit int) ([]*domain.Product, error) {
+	collection := r.db.Collection("user_product_likes")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "product_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$product"}}},
+		{{Key: "$match", Value: bson.M{
+			"is_active":        true,
+			"price_changed_at": bson.M{"$gte": since},
+			"$expr":            bson.M{"$lt": bson.A{"$price.amount", "$previous_price.amount"}},
+		}}},
+		{{Key: "$set", Value: bson.M{"drop": bson.M{"$divide": bson.A{
+			bson.M{"$subtract": bson.A{"$previous_price.amount", "$price.amount"}},
+			"$previous_price.amount",
+		}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "drop", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{"drop": 0}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate liked price drops: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	products := make([]*domain.Product, 0)
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("decode liked price drops: %w", err)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+var filterOperators = map[domain.FilterOperator]string{
+	domain.FilterEq:  "$eq",
+	domain.FilterNe:  "$ne",
+	domain.FilterGt:  "$gt",
+	domain.FilterGte: "$gte",
+	domain.FilterLt:  "$lt",
+	domain.FilterLte: "$lte",
+	domain.FilterIn:  "$in",
+}
+
+// buildConditions compiles parsed filter conditions into Mongo expressions to be combined with $and.
+// Fields and operators are checked against the allowlist again, so callers can't bypass it.
+func buildConditions(conditions []domain.FilterCondition, allowed map[string]domain.FilterField) ([]bson.M, error) {
+	expressions := make([]bson.M, 0, len(conditions))
+	for _, condition := range conditions {
+		field, ok := allowed[condition.Field]
+		if !ok {
+			return nil, fmt.Errorf("field %q cannot be filtered: %w", condition.Field, domain.ErrValidation)
+		}
+		op, ok := filterOperators[condition.Operator]
+		if !ok || !field.Type.AllowsOperator(condition.Operator) || len(condition.Values) == 0 {
+			return nil, fmt.Errorf("invalid condition on %q: %w", condition.Field, domain.ErrValidation)
+		}
+
+		var value interface{} = condition.Values[0]
+		if condition.Operator == domain.FilterIn {
+			value = bson.A(condition.Values)
+		}
+
+		expressions = append(expressions, bson.M{field.Path: bson.M{op: value}})
+	}
+	return expressions, nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type FlashSaleRepository interface {
+	// Set replaces the product's flash sale, with all its units left, and returns the product
+	Set(ctx context.Context, productID int, sale *domain.FlashSale) (*domain.Product, error)
+
+	// Clear removes the product's flash sale; its unsold units stay in stock
+	Clear(ctx context.Context, productID int) error
+
+	// Join places the user in the waiting room of the sale starting at startsAt and returns
+	// their position, counted from 1. A user who has already joined keeps their position.
+	Join(ctx context.Context, productID int, startsAt time.Time, userID int) (int, error)
+
+	// Requeue moves the user from position to the back of the waiting room and returns their
+	// new position. A user no longer at position has been moved already and keeps their place.
+	Requeue(ctx context.Context, productID int, startsAt time.Time, userID, position int) (int, error)
+
+	// UseTicket marks the queue position of the sale starting at startsAt as used by a purchase,
+	// until expiresAt. It reports false if the position was already used.
+	UseTicket(ctx context.Context, productID int, startsAt time.Time, position, userID int, expiresAt time.Time) (bool, error)
+
+	// ReleaseTicket makes a used queue position usable again, for a purchase that failed
+	ReleaseTicket(ctx context.Context, productID int, startsAt time.Time, position int) error
+
+	// Take takes quantity units of the sale starting at startsAt from its units left and the
+	// product's stock at once, if the sale hasn't ended at now and both cover it. It returns the
+	// updated product, or domain.ErrSoldOut.
+	Take(ctx context.Context, productID, quantity int, startsAt, now time.Time) (*domain.Product, error)
+
+	// Return gives back units taken by a purchase that failed, to the sale if it is still the
+	// one starting at startsAt, and to the stock
+	Return(ctx context.Context, productID, quantity int, startsAt time.Time) (*domain.Product, error)
+}
+
+type flashSaleRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewFlashSaleRepository(db *mongodb.MongoDB) FlashSaleRepository {
+	return &flashSaleRepository{db: db}
+}
+
+// Set stores the sale on the product document, so it is read with the product and taken from
+// in the same update as the stock
+func (r *flashSaleRepository) Set(ctx context.Context, productID int, sale *domain.FlashSale) (*domain.Product, error) {
+	sale.Remaining = sale.Quantity
+	update := bson.M{"$set": bson.M{"flash_sale": sale, "updated_at": time.Now().UTC()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product domain.Product
+	err := r.db.Collection("products").FindOneAndUpdate(ctx, bson.M{"_id": productID}, update, opts).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("set flash sale: %w", err)
+	}
+
+	return &product, nil
+}
+
+// Clear unsets the sale on the product document
+func (r *flashSaleRepository) Clear(ctx context.Context, productID int) error {
+	update := bson.M{"$unset": bson.M{"flash_sale": ""}, "$set": bson.M{"updated_at": time.Now().UTC()}}
+
+	result, err := r.db.Collection("products").UpdateOne(ctx, bson.M{"_id": productID}, update)
+	if err != nil {
+		return fmt.Errorf("clear flash sale: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Join returns the user's place in the sale's waiting room, or takes the next position and
+// stores it as theirs. Places are unique per user, so of concurrent joins of one user only
+// the first keeps its position and the others return it; the positions they took stay empty.
+func (r *flashSaleRepository) Join(ctx context.Context, productID int, startsAt time.Time, userID int) (int, error) {
+	filter := bson.M{"product_id": productID, "starts_at": startsAt, "user_id": userID}
+
+	position, err := r.findPlace(ctx, filter)
+	if err != domain.ErrNotFound {
+		return position, err
+	}
+
+	position, err = r.next(ctx, productID, startsAt)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = r.db.Collection("flash_sale_places").InsertOne(ctx, bson.M{
+		"product_id": productID,
+		"starts_at":  startsAt,
+		"user_id":    userID,
+		"position":   position,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return r.findPlace(ctx, filter)
+		}
+		return 0, fmt.Errorf("store flash sale place: %w", err)
+	}
+
+	return position, nil
+}
+
+// Requeue takes the next position and moves the user's place to it only if it is still at
+// position, so concurrent requeues of one user move it once; the positions the others took
+// stay empty
+func (r *flashSaleRepository) Requeue(ctx context.Context, productID int, startsAt time.Time, userID, position int) (int, error) {
+	filter := bson.M{"product_id": productID, "starts_at": startsAt, "user_id": userID}
+
+	next, err := r.next(ctx, productID, startsAt)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Collection("flash_sale_places").UpdateOne(ctx,
+		bson.M{"product_id": productID, "starts_at": startsAt, "user_id": userID, "position": position},
+		bson.M{"$set": bson.M{"position": next}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("move flash sale place: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return r.findPlace(ctx, filter)
+	}
+
+	return next, nil
+}
+
+// findPlace returns the position of the place matching filter, or domain.ErrNotFound
+func (r *flashSaleRepository) findPlace(ctx context.Context, filter bson.M) (int, error) {
+	var place struct {
+		Position int `bson:"position"`
+	}
+
+	err := r.db.Collection("flash_sale_places").FindOne(ctx, filter).Decode(&place)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, domain.ErrNotFound
+		}
+		return 0, fmt.Errorf("get flash sale place: %w", err)
+	}
+
+	return place.Position, nil
+}
+
+// next increments the sale's queue counter, kept apart from the product so joining doesn't
+// contend with purchases
+func (r *flashSaleRepository) next(ctx context.Context, productID int, startsAt time.Time) (int, error) {
+	var queue struct {
+		Joined int `bson:"joined"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := r.db.Collection("flash_sale_queues").FindOneAndUpdate(
+		ctx,
+		bson.M{"product_id": productID, "starts_at": startsAt},
+		bson.M{"$inc": bson.M{"joined": 1}},
+		opts,
+	).Decode(&queue)
+	if err != nil {
+		return 0, fmt.Errorf("join flash sale queue: %w", err)
+	}
+
+	return queue.Joined, nil
+}
+
+// UseTicket inserts the used position; the unique index rejects a second use, so concurrent
+// purchases with the same token can't both get through
+func (r *flashSaleRepository) UseTicket(ctx context.Context, productID int, startsAt time.Time, position, userID int, expiresAt time.Time) (bool, error) {
+	_, err := r.db.Collection("flash_sale_tickets").InsertOne(ctx, bson.M{
+		"product_id": productID,
+		"starts_at":  startsAt,
+		"position":   position,
+		"user_id":    userID,
+		"used_at":    time.Now().UTC(),
+		"expires_at": expiresAt,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("use flash sale ticket: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseTicket deletes the used position
+func (r *flashSaleRepository) ReleaseTicket(ctx context.Context, productID int, startsAt time.Time, position int) error {
+	_, err := r.db.Collection("flash_sale_tickets").DeleteOne(ctx, bson.M{
+		"product_id": productID,
+		"starts_at":  startsAt,
+		"position":   position,
+	})
+	if err != nil {
+		return fmt.Errorf("release flash sale ticket: %w", err)
+	}
+
+	return nil
+}
+
+// Take is a single conditional update of the product document, so concurrent purchases need
+// neither a transaction nor a lock: each either takes its units or matches nothing
+func (r *flashSaleRepository) Take(ctx context.Context, productID, quantity int, startsAt, now time.Time) (*domain.Product, error) {
+	filter := bson.M{
+		"_id":                  productID,
+		"stock":                bson.M{"$gte": quantity},
+		"flash_sale.starts_at": startsAt,
+		"flash_sale.remaining": bson.M{"$gte": quantity},
+		"$or": bson.A{
+			bson.M{"flash_sale.ends_at": bson.M{"$exists": false}},
+			bson.M{"flash_sale.ends_at": bson.M{"$gt": now}},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"stock": -quantity, "flash_sale.remaining": -quantity},
+		"$set": bson.M{"updated_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product domain.Product
+	err := r.db.Collection("products").FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrSoldOut
+		}
+		return nil, fmt.Errorf("take flash sale units: %w", err)
+	}
+
+	return &product, nil
+}
+
+// Return increments the stock and the sale's units left, or only the stock if the sale has
+// been replaced or cleared since
+func (r *flashSaleRepository) Return(ctx context.Context, productID, quantity int, startsAt time.Time) (*domain.Product, error) {
+	products := r.db.Collection("products")
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	now := time.Now().UTC()
+
+	var product domain.Product
+	err := products.FindOneAndUpdate(ctx,
+		bson.M{"_id": productID, "flash_sale.starts_at": startsAt},
+		bson.M{"$inc": bson.M{"stock": quantity, "flash_sale.remaining": quantity}, "$set": bson.M{"updated_at": now}},
+		opts,
+	).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		err = products.FindOneAndUpdate(ctx,
+			bson.M{"_id": productID},
+			bson.M{"$inc": bson.M{"stock": quantity}, "$set": bson.M{"updated_at": now}},
+			opts,
+		).Decode(&product)
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("return flash sale units: %w", err)
+	}
+
+	return &product, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/testutil"
+)
+
+func TestFlashSaleQueueKeepsOnePlacePerUser(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	repo := NewFlashSaleRepository(db)
+	ctx := context.Background()
+	startsAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	joins := []struct {
+		name     string
+		userID   int
+		position int
+	}{
+		{"first user", 1, 1},
+		{"second user", 2, 2},
+		{"first user again", 1, 1},
+		{"second user again", 2, 2},
+		{"third user", 3, 3},
+	}
+	for _, tt := range joins {
+		position, err := repo.Join(ctx, 1, startsAt, tt.userID)
+		if err != nil {
+			t.Fatalf("%s: join: %v", tt.name, err)
+		}
+		if position != tt.position {
+			t.Errorf("%s: position = %d, want %d", tt.name, position, tt.position)
+		}
+	}
+
+	// The same user in another sale gets a place of its own
+	if position, err := repo.Join(ctx, 1, startsAt.Add(time.Hour), 1); err != nil || position != 1 {
+		t.Errorf("join another sale: position = %d, err = %v, want 1", position, err)
+	}
+
+	// Requeueing moves the user to the back once; a stale requeue keeps the new place
+	position, err := repo.Requeue(ctx, 1, startsAt, 1, 1)
+	if err != nil || position != 4 {
+		t.Fatalf("requeue: position = %d, err = %v, want 4", position, err)
+	}
+	if position, err := repo.Requeue(ctx, 1, startsAt, 1, 1); err != nil || position != 4 {
+		t.Errorf("requeue from the old position: position = %d, err = %v, want 4", position, err)
+	}
+	if position, err := repo.Join(ctx, 1, startsAt, 1); err != nil || position != 4 {
+		t.Errorf("join after requeue: position = %d, err = %v, want 4", position, err)
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type IndexRepository interface {
+	// List reports the indexes of every collection the application creates indexes on
+	List(ctx context.Context) ([]domain.CollectionIndexes, error)
+
+	// BuildMissing creates the missing expected indexes and returns them as collection.index
+	BuildMissing(ctx context.Context) ([]string, error)
+}
+
+type indexRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewIndexRepository(db *mongodb.MongoDB) IndexRepository {
+	return &indexRepository{db: db}
+}
+
+func (r *indexRepository) List(ctx context.Context) ([]domain.CollectionIndexes, error) {
+	reports, err := r.db.IndexReports(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+
+	collections := make([]domain.CollectionIndexes, len(reports))
+	for i, report := range reports {
+		collection := domain.CollectionIndexes{
+			Collection: report.Collection,
+			Indexes:    make([]domain.IndexInfo, len(report.Indexes)),
+			Missing:    report.Missing,
+			Unexpected: report.Unexpected,
+		}
+		for j, index := range report.Indexes {
+			keys := make([]domain.IndexKey, len(index.Keys))
+			for k, key := range index.Keys {
+				keys[k] = domain.IndexKey{Field: key.Key, Type: fmt.Sprint(key.Value)}
+			}
+			collection.Indexes[j] = domain.IndexInfo{
+				Name:               index.Name,
+				Keys:               keys,
+				Unique:             index.Unique,
+				ExpireAfterSeconds: index.ExpireAfterSeconds,
+				Size:               index.Size,
+			}
+			collection.TotalSize += index.Size
+		}
+		collections[i] = collection
+	}
+
+	return collections, nil
+}
+
+func (r *indexRepository) BuildMissing(ctx context.Context) ([]string, error) {
+	built, err := r.db.BuildMissingIndexes(ctx)
+	if err != nil {
+		return built, fmt.Errorf("build missing indexes: %w", err)
+	}
+	return built, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+const (
+	// integritySampleSize is how many IDs of orphaned documents a check reports
+	integritySampleSize = 10
+
+	// integrityBatchSize is how many orphaned documents are repaired or quarantined per write
+	integrityBatchSize = 500
+)
+
+type IntegrityRepository interface {
+	// Check runs every integrity check and reports how many orphaned documents each found
+	Check(ctx context.Context) ([]domain.IntegrityIssue, error)
+
+	// Repair applies the action to the orphaned documents of a check and returns how many
+	// documents it changed
+	Repair(ctx context.Context, check, action string) (int64, error)
+}
+
+// integrityCheck follows a reference from a collection to the documents it points at
+type integrityCheck struct {
+	name       string
+	collection string
+	field      string
+	references string
+	repair     string // domain.IntegrityRepairUnset, domain.IntegrityRepairDelete or ""
+}
+
+// integrityChecks are the references checked. A purchase records a sale, so it is never
+// deleted by repair; it can only be quarantined.
+var integrityChecks = []integrityCheck{
+	{domain.IntegrityProductCategory, "products", "category_id", "categories", domain.IntegrityRepairUnset},
+	{domain.IntegrityCategoryParent, "categories", "parent_id", "categories", domain.IntegrityRepairUnset},
+	{domain.IntegrityViewProduct, "user_product_views", "product_id", "products", domain.IntegrityRepairDelete},
+	{domain.IntegrityViewUser, "user_product_views", "user_id", "users", domain.IntegrityRepairDelete},
+	{domain.IntegrityLikeProduct, "user_product_likes", "product_id", "products", domain.IntegrityRepairDelete},
+	{domain.IntegrityLikeUser, "user_product_likes", "user_id", "users", domain.IntegrityRepairDelete},
+	{domain.IntegrityPurchaseUser, "user_product_purchases", "user_id", "users", ""},
+	{domain.IntegrityUserRoleRole, "user_roles", "role_id", "roles", domain.IntegrityRepairDelete},
+	{domain.IntegrityUserRoleUser, "user_roles", "user_id", "users", domain.IntegrityRepairDelete},
+}
+
+// quarantinedDocument is a document moved out of its collection by the quarantine action
+type quarantinedDocument struct {
+	Check         string    `bson:"check"`
+	Collection    string    `bson:"collection"`
+	Document      bson.Raw  `bson:"document"`
+	QuarantinedAt time.Time `bson:"quarantined_at"`
+}
+
+type integrityRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewIntegrityRepository(db *mongodb.MongoDB) IntegrityRepository {
+	return &integrityRepository{db: db}
+}
+
+func (r *integrityRepository) Check(ctx context.Context) ([]domain.IntegrityIssue, error) {
+	issues := make([]domain.IntegrityIssue, 0, len(integrityChecks))
+	for _, check := range integrityChecks {
+		pipeline := append(orphansPipeline(check),
+			bson.D{{Key: "$facet", Value: bson.M{
+				"count":  bson.A{bson.M{"$count": "n"}},
+				"sample": bson.A{bson.M{"$limit": integritySampleSize}, bson.M{"$project": bson.M{"_id": 1}}},
+			}}},
+		)
+
+		cursor, err := r.db.AnalyticsCollection(check.collection).Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", check.name, err)
+		}
+		var results []struct {
+			Count []struct {
+				N int64 `bson:"n"`
+			} `bson:"count"`
+			Sample []struct {
+				ID any `bson:"_id"`
+			} `bson:"sample"`
+		}
+		err = cursor.All(ctx, &results)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s check: %w", check.name, err)
+		}
+
+		issue := domain.IntegrityIssue{
+			Check:      check.name,
+			Collection: check.collection,
+			Field:      check.field,
+			References: check.references,
+			Sample:     []any{},
+			Repair:     check.repair,
+		}
+		if len(results) > 0 {
+			if len(results[0].Count) > 0 {
+				issue.Count = results[0].Count[0].N
+			}
+			for _, sample := range results[0].Sample {
+				issue.Sample = append(issue.Sample, sample.ID)
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+func (r *integrityRepository) Repair(ctx context.Context, name, action string) (int64, error) {
+	var check *integrityCheck
+	for i := range integrityChecks {
+		if integrityChecks[i].name == name {
+			check = &integrityChecks[i]
+		}
+	}
+	if check == nil {
+		return 0, fmt.Errorf("%w: unknown check %q", domain.ErrValidation, name)
+	}
+	if action == domain.IntegrityActionRepair && check.repair == "" {
+		return 0, fmt.Errorf("%w: %s can only be quarantined", domain.ErrValidation, name)
+	}
+
+	// Orphans are read from the primary, so documents repaired a moment ago are not seen again
+	collection := r.db.Collection(check.collection)
+	cursor, err := collection.Aggregate(ctx, append(orphansPipeline(*check),
+		bson.D{{Key: "$project", Value: bson.M{"ref": 0}}},
+	))
+	if err != nil {
+		return 0, fmt.Errorf("find %s orphans: %w", name, err)
+	}
+	defer cursor.Close(ctx)
+
+	var affected int64
+	batch := make([]bson.Raw, 0, integrityBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := r.applyBatch(ctx, collection, *check, action, batch)
+		affected += n
+		batch = batch[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		batch = append(batch, append(bson.Raw(nil), cursor.Current...))
+		if len(batch) == integrityBatchSize {
+			if err := flush(); err != nil {
+				return affected, fmt.Errorf("%s %s orphans: %w", action, name, err)
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return affected, fmt.Errorf("read %s orphans: %w", name, err)
+	}
+	if err := flush(); err != nil {
+		return affected, fmt.Errorf("%s %s orphans: %w", action, name, err)
+	}
+
+	return affected, nil
+}
+
+// applyBatch applies the action to a batch of orphaned documents
+func (r *integrityRepository) applyBatch(ctx context.Context, collection *mongodb.Collection, check integrityCheck, action string, documents []bson.Raw) (int64, error) {
+	ids := make(bson.A, len(documents))
+	for i, document := range documents {
+		ids[i] = document.Lookup("_id")
+	}
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+
+	switch {
+	case action == domain.IntegrityActionQuarantine:
+		var deleted int64
+		err := r.db.WithTransaction(ctx, func(ctx context.Context) error {
+			now := time.Now()
+			quarantined := make([]interface{}, len(documents))
+			for i, document := range documents {
+				quarantined[i] = quarantinedDocument{
+					Check:         check.name,
+					Collection:    check.collection,
+					Document:      document,
+					QuarantinedAt: now,
+				}
+			}
+			if _, err := r.db.Collection("quarantine").InsertMany(ctx, quarantined); err != nil {
+				return fmt.Errorf("insert quarantined documents: %w", err)
+			}
+
+			result, err := collection.DeleteMany(ctx, filter)
+			if err != nil {
+				return fmt.Errorf("delete quarantined documents: %w", err)
+			}
+			deleted = result.DeletedCount
+			return nil
+		})
+		return deleted, err
+
+	case check.repair == domain.IntegrityRepairUnset:
+		result, err := collection.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{check.field: ""}})
+		if err != nil {
+			return 0, fmt.Errorf("unset %s: %w", check.field, err)
+		}
+		return result.ModifiedCount, nil
+
+	default:
+		result, err := collection.DeleteMany(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("delete documents: %w", err)
+		}
+		return result.DeletedCount, nil
+	}
+}
+
+// orphansPipeline matches the documents of the check whose reference points at no document.
+// IDs start at 1, so $gt 0 skips documents without the reference, such as the views of guests.
+func orphansPipeline(check integrityCheck) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{check.field: bson.M{"$gt": 0}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         check.references,
+			"localField":   check.field,
+			"foreignField": "_id",
+			"as":           "ref",
+		}}},
+		{{Key: "$match", Value: bson.M{"ref": bson.M{"$size": 0}}}},
+	}
+}
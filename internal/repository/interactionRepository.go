@@ -6,28 +6,40 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
 )
 
+//go:generate go tool mockgen -source=interactionRepository.go -destination=mocks/interactionRepository.go -package=mocks
+
 type InteractionRepository interface {
 	// View interactions
-	RecordView(ctx context.Context, userID, productID int) error
-	GetUserViews(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error)
+	RecordView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error
+	GetUserViews(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error)
 	HasViewed(ctx context.Context, userID, productID int) (bool, error)
+	RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error
 
 	// Like interactions
 	RecordLike(ctx context.Context, userID, productID int) error
 	RemoveLike(ctx context.Context, userID, productID int) error
-	GetUserLikes(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error)
+	GetUserLikes(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error)
 	HasLiked(ctx context.Context, userID, productID int) (bool, error)
+	GetLikedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error)
 
-	// Purchase interactions
-	RecordPurchase(ctx context.Context, userID, productID int, quantity int, price float64) error
-	GetUserPurchases(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error)
+	// Purchase interactions, recorded at the unit price and the unit cost price (zero if unknown)
+	RecordPurchase(ctx context.Context, userID, productID int, quantity int, price, cost domain.Money) error
+	GetUserPurchases(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error)
 	HasPurchased(ctx context.Context, userID, productID int) (bool, error)
+	GetPurchasedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error)
+	GetPurchaseTotals(ctx context.Context, since time.Time) (*domain.PurchaseTotals, error)
+	RecordAnonymousPurchase(ctx context.Context, anonymousID string, productID int, quantity int, price, cost domain.Money) error
+
+	// Anonymous sessions
+	MergeAnonymous(ctx context.Context, anonymousID string, userID int) (int64, error)
 
 	// Summary
 	GetUserInteractionSummary(ctx context.Context, userID int) (*domain.UserInteractionSummary, error)
@@ -36,71 +48,82 @@ type InteractionRepository interface {
 	GetAllUserViews(ctx context.Context) ([]domain.UserProductView, error)
 	GetAllUserLikes(ctx context.Context) ([]domain.UserProductLike, error)
 	GetAllUserPurchases(ctx context.Context) ([]domain.UserProductPurchase, error)
+
+	// For search ranking
+	GetInteractionCounts(ctx context.Context, productIDs []int) (map[int]domain.InteractionCounts, error)
+
+	// Export
+	ExportEvents(ctx context.Context, filter domain.InteractionExportFilter) ([]domain.InteractionEvent, error)
 }
 
 type interactionRepository struct {
 	db *mongodb.MongoDB
+
+	// views batches view inserts; nil inserts each view on its own
+	views *viewWriter
 }
 
 func NewInteractionRepository(db *mongodb.MongoDB) InteractionRepository {
 	return &interactionRepository{db: db}
 }
 
-// RecordView records a user viewing a product
-func (r *interactionRepository) RecordView(ctx context.Context, userID, productID int) error {
+// RecordView records a user viewing a product. With batching the view is buffered and inserted
+// with the next batch.
+func (r *interactionRepository) RecordView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error {
 	collection := r.db.Collection("user_product_views")
 
 	view := domain.UserProductView{
-		UserID:    userID,
-		ProductID: productID,
-		ViewedAt:  time.Now(),
+		UserID:           userID,
+		ProductID:        productID,
+		ViewedAt:         time.Now().UTC(),
+		ViewEngagement:   engagement,
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
 	}
 
+	if r.views != nil && r.views.add(ctx, view) {
+		return nil
+	}
+
+	// A retried write of the same view is already recorded
 	_, err := collection.InsertOne(ctx, view)
-	if err != nil {
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
 		return fmt.Errorf("record view: %w", err)
 	}
 
 	return nil
 }
 
-// GetUserViews retrieves products a user has viewed
-func (r *interactionRepository) GetUserViews(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error) {
+// RecordAnonymousView records a guest viewing a product
+func (r *interactionRepository) RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error {
 	collection := r.db.Collection("user_product_views")
 
-	// Aggregation pipeline to get product details
-	pipeline := []bson.M{
-		{"$match": bson.M{"user_id": userID}},
-		{"$sort": bson.M{"viewed_at": -1}},
-		{"$limit": limit},
-		{"$lookup": bson.M{
-			"from":         "products",
-			"localField":   "product_id",
-			"foreignField": "_id",
-			"as":           "product",
-		}},
-		{"$unwind": "$product"},
-		{"$project": bson.M{
-			"product_id":    "$product_id",
-			"product_name":  "$product.name",
-			"category_id":   "$product.category_id",
-			"price":         "$product.price",
-			"interacted_at": "$viewed_at",
-		}},
+	view := domain.UserProductView{
+		AnonymousID:      anonymousID,
+		ProductID:        productID,
+		ViewedAt:         time.Now().UTC(),
+		ViewEngagement:   engagement,
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
 	}
 
-	cursor, err := collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, fmt.Errorf("get user views: %w", err)
+	if r.views != nil && r.views.add(ctx, view) {
+		return nil
 	}
-	defer cursor.Close(ctx)
 
-	var interactions []domain.ProductInteraction
-	if err := cursor.All(ctx, &interactions); err != nil {
-		return nil, fmt.Errorf("decode user views: %w", err)
+	_, err := collection.InsertOne(ctx, view)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("record anonymous view: %w", err)
 	}
 
-	return interactions, nil
+	return nil
+}
+
+// GetUserViews retrieves a page of the products a user has viewed
+func (r *interactionRepository) GetUserViews(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	interactions, total, err := r.getUserInteractions(ctx, "user_product_views", "viewed_at", userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get user views: %w", err)
+	}
+	return interactions, total, nil
 }
 
 // HasViewed checks if a user has viewed a product
@@ -118,6 +141,11 @@ func (r *interactionRepository) HasViewed(ctx context.Context, userID, productID
 	return count > 0, nil
 }
 
+// GetLikedProductIDs returns which of the given products the user has liked
+func (r *interactionRepository) GetLikedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error) {
+	return r.distinctProductIDs(ctx, "user_product_likes", userID, productIDs)
+}
+
 // RecordLike records a user liking a product
 func (r *interactionRepository) RecordLike(ctx context.Context, userID, productID int) error {
 	collection := r.db.Collection("user_product_likes")
@@ -136,9 +164,10 @@ func (r *interactionRepository) RecordLike(ctx context.Context, userID, productI
 	}
 
 	like := domain.UserProductLike{
-		UserID:    userID,
-		ProductID: productID,
-		LikedAt:   time.Now(),
+		UserID:           userID,
+		ProductID:        productID,
+		LikedAt:          time.Now().UTC(),
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
 	}
 
 	_, err = collection.InsertOne(ctx, like)
@@ -168,43 +197,13 @@ func (r *interactionRepository) RemoveLike(ctx context.Context, userID, productI
 	return nil
 }
 
-// GetUserLikes retrieves products a user has liked
-func (r *interactionRepository) GetUserLikes(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error) {
-	collection := r.db.Collection("user_product_likes")
-
-	// Aggregation pipeline to get product details
-	pipeline := []bson.M{
-		{"$match": bson.M{"user_id": userID}},
-		{"$sort": bson.M{"liked_at": -1}},
-		{"$limit": limit},
-		{"$lookup": bson.M{
-			"from":         "products",
-			"localField":   "product_id",
-			"foreignField": "_id",
-			"as":           "product",
-		}},
-		{"$unwind": "$product"},
-		{"$project": bson.M{
-			"product_id":    "$product_id",
-			"product_name":  "$product.name",
-			"category_id":   "$product.category_id",
-			"price":         "$product.price",
-			"interacted_at": "$liked_at",
-		}},
-	}
-
-	cursor, err := collection.Aggregate(ctx, pipeline)
+// GetUserLikes retrieves a page of the products a user has liked
+func (r *interactionRepository) GetUserLikes(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	interactions, total, err := r.getUserInteractions(ctx, "user_product_likes", "liked_at", userID, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("get user likes: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	var interactions []domain.ProductInteraction
-	if err := cursor.All(ctx, &interactions); err != nil {
-		return nil, fmt.Errorf("decode user likes: %w", err)
+		return nil, 0, fmt.Errorf("get user likes: %w", err)
 	}
-
-	return interactions, nil
+	return interactions, total, nil
 }
 
 // HasLiked checks if a user has liked a product
@@ -224,43 +223,22 @@ func (r *interactionRepository) HasLiked(ctx context.Context, userID, productID
 
 // GetUserInteractionSummary gets a summary of all user interactions
 func (r *interactionRepository) GetUserInteractionSummary(ctx context.Context, userID int) (*domain.UserInteractionSummary, error) {
-	// Get views
-	views, err := r.GetUserViews(ctx, userID, 50)
+	// Get the latest interactions with their totals
+	views, totalViews, err := r.GetUserViews(ctx, userID, 50, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get likes
-	likes, err := r.GetUserLikes(ctx, userID, 50)
+	likes, totalLikes, err := r.GetUserLikes(ctx, userID, 50, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get purchases
-	purchases, err := r.GetUserPurchases(ctx, userID, 50)
+	purchases, totalPurchases, err := r.GetUserPurchases(ctx, userID, 50, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// Count totals
-	viewsCollection := r.db.Collection("user_product_views")
-	totalViews, err := viewsCollection.CountDocuments(ctx, bson.M{"user_id": userID})
-	if err != nil {
-		totalViews = 0
-	}
-
-	likesCollection := r.db.Collection("user_product_likes")
-	totalLikes, err := likesCollection.CountDocuments(ctx, bson.M{"user_id": userID})
-	if err != nil {
-		totalLikes = 0
-	}
-
-	purchasesCollection := r.db.Collection("user_product_purchases")
-	totalPurchases, err := purchasesCollection.CountDocuments(ctx, bson.M{"user_id": userID})
-	if err != nil {
-		totalPurchases = 0
-	}
-
 	summary := &domain.UserInteractionSummary{
 		UserID:            userID,
 		ViewedProducts:    views,
@@ -276,10 +254,11 @@ func (r *interactionRepository) GetUserInteractionSummary(ctx context.Context, u
 
 // GetAllUserViews retrieves all user views (for recommendation algorithm)
 func (r *interactionRepository) GetAllUserViews(ctx context.Context) ([]domain.UserProductView, error) {
-	collection := r.db.Collection("user_product_views")
+	collection := r.db.AnalyticsCollection("user_product_views")
 
+	// Guest views are left out until they are merged into a user
 	opts := options.Find().SetSort(bson.M{"viewed_at": -1})
-	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	cursor, err := collection.Find(ctx, bson.M{"user_id": bson.M{"$gt": 0}}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("get all views: %w", err)
 	}
@@ -295,7 +274,7 @@ func (r *interactionRepository) GetAllUserViews(ctx context.Context) ([]domain.U
 
 // GetAllUserLikes retrieves all user likes (for recommendation algorithm)
 func (r *interactionRepository) GetAllUserLikes(ctx context.Context) ([]domain.UserProductLike, error) {
-	collection := r.db.Collection("user_product_likes")
+	collection := r.db.AnalyticsCollection("user_product_likes")
 
 	opts := options.Find().SetSort(bson.M{"liked_at": -1})
 	cursor, err := collection.Find(ctx, bson.M{}, opts)
@@ -313,15 +292,20 @@ func (r *interactionRepository) GetAllUserLikes(ctx context.Context) ([]domain.U
 }
 
 // RecordPurchase records a user purchasing a product
-func (r *interactionRepository) RecordPurchase(ctx context.Context, userID, productID int, quantity int, price float64) error {
+func (r *interactionRepository) RecordPurchase(ctx context.Context, userID, productID int, quantity int, price, cost domain.Money) error {
 	collection := r.db.Collection("user_product_purchases")
 
 	purchase := domain.UserProductPurchase{
-		UserID:          userID,
-		ProductID:       productID,
-		Quantity:        quantity,
-		PriceAtPurchase: price,
-		PurchasedAt:     time.Now(),
+		UserID:           userID,
+		ProductID:        productID,
+		Quantity:         quantity,
+		PriceAtPurchase:  price,
+		CostAtPurchase:   cost,
+		PurchasedAt:      time.Now().UTC(),
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}
+	if err := r.snapshotProduct(ctx, &purchase); err != nil {
+		return err
 	}
 
 	_, err := collection.InsertOne(ctx, purchase)
@@ -332,14 +316,143 @@ func (r *interactionRepository) RecordPurchase(ctx context.Context, userID, prod
 	return nil
 }
 
-// GetUserPurchases retrieves products a user has purchased
-func (r *interactionRepository) GetUserPurchases(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error) {
+// RecordAnonymousPurchase records a guest checkout of a product
+func (r *interactionRepository) RecordAnonymousPurchase(ctx context.Context, anonymousID string, productID int, quantity int, price, cost domain.Money) error {
 	collection := r.db.Collection("user_product_purchases")
 
+	purchase := domain.UserProductPurchase{
+		AnonymousID:      anonymousID,
+		ProductID:        productID,
+		Quantity:         quantity,
+		PriceAtPurchase:  price,
+		CostAtPurchase:   cost,
+		PurchasedAt:      time.Now().UTC(),
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}
+	if err := r.snapshotProduct(ctx, &purchase); err != nil {
+		return err
+	}
+
+	_, err := collection.InsertOne(ctx, purchase)
+	if err != nil {
+		return fmt.Errorf("record anonymous purchase: %w", err)
+	}
+
+	return nil
+}
+
+// snapshotProduct copies the name, image and category of the purchased product onto the
+// purchase. A product deleted in the meantime leaves the purchase without them.
+func (r *interactionRepository) snapshotProduct(ctx context.Context, purchase *domain.UserProductPurchase) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": purchase.ProductID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "categories",
+			"localField":   "category_id",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"name":          1,
+			"image_url":     1,
+			"category_id":   1,
+			"category_name": bson.M{"$arrayElemAt": bson.A{"$category.name", 0}},
+		}}},
+	}
+
+	cursor, err := r.db.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("get purchased product: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		var product domain.ProductWithCategory
+		if err := cursor.Decode(&product); err != nil {
+			return fmt.Errorf("decode purchased product: %w", err)
+		}
+		purchase.SnapshotProduct(&product)
+	}
+	return cursor.Err()
+}
+
+// MergeAnonymous reassigns the views and purchases of an anonymous session to the user,
+// returning the number of interactions moved
+func (r *interactionRepository) MergeAnonymous(ctx context.Context, anonymousID string, userID int) (int64, error) {
+	filter := bson.M{"anonymous_id": anonymousID, "user_id": 0}
+	update := bson.M{
+		"$set":   bson.M{"user_id": userID},
+		"$unset": bson.M{"anonymous_id": ""},
+	}
+
+	var merged int64
+	for _, name := range []string{"user_product_views", "user_product_purchases"} {
+		result, err := r.db.Collection(name).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return merged, fmt.Errorf("merge %s: %w", name, err)
+		}
+		merged += result.ModifiedCount
+	}
+
+	return merged, nil
+}
+
+// GetPurchaseTotals counts purchases made since the given time and sums their revenue
+func (r *interactionRepository) GetPurchaseTotals(ctx context.Context, since time.Time) (*domain.PurchaseTotals, error) {
+	collection := r.db.AnalyticsCollection("user_product_purchases")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"purchased_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      nil,
+			"count":    bson.M{"$sum": 1},
+			"revenue":  bson.M{"$sum": purchaseAmount},
+			"currency": firstCurrency("price_at_purchase"),
+		}}},
+		moneyStage("revenue"),
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate purchase totals: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals domain.PurchaseTotals
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&totals); err != nil {
+			return nil, fmt.Errorf("decode purchase totals: %w", err)
+		}
+	}
+
+	return &totals, cursor.Err()
+}
+
+// GetUserPurchases retrieves a page of the products a user has purchased
+func (r *interactionRepository) GetUserPurchases(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	interactions, total, err := r.getUserInteractions(ctx, "user_product_purchases", "purchased_at", userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get user purchases: %w", err)
+	}
+	return interactions, total, nil
+}
+
+// getUserInteractions retrieves a page of a user's interactions in the collection, most recent
+// first and joined with their products, and counts all of them
+func (r *interactionRepository) getUserInteractions(ctx context.Context, collectionName, timeField string, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	collection := r.db.Collection(collectionName)
+	filter := bson.M{"user_id": userID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count: %w", err)
+	}
+
 	// Aggregation pipeline to get product details
 	pipeline := []bson.M{
-		{"$match": bson.M{"user_id": userID}},
-		{"$sort": bson.M{"purchased_at": -1}},
+		{"$match": filter},
+		{"$sort": bson.M{timeField: -1, "_id": -1}},
+		{"$skip": offset},
 		{"$limit": limit},
 		{"$lookup": bson.M{
 			"from":         "products",
@@ -347,28 +460,33 @@ func (r *interactionRepository) GetUserPurchases(ctx context.Context, userID int
 			"foreignField": "_id",
 			"as":           "product",
 		}},
-		{"$unwind": "$product"},
+		{"$unwind": bson.M{"path": "$product", "preserveNullAndEmptyArrays": true}},
+		// Purchases keep the product as it was bought, even once it has been deleted
+		{"$match": bson.M{"$or": bson.A{
+			bson.M{"product": bson.M{"$exists": true}},
+			bson.M{"product_name": bson.M{"$exists": true}},
+		}}},
 		{"$project": bson.M{
 			"product_id":    "$product_id",
-			"product_name":  "$product.name",
-			"category_id":   "$product.category_id",
-			"price":         "$product.price",
-			"interacted_at": "$purchased_at",
+			"product_name":  bson.M{"$ifNull": bson.A{"$product_name", "$product.name"}},
+			"category_id":   bson.M{"$ifNull": bson.A{"$category_id", "$product.category_id"}},
+			"price":         bson.M{"$ifNull": bson.A{"$price_at_purchase", "$product.price"}},
+			"interacted_at": "$" + timeField,
 		}},
 	}
 
 	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, fmt.Errorf("get user purchases: %w", err)
+		return nil, 0, err
 	}
 	defer cursor.Close(ctx)
 
 	var interactions []domain.ProductInteraction
 	if err := cursor.All(ctx, &interactions); err != nil {
-		return nil, fmt.Errorf("decode user purchases: %w", err)
+		return nil, 0, fmt.Errorf("decode: %w", err)
 	}
 
-	return interactions, nil
+	return interactions, total, nil
 }
 
 // HasPurchased checks if a user has purchased a product
@@ -386,12 +504,45 @@ func (r *interactionRepository) HasPurchased(ctx context.Context, userID, produc
 	return count > 0, nil
 }
 
+// GetPurchasedProductIDs returns which of the given products the user has purchased
+func (r *interactionRepository) GetPurchasedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error) {
+	return r.distinctProductIDs(ctx, "user_product_purchases", userID, productIDs)
+}
+
+// distinctProductIDs returns the product IDs among productIDs that the user has an interaction with
+func (r *interactionRepository) distinctProductIDs(ctx context.Context, collectionName string, userID int, productIDs []int) ([]int, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	values, err := r.db.Collection(collectionName).Distinct(ctx, "product_id", bson.M{
+		"user_id":    userID,
+		"product_id": bson.M{"$in": productIDs},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find %s: %w", collectionName, err)
+	}
+
+	ids := make([]int, 0, len(values))
+	for _, value := range values {
+		switch id := value.(type) {
+		case int32:
+			ids = append(ids, int(id))
+		case int64:
+			ids = append(ids, int(id))
+		}
+	}
+
+	return ids, nil
+}
+
 // GetAllUserPurchases retrieves all user purchases (for recommendation algorithm)
 func (r *interactionRepository) GetAllUserPurchases(ctx context.Context) ([]domain.UserProductPurchase, error) {
-	collection := r.db.Collection("user_product_purchases")
+	collection := r.db.AnalyticsCollection("user_product_purchases")
 
+	// Guest checkouts are left out until they are merged into a user
 	opts := options.Find().SetSort(bson.M{"purchased_at": -1})
-	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	cursor, err := collection.Find(ctx, bson.M{"user_id": bson.M{"$gt": 0}}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("get all purchases: %w", err)
 	}
@@ -404,3 +555,173 @@ func (r *interactionRepository) GetAllUserPurchases(ctx context.Context) ([]doma
 
 	return purchases, nil
 }
+
+// GetInteractionCounts counts the views, likes and purchases per product. A nil productIDs counts
+// all products; products without interactions are missing from the result.
+func (r *interactionRepository) GetInteractionCounts(ctx context.Context, productIDs []int) (map[int]domain.InteractionCounts, error) {
+	match := bson.M{}
+	if productIDs != nil {
+		match["product_id"] = bson.M{"$in": productIDs}
+	}
+
+	counts := make(map[int]domain.InteractionCounts)
+	for _, source := range []struct {
+		collection string
+		set        func(*domain.InteractionCounts, int64)
+	}{
+		{"user_product_views", func(c *domain.InteractionCounts, n int64) { c.Views = n }},
+		{"user_product_likes", func(c *domain.InteractionCounts, n int64) { c.Likes = n }},
+		{"user_product_purchases", func(c *domain.InteractionCounts, n int64) { c.Purchases = n }},
+	} {
+		cursor, err := r.db.AnalyticsCollection(source.collection).Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$group", Value: bson.M{"_id": "$product_id", "count": bson.M{"$sum": 1}}}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("count %s: %w", source.collection, err)
+		}
+
+		var rows []struct {
+			ProductID int   `bson:"_id"`
+			Count     int64 `bson:"count"`
+		}
+		err = cursor.All(ctx, &rows)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s counts: %w", source.collection, err)
+		}
+
+		for _, row := range rows {
+			c := counts[row.ProductID]
+			source.set(&c, row.Count)
+			counts[row.ProductID] = c
+		}
+	}
+
+	return counts, nil
+}
+
+// interactionEventSources describes how each interaction collection maps onto InteractionEvent
+var interactionEventSources = map[string]struct {
+	collection string
+	timeField  string
+	project    bson.M
+}{
+	domain.EventTypeView: {
+		collection: "user_product_views",
+		timeField:  "viewed_at",
+		project: bson.M{
+			"event_type":  bson.M{"$literal": domain.EventTypeView},
+			"user_id":     1,
+			"product_id":  1,
+			"quantity":    bson.M{"$literal": 0},
+			"price":       bson.M{"$literal": domain.Money{}},
+			"occurred_at": "$viewed_at",
+			"source":      bson.M{"$ifNull": bson.A{"$source", ""}},
+			"request_id":  bson.M{"$ifNull": bson.A{"$request_id", ""}},
+			"session_id":  bson.M{"$ifNull": bson.A{"$session_id", 0}},
+		},
+	},
+	domain.EventTypeLike: {
+		collection: "user_product_likes",
+		timeField:  "liked_at",
+		project: bson.M{
+			"event_type":  bson.M{"$literal": domain.EventTypeLike},
+			"user_id":     1,
+			"product_id":  1,
+			"quantity":    bson.M{"$literal": 0},
+			"price":       bson.M{"$literal": domain.Money{}},
+			"occurred_at": "$liked_at",
+			"source":      bson.M{"$ifNull": bson.A{"$source", ""}},
+			"request_id":  bson.M{"$ifNull": bson.A{"$request_id", ""}},
+			"session_id":  bson.M{"$ifNull": bson.A{"$session_id", 0}},
+		},
+	},
+	domain.EventTypePurchase: {
+		collection: "user_product_purchases",
+		timeField:  "purchased_at",
+		project: bson.M{
+			"event_type":  bson.M{"$literal": domain.EventTypePurchase},
+			"user_id":     1,
+			"product_id":  1,
+			"quantity":    "$quantity",
+			"price":       "$price_at_purchase",
+			"occurred_at": "$purchased_at",
+			"source":      bson.M{"$ifNull": bson.A{"$source", ""}},
+			"request_id":  bson.M{"$ifNull": bson.A{"$request_id", ""}},
+			"session_id":  bson.M{"$ifNull": bson.A{"$session_id", 0}},
+		},
+	},
+}
+
+// ExportEvents retrieves interaction events of the requested types ordered by time,
+// merging all interaction collections with $unionWith
+func (r *interactionRepository) ExportEvents(ctx context.Context, filter domain.InteractionExportFilter) ([]domain.InteractionEvent, error) {
+	if len(filter.EventTypes) == 0 {
+		return nil, domain.ErrValidation
+	}
+
+	// Build a normalizing sub-pipeline per event type
+	subPipelines := make([]bson.A, 0, len(filter.EventTypes))
+	for _, eventType := range filter.EventTypes {
+		source, ok := interactionEventSources[eventType]
+		if !ok {
+			return nil, domain.ErrValidation
+		}
+
+		stages := bson.A{}
+		timeRange := bson.M{}
+		if filter.From != nil {
+			timeRange["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			timeRange["$lt"] = *filter.To
+		}
+		if len(timeRange) > 0 {
+			stages = append(stages, bson.M{"$match": bson.M{source.timeField: timeRange}})
+		}
+		stages = append(stages, bson.M{"$project": source.project})
+
+		subPipelines = append(subPipelines, stages)
+	}
+
+	pipeline := bson.A{}
+	pipeline = append(pipeline, subPipelines[0]...)
+	for i, eventType := range filter.EventTypes[1:] {
+		pipeline = append(pipeline, bson.M{"$unionWith": bson.M{
+			"coll":     interactionEventSources[eventType].collection,
+			"pipeline": subPipelines[i+1],
+		}})
+	}
+
+	// Resume after the cursor position
+	if filter.AfterTime != nil {
+		afterID, err := primitive.ObjectIDFromHex(filter.AfterID)
+		if err != nil {
+			return nil, domain.ErrValidation
+		}
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"$or": bson.A{
+			bson.M{"occurred_at": bson.M{"$gt": *filter.AfterTime}},
+			bson.M{"occurred_at": *filter.AfterTime, "_id": bson.M{"$gt": afterID}},
+		}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": filter.Limit},
+	)
+
+	collection := r.db.AnalyticsCollection(interactionEventSources[filter.EventTypes[0]].collection)
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("export events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]domain.InteractionEvent, 0, filter.Limit)
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("decode events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/PrimeraAizen/e-comm/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithMongo(m))
+}
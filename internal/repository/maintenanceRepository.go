@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// maintenanceSettingID is the settings document holding the maintenance switch
+const maintenanceSettingID = "maintenance"
+
+type MaintenanceRepository interface {
+	// Get returns the stored maintenance switch; it is disabled until first set
+	Get(ctx context.Context) (*domain.Maintenance, error)
+	Set(ctx context.Context, maintenance *domain.Maintenance) error
+}
+
+type maintenanceRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewMaintenanceRepository(db *mongodb.MongoDB) MaintenanceRepository {
+	return &maintenanceRepository{db: db}
+}
+
+func (r *maintenanceRepository) Get(ctx context.Context) (*domain.Maintenance, error) {
+	var maintenance domain.Maintenance
+	err := r.db.Collection("settings").FindOne(ctx, bson.M{"_id": maintenanceSettingID}).Decode(&maintenance)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &domain.Maintenance{}, nil
+		}
+		return nil, fmt.Errorf("find maintenance setting: %w", err)
+	}
+	return &maintenance, nil
+}
+
+func (r *maintenanceRepository) Set(ctx context.Context, maintenance *domain.Maintenance) error {
+	_, err := r.db.Collection("settings").UpdateOne(ctx,
+		bson.M{"_id": maintenanceSettingID},
+		bson.M{"$set": maintenance},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("update maintenance setting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type bundleRepository struct {
+	store *Store
+}
+
+func NewBundleRepository(store *Store) repository.BundleRepository {
+	return &bundleRepository{store: store}
+}
+
+// Create stores a new bundle
+func (r *bundleRepository) Create(ctx context.Context, bundle *domain.Bundle) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now().UTC()
+	bundle.ID = nextID(r.store.bundles)
+	bundle.CreatedAt = now
+	bundle.UpdatedAt = now
+
+	r.store.bundles[bundle.ID] = cloneBundle(bundle)
+	return nil
+}
+
+// GetByID retrieves a bundle by ID
+func (r *bundleRepository) GetByID(ctx context.Context, id int) (*domain.Bundle, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	bundle, ok := r.store.bundles[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneBundle(bundle), nil
+}
+
+// List retrieves a page of bundles, newest first, with the total count
+func (r *bundleRepository) List(ctx context.Context, filter domain.BundleFilter, limit, offset int) ([]*domain.Bundle, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var bundles []*domain.Bundle
+	for _, bundle := range r.store.bundles {
+		if filter.IsActive != nil && bundle.IsActive != *filter.IsActive {
+			continue
+		}
+		if filter.Targeted && !bundle.OfferedTo(filter.SegmentIDs) {
+			continue
+		}
+		bundles = append(bundles, cloneBundle(bundle))
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		if !bundles[i].CreatedAt.Equal(bundles[j].CreatedAt) {
+			return bundles[i].CreatedAt.After(bundles[j].CreatedAt)
+		}
+		return bundles[i].ID > bundles[j].ID
+	})
+
+	total := int64(len(bundles))
+	bundles = bundles[min(offset, len(bundles)):]
+	if limit > 0 && len(bundles) > limit {
+		bundles = bundles[:limit]
+	}
+	return bundles, total, nil
+}
+
+// Update updates a bundle
+func (r *bundleRepository) Update(ctx context.Context, bundle *domain.Bundle) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.bundles[bundle.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	bundle.CreatedAt = stored.CreatedAt
+	bundle.UpdatedAt = time.Now().UTC()
+	r.store.bundles[bundle.ID] = cloneBundle(bundle)
+	return nil
+}
+
+// Delete deletes a bundle
+func (r *bundleRepository) Delete(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.bundles[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.store.bundles, id)
+	return nil
+}
+
+// ComponentStock returns the stock of each of the products that exist
+func (r *bundleRepository) ComponentStock(ctx context.Context, productIDs []int) (map[int]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stock := make(map[int]int, len(productIDs))
+	for _, id := range productIDs {
+		if product, ok := r.store.products[id]; ok {
+			stock[id] = product.Stock
+		}
+	}
+	return stock, nil
+}
+
+func cloneBundle(bundle *domain.Bundle) *domain.Bundle {
+	copied := *bundle
+	copied.Components = append([]domain.BundleComponent(nil), bundle.Components...)
+	copied.SegmentIDs = append([]int(nil), bundle.SegmentIDs...)
+	return &copied
+}
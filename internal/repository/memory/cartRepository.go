@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type cartRepository struct {
+	store *Store
+}
+
+func NewCartRepository(store *Store) repository.CartRepository {
+	return &cartRepository{store: store}
+}
+
+// Get retrieves a user's cart
+func (r *cartRepository) Get(ctx context.Context, userID int) (*domain.Cart, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	cart, ok := r.store.carts[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneCart(cart), nil
+}
+
+// Save stores the items of a cart, creating it if needed
+func (r *cartRepository) Save(ctx context.Context, cart *domain.Cart) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	cart.UpdatedAt = time.Now().UTC()
+
+	stored, ok := r.store.carts[cart.UserID]
+	if !ok {
+		stored = &domain.Cart{UserID: cart.UserID}
+		r.store.carts[cart.UserID] = stored
+	}
+	stored.Items = append([]domain.CartItem(nil), cart.Items...)
+	stored.UpdatedAt = cart.UpdatedAt
+	return nil
+}
+
+// Delete deletes a user's cart; deleting a missing cart is not an error
+func (r *cartRepository) Delete(ctx context.Context, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.carts, userID)
+	return nil
+}
+
+// ListIdle retrieves idle carts, longest idle first
+func (r *cartRepository) ListIdle(ctx context.Context, before time.Time, limit int) ([]*domain.Cart, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	carts := make([]*domain.Cart, 0)
+	for _, cart := range r.store.carts {
+		if len(cart.Items) > 0 && cart.UpdatedAt.Before(before) && !abandonedSinceChange(cart) {
+			carts = append(carts, cloneCart(cart))
+		}
+	}
+	sort.Slice(carts, func(i, j int) bool {
+		return carts[i].UpdatedAt.Before(carts[j].UpdatedAt)
+	})
+
+	if limit > 0 && len(carts) > limit {
+		carts = carts[:limit]
+	}
+	return carts, nil
+}
+
+func abandonedSinceChange(cart *domain.Cart) bool {
+	return cart.AbandonedAt != nil && !cart.AbandonedAt.Before(cart.UpdatedAt)
+}
+
+// Abandon links a new abandoned cart record to the cart
+func (r *cartRepository) Abandon(ctx context.Context, cart *domain.Cart, abandoned *domain.AbandonedCart) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.carts[cart.UserID]
+	if !ok || !stored.UpdatedAt.Equal(cart.UpdatedAt) || abandonedSinceChange(stored) {
+		return false, nil
+	}
+
+	abandoned.ID = nextID(r.store.abandonedCarts)
+	copied := *abandoned
+	copied.Items = append([]domain.CartItem(nil), abandoned.Items...)
+	r.store.abandonedCarts[abandoned.ID] = &copied
+
+	at := abandoned.AbandonedAt
+	stored.AbandonedCartID = abandoned.ID
+	stored.AbandonedAt = &at
+	cart.AbandonedCartID = abandoned.ID
+	cart.AbandonedAt = &at
+	return true, nil
+}
+
+// SetReminded records when the user was reminded of an abandoned cart
+func (r *cartRepository) SetReminded(ctx context.Context, id int, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	abandoned, ok := r.store.abandonedCarts[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	abandoned.RemindedAt = &at
+	return nil
+}
+
+// MarkRecovered records the checkout of an abandoned cart, returning nil if it was abandoned
+// before since or is already recovered
+func (r *cartRepository) MarkRecovered(ctx context.Context, id int, since, at time.Time, value domain.Money) (*domain.AbandonedCart, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	abandoned, ok := r.store.abandonedCarts[id]
+	if !ok || abandoned.AbandonedAt.Before(since) || abandoned.RecoveredAt != nil {
+		return nil, nil
+	}
+	abandoned.RecoveredAt = &at
+	abandoned.RecoveredValue = &value
+
+	copied := *abandoned
+	copied.Items = append([]domain.CartItem(nil), abandoned.Items...)
+	copied.RecoveredValue = clonePtr(abandoned.RecoveredValue)
+	return &copied, nil
+}
+
+// RecoveryStats summarizes the carts abandoned in [from, to)
+func (r *cartRepository) RecoveryStats(ctx context.Context, from, to time.Time) (*domain.CartRecoveryStats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stats := &domain.CartRecoveryStats{From: from, To: to}
+	for _, abandoned := range r.store.abandonedCarts {
+		if abandoned.AbandonedAt.Before(from) || !abandoned.AbandonedAt.Before(to) {
+			continue
+		}
+		stats.Abandoned++
+		stats.AbandonedValue = stats.AbandonedValue.Add(abandoned.Value)
+		if abandoned.RemindedAt != nil {
+			stats.Reminded++
+		}
+		if abandoned.RecoveredAt != nil {
+			stats.Recovered++
+			stats.RecoveredValue = stats.RecoveredValue.Add(*abandoned.RecoveredValue)
+			if abandoned.RemindedAt != nil {
+				stats.RecoveredAfterReminder++
+			}
+		}
+	}
+	stats.SetRates()
+
+	return stats, nil
+}
+
+func cloneCart(cart *domain.Cart) *domain.Cart {
+	copied := *cart
+	copied.Items = append([]domain.CartItem(nil), cart.Items...)
+	copied.AbandonedAt = clonePtr(cart.AbandonedAt)
+	return &copied
+}
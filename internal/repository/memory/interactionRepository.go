@@ -0,0 +1,571 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type interactionRepository struct {
+	store *Store
+}
+
+func NewInteractionRepository(store *Store) repository.InteractionRepository {
+	return &interactionRepository{store: store}
+}
+
+// RecordView records a user viewing a product
+func (r *interactionRepository) RecordView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.views = append(r.store.views, viewRecord{primitive.NewObjectID(), domain.UserProductView{
+		UserID:           userID,
+		ProductID:        productID,
+		ViewedAt:         time.Now().UTC(),
+		ViewEngagement:   engagement,
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}})
+	return nil
+}
+
+// RecordAnonymousView records a guest viewing a product
+func (r *interactionRepository) RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.views = append(r.store.views, viewRecord{primitive.NewObjectID(), domain.UserProductView{
+		AnonymousID:      anonymousID,
+		ProductID:        productID,
+		ViewedAt:         time.Now().UTC(),
+		ViewEngagement:   engagement,
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}})
+	return nil
+}
+
+// GetUserViews retrieves a page of the products a user has viewed, most recent first
+func (r *interactionRepository) GetUserViews(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var matches []interactionRef
+	for _, view := range r.store.views {
+		if view.UserID == userID {
+			matches = append(matches, interactionRef{view.ProductID, view.ViewedAt, nil})
+		}
+	}
+	return r.withProducts(matches, limit, offset), int64(len(matches)), nil
+}
+
+// HasViewed checks if a user has viewed a product
+func (r *interactionRepository) HasViewed(ctx context.Context, userID, productID int) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, view := range r.store.views {
+		if view.UserID == userID && view.ProductID == productID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RecordLike records a user liking a product
+func (r *interactionRepository) RecordLike(ctx context.Context, userID, productID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, like := range r.store.likes {
+		if like.UserID == userID && like.ProductID == productID {
+			return nil // Already liked, no error
+		}
+	}
+
+	r.store.likes = append(r.store.likes, likeRecord{primitive.NewObjectID(), domain.UserProductLike{
+		UserID:           userID,
+		ProductID:        productID,
+		LikedAt:          time.Now().UTC(),
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}})
+	return nil
+}
+
+// RemoveLike removes a user's like from a product
+func (r *interactionRepository) RemoveLike(ctx context.Context, userID, productID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, like := range r.store.likes {
+		if like.UserID == userID && like.ProductID == productID {
+			r.store.likes = append(r.store.likes[:i], r.store.likes[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+// GetUserLikes retrieves a page of the products a user has liked, most recent first
+func (r *interactionRepository) GetUserLikes(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var matches []interactionRef
+	for _, like := range r.store.likes {
+		if like.UserID == userID {
+			matches = append(matches, interactionRef{like.ProductID, like.LikedAt, nil})
+		}
+	}
+	return r.withProducts(matches, limit, offset), int64(len(matches)), nil
+}
+
+// HasLiked checks if a user has liked a product
+func (r *interactionRepository) HasLiked(ctx context.Context, userID, productID int) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, like := range r.store.likes {
+		if like.UserID == userID && like.ProductID == productID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetLikedProductIDs returns which of the given products the user has liked
+func (r *interactionRepository) GetLikedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var interacted []int
+	for _, like := range r.store.likes {
+		if like.UserID == userID {
+			interacted = append(interacted, like.ProductID)
+		}
+	}
+	return intersect(interacted, productIDs), nil
+}
+
+// RecordPurchase records a user purchasing a product
+func (r *interactionRepository) RecordPurchase(ctx context.Context, userID, productID int, quantity int, price, cost domain.Money) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purchase := domain.UserProductPurchase{
+		UserID:           userID,
+		ProductID:        productID,
+		Quantity:         quantity,
+		PriceAtPurchase:  price,
+		CostAtPurchase:   cost,
+		PurchasedAt:      time.Now().UTC(),
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}
+	r.snapshotProduct(&purchase)
+	r.store.purchases = append(r.store.purchases, purchaseRecord{primitive.NewObjectID(), purchase})
+	return nil
+}
+
+// RecordAnonymousPurchase records a guest checkout of a product
+func (r *interactionRepository) RecordAnonymousPurchase(ctx context.Context, anonymousID string, productID int, quantity int, price, cost domain.Money) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purchase := domain.UserProductPurchase{
+		AnonymousID:      anonymousID,
+		ProductID:        productID,
+		Quantity:         quantity,
+		PriceAtPurchase:  price,
+		CostAtPurchase:   cost,
+		PurchasedAt:      time.Now().UTC(),
+		InteractionTrace: domain.InteractionTraceFrom(ctx),
+	}
+	r.snapshotProduct(&purchase)
+	r.store.purchases = append(r.store.purchases, purchaseRecord{primitive.NewObjectID(), purchase})
+	return nil
+}
+
+// snapshotProduct copies the name, image and category of the purchased product onto the
+// purchase; the caller holds the lock
+func (r *interactionRepository) snapshotProduct(purchase *domain.UserProductPurchase) {
+	product, ok := r.store.products[purchase.ProductID]
+	if !ok {
+		return
+	}
+	snapshot := &domain.ProductWithCategory{Name: product.Name, ImageURL: product.ImageURL}
+	if product.CategoryID != nil {
+		categoryID := *product.CategoryID
+		snapshot.CategoryID = &categoryID
+		if category, ok := r.store.categories[categoryID]; ok {
+			snapshot.CategoryName = category.Name
+		}
+	}
+	purchase.SnapshotProduct(snapshot)
+}
+
+// GetUserPurchases retrieves a page of the products a user has purchased, most recent first
+func (r *interactionRepository) GetUserPurchases(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var matches []interactionRef
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID == userID {
+			matches = append(matches, interactionRef{purchase.ProductID, purchase.PurchasedAt, &purchase.UserProductPurchase})
+		}
+	}
+	return r.withProducts(matches, limit, offset), int64(len(matches)), nil
+}
+
+// HasPurchased checks if a user has purchased a product
+func (r *interactionRepository) HasPurchased(ctx context.Context, userID, productID int) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID == userID && purchase.ProductID == productID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetPurchasedProductIDs returns which of the given products the user has purchased
+func (r *interactionRepository) GetPurchasedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var interacted []int
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID == userID {
+			interacted = append(interacted, purchase.ProductID)
+		}
+	}
+	return intersect(interacted, productIDs), nil
+}
+
+// GetPurchaseTotals counts purchases made since the given time and sums their revenue
+func (r *interactionRepository) GetPurchaseTotals(ctx context.Context, since time.Time) (*domain.PurchaseTotals, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var totals domain.PurchaseTotals
+	for _, purchase := range r.store.purchases {
+		if !purchase.PurchasedAt.Before(since) {
+			totals.Count++
+			totals.Revenue = totals.Revenue.Add(purchase.PriceAtPurchase.Mul(purchase.Quantity))
+		}
+	}
+	return &totals, nil
+}
+
+// MergeAnonymous reassigns the views and purchases of an anonymous session to the user,
+// returning the number of interactions moved
+func (r *interactionRepository) MergeAnonymous(ctx context.Context, anonymousID string, userID int) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var merged int64
+	for i := range r.store.views {
+		view := &r.store.views[i]
+		if view.AnonymousID == anonymousID && view.UserID == 0 {
+			view.UserID = userID
+			view.AnonymousID = ""
+			merged++
+		}
+	}
+	for i := range r.store.purchases {
+		purchase := &r.store.purchases[i]
+		if purchase.AnonymousID == anonymousID && purchase.UserID == 0 {
+			purchase.UserID = userID
+			purchase.AnonymousID = ""
+			merged++
+		}
+	}
+
+	return merged, nil
+}
+
+// GetUserInteractionSummary gets a summary of all user interactions
+func (r *interactionRepository) GetUserInteractionSummary(ctx context.Context, userID int) (*domain.UserInteractionSummary, error) {
+	views, totalViews, err := r.GetUserViews(ctx, userID, 50, 0)
+	if err != nil {
+		return nil, err
+	}
+	likes, totalLikes, err := r.GetUserLikes(ctx, userID, 50, 0)
+	if err != nil {
+		return nil, err
+	}
+	purchases, totalPurchases, err := r.GetUserPurchases(ctx, userID, 50, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.UserInteractionSummary{
+		UserID:            userID,
+		ViewedProducts:    views,
+		LikedProducts:     likes,
+		PurchasedProducts: purchases,
+		TotalViews:        totalViews,
+		TotalLikes:        totalLikes,
+		TotalPurchases:    totalPurchases,
+	}
+	return summary, nil
+}
+
+// GetAllUserViews retrieves all user views, most recent first. Guest views are left out
+// until they are merged into a user.
+func (r *interactionRepository) GetAllUserViews(ctx context.Context) ([]domain.UserProductView, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	views := make([]domain.UserProductView, 0, len(r.store.views))
+	for _, view := range r.store.views {
+		if view.UserID > 0 {
+			views = append(views, view.UserProductView)
+		}
+	}
+	sort.SliceStable(views, func(i, j int) bool { return views[i].ViewedAt.After(views[j].ViewedAt) })
+	return views, nil
+}
+
+// GetAllUserLikes retrieves all user likes, most recent first
+func (r *interactionRepository) GetAllUserLikes(ctx context.Context) ([]domain.UserProductLike, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	likes := make([]domain.UserProductLike, 0, len(r.store.likes))
+	for _, like := range r.store.likes {
+		likes = append(likes, like.UserProductLike)
+	}
+	sort.SliceStable(likes, func(i, j int) bool { return likes[i].LikedAt.After(likes[j].LikedAt) })
+	return likes, nil
+}
+
+// GetAllUserPurchases retrieves all user purchases, most recent first. Guest checkouts are
+// left out until they are merged into a user.
+func (r *interactionRepository) GetAllUserPurchases(ctx context.Context) ([]domain.UserProductPurchase, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	purchases := make([]domain.UserProductPurchase, 0, len(r.store.purchases))
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID > 0 {
+			purchases = append(purchases, purchase.UserProductPurchase)
+		}
+	}
+	sort.SliceStable(purchases, func(i, j int) bool { return purchases[i].PurchasedAt.After(purchases[j].PurchasedAt) })
+	return purchases, nil
+}
+
+// GetInteractionCounts counts the views, likes and purchases per product. A nil productIDs counts
+// all products; products without interactions are missing from the result.
+func (r *interactionRepository) GetInteractionCounts(ctx context.Context, productIDs []int) (map[int]domain.InteractionCounts, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var wanted map[int]bool
+	if productIDs != nil {
+		wanted = make(map[int]bool, len(productIDs))
+		for _, id := range productIDs {
+			wanted[id] = true
+		}
+	}
+	counted := func(productID int) bool { return wanted == nil || wanted[productID] }
+
+	counts := make(map[int]domain.InteractionCounts)
+	for _, view := range r.store.views {
+		if counted(view.ProductID) {
+			c := counts[view.ProductID]
+			c.Views++
+			counts[view.ProductID] = c
+		}
+	}
+	for _, like := range r.store.likes {
+		if counted(like.ProductID) {
+			c := counts[like.ProductID]
+			c.Likes++
+			counts[like.ProductID] = c
+		}
+	}
+	for _, purchase := range r.store.purchases {
+		if counted(purchase.ProductID) {
+			c := counts[purchase.ProductID]
+			c.Purchases++
+			counts[purchase.ProductID] = c
+		}
+	}
+
+	return counts, nil
+}
+
+// ExportEvents retrieves interaction events of the requested types ordered by time and ID
+func (r *interactionRepository) ExportEvents(ctx context.Context, filter domain.InteractionExportFilter) ([]domain.InteractionEvent, error) {
+	if len(filter.EventTypes) == 0 {
+		return nil, domain.ErrValidation
+	}
+
+	var afterID primitive.ObjectID
+	if filter.AfterTime != nil {
+		var err error
+		if afterID, err = primitive.ObjectIDFromHex(filter.AfterID); err != nil {
+			return nil, domain.ErrValidation
+		}
+	}
+
+	type event struct {
+		id primitive.ObjectID
+		domain.InteractionEvent
+	}
+
+	r.store.mu.RLock()
+	var events []event
+	for _, eventType := range filter.EventTypes {
+		switch eventType {
+		case domain.EventTypeView:
+			for _, view := range r.store.views {
+				e := event{view.id, domain.InteractionEvent{
+					EventType: eventType, UserID: view.UserID, ProductID: view.ProductID, OccurredAt: view.ViewedAt,
+				}}
+				e.SetTrace(view.InteractionTrace)
+				events = append(events, e)
+			}
+		case domain.EventTypeLike:
+			for _, like := range r.store.likes {
+				e := event{like.id, domain.InteractionEvent{
+					EventType: eventType, UserID: like.UserID, ProductID: like.ProductID, OccurredAt: like.LikedAt,
+				}}
+				e.SetTrace(like.InteractionTrace)
+				events = append(events, e)
+			}
+		case domain.EventTypePurchase:
+			for _, purchase := range r.store.purchases {
+				e := event{purchase.id, domain.InteractionEvent{
+					EventType: eventType, UserID: purchase.UserID, ProductID: purchase.ProductID,
+					Quantity: purchase.Quantity, Price: purchase.PriceAtPurchase, OccurredAt: purchase.PurchasedAt,
+				}}
+				e.SetTrace(purchase.InteractionTrace)
+				events = append(events, e)
+			}
+		default:
+			r.store.mu.RUnlock()
+			return nil, domain.ErrValidation
+		}
+	}
+	r.store.mu.RUnlock()
+
+	selected := events[:0]
+	for _, e := range events {
+		if filter.From != nil && e.OccurredAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !e.OccurredAt.Before(*filter.To) {
+			continue
+		}
+		if filter.AfterTime != nil {
+			if e.OccurredAt.Before(*filter.AfterTime) {
+				continue
+			}
+			if e.OccurredAt.Equal(*filter.AfterTime) && e.id.Hex() <= afterID.Hex() {
+				continue
+			}
+		}
+		selected = append(selected, e)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if !selected[i].OccurredAt.Equal(selected[j].OccurredAt) {
+			return selected[i].OccurredAt.Before(selected[j].OccurredAt)
+		}
+		return selected[i].id.Hex() < selected[j].id.Hex()
+	})
+	if filter.Limit > 0 && len(selected) > filter.Limit {
+		selected = selected[:filter.Limit]
+	}
+
+	result := make([]domain.InteractionEvent, 0, len(selected))
+	for _, e := range selected {
+		e.EventID = e.id.Hex()
+		result = append(result, e.InteractionEvent)
+	}
+	return result, nil
+}
+
+// interactionRef is a product interaction before the product details are joined
+type interactionRef struct {
+	productID int
+	at        time.Time
+	purchase  *domain.UserProductPurchase // the product as it was bought, for purchases
+}
+
+// withProducts sorts interactions newest first, keeps the page of them and adds the product
+// details, dropping interactions with deleted products like the MongoDB $lookup and $unwind.
+// The caller holds the lock.
+func (r *interactionRepository) withProducts(refs []interactionRef, limit, offset int) []domain.ProductInteraction {
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].at.After(refs[j].at) })
+	refs = refs[min(offset, len(refs)):]
+	if limit > 0 && len(refs) > limit {
+		refs = refs[:limit]
+	}
+
+	var interactions []domain.ProductInteraction
+	for _, ref := range refs {
+		if purchase := ref.purchase; purchase != nil && purchase.ProductName != "" {
+			interaction := domain.ProductInteraction{
+				ProductID:    purchase.ProductID,
+				ProductName:  purchase.ProductName,
+				Price:        purchase.PriceAtPurchase,
+				InteractedAt: ref.at,
+			}
+			if purchase.CategoryID != nil {
+				interaction.CategoryID = *purchase.CategoryID
+			}
+			interactions = append(interactions, interaction)
+			continue
+		}
+
+		product, ok := r.store.products[ref.productID]
+		if !ok {
+			continue
+		}
+		interaction := domain.ProductInteraction{
+			ProductID:    product.ID,
+			ProductName:  product.Name,
+			Price:        product.Price,
+			InteractedAt: ref.at,
+		}
+		if ref.purchase != nil {
+			interaction.Price = ref.purchase.PriceAtPurchase
+		}
+		if product.CategoryID != nil {
+			interaction.CategoryID = *product.CategoryID
+		}
+		interactions = append(interactions, interaction)
+	}
+	return interactions
+}
+
+// intersect returns the distinct IDs that are in both lists
+func intersect(ids, wanted []int) []int {
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	want := make(map[int]bool, len(wanted))
+	for _, id := range wanted {
+		want[id] = true
+	}
+
+	result := make([]int, 0, len(wanted))
+	for _, id := range ids {
+		if want[id] {
+			result = append(result, id)
+			delete(want, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// outboxRecord is an outbox entry with the lease of the relay that claimed it
+type outboxRecord struct {
+	domain.OutboxEntry
+	lockedUntil time.Time
+}
+
+type outboxRepository struct {
+	store *Store
+}
+
+func NewOutboxRepository(store *Store) repository.OutboxRepository {
+	return &outboxRepository{store: store}
+}
+
+// Add records an event
+func (r *outboxRepository) Add(ctx context.Context, entry *domain.OutboxEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry.ID = primitive.NewObjectID().Hex()
+	entry.CreatedAt = time.Now().UTC()
+
+	record := &outboxRecord{OutboxEntry: *entry}
+	record.Payload = append([]byte(nil), entry.Payload...)
+	r.store.outbox = append(r.store.outbox, record)
+	return nil
+}
+
+// Claim locks up to limit undelivered events, oldest first
+func (r *outboxRepository) Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]*domain.OutboxEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now().UTC()
+	entries := make([]*domain.OutboxEntry, 0, limit)
+	for _, record := range r.store.outbox {
+		if len(entries) == limit {
+			break
+		}
+		if record.DeliveredAt != nil || record.Attempts >= maxAttempts || record.lockedUntil.After(now) {
+			continue
+		}
+		record.lockedUntil = now.Add(lease)
+		entry := record.OutboxEntry
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// MarkDelivered records a claimed event as published. Delivered events are kept; there is no
+// TTL in memory.
+func (r *outboxRepository) MarkDelivered(ctx context.Context, id string, retention time.Duration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.outboxRecord(id)
+	if record == nil {
+		return domain.ErrNotFound
+	}
+	now := time.Now().UTC()
+	record.DeliveredAt = &now
+	record.lockedUntil = time.Time{}
+	return nil
+}
+
+// MarkFailed releases a claimed event for another attempt
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.outboxRecord(id)
+	if record == nil {
+		return domain.ErrNotFound
+	}
+	record.Attempts++
+	record.LastError = reason
+	record.lockedUntil = time.Time{}
+	return nil
+}
+
+// outboxRecord finds an outbox record by ID; the caller holds the lock
+func (s *Store) outboxRecord(id string) *outboxRecord {
+	for _, record := range s.outbox {
+		if record.ID == id {
+			return record
+		}
+	}
+	return nil
+}
+
+// transactor runs functions as they are; the store applies each write atomically on its own
+type transactor struct{}
+
+func (transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,623 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type productRepository struct {
+	store *Store
+}
+
+func NewProductRepository(store *Store) repository.ProductRepository {
+	return &productRepository{store: store}
+}
+
+// Create creates a new product
+func (r *productRepository) Create(ctx context.Context, product *domain.Product) error {
+	r.store.mu.Lock()
+	product.ID = nextID(r.store.products)
+	product.CreatedAt = time.Now().UTC()
+	product.UpdatedAt = time.Now().UTC()
+	product.IsActive = true
+
+	r.store.products[product.ID] = cloneProduct(product)
+	watchers := r.store.stockWatcherFuncs()
+	r.store.mu.Unlock()
+
+	notifyStock(watchers, product)
+	return nil
+}
+
+// GetByID retrieves a product by ID
+func (r *productRepository) GetByID(ctx context.Context, id int) (*domain.Product, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	product, ok := r.store.products[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneProduct(product), nil
+}
+
+// GetByIDWithCategory retrieves a product with category information.
+// If fields is not empty, only those fields are returned.
+func (r *productRepository) GetByIDWithCategory(ctx context.Context, id int, fields []string) (*domain.ProductWithCategory, error) {
+	r.store.mu.RLock()
+	product, ok := r.store.products[id]
+	var result *domain.ProductWithCategory
+	if ok {
+		result = r.withCategory(product)
+	}
+	r.store.mu.RUnlock()
+
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	projected, err := project(result, productPaths(fields))
+	if err != nil {
+		return nil, fmt.Errorf("project product: %w", err)
+	}
+	return projected, nil
+}
+
+// Update updates a product
+func (r *productRepository) Update(ctx context.Context, product *domain.Product) error {
+	r.store.mu.Lock()
+	product.UpdatedAt = time.Now().UTC()
+
+	stored, ok := r.store.products[product.ID]
+	if !ok {
+		r.store.mu.Unlock()
+		return domain.ErrNotFound
+	}
+
+	stored.Name = product.Name
+	stored.Description = product.Description
+	stored.CategoryID = clonePtr(product.CategoryID)
+	stored.Price = product.Price
+	stored.CostPrice = product.CostPrice
+	stored.Stock = product.Stock
+	stored.ImageURL = product.ImageURL
+	stored.ImageVariants = maps.Clone(product.ImageVariants)
+	stored.IsActive = product.IsActive
+	stored.UpdatedAt = product.UpdatedAt
+	stored.PreviousPrice = clonePtr(product.PreviousPrice)
+	stored.PriceChangedAt = clonePtr(product.PriceChangedAt)
+	stored.PriceTiers = slices.Clone(product.PriceTiers)
+	stored.MinOrderQty = product.MinOrderQty
+	stored.MaxOrderQty = product.MaxOrderQty
+	stored.QtyStep = product.QtyStep
+	stored.PurchaseLimit = clonePtr(product.PurchaseLimit)
+	watchers := r.store.stockWatcherFuncs()
+	r.store.mu.Unlock()
+
+	notifyStock(watchers, product)
+	return nil
+}
+
+// SetImage sets the image of an upload unless a later upload's image is set
+func (r *productRepository) SetImage(ctx context.Context, id int, imageURL string, variants map[string]string, uploadedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	product, ok := r.store.products[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if product.ImageUploadedAt != nil && product.ImageUploadedAt.After(uploadedAt) {
+		return nil
+	}
+
+	product.ImageURL = imageURL
+	product.ImageVariants = maps.Clone(variants)
+	product.ImageUploadedAt = &uploadedAt
+	product.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// SetTranslation creates or replaces the product's translation into locale
+func (r *productRepository) SetTranslation(ctx context.Context, productID int, locale string, translation domain.ProductTranslation) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	product, ok := r.store.products[productID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if product.Translations == nil {
+		product.Translations = make(map[string]domain.ProductTranslation)
+	}
+	product.Translations[locale] = translation
+	product.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// DeleteTranslation removes the product's translation into locale
+func (r *productRepository) DeleteTranslation(ctx context.Context, productID int, locale string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	product, ok := r.store.products[productID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if _, ok := product.Translations[locale]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(product.Translations, locale)
+	product.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// Delete deletes a product
+func (r *productRepository) Delete(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.products[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.store.products, id)
+	return nil
+}
+
+// List retrieves products with filtering and pagination
+func (r *productRepository) List(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error) {
+	matches, total, err := r.query(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	paths := productPaths(filter.Fields)
+	products := make([]*domain.Product, 0, len(matches))
+	for _, match := range matches {
+		product, err := project(toProduct(match), paths)
+		if err != nil {
+			return nil, 0, fmt.Errorf("project product: %w", err)
+		}
+		products = append(products, product)
+	}
+
+	return products, total, nil
+}
+
+// ListWithCategories retrieves products with category names
+func (r *productRepository) ListWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error) {
+	matches, total, err := r.query(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	paths := productPaths(filter.Fields)
+	for i, match := range matches {
+		if matches[i], err = project(match, paths); err != nil {
+			return nil, 0, fmt.Errorf("project product: %w", err)
+		}
+	}
+	if filter.SkipCount {
+		total = 0
+	}
+
+	return matches, total, nil
+}
+
+// StreamWithCategories calls fn for each product with category name matching the filter.
+// A zero Limit streams all matching products.
+func (r *productRepository) StreamWithCategories(ctx context.Context, filter domain.ProductFilter, fn func(*domain.ProductWithCategory) error) error {
+	products, _, err := r.ListWithCategories(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search searches for products (alias for List with search query)
+func (r *productRepository) Search(ctx context.Context, query string, limit, offset int) ([]*domain.Product, int64, error) {
+	return r.List(ctx, domain.ProductFilter{
+		SearchQuery: query,
+		Limit:       limit,
+		Offset:      offset,
+	})
+}
+
+// query returns a page of the products matching the filter, newest first unless the filter
+// sorts them, and the total number of matches
+func (r *productRepository) query(filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var matches []*domain.ProductWithCategory
+	for _, stored := range r.store.products {
+		product := r.withCategory(stored)
+
+		if filter.CategoryID != nil && (product.CategoryID == nil || *product.CategoryID != *filter.CategoryID) {
+			continue
+		}
+		if filter.MinPrice != nil && product.Price.Amount < filter.MinPrice.Amount {
+			continue
+		}
+		if filter.MaxPrice != nil && product.Price.Amount > filter.MaxPrice.Amount {
+			continue
+		}
+		if filter.IsActive != nil && product.IsActive != *filter.IsActive {
+			continue
+		}
+		if filter.SearchQuery != "" && !matchText(product, filter.SearchQuery) {
+			continue
+		}
+		if len(filter.NamePrefixes) > 0 && !matchNamePrefixes(product.Name, filter.NamePrefixes) {
+			continue
+		}
+		matched, err := matchConditions(product, filter.Conditions)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !matched {
+			continue
+		}
+
+		matches = append(matches, product)
+	}
+
+	total := int64(len(matches))
+	sortProducts(matches, filter.Sort, domain.SortField{Field: "created_at", Descending: true})
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(matches) {
+			return nil, total, nil
+		}
+		matches = matches[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(matches) {
+		matches = matches[:filter.Limit]
+	}
+
+	return matches, total, nil
+}
+
+// withCategory copies a stored product and adds its category name; the caller holds the lock
+func (r *productRepository) withCategory(stored *domain.Product) *domain.ProductWithCategory {
+	product := cloneProduct(stored)
+	result := &domain.ProductWithCategory{
+		ID:           product.ID,
+		Name:         product.Name,
+		Description:  product.Description,
+		CategoryID:   product.CategoryID,
+		Price:        product.Price,
+		CostPrice:    product.CostPrice,
+		Stock:        product.Stock,
+		ImageURL:     product.ImageURL,
+		IsActive:     product.IsActive,
+		CreatedAt:    product.CreatedAt,
+		UpdatedAt:    product.UpdatedAt,
+		Translations: product.Translations,
+		PriceTiers:   product.PriceTiers,
+		MinOrderQty:  product.MinOrderQty,
+		MaxOrderQty:  product.MaxOrderQty,
+		QtyStep:      product.QtyStep,
+
+		PurchaseLimit: product.PurchaseLimit,
+		FlashSale:     product.FlashSale,
+
+		ImageVariants: product.ImageVariants,
+	}
+	if product.CategoryID != nil {
+		if category, ok := r.store.categories[*product.CategoryID]; ok {
+			result.CategoryName = category.Name
+		}
+	}
+	return result
+}
+
+func toProduct(product *domain.ProductWithCategory) *domain.Product {
+	return &domain.Product{
+		ID:           product.ID,
+		Name:         product.Name,
+		Description:  product.Description,
+		CategoryID:   product.CategoryID,
+		Price:        product.Price,
+		CostPrice:    product.CostPrice,
+		Stock:        product.Stock,
+		ImageURL:     product.ImageURL,
+		IsActive:     product.IsActive,
+		CreatedAt:    product.CreatedAt,
+		UpdatedAt:    product.UpdatedAt,
+		Translations: product.Translations,
+		PriceTiers:   product.PriceTiers,
+		MinOrderQty:  product.MinOrderQty,
+		MaxOrderQty:  product.MaxOrderQty,
+		QtyStep:      product.QtyStep,
+
+		PurchaseLimit: product.PurchaseLimit,
+		FlashSale:     product.FlashSale,
+
+		ImageVariants: product.ImageVariants,
+	}
+}
+
+// CreateCategory creates a new category
+func (r *productRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.findCategoryByName(category.Name) != nil {
+		return fmt.Errorf("category with this name already exists: %w", domain.ErrAlreadyExists)
+	}
+
+	category.ID = nextID(r.store.categories)
+	category.CreatedAt = time.Now().UTC()
+	category.UpdatedAt = time.Now().UTC()
+
+	r.store.categories[category.ID] = cloneCategory(category)
+	return nil
+}
+
+// GetCategoryByID retrieves a category by ID
+func (r *productRepository) GetCategoryByID(ctx context.Context, id int) (*domain.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	category, ok := r.store.categories[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneCategory(category), nil
+}
+
+// GetCategoryByName retrieves a category by name
+func (r *productRepository) GetCategoryByName(ctx context.Context, name string) (*domain.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	category := r.findCategoryByName(name)
+	if category == nil {
+		return nil, domain.ErrNotFound
+	}
+	return cloneCategory(category), nil
+}
+
+// ListCategories retrieves all categories sorted by name
+func (r *productRepository) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	categories := make([]*domain.Category, 0, len(r.store.categories))
+	for _, category := range r.store.categories {
+		categories = append(categories, cloneCategory(category))
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+
+	return categories, nil
+}
+
+// UpdateCategory updates a category
+func (r *productRepository) UpdateCategory(ctx context.Context, category *domain.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	category.UpdatedAt = time.Now().UTC()
+
+	stored, ok := r.store.categories[category.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if existing := r.findCategoryByName(category.Name); existing != nil && existing.ID != category.ID {
+		return fmt.Errorf("update category: %w", domain.ErrAlreadyExists)
+	}
+
+	stored.Name = category.Name
+	stored.Description = category.Description
+	stored.ParentID = clonePtr(category.ParentID)
+	stored.UpdatedAt = category.UpdatedAt
+	return nil
+}
+
+// DeleteCategory deletes a category
+func (r *productRepository) DeleteCategory(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.categories[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.store.categories, id)
+	return nil
+}
+
+// CountCategoryContents counts the products and direct child categories of a category
+func (r *productRepository) CountCategoryContents(ctx context.Context, id int) (int64, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var products, children int64
+	for _, product := range r.store.products {
+		if product.CategoryID != nil && *product.CategoryID == id {
+			products++
+		}
+	}
+	for _, category := range r.store.categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			children++
+		}
+	}
+
+	return products, children, nil
+}
+
+// CountActiveProductsByCategory counts active products per category, keyed by category ID.
+// Categories without active products are absent from the map.
+func (r *productRepository) CountActiveProductsByCategory(ctx context.Context) (map[int]int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	counts := make(map[int]int64)
+	for _, product := range r.store.products {
+		if product.IsActive && product.CategoryID != nil {
+			counts[*product.CategoryID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *productRepository) NameVocabulary(ctx context.Context) (map[string]int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	vocabulary := make(map[string]int64)
+	for _, product := range r.store.products {
+		if !product.IsActive {
+			continue
+		}
+		for _, word := range domain.NameWords(product.Name) {
+			vocabulary[word]++
+		}
+	}
+	return vocabulary, nil
+}
+
+// MergeCategory moves the products and child categories of the source category to the target
+// and deletes the source
+func (r *productRepository) MergeCategory(ctx context.Context, sourceID, targetID int) (*domain.CategoryMergeResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.categories[sourceID]; !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	result := &domain.CategoryMergeResult{SourceID: sourceID, TargetID: targetID, MovedProductIDs: []int{}}
+	now := time.Now().UTC()
+
+	for _, product := range r.store.products {
+		if product.CategoryID != nil && *product.CategoryID == sourceID {
+			target := targetID
+			product.CategoryID = &target
+			product.UpdatedAt = now
+			result.MovedProductIDs = append(result.MovedProductIDs, product.ID)
+		}
+	}
+	sort.Ints(result.MovedProductIDs)
+	result.MovedProducts = int64(len(result.MovedProductIDs))
+
+	for _, category := range r.store.categories {
+		if category.ParentID != nil && *category.ParentID == sourceID {
+			target := targetID
+			category.ParentID = &target
+			category.UpdatedAt = now
+			result.MovedCategories++
+		}
+	}
+
+	delete(r.store.categories, sourceID)
+	return result, nil
+}
+
+// findCategoryByName returns the stored category with the name; the caller holds the lock
+func (r *productRepository) findCategoryByName(name string) *domain.Category {
+	for _, category := range r.store.categories {
+		if category.Name == name {
+			return category
+		}
+	}
+	return nil
+}
+
+// GetProductStatistics retrieves statistics for a product
+func (r *productRepository) GetProductStatistics(ctx context.Context, productID int) (*domain.ProductStatistics, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	product, ok := r.store.products[productID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	stats := &domain.ProductStatistics{
+		ProductID:   productID,
+		ProductName: product.Name,
+	}
+	for _, view := range r.store.views {
+		if view.ProductID == productID {
+			stats.ViewCount++
+		}
+	}
+	for _, like := range r.store.likes {
+		if like.ProductID == productID {
+			stats.LikeCount++
+		}
+	}
+	for _, purchase := range r.store.purchases {
+		if purchase.ProductID == productID {
+			stats.PurchaseCount++
+		}
+	}
+
+	return stats, nil
+}
+
+// RefreshProductStatistics is a no-op; statistics are calculated on demand
+func (r *productRepository) RefreshProductStatistics(ctx context.Context) error {
+	return nil
+}
+
+// WatchStock calls fn whenever a product's stock is written, until ctx is cancelled
+func (r *productRepository) WatchStock(ctx context.Context, fn func(domain.StockEvent)) error {
+	r.store.mu.Lock()
+	id := r.store.nextWatcher
+	r.store.nextWatcher++
+	r.store.stockWatchers[id] = fn
+	r.store.mu.Unlock()
+
+	<-ctx.Done()
+
+	r.store.mu.Lock()
+	delete(r.store.stockWatchers, id)
+	r.store.mu.Unlock()
+
+	return nil
+}
+
+// stockWatcherFuncs returns the functions registered by WatchStock; the caller holds the lock
+func (s *Store) stockWatcherFuncs() []func(domain.StockEvent) {
+	watchers := make([]func(domain.StockEvent), 0, len(s.stockWatchers))
+	for _, fn := range s.stockWatchers {
+		watchers = append(watchers, fn)
+	}
+	return watchers
+}
+
+// notifyStock passes a written product's stock to the watchers
+func notifyStock(watchers []func(domain.StockEvent), product *domain.Product) {
+	for _, fn := range watchers {
+		fn(domain.StockEvent{
+			ProductID: product.ID,
+			Stock:     product.Stock,
+			InStock:   product.Stock > 0,
+			UpdatedAt: product.UpdatedAt,
+		})
+	}
+}
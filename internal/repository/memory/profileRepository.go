@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type profileRepository struct {
+	store *Store
+}
+
+func NewProfileRepository(store *Store) repository.ProfileRepository {
+	return &profileRepository{store: store}
+}
+
+// Create creates a new profile
+func (r *profileRepository) Create(ctx context.Context, profile *domain.Profile) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.profiles[profile.UserID]; ok {
+		return fmt.Errorf("create profile: %w", domain.ErrAlreadyExists)
+	}
+
+	r.store.profileSeq++
+	profile.ID = r.store.profileSeq
+
+	now := time.Now().UTC()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+
+	r.store.profiles[profile.UserID] = cloneProfile(profile)
+	return nil
+}
+
+// GetByUserID gets profile by user ID
+func (r *profileRepository) GetByUserID(ctx context.Context, userID int) (*domain.Profile, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	profile, ok := r.store.profiles[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneProfile(profile), nil
+}
+
+// GetByUserIDWithFields gets profile by user ID, returning only the selected ProfileFields
+func (r *profileRepository) GetByUserIDWithFields(ctx context.Context, userID int, fields []string) (*domain.Profile, error) {
+	profile, err := r.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	projected, err := project(profile, selectedPaths(fields, domain.ProfileFields))
+	if err != nil {
+		return nil, fmt.Errorf("project profile: %w", err)
+	}
+	return projected, nil
+}
+
+// Update updates a profile
+func (r *profileRepository) Update(ctx context.Context, profile *domain.Profile) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	profile.UpdatedAt = time.Now().UTC()
+
+	stored, ok := r.store.profiles[profile.UserID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	updated := cloneProfile(profile)
+	updated.ID = stored.ID
+	updated.Preferences = stored.Preferences
+	updated.CreatedAt = stored.CreatedAt
+	r.store.profiles[profile.UserID] = updated
+	return nil
+}
+
+// Delete deletes a profile
+func (r *profileRepository) Delete(ctx context.Context, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.profiles[userID]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.store.profiles, userID)
+	return nil
+}
+
+// MarkPhoneVerified marks the profile phone as verified if it still equals phone
+func (r *profileRepository) MarkPhoneVerified(ctx context.Context, userID int, phone string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	profile, ok := r.store.profiles[userID]
+	if !ok || profile.Phone == nil || *profile.Phone != phone {
+		return domain.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	profile.PhoneVerified = true
+	profile.PhoneVerifiedAt = &now
+	profile.UpdatedAt = now
+	return nil
+}
+
+// RecordChange appends an entry to the user's profile change log
+func (r *profileRepository) RecordChange(ctx context.Context, change *domain.ProfileChange) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now().UTC()
+	}
+
+	recorded := *change
+	recorded.Fields = append([]string(nil), change.Fields...)
+	r.store.profileChanges = append(r.store.profileChanges, recorded)
+	return nil
+}
+
+// UpdatePreferences replaces the preferences stored on the user's profile
+func (r *profileRepository) UpdatePreferences(ctx context.Context, userID int, preferences *domain.Preferences) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	profile, ok := r.store.profiles[userID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	profile.Preferences = clonePreferences(*preferences)
+	profile.UpdatedAt = time.Now().UTC()
+	return nil
+}
@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// selectedPaths maps selected JSON field names to document fields. It returns nil when no
+// fields are selected or none are stored, meaning the whole document.
+func selectedPaths(fields []string, allowed map[string]string) map[string]bool {
+	paths := make(map[string]bool)
+	for _, field := range fields {
+		if path := allowed[field]; path != "" {
+			paths[path] = true
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return paths
+}
+
+// project keeps only the document fields in paths, like a MongoDB inclusion projection.
+// The value goes through BSON so the result is what the MongoDB repository would decode.
+func project[T any](value *T, paths map[string]bool) (*T, error) {
+	if paths == nil {
+		return value, nil
+	}
+
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.D
+	if err := bson.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	projected := bson.D{}
+	for _, element := range document {
+		if paths[element.Key] {
+			projected = append(projected, element)
+		}
+	}
+
+	data, err = bson.Marshal(projected)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if err := bson.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// productPaths is selectedPaths for products. Translations are kept along with the name or
+// description so the response can be localized.
+func productPaths(fields []string) map[string]bool {
+	paths := selectedPaths(fields, domain.ProductFields)
+	if paths != nil && (paths["name"] || paths["description"]) {
+		paths["translations"] = true
+	}
+	return paths
+}
+
+// productField returns the value of a product document field, or nil when it is not set
+func productField(product *domain.ProductWithCategory, path string) interface{} {
+	switch path {
+	case "_id":
+		return product.ID
+	case "name":
+		return product.Name
+	case "description":
+		return product.Description
+	case "category_id":
+		if product.CategoryID == nil {
+			return nil
+		}
+		return *product.CategoryID
+	case "category_name":
+		return product.CategoryName
+	case "price.amount":
+		return product.Price.Amount
+	case "stock":
+		return product.Stock
+	case "image_url":
+		return product.ImageURL
+	case "is_active":
+		return product.IsActive
+	case "created_at":
+		return product.CreatedAt
+	case "updated_at":
+		return product.UpdatedAt
+	}
+	return nil
+}
+
+// compareValues orders two field values the way MongoDB does for the types products use:
+// missing values first, numbers by value whatever their Go type. ok is false when the values
+// are of different kinds and so never equal.
+func compareValues(a, b interface{}) (result int, ok bool) {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0, true
+		case a == nil:
+			return -1, false
+		default:
+			return 1, false
+		}
+	}
+
+	if x, isNumber := toFloat(a); isNumber {
+		y, isNumber := toFloat(b)
+		if !isNumber {
+			return -1, false
+		}
+		return compareOrdered(x, y), true
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, isString := b.(string); isString {
+			return compareOrdered(x, y), true
+		}
+	case bool:
+		if y, isBool := b.(bool); isBool {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			default:
+				return 1, true
+			}
+		}
+	case time.Time:
+		if y, isTime := b.(time.Time); isTime {
+			return x.Compare(y), true
+		}
+	}
+	return -1, false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func compareOrdered[T int | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matchConditions reports whether the product satisfies all parsed filter conditions. Fields
+// and operators are checked against the allowlist like the MongoDB repository does.
+func matchConditions(product *domain.ProductWithCategory, conditions []domain.FilterCondition) (bool, error) {
+	for _, condition := range conditions {
+		field, ok := domain.ProductFilterFields[condition.Field]
+		if !ok {
+			return false, fmt.Errorf("field %q cannot be filtered: %w", condition.Field, domain.ErrValidation)
+		}
+		if !field.Type.AllowsOperator(condition.Operator) || len(condition.Values) == 0 {
+			return false, fmt.Errorf("invalid condition on %q: %w", condition.Field, domain.ErrValidation)
+		}
+
+		value := productField(product, field.Path)
+		cmp, comparable := compareValues(value, condition.Values[0])
+
+		var matched bool
+		switch condition.Operator {
+		case domain.FilterEq:
+			matched = comparable && cmp == 0
+		case domain.FilterNe:
+			matched = !comparable || cmp != 0
+		case domain.FilterGt:
+			matched = comparable && cmp > 0
+		case domain.FilterGte:
+			matched = comparable && cmp >= 0
+		case domain.FilterLt:
+			matched = comparable && cmp < 0
+		case domain.FilterLte:
+			matched = comparable && cmp <= 0
+		case domain.FilterIn:
+			for _, candidate := range condition.Values {
+				if cmp, comparable := compareValues(value, candidate); comparable && cmp == 0 {
+					matched = true
+					break
+				}
+			}
+		default:
+			return false, fmt.Errorf("invalid condition on %q: %w", condition.Field, domain.ErrValidation)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchText reports whether any word of the query occurs in the product name or description
+func matchText(product *domain.ProductWithCategory, query string) bool {
+	text := strings.ToLower(product.Name + " " + product.Description)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNamePrefixes reports whether a word of the name starts with any of the prefixes
+func matchNamePrefixes(name string, prefixes []string) bool {
+	for _, word := range domain.NameWords(name) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(word, strings.ToLower(prefix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortProducts orders products by the sort keys, or by defaultSort when there are none, with
+// the ID as the final tiebreaker like the MongoDB repository
+func sortProducts(products []*domain.ProductWithCategory, keys []domain.SortField, defaultSort ...domain.SortField) {
+	if len(keys) == 0 {
+		keys = defaultSort
+	}
+
+	type sortPath struct {
+		path       string
+		descending bool
+	}
+	paths := make([]sortPath, 0, len(keys)+1)
+	for _, key := range keys {
+		if path, ok := domain.ProductSortFields[key.Field]; ok {
+			paths = append(paths, sortPath{path, key.Descending})
+		}
+	}
+	paths = append(paths, sortPath{path: "_id"})
+
+	sort.SliceStable(products, func(i, j int) bool {
+		for _, key := range paths {
+			cmp, _ := compareValues(productField(products[i], key.path), productField(products[j], key.path))
+			if cmp == 0 {
+				continue
+			}
+			if key.descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type recommendationRepository struct {
+	store *Store
+}
+
+func NewRecommendationRepository(store *Store) repository.RecommendationRepository {
+	return &recommendationRepository{store: store}
+}
+
+// SaveFeedback stores user feedback for a product, replacing any previous feedback
+func (r *recommendationRepository) SaveFeedback(ctx context.Context, feedback *domain.RecommendationFeedback) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	feedback.CreatedAt = time.Now().UTC()
+
+	for i, existing := range r.store.feedback {
+		if existing.UserID == feedback.UserID && existing.ProductID == feedback.ProductID {
+			r.store.feedback[i] = *feedback
+			return nil
+		}
+	}
+	r.store.feedback = append(r.store.feedback, *feedback)
+	return nil
+}
+
+// GetFeedbackProductIDs retrieves IDs of products the user gave feedback on
+func (r *recommendationRepository) GetFeedbackProductIDs(ctx context.Context, userID int) ([]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	productIDs := make([]int, 0)
+	for _, feedback := range r.store.feedback {
+		if feedback.UserID == userID {
+			productIDs = append(productIDs, feedback.ProductID)
+		}
+	}
+	return productIDs, nil
+}
+
+// RecordClick records a click on a recommended product
+func (r *recommendationRepository) RecordClick(ctx context.Context, click *domain.RecommendationClick) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	click.ClickedAt = time.Now().UTC()
+	r.store.clicks = append(r.store.clicks, *click)
+	return nil
+}
+
+// SaveFactors replaces the factor vectors of the previous model
+func (r *recommendationRepository) SaveFactors(ctx context.Context, userFactors, itemFactors []domain.FactorVector, trainedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	trainedAt = trainedAt.Truncate(time.Millisecond)
+	r.store.userFactors = factorMap(userFactors, trainedAt)
+	r.store.itemFactors = factorMap(itemFactors, trainedAt)
+	return nil
+}
+
+// GetUserFactors retrieves the factor vector of a user
+func (r *recommendationRepository) GetUserFactors(ctx context.Context, userID int) (*domain.FactorVector, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	factors, ok := r.store.userFactors[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneFactors(factors), nil
+}
+
+// GetAllItemFactors retrieves factor vectors of all products, ordered by product ID
+func (r *recommendationRepository) GetAllItemFactors(ctx context.Context) ([]domain.FactorVector, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	factors := make([]domain.FactorVector, 0, len(r.store.itemFactors))
+	for _, vector := range r.store.itemFactors {
+		factors = append(factors, *cloneFactors(vector))
+	}
+	sort.Slice(factors, func(i, j int) bool { return factors[i].ID < factors[j].ID })
+	return factors, nil
+}
+
+func factorMap(vectors []domain.FactorVector, trainedAt time.Time) map[int]*domain.FactorVector {
+	factors := make(map[int]*domain.FactorVector, len(vectors))
+	for i := range vectors {
+		vector := cloneFactors(&vectors[i])
+		vector.UpdatedAt = trainedAt
+		factors[vector.ID] = vector
+	}
+	return factors
+}
+
+func cloneFactors(vector *domain.FactorVector) *domain.FactorVector {
+	copied := *vector
+	copied.Factors = append([]float64(nil), vector.Factors...)
+	return &copied
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type riskRepository struct {
+	store *Store
+}
+
+func NewRiskRepository(store *Store) repository.RiskRepository {
+	return &riskRepository{store: store}
+}
+
+// CountPurchases counts the purchase records of a buyer since the given time
+func (r *riskRepository) CountPurchases(ctx context.Context, userID int, anonymousID string, since time.Time) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var count int64
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID != userID || userID == 0 && purchase.AnonymousID != anonymousID {
+			continue
+		}
+		if !purchase.PurchasedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateReview queues a flagged checkout for review
+func (r *riskRepository) CreateReview(ctx context.Context, review *domain.RiskReview) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	review.ID = nextID(r.store.riskReviews)
+	review.Status = domain.RiskReviewPending
+	review.CreatedAt = time.Now().UTC()
+
+	r.store.riskReviews[review.ID] = cloneRiskReview(review)
+	return nil
+}
+
+// ListReviews retrieves a page of reviews, oldest first, with the total count
+func (r *riskRepository) ListReviews(ctx context.Context, status string, limit, offset int) ([]*domain.RiskReview, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	reviews := make([]*domain.RiskReview, 0)
+	for _, review := range r.store.riskReviews {
+		if status == "" || review.Status == status {
+			reviews = append(reviews, cloneRiskReview(review))
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.Before(reviews[j].CreatedAt)
+		}
+		return reviews[i].ID < reviews[j].ID
+	})
+
+	total := int64(len(reviews))
+	reviews = reviews[min(offset, len(reviews)):]
+	if limit > 0 && len(reviews) > limit {
+		reviews = reviews[:limit]
+	}
+	return reviews, total, nil
+}
+
+// ResolveReview sets the outcome of a pending review
+func (r *riskRepository) ResolveReview(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.RiskReview, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	review, ok := r.store.riskReviews[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if review.Status != domain.RiskReviewPending {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	now := time.Now().UTC()
+	review.Status = status
+	review.ReviewedBy = reviewerID
+	review.ReviewedAt = &now
+	if note != "" {
+		review.Note = note
+	}
+	return cloneRiskReview(review), nil
+}
+
+func cloneRiskReview(review *domain.RiskReview) *domain.RiskReview {
+	copied := *review
+	copied.Lines = append([]domain.PurchaseLine(nil), review.Lines...)
+	copied.Signals = append([]domain.RiskSignal(nil), review.Signals...)
+	copied.ReviewedAt = clonePtr(review.ReviewedAt)
+	return &copied
+}
@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type stockRepository struct {
+	store *Store
+}
+
+func NewStockRepository(store *Store) repository.StockRepository {
+	return &stockRepository{store: store}
+}
+
+// Adjust changes the product's stock by the adjustment's delta and records it in the ledger.
+// With a WarehouseID the quantity at that warehouse changes as well. Stock never goes below
+// zero, in total or at the warehouse: the change is refused with domain.ErrInsufficientStock
+// instead.
+func (r *stockRepository) Adjust(ctx context.Context, adjustment *domain.StockAdjustment) (*domain.Product, error) {
+	products, err := r.AdjustMany(ctx, []*domain.StockAdjustment{adjustment})
+	if err != nil {
+		return nil, err
+	}
+
+	return products[0], nil
+}
+
+// AdjustMany applies the adjustments, each to a different product, as Adjust does: either all
+// of them are applied or none is
+func (r *stockRepository) AdjustMany(ctx context.Context, adjustments []*domain.StockAdjustment) ([]*domain.Product, error) {
+	r.store.mu.Lock()
+	for _, adjustment := range adjustments {
+		stored, ok := r.store.products[adjustment.ProductID]
+		if !ok {
+			r.store.mu.Unlock()
+			return nil, domain.ErrNotFound
+		}
+		if stored.Stock+adjustment.Delta < 0 {
+			r.store.mu.Unlock()
+			return nil, domain.ErrInsufficientStock
+		}
+		if adjustment.WarehouseID != 0 && adjustment.Delta < 0 {
+			level, ok := r.store.inventory[inventoryKey{productID: adjustment.ProductID, warehouseID: adjustment.WarehouseID}]
+			if !ok || level.Quantity+adjustment.Delta < 0 {
+				r.store.mu.Unlock()
+				return nil, domain.ErrInsufficientStock
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	products := make([]*domain.Product, len(adjustments))
+	for i, adjustment := range adjustments {
+		if adjustment.WarehouseID != 0 {
+			r.adjustLocation(adjustment.ProductID, adjustment.WarehouseID, adjustment.Delta)
+		}
+
+		stored := r.store.products[adjustment.ProductID]
+		stored.Stock += adjustment.Delta
+		stored.UpdatedAt = now
+
+		adjustment.ID = len(r.store.stockLedger) + 1
+		adjustment.StockAfter = stored.Stock
+		adjustment.CreatedAt = now
+		r.store.stockLedger = append(r.store.stockLedger, *adjustment)
+		products[i] = cloneProduct(stored)
+	}
+
+	watchers := r.store.stockWatcherFuncs()
+	r.store.mu.Unlock()
+
+	for _, product := range products {
+		notifyStock(watchers, product)
+	}
+	return products, nil
+}
+
+// adjustLocation changes the product's quantity at the warehouse by delta, reporting false if
+// it would go below zero. The caller holds the lock.
+func (r *stockRepository) adjustLocation(productID, warehouseID, delta int) bool {
+	key := inventoryKey{productID: productID, warehouseID: warehouseID}
+	level, ok := r.store.inventory[key]
+	if !ok {
+		level = &domain.InventoryLevel{ProductID: productID, WarehouseID: warehouseID}
+	}
+	if level.Quantity+delta < 0 {
+		return false
+	}
+
+	level.Quantity += delta
+	level.UpdatedAt = time.Now().UTC()
+	r.store.inventory[key] = level
+	return true
+}
+
+// Transfer moves stock between warehouses, or from unallocated stock to a warehouse, and
+// records it in the ledger as a pair of adjustments
+func (r *stockRepository) Transfer(ctx context.Context, transfer *domain.StockTransfer) ([]domain.StockAdjustment, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	product, ok := r.store.products[transfer.ProductID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	if transfer.FromWarehouseID != 0 {
+		if !r.adjustLocation(transfer.ProductID, transfer.FromWarehouseID, -transfer.Quantity) {
+			return nil, domain.ErrInsufficientStock
+		}
+	} else {
+		allocated := 0
+		for key, level := range r.store.inventory {
+			if key.productID == transfer.ProductID {
+				allocated += level.Quantity
+			}
+		}
+		if product.Stock-allocated < transfer.Quantity {
+			return nil, domain.ErrInsufficientStock
+		}
+	}
+	r.adjustLocation(transfer.ProductID, transfer.ToWarehouseID, transfer.Quantity)
+
+	now := time.Now().UTC()
+	adjustments := []domain.StockAdjustment{
+		{WarehouseID: transfer.FromWarehouseID, Delta: -transfer.Quantity},
+		{WarehouseID: transfer.ToWarehouseID, Delta: transfer.Quantity},
+	}
+	for i := range adjustments {
+		adjustments[i].ID = len(r.store.stockLedger) + 1
+		adjustments[i].ProductID = transfer.ProductID
+		adjustments[i].Reason = domain.StockReasonTransfer
+		adjustments[i].Note = transfer.Note
+		adjustments[i].ActorID = transfer.ActorID
+		adjustments[i].StockAfter = product.Stock
+		adjustments[i].CreatedAt = now
+		r.store.stockLedger = append(r.store.stockLedger, adjustments[i])
+	}
+	return adjustments, nil
+}
+
+// GetLocations retrieves the product's quantity at each warehouse holding it, ordered by
+// warehouse code
+func (r *stockRepository) GetLocations(ctx context.Context, productID int) ([]domain.LocationStock, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	locations := make([]domain.LocationStock, 0)
+	for key, level := range r.store.inventory {
+		warehouse, ok := r.store.warehouses[key.warehouseID]
+		if key.productID != productID || level.Quantity <= 0 || !ok {
+			continue
+		}
+		locations = append(locations, domain.LocationStock{
+			WarehouseID: warehouse.ID,
+			Code:        warehouse.Code,
+			Name:        warehouse.Name,
+			Quantity:    level.Quantity,
+			IsActive:    warehouse.IsActive,
+		})
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Code < locations[j].Code })
+	return locations, nil
+}
+
+// ListInventory retrieves a page of the products held at a warehouse, ordered by product ID,
+// with the total count
+func (r *stockRepository) ListInventory(ctx context.Context, warehouseID int, limit, offset int) ([]domain.InventoryLevel, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	levels := make([]domain.InventoryLevel, 0)
+	for key, level := range r.store.inventory {
+		if key.warehouseID == warehouseID && level.Quantity > 0 {
+			levels = append(levels, *level)
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].ProductID < levels[j].ProductID })
+
+	total := int64(len(levels))
+	levels = levels[min(offset, len(levels)):]
+	if limit > 0 && len(levels) > limit {
+		levels = levels[:limit]
+	}
+	return levels, total, nil
+}
+
+// Record adds an adjustment to the ledger for stock the caller has already written
+func (r *stockRepository) Record(ctx context.Context, adjustment *domain.StockAdjustment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	adjustment.ID = len(r.store.stockLedger) + 1
+	adjustment.CreatedAt = time.Now().UTC()
+	r.store.stockLedger = append(r.store.stockLedger, *adjustment)
+	return nil
+}
+
+// List retrieves a page of the product's stock adjustments, newest first, with the total count
+func (r *stockRepository) List(ctx context.Context, productID int, limit, offset int) ([]domain.StockAdjustment, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	adjustments := make([]domain.StockAdjustment, 0)
+	for _, adjustment := range r.store.stockLedger {
+		if adjustment.ProductID == productID {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		if !adjustments[i].CreatedAt.Equal(adjustments[j].CreatedAt) {
+			return adjustments[i].CreatedAt.After(adjustments[j].CreatedAt)
+		}
+		return adjustments[i].ID > adjustments[j].ID
+	})
+
+	total := int64(len(adjustments))
+	adjustments = adjustments[min(offset, len(adjustments)):]
+	if limit > 0 && len(adjustments) > limit {
+		adjustments = adjustments[:limit]
+	}
+	return adjustments, total, nil
+}
+
+// SumByReason totals the product's stock adjustments per reason
+func (r *stockRepository) SumByReason(ctx context.Context, productID int) (map[string]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	totals := make(map[string]int)
+	for _, adjustment := range r.store.stockLedger {
+		if adjustment.ProductID == productID {
+			totals[adjustment.Reason] += adjustment.Delta
+		}
+	}
+	return totals, nil
+}
@@ -0,0 +1,246 @@
+// Package memory implements repositories in memory so services can be unit tested and
+// benchmarked without MongoDB.
+//
+// The repositories share a Store, so lookups that join collections in MongoDB, such as the
+// product names of a user's views, see the same data:
+//
+//	store := memory.NewStore()
+//	repos := store.Repositories()
+//	outbox, _ := service.NewOutbox(repos.Outbox, nil, cfg)
+//	products := service.NewProductService(repos.Product, repos.Stock, repos.Warehouse, nil, repos.Transactor, outbox, "USD", nil)
+//
+// They follow the MongoDB implementations: the same IDs, timestamps, ordering and errors.
+// Text search matches any query word in the name or description, without stemming.
+package memory
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// Store holds the data of the in-memory repositories
+type Store struct {
+	mu sync.RWMutex
+
+	users          map[int]*domain.User
+	profiles       map[int]*domain.Profile // by user ID
+	profileSeq     int
+	profileChanges []domain.ProfileChange
+	products       map[int]*domain.Product
+	categories     map[int]*domain.Category
+	views          []viewRecord
+	likes          []likeRecord
+	purchases      []purchaseRecord
+	feedback       []domain.RecommendationFeedback
+	clicks         []domain.RecommendationClick
+	userFactors    map[int]*domain.FactorVector
+	itemFactors    map[int]*domain.FactorVector
+	stockLedger    []domain.StockAdjustment
+	warehouses     map[int]*domain.Warehouse
+	inventory      map[inventoryKey]*domain.InventoryLevel
+	bundles        map[int]*domain.Bundle
+
+	subscriptionPlans map[int]*domain.SubscriptionPlan
+	subscriptions     map[int]*domain.Subscription
+	carts             map[int]*domain.Cart // by user ID
+	abandonedCarts    map[int]*domain.AbandonedCart
+	riskReviews       map[int]*domain.RiskReview
+	outbox            []*outboxRecord
+
+	stockWatchers map[int]func(domain.StockEvent)
+	nextWatcher   int
+}
+
+// inventoryKey identifies the inventory record of a product at a warehouse
+type inventoryKey struct {
+	productID   int
+	warehouseID int
+}
+
+// Interaction records carry an ObjectID like their MongoDB documents, which export cursors use
+type viewRecord struct {
+	id primitive.ObjectID
+	domain.UserProductView
+}
+
+type likeRecord struct {
+	id primitive.ObjectID
+	domain.UserProductLike
+}
+
+type purchaseRecord struct {
+	id primitive.ObjectID
+	domain.UserProductPurchase
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{
+		users:         make(map[int]*domain.User),
+		profiles:      make(map[int]*domain.Profile),
+		products:      make(map[int]*domain.Product),
+		categories:    make(map[int]*domain.Category),
+		userFactors:   make(map[int]*domain.FactorVector),
+		itemFactors:   make(map[int]*domain.FactorVector),
+		warehouses:    make(map[int]*domain.Warehouse),
+		inventory:     make(map[inventoryKey]*domain.InventoryLevel),
+		bundles:       make(map[int]*domain.Bundle),
+		stockWatchers: make(map[int]func(domain.StockEvent)),
+
+		subscriptionPlans: make(map[int]*domain.SubscriptionPlan),
+		subscriptions:     make(map[int]*domain.Subscription),
+		carts:             make(map[int]*domain.Cart),
+		abandonedCarts:    make(map[int]*domain.AbandonedCart),
+		riskReviews:       make(map[int]*domain.RiskReview),
+	}
+}
+
+// Repositories returns a repository set backed by the store. Only the user, profile, product,
+// interaction, recommendation, stock, warehouse, bundle, subscription, cart, risk and outbox
+// repositories and the transactor are set; assign mock implementations of the others as a
+// test needs them.
+func (s *Store) Repositories() *repository.Repository {
+	return &repository.Repository{
+		User:           NewUserRepository(s),
+		Profile:        NewProfileRepository(s),
+		Product:        NewProductRepository(s),
+		Interaction:    NewInteractionRepository(s),
+		Recommendation: NewRecommendationRepository(s),
+		Stock:          NewStockRepository(s),
+		Warehouse:      NewWarehouseRepository(s),
+		Bundle:         NewBundleRepository(s),
+		Subscription:   NewSubscriptionRepository(s),
+		Cart:           NewCartRepository(s),
+		Risk:           NewRiskRepository(s),
+		Outbox:         NewOutboxRepository(s),
+		Transactor:     transactor{},
+	}
+}
+
+// Snapshot is data to load into a store as is, keeping its IDs and timestamps
+type Snapshot struct {
+	Users      []domain.User
+	Profiles   []domain.Profile
+	Categories []domain.Category
+	Products   []domain.Product
+	Views      []domain.UserProductView
+	Likes      []domain.UserProductLike
+	Purchases  []domain.UserProductPurchase
+}
+
+// Load adds the snapshot to the store, replacing records with the same IDs. It is meant for
+// fixtures and for copying a seeded database into memory for benchmarks.
+func (s *Store) Load(snapshot *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range snapshot.Users {
+		s.users[snapshot.Users[i].ID] = cloneUser(&snapshot.Users[i])
+	}
+	for i := range snapshot.Profiles {
+		profile := cloneProfile(&snapshot.Profiles[i])
+		s.profiles[profile.UserID] = profile
+		if profile.ID > s.profileSeq {
+			s.profileSeq = profile.ID
+		}
+	}
+	for i := range snapshot.Categories {
+		s.categories[snapshot.Categories[i].ID] = cloneCategory(&snapshot.Categories[i])
+	}
+	for i := range snapshot.Products {
+		s.products[snapshot.Products[i].ID] = cloneProduct(&snapshot.Products[i])
+	}
+	for _, view := range snapshot.Views {
+		s.views = append(s.views, viewRecord{primitive.NewObjectID(), view})
+	}
+	for _, like := range snapshot.Likes {
+		s.likes = append(s.likes, likeRecord{primitive.NewObjectID(), like})
+	}
+	for _, purchase := range snapshot.Purchases {
+		s.purchases = append(s.purchases, purchaseRecord{primitive.NewObjectID(), purchase})
+	}
+}
+
+// nextID returns one more than the highest key, like the MongoDB repositories' getNextID
+func nextID[T any](items map[int]T) int {
+	max := 0
+	for id := range items {
+		if id > max {
+			max = id
+		}
+	}
+	return max + 1
+}
+
+func clonePtr[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func cloneUser(user *domain.User) *domain.User {
+	copied := *user
+	copied.LastLoginAt = clonePtr(user.LastLoginAt)
+	copied.StatusChangedAt = clonePtr(user.StatusChangedAt)
+	copied.StatusChangedBy = clonePtr(user.StatusChangedBy)
+	return &copied
+}
+
+func cloneProfile(profile *domain.Profile) *domain.Profile {
+	copied := *profile
+	copied.MiddleName = clonePtr(profile.MiddleName)
+	copied.DateOfBirth = clonePtr(profile.DateOfBirth)
+	copied.Gender = clonePtr(profile.Gender)
+	copied.Phone = clonePtr(profile.Phone)
+	copied.PhoneVerifiedAt = clonePtr(profile.PhoneVerifiedAt)
+	copied.Address = clonePtr(profile.Address)
+	copied.City = clonePtr(profile.City)
+	copied.Country = clonePtr(profile.Country)
+	copied.PostalCode = clonePtr(profile.PostalCode)
+	copied.Preferences = clonePreferences(profile.Preferences)
+	return &copied
+}
+
+func clonePreferences(preferences domain.Preferences) domain.Preferences {
+	if preferences.FavoriteCategoryIDs != nil {
+		preferences.FavoriteCategoryIDs = append([]int(nil), preferences.FavoriteCategoryIDs...)
+	}
+	return preferences
+}
+
+func cloneProduct(product *domain.Product) *domain.Product {
+	copied := *product
+	copied.CategoryID = clonePtr(product.CategoryID)
+	copied.ImageVariants = maps.Clone(product.ImageVariants)
+	copied.ImageUploadedAt = clonePtr(product.ImageUploadedAt)
+	copied.PriceTiers = slices.Clone(product.PriceTiers)
+	copied.PurchaseLimit = clonePtr(product.PurchaseLimit)
+	if product.FlashSale != nil {
+		sale := *product.FlashSale
+		sale.EndsAt = clonePtr(product.FlashSale.EndsAt)
+		copied.FlashSale = &sale
+	}
+	if product.Translations != nil {
+		copied.Translations = make(map[string]domain.ProductTranslation, len(product.Translations))
+		for locale, translation := range product.Translations {
+			copied.Translations[locale] = translation
+		}
+	}
+	return &copied
+}
+
+func cloneCategory(category *domain.Category) *domain.Category {
+	copied := *category
+	copied.ParentID = clonePtr(category.ParentID)
+	copied.ProductCount = nil
+	copied.Breadcrumbs = nil
+	return &copied
+}
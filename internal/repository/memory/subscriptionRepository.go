@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type subscriptionRepository struct {
+	store *Store
+}
+
+func NewSubscriptionRepository(store *Store) repository.SubscriptionRepository {
+	return &subscriptionRepository{store: store}
+}
+
+// CreatePlan stores a new subscription plan
+func (r *subscriptionRepository) CreatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	plan.ID = nextID(r.store.subscriptionPlans)
+	plan.CreatedAt = time.Now().UTC()
+
+	copied := *plan
+	r.store.subscriptionPlans[plan.ID] = &copied
+	return nil
+}
+
+// GetPlan retrieves a subscription plan by ID
+func (r *subscriptionRepository) GetPlan(ctx context.Context, id int) (*domain.SubscriptionPlan, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	plan, ok := r.store.subscriptionPlans[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *plan
+	return &copied, nil
+}
+
+// ListPlans retrieves the plans of a product, cheapest first
+func (r *subscriptionRepository) ListPlans(ctx context.Context, productID int, activeOnly bool) ([]*domain.SubscriptionPlan, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	plans := make([]*domain.SubscriptionPlan, 0)
+	for _, plan := range r.store.subscriptionPlans {
+		if plan.ProductID != productID || (activeOnly && !plan.IsActive) {
+			continue
+		}
+		copied := *plan
+		plans = append(plans, &copied)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		if plans[i].Price != plans[j].Price {
+			return plans[i].Price.Amount < plans[j].Price.Amount
+		}
+		return plans[i].ID < plans[j].ID
+	})
+	return plans, nil
+}
+
+// DeactivatePlan stops offering a plan; existing subscriptions to it keep renewing
+func (r *subscriptionRepository) DeactivatePlan(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	plan, ok := r.store.subscriptionPlans[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	plan.IsActive = false
+	return nil
+}
+
+// Create stores a new subscription
+func (r *subscriptionRepository) Create(ctx context.Context, subscription *domain.Subscription) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now().UTC()
+	subscription.ID = nextID(r.store.subscriptions)
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	r.store.subscriptions[subscription.ID] = cloneSubscription(subscription)
+	return nil
+}
+
+// GetByID retrieves a subscription by ID
+func (r *subscriptionRepository) GetByID(ctx context.Context, id int) (*domain.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneSubscription(subscription), nil
+}
+
+// ListByUser retrieves a page of a user's subscriptions, newest first, with the total count
+func (r *subscriptionRepository) ListByUser(ctx context.Context, userID, limit, offset int) ([]*domain.Subscription, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subscriptions := make([]*domain.Subscription, 0)
+	for _, subscription := range r.store.subscriptions {
+		if subscription.UserID == userID {
+			subscriptions = append(subscriptions, cloneSubscription(subscription))
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if !subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].CreatedAt.After(subscriptions[j].CreatedAt)
+		}
+		return subscriptions[i].ID > subscriptions[j].ID
+	})
+
+	total := int64(len(subscriptions))
+	subscriptions = subscriptions[min(offset, len(subscriptions)):]
+	if limit > 0 && len(subscriptions) > limit {
+		subscriptions = subscriptions[:limit]
+	}
+	return subscriptions, total, nil
+}
+
+// UpdateStatus sets the status of a subscription whose status is one of from
+func (r *subscriptionRepository) UpdateStatus(ctx context.Context, id int, from []string, to string) (*domain.Subscription, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if !slices.Contains(from, subscription.Status) {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	now := time.Now().UTC()
+	subscription.Status = to
+	subscription.UpdatedAt = now
+	if to == domain.SubscriptionCancelled {
+		subscription.CancelledAt = &now
+	}
+	return cloneSubscription(subscription), nil
+}
+
+// ListDue retrieves active and past due subscriptions whose renewal is due, earliest first
+func (r *subscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subscriptions := make([]*domain.Subscription, 0)
+	for _, subscription := range r.store.subscriptions {
+		if subscription.Renewable() && !subscription.NextRenewalAt.After(now) {
+			subscriptions = append(subscriptions, cloneSubscription(subscription))
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].NextRenewalAt.Before(subscriptions[j].NextRenewalAt)
+	})
+
+	if limit > 0 && len(subscriptions) > limit {
+		subscriptions = subscriptions[:limit]
+	}
+	return subscriptions, nil
+}
+
+// ClaimRenewal moves the renewal of a subscription still due at due to until
+func (r *subscriptionRepository) ClaimRenewal(ctx context.Context, id int, due, until time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subscription, ok := r.store.subscriptions[id]
+	if !ok || !subscription.Renewable() || !subscription.NextRenewalAt.Equal(due) {
+		return false, nil
+	}
+	subscription.NextRenewalAt = until
+	return true, nil
+}
+
+// SaveRenewal stores the outcome of a renewal
+func (r *subscriptionRepository) SaveRenewal(ctx context.Context, subscription *domain.Subscription) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.subscriptions[subscription.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	subscription.UpdatedAt = time.Now().UTC()
+	stored.NextRenewalAt = subscription.NextRenewalAt
+	stored.LastRenewedAt = clonePtr(subscription.LastRenewedAt)
+	stored.LastChargeID = subscription.LastChargeID
+	stored.FailedAttempts = subscription.FailedAttempts
+	stored.LastError = subscription.LastError
+	stored.UpdatedAt = subscription.UpdatedAt
+	return nil
+}
+
+func cloneSubscription(subscription *domain.Subscription) *domain.Subscription {
+	copied := *subscription
+	copied.LastRenewedAt = clonePtr(subscription.LastRenewedAt)
+	copied.CancelledAt = clonePtr(subscription.CancelledAt)
+	return &copied
+}
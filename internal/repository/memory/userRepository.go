@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.findByEmail(user.Email) != nil {
+		return fmt.Errorf("user with this email already exists: %w", domain.ErrAlreadyExists)
+	}
+
+	user.CreatedAt = time.Now().UTC()
+	user.UpdatedAt = time.Now().UTC()
+	user.Status = domain.UserStatusActive
+	user.ID = nextID(r.store.users)
+
+	r.store.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user := r.findByEmail(email)
+	if user == nil {
+		return nil, domain.ErrNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user.UpdatedAt = time.Now().UTC()
+
+	stored, ok := r.store.users[user.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if existing := r.findByEmail(user.Email); existing != nil && existing.ID != user.ID {
+		return domain.ErrAlreadyExists
+	}
+
+	stored.Email = user.Email
+	stored.PasswordHash = user.PasswordHash
+	stored.Status = user.Status
+	stored.UpdatedAt = user.UpdatedAt
+	return nil
+}
+
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	now := time.Now().UTC()
+	user.LastLoginAt = &now
+	return nil
+}
+
+// IncrementTokenVersion invalidates tokens issued to the user so far
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	user.TokenVersion++
+	user.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// UpdateStatus sets the status of a user whose status is one of from
+func (r *userRepository) UpdateStatus(ctx context.Context, id int, from []string, change domain.UserStatusChange) (*domain.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if !slices.Contains(from, user.Status) {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	now := time.Now().UTC()
+	changedBy := change.ChangedBy
+	user.Status = change.Status
+	user.StatusReason = change.Reason
+	user.StatusChangedAt = &now
+	user.StatusChangedBy = &changedBy
+	user.UpdatedAt = now
+	return cloneUser(user), nil
+}
+
+// List filters, sorts and pages the users like the MongoDB repository
+func (r *userRepository) List(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := make([]domain.AdminUser, 0)
+	for _, user := range r.store.users {
+		if filter.Email != "" && !strings.Contains(strings.ToLower(user.Email), strings.ToLower(filter.Email)) {
+			continue
+		}
+		if filter.Status != "" && user.Status != filter.Status {
+			continue
+		}
+		users = append(users, domain.AdminUser{User: *cloneUser(user), LTV: r.customerValue(user.ID)})
+	}
+
+	keys := append([]domain.SortField(nil), filter.Sort...)
+	if len(keys) == 0 {
+		keys = []domain.SortField{{Field: "created_at", Descending: true}}
+	}
+	keys = append(keys, domain.SortField{Field: "id"})
+	sort.SliceStable(users, func(i, j int) bool {
+		for _, key := range keys {
+			cmp, _ := compareValues(adminUserField(&users[i], key.Field), adminUserField(&users[j], key.Field))
+			if cmp == 0 {
+				continue
+			}
+			if key.Descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+
+	total := int64(len(users))
+	start := min(filter.Offset, len(users))
+	end := min(start+filter.Limit, len(users))
+	return users[start:end], total, nil
+}
+
+// adminUserField returns the value of a UserSortFields key, nil if missing
+func adminUserField(user *domain.AdminUser, field string) interface{} {
+	switch field {
+	case "id":
+		return user.ID
+	case "email":
+		return user.Email
+	case "created_at":
+		return user.CreatedAt
+	case "last_login_at":
+		if user.LastLoginAt == nil {
+			return nil
+		}
+		return *user.LastLoginAt
+	case "total_spend":
+		return user.LTV.TotalSpend.Amount
+	case "orders":
+		return user.LTV.Orders
+	case "average_order_value":
+		return user.LTV.AverageOrderValue.Amount
+	case "last_purchase_at":
+		if user.LTV.LastPurchaseAt == nil {
+			return nil
+		}
+		return *user.LTV.LastPurchaseAt
+	}
+	return nil
+}
+
+func (r *userRepository) GetCustomerValue(ctx context.Context, userID int) (*domain.CustomerValue, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	value := r.customerValue(userID)
+	return &value, nil
+}
+
+// customerValue sums the purchases of the user; the caller holds the lock
+func (r *userRepository) customerValue(userID int) domain.CustomerValue {
+	value := domain.CustomerValue{UserID: userID}
+	for _, purchase := range r.store.purchases {
+		if purchase.UserID != userID {
+			continue
+		}
+
+		value.TotalSpend = value.TotalSpend.Add(purchase.PriceAtPurchase.Mul(purchase.Quantity))
+		value.Orders++
+		if value.FirstPurchaseAt == nil || purchase.PurchasedAt.Before(*value.FirstPurchaseAt) {
+			value.FirstPurchaseAt = &purchase.PurchasedAt
+		}
+		if value.LastPurchaseAt == nil || purchase.PurchasedAt.After(*value.LastPurchaseAt) {
+			value.LastPurchaseAt = &purchase.PurchasedAt
+		}
+	}
+	if value.Orders > 0 {
+		value.AverageOrderValue = value.TotalSpend.Div(value.Orders)
+	}
+	return value
+}
+
+// findByEmail returns the stored user with the email; the caller holds the lock
+func (r *userRepository) findByEmail(email string) *domain.User {
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type warehouseRepository struct {
+	store *Store
+}
+
+func NewWarehouseRepository(store *Store) repository.WarehouseRepository {
+	return &warehouseRepository{store: store}
+}
+
+// Create stores a new warehouse; codes are unique
+func (r *warehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.codeTaken(warehouse.Code, 0) {
+		return domain.ErrAlreadyExists
+	}
+
+	now := time.Now().UTC()
+	warehouse.ID = nextID(r.store.warehouses)
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+
+	copied := *warehouse
+	r.store.warehouses[warehouse.ID] = &copied
+	return nil
+}
+
+// GetByID retrieves a warehouse by ID
+func (r *warehouseRepository) GetByID(ctx context.Context, id int) (*domain.Warehouse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	warehouse, ok := r.store.warehouses[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *warehouse
+	return &copied, nil
+}
+
+// List retrieves all warehouses ordered by code
+func (r *warehouseRepository) List(ctx context.Context) ([]domain.Warehouse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	warehouses := make([]domain.Warehouse, 0, len(r.store.warehouses))
+	for _, warehouse := range r.store.warehouses {
+		warehouses = append(warehouses, *warehouse)
+	}
+	sort.Slice(warehouses, func(i, j int) bool { return warehouses[i].Code < warehouses[j].Code })
+	return warehouses, nil
+}
+
+// Update updates a warehouse
+func (r *warehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.warehouses[warehouse.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if r.codeTaken(warehouse.Code, warehouse.ID) {
+		return domain.ErrAlreadyExists
+	}
+
+	warehouse.UpdatedAt = time.Now().UTC()
+	stored.Code = warehouse.Code
+	stored.Name = warehouse.Name
+	stored.Address = warehouse.Address
+	stored.IsActive = warehouse.IsActive
+	stored.UpdatedAt = warehouse.UpdatedAt
+	return nil
+}
+
+// Delete deletes a warehouse and its empty inventory records
+func (r *warehouseRepository) Delete(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.warehouses[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.store.warehouses, id)
+
+	for key := range r.store.inventory {
+		if key.warehouseID == id {
+			delete(r.store.inventory, key)
+		}
+	}
+	return nil
+}
+
+// HasInventory reports whether the warehouse holds any stock
+func (r *warehouseRepository) HasInventory(ctx context.Context, id int) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for key, level := range r.store.inventory {
+		if key.warehouseID == id && level.Quantity > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// codeTaken reports whether another warehouse has the code; the caller holds the lock
+func (r *warehouseRepository) codeTaken(code string, exceptID int) bool {
+	for _, warehouse := range r.store.warehouses {
+		if warehouse.Code == code && warehouse.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,370 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interactionRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interactionRepository.go -destination=mocks/interactionRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/PrimeraAizen/e-comm/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractionRepository is a mock of InteractionRepository interface.
+type MockInteractionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockInteractionRepositoryMockRecorder is the mock recorder for MockInteractionRepository.
+type MockInteractionRepositoryMockRecorder struct {
+	mock *MockInteractionRepository
+}
+
+// NewMockInteractionRepository creates a new mock instance.
+func NewMockInteractionRepository(ctrl *gomock.Controller) *MockInteractionRepository {
+	mock := &MockInteractionRepository{ctrl: ctrl}
+	mock.recorder = &MockInteractionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractionRepository) EXPECT() *MockInteractionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ExportEvents mocks base method.
+func (m *MockInteractionRepository) ExportEvents(ctx context.Context, filter domain.InteractionExportFilter) ([]domain.InteractionEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportEvents", ctx, filter)
+	ret0, _ := ret[0].([]domain.InteractionEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportEvents indicates an expected call of ExportEvents.
+func (mr *MockInteractionRepositoryMockRecorder) ExportEvents(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportEvents", reflect.TypeOf((*MockInteractionRepository)(nil).ExportEvents), ctx, filter)
+}
+
+// GetAllUserLikes mocks base method.
+func (m *MockInteractionRepository) GetAllUserLikes(ctx context.Context) ([]domain.UserProductLike, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllUserLikes", ctx)
+	ret0, _ := ret[0].([]domain.UserProductLike)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllUserLikes indicates an expected call of GetAllUserLikes.
+func (mr *MockInteractionRepositoryMockRecorder) GetAllUserLikes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUserLikes", reflect.TypeOf((*MockInteractionRepository)(nil).GetAllUserLikes), ctx)
+}
+
+// GetAllUserPurchases mocks base method.
+func (m *MockInteractionRepository) GetAllUserPurchases(ctx context.Context) ([]domain.UserProductPurchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllUserPurchases", ctx)
+	ret0, _ := ret[0].([]domain.UserProductPurchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllUserPurchases indicates an expected call of GetAllUserPurchases.
+func (mr *MockInteractionRepositoryMockRecorder) GetAllUserPurchases(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUserPurchases", reflect.TypeOf((*MockInteractionRepository)(nil).GetAllUserPurchases), ctx)
+}
+
+// GetAllUserViews mocks base method.
+func (m *MockInteractionRepository) GetAllUserViews(ctx context.Context) ([]domain.UserProductView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllUserViews", ctx)
+	ret0, _ := ret[0].([]domain.UserProductView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllUserViews indicates an expected call of GetAllUserViews.
+func (mr *MockInteractionRepositoryMockRecorder) GetAllUserViews(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUserViews", reflect.TypeOf((*MockInteractionRepository)(nil).GetAllUserViews), ctx)
+}
+
+// GetInteractionCounts mocks base method.
+func (m *MockInteractionRepository) GetInteractionCounts(ctx context.Context, productIDs []int) (map[int]domain.InteractionCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInteractionCounts", ctx, productIDs)
+	ret0, _ := ret[0].(map[int]domain.InteractionCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInteractionCounts indicates an expected call of GetInteractionCounts.
+func (mr *MockInteractionRepositoryMockRecorder) GetInteractionCounts(ctx, productIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInteractionCounts", reflect.TypeOf((*MockInteractionRepository)(nil).GetInteractionCounts), ctx, productIDs)
+}
+
+// GetLikedProductIDs mocks base method.
+func (m *MockInteractionRepository) GetLikedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLikedProductIDs", ctx, userID, productIDs)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLikedProductIDs indicates an expected call of GetLikedProductIDs.
+func (mr *MockInteractionRepositoryMockRecorder) GetLikedProductIDs(ctx, userID, productIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLikedProductIDs", reflect.TypeOf((*MockInteractionRepository)(nil).GetLikedProductIDs), ctx, userID, productIDs)
+}
+
+// GetPurchaseTotals mocks base method.
+func (m *MockInteractionRepository) GetPurchaseTotals(ctx context.Context, since time.Time) (*domain.PurchaseTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchaseTotals", ctx, since)
+	ret0, _ := ret[0].(*domain.PurchaseTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchaseTotals indicates an expected call of GetPurchaseTotals.
+func (mr *MockInteractionRepositoryMockRecorder) GetPurchaseTotals(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchaseTotals", reflect.TypeOf((*MockInteractionRepository)(nil).GetPurchaseTotals), ctx, since)
+}
+
+// GetPurchasedProductIDs mocks base method.
+func (m *MockInteractionRepository) GetPurchasedProductIDs(ctx context.Context, userID int, productIDs []int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchasedProductIDs", ctx, userID, productIDs)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchasedProductIDs indicates an expected call of GetPurchasedProductIDs.
+func (mr *MockInteractionRepositoryMockRecorder) GetPurchasedProductIDs(ctx, userID, productIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchasedProductIDs", reflect.TypeOf((*MockInteractionRepository)(nil).GetPurchasedProductIDs), ctx, userID, productIDs)
+}
+
+// GetUserInteractionSummary mocks base method.
+func (m *MockInteractionRepository) GetUserInteractionSummary(ctx context.Context, userID int) (*domain.UserInteractionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserInteractionSummary", ctx, userID)
+	ret0, _ := ret[0].(*domain.UserInteractionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserInteractionSummary indicates an expected call of GetUserInteractionSummary.
+func (mr *MockInteractionRepositoryMockRecorder) GetUserInteractionSummary(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInteractionSummary", reflect.TypeOf((*MockInteractionRepository)(nil).GetUserInteractionSummary), ctx, userID)
+}
+
+// GetUserLikes mocks base method.
+func (m *MockInteractionRepository) GetUserLikes(ctx context.Context, userID, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLikes", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]domain.ProductInteraction)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserLikes indicates an expected call of GetUserLikes.
+func (mr *MockInteractionRepositoryMockRecorder) GetUserLikes(ctx, userID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLikes", reflect.TypeOf((*MockInteractionRepository)(nil).GetUserLikes), ctx, userID, limit, offset)
+}
+
+// GetUserPurchases mocks base method.
+func (m *MockInteractionRepository) GetUserPurchases(ctx context.Context, userID, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPurchases", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]domain.ProductInteraction)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserPurchases indicates an expected call of GetUserPurchases.
+func (mr *MockInteractionRepositoryMockRecorder) GetUserPurchases(ctx, userID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPurchases", reflect.TypeOf((*MockInteractionRepository)(nil).GetUserPurchases), ctx, userID, limit, offset)
+}
+
+// GetUserViews mocks base method.
+func (m *MockInteractionRepository) GetUserViews(ctx context.Context, userID, limit, offset int) ([]domain.ProductInteraction, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserViews", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]domain.ProductInteraction)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserViews indicates an expected call of GetUserViews.
+func (mr *MockInteractionRepositoryMockRecorder) GetUserViews(ctx, userID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserViews", reflect.TypeOf((*MockInteractionRepository)(nil).GetUserViews), ctx, userID, limit, offset)
+}
+
+// HasLiked mocks base method.
+func (m *MockInteractionRepository) HasLiked(ctx context.Context, userID, productID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasLiked", ctx, userID, productID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasLiked indicates an expected call of HasLiked.
+func (mr *MockInteractionRepositoryMockRecorder) HasLiked(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasLiked", reflect.TypeOf((*MockInteractionRepository)(nil).HasLiked), ctx, userID, productID)
+}
+
+// HasPurchased mocks base method.
+func (m *MockInteractionRepository) HasPurchased(ctx context.Context, userID, productID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasPurchased", ctx, userID, productID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasPurchased indicates an expected call of HasPurchased.
+func (mr *MockInteractionRepositoryMockRecorder) HasPurchased(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPurchased", reflect.TypeOf((*MockInteractionRepository)(nil).HasPurchased), ctx, userID, productID)
+}
+
+// HasViewed mocks base method.
+func (m *MockInteractionRepository) HasViewed(ctx context.Context, userID, productID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasViewed", ctx, userID, productID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasViewed indicates an expected call of HasViewed.
+func (mr *MockInteractionRepositoryMockRecorder) HasViewed(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasViewed", reflect.TypeOf((*MockInteractionRepository)(nil).HasViewed), ctx, userID, productID)
+}
+
+// MergeAnonymous mocks base method.
+func (m *MockInteractionRepository) MergeAnonymous(ctx context.Context, anonymousID string, userID int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeAnonymous", ctx, anonymousID, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeAnonymous indicates an expected call of MergeAnonymous.
+func (mr *MockInteractionRepositoryMockRecorder) MergeAnonymous(ctx, anonymousID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeAnonymous", reflect.TypeOf((*MockInteractionRepository)(nil).MergeAnonymous), ctx, anonymousID, userID)
+}
+
+// RecordAnonymousPurchase mocks base method.
+func (m *MockInteractionRepository) RecordAnonymousPurchase(ctx context.Context, anonymousID string, productID, quantity int, price, cost domain.Money) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAnonymousPurchase", ctx, anonymousID, productID, quantity, price, cost)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAnonymousPurchase indicates an expected call of RecordAnonymousPurchase.
+func (mr *MockInteractionRepositoryMockRecorder) RecordAnonymousPurchase(ctx, anonymousID, productID, quantity, price, cost any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAnonymousPurchase", reflect.TypeOf((*MockInteractionRepository)(nil).RecordAnonymousPurchase), ctx, anonymousID, productID, quantity, price, cost)
+}
+
+// RecordAnonymousView mocks base method.
+func (m *MockInteractionRepository) RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAnonymousView", ctx, anonymousID, productID, engagement)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAnonymousView indicates an expected call of RecordAnonymousView.
+func (mr *MockInteractionRepositoryMockRecorder) RecordAnonymousView(ctx, anonymousID, productID, engagement any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAnonymousView", reflect.TypeOf((*MockInteractionRepository)(nil).RecordAnonymousView), ctx, anonymousID, productID, engagement)
+}
+
+// RecordLike mocks base method.
+func (m *MockInteractionRepository) RecordLike(ctx context.Context, userID, productID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLike", ctx, userID, productID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLike indicates an expected call of RecordLike.
+func (mr *MockInteractionRepositoryMockRecorder) RecordLike(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLike", reflect.TypeOf((*MockInteractionRepository)(nil).RecordLike), ctx, userID, productID)
+}
+
+// RecordPurchase mocks base method.
+func (m *MockInteractionRepository) RecordPurchase(ctx context.Context, userID, productID, quantity int, price, cost domain.Money) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPurchase", ctx, userID, productID, quantity, price, cost)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordPurchase indicates an expected call of RecordPurchase.
+func (mr *MockInteractionRepositoryMockRecorder) RecordPurchase(ctx, userID, productID, quantity, price, cost any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPurchase", reflect.TypeOf((*MockInteractionRepository)(nil).RecordPurchase), ctx, userID, productID, quantity, price, cost)
+}
+
+// RecordView mocks base method.
+func (m *MockInteractionRepository) RecordView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordView", ctx, userID, productID, engagement)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordView indicates an expected call of RecordView.
+func (mr *MockInteractionRepositoryMockRecorder) RecordView(ctx, userID, productID, engagement any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordView", reflect.TypeOf((*MockInteractionRepository)(nil).RecordView), ctx, userID, productID, engagement)
+}
+
+// RemoveLike mocks base method.
+func (m *MockInteractionRepository) RemoveLike(ctx context.Context, userID, productID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveLike", ctx, userID, productID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveLike indicates an expected call of RemoveLike.
+func (mr *MockInteractionRepositoryMockRecorder) RemoveLike(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveLike", reflect.TypeOf((*MockInteractionRepository)(nil).RemoveLike), ctx, userID, productID)
+}
@@ -0,0 +1,410 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: productRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=productRepository.go -destination=mocks/productRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/PrimeraAizen/e-comm/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProductRepository is a mock of ProductRepository interface.
+type MockProductRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockProductRepositoryMockRecorder is the mock recorder for MockProductRepository.
+type MockProductRepositoryMockRecorder struct {
+	mock *MockProductRepository
+}
+
+// NewMockProductRepository creates a new mock instance.
+func NewMockProductRepository(ctrl *gomock.Controller) *MockProductRepository {
+	mock := &MockProductRepository{ctrl: ctrl}
+	mock.recorder = &MockProductRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountActiveProductsByCategory mocks base method.
+func (m *MockProductRepository) CountActiveProductsByCategory(ctx context.Context) (map[int]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountActiveProductsByCategory", ctx)
+	ret0, _ := ret[0].(map[int]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountActiveProductsByCategory indicates an expected call of CountActiveProductsByCategory.
+func (mr *MockProductRepositoryMockRecorder) CountActiveProductsByCategory(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountActiveProductsByCategory", reflect.TypeOf((*MockProductRepository)(nil).CountActiveProductsByCategory), ctx)
+}
+
+// CountCategoryContents mocks base method.
+func (m *MockProductRepository) CountCategoryContents(ctx context.Context, id int) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCategoryContents", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CountCategoryContents indicates an expected call of CountCategoryContents.
+func (mr *MockProductRepositoryMockRecorder) CountCategoryContents(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCategoryContents", reflect.TypeOf((*MockProductRepository)(nil).CountCategoryContents), ctx, id)
+}
+
+// Create mocks base method.
+func (m *MockProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, product)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockProductRepositoryMockRecorder) Create(ctx, product any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProductRepository)(nil).Create), ctx, product)
+}
+
+// CreateCategory mocks base method.
+func (m *MockProductRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCategory", ctx, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCategory indicates an expected call of CreateCategory.
+func (mr *MockProductRepositoryMockRecorder) CreateCategory(ctx, category any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockProductRepository)(nil).CreateCategory), ctx, category)
+}
+
+// Delete mocks base method.
+func (m *MockProductRepository) Delete(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockProductRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProductRepository)(nil).Delete), ctx, id)
+}
+
+// DeleteCategory mocks base method.
+func (m *MockProductRepository) DeleteCategory(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockProductRepositoryMockRecorder) DeleteCategory(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockProductRepository)(nil).DeleteCategory), ctx, id)
+}
+
+// DeleteTranslation mocks base method.
+func (m *MockProductRepository) DeleteTranslation(ctx context.Context, productID int, locale string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTranslation", ctx, productID, locale)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTranslation indicates an expected call of DeleteTranslation.
+func (mr *MockProductRepositoryMockRecorder) DeleteTranslation(ctx, productID, locale any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTranslation", reflect.TypeOf((*MockProductRepository)(nil).DeleteTranslation), ctx, productID, locale)
+}
+
+// GetByID mocks base method.
+func (m *MockProductRepository) GetByID(ctx context.Context, id int) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockProductRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockProductRepository)(nil).GetByID), ctx, id)
+}
+
+// GetByIDWithCategory mocks base method.
+func (m *MockProductRepository) GetByIDWithCategory(ctx context.Context, id int, fields []string) (*domain.ProductWithCategory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDWithCategory", ctx, id, fields)
+	ret0, _ := ret[0].(*domain.ProductWithCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDWithCategory indicates an expected call of GetByIDWithCategory.
+func (mr *MockProductRepositoryMockRecorder) GetByIDWithCategory(ctx, id, fields any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDWithCategory", reflect.TypeOf((*MockProductRepository)(nil).GetByIDWithCategory), ctx, id, fields)
+}
+
+// GetCategoryByID mocks base method.
+func (m *MockProductRepository) GetCategoryByID(ctx context.Context, id int) (*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryByID indicates an expected call of GetCategoryByID.
+func (mr *MockProductRepositoryMockRecorder) GetCategoryByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryByID", reflect.TypeOf((*MockProductRepository)(nil).GetCategoryByID), ctx, id)
+}
+
+// GetCategoryByName mocks base method.
+func (m *MockProductRepository) GetCategoryByName(ctx context.Context, name string) (*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryByName", ctx, name)
+	ret0, _ := ret[0].(*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryByName indicates an expected call of GetCategoryByName.
+func (mr *MockProductRepositoryMockRecorder) GetCategoryByName(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryByName", reflect.TypeOf((*MockProductRepository)(nil).GetCategoryByName), ctx, name)
+}
+
+// GetProductStatistics mocks base method.
+func (m *MockProductRepository) GetProductStatistics(ctx context.Context, productID int) (*domain.ProductStatistics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductStatistics", ctx, productID)
+	ret0, _ := ret[0].(*domain.ProductStatistics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductStatistics indicates an expected call of GetProductStatistics.
+func (mr *MockProductRepositoryMockRecorder) GetProductStatistics(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductStatistics", reflect.TypeOf((*MockProductRepository)(nil).GetProductStatistics), ctx, productID)
+}
+
+// List mocks base method.
+func (m *MockProductRepository) List(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]*domain.Product)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockProductRepositoryMockRecorder) List(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProductRepository)(nil).List), ctx, filter)
+}
+
+// ListCategories mocks base method.
+func (m *MockProductRepository) ListCategories(ctx context.Context) ([]*domain.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCategories", ctx)
+	ret0, _ := ret[0].([]*domain.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCategories indicates an expected call of ListCategories.
+func (mr *MockProductRepositoryMockRecorder) ListCategories(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCategories", reflect.TypeOf((*MockProductRepository)(nil).ListCategories), ctx)
+}
+
+// ListWithCategories mocks base method.
+func (m *MockProductRepository) ListWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWithCategories", ctx, filter)
+	ret0, _ := ret[0].([]*domain.ProductWithCategory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListWithCategories indicates an expected call of ListWithCategories.
+func (mr *MockProductRepositoryMockRecorder) ListWithCategories(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWithCategories", reflect.TypeOf((*MockProductRepository)(nil).ListWithCategories), ctx, filter)
+}
+
+// MergeCategory mocks base method.
+func (m *MockProductRepository) MergeCategory(ctx context.Context, sourceID, targetID int) (*domain.CategoryMergeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeCategory", ctx, sourceID, targetID)
+	ret0, _ := ret[0].(*domain.CategoryMergeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeCategory indicates an expected call of MergeCategory.
+func (mr *MockProductRepositoryMockRecorder) MergeCategory(ctx, sourceID, targetID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeCategory", reflect.TypeOf((*MockProductRepository)(nil).MergeCategory), ctx, sourceID, targetID)
+}
+
+// NameVocabulary mocks base method.
+func (m *MockProductRepository) NameVocabulary(ctx context.Context) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NameVocabulary", ctx)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NameVocabulary indicates an expected call of NameVocabulary.
+func (mr *MockProductRepositoryMockRecorder) NameVocabulary(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NameVocabulary", reflect.TypeOf((*MockProductRepository)(nil).NameVocabulary), ctx)
+}
+
+// RefreshProductStatistics mocks base method.
+func (m *MockProductRepository) RefreshProductStatistics(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshProductStatistics", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshProductStatistics indicates an expected call of RefreshProductStatistics.
+func (mr *MockProductRepositoryMockRecorder) RefreshProductStatistics(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshProductStatistics", reflect.TypeOf((*MockProductRepository)(nil).RefreshProductStatistics), ctx)
+}
+
+// Search mocks base method.
+func (m *MockProductRepository) Search(ctx context.Context, query string, limit, offset int) ([]*domain.Product, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit, offset)
+	ret0, _ := ret[0].([]*domain.Product)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockProductRepositoryMockRecorder) Search(ctx, query, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockProductRepository)(nil).Search), ctx, query, limit, offset)
+}
+
+// SetImage mocks base method.
+func (m *MockProductRepository) SetImage(ctx context.Context, id int, imageURL string, variants map[string]string, uploadedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetImage", ctx, id, imageURL, variants, uploadedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetImage indicates an expected call of SetImage.
+func (mr *MockProductRepositoryMockRecorder) SetImage(ctx, id, imageURL, variants, uploadedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetImage", reflect.TypeOf((*MockProductRepository)(nil).SetImage), ctx, id, imageURL, variants, uploadedAt)
+}
+
+// SetTranslation mocks base method.
+func (m *MockProductRepository) SetTranslation(ctx context.Context, productID int, locale string, translation domain.ProductTranslation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTranslation", ctx, productID, locale, translation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTranslation indicates an expected call of SetTranslation.
+func (mr *MockProductRepositoryMockRecorder) SetTranslation(ctx, productID, locale, translation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTranslation", reflect.TypeOf((*MockProductRepository)(nil).SetTranslation), ctx, productID, locale, translation)
+}
+
+// StreamWithCategories mocks base method.
+func (m *MockProductRepository) StreamWithCategories(ctx context.Context, filter domain.ProductFilter, fn func(*domain.ProductWithCategory) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamWithCategories", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamWithCategories indicates an expected call of StreamWithCategories.
+func (mr *MockProductRepositoryMockRecorder) StreamWithCategories(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamWithCategories", reflect.TypeOf((*MockProductRepository)(nil).StreamWithCategories), ctx, filter, fn)
+}
+
+// Update mocks base method.
+func (m *MockProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, product)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockProductRepositoryMockRecorder) Update(ctx, product any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), ctx, product)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockProductRepository) UpdateCategory(ctx context.Context, category *domain.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", ctx, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockProductRepositoryMockRecorder) UpdateCategory(ctx, category any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockProductRepository)(nil).UpdateCategory), ctx, category)
+}
+
+// WatchStock mocks base method.
+func (m *MockProductRepository) WatchStock(ctx context.Context, fn func(domain.StockEvent)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchStock", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchStock indicates an expected call of WatchStock.
+func (mr *MockProductRepositoryMockRecorder) WatchStock(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchStock", reflect.TypeOf((*MockProductRepository)(nil).WatchStock), ctx, fn)
+}
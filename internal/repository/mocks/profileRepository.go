@@ -0,0 +1,156 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: profileRepository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=profileRepository.go -destination=mocks/profileRepository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/PrimeraAizen/e-comm/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProfileRepository is a mock of ProfileRepository interface.
+type MockProfileRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProfileRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockProfileRepositoryMockRecorder is the mock recorder for MockProfileRepository.
+type MockProfileRepositoryMockRecorder struct {
+	mock *MockProfileRepository
+}
+
+// NewMockProfileRepository creates a new mock instance.
+func NewMockProfileRepository(ctrl *gomock.Controller) *MockProfileRepository {
+	mock := &MockProfileRepository{ctrl: ctrl}
+	mock.recorder = &MockProfileRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProfileRepository) EXPECT() *MockProfileRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockProfileRepository) Create(ctx context.Context, profile *domain.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, profile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockProfileRepositoryMockRecorder) Create(ctx, profile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProfileRepository)(nil).Create), ctx, profile)
+}
+
+// Delete mocks base method.
+func (m *MockProfileRepository) Delete(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockProfileRepositoryMockRecorder) Delete(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProfileRepository)(nil).Delete), ctx, userID)
+}
+
+// GetByUserID mocks base method.
+func (m *MockProfileRepository) GetByUserID(ctx context.Context, userID int) (*domain.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockProfileRepositoryMockRecorder) GetByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockProfileRepository)(nil).GetByUserID), ctx, userID)
+}
+
+// GetByUserIDWithFields mocks base method.
+func (m *MockProfileRepository) GetByUserIDWithFields(ctx context.Context, userID int, fields []string) (*domain.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserIDWithFields", ctx, userID, fields)
+	ret0, _ := ret[0].(*domain.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserIDWithFields indicates an expected call of GetByUserIDWithFields.
+func (mr *MockProfileRepositoryMockRecorder) GetByUserIDWithFields(ctx, userID, fields any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserIDWithFields", reflect.TypeOf((*MockProfileRepository)(nil).GetByUserIDWithFields), ctx, userID, fields)
+}
+
+// MarkPhoneVerified mocks base method.
+func (m *MockProfileRepository) MarkPhoneVerified(ctx context.Context, userID int, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPhoneVerified", ctx, userID, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPhoneVerified indicates an expected call of MarkPhoneVerified.
+func (mr *MockProfileRepositoryMockRecorder) MarkPhoneVerified(ctx, userID, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPhoneVerified", reflect.TypeOf((*MockProfileRepository)(nil).MarkPhoneVerified), ctx, userID, phone)
+}
+
+// RecordChange mocks base method.
+func (m *MockProfileRepository) RecordChange(ctx context.Context, change *domain.ProfileChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordChange indicates an expected call of RecordChange.
+func (mr *MockProfileRepositoryMockRecorder) RecordChange(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordChange", reflect.TypeOf((*MockProfileRepository)(nil).RecordChange), ctx, change)
+}
+
+// Update mocks base method.
+func (m *MockProfileRepository) Update(ctx context.Context, profile *domain.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, profile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockProfileRepositoryMockRecorder) Update(ctx, profile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProfileRepository)(nil).Update), ctx, profile)
+}
+
+// UpdatePreferences mocks base method.
+func (m *MockProfileRepository) UpdatePreferences(ctx context.Context, userID int, preferences *domain.Preferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePreferences", ctx, userID, preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePreferences indicates an expected call of UpdatePreferences.
+func (mr *MockProfileRepositoryMockRecorder) UpdatePreferences(ctx, userID, preferences any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePreferences", reflect.TypeOf((*MockProfileRepository)(nil).UpdatePreferences), ctx, userID, preferences)
+}
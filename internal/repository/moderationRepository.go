@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type ModerationRepository interface {
+	// Create quarantines an item
+	Create(ctx context.Context, item *domain.ModerationItem) error
+	ListItems(ctx context.Context, status string, limit, offset int) ([]*domain.ModerationItem, int64, error)
+
+	// Resolve sets the outcome of a quarantined item and returns it, or ErrInvalidTransition
+	// if it was already resolved
+	Resolve(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.ModerationItem, error)
+}
+
+type moderationRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewModerationRepository(db *mongodb.MongoDB) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// getNextID gets the next moderation item ID from the counter
+func (r *moderationRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "moderation_item_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next moderation item id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new quarantined item
+func (r *moderationRepository) Create(ctx context.Context, item *domain.ModerationItem) error {
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	item.ID = id
+	item.Status = domain.ModerationQuarantined
+	item.CreatedAt = time.Now().UTC()
+
+	if _, err := r.db.Collection("moderation_items").InsertOne(ctx, item); err != nil {
+		return fmt.Errorf("insert moderation item: %w", err)
+	}
+
+	return nil
+}
+
+// ListItems retrieves a page of items, any status if status is empty, oldest first so the
+// queue is worked in order
+func (r *moderationRepository) ListItems(ctx context.Context, status string, limit, offset int) ([]*domain.ModerationItem, int64, error) {
+	collection := r.db.Collection("moderation_items")
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count moderation items: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find moderation items: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := make([]*domain.ModerationItem, 0)
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, 0, fmt.Errorf("decode moderation items: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// Resolve sets the outcome of a quarantined item
+func (r *moderationRepository) Resolve(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.ModerationItem, error) {
+	collection := r.db.Collection("moderation_items")
+
+	set := bson.M{"status": status, "reviewed_by": reviewerID, "reviewed_at": time.Now().UTC()}
+	if note != "" {
+		set["note"] = note
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var item domain.ModerationItem
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": domain.ModerationQuarantined},
+		bson.M{"$set": set},
+		opts,
+	).Decode(&item)
+	if err == nil {
+		return &item, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("resolve moderation item: %w", err)
+	}
+
+	// Tell a missing item from one already resolved
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("count moderation items: %w", err)
+	}
+	if count == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return nil, domain.ErrInvalidTransition
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Money is stored as {amount, currency}, the amount in minor units. Pipelines sum the amounts
+// and take the currency of the first document, which is the same for all documents of a
+// tenant; moneyStage then turns the sums into money documents again.
+
+// purchaseAmount is what a purchase record cost in minor units: its unit price times its quantity
+var purchaseAmount = bson.M{"$multiply": bson.A{"$price_at_purchase.amount", "$quantity"}}
+
+// firstCurrency is the $group accumulator of the currency of the money at path
+func firstCurrency(path string) bson.M {
+	return bson.M{"$first": "$" + path + ".currency"}
+}
+
+// moneyStage is a $set stage turning the minor unit amounts in fields into money documents in
+// the currency of the "currency" field, and removing that field
+func moneyStage(fields ...string) bson.D {
+	set := bson.M{"currency": "$$REMOVE"}
+	for _, field := range fields {
+		set[field] = bson.M{
+			"amount":   bson.M{"$ifNull": bson.A{"$" + field, 0}},
+			"currency": bson.M{"$ifNull": bson.A{"$currency", ""}},
+		}
+	}
+	return bson.D{{Key: "$set", Value: set}}
+}
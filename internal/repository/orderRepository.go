@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// OrderRepository reads purchase records as orders for staff. Purchases are written by the
+// interaction repository.
+type OrderRepository interface {
+	// List retrieves a page of the orders matching the filter and their total number
+	List(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, int64, error)
+
+	// Stream calls fn for each order matching the filter as it is read, for exports of any size
+	Stream(ctx context.Context, filter domain.OrderFilter, fn func(*domain.Order) error) error
+}
+
+type orderRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewOrderRepository(db *mongodb.MongoDB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+// List counts and finds on the analytics read preference, like the other purchase reports
+func (r *orderRepository) List(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, int64, error) {
+	collection := r.db.AnalyticsCollection("user_product_purchases")
+	query, err := orderQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count orders: %w", err)
+	}
+
+	orders := make([]*domain.Order, 0)
+	err = r.find(ctx, collection, filter, func(order *domain.Order) error {
+		orders = append(orders, order)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+func (r *orderRepository) Stream(ctx context.Context, filter domain.OrderFilter, fn func(*domain.Order) error) error {
+	return r.find(ctx, r.db.AnalyticsCollection("user_product_purchases"), filter, fn)
+}
+
+// find decodes the orders matching the filter one at a time, newest first, with the
+// (user_id, purchased_at) or purchased_at index
+func (r *orderRepository) find(ctx context.Context, collection *mongodb.Collection, filter domain.OrderFilter, fn func(*domain.Order) error) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "purchased_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(filter.Offset)).
+		SetBatchSize(500)
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	query, err := orderQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return fmt.Errorf("find orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var order domain.Order
+		if err := cursor.Decode(&order); err != nil {
+			return fmt.Errorf("decode order: %w", err)
+		}
+		order.Total = order.Price.Mul(order.Quantity)
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// orderQuery builds the query of the filter; an ID that is not an event ID is a validation error
+func orderQuery(filter domain.OrderFilter) (bson.M, error) {
+	query := bson.M{}
+	if filter.ID != "" {
+		id, err := primitive.ObjectIDFromHex(filter.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid order ID %q: %w", filter.ID, domain.ErrValidation)
+		}
+		query["_id"] = id
+	}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+
+	purchasedAt := bson.M{}
+	if filter.From != nil {
+		purchasedAt["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		purchasedAt["$lt"] = *filter.To
+	}
+	if len(purchasedAt) > 0 {
+		query["purchased_at"] = purchasedAt
+	}
+
+	return query, nil
+}
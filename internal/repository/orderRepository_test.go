@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/testutil"
+)
+
+func TestListOrders(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	testutil.LoadFixtures(t, db, "testdata/orders.yaml")
+	repo := NewOrderRepository(db)
+
+	user := 1
+	from := time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filter   domain.OrderFilter
+		products []int
+		total    int64
+	}{
+		{"newest first", domain.OrderFilter{Limit: 2}, []int{4, 2}, 3},
+		{"second page", domain.OrderFilter{Limit: 2, Offset: 2}, []int{1}, 3},
+		{"of a user", domain.OrderFilter{UserID: &user}, []int{4, 1}, 2},
+		{"in a time range", domain.OrderFilter{From: &from, To: &to}, []int{2}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, total, err := repo.List(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("list orders: %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+
+			var products []int
+			for _, order := range orders {
+				products = append(products, order.ProductID)
+				if order.ID == "" {
+					t.Errorf("order of product %d has no id", order.ProductID)
+				}
+			}
+			if !slices.Equal(products, tt.products) {
+				t.Errorf("products = %v, want %v", products, tt.products)
+			}
+		})
+	}
+
+	// Streaming reads every matching order with its total
+	var totals []domain.Money
+	err := repo.Stream(context.Background(), domain.OrderFilter{UserID: &user}, func(order *domain.Order) error {
+		totals = append(totals, order.Total)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream orders: %v", err)
+	}
+	want := []domain.Money{domain.NewMoney(4500, "USD"), domain.NewMoney(99999, "USD")}
+	if !slices.Equal(totals, want) {
+		t.Errorf("totals = %v, want %v", totals, want)
+	}
+
+	// An order is found by its event ID
+	orders, _, err := repo.List(context.Background(), domain.OrderFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("list orders: %v", err)
+	}
+	found, total, err := repo.List(context.Background(), domain.OrderFilter{ID: orders[0].ID})
+	if err != nil {
+		t.Fatalf("list order by id: %v", err)
+	}
+	if total != 1 || found[0].ID != orders[0].ID {
+		t.Errorf("orders by id = %v (total %d), want order %s", found, total, orders[0].ID)
+	}
+	if _, _, err := repo.List(context.Background(), domain.OrderFilter{ID: "laptop"}); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("list by invalid id: err = %v, want validation error", err)
+	}
+}
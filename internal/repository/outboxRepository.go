@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type OutboxRepository interface {
+	// Add records an event; call it in the transaction of the change the event reports
+	Add(ctx context.Context, entry *domain.OutboxEntry) error
+
+	// Claim locks up to limit undelivered events with fewer than maxAttempts failures for lease,
+	// oldest first, so no other relay publishes them meanwhile
+	Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]*domain.OutboxEntry, error)
+
+	// MarkDelivered records a claimed event as published; it is removed after retention
+	MarkDelivered(ctx context.Context, id string, retention time.Duration) error
+
+	// MarkFailed releases a claimed event for another attempt
+	MarkFailed(ctx context.Context, id string, reason string) error
+}
+
+type outboxRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewOutboxRepository(db *mongodb.MongoDB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// outboxDocument is the stored form of an OutboxEntry. The payload is kept as JSON text, as it
+// is published.
+type outboxDocument struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Topic       string             `bson:"topic"`
+	Payload     string             `bson:"payload"`
+	Attempts    int                `bson:"attempts"`
+	LastError   string             `bson:"last_error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	DeliveredAt *time.Time         `bson:"delivered_at"`
+	LockedUntil *time.Time         `bson:"locked_until,omitempty"`
+	ExpiresAt   *time.Time         `bson:"expires_at,omitempty"`
+}
+
+func (d *outboxDocument) toDomain() *domain.OutboxEntry {
+	return &domain.OutboxEntry{
+		ID:          d.ID.Hex(),
+		Topic:       d.Topic,
+		Payload:     []byte(d.Payload),
+		Attempts:    d.Attempts,
+		LastError:   d.LastError,
+		CreatedAt:   d.CreatedAt,
+		DeliveredAt: d.DeliveredAt,
+	}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, entry *domain.OutboxEntry) error {
+	document := outboxDocument{
+		ID:        primitive.NewObjectID(),
+		Topic:     entry.Topic,
+		Payload:   string(entry.Payload),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if _, err := r.db.Collection("outbox").InsertOne(ctx, document); err != nil {
+		return fmt.Errorf("add outbox entry: %w", err)
+	}
+
+	entry.ID = document.ID.Hex()
+	entry.CreatedAt = document.CreatedAt
+	return nil
+}
+
+func (r *outboxRepository) Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]*domain.OutboxEntry, error) {
+	collection := r.db.Collection("outbox")
+	entries := make([]*domain.OutboxEntry, 0, limit)
+
+	// Each event is claimed on its own, so concurrent relays never take the same one
+	for len(entries) < limit {
+		now := time.Now().UTC()
+		filter := bson.M{
+			"delivered_at": nil,
+			"attempts":     bson.M{"$lt": maxAttempts},
+			"$or": bson.A{
+				bson.M{"locked_until": bson.M{"$exists": false}},
+				bson.M{"locked_until": bson.M{"$lte": now}},
+			},
+		}
+		update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+		opts := options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetReturnDocument(options.After)
+
+		var document outboxDocument
+		err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&document)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				break
+			}
+			return entries, fmt.Errorf("claim outbox entry: %w", err)
+		}
+		entries = append(entries, document.toDomain())
+	}
+
+	return entries, nil
+}
+
+func (r *outboxRepository) MarkDelivered(ctx context.Context, id string, retention time.Duration) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	_, err = r.db.Collection("outbox").UpdateOne(ctx,
+		bson.M{"_id": objectID},
+		bson.M{
+			"$set":   bson.M{"delivered_at": now, "expires_at": now.Add(retention)},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("mark outbox entry delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+
+	_, err = r.db.Collection("outbox").UpdateOne(ctx,
+		bson.M{"_id": objectID},
+		bson.M{
+			"$set":   bson.M{"last_error": reason},
+			"$inc":   bson.M{"attempts": 1},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("mark outbox entry failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type PermissionRepository interface {
+	Create(ctx context.Context, permission *domain.Permission) error
+	GetByID(ctx context.Context, id int) (*domain.Permission, error)
+	GetAll(ctx context.Context) ([]domain.Permission, error)
+	Delete(ctx context.Context, id int) error
+
+	// Role mapping
+	GetByRoleID(ctx context.Context, roleID int) ([]domain.Permission, error)
+	GetNamesByRole(ctx context.Context) (map[int][]string, error)
+	AssignToRole(ctx context.Context, roleID, permissionID int) error
+	RemoveFromRole(ctx context.Context, roleID, permissionID int) error
+}
+
+type permissionRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewPermissionRepository(db *mongodb.MongoDB) PermissionRepository {
+	return &permissionRepository{db: db}
+}
+
+// getNextID gets the next available permission ID
+func (r *permissionRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("permissions")
+
+	opts := options.FindOne().SetSort(bson.M{"_id": -1}).SetProjection(bson.M{"_id": 1})
+
+	var result domain.Permission
+	err := collection.FindOne(ctx, bson.M{}, opts).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil
+		}
+		return 0, err
+	}
+
+	return result.ID + 1, nil
+}
+
+// Create stores a new permission
+func (r *permissionRepository) Create(ctx context.Context, permission *domain.Permission) error {
+	collection := r.db.Collection("permissions")
+
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return fmt.Errorf("get next permission id: %w", err)
+	}
+
+	permission.ID = id
+	permission.CreatedAt = time.Now().UTC()
+
+	_, err = collection.InsertOne(ctx, permission)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("insert permission: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a permission by ID
+func (r *permissionRepository) GetByID(ctx context.Context, id int) (*domain.Permission, error) {
+	collection := r.db.Collection("permissions")
+
+	var permission domain.Permission
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&permission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find permission: %w", err)
+	}
+
+	return &permission, nil
+}
+
+// GetAll retrieves all permissions ordered by resource and action
+func (r *permissionRepository) GetAll(ctx context.Context) ([]domain.Permission, error) {
+	collection := r.db.Collection("permissions")
+
+	opts := options.Find().SetSort(bson.D{{Key: "resource", Value: 1}, {Key: "action", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find permissions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	permissions := make([]domain.Permission, 0)
+	if err := cursor.All(ctx, &permissions); err != nil {
+		return nil, fmt.Errorf("decode permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// Delete removes a permission and its role assignments
+func (r *permissionRepository) Delete(ctx context.Context, id int) error {
+	collection := r.db.Collection("permissions")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete permission: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	_, err = r.db.Collection("role_permissions").DeleteMany(ctx, bson.M{"permission_id": id})
+	if err != nil {
+		return fmt.Errorf("delete role permissions: %w", err)
+	}
+
+	return nil
+}
+
+// GetByRoleID retrieves permissions assigned to a role
+func (r *permissionRepository) GetByRoleID(ctx context.Context, roleID int) ([]domain.Permission, error) {
+	collection := r.db.Collection("role_permissions")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"role_id": roleID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "permissions",
+			"localField":   "permission_id",
+			"foreignField": "_id",
+			"as":           "permission",
+		}}},
+		{{Key: "$unwind", Value: "$permission"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$permission"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "resource", Value: 1}, {Key: "action", Value: 1}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("get role permissions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	permissions := make([]domain.Permission, 0)
+	if err := cursor.All(ctx, &permissions); err != nil {
+		return nil, fmt.Errorf("decode role permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// GetNamesByRole retrieves the names of the permissions granted directly to each role, by role ID
+func (r *permissionRepository) GetNamesByRole(ctx context.Context) (map[int][]string, error) {
+	collection := r.db.Collection("role_permissions")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "permissions",
+			"localField":   "permission_id",
+			"foreignField": "_id",
+			"as":           "permission",
+		}}},
+		{{Key: "$unwind", Value: "$permission"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$role_id",
+			"names": bson.M{"$addToSet": bson.M{
+				"$concat": bson.A{"$permission.resource", ":", "$permission.action"},
+			}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("get role permission names: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		RoleID int      `bson:"_id"`
+		Names  []string `bson:"names"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode role permission names: %w", err)
+	}
+
+	names := make(map[int][]string, len(results))
+	for _, result := range results {
+		names[result.RoleID] = result.Names
+	}
+
+	return names, nil
+}
+
+// AssignToRole grants a permission to a role
+func (r *permissionRepository) AssignToRole(ctx context.Context, roleID, permissionID int) error {
+	collection := r.db.Collection("role_permissions")
+
+	_, err := collection.InsertOne(ctx, bson.M{
+		"role_id":       roleID,
+		"permission_id": permissionID,
+		"created_at":    time.Now().UTC(),
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("assign permission: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFromRole revokes a permission from a role
+func (r *permissionRepository) RemoveFromRole(ctx context.Context, roleID, permissionID int) error {
+	collection := r.db.Collection("role_permissions")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"role_id": roleID, "permission_id": permissionID})
+	if err != nil {
+		return fmt.Errorf("remove permission: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type PhoneVerificationRepository interface {
+	Save(ctx context.Context, verification *domain.PhoneVerification) error
+	GetByUserID(ctx context.Context, userID int) (*domain.PhoneVerification, error)
+	IncrementAttempts(ctx context.Context, userID int) error
+	Delete(ctx context.Context, userID int) error
+}
+
+type phoneVerificationRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewPhoneVerificationRepository(db *mongodb.MongoDB) PhoneVerificationRepository {
+	return &phoneVerificationRepository{db: db}
+}
+
+// Save stores a verification code, replacing any earlier code of the user
+func (r *phoneVerificationRepository) Save(ctx context.Context, verification *domain.PhoneVerification) error {
+	collection := r.db.Collection("phone_verifications")
+
+	verification.CreatedAt = time.Now().UTC()
+	verification.Attempts = 0
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": verification.UserID}, verification, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("save phone verification: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID retrieves the user's pending verification code
+func (r *phoneVerificationRepository) GetByUserID(ctx context.Context, userID int) (*domain.PhoneVerification, error) {
+	collection := r.db.Collection("phone_verifications")
+
+	var verification domain.PhoneVerification
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&verification)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find phone verification: %w", err)
+	}
+
+	return &verification, nil
+}
+
+// IncrementAttempts records a failed verification attempt
+func (r *phoneVerificationRepository) IncrementAttempts(ctx context.Context, userID int) error {
+	collection := r.db.Collection("phone_verifications")
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"attempts": 1}})
+	if err != nil {
+		return fmt.Errorf("increment attempts: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the user's pending verification code
+func (r *phoneVerificationRepository) Delete(ctx context.Context, userID int) error {
+	collection := r.db.Collection("phone_verifications")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return fmt.Errorf("delete phone verification: %w", err)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,17 +14,28 @@ import (
 	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
 )
 
+//go:generate go tool mockgen -source=productRepository.go -destination=mocks/productRepository.go -package=mocks
+
 type ProductRepository interface {
 	// Product CRUD
 	Create(ctx context.Context, product *domain.Product) error
 	GetByID(ctx context.Context, id int) (*domain.Product, error)
-	GetByIDWithCategory(ctx context.Context, id int) (*domain.ProductWithCategory, error)
+	GetByIDWithCategory(ctx context.Context, id int, fields []string) (*domain.ProductWithCategory, error)
 	Update(ctx context.Context, product *domain.Product) error
 	Delete(ctx context.Context, id int) error
 
+	// SetImage sets the image and its variants generated from an upload. It does nothing if the
+	// image of a later upload is already set.
+	SetImage(ctx context.Context, id int, imageURL string, variants map[string]string, uploadedAt time.Time) error
+
+	// Translations
+	SetTranslation(ctx context.Context, productID int, locale string, translation domain.ProductTranslation) error
+	DeleteTranslation(ctx context.Context, productID int, locale string) error
+
 	// Product listing and search
 	List(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error)
 	ListWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error)
+	StreamWithCategories(ctx context.Context, filter domain.ProductFilter, fn func(*domain.ProductWithCategory) error) error
 	Search(ctx context.Context, query string, limit, offset int) ([]*domain.Product, int64, error)
 
 	// Category CRUD
@@ -33,10 +45,20 @@ type ProductRepository interface {
 	ListCategories(ctx context.Context) ([]*domain.Category, error)
 	UpdateCategory(ctx context.Context, category *domain.Category) error
 	DeleteCategory(ctx context.Context, id int) error
+	CountCategoryContents(ctx context.Context, id int) (products, children int64, err error)
+	CountActiveProductsByCategory(ctx context.Context) (map[int]int64, error)
+	MergeCategory(ctx context.Context, sourceID, targetID int) (*domain.CategoryMergeResult, error)
+
+	// NameVocabulary counts how often each word occurs in the names of active products, as
+	// split by domain.NameWords
+	NameVocabulary(ctx context.Context) (map[string]int64, error)
 
 	// Product statistics
 	GetProductStatistics(ctx context.Context, productID int) (*domain.ProductStatistics, error)
 	RefreshProductStatistics(ctx context.Context) error
+
+	// Change feed
+	WatchStock(ctx context.Context, fn func(domain.StockEvent)) error
 }
 
 type productRepository struct {
@@ -55,8 +77,8 @@ func (r *productRepository) Create(ctx context.Context, product *domain.Product)
 		return fmt.Errorf("get next ID: %w", err)
 	}
 	product.ID = nextID
-	product.CreatedAt = time.Now()
-	product.UpdatedAt = time.Now()
+	product.CreatedAt = time.Now().UTC()
+	product.UpdatedAt = time.Now().UTC()
 	product.IsActive = true
 
 	collection := r.db.Collection("products")
@@ -84,8 +106,46 @@ func (r *productRepository) GetByID(ctx context.Context, id int) (*domain.Produc
 	return &product, nil
 }
 
-// GetByIDWithCategory retrieves a product with category information
-func (r *productRepository) GetByIDWithCategory(ctx context.Context, id int) (*domain.ProductWithCategory, error) {
+// SetImage sets the image of an upload unless a later upload's image is set
+func (r *productRepository) SetImage(ctx context.Context, id int, imageURL string, variants map[string]string, uploadedAt time.Time) error {
+	collection := r.db.Collection("products")
+
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"image_uploaded_at": bson.M{"$exists": false}},
+			bson.M{"image_uploaded_at": bson.M{"$lte": uploadedAt}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"image_url":         imageURL,
+		"image_variants":    variants,
+		"image_uploaded_at": uploadedAt,
+		"updated_at":        time.Now().UTC(),
+	}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("set product image: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Tell a missing product from one with a later image
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("count products: %w", err)
+	}
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// GetByIDWithCategory retrieves a product with category information.
+// If fields is not empty, only those fields are returned.
+func (r *productRepository) GetByIDWithCategory(ctx context.Context, id int, fields []string) (*domain.ProductWithCategory, error) {
 	collection := r.db.Collection("products")
 
 	pipeline := mongo.Pipeline{
@@ -108,6 +168,10 @@ func (r *productRepository) GetByIDWithCategory(ctx context.Context, id int) (*d
 		}}},
 	}
 
+	if projection := productProjection(fields); projection != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
+
 	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("aggregate product with category: %w", err)
@@ -129,7 +193,7 @@ func (r *productRepository) GetByIDWithCategory(ctx context.Context, id int) (*d
 func (r *productRepository) Update(ctx context.Context, product *domain.Product) error {
 	collection := r.db.Collection("products")
 
-	product.UpdatedAt = time.Now()
+	product.UpdatedAt = time.Now().UTC()
 
 	update := bson.M{
 		"$set": bson.M{
@@ -137,10 +201,23 @@ func (r *productRepository) Update(ctx context.Context, product *domain.Product)
 			"description": product.Description,
 			"category_id": product.CategoryID,
 			"price":       product.Price,
+			"cost_price":  product.CostPrice,
 			"stock":       product.Stock,
 			"image_url":   product.ImageURL,
 			"is_active":   product.IsActive,
 			"updated_at":  product.UpdatedAt,
+
+			"image_variants": product.ImageVariants,
+
+			"previous_price":   product.PreviousPrice,
+			"price_changed_at": product.PriceChangedAt,
+
+			"price_tiers": product.PriceTiers,
+
+			"min_order_qty":  product.MinOrderQty,
+			"max_order_qty":  product.MaxOrderQty,
+			"qty_step":       product.QtyStep,
+			"purchase_limit": product.PurchaseLimit,
 		},
 	}
 
@@ -156,6 +233,51 @@ func (r *productRepository) Update(ctx context.Context, product *domain.Product)
 	return nil
 }
 
+// SetTranslation creates or replaces the product's translation into locale
+func (r *productRepository) SetTranslation(ctx context.Context, productID int, locale string, translation domain.ProductTranslation) error {
+	collection := r.db.Collection("products")
+
+	update := bson.M{
+		"$set": bson.M{
+			"translations." + locale: translation,
+			"updated_at":             time.Now().UTC(),
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": productID}, update)
+	if err != nil {
+		return fmt.Errorf("set product translation: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteTranslation removes the product's translation into locale
+func (r *productRepository) DeleteTranslation(ctx context.Context, productID int, locale string) error {
+	collection := r.db.Collection("products")
+
+	field := "translations." + locale
+	update := bson.M{
+		"$unset": bson.M{field: ""},
+		"$set":   bson.M{"updated_at": time.Now().UTC()},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": productID, field: bson.M{"$exists": true}}, update)
+	if err != nil {
+		return fmt.Errorf("delete product translation: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
 // Delete deletes a product
 func (r *productRepository) Delete(ctx context.Context, id int) error {
 	collection := r.db.Collection("products")
@@ -184,17 +306,17 @@ func (r *productRepository) List(ctx context.Context, filter domain.ProductFilte
 	}
 
 	if filter.MinPrice != nil {
-		if _, ok := mongoFilter["price"]; !ok {
-			mongoFilter["price"] = bson.M{}
+		if _, ok := mongoFilter["price.amount"]; !ok {
+			mongoFilter["price.amount"] = bson.M{}
 		}
-		mongoFilter["price"].(bson.M)["$gte"] = *filter.MinPrice
+		mongoFilter["price.amount"].(bson.M)["$gte"] = filter.MinPrice.Amount
 	}
 
 	if filter.MaxPrice != nil {
-		if _, ok := mongoFilter["price"]; !ok {
-			mongoFilter["price"] = bson.M{}
+		if _, ok := mongoFilter["price.amount"]; !ok {
+			mongoFilter["price.amount"] = bson.M{}
 		}
-		mongoFilter["price"].(bson.M)["$lte"] = *filter.MaxPrice
+		mongoFilter["price.amount"].(bson.M)["$lte"] = filter.MaxPrice.Amount
 	}
 
 	if filter.IsActive != nil {
@@ -205,6 +327,18 @@ func (r *productRepository) List(ctx context.Context, filter domain.ProductFilte
 		mongoFilter["$text"] = bson.M{"$search": filter.SearchQuery}
 	}
 
+	if len(filter.NamePrefixes) > 0 {
+		mongoFilter["$or"] = namePrefixConditions(filter.NamePrefixes)
+	}
+
+	if len(filter.Conditions) > 0 {
+		conditions, err := buildConditions(filter.Conditions, domain.ProductFilterFields)
+		if err != nil {
+			return nil, 0, err
+		}
+		mongoFilter["$and"] = conditions
+	}
+
 	// Count total
 	total, err := collection.CountDocuments(ctx, mongoFilter)
 	if err != nil {
@@ -214,16 +348,8 @@ func (r *productRepository) List(ctx context.Context, filter domain.ProductFilte
 	// Build options
 	opts := options.Find()
 
-	// Sort
-	sortField := "created_at"
-	if filter.SortBy != "" {
-		sortField = filter.SortBy
-	}
-	sortOrder := -1 // desc by default
-	if filter.SortOrder == "asc" {
-		sortOrder = 1
-	}
-	opts.SetSort(bson.M{sortField: sortOrder})
+	// Sort, newest first by default
+	opts.SetSort(buildSort(filter.Sort, domain.ProductSortFields, domain.SortField{Field: "created_at", Descending: true}))
 
 	// Pagination
 	if filter.Limit > 0 {
@@ -233,6 +359,11 @@ func (r *productRepository) List(ctx context.Context, filter domain.ProductFilte
 		opts.SetSkip(int64(filter.Offset))
 	}
 
+	// Projection
+	if projection := productProjection(filter.Fields); projection != nil {
+		opts.SetProjection(projection)
+	}
+
 	// Execute query
 	cursor, err := collection.Find(ctx, mongoFilter, opts)
 	if err != nil {
@@ -252,128 +383,187 @@ func (r *productRepository) List(ctx context.Context, filter domain.ProductFilte
 func (r *productRepository) ListWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error) {
 	collection := r.db.Collection("products")
 
-	// Build match stage
-	matchStage := bson.M{}
-
-	if filter.CategoryID != nil {
-		matchStage["category_id"] = *filter.CategoryID
+	pipeline, err := r.withCategoriesPipeline(filter)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if filter.MinPrice != nil {
-		if _, ok := matchStage["price"]; !ok {
-			matchStage["price"] = bson.M{}
+	// Count total
+	total := int64(0)
+	if !filter.SkipCount {
+		countPipeline := append(pipeline, bson.D{{Key: "$count", Value: "total"}})
+		countCursor, err := collection.Aggregate(ctx, countPipeline)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count products: %w", err)
 		}
-		matchStage["price"].(bson.M)["$gte"] = *filter.MinPrice
-	}
+		defer countCursor.Close(ctx)
 
-	if filter.MaxPrice != nil {
-		if _, ok := matchStage["price"]; !ok {
-			matchStage["price"] = bson.M{}
+		var countResult []struct {
+			Total int64 `bson:"total"`
+		}
+		if err := countCursor.All(ctx, &countResult); err != nil {
+			return nil, 0, fmt.Errorf("decode count: %w", err)
+		}
+		if len(countResult) > 0 {
+			total = countResult[0].Total
 		}
-		matchStage["price"].(bson.M)["$lte"] = *filter.MaxPrice
 	}
 
-	if filter.IsActive != nil {
-		matchStage["is_active"] = *filter.IsActive
-	}
+	// Sort, newest first by default
+	sort := buildSort(filter.Sort, domain.ProductSortFields, domain.SortField{Field: "created_at", Descending: true})
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
 
-	if filter.SearchQuery != "" {
-		matchStage["$text"] = bson.M{"$search": filter.SearchQuery}
+	// Pagination
+	if filter.Offset > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: filter.Offset}})
 	}
-
-	// Build pipeline
-	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: matchStage}},
-		{{Key: "$lookup", Value: bson.M{
-			"from":         "categories",
-			"localField":   "category_id",
-			"foreignField": "_id",
-			"as":           "category",
-		}}},
-		{{Key: "$unwind", Value: bson.M{
-			"path":                       "$category",
-			"preserveNullAndEmptyArrays": true,
-		}}},
-		{{Key: "$addFields", Value: bson.M{
-			"category_name": "$category.name",
-		}}},
-		{{Key: "$project", Value: bson.M{
-			"category": 0,
-		}}},
+	if filter.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: filter.Limit}})
 	}
 
-	// Count total
-	countPipeline := append(pipeline, bson.D{{Key: "$count", Value: "total"}})
-	countCursor, err := collection.Aggregate(ctx, countPipeline)
+	// Projection
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: productWithCategoryProjection(filter.Fields)}})
+
+	// Execute query
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, 0, fmt.Errorf("count products: %w", err)
+		return nil, 0, fmt.Errorf("aggregate products: %w", err)
 	}
-	defer countCursor.Close(ctx)
+	defer cursor.Close(ctx)
 
-	var countResult []struct {
-		Total int64 `bson:"total"`
-	}
-	if err := countCursor.All(ctx, &countResult); err != nil {
-		return nil, 0, fmt.Errorf("decode count: %w", err)
+	products, err := decodeProductsWithCategories(ctx, cursor)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	total := int64(0)
-	if len(countResult) > 0 {
-		total = countResult[0].Total
+	return products, total, nil
+}
+
+// decodeProductsWithCategories decodes each document of the cursor straight into a product,
+// without an intermediate map
+func decodeProductsWithCategories(ctx context.Context, cursor *mongo.Cursor) ([]*domain.ProductWithCategory, error) {
+	var products []*domain.ProductWithCategory
+	for cursor.Next(ctx) {
+		product := new(domain.ProductWithCategory)
+		if err := cursor.Decode(product); err != nil {
+			return nil, fmt.Errorf("decode product: %w", err)
+		}
+		products = append(products, product)
 	}
 
-	// Sort
-	sortField := "created_at"
-	if filter.SortBy != "" {
-		sortField = filter.SortBy
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
 	}
-	sortOrder := -1
-	if filter.SortOrder == "asc" {
-		sortOrder = 1
+
+	return products, nil
+}
+
+// StreamWithCategories calls fn for each product with category name matching the filter,
+// reading them from the cursor one at a time. A zero Limit streams all matching products.
+func (r *productRepository) StreamWithCategories(ctx context.Context, filter domain.ProductFilter, fn func(*domain.ProductWithCategory) error) error {
+	collection := r.db.Collection("products")
+
+	pipeline, err := r.withCategoriesPipeline(filter)
+	if err != nil {
+		return err
 	}
-	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.M{sortField: sortOrder}}})
 
-	// Pagination
+	sort := buildSort(filter.Sort, domain.ProductSortFields, domain.SortField{Field: "created_at", Descending: true})
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
 	if filter.Offset > 0 {
 		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: filter.Offset}})
 	}
 	if filter.Limit > 0 {
 		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: filter.Limit}})
 	}
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: productWithCategoryProjection(filter.Fields)}})
 
-	// Execute query
-	cursor, err := collection.Aggregate(ctx, pipeline)
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetBatchSize(500))
 	if err != nil {
-		return nil, 0, fmt.Errorf("aggregate products: %w", err)
+		return fmt.Errorf("aggregate products: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var products []*domain.ProductWithCategory
 	for cursor.Next(ctx) {
-		var rawDoc bson.M
-		if err := cursor.Decode(&rawDoc); err != nil {
-			return nil, 0, fmt.Errorf("decode raw doc: %w", err)
+		var product domain.ProductWithCategory
+		if err := cursor.Decode(&product); err != nil {
+			return fmt.Errorf("decode product: %w", err)
 		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
 
-		// Convert to bytes and back to properly handle UUID conversion
-		rawBytes, err := bson.Marshal(rawDoc)
-		if err != nil {
-			return nil, 0, fmt.Errorf("marshal doc: %w", err)
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
+	}
+
+	return nil
+}
+
+// withCategoriesPipeline builds the filter and category lookup stages shared by product listings
+func (r *productRepository) withCategoriesPipeline(filter domain.ProductFilter) (mongo.Pipeline, error) {
+	// Build match stage
+	matchStage := bson.M{}
+
+	if filter.CategoryID != nil {
+		matchStage["category_id"] = *filter.CategoryID
+	}
+
+	if filter.MinPrice != nil {
+		if _, ok := matchStage["price.amount"]; !ok {
+			matchStage["price.amount"] = bson.M{}
 		}
+		matchStage["price.amount"].(bson.M)["$gte"] = filter.MinPrice.Amount
+	}
 
-		var product domain.ProductWithCategory
-		if err := bson.Unmarshal(rawBytes, &product); err != nil {
-			return nil, 0, fmt.Errorf("unmarshal product: %w", err)
+	if filter.MaxPrice != nil {
+		if _, ok := matchStage["price.amount"]; !ok {
+			matchStage["price.amount"] = bson.M{}
 		}
+		matchStage["price.amount"].(bson.M)["$lte"] = filter.MaxPrice.Amount
+	}
 
-		products = append(products, &product)
+	if filter.IsActive != nil {
+		matchStage["is_active"] = *filter.IsActive
 	}
 
-	if err := cursor.Err(); err != nil {
-		return nil, 0, fmt.Errorf("cursor error: %w", err)
+	if filter.SearchQuery != "" {
+		matchStage["$text"] = bson.M{"$search": filter.SearchQuery}
 	}
 
-	return products, total, nil
+	if len(filter.NamePrefixes) > 0 {
+		matchStage["$or"] = namePrefixConditions(filter.NamePrefixes)
+	}
+
+	if len(filter.Conditions) > 0 {
+		conditions, err := buildConditions(filter.Conditions, domain.ProductFilterFields)
+		if err != nil {
+			return nil, err
+		}
+		matchStage["$and"] = conditions
+	}
+
+	// Build pipeline
+	return mongo.Pipeline{
+		{{Key: "$match", Value: matchStage}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "categories",
+			"localField":   "category_id",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		{{Key: "$unwind", Value: bson.M{
+			"path":                       "$category",
+			"preserveNullAndEmptyArrays": true,
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"category_name": "$category.name",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"category": 0,
+		}}},
+	}, nil
 }
 
 // Search searches for products (alias for List with search query)
@@ -394,8 +584,8 @@ func (r *productRepository) CreateCategory(ctx context.Context, category *domain
 		return fmt.Errorf("get next ID: %w", err)
 	}
 	category.ID = nextID
-	category.CreatedAt = time.Now()
-	category.UpdatedAt = time.Now()
+	category.CreatedAt = time.Now().UTC()
+	category.UpdatedAt = time.Now().UTC()
 
 	collection := r.db.Collection("categories")
 	_, err = collection.InsertOne(ctx, category)
@@ -463,7 +653,7 @@ func (r *productRepository) ListCategories(ctx context.Context) ([]*domain.Categ
 func (r *productRepository) UpdateCategory(ctx context.Context, category *domain.Category) error {
 	collection := r.db.Collection("categories")
 
-	category.UpdatedAt = time.Now()
+	category.UpdatedAt = time.Now().UTC()
 
 	update := bson.M{
 		"$set": bson.M{
@@ -502,6 +692,157 @@ func (r *productRepository) DeleteCategory(ctx context.Context, id int) error {
 	return nil
 }
 
+// CountCategoryContents counts the products and direct child categories of a category
+func (r *productRepository) CountCategoryContents(ctx context.Context, id int) (int64, int64, error) {
+	products, err := r.db.Collection("products").CountDocuments(ctx, bson.M{"category_id": id})
+	if err != nil {
+		return 0, 0, fmt.Errorf("count category products: %w", err)
+	}
+
+	children, err := r.db.Collection("categories").CountDocuments(ctx, bson.M{"parent_id": id})
+	if err != nil {
+		return 0, 0, fmt.Errorf("count child categories: %w", err)
+	}
+
+	return products, children, nil
+}
+
+// CountActiveProductsByCategory counts active products per category, keyed by category ID.
+// Categories without active products are absent from the map.
+func (r *productRepository) CountActiveProductsByCategory(ctx context.Context) (map[int]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"is_active": true}}},
+		{{Key: "$group", Value: bson.M{"_id": "$category_id", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.db.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate category product counts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		CategoryID int   `bson:"_id"`
+		Count      int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("decode category product counts: %w", err)
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+
+	return counts, nil
+}
+
+// NameVocabulary splits names on spaces in the database, so each distinct token is sent once,
+// and finishes splitting them here
+func (r *productRepository) NameVocabulary(ctx context.Context) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"is_active": true}}},
+		{{Key: "$project", Value: bson.M{"tokens": bson.M{"$setUnion": bson.A{
+			bson.M{"$split": bson.A{bson.M{"$toLower": "$name"}, " "}},
+		}}}}},
+		{{Key: "$unwind", Value: "$tokens"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tokens", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.db.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate product name words: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Token string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("decode product name words: %w", err)
+	}
+
+	vocabulary := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		for _, word := range domain.NameWords(row.Token) {
+			vocabulary[word] += row.Count
+		}
+	}
+	return vocabulary, nil
+}
+
+// namePrefixConditions matches names with a word starting with any of the prefixes
+func namePrefixConditions(prefixes []string) bson.A {
+	conditions := make(bson.A, len(prefixes))
+	for i, prefix := range prefixes {
+		conditions[i] = bson.M{"name": bson.M{
+			"$regex":   `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(prefix),
+			"$options": "i",
+		}}
+	}
+	return conditions
+}
+
+// MergeCategory moves the products and child categories of the source category to the target
+// and deletes the source, in one transaction where the deployment supports it
+func (r *productRepository) MergeCategory(ctx context.Context, sourceID, targetID int) (*domain.CategoryMergeResult, error) {
+	result := &domain.CategoryMergeResult{SourceID: sourceID, TargetID: targetID}
+
+	err := r.db.WithTransaction(ctx, func(ctx context.Context) error {
+		products := r.db.Collection("products")
+		categories := r.db.Collection("categories")
+		now := time.Now().UTC()
+
+		productIDs, err := products.Distinct(ctx, "_id", bson.M{"category_id": sourceID})
+		if err != nil {
+			return fmt.Errorf("find category products: %w", err)
+		}
+		result.MovedProductIDs = make([]int, 0, len(productIDs))
+		for _, value := range productIDs {
+			switch id := value.(type) {
+			case int32:
+				result.MovedProductIDs = append(result.MovedProductIDs, int(id))
+			case int64:
+				result.MovedProductIDs = append(result.MovedProductIDs, int(id))
+			}
+		}
+
+		moved, err := products.UpdateMany(ctx,
+			bson.M{"category_id": sourceID},
+			bson.M{"$set": bson.M{"category_id": targetID, "updated_at": now}},
+		)
+		if err != nil {
+			return fmt.Errorf("move products: %w", err)
+		}
+		result.MovedProducts = moved.ModifiedCount
+
+		movedChildren, err := categories.UpdateMany(ctx,
+			bson.M{"parent_id": sourceID},
+			bson.M{"$set": bson.M{"parent_id": targetID, "updated_at": now}},
+		)
+		if err != nil {
+			return fmt.Errorf("move child categories: %w", err)
+		}
+		result.MovedCategories = movedChildren.ModifiedCount
+
+		deleted, err := categories.DeleteOne(ctx, bson.M{"_id": sourceID})
+		if err != nil {
+			return fmt.Errorf("delete category: %w", err)
+		}
+		if deleted.DeletedCount == 0 {
+			return domain.ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // GetProductStatistics retrieves statistics for a product
 func (r *productRepository) GetProductStatistics(ctx context.Context, productID int) (*domain.ProductStatistics, error) {
 	product, err := r.GetByID(ctx, productID)
@@ -549,6 +890,51 @@ func (r *productRepository) RefreshProductStatistics(ctx context.Context) error
 	return nil
 }
 
+// WatchStock follows the products change stream and calls fn whenever a product's stock
+// is written, until ctx is cancelled. Change streams require a replica set or sharded cluster.
+func (r *productRepository) WatchStock(ctx context.Context, fn func(domain.StockEvent)) error {
+	collection := r.db.Collection("products")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"operationType": bson.M{"$in": bson.A{"insert", "replace"}}},
+			bson.M{"operationType": "update", "updateDescription.updatedFields.stock": bson.M{"$exists": true}},
+		}}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	stream, err := collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return fmt.Errorf("watch products: %w", err)
+	}
+	defer stream.Close(ctx)
+
+	for stream.Next(ctx) {
+		var change struct {
+			FullDocument *domain.Product `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			return fmt.Errorf("decode change: %w", err)
+		}
+		if change.FullDocument == nil {
+			continue
+		}
+
+		fn(domain.StockEvent{
+			ProductID: change.FullDocument.ID,
+			Stock:     change.FullDocument.Stock,
+			InStock:   change.FullDocument.Stock > 0,
+			UpdatedAt: change.FullDocument.UpdatedAt,
+		})
+	}
+
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("change stream: %w", err)
+	}
+
+	return nil
+}
+
 // getNextProductID gets the next auto-increment ID for products
 func (r *productRepository) getNextProductID(ctx context.Context) (int, error) {
 	collection := r.db.Collection("products")
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/testutil"
+)
+
+func TestListWithCategories(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	testutil.LoadFixtures(t, db, "testdata/catalog.yaml")
+	repo := NewProductRepository(db)
+
+	electronics, active := 1, true
+	tests := []struct {
+		name       string
+		filter     domain.ProductFilter
+		ids        []int
+		total      int64
+		categories []string
+	}{
+		{
+			name:       "newest first",
+			filter:     domain.ProductFilter{Limit: 3},
+			ids:        []int{5, 4, 3},
+			total:      5,
+			categories: []string{"", "Books", "Electronics"},
+		},
+		{
+			name:       "second page",
+			filter:     domain.ProductFilter{Limit: 3, Offset: 3},
+			ids:        []int{2, 1},
+			total:      5,
+			categories: []string{"Electronics", "Electronics"},
+		},
+		{
+			name: "active in category by price",
+			filter: domain.ProductFilter{
+				CategoryID: &electronics,
+				IsActive:   &active,
+				Sort:       []domain.SortField{{Field: "price"}},
+			},
+			ids:        []int{2, 1},
+			total:      2,
+			categories: []string{"Electronics", "Electronics"},
+		},
+		{
+			name: "price range without count",
+			filter: domain.ProductFilter{
+				MinPrice:  &domain.Money{Amount: 1000, Currency: "USD"},
+				MaxPrice:  &domain.Money{Amount: 50000, Currency: "USD"},
+				Sort:      []domain.SortField{{Field: "price", Descending: true}},
+				SkipCount: true,
+			},
+			ids:        []int{2, 3, 4},
+			total:      0,
+			categories: []string{"Electronics", "Electronics", "Books"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, total, err := repo.ListWithCategories(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("list with categories: %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+
+			var ids []int
+			var categories []string
+			for _, product := range products {
+				ids = append(ids, product.ID)
+				categories = append(categories, product.CategoryName)
+			}
+			if !slices.Equal(ids, tt.ids) {
+				t.Errorf("ids = %v, want %v", ids, tt.ids)
+			}
+			if !slices.Equal(categories, tt.categories) {
+				t.Errorf("categories = %v, want %v", categories, tt.categories)
+			}
+		})
+	}
+
+	// Only the selected fields are read
+	products, _, err := repo.ListWithCategories(context.Background(), domain.ProductFilter{Limit: 1, Fields: []string{"id", "name"}})
+	if err != nil {
+		t.Fatalf("list selected fields: %v", err)
+	}
+	if len(products) != 1 || products[0].ID != 5 || products[0].Name != "Cable" || products[0].Stock != 0 {
+		t.Errorf("selected fields = %+v, want only the ID and name of product 5", products)
+	}
+}
+
+// BenchmarkDecodeProductPage decodes a page of 10k products as ListWithCategories did before
+// projecting and decoding directly (whole documents through a bson.M and back) and as it does
+// now. It needs no database: the cursor is built from the documents.
+func BenchmarkDecodeProductPage(b *testing.B) {
+	const pageSize = 10000
+
+	var full, projected []interface{}
+	for i := 1; i <= pageSize; i++ {
+		document := productDocument(i)
+		full = append(full, document)
+
+		var kept bson.D
+		for _, element := range document {
+			if _, ok := productWithCategoryFields[element.Key]; ok {
+				kept = append(kept, element)
+			}
+		}
+		projected = append(projected, kept)
+	}
+
+	decoders := []struct {
+		name      string
+		documents []interface{}
+		decode    func(context.Context, *mongo.Cursor) ([]*domain.ProductWithCategory, error)
+	}{
+		{"MapRoundTrip", full, decodeProductsThroughMap},
+		{"Direct", projected, decodeProductsWithCategories},
+	}
+	for _, decoder := range decoders {
+		b.Run(decoder.name, func(b *testing.B) {
+			ctx := context.Background()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				cursor, err := mongo.NewCursorFromDocuments(decoder.documents, nil, nil)
+				if err != nil {
+					b.Fatalf("new cursor: %v", err)
+				}
+				b.StartTimer()
+
+				products, err := decoder.decode(ctx, cursor)
+				if err != nil {
+					b.Fatalf("decode: %v", err)
+				}
+				if len(products) != pageSize {
+					b.Fatalf("decoded %d products, want %d", len(products), pageSize)
+				}
+			}
+		})
+	}
+}
+
+// decodeProductsThroughMap is how ListWithCategories decoded products before: into a bson.M,
+// marshalled back and decoded again
+func decodeProductsThroughMap(ctx context.Context, cursor *mongo.Cursor) ([]*domain.ProductWithCategory, error) {
+	var products []*domain.ProductWithCategory
+	for cursor.Next(ctx) {
+		var rawDoc bson.M
+		if err := cursor.Decode(&rawDoc); err != nil {
+			return nil, err
+		}
+		rawBytes, err := bson.Marshal(rawDoc)
+		if err != nil {
+			return nil, err
+		}
+		var product domain.ProductWithCategory
+		if err := bson.Unmarshal(rawBytes, &product); err != nil {
+			return nil, err
+		}
+		products = append(products, &product)
+	}
+	return products, cursor.Err()
+}
+
+// productDocument is a stored product as the list pipeline returns it, with its category name
+// and the fields the list doesn't read
+func productDocument(id int) bson.D {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(id) * time.Minute)
+	categoryID := id%20 + 1
+	previous := domain.NewMoney(int64(id*100+500), "USD")
+	product := domain.Product{
+		ID:              id,
+		Name:            fmt.Sprintf("Product %d", id),
+		Description:     fmt.Sprintf("Description of product %d, long enough to look like a real one in a listing.", id),
+		CategoryID:      &categoryID,
+		Price:           domain.NewMoney(int64(id*100), "USD"),
+		Stock:           id % 50,
+		ImageURL:        fmt.Sprintf("https://cdn.example.com/products/%d.jpg", id),
+		IsActive:        true,
+		CreatedAt:       created,
+		UpdatedAt:       created,
+		ImageVariants:   map[string]string{"thumb": fmt.Sprintf("https://cdn.example.com/products/%d_thumb.webp", id), "medium_webp": fmt.Sprintf("https://cdn.example.com/products/%d_medium.webp", id)},
+		ImageUploadedAt: &created,
+		PreviousPrice:   &previous,
+		PriceChangedAt:  &created,
+		CostPrice:       domain.NewMoney(int64(id*60), "USD"),
+		Translations: map[string]domain.ProductTranslation{
+			"de": {Name: fmt.Sprintf("Produkt %d", id), Description: "Beschreibung"},
+			"fr": {Name: fmt.Sprintf("Produit %d", id), Description: "Description"},
+		},
+	}
+
+	data, err := bson.Marshal(product)
+	if err != nil {
+		panic(err)
+	}
+	var document bson.D
+	if err := bson.Unmarshal(data, &document); err != nil {
+		panic(err)
+	}
+	return append(document, bson.E{Key: "category_name", Value: fmt.Sprintf("Category %d", categoryID)})
+}
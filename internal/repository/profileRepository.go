@@ -13,11 +13,17 @@ import (
 	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
 )
 
+//go:generate go tool mockgen -source=profileRepository.go -destination=mocks/profileRepository.go -package=mocks
+
 type ProfileRepository interface {
 	Create(ctx context.Context, profile *domain.Profile) error
 	GetByUserID(ctx context.Context, userID int) (*domain.Profile, error)
+	GetByUserIDWithFields(ctx context.Context, userID int, fields []string) (*domain.Profile, error)
 	Update(ctx context.Context, profile *domain.Profile) error
 	Delete(ctx context.Context, userID int) error
+	MarkPhoneVerified(ctx context.Context, userID int, phone string) error
+	RecordChange(ctx context.Context, change *domain.ProfileChange) error
+	UpdatePreferences(ctx context.Context, userID int, preferences *domain.Preferences) error
 }
 
 type profileRepository struct {
@@ -69,7 +75,7 @@ func (r *profileRepository) Create(ctx context.Context, profile *domain.Profile)
 	profile.ID = id
 
 	// Set timestamps
-	now := time.Now()
+	now := time.Now().UTC()
 	profile.CreatedAt = now
 	profile.UpdatedAt = now
 
@@ -97,25 +103,48 @@ func (r *profileRepository) GetByUserID(ctx context.Context, userID int) (*domai
 	return &profile, nil
 }
 
+// GetByUserIDWithFields gets profile by user ID, returning only the selected ProfileFields
+func (r *profileRepository) GetByUserIDWithFields(ctx context.Context, userID int, fields []string) (*domain.Profile, error) {
+	collection := r.db.Collection("profiles")
+
+	opts := options.FindOne()
+	if projection := buildProjection(fields, domain.ProfileFields); projection != nil {
+		opts.SetProjection(projection)
+	}
+
+	var profile domain.Profile
+	err := collection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&profile)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get profile by user id: %w", err)
+	}
+
+	return &profile, nil
+}
+
 // Update updates a profile
 func (r *profileRepository) Update(ctx context.Context, profile *domain.Profile) error {
 	collection := r.db.Collection("profiles")
 
-	profile.UpdatedAt = time.Now()
+	profile.UpdatedAt = time.Now().UTC()
 
 	update := bson.M{
 		"$set": bson.M{
-			"first_name":    profile.FirstName,
-			"last_name":     profile.LastName,
-			"middle_name":   profile.MiddleName,
-			"date_of_birth": profile.DateOfBirth,
-			"gender":        profile.Gender,
-			"phone":         profile.Phone,
-			"address":       profile.Address,
-			"city":          profile.City,
-			"country":       profile.Country,
-			"postal_code":   profile.PostalCode,
-			"updated_at":    profile.UpdatedAt,
+			"first_name":        profile.FirstName,
+			"last_name":         profile.LastName,
+			"middle_name":       profile.MiddleName,
+			"date_of_birth":     profile.DateOfBirth,
+			"gender":            profile.Gender,
+			"phone":             profile.Phone,
+			"phone_verified":    profile.PhoneVerified,
+			"phone_verified_at": profile.PhoneVerifiedAt,
+			"address":           profile.Address,
+			"city":              profile.City,
+			"country":           profile.Country,
+			"postal_code":       profile.PostalCode,
+			"updated_at":        profile.UpdatedAt,
 		},
 	}
 
@@ -146,3 +175,66 @@ func (r *profileRepository) Delete(ctx context.Context, userID int) error {
 
 	return nil
 }
+
+// MarkPhoneVerified marks the profile phone as verified if it still equals phone
+func (r *profileRepository) MarkPhoneVerified(ctx context.Context, userID int, phone string) error {
+	collection := r.db.Collection("profiles")
+
+	now := time.Now().UTC()
+	update := bson.M{
+		"$set": bson.M{
+			"phone_verified":    true,
+			"phone_verified_at": now,
+			"updated_at":        now,
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"user_id": userID, "phone": phone}, update)
+	if err != nil {
+		return fmt.Errorf("mark phone verified: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordChange appends an entry to the user's profile change log
+func (r *profileRepository) RecordChange(ctx context.Context, change *domain.ProfileChange) error {
+	collection := r.db.Collection("profile_changes")
+
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now().UTC()
+	}
+
+	if _, err := collection.InsertOne(ctx, change); err != nil {
+		return fmt.Errorf("record profile change: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePreferences replaces the preferences stored on the user's profile
+func (r *profileRepository) UpdatePreferences(ctx context.Context, userID int, preferences *domain.Preferences) error {
+	collection := r.db.Collection("profiles")
+
+	update := bson.M{
+		"$set": bson.M{
+			"preferences": preferences,
+			"updated_at":  time.Now().UTC(),
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"user_id": userID}, update)
+	if err != nil {
+		return fmt.Errorf("update preferences: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
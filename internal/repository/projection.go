@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// buildProjection translates selected JSON field names into a Mongo inclusion projection.
+// It returns nil when no fields are selected (or none map to document fields),
+// meaning the whole document is returned.
+func buildProjection(fields []string, allowed map[string]string) bson.M {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	projection := bson.M{}
+	for _, field := range fields {
+		if docField := allowed[field]; docField != "" {
+			projection[docField] = 1
+		}
+	}
+	if len(projection) == 0 {
+		return nil
+	}
+
+	// _id is returned unless excluded explicitly
+	if _, ok := projection["_id"]; !ok {
+		projection["_id"] = 0
+	}
+
+	return projection
+}
+
+// productProjection is buildProjection for products. Translations are loaded along with the
+// name or description so the response can be localized.
+func productProjection(fields []string) bson.M {
+	projection := buildProjection(fields, domain.ProductFields)
+	if projection == nil {
+		return nil
+	}
+	if _, ok := projection["name"]; ok {
+		projection["translations"] = 1
+	} else if _, ok := projection["description"]; ok {
+		projection["translations"] = 1
+	}
+	return projection
+}
+
+// productWithCategoryFields are the document fields decoded into domain.ProductWithCategory.
+// Lists project only these, so fields used for search, pricing history and such aren't sent.
+var productWithCategoryFields = bson.M{
+	"_id":            1,
+	"name":           1,
+	"description":    1,
+	"category_id":    1,
+	"category_name":  1,
+	"price":          1,
+	"stock":          1,
+	"image_url":      1,
+	"is_active":      1,
+	"created_at":     1,
+	"updated_at":     1,
+	"cost_price":     1,
+	"image_variants": 1,
+	"translations":   1,
+	"price_tiers":    1,
+	"min_order_qty":  1,
+	"max_order_qty":  1,
+	"qty_step":       1,
+	"purchase_limit": 1,
+	"flash_sale":     1,
+}
+
+// productWithCategoryProjection is productProjection for products with their category name,
+// projecting every field they decode when none are selected
+func productWithCategoryProjection(fields []string) bson.M {
+	if projection := productProjection(fields); projection != nil {
+		return projection
+	}
+	return productWithCategoryFields
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type PromotionRepository interface {
+	// Templates
+	Create(ctx context.Context, template *domain.PromotionTemplate) error
+	GetByID(ctx context.Context, id int) (*domain.PromotionTemplate, error)
+	List(ctx context.Context) ([]*domain.PromotionTemplate, error)
+	ListActive(ctx context.Context, occasion string) ([]*domain.PromotionTemplate, error)
+	Update(ctx context.Context, template *domain.PromotionTemplate) error
+	Delete(ctx context.Context, id int) error
+
+	// ListRecipients returns the active users whose occasion falls on one of the days, given as
+	// MM-DD in UTC. Birthdays come from profiles and anniversaries from the sign-up time.
+	ListRecipients(ctx context.Context, occasion string, days []string) ([]domain.PromotionRecipient, error)
+
+	// Coupons
+
+	// GrantCoupon stores a coupon and reports whether it was granted. A coupon the template
+	// already granted the user for the year is not granted again.
+	GrantCoupon(ctx context.Context, coupon *domain.Coupon) (bool, error)
+	ListUserCoupons(ctx context.Context, userID int) ([]*domain.Coupon, error)
+
+	// RedeemCoupon marks the user's coupon with the code redeemed at now, if it has not been
+	// redeemed and has not expired, and returns it. Other coupons are reported as not found.
+	RedeemCoupon(ctx context.Context, userID int, code string, now time.Time) (*domain.Coupon, error)
+
+	// ReleaseCoupon undoes a redemption, for a checkout that failed after redeeming
+	ReleaseCoupon(ctx context.Context, id int) error
+}
+
+type promotionRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewPromotionRepository(db *mongodb.MongoDB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+// getNextID gets the next ID of the counter
+func (r *promotionRepository) getNextID(ctx context.Context, counter string) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": counter},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next %s: %w", counter, err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new promotion template
+func (r *promotionRepository) Create(ctx context.Context, template *domain.PromotionTemplate) error {
+	id, err := r.getNextID(ctx, "promotion_template_id")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	template.ID = id
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	if _, err := r.db.Collection("promotion_templates").InsertOne(ctx, template); err != nil {
+		return fmt.Errorf("insert promotion template: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a promotion template by ID
+func (r *promotionRepository) GetByID(ctx context.Context, id int) (*domain.PromotionTemplate, error) {
+	var template domain.PromotionTemplate
+	err := r.db.Collection("promotion_templates").FindOne(ctx, bson.M{"_id": id}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find promotion template: %w", err)
+	}
+
+	return &template, nil
+}
+
+// List retrieves all promotion templates by occasion and name
+func (r *promotionRepository) List(ctx context.Context) ([]*domain.PromotionTemplate, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// ListActive retrieves the active templates of an occasion
+func (r *promotionRepository) ListActive(ctx context.Context, occasion string) ([]*domain.PromotionTemplate, error) {
+	return r.find(ctx, bson.M{"occasion": occasion, "is_active": true})
+}
+
+func (r *promotionRepository) find(ctx context.Context, filter bson.M) ([]*domain.PromotionTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "occasion", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := r.db.Collection("promotion_templates").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find promotion templates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	templates := make([]*domain.PromotionTemplate, 0)
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("decode promotion templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Update saves everything but the creation time of a promotion template
+func (r *promotionRepository) Update(ctx context.Context, template *domain.PromotionTemplate) error {
+	template.UpdatedAt = time.Now().UTC()
+
+	result, err := r.db.Collection("promotion_templates").UpdateOne(ctx, bson.M{"_id": template.ID}, bson.M{"$set": bson.M{
+		"name":        template.Name,
+		"occasion":    template.Occasion,
+		"percent_off": template.PercentOff,
+		"valid_days":  template.ValidDays,
+		"channel":     template.Channel,
+		"subject":     template.Subject,
+		"body":        template.Body,
+		"is_active":   template.IsActive,
+		"updated_at":  template.UpdatedAt,
+	}})
+	if err != nil {
+		return fmt.Errorf("update promotion template: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a promotion template. Coupons it granted stay redeemable.
+func (r *promotionRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Collection("promotion_templates").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete promotion template: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListRecipients matches the month and day of the occasion's date in UTC, joined with the
+// user's name and preferences
+func (r *promotionRepository) ListRecipients(ctx context.Context, occasion string, days []string) ([]domain.PromotionRecipient, error) {
+	onDays := func(field string) bson.M {
+		return bson.M{"$expr": bson.M{"$in": bson.A{
+			bson.M{"$dateToString": bson.M{"format": "%m-%d", "date": field}},
+			days,
+		}}}
+	}
+
+	var collection *mongodb.Collection
+	var pipeline mongo.Pipeline
+	switch occasion {
+	case domain.PromotionBirthday:
+		collection = r.db.AnalyticsCollection("profiles")
+		pipeline = mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"date_of_birth": bson.M{"$type": "date"}}}},
+			{{Key: "$match", Value: onDays("$date_of_birth")}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":         "users",
+				"localField":   "user_id",
+				"foreignField": "_id",
+				"as":           "user",
+			}}},
+			{{Key: "$match", Value: bson.M{"user.status": domain.UserStatusActive}}},
+			{{Key: "$project", Value: bson.M{
+				"_id":         "$user_id",
+				"first_name":  1,
+				"date":        "$date_of_birth",
+				"preferences": 1,
+			}}},
+		}
+	case domain.PromotionAnniversary:
+		collection = r.db.AnalyticsCollection("users")
+		pipeline = mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"status": domain.UserStatusActive}}},
+			{{Key: "$match", Value: onDays("$created_at")}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":         "profiles",
+				"localField":   "_id",
+				"foreignField": "user_id",
+				"as":           "profile",
+			}}},
+			{{Key: "$unwind", Value: bson.M{"path": "$profile", "preserveNullAndEmptyArrays": true}}},
+			{{Key: "$project", Value: bson.M{
+				"first_name":  "$profile.first_name",
+				"date":        "$created_at",
+				"preferences": "$profile.preferences",
+			}}},
+		}
+	default:
+		return nil, fmt.Errorf("unknown occasion %q", occasion)
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate %s recipients: %w", occasion, err)
+	}
+	defer cursor.Close(ctx)
+
+	recipients := make([]domain.PromotionRecipient, 0)
+	if err := cursor.All(ctx, &recipients); err != nil {
+		return nil, fmt.Errorf("decode %s recipients: %w", occasion, err)
+	}
+
+	return recipients, nil
+}
+
+// GrantCoupon inserts the coupon; the unique index on user, template and year turns a second
+// grant into a duplicate key error
+func (r *promotionRepository) GrantCoupon(ctx context.Context, coupon *domain.Coupon) (bool, error) {
+	id, err := r.getNextID(ctx, "coupon_id")
+	if err != nil {
+		return false, err
+	}
+	coupon.ID = id
+
+	if _, err := r.db.Collection("coupons").InsertOne(ctx, coupon); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("insert coupon: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListUserCoupons retrieves the coupons of a user, newest first
+func (r *promotionRepository) ListUserCoupons(ctx context.Context, userID int) ([]*domain.Coupon, error) {
+	opts := options.Find().SetSort(bson.M{"issued_at": -1})
+	cursor, err := r.db.Collection("coupons").Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find coupons: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	coupons := make([]*domain.Coupon, 0)
+	if err := cursor.All(ctx, &coupons); err != nil {
+		return nil, fmt.Errorf("decode coupons: %w", err)
+	}
+
+	return coupons, nil
+}
+
+// RedeemCoupon claims the coupon in one update, so it is redeemed at most once
+func (r *promotionRepository) RedeemCoupon(ctx context.Context, userID int, code string, now time.Time) (*domain.Coupon, error) {
+	filter := bson.M{
+		"code":        code,
+		"user_id":     userID,
+		"redeemed_at": bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var coupon domain.Coupon
+	err := r.db.Collection("coupons").FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"redeemed_at": now}}, opts).Decode(&coupon)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("redeem coupon: %w", err)
+	}
+
+	return &coupon, nil
+}
+
+// ReleaseCoupon makes a redeemed coupon redeemable again
+func (r *promotionRepository) ReleaseCoupon(ctx context.Context, id int) error {
+	if _, err := r.db.Collection("coupons").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"redeemed_at": ""}}); err != nil {
+		return fmt.Errorf("release coupon: %w", err)
+	}
+	return nil
+}
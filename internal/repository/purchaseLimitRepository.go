@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type PurchaseLimitRepository interface {
+	// Consume adds quantity to the units of the product the user bought in the limit's window
+	// now is in, unless that would exceed the limit. It reports the units bought in the window
+	// and whether quantity was added.
+	Consume(ctx context.Context, productID, userID, quantity int, limit domain.PurchaseLimit, now time.Time) (int, bool, error)
+
+	// Release gives back quantity consumed in the window starting at windowStart, for a
+	// purchase that failed
+	Release(ctx context.Context, productID, userID, quantity int, windowStart time.Time) error
+}
+
+type purchaseLimitRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewPurchaseLimitRepository(db *mongodb.MongoDB) PurchaseLimitRepository {
+	return &purchaseLimitRepository{db: db}
+}
+
+// Consume increments the window's allowance only while it stays within the limit. The first
+// purchase in a window inserts the allowance; when the filter doesn't match an existing one
+// the upsert fails on the unique index, which means the limit would be exceeded. The update is
+// retried once, as two first purchases in a window race to insert it.
+func (r *purchaseLimitRepository) Consume(ctx context.Context, productID, userID, quantity int, limit domain.PurchaseLimit, now time.Time) (int, bool, error) {
+	if quantity > limit.Quantity {
+		used, err := r.used(ctx, productID, userID, limit, now)
+		return used, false, err
+	}
+
+	windowStart, windowEnd := limit.Window(now)
+	filter := bson.M{
+		"product_id":   productID,
+		"user_id":      userID,
+		"window_start": windowStart,
+		"quantity":     bson.M{"$lte": limit.Quantity - quantity},
+	}
+	update := bson.M{
+		"$inc":         bson.M{"quantity": quantity},
+		"$setOnInsert": bson.M{"expires_at": windowEnd},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		var allowance domain.PurchaseAllowance
+		err := r.db.Collection("purchase_allowances").FindOneAndUpdate(ctx, filter, update, opts).Decode(&allowance)
+		if err == nil {
+			return allowance.Quantity, true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return 0, false, fmt.Errorf("consume purchase allowance: %w", err)
+		}
+	}
+
+	used, err := r.used(ctx, productID, userID, limit, now)
+	return used, false, err
+}
+
+// used returns the units of the product the user bought in the window now is in
+func (r *purchaseLimitRepository) used(ctx context.Context, productID, userID int, limit domain.PurchaseLimit, now time.Time) (int, error) {
+	windowStart, _ := limit.Window(now)
+
+	var allowance domain.PurchaseAllowance
+	err := r.db.Collection("purchase_allowances").FindOne(ctx, bson.M{
+		"product_id":   productID,
+		"user_id":      userID,
+		"window_start": windowStart,
+	}).Decode(&allowance)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("find purchase allowance: %w", err)
+	}
+
+	return allowance.Quantity, nil
+}
+
+// Release decrements the window's allowance
+func (r *purchaseLimitRepository) Release(ctx context.Context, productID, userID, quantity int, windowStart time.Time) error {
+	_, err := r.db.Collection("purchase_allowances").UpdateOne(ctx,
+		bson.M{"product_id": productID, "user_id": userID, "window_start": windowStart},
+		bson.M{"$inc": bson.M{"quantity": -quantity}},
+	)
+	if err != nil {
+		return fmt.Errorf("release purchase allowance: %w", err)
+	}
+
+	return nil
+}
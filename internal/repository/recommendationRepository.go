@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type RecommendationRepository interface {
+	// Feedback
+	SaveFeedback(ctx context.Context, feedback *domain.RecommendationFeedback) error
+	GetFeedbackProductIDs(ctx context.Context, userID int) ([]int, error)
+
+	// Click-through tracking
+	RecordClick(ctx context.Context, click *domain.RecommendationClick) error
+
+	// Matrix factorization model
+	SaveFactors(ctx context.Context, userFactors, itemFactors []domain.FactorVector, trainedAt time.Time) error
+	GetUserFactors(ctx context.Context, userID int) (*domain.FactorVector, error)
+	GetAllItemFactors(ctx context.Context) ([]domain.FactorVector, error)
+}
+
+type recommendationRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewRecommendationRepository(db *mongodb.MongoDB) RecommendationRepository {
+	return &recommendationRepository{db: db}
+}
+
+// SaveFeedback stores user feedback for a product, replacing any previous feedback
+func (r *recommendationRepository) SaveFeedback(ctx context.Context, feedback *domain.RecommendationFeedback) error {
+	collection := r.db.Collection("recommendation_feedback")
+
+	feedback.CreatedAt = time.Now().UTC()
+
+	filter := bson.M{
+		"user_id":    feedback.UserID,
+		"product_id": feedback.ProductID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"type":       feedback.Type,
+			"created_at": feedback.CreatedAt,
+		},
+	}
+
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("save feedback: %w", err)
+	}
+
+	return nil
+}
+
+// GetFeedbackProductIDs retrieves IDs of products the user gave feedback on
+func (r *recommendationRepository) GetFeedbackProductIDs(ctx context.Context, userID int) ([]int, error) {
+	collection := r.db.Collection("recommendation_feedback")
+
+	opts := options.Find().SetProjection(bson.M{"product_id": 1})
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("get feedback: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var feedback []domain.RecommendationFeedback
+	if err := cursor.All(ctx, &feedback); err != nil {
+		return nil, fmt.Errorf("decode feedback: %w", err)
+	}
+
+	productIDs := make([]int, 0, len(feedback))
+	for _, f := range feedback {
+		productIDs = append(productIDs, f.ProductID)
+	}
+
+	return productIDs, nil
+}
+
+// RecordClick records a click on a recommended product
+func (r *recommendationRepository) RecordClick(ctx context.Context, click *domain.RecommendationClick) error {
+	collection := r.db.Collection("recommendation_clicks")
+
+	click.ClickedAt = time.Now().UTC()
+
+	_, err := collection.InsertOne(ctx, click)
+	if err != nil {
+		return fmt.Errorf("record click: %w", err)
+	}
+
+	return nil
+}
+
+// SaveFactors stores user and item factor vectors of a trained model and removes
+// vectors left over from previous runs
+func (r *recommendationRepository) SaveFactors(ctx context.Context, userFactors, itemFactors []domain.FactorVector, trainedAt time.Time) error {
+	// Mongo stores dates with millisecond precision
+	trainedAt = trainedAt.Truncate(time.Millisecond)
+
+	if err := r.replaceFactors(ctx, "user_factors", userFactors, trainedAt); err != nil {
+		return fmt.Errorf("save user factors: %w", err)
+	}
+
+	if err := r.replaceFactors(ctx, "item_factors", itemFactors, trainedAt); err != nil {
+		return fmt.Errorf("save item factors: %w", err)
+	}
+
+	return nil
+}
+
+// replaceFactors upserts factor vectors into a collection and deletes stale ones
+func (r *recommendationRepository) replaceFactors(ctx context.Context, collectionName string, factors []domain.FactorVector, trainedAt time.Time) error {
+	collection := r.db.Collection(collectionName)
+
+	if len(factors) > 0 {
+		models := make([]mongo.WriteModel, 0, len(factors))
+		for _, f := range factors {
+			f.UpdatedAt = trainedAt
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": f.ID}).
+				SetReplacement(f).
+				SetUpsert(true))
+		}
+
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+
+	_, err := collection.DeleteMany(ctx, bson.M{"updated_at": bson.M{"$lt": trainedAt}})
+	return err
+}
+
+// GetUserFactors retrieves the factor vector of a user
+func (r *recommendationRepository) GetUserFactors(ctx context.Context, userID int) (*domain.FactorVector, error) {
+	collection := r.db.Collection("user_factors")
+
+	var factors domain.FactorVector
+	err := collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&factors)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get user factors: %w", err)
+	}
+
+	return &factors, nil
+}
+
+// GetAllItemFactors retrieves factor vectors of all products
+func (r *recommendationRepository) GetAllItemFactors(ctx context.Context) ([]domain.FactorVector, error) {
+	collection := r.db.AnalyticsCollection("item_factors")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("get item factors: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var factors []domain.FactorVector
+	if err := cursor.All(ctx, &factors); err != nil {
+		return nil, fmt.Errorf("decode item factors: %w", err)
+	}
+
+	return factors, nil
+}
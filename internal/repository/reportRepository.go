@@ -0,0 +1,541 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type ReportRepository interface {
+	// SalesByProduct sums the purchases of each product in [from, to), highest revenue first
+	SalesByProduct(ctx context.Context, from, to time.Time) ([]domain.ProductSales, error)
+
+	// SalesGrouped sums the purchases in [from, to) per period in the time zone, per category or
+	// per product. Periods come oldest first, categories and products highest gross revenue first.
+	SalesGrouped(ctx context.Context, groupBy string, from, to time.Time, timezone string) ([]domain.SalesGroup, error)
+
+	// CohortSizes counts the users who signed up in [from, to) per week or month in the time zone
+	CohortSizes(ctx context.Context, period string, from, to time.Time, timezone string) ([]domain.CohortCount, error)
+
+	// CohortActivity counts the users who signed up in [from, to) and viewed, liked or bought
+	// something in each period since, up to to
+	CohortActivity(ctx context.Context, period string, from, to time.Time, timezone string) ([]domain.CohortCount, error)
+
+	// InventoryByCategory sums the stock of all products, active or not, per category, highest
+	// retail value first
+	InventoryByCategory(ctx context.Context) ([]domain.InventoryCategory, error)
+
+	// DeadStock finds the products in stock, added before since, that haven't sold since, with
+	// their totals. Up to limit products are listed, highest retail value first.
+	DeadStock(ctx context.Context, since time.Time, limit int) (*domain.DeadStock, error)
+
+	// SearchStats sums the searches in [from, to) in total, per term for the limit most searched
+	// terms, and per term over the searches without results for the limit most searched of those
+	SearchStats(ctx context.Context, from, to time.Time, limit int) (totals domain.SearchTermStats, top, zeroResults []domain.SearchTermStats, err error)
+
+	// ListLowStock retrieves the active products with at most threshold in stock, lowest first
+	ListLowStock(ctx context.Context, threshold int) ([]*domain.Product, error)
+
+	// ClaimRun records that the run with the given key is being sent, reporting whether it
+	// wasn't claimed before, so only one instance sends each report
+	ClaimRun(ctx context.Context, key string) (bool, error)
+
+	// ReleaseRun drops the claim of a run that failed, so it is sent again
+	ReleaseRun(ctx context.Context, key string) error
+}
+
+type reportRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewReportRepository(db *mongodb.MongoDB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// SalesByProduct groups the purchases of the period by product, with the product names
+func (r *reportRepository) SalesByProduct(ctx context.Context, from, to time.Time) ([]domain.ProductSales, error) {
+	collection := r.db.AnalyticsCollection("user_product_purchases")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"purchased_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$product_id",
+			"purchases": bson.M{"$sum": 1},
+			"units":     bson.M{"$sum": "$quantity"},
+			"revenue":   bson.M{"$sum": purchaseAmount},
+			"currency":  firstCurrency("price_at_purchase"),
+
+			"cost_of_goods":  bson.M{"$sum": purchaseCost},
+			"costed_revenue": bson.M{"$sum": bson.M{"$cond": bson.A{costed, purchaseAmount, 0}}},
+			"purchased_name": bson.M{"$last": "$product_name"},
+		}}},
+		marginStage,
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		// Deleted products keep the name they were bought under
+		{{Key: "$set", Value: bson.M{"name": bson.M{"$ifNull": bson.A{
+			bson.M{"$arrayElemAt": bson.A{"$product.name", 0}},
+			"$purchased_name",
+		}}}}},
+		{{Key: "$project", Value: bson.M{"product": 0, "purchased_name": 0}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+		moneyStage("revenue", "gross_margin"),
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate product sales: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	sales := make([]domain.ProductSales, 0)
+	if err := cursor.All(ctx, &sales); err != nil {
+		return nil, fmt.Errorf("decode product sales: %w", err)
+	}
+
+	return sales, nil
+}
+
+// SalesGrouped truncates purchase times with $dateTrunc (MongoDB 5.0 or later) to group by
+// period, and joins products to group by category
+func (r *reportRepository) SalesGrouped(ctx context.Context, groupBy string, from, to time.Time, timezone string) ([]domain.SalesGroup, error) {
+	collection := r.db.AnalyticsCollection("user_product_purchases")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"purchased_at": bson.M{"$gte": from, "$lt": to}}}},
+	}
+
+	var key any
+	switch groupBy {
+	case domain.SalesGroupDay, domain.SalesGroupWeek, domain.SalesGroupMonth:
+		key = truncDate("$purchased_at", groupBy, timezone)
+	case domain.SalesGroupCategory:
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         "products",
+				"localField":   "product_id",
+				"foreignField": "_id",
+				"as":           "product",
+			}}},
+			bson.D{{Key: "$set", Value: bson.M{"category_id": bson.M{"$arrayElemAt": bson.A{"$product.category_id", 0}}}}},
+		)
+		key = "$category_id"
+	case domain.SalesGroupProduct:
+		key = "$product_id"
+	default:
+		return nil, fmt.Errorf("unknown sales grouping %q: %w", groupBy, domain.ErrValidation)
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.M{
+		"_id":           key,
+		"purchases":     bson.M{"$sum": 1},
+		"units":         bson.M{"$sum": "$quantity"},
+		"gross_revenue": bson.M{"$sum": purchaseAmount},
+		"currency":      firstCurrency("price_at_purchase"),
+
+		"cost_of_goods":      bson.M{"$sum": purchaseCost},
+		"costed_revenue":     bson.M{"$sum": bson.M{"$cond": bson.A{costed, purchaseAmount, 0}}},
+		"uncosted_purchases": bson.M{"$sum": bson.M{"$cond": bson.A{costed, 0, 1}}},
+	}}}, marginStage)
+
+	if domain.IsTimeGrouping(groupBy) {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$set", Value: bson.M{"period": "$_id"}}},
+			bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		)
+	} else {
+		from := "products"
+		if groupBy == domain.SalesGroupCategory {
+			from = "categories"
+		}
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         from,
+				"localField":   "_id",
+				"foreignField": "_id",
+				"as":           "named",
+			}}},
+			bson.D{{Key: "$set", Value: bson.M{
+				"id":   "$_id",
+				"name": bson.M{"$arrayElemAt": bson.A{"$named.name", 0}},
+			}}},
+			bson.D{{Key: "$project", Value: bson.M{"named": 0}}},
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "gross_revenue", Value: -1}, {Key: "_id", Value: 1}}}},
+		)
+	}
+
+	// Nothing is taken off gross revenue until discounts and refunds are recorded
+	pipeline = append(pipeline,
+		moneyStage("gross_revenue", "cost_of_goods", "gross_margin"),
+		bson.D{{Key: "$set", Value: bson.M{"net_revenue": "$gross_revenue"}}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("aggregate grouped sales: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	groups := make([]domain.SalesGroup, 0)
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("decode grouped sales: %w", err)
+	}
+
+	return groups, nil
+}
+
+// purchaseCost is what the units of a purchase record cost to stock in minor units, 0 if it was
+// recorded without a cost price
+var purchaseCost = bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$cost_at_purchase.amount", 0}}, "$quantity"}}
+
+// costed is whether a purchase record has a cost price
+var costed = bson.M{"$gt": bson.A{"$cost_at_purchase.amount", 0}}
+
+// marginStage sets the gross margin of grouped purchases from the revenue of those with a cost
+// price and their cost of goods
+var marginStage = bson.D{{Key: "$set", Value: bson.M{
+	"gross_margin":   bson.M{"$subtract": bson.A{"$costed_revenue", "$cost_of_goods"}},
+	"costed_revenue": "$$REMOVE",
+}}}
+
+// truncDate is the $dateTrunc of a date to the start of its day, week (Monday) or month
+func truncDate(date any, unit, timezone string) bson.M {
+	trunc := bson.M{"date": date, "unit": unit, "timezone": timezone}
+	if unit == "week" {
+		trunc["startOfWeek"] = "monday"
+	}
+	return bson.M{"$dateTrunc": trunc}
+}
+
+// CohortSizes groups the users by the period of their signup
+func (r *reportRepository) CohortSizes(ctx context.Context, period string, from, to time.Time, timezone string) ([]domain.CohortCount, error) {
+	collection := r.db.AnalyticsCollection("users")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{"_id": truncDate("$created_at", period, timezone), "users": bson.M{"$sum": 1}}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "cohort": "$_id", "offset": bson.M{"$literal": 0}, "users": 1}}},
+		{{Key: "$sort", Value: bson.M{"cohort": 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate cohort sizes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make([]domain.CohortCount, 0)
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("decode cohort sizes: %w", err)
+	}
+
+	return counts, nil
+}
+
+// CohortActivity merges views, likes and purchases with $unionWith, reduces them to the periods
+// each user was active in, then joins the users to count them per signup cohort and period
+// since signup. $dateTrunc and $dateDiff require MongoDB 5.0 or later.
+func (r *reportRepository) CohortActivity(ctx context.Context, period string, from, to time.Time, timezone string) ([]domain.CohortCount, error) {
+	var first string
+	var pipeline bson.A
+	for _, activityType := range []string{domain.ActivityView, domain.ActivityLike, domain.ActivityPurchase} {
+		source := activitySources[activityType]
+		stages := bson.A{
+			bson.M{"$match": bson.M{"user_id": bson.M{"$gt": 0}, source.timeField: bson.M{"$gte": from, "$lt": to}}},
+			bson.M{"$project": bson.M{"_id": 0, "user_id": 1, "at": "$" + source.timeField}},
+		}
+
+		if first == "" {
+			first = source.collection
+			pipeline = append(pipeline, stages...)
+			continue
+		}
+		pipeline = append(pipeline, bson.M{"$unionWith": bson.M{
+			"coll":     source.collection,
+			"pipeline": stages,
+		}})
+	}
+
+	diff := bson.M{"startDate": "$cohort", "endDate": "$_id.period", "unit": period, "timezone": timezone}
+	if period == "week" {
+		diff["startOfWeek"] = "monday"
+	}
+
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"user": "$user_id", "period": truncDate("$at", period, timezone)}}},
+		bson.M{"$lookup": bson.M{
+			"from":         "users",
+			"localField":   "_id.user",
+			"foreignField": "_id",
+			"as":           "user",
+		}},
+		bson.M{"$set": bson.M{"created_at": bson.M{"$arrayElemAt": bson.A{"$user.created_at", 0}}}},
+		bson.M{"$match": bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}},
+		bson.M{"$set": bson.M{"cohort": truncDate("$created_at", period, timezone)}},
+		bson.M{"$set": bson.M{"offset": bson.M{"$dateDiff": diff}}},
+		// Guest activity merged into an account on signup can predate it
+		bson.M{"$match": bson.M{"offset": bson.M{"$gte": 0}}},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"cohort": "$cohort", "offset": "$offset"},
+			"users": bson.M{"$sum": 1},
+		}},
+		bson.M{"$project": bson.M{"_id": 0, "cohort": "$_id.cohort", "offset": "$_id.offset", "users": 1}},
+	)
+
+	cursor, err := r.db.AnalyticsCollection(first).Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("aggregate cohort activity: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make([]domain.CohortCount, 0)
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("decode cohort activity: %w", err)
+	}
+
+	return counts, nil
+}
+
+// stockValues sets the retail_value and cost_value of a product's stock, in minor units
+var stockValues = bson.M{
+	"retail_value": bson.M{"$multiply": bson.A{"$stock", "$price.amount"}},
+	"cost_value":   bson.M{"$multiply": bson.A{"$stock", bson.M{"$ifNull": bson.A{"$cost_price.amount", 0}}}},
+}
+
+// InventoryByCategory groups the products by category, with the category names
+func (r *reportRepository) InventoryByCategory(ctx context.Context) ([]domain.InventoryCategory, error) {
+	collection := r.db.AnalyticsCollection("products")
+
+	inStock := bson.M{"$gt": bson.A{"$stock", 0}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: stockValues}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$category_id",
+			"products":     bson.M{"$sum": 1},
+			"in_stock":     bson.M{"$sum": bson.M{"$cond": bson.A{inStock, 1, 0}}},
+			"units":        bson.M{"$sum": "$stock"},
+			"retail_value": bson.M{"$sum": "$retail_value"},
+			"cost_value":   bson.M{"$sum": "$cost_value"},
+			"currency":     firstCurrency("price"),
+			"uncosted_products": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{inStock, bson.M{"$not": bson.A{bson.M{"$gt": bson.A{"$cost_price.amount", 0}}}}}}, 1, 0,
+			}}},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "categories",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"id":   "$_id",
+			"name": bson.M{"$arrayElemAt": bson.A{"$category.name", 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{"category": 0}}},
+		{{Key: "$sort", Value: bson.D{{Key: "retail_value", Value: -1}, {Key: "_id", Value: 1}}}},
+		moneyStage("retail_value", "cost_value"),
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate inventory: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	categories := make([]domain.InventoryCategory, 0)
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, fmt.Errorf("decode inventory: %w", err)
+	}
+
+	return categories, nil
+}
+
+// DeadStock joins each stocked product's last purchase and keeps the ones not bought since
+func (r *reportRepository) DeadStock(ctx context.Context, since time.Time, limit int) (*domain.DeadStock, error) {
+	collection := r.db.AnalyticsCollection("products")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"stock": bson.M{"$gt": 0}, "created_at": bson.M{"$lt": since}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "user_product_purchases",
+			"localField":   "_id",
+			"foreignField": "product_id",
+			"pipeline": bson.A{
+				bson.M{"$sort": bson.M{"purchased_at": -1}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"purchased_at": 1}},
+			},
+			"as": "last_sale",
+		}}},
+		{{Key: "$set", Value: bson.M{"last_sold_at": bson.M{"$arrayElemAt": bson.A{"$last_sale.purchased_at", 0}}}}},
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"last_sold_at": bson.M{"$exists": false}},
+			bson.M{"last_sold_at": bson.M{"$lt": since}},
+		}}}},
+		{{Key: "$set", Value: stockValues}},
+		{{Key: "$facet", Value: bson.M{
+			"products": bson.A{
+				bson.M{"$sort": bson.D{{Key: "retail_value", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": limit},
+				bson.M{"$project": bson.M{
+					"name": 1, "category_id": 1, "stock": 1, "retail_value": 1, "cost_value": 1, "last_sold_at": 1,
+					"currency": "$price.currency",
+				}},
+				moneyStage("retail_value", "cost_value"),
+			},
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":          nil,
+					"count":        bson.M{"$sum": 1},
+					"units":        bson.M{"$sum": "$stock"},
+					"retail_value": bson.M{"$sum": "$retail_value"},
+					"cost_value":   bson.M{"$sum": "$cost_value"},
+					"currency":     firstCurrency("price"),
+				}},
+				moneyStage("retail_value", "cost_value"),
+			},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("aggregate dead stock: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Products []domain.DeadStockProduct `bson:"products"`
+		Totals   []domain.DeadStock        `bson:"totals"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode dead stock: %w", err)
+	}
+
+	deadStock := &domain.DeadStock{}
+	if len(results) > 0 && len(results[0].Totals) > 0 {
+		*deadStock = results[0].Totals[0]
+	}
+	deadStock.Products = make([]domain.DeadStockProduct, 0)
+	if len(results) > 0 {
+		deadStock.Products = append(deadStock.Products, results[0].Products...)
+	}
+
+	return deadStock, nil
+}
+
+// searchZeroResults is whether a logged search found nothing as typed
+var searchZeroResults = bson.M{"$or": bson.A{bson.M{"$eq": bson.A{"$result_count", 0}}, "$corrected"}}
+
+// searchTermGroup sums the searches grouped by key
+func searchTermGroup(key any) bson.M {
+	return bson.M{"$group": bson.M{
+		"_id":             key,
+		"searches":        bson.M{"$sum": 1},
+		"zero_results":    bson.M{"$sum": bson.M{"$cond": bson.A{searchZeroResults, 1, 0}}},
+		"corrected":       bson.M{"$sum": bson.M{"$cond": bson.A{"$corrected", 1, 0}}},
+		"average_results": bson.M{"$avg": "$result_count"},
+		"clicks":          bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$clicked_at", false}}, 1, 0}}},
+	}}
+}
+
+// SearchStats groups the searches of the period three ways in one pass
+func (r *reportRepository) SearchStats(ctx context.Context, from, to time.Time, limit int) (domain.SearchTermStats, []domain.SearchTermStats, []domain.SearchTermStats, error) {
+	collection := r.db.AnalyticsCollection("searches")
+
+	mostSearched := bson.M{"$sort": bson.D{{Key: "searches", Value: -1}, {Key: "_id", Value: 1}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"searched_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{searchTermGroup(nil)},
+			"top":    bson.A{searchTermGroup("$term"), mostSearched, bson.M{"$limit": limit}},
+			"zero_results": bson.A{
+				bson.M{"$match": bson.M{"$expr": searchZeroResults}},
+				searchTermGroup("$term"),
+				mostSearched,
+				bson.M{"$limit": limit},
+			},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return domain.SearchTermStats{}, nil, nil, fmt.Errorf("aggregate search stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Totals      []domain.SearchTermStats `bson:"totals"`
+		Top         []domain.SearchTermStats `bson:"top"`
+		ZeroResults []domain.SearchTermStats `bson:"zero_results"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return domain.SearchTermStats{}, nil, nil, fmt.Errorf("decode search stats: %w", err)
+	}
+
+	var totals domain.SearchTermStats
+	top := make([]domain.SearchTermStats, 0)
+	zeroResults := make([]domain.SearchTermStats, 0)
+	if len(results) > 0 {
+		if len(results[0].Totals) > 0 {
+			totals = results[0].Totals[0]
+		}
+		top = append(top, results[0].Top...)
+		zeroResults = append(zeroResults, results[0].ZeroResults...)
+	}
+
+	return totals, top, zeroResults, nil
+}
+
+// ListLowStock retrieves active products running out of stock
+func (r *reportRepository) ListLowStock(ctx context.Context, threshold int) ([]*domain.Product, error) {
+	collection := r.db.AnalyticsCollection("products")
+
+	filter := bson.M{"is_active": true, "stock": bson.M{"$lte": threshold}}
+	opts := options.Find().SetSort(bson.D{{Key: "stock", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find low stock products: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	products := make([]*domain.Product, 0)
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, fmt.Errorf("decode low stock products: %w", err)
+	}
+
+	return products, nil
+}
+
+// ClaimRun inserts the run; a duplicate key means another instance claimed it
+func (r *reportRepository) ClaimRun(ctx context.Context, key string) (bool, error) {
+	_, err := r.db.Collection("report_runs").InsertOne(ctx, bson.M{"_id": key, "claimed_at": time.Now().UTC()})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("claim report run: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseRun deletes the claim of a run
+func (r *reportRepository) ReleaseRun(ctx context.Context, key string) error {
+	if _, err := r.db.Collection("report_runs").DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("release report run: %w", err)
+	}
+
+	return nil
+}
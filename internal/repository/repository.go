@@ -1,23 +1,117 @@
 package repository
 
-import mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// Transactor runs fn in a transaction, so the writes of the repositories it calls commit or roll
+// back together where the deployment supports transactions. fn must use the context it is given.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
 
 type Repository struct {
-	Example     Example
-	Health      Health
-	User        UserRepository
-	Profile     ProfileRepository
-	Product     ProductRepository
-	Interaction InteractionRepository
+	Example           Example
+	Health            Health
+	User              UserRepository
+	Profile           ProfileRepository
+	Product           ProductRepository
+	Interaction       InteractionRepository
+	Order             OrderRepository
+	Recommendation    RecommendationRepository
+	BoughtTogether    BoughtTogetherRepository
+	Feed              FeedRepository
+	Role              RoleRepository
+	Session           SessionRepository
+	EmailChange       EmailChangeRepository
+	PhoneVerification PhoneVerificationRepository
+	Permission        PermissionRepository
+	Activity          ActivityRepository
+	Stock             StockRepository
+	Warehouse         WarehouseRepository
+	Bundle            BundleRepository
+	Subscription      SubscriptionRepository
+	Cart              CartRepository
+	Risk              RiskRepository
+	Moderation        ModerationRepository
+	Segment           SegmentRepository
+	Promotion         PromotionRepository
+	CustomerGroup     CustomerGroupRepository
+	PurchaseLimit     PurchaseLimitRepository
+	FlashSale         FlashSaleRepository
+	SupportNote       SupportNoteRepository
+	SearchLog         SearchLogRepository
+	Usage             UsageRepository
+	Report            ReportRepository
+	Index             IndexRepository
+	Integrity         IntegrityRepository
+	Backup            BackupRepository
+	Maintenance       MaintenanceRepository
+	Outbox            OutboxRepository
+
+	// Transactor spans a transaction over calls to several repositories
+	Transactor Transactor
+
+	views *viewWriter
 }
 
-func NewRepositories(db *mongodb.MongoDB) *Repository {
+func NewRepositories(db *mongodb.MongoDB, cfg *config.Config) *Repository {
+	var views *viewWriter
+	if cfg.Interactions.BatchViews {
+		views = newViewWriter(db, &cfg.Interactions)
+	}
+
 	return &Repository{
-		Example:     NewExampleRepository(db),
-		Health:      NewHealthRepository(db),
-		User:        NewUserRepository(db),
-		Profile:     NewProfileRepository(db),
-		Product:     NewProductRepository(db),
-		Interaction: NewInteractionRepository(db),
+		Example:           NewExampleRepository(db),
+		Health:            NewHealthRepository(db),
+		User:              NewUserRepository(db),
+		Profile:           NewProfileRepository(db),
+		Product:           NewProductRepository(db),
+		Interaction:       &interactionRepository{db: db, views: views},
+		Order:             NewOrderRepository(db),
+		Recommendation:    NewRecommendationRepository(db),
+		BoughtTogether:    NewBoughtTogetherRepository(db),
+		Feed:              NewFeedRepository(db),
+		Role:              NewRoleRepository(db),
+		Session:           NewSessionRepository(db),
+		EmailChange:       NewEmailChangeRepository(db),
+		PhoneVerification: NewPhoneVerificationRepository(db),
+		Permission:        NewPermissionRepository(db),
+		Activity:          NewActivityRepository(db),
+		Stock:             NewStockRepository(db),
+		Warehouse:         NewWarehouseRepository(db),
+		Bundle:            NewBundleRepository(db),
+		Subscription:      NewSubscriptionRepository(db),
+		Cart:              NewCartRepository(db),
+		Risk:              NewRiskRepository(db),
+		Moderation:        NewModerationRepository(db),
+		Segment:           NewSegmentRepository(db),
+		Promotion:         NewPromotionRepository(db),
+		CustomerGroup:     NewCustomerGroupRepository(db),
+		PurchaseLimit:     NewPurchaseLimitRepository(db),
+		FlashSale:         NewFlashSaleRepository(db),
+		SupportNote:       NewSupportNoteRepository(db),
+		SearchLog:         NewSearchLogRepository(db),
+		Usage:             NewUsageRepository(db),
+		Report:            NewReportRepository(db),
+		Index:             NewIndexRepository(db),
+		Integrity:         NewIntegrityRepository(db),
+		Backup:            NewBackupRepository(db),
+		Maintenance:       NewMaintenanceRepository(db),
+		Outbox:            NewOutboxRepository(db),
+		Transactor:        db,
+		views:             views,
+	}
+}
+
+// Close writes out buffered interactions. Call it after requests have stopped and before the
+// database is closed.
+func (r *Repository) Close(ctx context.Context) error {
+	if r.views == nil {
+		return nil
 	}
+	return r.views.Close(ctx)
 }
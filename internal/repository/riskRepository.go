@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type RiskRepository interface {
+	// CountPurchases counts the purchase records of a user, or of a guest if userID is 0,
+	// made since the given time
+	CountPurchases(ctx context.Context, userID int, anonymousID string, since time.Time) (int64, error)
+
+	// Review queue
+	CreateReview(ctx context.Context, review *domain.RiskReview) error
+	ListReviews(ctx context.Context, status string, limit, offset int) ([]*domain.RiskReview, int64, error)
+
+	// ResolveReview sets the outcome of a pending review and returns it, or
+	// ErrInvalidTransition if it was already resolved
+	ResolveReview(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.RiskReview, error)
+}
+
+type riskRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewRiskRepository(db *mongodb.MongoDB) RiskRepository {
+	return &riskRepository{db: db}
+}
+
+// getNextID gets the next risk review ID from the counter
+func (r *riskRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "risk_review_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next risk review id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// CountPurchases counts the purchase records of a buyer since the given time
+func (r *riskRepository) CountPurchases(ctx context.Context, userID int, anonymousID string, since time.Time) (int64, error) {
+	filter := bson.M{"purchased_at": bson.M{"$gte": since}}
+	if userID != 0 {
+		filter["user_id"] = userID
+	} else {
+		filter["user_id"] = 0
+		filter["anonymous_id"] = anonymousID
+	}
+
+	count, err := r.db.Collection("user_product_purchases").CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count purchases: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateReview queues a flagged checkout for review
+func (r *riskRepository) CreateReview(ctx context.Context, review *domain.RiskReview) error {
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	review.ID = id
+	review.Status = domain.RiskReviewPending
+	review.CreatedAt = time.Now().UTC()
+
+	if _, err := r.db.Collection("risk_reviews").InsertOne(ctx, review); err != nil {
+		return fmt.Errorf("insert risk review: %w", err)
+	}
+
+	return nil
+}
+
+// ListReviews retrieves a page of reviews, any status if status is empty, oldest first so
+// the queue is worked in order
+func (r *riskRepository) ListReviews(ctx context.Context, status string, limit, offset int) ([]*domain.RiskReview, int64, error) {
+	collection := r.db.Collection("risk_reviews")
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count risk reviews: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find risk reviews: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	reviews := make([]*domain.RiskReview, 0)
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, fmt.Errorf("decode risk reviews: %w", err)
+	}
+
+	return reviews, total, nil
+}
+
+// ResolveReview sets the outcome of a pending review
+func (r *riskRepository) ResolveReview(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.RiskReview, error) {
+	collection := r.db.Collection("risk_reviews")
+
+	set := bson.M{"status": status, "reviewed_by": reviewerID, "reviewed_at": time.Now().UTC()}
+	if note != "" {
+		set["note"] = note
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review domain.RiskReview
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": domain.RiskReviewPending},
+		bson.M{"$set": set},
+		opts,
+	).Decode(&review)
+	if err == nil {
+		return &review, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("resolve risk review: %w", err)
+	}
+
+	// Tell a missing review from one already resolved
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("count risk reviews: %w", err)
+	}
+	if count == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return nil, domain.ErrInvalidTransition
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *domain.Role) error
+	GetByID(ctx context.Context, id int) (*domain.Role, error)
+	GetAll(ctx context.Context) ([]domain.Role, error)
+	Update(ctx context.Context, role *domain.Role) error
+	Delete(ctx context.Context, id int) error
+	GetUserRoleNames(ctx context.Context, userID int) ([]string, error)
+	AssignToUser(ctx context.Context, userID, roleID int) error
+	CountUsers(ctx context.Context, roleID int) (int64, error)
+}
+
+type roleRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewRoleRepository(db *mongodb.MongoDB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// getNextID gets the next available role ID
+func (r *roleRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("roles")
+
+	opts := options.FindOne().SetSort(bson.M{"_id": -1}).SetProjection(bson.M{"_id": 1})
+
+	var result domain.Role
+	err := collection.FindOne(ctx, bson.M{}, opts).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil
+		}
+		return 0, err
+	}
+
+	return result.ID + 1, nil
+}
+
+// Create stores a new role
+func (r *roleRepository) Create(ctx context.Context, role *domain.Role) error {
+	collection := r.db.Collection("roles")
+
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return fmt.Errorf("get next role id: %w", err)
+	}
+
+	role.ID = id
+	role.CreatedAt = time.Now().UTC()
+	role.UpdatedAt = role.CreatedAt
+
+	_, err = collection.InsertOne(ctx, role)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("insert role: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a role by ID
+func (r *roleRepository) GetByID(ctx context.Context, id int) (*domain.Role, error) {
+	collection := r.db.Collection("roles")
+
+	var role domain.Role
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&role)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find role: %w", err)
+	}
+
+	return &role, nil
+}
+
+// GetAll retrieves all roles
+func (r *roleRepository) GetAll(ctx context.Context) ([]domain.Role, error) {
+	collection := r.db.Collection("roles")
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("find roles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	roles := make([]domain.Role, 0)
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, fmt.Errorf("decode roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// Update saves the description and parent roles of a role; names are fixed once tokens carry them
+func (r *roleRepository) Update(ctx context.Context, role *domain.Role) error {
+	collection := r.db.Collection("roles")
+
+	role.UpdatedAt = time.Now().UTC()
+	inherits := role.Inherits
+	if inherits == nil {
+		inherits = []int{}
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": role.ID}, bson.M{"$set": bson.M{
+		"description": role.Description,
+		"inherits":    inherits,
+		"updated_at":  role.UpdatedAt,
+	}})
+	if err != nil {
+		return fmt.Errorf("update role: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a role and its permission grants
+func (r *roleRepository) Delete(ctx context.Context, id int) error {
+	collection := r.db.Collection("roles")
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	_, err = r.db.Collection("role_permissions").DeleteMany(ctx, bson.M{"role_id": id})
+	if err != nil {
+		return fmt.Errorf("delete role permissions: %w", err)
+	}
+
+	return nil
+}
+
+// CountUsers counts the users assigned a role in every tenant, as roles are shared by all of them
+func (r *roleRepository) CountUsers(ctx context.Context, roleID int) (int64, error) {
+	count, err := r.db.AllTenantsCollection("user_roles").CountDocuments(ctx, bson.M{"role_id": roleID})
+	if err != nil {
+		return 0, fmt.Errorf("count role users: %w", err)
+	}
+
+	return count, nil
+}
+
+// AssignToUser assigns a role to a user. Assigning a role the user already has does nothing.
+func (r *roleRepository) AssignToUser(ctx context.Context, userID, roleID int) error {
+	assignment := bson.M{"user_id": userID, "role_id": roleID}
+	opts := options.Update().SetUpsert(true)
+	if _, err := r.db.Collection("user_roles").UpdateOne(ctx, assignment, bson.M{"$setOnInsert": assignment}, opts); err != nil {
+		return fmt.Errorf("assign user role: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserRoleNames retrieves names of all roles assigned to a user
+func (r *roleRepository) GetUserRoleNames(ctx context.Context, userID int) ([]string, error) {
+	collection := r.db.Collection("user_roles")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "roles",
+			"localField":   "role_id",
+			"foreignField": "_id",
+			"as":           "role",
+		}}},
+		{{Key: "$unwind", Value: "$role"}},
+		{{Key: "$project", Value: bson.M{
+			"_id":  0,
+			"name": "$role.name",
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("get user roles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode user roles: %w", err)
+	}
+
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+
+	return names, nil
+}
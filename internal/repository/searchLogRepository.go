@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type SearchLogRepository interface {
+	// Create stores a search, setting its ID
+	Create(ctx context.Context, log *domain.SearchLog) error
+
+	// RecordClick records the product opened from the results of a search, unless one already
+	// was. Unknown searches are ignored.
+	RecordClick(ctx context.Context, id string, productID int, clickedAt time.Time) error
+}
+
+type searchLogRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewSearchLogRepository(db *mongodb.MongoDB) SearchLogRepository {
+	return &searchLogRepository{db: db}
+}
+
+// Create inserts the search with a new ObjectID, which sorts by time without a counter
+func (r *searchLogRepository) Create(ctx context.Context, log *domain.SearchLog) error {
+	log.ID = primitive.NewObjectID().Hex()
+	if _, err := r.db.Collection("searches").InsertOne(ctx, log); err != nil {
+		return fmt.Errorf("insert search: %w", err)
+	}
+	return nil
+}
+
+// RecordClick sets the clicked product of the search if it has none
+func (r *searchLogRepository) RecordClick(ctx context.Context, id string, productID int, clickedAt time.Time) error {
+	_, err := r.db.Collection("searches").UpdateOne(ctx,
+		bson.M{"_id": id, "clicked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"clicked_product_id": productID, "clicked_at": clickedAt}},
+	)
+	if err != nil {
+		return fmt.Errorf("record search click: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type SegmentRepository interface {
+	// Definitions
+	Create(ctx context.Context, segment *domain.Segment) error
+	GetByID(ctx context.Context, id int) (*domain.Segment, error)
+	List(ctx context.Context) ([]*domain.Segment, error)
+	Update(ctx context.Context, segment *domain.Segment) error
+	Delete(ctx context.Context, id int) error
+
+	// Evaluation inputs
+	ListUsers(ctx context.Context) ([]domain.SegmentUser, error)
+
+	// AggregateInteractions returns the value of an interaction attribute for each user with
+	// interactions since the given time, or over all time if since is nil
+	AggregateInteractions(ctx context.Context, attribute string, since *time.Time) (map[int]float64, error)
+
+	// ReplaceMembers stores the members of a segment evaluated at evaluatedAt, dropping the
+	// members of earlier evaluations, and records the count on the segment
+	ReplaceMembers(ctx context.Context, segmentID int, userIDs []int, evaluatedAt time.Time) error
+
+	// Membership
+	ListMembers(ctx context.Context, segmentID int, limit, offset int) ([]int, int64, error)
+	GetUserSegmentIDs(ctx context.Context, userID int) ([]int, error)
+}
+
+type segmentRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewSegmentRepository(db *mongodb.MongoDB) SegmentRepository {
+	return &segmentRepository{db: db}
+}
+
+// interactionAggregates maps interaction attributes to their collection, time field and the
+// value summed per interaction. Money is summed in minor units and reported in major units,
+// which segment thresholds are set in.
+var interactionAggregates = map[string]struct {
+	collection string
+	timeField  string
+	value      interface{}
+	money      bool
+}{
+	domain.SegmentAttributeViews:     {"user_product_views", "viewed_at", 1, false},
+	domain.SegmentAttributeLikes:     {"user_product_likes", "liked_at", 1, false},
+	domain.SegmentAttributePurchases: {"user_product_purchases", "purchased_at", 1, false},
+	domain.SegmentAttributeSpent:     {"user_product_purchases", "purchased_at", purchaseAmount, true},
+}
+
+// getNextID gets the next segment ID from the counter
+func (r *segmentRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "segment_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next segment id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new segment
+func (r *segmentRepository) Create(ctx context.Context, segment *domain.Segment) error {
+	collection := r.db.Collection("segments")
+
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	segment.ID = id
+	segment.CreatedAt = now
+	segment.UpdatedAt = now
+
+	if _, err := collection.InsertOne(ctx, segment); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("insert segment: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a segment by ID
+func (r *segmentRepository) GetByID(ctx context.Context, id int) (*domain.Segment, error) {
+	collection := r.db.Collection("segments")
+
+	var segment domain.Segment
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&segment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find segment: %w", err)
+	}
+
+	return &segment, nil
+}
+
+// List retrieves all segments by name
+func (r *segmentRepository) List(ctx context.Context) ([]*domain.Segment, error) {
+	collection := r.db.Collection("segments")
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("find segments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	segments := make([]*domain.Segment, 0)
+	if err := cursor.All(ctx, &segments); err != nil {
+		return nil, fmt.Errorf("decode segments: %w", err)
+	}
+
+	return segments, nil
+}
+
+// Update saves the name, description and rules of a segment
+func (r *segmentRepository) Update(ctx context.Context, segment *domain.Segment) error {
+	collection := r.db.Collection("segments")
+
+	segment.UpdatedAt = time.Now().UTC()
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": segment.ID}, bson.M{"$set": bson.M{
+		"name":        segment.Name,
+		"description": segment.Description,
+		"rules":       segment.Rules,
+		"updated_at":  segment.UpdatedAt,
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("update segment: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a segment and its members
+func (r *segmentRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Collection("segments").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete segment: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	if _, err := r.db.Collection("segment_members").DeleteMany(ctx, bson.M{"segment_id": id}); err != nil {
+		return fmt.Errorf("delete segment members: %w", err)
+	}
+
+	return nil
+}
+
+// ListUsers retrieves the active users with the profile attributes rules are evaluated on
+func (r *segmentRepository) ListUsers(ctx context.Context) ([]domain.SegmentUser, error) {
+	collection := r.db.AnalyticsCollection("users")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": domain.UserStatusActive}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "profiles",
+			"localField":   "_id",
+			"foreignField": "user_id",
+			"as":           "profile",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$profile", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$project", Value: bson.M{
+			"country": "$profile.country",
+			"city":    "$profile.city",
+			"gender":  "$profile.gender",
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate segment users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	users := make([]domain.SegmentUser, 0)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("decode segment users: %w", err)
+	}
+
+	return users, nil
+}
+
+// AggregateInteractions counts or sums the interactions of signed-in users per user
+func (r *segmentRepository) AggregateInteractions(ctx context.Context, attribute string, since *time.Time) (map[int]float64, error) {
+	aggregate, ok := interactionAggregates[attribute]
+	if !ok {
+		return nil, fmt.Errorf("unknown interaction attribute %q", attribute)
+	}
+
+	match := bson.M{"user_id": bson.M{"$gt": 0}}
+	if since != nil {
+		match[aggregate.timeField] = bson.M{"$gte": *since}
+	}
+
+	group := bson.M{
+		"_id":   "$user_id",
+		"value": bson.M{"$sum": aggregate.value},
+	}
+	if aggregate.money {
+		group["currency"] = firstCurrency("price_at_purchase")
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: group}},
+	}
+
+	cursor, err := r.db.AnalyticsCollection(aggregate.collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate %s: %w", attribute, err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		UserID   int     `bson:"_id"`
+		Value    float64 `bson:"value"`
+		Currency string  `bson:"currency"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", attribute, err)
+	}
+
+	values := make(map[int]float64, len(results))
+	for _, result := range results {
+		values[result.UserID] = result.Value
+		if aggregate.money {
+			values[result.UserID] = domain.NewMoney(int64(result.Value), result.Currency).Float64()
+		}
+	}
+
+	return values, nil
+}
+
+// ReplaceMembers upserts the current members, then deletes the stale ones, so readers see the
+// previous members rather than none while a segment is evaluated
+func (r *segmentRepository) ReplaceMembers(ctx context.Context, segmentID int, userIDs []int, evaluatedAt time.Time) error {
+	collection := r.db.Collection("segment_members")
+
+	if len(userIDs) > 0 {
+		models := make([]mongo.WriteModel, 0, len(userIDs))
+		for _, userID := range userIDs {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"segment_id": segmentID, "user_id": userID}).
+				SetUpdate(bson.M{"$set": bson.M{"evaluated_at": evaluatedAt}}).
+				SetUpsert(true))
+		}
+
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("upsert segment members: %w", err)
+		}
+	}
+
+	_, err := collection.DeleteMany(ctx, bson.M{"segment_id": segmentID, "evaluated_at": bson.M{"$lt": evaluatedAt}})
+	if err != nil {
+		return fmt.Errorf("delete stale segment members: %w", err)
+	}
+
+	_, err = r.db.Collection("segments").UpdateOne(ctx, bson.M{"_id": segmentID}, bson.M{"$set": bson.M{
+		"member_count": len(userIDs),
+		"evaluated_at": evaluatedAt,
+	}})
+	if err != nil {
+		return fmt.Errorf("update segment member count: %w", err)
+	}
+
+	return nil
+}
+
+// ListMembers retrieves a page of the IDs of a segment's members, in ID order
+func (r *segmentRepository) ListMembers(ctx context.Context, segmentID int, limit, offset int) ([]int, int64, error) {
+	collection := r.db.Collection("segment_members")
+
+	filter := bson.M{"segment_id": segmentID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count segment members: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"user_id": 1}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"user_id": 1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find segment members: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var members []struct {
+		UserID int `bson:"user_id"`
+	}
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, 0, fmt.Errorf("decode segment members: %w", err)
+	}
+
+	userIDs := make([]int, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+
+	return userIDs, total, nil
+}
+
+// GetUserSegmentIDs retrieves the IDs of the segments a user is a member of
+func (r *segmentRepository) GetUserSegmentIDs(ctx context.Context, userID int) ([]int, error) {
+	collection := r.db.Collection("segment_members")
+
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetProjection(bson.M{"segment_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("find user segments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []struct {
+		SegmentID int `bson:"segment_id"`
+	}
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, fmt.Errorf("decode user segments: %w", err)
+	}
+
+	segmentIDs := make([]int, len(memberships))
+	for i, membership := range memberships {
+		segmentIDs[i] = membership.SegmentID
+	}
+
+	return segmentIDs, nil
+}
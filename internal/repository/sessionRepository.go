@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	GetByID(ctx context.Context, id int) (*domain.Session, error)
+	GetActiveByUserID(ctx context.Context, userID int) ([]domain.Session, error)
+	Rotate(ctx context.Context, id int, oldTokenID, newTokenID string, client domain.ClientInfo, expiresAt time.Time) error
+	Revoke(ctx context.Context, userID, id int) error
+	RevokeAll(ctx context.Context, userID int) (int64, error)
+}
+
+type sessionRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewSessionRepository(db *mongodb.MongoDB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// getNextID gets the next session ID from the counter
+func (r *sessionRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "session_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next session id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new session
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	collection := r.db.Collection("sessions")
+
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	session.ID = id
+	session.CreatedAt = now
+	session.LastUsedAt = now
+
+	_, err = collection.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("insert session: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a session by ID
+func (r *sessionRepository) GetByID(ctx context.Context, id int) (*domain.Session, error) {
+	collection := r.db.Collection("sessions")
+
+	var session domain.Session
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// GetActiveByUserID retrieves sessions that are neither revoked nor expired, most recently used first
+func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID int) ([]domain.Session, error) {
+	collection := r.db.Collection("sessions")
+
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now().UTC()},
+	}
+	opts := options.Find().SetSort(bson.M{"last_used_at": -1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	sessions := make([]domain.Session, 0)
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("decode sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Rotate replaces the refresh token bound to an active session and records its use.
+// Returns ErrNotFound if the session is revoked or oldTokenID is no longer current.
+func (r *sessionRepository) Rotate(ctx context.Context, id int, oldTokenID, newTokenID string, client domain.ClientInfo, expiresAt time.Time) error {
+	collection := r.db.Collection("sessions")
+
+	filter := bson.M{
+		"_id":              id,
+		"refresh_token_id": oldTokenID,
+		"revoked_at":       bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"refresh_token_id": newTokenID,
+			"user_agent":       client.UserAgent,
+			"ip_address":       client.IPAddress,
+			"last_used_at":     time.Now().UTC(),
+			"expires_at":       expiresAt,
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("rotate session: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Revoke revokes a single session of a user
+func (r *sessionRepository) Revoke(ctx context.Context, userID, id int) error {
+	collection := r.db.Collection("sessions")
+
+	filter := bson.M{
+		"_id":        id,
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeAll revokes every active session of a user
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID int) (int64, error) {
+	collection := r.db.Collection("sessions")
+
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
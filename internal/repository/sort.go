@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// buildSort translates sort keys into an ordered Mongo sort document, falling back to
+// defaultSort when none are given. _id is always appended as a tiebreaker so documents with
+// equal keys keep the same order across pages.
+func buildSort(sort []domain.SortField, allowed map[string]string, defaultSort ...domain.SortField) bson.D {
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+
+	doc := bson.D{}
+	hasID := false
+	for _, field := range sort {
+		path, ok := allowed[field.Field]
+		if !ok {
+			continue
+		}
+		order := 1
+		if field.Descending {
+			order = -1
+		}
+		doc = append(doc, bson.E{Key: path, Value: order})
+		hasID = hasID || path == "_id"
+	}
+
+	if !hasID {
+		doc = append(doc, bson.E{Key: "_id", Value: 1})
+	}
+
+	return doc
+}
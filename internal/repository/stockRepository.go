@@ -0,0 +1,430 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type StockRepository interface {
+	Adjust(ctx context.Context, adjustment *domain.StockAdjustment) (*domain.Product, error)
+	AdjustMany(ctx context.Context, adjustments []*domain.StockAdjustment) ([]*domain.Product, error)
+	Record(ctx context.Context, adjustment *domain.StockAdjustment) error
+	List(ctx context.Context, productID int, limit, offset int) ([]domain.StockAdjustment, int64, error)
+	SumByReason(ctx context.Context, productID int) (map[string]int, error)
+
+	// Inventory per warehouse
+	Transfer(ctx context.Context, transfer *domain.StockTransfer) ([]domain.StockAdjustment, error)
+	GetLocations(ctx context.Context, productID int) ([]domain.LocationStock, error)
+	ListInventory(ctx context.Context, warehouseID int, limit, offset int) ([]domain.InventoryLevel, int64, error)
+}
+
+type stockRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewStockRepository(db *mongodb.MongoDB) StockRepository {
+	return &stockRepository{db: db}
+}
+
+// getNextID gets the next stock adjustment ID from the counter
+func (r *stockRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "stock_adjustment_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next stock adjustment id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Adjust changes the product's stock by the adjustment's delta and records it in the ledger,
+// in one transaction where supported. With a WarehouseID the quantity at that warehouse changes
+// as well. Stock never goes below zero, in total or at the warehouse: the change is refused
+// with domain.ErrInsufficientStock instead. Returns the updated product.
+func (r *stockRepository) Adjust(ctx context.Context, adjustment *domain.StockAdjustment) (*domain.Product, error) {
+	products, err := r.AdjustMany(ctx, []*domain.StockAdjustment{adjustment})
+	if err != nil {
+		return nil, err
+	}
+
+	return products[0], nil
+}
+
+// AdjustMany applies the adjustments, each to a different product, as Adjust does: either all
+// of them are applied or none is. Without transactions the adjustments already made are
+// reverted when a later one fails. Returns the updated products in the order of the adjustments.
+func (r *stockRepository) AdjustMany(ctx context.Context, adjustments []*domain.StockAdjustment) ([]*domain.Product, error) {
+	ids := make([]int, len(adjustments))
+	for i := range adjustments {
+		id, err := r.getNextID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	var products []*domain.Product
+	err := r.db.WithTransaction(ctx, func(ctx context.Context) error {
+		products = make([]*domain.Product, 0, len(adjustments))
+		for _, adjustment := range adjustments {
+			product, err := r.apply(ctx, adjustment)
+			if err != nil {
+				for _, applied := range adjustments[:len(products)] {
+					r.revert(ctx, applied)
+				}
+				return err
+			}
+			products = append(products, product)
+		}
+
+		documents := make([]interface{}, len(adjustments))
+		for i, adjustment := range adjustments {
+			adjustment.ID = ids[i]
+			adjustment.StockAfter = products[i].Stock
+			adjustment.CreatedAt = products[i].UpdatedAt
+			documents[i] = adjustment
+		}
+		if _, err := r.db.Collection("stock_adjustments").InsertMany(ctx, documents); err != nil {
+			return fmt.Errorf("insert stock adjustments: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// apply changes the stock at the adjustment's warehouse, if any, and the product's total stock
+func (r *stockRepository) apply(ctx context.Context, adjustment *domain.StockAdjustment) (*domain.Product, error) {
+	if adjustment.WarehouseID != 0 {
+		if err := r.adjustLocation(ctx, adjustment.ProductID, adjustment.WarehouseID, adjustment.Delta); err != nil {
+			return nil, err
+		}
+	}
+
+	product, err := r.adjustProduct(ctx, adjustment.ProductID, adjustment.Delta)
+	if err != nil {
+		if adjustment.WarehouseID != 0 {
+			_ = r.adjustLocation(ctx, adjustment.ProductID, adjustment.WarehouseID, -adjustment.Delta)
+		}
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// revert undoes an applied adjustment. It is best effort: in a transaction that is aborted
+// anyway, and otherwise there is nothing better to do with a failure.
+func (r *stockRepository) revert(ctx context.Context, adjustment *domain.StockAdjustment) {
+	if adjustment.WarehouseID != 0 {
+		_ = r.adjustLocation(ctx, adjustment.ProductID, adjustment.WarehouseID, -adjustment.Delta)
+	}
+	_, _ = r.adjustProduct(ctx, adjustment.ProductID, -adjustment.Delta)
+}
+
+// adjustProduct changes the product's total stock by delta, keeping it at zero or above
+func (r *stockRepository) adjustProduct(ctx context.Context, productID, delta int) (*domain.Product, error) {
+	products := r.db.Collection("products")
+
+	filter := bson.M{"_id": productID}
+	if delta < 0 {
+		filter["stock"] = bson.M{"$gte": -delta}
+	}
+	update := bson.M{
+		"$inc": bson.M{"stock": delta},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var product domain.Product
+	err := products.FindOneAndUpdate(ctx, filter, update, opts).Decode(&product)
+	if err == mongo.ErrNoDocuments {
+		count, err := products.CountDocuments(ctx, bson.M{"_id": productID})
+		if err != nil {
+			return nil, fmt.Errorf("find product: %w", err)
+		}
+		if count == 0 {
+			return nil, domain.ErrNotFound
+		}
+		return nil, domain.ErrInsufficientStock
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update stock: %w", err)
+	}
+
+	return &product, nil
+}
+
+// adjustLocation changes the product's quantity at the warehouse by delta, keeping it at zero
+// or above
+func (r *stockRepository) adjustLocation(ctx context.Context, productID, warehouseID, delta int) error {
+	filter := bson.M{"product_id": productID, "warehouse_id": warehouseID}
+	if delta < 0 {
+		filter["quantity"] = bson.M{"$gte": -delta}
+	}
+	update := bson.M{
+		"$inc": bson.M{"quantity": delta},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	}
+
+	result, err := r.db.Collection("inventory").UpdateOne(ctx, filter, update, options.Update().SetUpsert(delta > 0))
+	if err != nil {
+		return fmt.Errorf("update inventory: %w", err)
+	}
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		return domain.ErrInsufficientStock
+	}
+
+	return nil
+}
+
+// Transfer moves stock between warehouses, or from unallocated stock to a warehouse, and
+// records it in the ledger as a pair of adjustments. The product's total stock is unchanged.
+func (r *stockRepository) Transfer(ctx context.Context, transfer *domain.StockTransfer) ([]domain.StockAdjustment, error) {
+	outID, err := r.getNextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inID, err := r.getNextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments []domain.StockAdjustment
+	err = r.db.WithTransaction(ctx, func(ctx context.Context) error {
+		var product domain.Product
+		err := r.db.Collection("products").FindOne(ctx, bson.M{"_id": transfer.ProductID}).Decode(&product)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return domain.ErrNotFound
+			}
+			return fmt.Errorf("find product: %w", err)
+		}
+
+		if transfer.FromWarehouseID != 0 {
+			if err := r.adjustLocation(ctx, transfer.ProductID, transfer.FromWarehouseID, -transfer.Quantity); err != nil {
+				return err
+			}
+		} else {
+			allocated, err := r.allocatedStock(ctx, transfer.ProductID)
+			if err != nil {
+				return err
+			}
+			if product.Stock-allocated < transfer.Quantity {
+				return domain.ErrInsufficientStock
+			}
+		}
+		if err := r.adjustLocation(ctx, transfer.ProductID, transfer.ToWarehouseID, transfer.Quantity); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		adjustments = []domain.StockAdjustment{
+			{ID: outID, WarehouseID: transfer.FromWarehouseID, Delta: -transfer.Quantity},
+			{ID: inID, WarehouseID: transfer.ToWarehouseID, Delta: transfer.Quantity},
+		}
+		documents := make([]interface{}, len(adjustments))
+		for i := range adjustments {
+			adjustments[i].ProductID = transfer.ProductID
+			adjustments[i].Reason = domain.StockReasonTransfer
+			adjustments[i].Note = transfer.Note
+			adjustments[i].ActorID = transfer.ActorID
+			adjustments[i].StockAfter = product.Stock
+			adjustments[i].CreatedAt = now
+			documents[i] = adjustments[i]
+		}
+		if _, err := r.db.Collection("stock_adjustments").InsertMany(ctx, documents); err != nil {
+			return fmt.Errorf("insert stock adjustments: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
+
+// allocatedStock sums the product's quantities across warehouses
+func (r *stockRepository) allocatedStock(ctx context.Context, productID int) (int, error) {
+	locations, err := r.GetLocations(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	allocated := 0
+	for _, location := range locations {
+		allocated += location.Quantity
+	}
+	return allocated, nil
+}
+
+// GetLocations retrieves the product's quantity at each warehouse holding it, ordered by
+// warehouse code
+func (r *stockRepository) GetLocations(ctx context.Context, productID int) ([]domain.LocationStock, error) {
+	collection := r.db.Collection("inventory")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": productID, "quantity": bson.M{"$gt": 0}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "warehouses",
+			"localField":   "warehouse_id",
+			"foreignField": "_id",
+			"as":           "warehouse",
+		}}},
+		{{Key: "$unwind", Value: "$warehouse"}},
+		{{Key: "$project", Value: bson.M{
+			"warehouse_id": 1,
+			"quantity":     1,
+			"code":         "$warehouse.code",
+			"name":         "$warehouse.name",
+			"is_active":    "$warehouse.is_active",
+		}}},
+		{{Key: "$sort", Value: bson.M{"code": 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate inventory: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	locations := make([]domain.LocationStock, 0)
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, fmt.Errorf("decode inventory: %w", err)
+	}
+
+	return locations, nil
+}
+
+// ListInventory retrieves a page of the products held at a warehouse, ordered by product ID,
+// with the total count
+func (r *stockRepository) ListInventory(ctx context.Context, warehouseID int, limit, offset int) ([]domain.InventoryLevel, int64, error) {
+	collection := r.db.Collection("inventory")
+	filter := bson.M{"warehouse_id": warehouseID, "quantity": bson.M{"$gt": 0}}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count inventory: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"product_id": 1}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find inventory: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	levels := make([]domain.InventoryLevel, 0)
+	if err := cursor.All(ctx, &levels); err != nil {
+		return nil, 0, fmt.Errorf("decode inventory: %w", err)
+	}
+
+	return levels, total, nil
+}
+
+// Record adds an adjustment to the ledger for stock the caller has already written, such as
+// the initial stock of a new product
+func (r *stockRepository) Record(ctx context.Context, adjustment *domain.StockAdjustment) error {
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	adjustment.ID = id
+	adjustment.CreatedAt = time.Now().UTC()
+
+	if _, err := r.db.Collection("stock_adjustments").InsertOne(ctx, adjustment); err != nil {
+		return fmt.Errorf("insert stock adjustment: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves a page of the product's stock adjustments, newest first, with the total count
+func (r *stockRepository) List(ctx context.Context, productID int, limit, offset int) ([]domain.StockAdjustment, int64, error) {
+	collection := r.db.Collection("stock_adjustments")
+	filter := bson.M{"product_id": productID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count stock adjustments: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find stock adjustments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	adjustments := make([]domain.StockAdjustment, 0)
+	if err := cursor.All(ctx, &adjustments); err != nil {
+		return nil, 0, fmt.Errorf("decode stock adjustments: %w", err)
+	}
+
+	return adjustments, total, nil
+}
+
+// SumByReason totals the product's stock adjustments per reason
+func (r *stockRepository) SumByReason(ctx context.Context, productID int) (map[string]int, error) {
+	collection := r.db.Collection("stock_adjustments")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"product_id": productID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$reason", "delta": bson.M{"$sum": "$delta"}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate stock adjustments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Reason string `bson:"_id"`
+		Delta  int    `bson:"delta"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("decode stock adjustment totals: %w", err)
+	}
+
+	totals := make(map[string]int, len(results))
+	for _, result := range results {
+		totals[result.Reason] = result.Delta
+	}
+
+	return totals, nil
+}
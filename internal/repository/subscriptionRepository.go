@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type SubscriptionRepository interface {
+	// Plans
+	CreatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error
+	GetPlan(ctx context.Context, id int) (*domain.SubscriptionPlan, error)
+	ListPlans(ctx context.Context, productID int, activeOnly bool) ([]*domain.SubscriptionPlan, error)
+	DeactivatePlan(ctx context.Context, id int) error
+
+	// Subscriptions
+	Create(ctx context.Context, subscription *domain.Subscription) error
+	GetByID(ctx context.Context, id int) (*domain.Subscription, error)
+	ListByUser(ctx context.Context, userID, limit, offset int) ([]*domain.Subscription, int64, error)
+
+	// UpdateStatus sets the status of a subscription whose status is one of from and returns
+	// it, or ErrInvalidTransition if it has another status
+	UpdateStatus(ctx context.Context, id int, from []string, to string) (*domain.Subscription, error)
+
+	// Renewals
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error)
+
+	// ClaimRenewal moves the renewal of a subscription still due at due to until, reporting
+	// whether it did. Only one caller claims a renewal, so instances never renew it twice.
+	ClaimRenewal(ctx context.Context, id int, due, until time.Time) (bool, error)
+
+	// SaveRenewal stores the outcome of a renewal: the next renewal time, the last charge
+	// and the failed attempts. The status is changed separately with UpdateStatus.
+	SaveRenewal(ctx context.Context, subscription *domain.Subscription) error
+}
+
+type subscriptionRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewSubscriptionRepository(db *mongodb.MongoDB) SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+// getNextID gets the next ID of the named counter
+func (r *subscriptionRepository) getNextID(ctx context.Context, counter string) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": counter},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next %s: %w", counter, err)
+	}
+
+	return result.Seq, nil
+}
+
+// CreatePlan stores a new subscription plan
+func (r *subscriptionRepository) CreatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error {
+	id, err := r.getNextID(ctx, "subscription_plan_id")
+	if err != nil {
+		return err
+	}
+
+	plan.ID = id
+	plan.CreatedAt = time.Now().UTC()
+
+	if _, err := r.db.Collection("subscription_plans").InsertOne(ctx, plan); err != nil {
+		return fmt.Errorf("insert subscription plan: %w", err)
+	}
+
+	return nil
+}
+
+// GetPlan retrieves a subscription plan by ID
+func (r *subscriptionRepository) GetPlan(ctx context.Context, id int) (*domain.SubscriptionPlan, error) {
+	var plan domain.SubscriptionPlan
+	err := r.db.Collection("subscription_plans").FindOne(ctx, bson.M{"_id": id}).Decode(&plan)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find subscription plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// ListPlans retrieves the plans of a product, cheapest first
+func (r *subscriptionRepository) ListPlans(ctx context.Context, productID int, activeOnly bool) ([]*domain.SubscriptionPlan, error) {
+	filter := bson.M{"product_id": productID}
+	if activeOnly {
+		filter["is_active"] = true
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "price.amount", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.Collection("subscription_plans").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find subscription plans: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	plans := make([]*domain.SubscriptionPlan, 0)
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, fmt.Errorf("decode subscription plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+// DeactivatePlan stops offering a plan; existing subscriptions to it keep renewing
+func (r *subscriptionRepository) DeactivatePlan(ctx context.Context, id int) error {
+	result, err := r.db.Collection("subscription_plans").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"is_active": false}},
+	)
+	if err != nil {
+		return fmt.Errorf("deactivate subscription plan: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Create stores a new subscription
+func (r *subscriptionRepository) Create(ctx context.Context, subscription *domain.Subscription) error {
+	id, err := r.getNextID(ctx, "subscription_id")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	subscription.ID = id
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	if _, err := r.db.Collection("subscriptions").InsertOne(ctx, subscription); err != nil {
+		return fmt.Errorf("insert subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a subscription by ID
+func (r *subscriptionRepository) GetByID(ctx context.Context, id int) (*domain.Subscription, error) {
+	var subscription domain.Subscription
+	err := r.db.Collection("subscriptions").FindOne(ctx, bson.M{"_id": id}).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+// ListByUser retrieves a page of a user's subscriptions, newest first, with the total count
+func (r *subscriptionRepository) ListByUser(ctx context.Context, userID, limit, offset int) ([]*domain.Subscription, int64, error) {
+	collection := r.db.Collection("subscriptions")
+	filter := bson.M{"user_id": userID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count subscriptions: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find subscriptions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := make([]*domain.Subscription, 0)
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, 0, fmt.Errorf("decode subscriptions: %w", err)
+	}
+
+	return subscriptions, total, nil
+}
+
+// UpdateStatus sets the status of a subscription whose status is one of from
+func (r *subscriptionRepository) UpdateStatus(ctx context.Context, id int, from []string, to string) (*domain.Subscription, error) {
+	collection := r.db.Collection("subscriptions")
+
+	now := time.Now().UTC()
+	set := bson.M{"status": to, "updated_at": now}
+	if to == domain.SubscriptionCancelled {
+		set["cancelled_at"] = now
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var subscription domain.Subscription
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": set},
+		opts,
+	).Decode(&subscription)
+	if err == nil {
+		return &subscription, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("update subscription status: %w", err)
+	}
+
+	// Tell a missing subscription from one in another status
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("count subscriptions: %w", err)
+	}
+	if count == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return nil, domain.ErrInvalidTransition
+}
+
+// ListDue retrieves active and past due subscriptions whose renewal is due, earliest first
+func (r *subscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error) {
+	filter := bson.M{
+		"status":          bson.M{"$in": bson.A{domain.SubscriptionActive, domain.SubscriptionPastDue}},
+		"next_renewal_at": bson.M{"$lte": now},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "next_renewal_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.db.Collection("subscriptions").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find due subscriptions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := make([]*domain.Subscription, 0)
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, fmt.Errorf("decode due subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// ClaimRenewal moves the renewal of a subscription still due at due to until
+func (r *subscriptionRepository) ClaimRenewal(ctx context.Context, id int, due, until time.Time) (bool, error) {
+	result, err := r.db.Collection("subscriptions").UpdateOne(ctx,
+		bson.M{
+			"_id":             id,
+			"status":          bson.M{"$in": bson.A{domain.SubscriptionActive, domain.SubscriptionPastDue}},
+			"next_renewal_at": due,
+		},
+		bson.M{"$set": bson.M{"next_renewal_at": until}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("claim subscription renewal: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// SaveRenewal stores the outcome of a renewal
+func (r *subscriptionRepository) SaveRenewal(ctx context.Context, subscription *domain.Subscription) error {
+	subscription.UpdatedAt = time.Now().UTC()
+
+	update := bson.M{
+		"$set": bson.M{
+			"next_renewal_at": subscription.NextRenewalAt,
+			"last_renewed_at": subscription.LastRenewedAt,
+			"last_charge_id":  subscription.LastChargeID,
+			"failed_attempts": subscription.FailedAttempts,
+			"last_error":      subscription.LastError,
+			"updated_at":      subscription.UpdatedAt,
+		},
+	}
+
+	result, err := r.db.Collection("subscriptions").UpdateOne(ctx, bson.M{"_id": subscription.ID}, update)
+	if err != nil {
+		return fmt.Errorf("save subscription renewal: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
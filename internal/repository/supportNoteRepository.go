@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type SupportNoteRepository interface {
+	Create(ctx context.Context, note *domain.SupportNote) error
+	GetByID(ctx context.Context, id int) (*domain.SupportNote, error)
+
+	// List retrieves a page of the notes on a subject, pinned ones first, then newest first
+	List(ctx context.Context, subject, subjectID string, limit, offset int) ([]*domain.SupportNote, int64, error)
+
+	// Update saves the text and pinned flag of a note
+	Update(ctx context.Context, note *domain.SupportNote) error
+	Delete(ctx context.Context, id int) error
+
+	// OrderExists checks that a purchase record with the given event ID exists
+	OrderExists(ctx context.Context, orderID string) (bool, error)
+}
+
+type supportNoteRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewSupportNoteRepository(db *mongodb.MongoDB) SupportNoteRepository {
+	return &supportNoteRepository{db: db}
+}
+
+// getNextID gets the next support note ID from the counter
+func (r *supportNoteRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "support_note_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next support note id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new note
+func (r *supportNoteRepository) Create(ctx context.Context, note *domain.SupportNote) error {
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	note.ID = id
+	note.CreatedAt = now
+	note.UpdatedAt = now
+
+	if _, err := r.db.Collection("support_notes").InsertOne(ctx, note); err != nil {
+		return fmt.Errorf("insert support note: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a note by ID
+func (r *supportNoteRepository) GetByID(ctx context.Context, id int) (*domain.SupportNote, error) {
+	var note domain.SupportNote
+	err := r.db.Collection("support_notes").FindOne(ctx, bson.M{"_id": id}).Decode(&note)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find support note: %w", err)
+	}
+
+	return &note, nil
+}
+
+// List retrieves a page of the notes on a subject
+func (r *supportNoteRepository) List(ctx context.Context, subject, subjectID string, limit, offset int) ([]*domain.SupportNote, int64, error) {
+	collection := r.db.Collection("support_notes")
+
+	filter := bson.M{"subject": subject, "subject_id": subjectID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count support notes: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "pinned", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("find support notes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	notes := make([]*domain.SupportNote, 0)
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, 0, fmt.Errorf("decode support notes: %w", err)
+	}
+
+	return notes, total, nil
+}
+
+// Update saves the text and pinned flag of a note
+func (r *supportNoteRepository) Update(ctx context.Context, note *domain.SupportNote) error {
+	note.UpdatedAt = time.Now().UTC()
+
+	result, err := r.db.Collection("support_notes").UpdateOne(ctx, bson.M{"_id": note.ID}, bson.M{"$set": bson.M{
+		"text":       note.Text,
+		"pinned":     note.Pinned,
+		"updated_at": note.UpdatedAt,
+	}})
+	if err != nil {
+		return fmt.Errorf("update support note: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a note
+func (r *supportNoteRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Collection("support_notes").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete support note: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// OrderExists checks that a purchase record with the given event ID exists
+func (r *supportNoteRepository) OrderExists(ctx context.Context, orderID string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return false, nil
+	}
+
+	count, err := r.db.Collection("user_product_purchases").CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("count purchases: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type UsageRepository interface {
+	// Add counts requests of a user in a period and returns the period's new total
+	Add(ctx context.Context, userID int, period string, requests int64) (int64, error)
+
+	// Get returns the requests of a user in a period, 0 if none were counted
+	Get(ctx context.Context, userID int, period string) (int64, error)
+}
+
+type usageRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewUsageRepository(db *mongodb.MongoDB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) Add(ctx context.Context, userID int, period string, requests int64) (int64, error) {
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	var usage domain.APIUsage
+	err := r.db.Collection("api_usage").FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID, "period": period},
+		bson.M{"$inc": bson.M{"requests": requests}},
+		opts,
+	).Decode(&usage)
+	if err != nil {
+		return 0, fmt.Errorf("add api usage: %w", err)
+	}
+
+	return usage.Requests, nil
+}
+
+func (r *usageRepository) Get(ctx context.Context, userID int, period string) (int64, error) {
+	var usage domain.APIUsage
+	err := r.db.Collection("api_usage").FindOne(ctx, bson.M{"user_id": userID, "period": period}).Decode(&usage)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get api usage: %w", err)
+	}
+
+	return usage.Requests, nil
+}
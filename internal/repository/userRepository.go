@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,12 +15,26 @@ import (
 	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
 )
 
+//go:generate go tool mockgen -source=userRepository.go -destination=mocks/userRepository.go -package=mocks
+
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetByID(ctx context.Context, id int) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
 	UpdateLastLogin(ctx context.Context, id int) error
+	IncrementTokenVersion(ctx context.Context, id int) error
+
+	// UpdateStatus moves a user whose status is one of from to the new status and returns it,
+	// or ErrInvalidTransition if the user has another status
+	UpdateStatus(ctx context.Context, id int, from []string, change domain.UserStatusChange) (*domain.User, error)
+
+	// List retrieves a page of the users matching the filter, with their lifetime value, and the
+	// total count of matching users
+	List(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int64, error)
+
+	// GetCustomerValue sums the purchases of a user; a user who never bought has a zero value
+	GetCustomerValue(ctx context.Context, userID int) (*domain.CustomerValue, error)
 }
 
 type userRepository struct {
@@ -30,9 +46,9 @@ func NewUserRepository(db *mongodb.MongoDB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
-	user.Status = "active"
+	user.CreatedAt = time.Now().UTC()
+	user.UpdatedAt = time.Now().UTC()
+	user.Status = domain.UserStatusActive
 
 	collection := r.db.Collection("users")
 
@@ -111,7 +127,7 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, err
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	collection := r.db.Collection("users")
 
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = time.Now().UTC()
 
 	update := bson.M{
 		"$set": bson.M{
@@ -124,6 +140,9 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 
 	result, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
 		return fmt.Errorf("update user: %w", err)
 	}
 
@@ -137,7 +156,7 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 func (r *userRepository) UpdateLastLogin(ctx context.Context, id int) error {
 	collection := r.db.Collection("users")
 
-	now := time.Now()
+	now := time.Now().UTC()
 	update := bson.M{
 		"$set": bson.M{
 			"last_login_at": now,
@@ -155,3 +174,167 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// IncrementTokenVersion invalidates tokens issued to the user so far
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id int) error {
+	collection := r.db.Collection("users")
+
+	update := bson.M{
+		"$inc": bson.M{"token_version": 1},
+		"$set": bson.M{"updated_at": time.Now().UTC()},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("increment token version: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateStatus sets the status of a user whose status is one of from
+func (r *userRepository) UpdateStatus(ctx context.Context, id int, from []string, change domain.UserStatusChange) (*domain.User, error) {
+	collection := r.db.Collection("users")
+
+	now := time.Now().UTC()
+	update := bson.M{"$set": bson.M{
+		"status":            change.Status,
+		"status_reason":     change.Reason,
+		"status_changed_at": now,
+		"status_changed_by": change.ChangedBy,
+		"updated_at":        now,
+	}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user domain.User
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		update,
+		opts,
+	).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("update user status: %w", err)
+	}
+
+	// Tell a missing user from one in another status
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return nil, fmt.Errorf("count users: %w", err)
+	}
+	if count == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return nil, domain.ErrInvalidTransition
+}
+
+// customerValueStages reduce a user's purchases to their lifetime value
+var customerValueStages = bson.A{
+	bson.M{"$group": bson.M{
+		"_id":               "$user_id",
+		"total_spend":       bson.M{"$sum": purchaseAmount},
+		"currency":          firstCurrency("price_at_purchase"),
+		"orders":            bson.M{"$sum": 1},
+		"first_purchase_at": bson.M{"$min": "$purchased_at"},
+		"last_purchase_at":  bson.M{"$max": "$purchased_at"},
+	}},
+	bson.M{"$set": bson.M{
+		"user_id":             "$_id",
+		"average_order_value": bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$total_spend", "$orders"}}, 0}}},
+	}},
+	moneyStage("total_spend", "average_order_value"),
+	bson.M{"$project": bson.M{"_id": 0}},
+}
+
+// List joins each user's purchases to compute their lifetime value. Sorted by a lifetime value
+// key it is computed for every matching user before paging, otherwise only for the page.
+func (r *userRepository) List(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int64, error) {
+	collection := r.db.Collection("users")
+
+	match := bson.M{}
+	if filter.Email != "" {
+		match["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Email), "$options": "i"}
+	}
+	if filter.Status != "" {
+		match["status"] = filter.Status
+	}
+
+	total, err := collection.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	ltv := bson.A{
+		bson.M{"$lookup": bson.M{
+			"from":         "user_product_purchases",
+			"localField":   "_id",
+			"foreignField": "user_id",
+			"pipeline":     customerValueStages,
+			"as":           "ltv",
+		}},
+		bson.M{"$set": bson.M{"ltv": bson.M{"$ifNull": bson.A{
+			bson.M{"$arrayElemAt": bson.A{"$ltv", 0}},
+			bson.M{"user_id": "$_id", "total_spend": domain.Money{}, "orders": 0, "average_order_value": domain.Money{}},
+		}}}},
+	}
+	page := bson.A{
+		bson.M{"$sort": buildSort(filter.Sort, domain.UserSortFields, domain.SortField{Field: "created_at", Descending: true})},
+		bson.M{"$skip": filter.Offset},
+		bson.M{"$limit": filter.Limit},
+	}
+
+	sortsByLTV := false
+	for _, field := range filter.Sort {
+		sortsByLTV = sortsByLTV || strings.HasPrefix(domain.UserSortFields[field.Field], "ltv.")
+	}
+
+	pipeline := bson.A{bson.M{"$match": match}}
+	if sortsByLTV {
+		pipeline = append(append(pipeline, ltv...), page...)
+	} else {
+		pipeline = append(append(pipeline, page...), ltv...)
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	users := make([]domain.AdminUser, 0)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, fmt.Errorf("decode users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// GetCustomerValue aggregates the purchases of the user
+func (r *userRepository) GetCustomerValue(ctx context.Context, userID int) (*domain.CustomerValue, error) {
+	collection := r.db.Collection("user_product_purchases")
+
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"user_id": userID}}}, customerValueStages...)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate customer value: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var values []domain.CustomerValue
+	if err := cursor.All(ctx, &values); err != nil {
+		return nil, fmt.Errorf("decode customer value: %w", err)
+	}
+	if len(values) == 0 {
+		return &domain.CustomerValue{UserID: userID}, nil
+	}
+
+	return &values[0], nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// viewFlushTimeout bounds a single batch insert
+const viewFlushTimeout = 10 * time.Second
+
+// bufferedView is a view waiting to be inserted, with the tenant it was recorded for
+type bufferedView struct {
+	tenant *config.Tenant
+	view   domain.UserProductView
+}
+
+// viewWriter buffers product views and inserts them with one InsertMany per batch, flushed when
+// a batch fills up or the flush interval passes. Buffered views are not visible to reads until
+// they are flushed.
+type viewWriter struct {
+	db            *mongodb.MongoDB
+	views         chan bufferedView
+	batchSize     int
+	flushInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func newViewWriter(db *mongodb.MongoDB, cfg *config.Interactions) *viewWriter {
+	w := &viewWriter{
+		db:            db,
+		views:         make(chan bufferedView, cfg.BufferSize),
+		batchSize:     cfg.BatchSize,
+		flushInterval: time.Duration(cfg.FlushInterval) * time.Millisecond,
+		done:          make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// add buffers a view. It returns false when the buffer is full or the writer is closed, and
+// the caller should insert the view itself.
+func (w *viewWriter) add(ctx context.Context, view domain.UserProductView) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return false
+	}
+
+	select {
+	case w.views <- bufferedView{tenant: tenant.FromContext(ctx), view: view}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting views and waits until the buffered ones are inserted or ctx is done
+func (w *viewWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.views)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flush buffered views: %w", ctx.Err())
+	}
+}
+
+func (w *viewWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]bufferedView, 0, w.batchSize)
+	for {
+		select {
+		case view, ok := <-w.views:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, view)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush inserts a batch with one InsertMany per tenant. Failed views are logged and dropped;
+// a view is not worth holding up the ones behind it.
+func (w *viewWriter) flush(batch []bufferedView) {
+	if len(batch) == 0 {
+		return
+	}
+
+	byTenant := make(map[*config.Tenant][]interface{})
+	for _, buffered := range batch {
+		byTenant[buffered.tenant] = append(byTenant[buffered.tenant], buffered.view)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), viewFlushTimeout)
+	defer cancel()
+
+	for viewTenant, views := range byTenant {
+		tenantCtx := ctx
+		if viewTenant != nil {
+			tenantCtx = tenant.NewContext(ctx, viewTenant)
+		}
+
+		_, err := w.db.Collection("user_product_views").InsertMany(tenantCtx, views, options.InsertMany().SetOrdered(false))
+		if err != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("repository").WithError(err).WithFields(logger.Fields{
+				"views":  len(views),
+				"tenant": tenant.ID(tenantCtx),
+			}).Error("Failed to insert buffered views")
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+type WarehouseRepository interface {
+	Create(ctx context.Context, warehouse *domain.Warehouse) error
+	GetByID(ctx context.Context, id int) (*domain.Warehouse, error)
+	List(ctx context.Context) ([]domain.Warehouse, error)
+	Update(ctx context.Context, warehouse *domain.Warehouse) error
+	Delete(ctx context.Context, id int) error
+	HasInventory(ctx context.Context, id int) (bool, error)
+}
+
+type warehouseRepository struct {
+	db *mongodb.MongoDB
+}
+
+func NewWarehouseRepository(db *mongodb.MongoDB) WarehouseRepository {
+	return &warehouseRepository{db: db}
+}
+
+// getNextID gets the next warehouse ID from the counter
+func (r *warehouseRepository) getNextID(ctx context.Context) (int, error) {
+	collection := r.db.Collection("counters")
+
+	var result struct {
+		Seq int `bson:"seq"`
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(true)
+
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "warehouse_id"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opts,
+	).Decode(&result)
+	if err != nil {
+		return 0, fmt.Errorf("get next warehouse id: %w", err)
+	}
+
+	return result.Seq, nil
+}
+
+// Create stores a new warehouse; codes are unique
+func (r *warehouseRepository) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	collection := r.db.Collection("warehouses")
+
+	id, err := r.getNextID(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	warehouse.ID = id
+	warehouse.CreatedAt = now
+	warehouse.UpdatedAt = now
+
+	_, err = collection.InsertOne(ctx, warehouse)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("insert warehouse: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a warehouse by ID
+func (r *warehouseRepository) GetByID(ctx context.Context, id int) (*domain.Warehouse, error) {
+	collection := r.db.Collection("warehouses")
+
+	var warehouse domain.Warehouse
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&warehouse)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("find warehouse: %w", err)
+	}
+
+	return &warehouse, nil
+}
+
+// List retrieves all warehouses ordered by code
+func (r *warehouseRepository) List(ctx context.Context) ([]domain.Warehouse, error) {
+	collection := r.db.Collection("warehouses")
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"code": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("find warehouses: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	warehouses := make([]domain.Warehouse, 0)
+	if err := cursor.All(ctx, &warehouses); err != nil {
+		return nil, fmt.Errorf("decode warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+// Update updates a warehouse
+func (r *warehouseRepository) Update(ctx context.Context, warehouse *domain.Warehouse) error {
+	collection := r.db.Collection("warehouses")
+
+	warehouse.UpdatedAt = time.Now().UTC()
+
+	update := bson.M{
+		"$set": bson.M{
+			"code":       warehouse.Code,
+			"name":       warehouse.Name,
+			"address":    warehouse.Address,
+			"is_active":  warehouse.IsActive,
+			"updated_at": warehouse.UpdatedAt,
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": warehouse.ID}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("update warehouse: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a warehouse and its empty inventory records
+func (r *warehouseRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.Collection("warehouses").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("delete warehouse: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	if _, err := r.db.Collection("inventory").DeleteMany(ctx, bson.M{"warehouse_id": id}); err != nil {
+		return fmt.Errorf("delete warehouse inventory: %w", err)
+	}
+
+	return nil
+}
+
+// HasInventory reports whether the warehouse holds any stock
+func (r *warehouseRepository) HasInventory(ctx context.Context, id int) (bool, error) {
+	count, err := r.db.Collection("inventory").CountDocuments(
+		ctx,
+		bson.M{"warehouse_id": id, "quantity": bson.M{"$gt": 0}},
+		options.Count().SetLimit(1),
+	)
+	if err != nil {
+		return false, fmt.Errorf("count warehouse inventory: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 100
+)
+
+type ActivityService interface {
+	// GetActivity returns a page of the user's activity timeline, newest first, and the total count
+	GetActivity(ctx context.Context, filter domain.ActivityFilter) ([]domain.ActivityItem, int64, error)
+
+	// GetAdminActivity returns a page of staff changes, purchases and alerts across all users,
+	// newest first, and the total count
+	GetAdminActivity(ctx context.Context, filter domain.AdminActivityFilter) ([]domain.AdminActivityItem, int64, error)
+}
+
+type activityService struct {
+	activityRepo repository.ActivityRepository
+}
+
+func NewActivityService(activityRepo repository.ActivityRepository) ActivityService {
+	return &activityService{activityRepo: activityRepo}
+}
+
+func (s *activityService) GetActivity(ctx context.Context, filter domain.ActivityFilter) ([]domain.ActivityItem, int64, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultActivityLimit
+	}
+	if filter.Limit > maxActivityLimit {
+		filter.Limit = maxActivityLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := s.activityRepo.GetActivity(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get activity: %w", err)
+	}
+
+	return items, total, nil
+}
+
+func (s *activityService) GetAdminActivity(ctx context.Context, filter domain.AdminActivityFilter) ([]domain.AdminActivityItem, int64, error) {
+	if filter.Category != "" && !domain.IsAdminActivityCategory(filter.Category) {
+		return nil, 0, fmt.Errorf("unknown activity category %q: %w", filter.Category, domain.ErrValidation)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, 0, fmt.Errorf("from must be before to: %w", domain.ErrValidation)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultActivityLimit
+	}
+	if filter.Limit > maxActivityLimit {
+		filter.Limit = maxActivityLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	items, total, err := s.activityRepo.GetAdminActivity(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get admin activity: %w", err)
+	}
+
+	return items, total, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Implicit feedback strength of each interaction type
+const (
+	viewFeedbackWeight     = 1.0
+	likeFeedbackWeight     = 3.0
+	purchaseFeedbackWeight = 5.0
+)
+
+// alsParams holds hyperparameters of the implicit ALS model
+type alsParams struct {
+	factors        int
+	iterations     int
+	regularization float64
+	alpha          float64
+}
+
+// trainImplicitALS learns user and item factor vectors from implicit feedback
+// (Hu, Koren, Volinsky - "Collaborative Filtering for Implicit Feedback Datasets").
+// ratings maps user ID -> product ID -> feedback strength.
+func trainImplicitALS(ratings map[int]map[int]float64, params alsParams) (map[int][]float64, map[int][]float64) {
+	// Build the transposed matrix so item factors can be solved the same way
+	itemRatings := make(map[int]map[int]float64)
+	for userID, items := range ratings {
+		for itemID, r := range items {
+			if itemRatings[itemID] == nil {
+				itemRatings[itemID] = make(map[int]float64)
+			}
+			itemRatings[itemID][userID] = r
+		}
+	}
+
+	// Deterministic initialization keeps training runs reproducible
+	rng := rand.New(rand.NewSource(42))
+	userFactors := initFactors(ratings, params.factors, rng)
+	itemFactors := initFactors(itemRatings, params.factors, rng)
+
+	for i := 0; i < params.iterations; i++ {
+		solveFactors(userFactors, itemFactors, ratings, params)
+		solveFactors(itemFactors, userFactors, itemRatings, params)
+	}
+
+	return userFactors, itemFactors
+}
+
+// initFactors creates small random factor vectors for every row of the matrix
+func initFactors(ratings map[int]map[int]float64, k int, rng *rand.Rand) map[int][]float64 {
+	factors := make(map[int][]float64, len(ratings))
+	for id := range ratings {
+		vec := make([]float64, k)
+		for f := range vec {
+			vec[f] = rng.Float64() * 0.01
+		}
+		factors[id] = vec
+	}
+	return factors
+}
+
+// solveFactors recomputes every vector in target while keeping fixed constant:
+// x_u = (YᵀY + Yᵀ(Cᵘ - I)Y + λI)⁻¹ YᵀCᵘp(u)
+func solveFactors(target, fixed map[int][]float64, ratings map[int]map[int]float64, params alsParams) {
+	k := params.factors
+
+	// YᵀY is shared by all rows
+	yty := make([][]float64, k)
+	for a := range yty {
+		yty[a] = make([]float64, k)
+	}
+	for _, y := range fixed {
+		for a := 0; a < k; a++ {
+			for b := 0; b < k; b++ {
+				yty[a][b] += y[a] * y[b]
+			}
+		}
+	}
+
+	for id, row := range ratings {
+		A := make([][]float64, k)
+		for a := range A {
+			A[a] = make([]float64, k)
+			copy(A[a], yty[a])
+			A[a][a] += params.regularization
+		}
+		b := make([]float64, k)
+
+		for otherID, r := range row {
+			y := fixed[otherID]
+			confidence := 1 + params.alpha*r
+			for a := 0; a < k; a++ {
+				b[a] += confidence * y[a]
+				for c := 0; c < k; c++ {
+					A[a][c] += (confidence - 1) * y[a] * y[c]
+				}
+			}
+		}
+
+		if x := solveLinearSystem(A, b); x != nil {
+			target[id] = x
+		}
+	}
+}
+
+// solveLinearSystem solves Ax = b with Gaussian elimination and partial pivoting.
+// Returns nil if the matrix is singular.
+func solveLinearSystem(A [][]float64, b []float64) []float64 {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(A[row][col]) > math.Abs(A[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(A[pivot][col]) < 1e-12 {
+			return nil
+		}
+		A[col], A[pivot] = A[pivot], A[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := A[row][col] / A[col][col]
+			for c := col; c < n; c++ {
+				A[row][c] -= factor * A[col][c]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for c := row + 1; c < n; c++ {
+			sum -= A[row][c] * x[c]
+		}
+		x[row] = sum / A[row][row]
+	}
+	return x
+}
+
+// dotProduct returns the dot product of two factor vectors
+func dotProduct(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
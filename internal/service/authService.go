@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,24 +15,45 @@ import (
 	"github.com/PrimeraAizen/e-comm/config"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
 )
 
 type AuthService interface {
-	Register(ctx context.Context, req *domain.User) (*domain.Token, error)
-	Login(ctx context.Context, req *domain.LoginRequest) (*domain.Token, error)
+	Register(ctx context.Context, req *domain.User, client domain.ClientInfo) (*domain.Token, error)
+	Login(ctx context.Context, req *domain.LoginRequest, client domain.ClientInfo) (*domain.Token, error)
 	ValidateToken(tokenString string) (*domain.TokenClaims, error)
-	RefreshToken(ctx context.Context, refreshToken string) (*domain.Token, error)
+	GetJWKS() domain.JSONWebKeySet
+	RefreshToken(ctx context.Context, refreshToken string, client domain.ClientInfo) (*domain.Token, error)
+
+	// Sessions
+	GetSessions(ctx context.Context, userID int) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
+	RevokeAllSessions(ctx context.Context, userID int) (int64, error)
+
+	// Sudo mode
+	Sudo(ctx context.Context, userID, sessionID int, password string) (*domain.SudoToken, error)
+	RecentlyAuthenticated(authTime time.Time) bool
 }
 
 type authService struct {
 	userRepo             repository.UserRepository
-	jwtSecret            string
+	sessionRepo          repository.SessionRepository
+	roleRepo             repository.RoleRepository
+	roles                RoleCatalog
+	keys                 *jwtKeySet
 	config               config.Config
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
+	reauthWindow         time.Duration
 }
 
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) (AuthService, error) {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	roleRepo repository.RoleRepository,
+	roles RoleCatalog,
+	cfg *config.Config,
+) (AuthService, error) {
 	accessDuration, err := time.ParseDuration(cfg.JWT.AccessTokenDuration)
 	if err != nil {
 		return nil, fmt.Errorf("parse access token duration: %w", err)
@@ -40,15 +64,29 @@ func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) (Aut
 		return nil, fmt.Errorf("parse refresh token duration: %w", err)
 	}
 
+	reauthWindow, err := time.ParseDuration(cfg.JWT.ReauthWindow)
+	if err != nil {
+		return nil, fmt.Errorf("parse reauth window: %w", err)
+	}
+
+	keys, err := newJWTKeySet(&cfg.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("load jwt keys: %w", err)
+	}
+
 	return &authService{
 		userRepo:             userRepo,
-		jwtSecret:            cfg.JWT.Secret,
+		sessionRepo:          sessionRepo,
+		roleRepo:             roleRepo,
+		roles:                roles,
+		keys:                 keys,
 		accessTokenDuration:  accessDuration,
 		refreshTokenDuration: refreshDuration,
+		reauthWindow:         reauthWindow,
 	}, nil
 }
 
-func (s *authService) Register(ctx context.Context, user *domain.User) (*domain.Token, error) {
+func (s *authService) Register(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.Token, error) {
 	// Check if user already exists
 	existingUser, err := s.userRepo.GetByEmail(ctx, user.Email)
 	if err != nil && err != domain.ErrNotFound {
@@ -63,10 +101,10 @@ func (s *authService) Register(ctx context.Context, user *domain.User) (*domain.
 	}
 
 	// Generate tokens
-	return s.generateAuthResponse(user)
+	return s.startSession(ctx, user, client)
 }
 
-func (s *authService) Login(ctx context.Context, req *domain.LoginRequest) (*domain.Token, error) {
+func (s *authService) Login(ctx context.Context, req *domain.LoginRequest, client domain.ClientInfo) (*domain.Token, error) {
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
@@ -82,7 +120,7 @@ func (s *authService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 	}
 
 	// Check user status
-	if user.Status != "active" {
+	if user.Status != domain.UserStatusActive {
 		return nil, domain.ErrUserInactive
 	}
 
@@ -93,16 +131,26 @@ func (s *authService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 	}
 
 	// Generate tokens
-	return s.generateAuthResponse(user)
+	return s.startSession(ctx, user, client)
 }
 
+// ValidateToken validates an access token; refresh tokens are rejected, so a session's refresh
+// token can't authorize requests after the session is revoked
 func (s *authService) ValidateToken(tokenString string) (*domain.TokenClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.jwtSecret), nil
-	})
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != domain.TokenTypeAccess {
+		return nil, domain.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// parseToken verifies a token of either type and returns its claims
+func (s *authService) parseToken(tokenString string) (*domain.TokenClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keys.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("parse token: %w", err)
@@ -127,18 +175,75 @@ func (s *authService) ValidateToken(tokenString string) (*domain.TokenClaims, er
 		return nil, domain.ErrInvalidToken
 	}
 
-	return &domain.TokenClaims{
+	tokenClaims := &domain.TokenClaims{
 		UserID: userID,
 		Email:  email,
-	}, nil
+	}
+
+	// Tokens issued before the typ claim are told apart by the jti only refresh tokens carry
+	switch typ, _ := claims["typ"].(string); {
+	case typ != "":
+		tokenClaims.Type = typ
+	case claims["jti"] != nil:
+		tokenClaims.Type = domain.TokenTypeRefresh
+	default:
+		tokenClaims.Type = domain.TokenTypeAccess
+	}
+
+	// Session claims are absent in tokens issued before session tracking
+	if sid, ok := claims["sid"].(float64); ok {
+		tokenClaims.SessionID = int(sid)
+	}
+	if jti, ok := claims["jti"].(string); ok {
+		tokenClaims.TokenID = jti
+	}
+	if ver, ok := claims["ver"].(float64); ok {
+		tokenClaims.TokenVersion = int(ver)
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				tokenClaims.Roles = append(tokenClaims.Roles, name)
+			}
+		}
+	}
+	if scope, ok := claims["scope"].(string); ok && scope != "" {
+		tokenClaims.Scopes = strings.Fields(scope)
+	}
+
+	// Authorize access tokens by the current roles; the scope they were issued with is only
+	// used until the role catalog is loaded
+	if _, ok := claims["roles"]; ok {
+		if roles, permissions, ok := s.roles.Resolve(tokenClaims.Roles); ok {
+			tokenClaims.Roles = roles
+			tokenClaims.Scopes = permissions
+		}
+	}
+	if tid, ok := claims["tid"].(string); ok {
+		tokenClaims.TenantID = tid
+	}
+	// Tokens issued before sudo mode carry no auth time and never count as recent
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		tokenClaims.AuthTime = int64(authTime)
+	}
+
+	return tokenClaims, nil
 }
 
-func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*domain.Token, error) {
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string, client domain.ClientInfo) (*domain.Token, error) {
 	// Validate refresh token
-	claims, err := s.ValidateToken(refreshToken)
+	claims, err := s.parseToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
+	if claims.Type != domain.TokenTypeRefresh {
+		return nil, domain.ErrInvalidToken
+	}
+
+	// Only refresh tokens are bound to a session
+	if claims.SessionID == 0 || claims.TokenID == "" {
+		return nil, domain.ErrInvalidToken
+	}
 
 	// Get user
 	userID, err := strconv.Atoi(claims.UserID)
@@ -155,23 +260,209 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*d
 	}
 
 	// Check user status
-	if user.Status != "active" {
+	if user.Status != domain.UserStatusActive {
 		return nil, domain.ErrUserInactive
 	}
 
-	// Generate new tokens
-	return s.generateAuthResponse(user)
+	// Tokens issued before a role or identity change can't be refreshed
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, domain.ErrInvalidToken
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	if session.UserID != user.ID {
+		return nil, domain.ErrInvalidToken
+	}
+
+	// Rotate the refresh token; a token that was already used or revoked is rejected
+	tokenID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.refreshTokenDuration)
+	if err := s.sessionRepo.Rotate(ctx, session.ID, claims.TokenID, tokenID, client, expiresAt); err != nil {
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("rotate session: %w", err)
+	}
+
+	// Generate new tokens; refreshing doesn't count as entering the password
+	var authTime time.Time
+	if claims.AuthTime != 0 {
+		authTime = time.Unix(claims.AuthTime, 0)
+	}
+	return s.generateAuthResponse(ctx, user, session.ID, tokenID, authTime)
+}
+
+// GetJWKS returns the public keys used to verify issued tokens
+func (s *authService) GetJWKS() domain.JSONWebKeySet {
+	return s.keys.jwks()
+}
+
+// GetSessions retrieves the user's active sessions
+func (s *authService) GetSessions(ctx context.Context, userID int) ([]domain.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's sessions so its refresh token can no longer be used
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	return nil
 }
 
-func (s *authService) generateAuthResponse(user *domain.User) (*domain.Token, error) {
+// RevokeAllSessions revokes all of the user's sessions (log out everywhere)
+func (s *authService) RevokeAllSessions(ctx context.Context, userID int) (int64, error) {
+	revoked, err := s.sessionRepo.RevokeAll(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke all sessions: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// startSession creates a session for a new login and issues tokens bound to it
+func (s *authService) startSession(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.Token, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &domain.Session{
+		UserID:         user.ID,
+		RefreshTokenID: tokenID,
+		UserAgent:      client.UserAgent,
+		IPAddress:      client.IPAddress,
+		ExpiresAt:      time.Now().Add(s.refreshTokenDuration),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+
+	return s.generateAuthResponse(ctx, user, session.ID, tokenID, time.Now())
+}
+
+// Sudo checks the user's password again and issues a short-lived access token for the session
+// that sensitive operations accept
+func (s *authService) Sudo(ctx context.Context, userID, sessionID int, password string) (*domain.SudoToken, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if user.Status != domain.UserStatusActive {
+		return nil, domain.ErrUserInactive
+	}
+
+	// The token is bound to the caller's session, so it can't outlive a sign-out
+	if sessionID == 0 {
+		return nil, domain.ErrInvalidToken
+	}
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	if session.UserID != user.ID || session.RevokedAt != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	claims, err := s.accessClaims(ctx, user, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.generateToken(ctx, user, s.reauthWindow, sessionID, claims)
+	if err != nil {
+		return nil, fmt.Errorf("generate sudo token: %w", err)
+	}
+
+	return &domain.SudoToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.reauthWindow.Seconds()),
+	}, nil
+}
+
+// RecentlyAuthenticated reports whether a password entered at authTime still allows sensitive
+// operations
+func (s *authService) RecentlyAuthenticated(authTime time.Time) bool {
+	return !authTime.IsZero() && time.Since(authTime) <= s.reauthWindow
+}
+
+// accessClaims returns the claims access tokens carry besides the identity. Roles and
+// permissions are embedded so authorization doesn't need DB lookups. Requests are authorized
+// by the current permissions of the roles, so role changes apply at once.
+func (s *authService) accessClaims(ctx context.Context, user *domain.User, authTime time.Time) (jwt.MapClaims, error) {
+	roles, err := s.roleRepo.GetUserRoleNames(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get user roles: %w", err)
+	}
+
+	_, permissions, ok := s.roles.Resolve(roles)
+	if !ok {
+		if err := s.roles.Reload(ctx); err != nil {
+			return nil, fmt.Errorf("load roles: %w", err)
+		}
+		_, permissions, _ = s.roles.Resolve(roles)
+	}
+
+	claims := jwt.MapClaims{
+		"typ":   domain.TokenTypeAccess,
+		"roles": roles,
+		"scope": strings.Join(permissions, " "),
+	}
+	if !authTime.IsZero() {
+		claims["auth_time"] = authTime.Unix()
+	}
+	return claims, nil
+}
+
+func (s *authService) generateAuthResponse(ctx context.Context, user *domain.User, sessionID int, refreshTokenID string, authTime time.Time) (*domain.Token, error) {
+	claims, err := s.accessClaims(ctx, user, authTime)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate access token
-	accessToken, err := s.generateToken(user, s.accessTokenDuration)
+	accessToken, err := s.generateToken(ctx, user, s.accessTokenDuration, sessionID, claims)
 	if err != nil {
 		return nil, fmt.Errorf("generate access token: %w", err)
 	}
 
-	// Generate refresh token
-	refreshToken, err := s.generateToken(user, s.refreshTokenDuration)
+	// Generate refresh token; it carries the auth time on to the access tokens it is exchanged for
+	refreshClaims := jwt.MapClaims{"typ": domain.TokenTypeRefresh, "jti": refreshTokenID}
+	if !authTime.IsZero() {
+		refreshClaims["auth_time"] = authTime.Unix()
+	}
+	refreshToken, err := s.generateToken(ctx, user, s.refreshTokenDuration, sessionID, refreshClaims)
 	if err != nil {
 		return nil, fmt.Errorf("generate refresh token: %w", err)
 	}
@@ -188,19 +479,36 @@ func (s *authService) generateAuthResponse(user *domain.User) (*domain.Token, er
 	}, nil
 }
 
-func (s *authService) generateToken(user *domain.User, duration time.Duration) (string, error) {
+func (s *authService) generateToken(ctx context.Context, user *domain.User, duration time.Duration, sessionID int, extra jwt.MapClaims) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": strconv.Itoa(user.ID),
 		"email":   user.Email,
+		"sid":     sessionID,
+		"ver":     user.TokenVersion,
 		"exp":     time.Now().Add(duration).Unix(),
 		"iat":     time.Now().Unix(),
 	}
+	// Tokens are bound to the storefront they are issued by
+	if tenantID := tenant.ID(ctx); tenantID != "" {
+		claims["tid"] = tenantID
+	}
+	for key, value := range extra {
+		claims[key] = value
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	tokenString, err := s.keys.sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("sign token: %w", err)
 	}
 
 	return tokenString, nil
 }
+
+// newTokenID generates a random refresh token identifier
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/s3"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+type BackupService interface {
+	// StartBackup starts backing up the configured collections to the backup directory, uploading
+	// the file to S3 when a bucket is configured. The backup continues after the call returns and
+	// its outcome is logged. Only one backup runs at a time.
+	StartBackup(ctx context.Context) (*domain.Backup, error)
+}
+
+type backupService struct {
+	backupRepo repository.BackupRepository
+	storage    *s3.Client
+	cfg        *config.Backup
+
+	running atomic.Bool
+}
+
+func NewBackupService(backupRepo repository.BackupRepository, cfg *config.Config) BackupService {
+	return &backupService{
+		backupRepo: backupRepo,
+		storage:    s3.New(&cfg.Backup.S3),
+		cfg:        &cfg.Backup,
+	}
+}
+
+func (s *backupService) StartBackup(ctx context.Context) (*domain.Backup, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, domain.ErrBackupInProgress
+	}
+
+	backup := &domain.Backup{StartedAt: time.Now().UTC()}
+	backup.Name = domain.BackupName(backup.StartedAt)
+	if s.storage != nil {
+		backup.S3Key = s.storage.Key(backup.Name)
+	}
+
+	// The backup outlives the request
+	backupCtx := context.WithoutCancel(ctx)
+	go func() {
+		defer s.running.Store(false)
+
+		log := logger.GetLoggerFromContext(backupCtx).WithComponent("backup").WithFields(logger.Fields{"name": backup.Name})
+		counts, err := s.run(backupCtx, backup)
+		if err != nil {
+			log.WithError(err).Error("Failed to back up database")
+			return
+		}
+		log.WithDuration(time.Since(backup.StartedAt)).WithFields(logger.Fields{
+			"collections": counts,
+			"s3_key":      backup.S3Key,
+		}).Info("Backed up database")
+	}()
+
+	return backup, nil
+}
+
+// run writes the backup file and uploads it
+func (s *backupService) run(ctx context.Context, backup *domain.Backup) (map[string]int64, error) {
+	if err := os.MkdirAll(s.cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create backup directory: %w", err)
+	}
+
+	path := filepath.Join(s.cfg.Dir, backup.Name)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create backup file: %w", err)
+	}
+	defer file.Close()
+
+	counts, err := s.backupRepo.Dump(ctx, file, s.cfg.Collections)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("dump database: %w", err)
+	}
+
+	if s.storage == nil {
+		return counts, nil
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("measure backup file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("rewind backup file: %w", err)
+	}
+	if err := s.storage.Put(ctx, backup.S3Key, file, size); err != nil {
+		return nil, fmt.Errorf("upload backup: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// BoughtTogetherService serves the products frequently bought together with a product, for cart
+// upsells. The lists are computed from purchase history in the background and stored, so
+// serving them doesn't aggregate purchases.
+type BoughtTogetherService interface {
+	// Get returns up to limit active products most often bought together with a product
+	Get(ctx context.Context, productID int, limit int) (*domain.FrequentlyBoughtTogether, error)
+
+	// Refresh recomputes the lists of the tenant of ctx
+	Refresh(ctx context.Context) error
+
+	// Run refreshes the lists of every tenant each refresh interval until ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type boughtTogetherService struct {
+	boughtTogetherRepo repository.BoughtTogetherRepository
+	productRepo        repository.ProductRepository
+	tenancy            *config.Tenancy
+	refreshInterval    time.Duration
+	minBuyers          int
+	maxItems           int
+}
+
+func NewBoughtTogetherService(
+	boughtTogetherRepo repository.BoughtTogetherRepository,
+	productRepo repository.ProductRepository,
+	cfg *config.Config,
+) (BoughtTogetherService, error) {
+	refreshInterval, err := time.ParseDuration(cfg.Recommendation.BoughtTogether.RefreshInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse bought together refresh interval: %w", err)
+	}
+
+	return &boughtTogetherService{
+		boughtTogetherRepo: boughtTogetherRepo,
+		productRepo:        productRepo,
+		tenancy:            &cfg.Tenancy,
+		refreshInterval:    refreshInterval,
+		minBuyers:          cfg.Recommendation.BoughtTogether.MinBuyers,
+		maxItems:           cfg.Recommendation.BoughtTogether.MaxItems,
+	}, nil
+}
+
+// Get skips products deleted or deactivated since the list was computed
+func (s *boughtTogetherService) Get(ctx context.Context, productID int, limit int) (*domain.FrequentlyBoughtTogether, error) {
+	if limit <= 0 || limit > s.maxItems {
+		limit = s.maxItems
+	}
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+
+	result := &domain.FrequentlyBoughtTogether{
+		ProductID: productID,
+		Products:  make([]domain.BoughtTogetherProduct, 0, limit),
+	}
+
+	list, err := s.boughtTogetherRepo.Get(ctx, productID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return result, nil
+		}
+		return nil, fmt.Errorf("get bought together: %w", err)
+	}
+	result.ComputedAt = &list.ComputedAt
+
+	for _, item := range list.Items {
+		if len(result.Products) == limit {
+			break
+		}
+
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			if err == domain.ErrNotFound {
+				continue
+			}
+			return nil, fmt.Errorf("get product: %w", err)
+		}
+		if !product.IsActive {
+			continue
+		}
+
+		result.Products = append(result.Products, domain.BoughtTogetherProduct{
+			Product:    product,
+			Buyers:     item.Buyers,
+			Confidence: item.Confidence,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *boughtTogetherService) Refresh(ctx context.Context) error {
+	started := time.Now()
+
+	lists, err := s.boughtTogetherRepo.Compute(ctx, s.minBuyers, s.maxItems)
+	if err != nil {
+		return err
+	}
+
+	if err := s.boughtTogetherRepo.Replace(ctx, lists, started); err != nil {
+		return err
+	}
+
+	logger.GetLoggerFromContext(ctx).WithComponent("bought_together").WithDuration(time.Since(started)).WithFields(logger.Fields{
+		"tenant":   tenant.ID(ctx),
+		"products": len(lists),
+	}).Debug("Computed frequently bought together products")
+
+	return nil
+}
+
+func (s *boughtTogetherService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, tenantCtx := range tenant.Contexts(ctx, s.tenancy) {
+			if err := s.refreshStale(tenantCtx); err != nil && ctx.Err() == nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("bought_together").WithError(err).WithFields(logger.Fields{
+					"tenant": tenant.ID(tenantCtx),
+				}).Error("Failed to compute frequently bought together products")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshStale refreshes the lists unless they were computed in the last half interval, as
+// when another instance just did
+func (s *boughtTogetherService) refreshStale(ctx context.Context) error {
+	computedAt, err := s.boughtTogetherRepo.LastComputedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if computedAt.After(time.Now().Add(-s.refreshInterval / 2)) {
+		return nil
+	}
+
+	return s.Refresh(ctx)
+}
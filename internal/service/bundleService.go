@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type BundleService interface {
+	CreateBundle(ctx context.Context, bundle *domain.Bundle) error
+	GetBundle(ctx context.Context, id int) (*domain.Bundle, error)
+	ListBundles(ctx context.Context, filter domain.BundleFilter, limit, offset int) ([]*domain.Bundle, int64, error)
+	UpdateBundle(ctx context.Context, bundle *domain.Bundle) error
+	DeleteBundle(ctx context.Context, id int) error
+
+	// Purchases
+	PurchaseBundle(ctx context.Context, userID, bundleID int, quantity int, client domain.ClientInfo) error
+	PurchaseBundleAsGuest(ctx context.Context, anonymousID string, bundleID int, quantity int, client domain.ClientInfo) error
+}
+
+type bundleService struct {
+	bundleRepo      repository.BundleRepository
+	segmentRepo     repository.SegmentRepository
+	productRepo     repository.ProductRepository
+	stockRepo       repository.StockRepository
+	interactionRepo repository.InteractionRepository
+	stockFeed       StockFeed
+	risk            RiskService
+}
+
+func NewBundleService(
+	bundleRepo repository.BundleRepository,
+	segmentRepo repository.SegmentRepository,
+	productRepo repository.ProductRepository,
+	stockRepo repository.StockRepository,
+	interactionRepo repository.InteractionRepository,
+	stockFeed StockFeed,
+	risk RiskService,
+) BundleService {
+	return &bundleService{
+		bundleRepo:      bundleRepo,
+		segmentRepo:     segmentRepo,
+		productRepo:     productRepo,
+		stockRepo:       stockRepo,
+		interactionRepo: interactionRepo,
+		stockFeed:       stockFeed,
+		risk:            risk,
+	}
+}
+
+// CreateBundle creates a bundle of existing products and sets its stock
+func (s *bundleService) CreateBundle(ctx context.Context, bundle *domain.Bundle) error {
+	if err := s.validateBundle(ctx, bundle); err != nil {
+		return err
+	}
+
+	if err := s.bundleRepo.Create(ctx, bundle); err != nil {
+		return fmt.Errorf("create bundle: %w", err)
+	}
+
+	return s.setStock(ctx, bundle)
+}
+
+// GetBundle retrieves a bundle with the stock its components make up
+func (s *bundleService) GetBundle(ctx context.Context, id int) (*domain.Bundle, error) {
+	bundle, err := s.bundleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setStock(ctx, bundle); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// ListBundles retrieves a page of the bundles the filter selects, newest first, with the stock
+// their components make up
+func (s *bundleService) ListBundles(ctx context.Context, filter domain.BundleFilter, limit, offset int) ([]*domain.Bundle, int64, error) {
+	bundles, total, err := s.bundleRepo.List(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list bundles: %w", err)
+	}
+
+	if err := s.setStock(ctx, bundles...); err != nil {
+		return nil, 0, err
+	}
+
+	return bundles, total, nil
+}
+
+// UpdateBundle updates a bundle
+func (s *bundleService) UpdateBundle(ctx context.Context, bundle *domain.Bundle) error {
+	if err := s.validateBundle(ctx, bundle); err != nil {
+		return err
+	}
+
+	if err := s.bundleRepo.Update(ctx, bundle); err != nil {
+		return err
+	}
+
+	return s.setStock(ctx, bundle)
+}
+
+// DeleteBundle deletes a bundle; its components and their purchases are kept
+func (s *bundleService) DeleteBundle(ctx context.Context, id int) error {
+	return s.bundleRepo.Delete(ctx, id)
+}
+
+// validateBundle checks the bundle and that all of its components and segments exist
+func (s *bundleService) validateBundle(ctx context.Context, bundle *domain.Bundle) error {
+	if err := bundle.Validate(); err != nil {
+		return err
+	}
+
+	stock, err := s.bundleRepo.ComponentStock(ctx, componentIDs(bundle))
+	if err != nil {
+		return fmt.Errorf("get component stock: %w", err)
+	}
+	for _, component := range bundle.Components {
+		if _, ok := stock[component.ProductID]; !ok {
+			return fmt.Errorf("product %d not found: %w", component.ProductID, domain.ErrValidation)
+		}
+	}
+
+	for _, segmentID := range bundle.SegmentIDs {
+		if _, err := s.segmentRepo.GetByID(ctx, segmentID); err != nil {
+			if err == domain.ErrNotFound {
+				return fmt.Errorf("segment %d not found: %w", segmentID, domain.ErrValidation)
+			}
+			return fmt.Errorf("verify segment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setStock sets the stock of the bundles from their components' stock, loaded in one query
+func (s *bundleService) setStock(ctx context.Context, bundles ...*domain.Bundle) error {
+	var productIDs []int
+	for _, bundle := range bundles {
+		productIDs = append(productIDs, componentIDs(bundle)...)
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	stock, err := s.bundleRepo.ComponentStock(ctx, productIDs)
+	if err != nil {
+		return fmt.Errorf("get component stock: %w", err)
+	}
+	for _, bundle := range bundles {
+		bundle.StockFrom(stock)
+	}
+
+	return nil
+}
+
+func componentIDs(bundle *domain.Bundle) []int {
+	ids := make([]int, len(bundle.Components))
+	for i, component := range bundle.Components {
+		ids[i] = component.ProductID
+	}
+	return ids
+}
+
+// PurchaseBundle records a user purchasing a bundle
+func (s *bundleService) PurchaseBundle(ctx context.Context, userID, bundleID int, quantity int, client domain.ClientInfo) error {
+	buyer := domain.RiskCheck{UserID: userID, Client: client}
+	return s.purchase(ctx, buyer, bundleID, quantity, func(productID, quantity int, price, cost domain.Money) error {
+		return s.interactionRepo.RecordPurchase(ctx, userID, productID, quantity, price, cost)
+	})
+}
+
+// PurchaseBundleAsGuest records a guest checkout of a bundle
+func (s *bundleService) PurchaseBundleAsGuest(ctx context.Context, anonymousID string, bundleID int, quantity int, client domain.ClientInfo) error {
+	buyer := domain.RiskCheck{AnonymousID: anonymousID, Client: client}
+	return s.purchase(ctx, buyer, bundleID, quantity, func(productID, quantity int, price, cost domain.Money) error {
+		return s.interactionRepo.RecordAnonymousPurchase(ctx, anonymousID, productID, quantity, price, cost)
+	})
+}
+
+// purchase takes the stock of all components at once, each from the warehouse a product
+// purchase would ship from, and records a purchase of each component at its share of the
+// bundle price, then screens the purchase for risk. buyer identifies the buyer and their client.
+func (s *bundleService) purchase(ctx context.Context, buyer domain.RiskCheck, bundleID int, quantity int, record func(productID, quantity int, price, cost domain.Money) error) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than 0")
+	}
+
+	bundle, err := s.bundleRepo.GetByID(ctx, bundleID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return fmt.Errorf("bundle not found")
+		}
+		return fmt.Errorf("get bundle: %w", err)
+	}
+	if !bundle.IsActive {
+		return fmt.Errorf("bundle is not available")
+	}
+
+	// Bundles targeted at segments are hidden from everyone else, guests included
+	if len(bundle.SegmentIDs) > 0 {
+		var segmentIDs []int
+		if buyer.UserID != 0 {
+			segmentIDs, err = s.segmentRepo.GetUserSegmentIDs(ctx, buyer.UserID)
+			if err != nil {
+				return fmt.Errorf("get user segments: %w", err)
+			}
+		}
+		if !bundle.OfferedTo(segmentIDs) {
+			return fmt.Errorf("bundle not found")
+		}
+	}
+
+	products := make([]*domain.Product, len(bundle.Components))
+	adjustments := make([]*domain.StockAdjustment, len(bundle.Components))
+	for i, component := range bundle.Components {
+		product, err := s.productRepo.GetByID(ctx, component.ProductID)
+		if err != nil {
+			if err == domain.ErrNotFound {
+				return fmt.Errorf("bundle product %d not found", component.ProductID)
+			}
+			return fmt.Errorf("verify product: %w", err)
+		}
+		if product.FlashSale != nil && product.FlashSale.Active(time.Now()) {
+			return errFlashSaleActive(product.ID)
+		}
+
+		units := component.Quantity * quantity
+		if product.Stock < units {
+			return fmt.Errorf("insufficient stock of product %d: requested %d, available %d", product.ID, units, product.Stock)
+		}
+
+		locations, err := s.stockRepo.GetLocations(ctx, product.ID)
+		if err != nil {
+			return fmt.Errorf("get stock locations: %w", err)
+		}
+		warehouseID, err := pickWarehouse(product, locations, units, 0)
+		if err != nil {
+			return err
+		}
+
+		products[i] = product
+		adjustments[i] = &domain.StockAdjustment{
+			ProductID:   product.ID,
+			WarehouseID: warehouseID,
+			Delta:       -units,
+			Reason:      domain.StockReasonPurchase,
+			Note:        fmt.Sprintf("bundle %d", bundle.ID),
+			ActorID:     buyer.UserID,
+		}
+	}
+
+	// Reduce the stock of all components together, so a bundle is never sold in part
+	updated, err := s.stockRepo.AdjustMany(ctx, adjustments)
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return fmt.Errorf("insufficient stock for bundle %d", bundle.ID)
+		}
+		return fmt.Errorf("update product stock: %w", err)
+	}
+
+	prices := componentPrices(bundle, products)
+	lines := make([]domain.PurchaseLine, len(bundle.Components))
+	for i, component := range bundle.Components {
+		lines[i] = domain.PurchaseLine{ProductID: component.ProductID, Quantity: component.Quantity * quantity, Price: prices[i]}
+		if err := record(lines[i].ProductID, lines[i].Quantity, lines[i].Price, products[i].CostPrice); err != nil {
+			// Give back the stock of the components not recorded
+			for _, product := range updated[:i] {
+				s.stockFeed.Publish(product)
+			}
+			restoreStock(ctx, s.stockRepo, s.stockFeed, adjustments[i:], buyer.UserID)
+			return fmt.Errorf("record purchase: %w", err)
+		}
+	}
+
+	for _, product := range updated {
+		s.stockFeed.Publish(product)
+	}
+
+	buyer.Source = domain.PurchaseSourceBundle
+	buyer.SourceID = bundle.ID
+	buyer.Lines = lines
+	screenPurchase(ctx, s.risk, buyer)
+	return nil
+}
+
+// componentPrices splits the bundle price over its components in proportion to their own
+// prices, returning the unit price of each component rounded to the minor unit
+func componentPrices(bundle *domain.Bundle, products []*domain.Product) []domain.Money {
+	var listPrice int64
+	units := 0
+	for i, component := range bundle.Components {
+		listPrice += products[i].Price.Mul(component.Quantity).Amount
+		units += component.Quantity
+	}
+
+	prices := make([]domain.Money, len(bundle.Components))
+	for i := range bundle.Components {
+		if listPrice > 0 {
+			share := float64(products[i].Price.Amount) / float64(listPrice)
+			prices[i] = domain.NewMoney(int64(math.Round(float64(bundle.Price.Amount)*share)), bundle.Price.Currency)
+		} else {
+			prices[i] = bundle.Price.Div(units)
+		}
+	}
+	return prices
+}
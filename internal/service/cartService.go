@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const (
+	// CartEventsTopic is the topic cart events are published on
+	CartEventsTopic = "carts"
+
+	// abandonBatchSize limits the idle carts handled per check
+	abandonBatchSize = 100
+)
+
+type CartService interface {
+	GetCart(ctx context.Context, userID int) (*domain.Cart, error)
+	SetItem(ctx context.Context, userID, productID, quantity int) (*domain.Cart, error)
+	ClearCart(ctx context.Context, userID int) error
+
+	// Checkout purchases everything in the cart at once and empties it. A coupon code, if not
+	// empty, takes the coupon's discount off every line and redeems the coupon.
+	Checkout(ctx context.Context, userID int, couponCode string, client domain.ClientInfo) error
+
+	// RecoveryStats summarizes the carts abandoned in [from, to)
+	RecoveryStats(ctx context.Context, from, to time.Time) (*domain.CartRecoveryStats, error)
+
+	// Run looks for abandoned carts every check interval and reminds their owners, until ctx
+	// is cancelled
+	Run(ctx context.Context) error
+}
+
+type cartService struct {
+	cartRepo        repository.CartRepository
+	productRepo     repository.ProductRepository
+	stockRepo       repository.StockRepository
+	interactionRepo repository.InteractionRepository
+	notifications   NotificationService
+	stockFeed       StockFeed
+	tx              repository.Transactor
+	outbox          Outbox
+	risk            RiskService
+	promotionRepo   repository.PromotionRepository
+	groupRepo       repository.CustomerGroupRepository
+	limitRepo       repository.PurchaseLimitRepository
+	abandonAfter    time.Duration
+	checkInterval   time.Duration
+	recoveryURL     string
+	recoveryWindow  time.Duration
+	tenancy         *config.Tenancy
+}
+
+func NewCartService(
+	cartRepo repository.CartRepository,
+	productRepo repository.ProductRepository,
+	stockRepo repository.StockRepository,
+	interactionRepo repository.InteractionRepository,
+	notifications NotificationService,
+	stockFeed StockFeed,
+	tx repository.Transactor,
+	outbox Outbox,
+	risk RiskService,
+	promotionRepo repository.PromotionRepository,
+	groupRepo repository.CustomerGroupRepository,
+	limitRepo repository.PurchaseLimitRepository,
+	cfg *config.Config,
+) (CartService, error) {
+	abandonAfter, err := time.ParseDuration(cfg.Carts.AbandonAfter)
+	if err != nil {
+		return nil, fmt.Errorf("parse abandon after: %w", err)
+	}
+
+	checkInterval, err := time.ParseDuration(cfg.Carts.CheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse check interval: %w", err)
+	}
+
+	recoveryWindow, err := time.ParseDuration(cfg.Carts.RecoveryWindow)
+	if err != nil {
+		return nil, fmt.Errorf("parse recovery window: %w", err)
+	}
+
+	if _, err := url.Parse(cfg.Carts.RecoveryURL); err != nil {
+		return nil, fmt.Errorf("parse recovery url: %w", err)
+	}
+
+	return &cartService{
+		cartRepo:        cartRepo,
+		productRepo:     productRepo,
+		stockRepo:       stockRepo,
+		interactionRepo: interactionRepo,
+		notifications:   notifications,
+		stockFeed:       stockFeed,
+		tx:              tx,
+		outbox:          outbox,
+		risk:            risk,
+		promotionRepo:   promotionRepo,
+		groupRepo:       groupRepo,
+		limitRepo:       limitRepo,
+		abandonAfter:    abandonAfter,
+		checkInterval:   checkInterval,
+		recoveryURL:     cfg.Carts.RecoveryURL,
+		recoveryWindow:  recoveryWindow,
+		tenancy:         &cfg.Tenancy,
+	}, nil
+}
+
+// GetCart retrieves a user's cart with its total at current prices; users without a cart
+// get an empty one
+func (s *cartService) GetCart(ctx context.Context, userID int) (*domain.Cart, error) {
+	cart, err := s.getCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.setTotal(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+func (s *cartService) getCart(ctx context.Context, userID int) (*domain.Cart, error) {
+	cart, err := s.cartRepo.Get(ctx, userID)
+	if err == domain.ErrNotFound {
+		return &domain.Cart{UserID: userID, Items: []domain.CartItem{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+	return cart, nil
+}
+
+// SetItem sets the quantity of a product in the cart, removing it at 0. The quantity must be
+// one the product's order quantity rules allow; stock is only checked at checkout.
+func (s *cartService) SetItem(ctx context.Context, userID, productID, quantity int) (*domain.Cart, error) {
+	if quantity > 0 {
+		product, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if err := product.CheckQuantity(quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	cart, err := s.getCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cart.SetItem(productID, quantity, domain.InteractionTraceFrom(ctx).Source, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.Save(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.setTotal(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+// ClearCart empties a user's cart
+func (s *cartService) ClearCart(ctx context.Context, userID int) error {
+	return s.cartRepo.Delete(ctx, userID)
+}
+
+// setTotal sets the total of the cart at the current prices of the owner's customer group,
+// lowered by the volume price each item's quantity reaches. It returns the cart's products in
+// item order, priced the same. Products deleted since they were added are left out of the
+// total.
+func (s *cartService) setTotal(ctx context.Context, cart *domain.Cart) ([]*domain.Product, error) {
+	products := make([]*domain.Product, len(cart.Items))
+	for i, item := range cart.Items {
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if err == domain.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get cart product: %w", err)
+		}
+		products[i] = product
+	}
+	if err := applyGroupPrices(ctx, s.groupRepo, cart.UserID, products...); err != nil {
+		return nil, err
+	}
+
+	cart.Total = domain.Money{}
+	for i, item := range cart.Items {
+		if products[i] != nil {
+			products[i].Price = products[i].UnitPrice(item.Quantity)
+			cart.Total = cart.Total.Add(products[i].Price.Mul(item.Quantity))
+		}
+	}
+	return products, nil
+}
+
+// Checkout takes the stock of every product in the cart at once, each from the warehouse a
+// product purchase would ship from, and records a purchase of each at its current price.
+// If any product is short nothing is taken. A checkout of a cart abandoned within the
+// recovery window counts as a recovery. The checkout is then screened for risk.
+// A coupon is redeemed before the stock is taken and given back if nothing was purchased;
+// purchase limits are counted the same way and given back for the products not purchased.
+// Order quantity rules changed since an item was added are checked again, and products on an
+// active flash sale can't be checked out until it ends.
+func (s *cartService) Checkout(ctx context.Context, userID int, couponCode string, client domain.ClientInfo) error {
+	cart, err := s.getCart(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(cart.Items) == 0 {
+		return fmt.Errorf("cart is empty: %w", domain.ErrValidation)
+	}
+
+	products, err := s.setTotal(ctx, cart)
+	if err != nil {
+		return err
+	}
+
+	adjustments := make([]*domain.StockAdjustment, len(cart.Items))
+	for i, item := range cart.Items {
+		product := products[i]
+		if product == nil {
+			return fmt.Errorf("product %d is no longer available: %w", item.ProductID, domain.ErrValidation)
+		}
+		if product.FlashSale != nil && product.FlashSale.Active(time.Now()) {
+			return errFlashSaleActive(product.ID)
+		}
+		// The rules may have changed since the product was added
+		if err := product.CheckQuantity(item.Quantity); err != nil {
+			return err
+		}
+
+		locations, err := s.stockRepo.GetLocations(ctx, product.ID)
+		if err != nil {
+			return fmt.Errorf("get stock locations: %w", err)
+		}
+		warehouseID, err := pickWarehouse(product, locations, item.Quantity, 0)
+		if err != nil {
+			return fmt.Errorf("product %d: %w", product.ID, domain.ErrInsufficientStock)
+		}
+
+		adjustments[i] = &domain.StockAdjustment{
+			ProductID:   product.ID,
+			WarehouseID: warehouseID,
+			Delta:       -item.Quantity,
+			Reason:      domain.StockReasonPurchase,
+			Note:        "cart checkout",
+			ActorID:     userID,
+		}
+	}
+
+	// Purchase limits are counted and the coupon is redeemed before the stock is taken, so
+	// concurrent checkouts cannot exceed a limit or redeem a coupon twice
+	releaseLimits := make([]func(), 0, len(cart.Items))
+	releaseLimitsFrom := func(i int) {
+		for _, release := range releaseLimits[i:] {
+			release()
+		}
+	}
+	for i, item := range cart.Items {
+		release, err := consumePurchaseLimit(ctx, s.limitRepo, products[i], userID, item.Quantity)
+		if err != nil {
+			releaseLimitsFrom(0)
+			return err
+		}
+		releaseLimits = append(releaseLimits, release)
+	}
+
+	prices := make([]domain.Money, len(cart.Items))
+	for i := range cart.Items {
+		prices[i] = products[i].Price
+	}
+	var coupon *domain.Coupon
+	if couponCode != "" {
+		coupon, err = s.promotionRepo.RedeemCoupon(ctx, userID, couponCode, time.Now().UTC())
+		if err != nil {
+			releaseLimitsFrom(0)
+			if err == domain.ErrNotFound {
+				return fmt.Errorf("coupon is invalid, expired or already redeemed: %w", domain.ErrValidation)
+			}
+			return fmt.Errorf("redeem coupon: %w", err)
+		}
+		for i := range prices {
+			prices[i] = coupon.Apply(prices[i])
+		}
+	}
+
+	// Reduce the stock of all products together, so a cart is never checked out in part
+	updated, err := s.stockRepo.AdjustMany(ctx, adjustments)
+	if err != nil {
+		s.releaseCoupon(ctx, coupon)
+		releaseLimitsFrom(0)
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return err
+		}
+		return fmt.Errorf("update product stock: %w", err)
+	}
+
+	for i, item := range cart.Items {
+		// Attribute each purchase to the page its product was added to the cart from
+		lineCtx := ctx
+		if item.Source != "" {
+			trace := domain.InteractionTraceFrom(ctx)
+			trace.Source = item.Source
+			lineCtx = domain.WithInteractionTrace(ctx, trace)
+		}
+		if err := s.interactionRepo.RecordPurchase(lineCtx, userID, item.ProductID, item.Quantity, prices[i], products[i].CostPrice); err != nil {
+			// Give back the stock of the products not recorded
+			for _, product := range updated[:i] {
+				s.stockFeed.Publish(product)
+			}
+			restoreStock(ctx, s.stockRepo, s.stockFeed, adjustments[i:], userID)
+			releaseLimitsFrom(i)
+			// Purchases already recorded keep the discount
+			if i == 0 {
+				s.releaseCoupon(ctx, coupon)
+			}
+			return fmt.Errorf("record purchase: %w", err)
+		}
+	}
+
+	for _, product := range updated {
+		s.stockFeed.Publish(product)
+	}
+
+	lines := make([]domain.PurchaseLine, len(cart.Items))
+	for i, item := range cart.Items {
+		lines[i] = domain.PurchaseLine{ProductID: item.ProductID, Quantity: item.Quantity, Price: prices[i]}
+	}
+	screenPurchase(ctx, s.risk, domain.RiskCheck{UserID: userID, Source: domain.PurchaseSourceCart, Lines: lines, Client: client})
+
+	if err := s.cartRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	if cart.AbandonedCartID != 0 {
+		now := time.Now().UTC()
+		err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+			recovered, err := s.cartRepo.MarkRecovered(ctx, cart.AbandonedCartID, now.Add(-s.recoveryWindow), now, cart.Total)
+			if err != nil || recovered == nil {
+				return err
+			}
+			return s.outbox.Record(ctx, CartEventsTopic, domain.CartEvent{Type: domain.CartRecovered, Cart: *recovered})
+		})
+		if err != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("carts").WithError(err).Error("Failed to record cart recovery")
+		}
+	}
+
+	return nil
+}
+
+// releaseCoupon gives back the coupon of a checkout that purchased nothing, if it had one
+func (s *cartService) releaseCoupon(ctx context.Context, coupon *domain.Coupon) {
+	if coupon == nil {
+		return
+	}
+	if err := s.promotionRepo.ReleaseCoupon(ctx, coupon.ID); err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("carts").WithError(err).Error("Failed to release coupon", "coupon_id", coupon.ID)
+	}
+}
+
+// RecoveryStats summarizes the carts abandoned in [from, to)
+func (s *cartService) RecoveryStats(ctx context.Context, from, to time.Time) (*domain.CartRecoveryStats, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to: %w", domain.ErrValidation)
+	}
+
+	stats, err := s.cartRepo.RecoveryStats(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get cart recovery stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Run looks for abandoned carts of every tenant each check interval until ctx is cancelled
+func (s *cartService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, tenantCtx := range tenant.Contexts(ctx, s.tenancy) {
+			if err := s.abandonIdle(tenantCtx); err != nil && ctx.Err() == nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("carts").WithError(err).WithFields(logger.Fields{
+					"tenant": tenant.ID(tenantCtx),
+				}).Error("Failed to detect abandoned carts")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// abandonIdle records carts idle for longer than the abandon threshold as abandoned, publishes
+// an event for each and reminds their owners
+func (s *cartService) abandonIdle(ctx context.Context) error {
+	carts, err := s.cartRepo.ListIdle(ctx, time.Now().Add(-s.abandonAfter), abandonBatchSize)
+	if err != nil {
+		return fmt.Errorf("list idle carts: %w", err)
+	}
+
+	for _, cart := range carts {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if _, err := s.setTotal(ctx, cart); err != nil {
+			return err
+		}
+
+		abandoned := &domain.AbandonedCart{
+			UserID:      cart.UserID,
+			Items:       cart.Items,
+			Value:       cart.Total,
+			AbandonedAt: time.Now().UTC(),
+		}
+		var claimed bool
+		err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			claimed, err = s.cartRepo.Abandon(ctx, cart, abandoned)
+			if err != nil || !claimed {
+				return err
+			}
+			return s.outbox.Record(ctx, CartEventsTopic, domain.CartEvent{Type: domain.CartAbandoned, Cart: *abandoned})
+		})
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			// Changed since it was listed, or handled by another instance
+			continue
+		}
+
+		s.remind(ctx, abandoned)
+	}
+
+	return nil
+}
+
+// remind sends the owner of an abandoned cart a reminder with a link back to the cart.
+// Reminders are marketing messages, so they only reach users who opted in to email.
+func (s *cartService) remind(ctx context.Context, abandoned *domain.AbandonedCart) {
+	log := logger.GetLoggerFromContext(ctx).WithComponent("carts").WithFields(logger.Fields{
+		"abandoned_cart_id": abandoned.ID,
+	})
+
+	recoveryURL := s.recoveryURL
+	if storefront := tenant.FromContext(ctx); storefront != nil {
+		recoveryURL = storefront.CartRecoveryURL
+	}
+	link, err := url.Parse(recoveryURL)
+	if err != nil {
+		log.WithError(err).Error("Failed to parse cart recovery url")
+		return
+	}
+	query := link.Query()
+	query.Set("abandoned_cart", strconv.Itoa(abandoned.ID))
+	link.RawQuery = query.Encode()
+
+	units := 0
+	for _, item := range abandoned.Items {
+		units += item.Quantity
+	}
+
+	sent, err := s.notifications.Notify(ctx, abandoned.UserID, domain.Notification{
+		Kind:    domain.NotificationMarketing,
+		Channel: domain.NotificationChannelEmail,
+		Subject: "You left items in your cart",
+		Body: fmt.Sprintf(
+			"You still have %d item(s) worth %s %s waiting in your cart.\n\n"+
+				"Pick up where you left off:\n\n%s\n",
+			units, abandoned.Value, abandoned.Value.Currency, link.String(),
+		),
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to send cart reminder")
+		return
+	}
+	if !sent {
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := s.cartRepo.SetReminded(ctx, abandoned.ID, now); err != nil {
+		log.WithError(err).Error("Failed to record cart reminder")
+		return
+	}
+	abandoned.RemindedAt = &now
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// categoryTreeTTL bounds how stale product counts can get when products change on another instance
+const categoryTreeTTL = time.Minute
+
+// categoryTree holds the category hierarchy with active product counts rolled up to every ancestor
+type categoryTree struct {
+	categories map[int]*domain.Category
+	counts     map[int]int64
+}
+
+// categoryTreeCache caches the category tree of each tenant until it expires or is invalidated
+type categoryTreeCache struct {
+	mu         sync.Mutex
+	trees      map[string]*cachedCategoryTree
+	generation uint64
+}
+
+type cachedCategoryTree struct {
+	tree      *categoryTree
+	expiresAt time.Time
+}
+
+// get returns the cached tree of the tenant of ctx, building it when missing or expired. When
+// the tree can't be built, as while the database is unavailable, the last one is served stale.
+func (c *categoryTreeCache) get(ctx context.Context, productRepo repository.ProductRepository) (*categoryTree, error) {
+	tenantID := tenant.ID(ctx)
+
+	c.mu.Lock()
+	cached := c.trees[tenantID]
+	if cached != nil && time.Now().Before(cached.expiresAt) {
+		c.mu.Unlock()
+		return cached.tree, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	tree, err := buildCategoryTree(ctx, productRepo)
+	if err != nil {
+		if cached != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("categories").WithError(err).Warn("Serving stale category tree")
+			return cached.tree, nil
+		}
+		return nil, err
+	}
+
+	// A tree built while the cache was invalidated may already be stale, so it is not stored
+	c.mu.Lock()
+	if c.generation == generation {
+		if c.trees == nil {
+			c.trees = make(map[string]*cachedCategoryTree)
+		}
+		c.trees[tenantID] = &cachedCategoryTree{tree: tree, expiresAt: time.Now().Add(categoryTreeTTL)}
+	}
+	c.mu.Unlock()
+
+	return tree, nil
+}
+
+// invalidate expires the cached trees so the next reads rebuild them. They are kept to serve
+// stale if that fails.
+func (c *categoryTreeCache) invalidate() {
+	c.mu.Lock()
+	for _, cached := range c.trees {
+		cached.expiresAt = time.Time{}
+	}
+	c.generation++
+	c.mu.Unlock()
+}
+
+func buildCategoryTree(ctx context.Context, productRepo repository.ProductRepository) (*categoryTree, error) {
+	categories, err := productRepo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	direct, err := productRepo.CountActiveProductsByCategory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &categoryTree{
+		categories: make(map[int]*domain.Category, len(categories)),
+		counts:     make(map[int]int64, len(categories)),
+	}
+	for _, category := range categories {
+		tree.categories[category.ID] = category
+	}
+
+	// Add each category's own products to itself and all of its ancestors
+	for _, category := range categories {
+		count := direct[category.ID]
+		if count == 0 {
+			continue
+		}
+		tree.walkUp(category.ID, func(ancestor *domain.Category) {
+			tree.counts[ancestor.ID] += count
+		})
+	}
+
+	return tree, nil
+}
+
+// walkUp calls fn for the category and each of its ancestors, nearest first. The walk is
+// bounded by the number of categories so a corrupted parent cycle cannot loop forever.
+func (t *categoryTree) walkUp(id int, fn func(*domain.Category)) {
+	for depth := 0; depth < len(t.categories); depth++ {
+		category, ok := t.categories[id]
+		if !ok {
+			return
+		}
+		fn(category)
+		if category.ParentID == nil {
+			return
+		}
+		id = *category.ParentID
+	}
+}
+
+// decorate sets the product count and breadcrumb path of a category. The breadcrumbs run
+// from the root down to the category itself.
+func (t *categoryTree) decorate(category *domain.Category) {
+	count := t.counts[category.ID]
+	category.ProductCount = &count
+
+	var path []domain.CategoryRef
+	if _, ok := t.categories[category.ID]; ok {
+		t.walkUp(category.ID, func(ancestor *domain.Category) {
+			path = append(path, domain.CategoryRef{ID: ancestor.ID, Name: ancestor.Name})
+		})
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		// The category itself may have been renamed since the tree was cached
+		path[len(path)-1].Name = category.Name
+	} else {
+		path = []domain.CategoryRef{{ID: category.ID, Name: category.Name}}
+	}
+	category.Breadcrumbs = path
+}
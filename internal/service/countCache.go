@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const (
+	// countEstimateTTL bounds how long a counted total is reused as an estimate
+	countEstimateTTL = time.Minute
+
+	// maxCachedCounts bounds the filters with a cached total, since every search query is one
+	maxCachedCounts = 10000
+)
+
+// countCache keeps the totals of product filters with many matches, so later pages of the same
+// listing don't count them again
+type countCache struct {
+	mu     sync.Mutex
+	totals map[string]*cachedCount
+}
+
+type cachedCount struct {
+	total     int64
+	expiresAt time.Time
+}
+
+// countKey identifies what the filter matches in the tenant of ctx, regardless of the page,
+// order and fields
+func countKey(ctx context.Context, filter domain.ProductFilter) string {
+	filter.Limit, filter.Offset, filter.Sort, filter.Fields, filter.SkipCount = 0, 0, nil, nil, false
+	key, err := json.Marshal(filter)
+	if err != nil {
+		return ""
+	}
+	return tenant.ID(ctx) + "|" + string(key)
+}
+
+// get returns the cached total of the filter, if it hasn't expired
+func (c *countCache) get(ctx context.Context, filter domain.ProductFilter) (int64, bool) {
+	key := countKey(ctx, filter)
+	if key == "" {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := c.totals[key]
+	if cached == nil || !time.Now().Before(cached.expiresAt) {
+		return 0, false
+	}
+	return cached.total, true
+}
+
+// put caches the total of the filter. When the cache is full, expired totals are dropped, and
+// if none were, the new total isn't kept.
+func (c *countCache) put(ctx context.Context, filter domain.ProductFilter, total int64) {
+	key := countKey(ctx, filter)
+	if key == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.totals == nil {
+		c.totals = make(map[string]*cachedCount)
+	}
+	now := time.Now()
+	if len(c.totals) >= maxCachedCounts {
+		for other, cached := range c.totals {
+			if !now.Before(cached.expiresAt) {
+				delete(c.totals, other)
+			}
+		}
+		if len(c.totals) >= maxCachedCounts {
+			return
+		}
+	}
+	c.totals[key] = &cachedCount{total: total, expiresAt: now.Add(countEstimateTTL)}
+}
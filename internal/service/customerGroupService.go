@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// CustomerGroupService manages customer groups, which price the catalog for their users
+type CustomerGroupService interface {
+	CreateGroup(ctx context.Context, group *domain.CustomerGroup) error
+	GetGroup(ctx context.Context, id int) (*domain.CustomerGroup, error)
+	ListGroups(ctx context.Context) ([]*domain.CustomerGroup, error)
+	UpdateGroup(ctx context.Context, group *domain.CustomerGroup) error
+	DeleteGroup(ctx context.Context, id int) error
+
+	// AssignUser assigns a user to a group, or back to list prices with a nil groupID
+	AssignUser(ctx context.Context, userID int, groupID *int) (*domain.User, error)
+
+	// PriceProducts sets the price of the products to the one the user's group pays, keeping
+	// the list price in ListPrice where they differ. Users without a group are left untouched.
+	PriceProducts(ctx context.Context, userID int, products []*domain.ProductWithCategory) error
+}
+
+type customerGroupService struct {
+	groupRepo   repository.CustomerGroupRepository
+	productRepo repository.ProductRepository
+}
+
+func NewCustomerGroupService(groupRepo repository.CustomerGroupRepository, productRepo repository.ProductRepository) CustomerGroupService {
+	return &customerGroupService{groupRepo: groupRepo, productRepo: productRepo}
+}
+
+// CreateGroup creates a customer group with a unique name
+func (s *customerGroupService) CreateGroup(ctx context.Context, group *domain.CustomerGroup) error {
+	if err := group.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkMargins(ctx, group); err != nil {
+		return err
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		if err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("create customer group: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroup retrieves a customer group by ID
+func (s *customerGroupService) GetGroup(ctx context.Context, id int) (*domain.CustomerGroup, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get customer group: %w", err)
+	}
+
+	return group, nil
+}
+
+// ListGroups retrieves all customer groups
+func (s *customerGroupService) ListGroups(ctx context.Context) ([]*domain.CustomerGroup, error) {
+	groups, err := s.groupRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list customer groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// UpdateGroup replaces a customer group's name, description and pricing. Its users pay the new
+// prices from their next request.
+func (s *customerGroupService) UpdateGroup(ctx context.Context, group *domain.CustomerGroup) error {
+	if err := group.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.GetGroup(ctx, group.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkMargins(ctx, group); err != nil {
+		return err
+	}
+
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		if err == domain.ErrNotFound || err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("update customer group: %w", err)
+	}
+
+	group.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+// DeleteGroup deletes a customer group; its users go back to list prices
+func (s *customerGroupService) DeleteGroup(ctx context.Context, id int) error {
+	if err := s.groupRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrNotFound
+		}
+		return fmt.Errorf("delete customer group: %w", err)
+	}
+
+	return nil
+}
+
+// AssignUser checks that the group exists before assigning the user to it
+func (s *customerGroupService) AssignUser(ctx context.Context, userID int, groupID *int) (*domain.User, error) {
+	if groupID != nil {
+		if _, err := s.GetGroup(ctx, *groupID); err != nil {
+			if err == domain.ErrNotFound {
+				return nil, fmt.Errorf("customer group %d not found: %w", *groupID, domain.ErrValidation)
+			}
+			return nil, err
+		}
+	}
+
+	user, err := s.groupRepo.AssignUser(ctx, userID, groupID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("assign customer group: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *customerGroupService) PriceProducts(ctx context.Context, userID int, products []*domain.ProductWithCategory) error {
+	group, err := s.groupRepo.GetUserGroup(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get customer group: %w", err)
+	}
+	if group == nil {
+		return nil
+	}
+
+	for _, product := range products {
+		price := group.Price(product.ID, product.CategoryID, product.Price)
+		if price == product.Price {
+			continue
+		}
+		listPrice := product.Price
+		product.ListPrice = &listPrice
+		product.Price = price
+	}
+
+	return nil
+}
+
+// checkMargins checks the group's price of every product against its cost price, as product
+// prices are when they are saved
+func (s *customerGroupService) checkMargins(ctx context.Context, group *domain.CustomerGroup) error {
+	if group.AllowBelowCost {
+		return nil
+	}
+
+	err := s.productRepo.StreamWithCategories(ctx, domain.ProductFilter{}, group.CheckMargin)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			return err
+		}
+		return fmt.Errorf("check customer group margins: %w", err)
+	}
+
+	return nil
+}
+
+// applyGroupPrices sets the price of the products to the one the user's group pays, so purchases
+// are recorded at it. nil products are skipped and users without a group pay list prices.
+func applyGroupPrices(ctx context.Context, groupRepo repository.CustomerGroupRepository, userID int, products ...*domain.Product) error {
+	group, err := groupRepo.GetUserGroup(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get customer group: %w", err)
+	}
+	if group == nil {
+		return nil
+	}
+
+	for _, product := range products {
+		if product != nil {
+			product.Price = group.Price(product.ID, product.CategoryID, product.Price)
+		}
+	}
+
+	return nil
+}
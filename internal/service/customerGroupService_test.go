@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/repository/memory"
+)
+
+// savedGroups stores the groups it is given in memory; the other methods are not used
+type savedGroups struct {
+	repository.CustomerGroupRepository
+	groups []*domain.CustomerGroup
+}
+
+func (r *savedGroups) Create(_ context.Context, group *domain.CustomerGroup) error {
+	r.groups = append(r.groups, group)
+	return nil
+}
+
+func TestCreateGroupChecksMargins(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	electronics := 1
+
+	store := memory.NewStore()
+	store.Load(&memory.Snapshot{
+		Categories: []domain.Category{{ID: 1, Name: "Electronics", CreatedAt: created, UpdatedAt: created}},
+		Products: []domain.Product{
+			// 20% margin
+			{ID: 1, Name: "Laptop", CategoryID: &electronics, Price: domain.NewMoney(100000, "USD"), CostPrice: domain.NewMoney(80000, "USD"), CreatedAt: created, UpdatedAt: created},
+			// Already sold at a loss
+			{ID: 2, Name: "Cable", Price: domain.NewMoney(500, "USD"), CostPrice: domain.NewMoney(600, "USD"), CreatedAt: created, UpdatedAt: created},
+			// No cost price
+			{ID: 3, Name: "Book", Price: domain.NewMoney(2000, "USD"), CreatedAt: created, UpdatedAt: created},
+		},
+	})
+	laptop, book := 1, 3
+	price := domain.NewMoney(79999, "USD")
+
+	tests := []struct {
+		name  string
+		group domain.CustomerGroup
+		err   error
+	}{
+		{"discount within the margin", domain.CustomerGroup{Rules: []domain.GroupPriceRule{{CategoryID: &electronics, PercentOff: 20}}}, nil},
+		{"category discount below cost", domain.CustomerGroup{Rules: []domain.GroupPriceRule{{CategoryID: &electronics, PercentOff: 21}}}, domain.ErrValidation},
+		{"discount on a product sold at a loss", domain.CustomerGroup{PercentOff: 1}, domain.ErrValidation},
+		{"product price below cost", domain.CustomerGroup{Rules: []domain.GroupPriceRule{{ProductID: &laptop, Price: &price}}}, domain.ErrValidation},
+		{"product without cost price", domain.CustomerGroup{Rules: []domain.GroupPriceRule{{ProductID: &book, PercentOff: 100}}}, nil},
+		{"allowed below cost", domain.CustomerGroup{PercentOff: 50, AllowBelowCost: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groupRepo := &savedGroups{}
+			groups := NewCustomerGroupService(groupRepo, store.Repositories().Product)
+
+			group := tt.group
+			group.Name = "wholesale"
+			err := groups.CreateGroup(ctx, &group)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("create group: err = %v, want %v", err, tt.err)
+			}
+			if saved := len(groupRepo.groups) == 1; saved != (tt.err == nil) {
+				t.Errorf("group saved = %v, want %v", saved, tt.err == nil)
+			}
+		})
+	}
+}
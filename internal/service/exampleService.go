@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
 )
 
 type Example interface {
@@ -12,6 +13,9 @@ type Example interface {
 
 type Health interface {
 	Ping(ctx context.Context) error
+
+	// Circuit returns the state of the database circuit breaker
+	Circuit() mongodb.Circuit
 }
 
 type ExampleServiceDeps struct {
@@ -39,3 +43,7 @@ func NewHealthService(repo repository.Health) *HealthServiceDeps {
 func (s *HealthServiceDeps) Ping(ctx context.Context) error {
 	return s.repo.Ping(ctx)
 }
+
+func (s *HealthServiceDeps) Circuit() mongodb.Circuit {
+	return s.repo.Circuit()
+}
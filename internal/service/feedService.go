@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// FeedService assembles the personalized home feed, so the home screen needs a single request
+type FeedService interface {
+	// GetFeed returns the user's feed. Sections that fail to load are logged and left out, so
+	// one slow or broken section doesn't take down the home screen.
+	GetFeed(ctx context.Context, userID int) (*domain.Feed, error)
+}
+
+type feedService struct {
+	feedRepo          repository.FeedRepository
+	productRepo       repository.ProductRepository
+	profileRepo       repository.ProfileRepository
+	recommendations   RecommendationService
+	sectionSize       int
+	trendingWindow    time.Duration
+	newProductsWindow time.Duration
+	priceDropWindow   time.Duration
+}
+
+func NewFeedService(
+	feedRepo repository.FeedRepository,
+	productRepo repository.ProductRepository,
+	profileRepo repository.ProfileRepository,
+	recommendations RecommendationService,
+	cfg *config.Config,
+) (FeedService, error) {
+	trendingWindow, err := time.ParseDuration(cfg.Feed.TrendingWindow)
+	if err != nil {
+		return nil, fmt.Errorf("parse feed trending window: %w", err)
+	}
+
+	newProductsWindow, err := time.ParseDuration(cfg.Feed.NewProductsWindow)
+	if err != nil {
+		return nil, fmt.Errorf("parse feed new products window: %w", err)
+	}
+
+	priceDropWindow, err := time.ParseDuration(cfg.Feed.PriceDropWindow)
+	if err != nil {
+		return nil, fmt.Errorf("parse feed price drop window: %w", err)
+	}
+
+	return &feedService{
+		feedRepo:          feedRepo,
+		productRepo:       productRepo,
+		profileRepo:       profileRepo,
+		recommendations:   recommendations,
+		sectionSize:       cfg.Feed.SectionSize,
+		trendingWindow:    trendingWindow,
+		newProductsWindow: newProductsWindow,
+		priceDropWindow:   priceDropWindow,
+	}, nil
+}
+
+// feedSection builds one section of the feed, or returns nil to leave it out
+type feedSection func(ctx context.Context, userID int, now time.Time) (*domain.FeedSection, error)
+
+func (s *feedService) GetFeed(ctx context.Context, userID int) (*domain.Feed, error) {
+	now := time.Now().UTC()
+
+	builders := []struct {
+		kind  string
+		build feedSection
+	}{
+		{domain.FeedSectionRecommended, s.recommended},
+		{domain.FeedSectionTrending, s.trending},
+		{domain.FeedSectionNewInFavorites, s.newInFavorites},
+		{domain.FeedSectionPriceDrops, s.priceDrops},
+	}
+
+	// The sections are independent, so they load at the same time
+	sections := make([]*domain.FeedSection, len(builders))
+	var wg sync.WaitGroup
+	for i, builder := range builders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			section, err := builder.build(ctx, userID, now)
+			if err != nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("feed").WithError(err).WithFields(logger.Fields{
+					"user_id": userID,
+					"section": builder.kind,
+				}).Warn("Failed to build feed section")
+				return
+			}
+			sections[i] = section
+		}()
+	}
+	wg.Wait()
+
+	feed := &domain.Feed{
+		UserID:      userID,
+		Sections:    make([]domain.FeedSection, 0, len(sections)),
+		GeneratedAt: now,
+	}
+	for _, section := range sections {
+		if section != nil && len(section.Items) > 0 {
+			section.Metadata.Count = len(section.Items)
+			feed.Sections = append(feed.Sections, *section)
+		}
+	}
+
+	return feed, nil
+}
+
+// recommended is the user's personalized recommendations
+func (s *feedService) recommended(ctx context.Context, userID int, now time.Time) (*domain.FeedSection, error) {
+	resp, err := s.recommendations.GetRecommendations(ctx, userID, s.sectionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	section := &domain.FeedSection{
+		Type:     domain.FeedSectionRecommended,
+		Title:    "Recommended for you",
+		Items:    make([]domain.FeedItem, 0, len(resp.Recommendations)),
+		Metadata: domain.FeedSectionMetadata{Algorithm: resp.Algorithm},
+	}
+	for _, recommendation := range resp.Recommendations {
+		product, err := s.activeProduct(ctx, recommendation.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			continue
+		}
+		section.Items = append(section.Items, domain.FeedItem{
+			Product: product,
+			Score:   recommendation.Score,
+			Reason:  recommendation.Reason,
+		})
+	}
+
+	return section, nil
+}
+
+// trending is the products with the most weighted interactions over the trending window
+func (s *feedService) trending(ctx context.Context, userID int, now time.Time) (*domain.FeedSection, error) {
+	since := now.Add(-s.trendingWindow)
+
+	// Some of the top products may have been deactivated since
+	trending, err := s.feedRepo.Trending(ctx, since, s.sectionSize*candidateFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	section := &domain.FeedSection{
+		Type:     domain.FeedSectionTrending,
+		Title:    "Trending now",
+		Items:    make([]domain.FeedItem, 0, s.sectionSize),
+		Metadata: domain.FeedSectionMetadata{Since: &since},
+	}
+	for _, entry := range trending {
+		if len(section.Items) == s.sectionSize {
+			break
+		}
+
+		product, err := s.activeProduct(ctx, entry.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			continue
+		}
+		section.Items = append(section.Items, domain.FeedItem{Product: product, Score: entry.Score})
+	}
+
+	return section, nil
+}
+
+// newInFavorites is the products recently added to the user's favorite categories
+func (s *feedService) newInFavorites(ctx context.Context, userID int, now time.Time) (*domain.FeedSection, error) {
+	preferences, err := loadPreferences(ctx, s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(preferences.FavoriteCategoryIDs) == 0 {
+		return nil, nil
+	}
+
+	since := now.Add(-s.newProductsWindow)
+	products, err := s.feedRepo.NewProducts(ctx, preferences.FavoriteCategoryIDs, since, s.sectionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	section := &domain.FeedSection{
+		Type:  domain.FeedSectionNewInFavorites,
+		Title: "New in your favorite categories",
+		Items: make([]domain.FeedItem, 0, len(products)),
+		Metadata: domain.FeedSectionMetadata{
+			Since:       &since,
+			CategoryIDs: preferences.FavoriteCategoryIDs,
+		},
+	}
+	for _, product := range products {
+		section.Items = append(section.Items, domain.FeedItem{Product: product})
+	}
+
+	return section, nil
+}
+
+// priceDrops is the products the user likes whose price recently went down
+func (s *feedService) priceDrops(ctx context.Context, userID int, now time.Time) (*domain.FeedSection, error) {
+	since := now.Add(-s.priceDropWindow)
+	products, err := s.feedRepo.LikedPriceDrops(ctx, userID, since, s.sectionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	section := &domain.FeedSection{
+		Type:     domain.FeedSectionPriceDrops,
+		Title:    "Price drops on items you like",
+		Items:    make([]domain.FeedItem, 0, len(products)),
+		Metadata: domain.FeedSectionMetadata{Since: &since},
+	}
+	for _, product := range products {
+		section.Items = append(section.Items, domain.FeedItem{Product: product, PriceDrop: product.PriceDrop()})
+	}
+
+	return section, nil
+}
+
+// activeProduct returns the product, or nil if it was deleted or deactivated
+func (s *feedService) activeProduct(ctx context.Context, id int) (*domain.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+	if !product.IsActive {
+		return nil, nil
+	}
+	return product, nil
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// FlashSaleService runs flash sales: a limited quantity of a product sold at a lower price
+// from a start time, to buyers admitted from a waiting room in the order they joined it
+type FlashSaleService interface {
+	// SetSale starts or replaces the product's flash sale, with all its units left
+	SetSale(ctx context.Context, productID int, sale *domain.FlashSale) (*domain.Product, error)
+
+	// ClearSale ends the product's flash sale; its unsold units go back to regular sale
+	ClearSale(ctx context.Context, productID int) error
+
+	// JoinQueue places the user in the waiting room of the product's flash sale. The ticket's
+	// token can purchase once its position is admitted.
+	JoinQueue(ctx context.Context, userID, productID int) (*domain.QueueTicket, error)
+
+	// Purchase buys quantity units at the sale price with an admitted queue token, which buys
+	// once. It returns a *domain.QueueWaitError before the token is admitted,
+	// domain.ErrInvalidSignature for a tampered, expired, used or someone else's token and
+	// domain.ErrSoldOut once the units are gone.
+	Purchase(ctx context.Context, userID, productID, quantity int, token string, client domain.ClientInfo) error
+}
+
+type flashSaleService struct {
+	flashSaleRepo   repository.FlashSaleRepository
+	productRepo     repository.ProductRepository
+	stockRepo       repository.StockRepository
+	interactionRepo repository.InteractionRepository
+	limitRepo       repository.PurchaseLimitRepository
+	stockFeed       StockFeed
+	risk            RiskService
+	secret          []byte
+	admitPerSecond  int
+	tokenTTL        time.Duration
+}
+
+func NewFlashSaleService(
+	flashSaleRepo repository.FlashSaleRepository,
+	productRepo repository.ProductRepository,
+	stockRepo repository.StockRepository,
+	interactionRepo repository.InteractionRepository,
+	limitRepo repository.PurchaseLimitRepository,
+	stockFeed StockFeed,
+	risk RiskService,
+	cfg *config.Config,
+) (FlashSaleService, error) {
+	tokenTTL, err := time.ParseDuration(cfg.FlashSales.TokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse flash sale token ttl: %w", err)
+	}
+
+	// Without a configured secret tokens only verify on this instance until it restarts
+	secret := []byte(cfg.FlashSales.SigningSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate flash sale signing secret: %w", err)
+		}
+	}
+
+	return &flashSaleService{
+		flashSaleRepo:   flashSaleRepo,
+		productRepo:     productRepo,
+		stockRepo:       stockRepo,
+		interactionRepo: interactionRepo,
+		limitRepo:       limitRepo,
+		stockFeed:       stockFeed,
+		risk:            risk,
+		secret:          secret,
+		admitPerSecond:  cfg.FlashSales.AdmitPerSecond,
+		tokenTTL:        tokenTTL,
+	}, nil
+}
+
+// SetSale checks the sale against the product's price and unallocated stock before setting it
+func (s *flashSaleService) SetSale(ctx context.Context, productID int, sale *domain.FlashSale) (*domain.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+
+	locations, err := s.stockRepo.GetLocations(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("get stock locations: %w", err)
+	}
+	unallocated := product.Stock
+	for _, location := range locations {
+		unallocated -= location.Quantity
+	}
+
+	sale.StartsAt = sale.StartsAt.UTC().Truncate(time.Millisecond)
+	if err := sale.Validate(product, unallocated); err != nil {
+		return nil, err
+	}
+
+	product, err = s.flashSaleRepo.Set(ctx, productID, sale)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("set flash sale: %w", err)
+	}
+
+	return product, nil
+}
+
+func (s *flashSaleService) ClearSale(ctx context.Context, productID int) error {
+	if err := s.flashSaleRepo.Clear(ctx, productID); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("clear flash sale: %w", err)
+	}
+
+	return nil
+}
+
+// JoinQueue hands out positions in join order, one per user: joining again returns the
+// user's position, so it can't be used to collect more tokens. Only once the position's token
+// has expired does joining again move the user to the back.
+func (s *flashSaleService) JoinQueue(ctx context.Context, userID, productID int) (*domain.QueueTicket, error) {
+	now := time.Now()
+	sale, err := s.getSale(ctx, productID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := s.flashSaleRepo.Join(ctx, productID, sale.StartsAt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("join queue: %w", err)
+	}
+
+	admitAt := sale.AdmitAt(position, s.admitPerSecond)
+	if !now.Before(admitAt.Add(s.tokenTTL)) {
+		position, err = s.flashSaleRepo.Requeue(ctx, productID, sale.StartsAt, userID, position)
+		if err != nil {
+			return nil, fmt.Errorf("requeue: %w", err)
+		}
+		admitAt = sale.AdmitAt(position, s.admitPerSecond)
+	}
+
+	return &domain.QueueTicket{
+		ProductID: productID,
+		Position:  position,
+		Token:     s.signToken(ctx, userID, productID, position, sale.StartsAt),
+		AdmitAt:   admitAt,
+		ExpiresAt: admitAt.Add(s.tokenTTL),
+	}, nil
+}
+
+// Purchase takes the units from the sale and the stock in one conditional update, which is all
+// concurrent buyers contend on. The token's queue position is used up and the purchase limit
+// counted before; both are given back, with the units, if the purchase fails.
+func (s *flashSaleService) Purchase(ctx context.Context, userID, productID, quantity int, token string, client domain.ClientInfo) error {
+	now := time.Now()
+	sale, err := s.getSale(ctx, productID, now)
+	if err != nil {
+		return err
+	}
+
+	position, err := s.verifyToken(ctx, token, userID, productID, sale.StartsAt)
+	if err != nil {
+		return err
+	}
+	admitAt := sale.AdmitAt(position, s.admitPerSecond)
+	if now.Before(admitAt) {
+		return &domain.QueueWaitError{AdmitAt: admitAt}
+	}
+	if !now.Before(admitAt.Add(s.tokenTTL)) {
+		return fmt.Errorf("queue token expired, join the queue again: %w", domain.ErrInvalidSignature)
+	}
+
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("get product: %w", err)
+	}
+	if err := product.CheckQuantity(quantity); err != nil {
+		return err
+	}
+
+	// Each admitted position buys once, so the first buyers can't drain the sale
+	used, err := s.flashSaleRepo.UseTicket(ctx, productID, sale.StartsAt, position, userID, admitAt.Add(s.tokenTTL))
+	if err != nil {
+		return fmt.Errorf("use queue ticket: %w", err)
+	}
+	if !used {
+		return fmt.Errorf("queue token already used, join the queue again: %w", domain.ErrInvalidSignature)
+	}
+	releaseTicket := func() {
+		if err := s.flashSaleRepo.ReleaseTicket(ctx, productID, sale.StartsAt, position); err != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("flash_sale").WithError(err).Error("Failed to release flash sale queue ticket", "product_id", productID)
+		}
+	}
+
+	releaseLimit, err := consumePurchaseLimit(ctx, s.limitRepo, product, userID, quantity)
+	if err != nil {
+		releaseTicket()
+		return err
+	}
+
+	updated, err := s.flashSaleRepo.Take(ctx, productID, quantity, sale.StartsAt, now.UTC())
+	if err != nil {
+		releaseLimit()
+		releaseTicket()
+		return err
+	}
+
+	adjustment := &domain.StockAdjustment{
+		ProductID:  productID,
+		Delta:      -quantity,
+		Reason:     domain.StockReasonPurchase,
+		Note:       "flash sale",
+		ActorID:    userID,
+		StockAfter: updated.Stock,
+	}
+	if err := s.stockRepo.Record(ctx, adjustment); err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("flash_sale").WithError(err).Error("Failed to record flash sale stock adjustment", "product_id", productID)
+	}
+
+	if err := s.interactionRepo.RecordPurchase(ctx, userID, productID, quantity, sale.Price, product.CostPrice); err != nil {
+		releaseLimit()
+		releaseTicket()
+		if returned, returnErr := s.flashSaleRepo.Return(ctx, productID, quantity, sale.StartsAt); returnErr == nil {
+			_ = s.stockRepo.Record(ctx, &domain.StockAdjustment{
+				ProductID:  productID,
+				Delta:      quantity,
+				Reason:     domain.StockReasonCorrection,
+				Note:       "flash sale purchase failed",
+				ActorID:    userID,
+				StockAfter: returned.Stock,
+			})
+			s.stockFeed.Publish(returned)
+		}
+		return fmt.Errorf("record purchase: %w", err)
+	}
+
+	s.stockFeed.Publish(updated)
+
+	screenPurchase(ctx, s.risk, domain.RiskCheck{
+		UserID:   userID,
+		Source:   domain.PurchaseSourceFlashSale,
+		SourceID: productID,
+		Lines:    []domain.PurchaseLine{{ProductID: productID, Quantity: quantity, Price: sale.Price}},
+		Client:   client,
+	})
+	return nil
+}
+
+// getSale returns the product's flash sale if it can still be bought from: it hasn't ended
+// and has units left
+func (s *flashSaleService) getSale(ctx context.Context, productID int, now time.Time) (*domain.FlashSale, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+
+	sale := product.FlashSale
+	switch {
+	case sale == nil:
+		return nil, fmt.Errorf("product %d has no flash sale: %w", productID, domain.ErrNotFound)
+	case sale.Ended(now):
+		return nil, fmt.Errorf("the flash sale of product %d has ended: %w", productID, domain.ErrSoldOut)
+	case sale.Remaining <= 0:
+		return nil, fmt.Errorf("the flash sale of product %d is sold out: %w", productID, domain.ErrSoldOut)
+	}
+	return sale, nil
+}
+
+// signToken returns a token of the user's position in the sale's queue: the position and an
+// HMAC-SHA256 of it with the tenant, user, product and sale, so it can't be moved forward or
+// used by someone else
+func (s *flashSaleService) signToken(ctx context.Context, userID, productID, position int, startsAt time.Time) string {
+	return strconv.Itoa(position) + "." + s.sign(ctx, userID, productID, position, startsAt)
+}
+
+// verifyToken returns the queue position of a token signed for the user and sale
+func (s *flashSaleService) verifyToken(ctx context.Context, token string, userID, productID int, startsAt time.Time) (int, error) {
+	positionText, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, domain.ErrInvalidSignature
+	}
+	position, err := strconv.Atoi(positionText)
+	if err != nil || position < 1 {
+		return 0, domain.ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(ctx, userID, productID, position, startsAt))) {
+		return 0, domain.ErrInvalidSignature
+	}
+	return position, nil
+}
+
+func (s *flashSaleService) sign(ctx context.Context, userID, productID, position int, startsAt time.Time) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d\n%d\n%d\n%d", tenant.ID(ctx), userID, productID, startsAt.UnixMilli(), position)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// errFlashSaleActive rejects a regular purchase of a product whose flash sale is on; it can
+// only be bought through the sale's waiting room until the sale ends or sells out
+func errFlashSaleActive(productID int) error {
+	return fmt.Errorf("product %d is on flash sale, join its queue to buy it: %w", productID, domain.ErrValidation)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+const (
+	globalSearchDefaultLimit = 5
+	globalSearchMaxLimit     = 20
+
+	// globalSearchCandidates is how many users per result are matched before ranking, since
+	// the user list is ordered newest first rather than by relevance
+	globalSearchCandidates = 4
+)
+
+// GlobalSearchService searches products, categories and, for admins, users and orders at once,
+// for a single search box in the storefront and admin UI
+type GlobalSearchService interface {
+	Search(ctx context.Context, query domain.GlobalSearchQuery) ([]domain.GlobalSearchGroup, error)
+}
+
+type globalSearchService struct {
+	search      SearchService
+	productRepo repository.ProductRepository
+	userRepo    repository.UserRepository
+	orderRepo   repository.OrderRepository
+}
+
+func NewGlobalSearchService(search SearchService, productRepo repository.ProductRepository, userRepo repository.UserRepository, orderRepo repository.OrderRepository) GlobalSearchService {
+	return &globalSearchService{
+		search:      search,
+		productRepo: productRepo,
+		userRepo:    userRepo,
+		orderRepo:   orderRepo,
+	}
+}
+
+// Search returns a group of the best matches of each requested kind, in the order requested
+func (s *globalSearchService) Search(ctx context.Context, query domain.GlobalSearchQuery) ([]domain.GlobalSearchGroup, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, fmt.Errorf("search query is required: %w", domain.ErrValidation)
+	}
+	if query.Limit <= 0 {
+		query.Limit = globalSearchDefaultLimit
+	}
+	query.Limit = min(query.Limit, globalSearchMaxLimit)
+
+	types := query.Types
+	if len(types) == 0 {
+		types = []string{domain.GlobalSearchProducts, domain.GlobalSearchCategories}
+		if query.Admin {
+			types = append(types, domain.GlobalSearchUsers)
+		}
+		if query.Orders {
+			types = append(types, domain.GlobalSearchOrders)
+		}
+	}
+
+	groups := make([]domain.GlobalSearchGroup, 0, len(types))
+	seen := make(map[string]bool)
+	for _, kind := range types {
+		if seen[kind] {
+			continue
+		}
+		seen[kind] = true
+
+		var group domain.GlobalSearchGroup
+		var err error
+		switch {
+		case kind == domain.GlobalSearchProducts:
+			group, err = s.searchProducts(ctx, query)
+		case kind == domain.GlobalSearchCategories:
+			group, err = s.searchCategories(ctx, query)
+		case kind == domain.GlobalSearchUsers && query.Admin:
+			group, err = s.searchUsers(ctx, query)
+		case kind == domain.GlobalSearchOrders && query.Orders:
+			group, err = s.searchOrders(ctx, query)
+		default:
+			return nil, fmt.Errorf("cannot search %q: %w", kind, domain.ErrValidation)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("search %s: %w", kind, err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// searchProducts ranks active products with the catalog search backend. The Mongo backend
+// has no scores, so its hits are scored by how well their name matches; hits the text index
+// found by a stem or the description alone score 0.2.
+func (s *globalSearchService) searchProducts(ctx context.Context, query domain.GlobalSearchQuery) (domain.GlobalSearchGroup, error) {
+	result, err := s.search.Search(ctx, domain.SearchQuery{Query: query.Query, Limit: query.Limit})
+	if err != nil {
+		return domain.GlobalSearchGroup{}, err
+	}
+
+	group := domain.GlobalSearchGroup{Type: domain.GlobalSearchProducts, Total: result.Total, Hits: []domain.GlobalSearchHit{}}
+	for _, hit := range result.Hits {
+		score := hit.Score
+		if score == 0 {
+			score = max(textMatchScore(query.Query, hit.Product.Name), 0.2)
+		}
+		group.Hits = append(group.Hits, domain.GlobalSearchHit{
+			Type:  "product",
+			ID:    hit.Product.ID,
+			Title: hit.Product.Name,
+			Score: score,
+			Item:  hit.Product,
+		})
+	}
+	return group, nil
+}
+
+// searchCategories matches the names and descriptions of all categories
+func (s *globalSearchService) searchCategories(ctx context.Context, query domain.GlobalSearchQuery) (domain.GlobalSearchGroup, error) {
+	categories, err := s.productRepo.ListCategories(ctx)
+	if err != nil {
+		return domain.GlobalSearchGroup{}, err
+	}
+
+	var hits []domain.GlobalSearchHit
+	for _, category := range categories {
+		score := max(textMatchScore(query.Query, category.Name), textMatchScore(query.Query, category.Description)/2)
+		if score == 0 {
+			continue
+		}
+		hits = append(hits, domain.GlobalSearchHit{
+			Type:  "category",
+			ID:    category.ID,
+			Title: category.Name,
+			Score: score,
+			Item:  category,
+		})
+	}
+	return rankedGroup(domain.GlobalSearchCategories, hits, int64(len(hits)), query.Limit), nil
+}
+
+// searchUsers matches emails. The best matches among the newest matching users are returned.
+func (s *globalSearchService) searchUsers(ctx context.Context, query domain.GlobalSearchQuery) (domain.GlobalSearchGroup, error) {
+	users, total, err := s.userRepo.List(ctx, domain.UserFilter{
+		Email: query.Query,
+		Limit: query.Limit * globalSearchCandidates,
+	})
+	if err != nil {
+		return domain.GlobalSearchGroup{}, err
+	}
+
+	hits := make([]domain.GlobalSearchHit, len(users))
+	for i := range users {
+		user := &users[i].User
+		hits[i] = domain.GlobalSearchHit{
+			Type:  "user",
+			ID:    user.ID,
+			Title: user.Email,
+			Score: textMatchScore(query.Query, user.Email),
+			Item:  user,
+		}
+	}
+	return rankedGroup(domain.GlobalSearchUsers, hits, total, query.Limit), nil
+}
+
+// searchOrders finds the order with the event ID searched for, or the newest orders of the user
+// with the ID or email searched for. Other text matches no orders; all hits match exactly.
+func (s *globalSearchService) searchOrders(ctx context.Context, query domain.GlobalSearchQuery) (domain.GlobalSearchGroup, error) {
+	group := domain.GlobalSearchGroup{Type: domain.GlobalSearchOrders, Hits: []domain.GlobalSearchHit{}}
+
+	filter := domain.OrderFilter{Limit: query.Limit}
+	userID, err := strconv.Atoi(query.Query)
+	switch {
+	case isEventID(query.Query):
+		filter.ID = query.Query
+	case err == nil && userID > 0:
+		filter.UserID = &userID
+	case strings.Contains(query.Query, "@"):
+		user, err := s.userRepo.GetByEmail(ctx, query.Query)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return group, nil
+			}
+			return domain.GlobalSearchGroup{}, err
+		}
+		filter.UserID = &user.ID
+	default:
+		return group, nil
+	}
+
+	orders, total, err := s.orderRepo.List(ctx, filter)
+	if err != nil {
+		return domain.GlobalSearchGroup{}, err
+	}
+
+	group.Total = total
+	for _, order := range orders {
+		title := order.ProductName
+		if title == "" {
+			title = fmt.Sprintf("Product %d", order.ProductID)
+		}
+		group.Hits = append(group.Hits, domain.GlobalSearchHit{
+			Type:    "order",
+			OrderID: order.ID,
+			Title:   fmt.Sprintf("%d × %s", order.Quantity, title),
+			Score:   1,
+			Item:    order,
+		})
+	}
+	return group, nil
+}
+
+// isEventID reports whether text is an event ID, the hex ObjectID orders are identified by
+func isEventID(text string) bool {
+	_, err := hex.DecodeString(text)
+	return len(text) == 24 && err == nil
+}
+
+// rankedGroup is the group of the limit best hits, out of total matches
+func rankedGroup(kind string, hits []domain.GlobalSearchHit, total int64, limit int) domain.GlobalSearchGroup {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	if hits == nil {
+		hits = []domain.GlobalSearchHit{}
+	}
+	return domain.GlobalSearchGroup{Type: kind, Total: total, Hits: hits}
+}
+
+// textMatchScore scores how well text matches the query, case-insensitively: 1 if it is the
+// query, 0.8 if it starts with it, 0.6 if a word does, 0.4 if it only contains it and 0 if not
+func textMatchScore(query, text string) float64 {
+	query, text = strings.ToLower(query), strings.ToLower(text)
+	switch {
+	case query == "" || !strings.Contains(text, query):
+		return 0
+	case text == query:
+		return 1
+	case strings.HasPrefix(text, query):
+		return 0.8
+	}
+	for _, word := range strings.FieldsFunc(text, isWordSeparator) {
+		if strings.HasPrefix(word, query) {
+			return 0.6
+		}
+	}
+	return 0.4
+}
+
+func isWordSeparator(r rune) bool {
+	return strings.ContainsRune(" \t\n-_.,;:/@()", r)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/internal/repository/memory"
+)
+
+// storedOrders lists the orders it holds by ID or user; the other methods are not used
+type storedOrders struct {
+	repository.OrderRepository
+	orders []*domain.Order
+}
+
+func (r *storedOrders) List(_ context.Context, filter domain.OrderFilter) ([]*domain.Order, int64, error) {
+	var orders []*domain.Order
+	for _, order := range r.orders {
+		if (filter.ID == "" || order.ID == filter.ID) && (filter.UserID == nil || order.UserID == *filter.UserID) {
+			orders = append(orders, order)
+		}
+	}
+	total := int64(len(orders))
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+	return orders, total, nil
+}
+
+func TestGlobalSearchFindsOrders(t *testing.T) {
+	store := memory.NewStore()
+	store.Load(&memory.Snapshot{
+		Users: []domain.User{{ID: 7, Email: "jane@example.com"}},
+	})
+	orders := &storedOrders{orders: []*domain.Order{
+		{ID: "65a1b2c3d4e5f60718293a4b", UserID: 7, ProductID: 1, ProductName: "Laptop", Quantity: 1},
+		{ID: "65a1b2c3d4e5f60718293a4c", UserID: 7, ProductID: 2, Quantity: 2},
+		{ID: "65a1b2c3d4e5f60718293a4d", UserID: 8, ProductID: 1, ProductName: "Laptop", Quantity: 1},
+	}}
+	search := NewGlobalSearchService(nil, store.Repositories().Product, store.Repositories().User, orders)
+
+	tests := []struct {
+		name   string
+		query  string
+		orders []string
+		titles []string
+		total  int64
+	}{
+		{"by event id", "65a1b2c3d4e5f60718293a4d", []string{"65a1b2c3d4e5f60718293a4d"}, []string{"1 × Laptop"}, 1},
+		{"by user id", "7", []string{"65a1b2c3d4e5f60718293a4b"}, []string{"1 × Laptop"}, 2},
+		{"by user email", "jane@example.com", []string{"65a1b2c3d4e5f60718293a4b"}, []string{"1 × Laptop"}, 2},
+		{"unknown email", "john@example.com", nil, nil, 0},
+		{"other text", "laptop", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := search.Search(context.Background(), domain.GlobalSearchQuery{
+				Query:  tt.query,
+				Types:  []string{domain.GlobalSearchOrders},
+				Limit:  1,
+				Orders: true,
+			})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+
+			group := groups[0]
+			var ids, titles []string
+			for _, hit := range group.Hits {
+				ids = append(ids, hit.OrderID)
+				titles = append(titles, hit.Title)
+			}
+			if group.Total != tt.total || !slices.Equal(ids, tt.orders) || !slices.Equal(titles, tt.titles) {
+				t.Errorf("orders = %v %v (total %d), want %v %v (total %d)", ids, titles, group.Total, tt.orders, tt.titles, tt.total)
+			}
+		})
+	}
+
+	// Orders are only searched for callers allowed to see them
+	if _, err := search.Search(context.Background(), domain.GlobalSearchQuery{Query: "7", Types: []string{domain.GlobalSearchOrders}}); err == nil {
+		t.Error("searching orders without permission: want error")
+	}
+}
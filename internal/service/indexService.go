@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+type IndexService interface {
+	// ListIndexes reports the indexes of each collection, their sizes and how they differ
+	// from the expected ones
+	ListIndexes(ctx context.Context) ([]domain.CollectionIndexes, error)
+
+	// BuildMissingIndexes starts building the missing expected indexes and returns their
+	// collection.index names. Large collections take a while, so the build continues after
+	// the call returns; ListIndexes shows the indexes once they are ready.
+	BuildMissingIndexes(ctx context.Context) ([]string, error)
+}
+
+type indexService struct {
+	indexRepo repository.IndexRepository
+}
+
+func NewIndexService(indexRepo repository.IndexRepository) IndexService {
+	return &indexService{indexRepo: indexRepo}
+}
+
+func (s *indexService) ListIndexes(ctx context.Context) ([]domain.CollectionIndexes, error) {
+	collections, err := s.indexRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+	return collections, nil
+}
+
+func (s *indexService) BuildMissingIndexes(ctx context.Context) ([]string, error) {
+	collections, err := s.indexRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+
+	missing := []string{}
+	for _, collection := range collections {
+		for _, name := range collection.Missing {
+			missing = append(missing, collection.Collection+"."+name)
+		}
+	}
+	if len(missing) == 0 {
+		return missing, nil
+	}
+
+	// The build outlives the request
+	buildCtx := context.WithoutCancel(ctx)
+	go func() {
+		log := logger.GetLoggerFromContext(buildCtx).WithComponent("indexes")
+		built, err := s.indexRepo.BuildMissing(buildCtx)
+		if err != nil {
+			log.WithError(err).WithFields(logger.Fields{"built": built}).Error("Failed to build missing indexes")
+			return
+		}
+		log.WithFields(logger.Fields{"built": built}).Info("Built missing indexes")
+	}()
+
+	return missing, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+type IntegrityService interface {
+	// CheckIntegrity runs every referential integrity check and reports the orphaned documents
+	// each found: documents whose reference points at a document that no longer exists
+	CheckIntegrity(ctx context.Context) ([]domain.IntegrityIssue, error)
+
+	// RepairIntegrity repairs or quarantines the orphaned documents of the checks. Without
+	// checks it applies the action to every check that found orphans and allows it.
+	RepairIntegrity(ctx context.Context, checks []string, action string) ([]domain.IntegrityRepair, error)
+}
+
+type integrityService struct {
+	integrityRepo repository.IntegrityRepository
+}
+
+func NewIntegrityService(integrityRepo repository.IntegrityRepository) IntegrityService {
+	return &integrityService{integrityRepo: integrityRepo}
+}
+
+func (s *integrityService) CheckIntegrity(ctx context.Context) ([]domain.IntegrityIssue, error) {
+	issues, err := s.integrityRepo.Check(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check integrity: %w", err)
+	}
+	return issues, nil
+}
+
+func (s *integrityService) RepairIntegrity(ctx context.Context, checks []string, action string) ([]domain.IntegrityRepair, error) {
+	if action != domain.IntegrityActionRepair && action != domain.IntegrityActionQuarantine {
+		return nil, fmt.Errorf("%w: action must be %s or %s", domain.ErrValidation,
+			domain.IntegrityActionRepair, domain.IntegrityActionQuarantine)
+	}
+
+	issues, err := s.integrityRepo.Check(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("check integrity: %w", err)
+	}
+
+	byCheck := make(map[string]domain.IntegrityIssue, len(issues))
+	for _, issue := range issues {
+		byCheck[issue.Check] = issue
+	}
+	if len(checks) == 0 {
+		for _, issue := range issues {
+			if issue.Count > 0 && (action == domain.IntegrityActionQuarantine || issue.Repair != "") {
+				checks = append(checks, issue.Check)
+			}
+		}
+	}
+	for _, check := range checks {
+		issue, ok := byCheck[check]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown check %q", domain.ErrValidation, check)
+		}
+		if action == domain.IntegrityActionRepair && issue.Repair == "" {
+			return nil, fmt.Errorf("%w: %s can only be quarantined", domain.ErrValidation, check)
+		}
+	}
+
+	log := logger.GetLoggerFromContext(ctx).WithComponent("integrity")
+	repairs := make([]domain.IntegrityRepair, 0, len(checks))
+	for _, check := range checks {
+		affected, err := s.integrityRepo.Repair(ctx, check, action)
+		if err != nil {
+			return repairs, fmt.Errorf("%s %s: %w", action, check, err)
+		}
+		repairs = append(repairs, domain.IntegrityRepair{Check: check, Action: action, Affected: affected})
+		log.WithFields(logger.Fields{"check": check, "action": action, "affected": affected}).
+			Info("Repaired orphaned documents")
+	}
+
+	return repairs, nil
+}
@@ -2,49 +2,84 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
 )
 
 type InteractionService interface {
 	// View interactions
-	RecordProductView(ctx context.Context, userID, productID int) error
-	GetUserViewHistory(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error)
+	RecordProductView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error
+	GetUserViewHistory(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error)
+	RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error
 
 	// Like interactions
 	LikeProduct(ctx context.Context, userID, productID int) error
 	UnlikeProduct(ctx context.Context, userID, productID int) error
-	GetUserLikedProducts(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error)
+	GetUserLikedProducts(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error)
 	IsProductLiked(ctx context.Context, userID, productID int) (bool, error)
 
 	// Purchase interactions
-	PurchaseProduct(ctx context.Context, userID, productID int, quantity int) error
-	GetUserPurchaseHistory(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error)
+	PurchaseProduct(ctx context.Context, userID, productID int, quantity int, warehouseID int, client domain.ClientInfo) error
+	GetUserPurchaseHistory(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error)
 	HasPurchasedProduct(ctx context.Context, userID, productID int) (bool, error)
+	PurchaseProductAsGuest(ctx context.Context, anonymousID string, productID int, quantity int, warehouseID int, client domain.ClientInfo) error
+
+	// Anonymous sessions
+	MergeAnonymousSession(ctx context.Context, anonymousID string, userID int) (int64, error)
+
+	// Personalization
+	PersonalizeProducts(ctx context.Context, userID int, products []*domain.ProductWithCategory) error
 
 	// Summary
 	GetUserInteractionSummary(ctx context.Context, userID int) (*domain.UserInteractionSummary, error)
+
+	// Export
+	ExportEvents(ctx context.Context, filter domain.InteractionExportFilter) (*domain.InteractionExportBatch, error)
 }
 
 type interactionService struct {
 	interactionRepo repository.InteractionRepository
 	productRepo     repository.ProductRepository
+	stockRepo       repository.StockRepository
+	stockFeed       StockFeed
+	risk            RiskService
+	groupRepo       repository.CustomerGroupRepository
+	limitRepo       repository.PurchaseLimitRepository
 }
 
 func NewInteractionService(
 	interactionRepo repository.InteractionRepository,
 	productRepo repository.ProductRepository,
+	stockRepo repository.StockRepository,
+	stockFeed StockFeed,
+	risk RiskService,
+	groupRepo repository.CustomerGroupRepository,
+	limitRepo repository.PurchaseLimitRepository,
 ) InteractionService {
 	return &interactionService{
 		interactionRepo: interactionRepo,
 		productRepo:     productRepo,
+		stockRepo:       stockRepo,
+		stockFeed:       stockFeed,
+		risk:            risk,
+		groupRepo:       groupRepo,
+		limitRepo:       limitRepo,
 	}
 }
 
-// RecordProductView records a user viewing a product
-func (s *interactionService) RecordProductView(ctx context.Context, userID, productID int) error {
+// RecordProductView records a user viewing a product, with the engagement the client reported
+func (s *interactionService) RecordProductView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error {
+	if err := engagement.Validate(); err != nil {
+		return err
+	}
+
 	// Verify product exists
 	_, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
@@ -55,7 +90,29 @@ func (s *interactionService) RecordProductView(ctx context.Context, userID, prod
 	}
 
 	// Record the view
-	if err := s.interactionRepo.RecordView(ctx, userID, productID); err != nil {
+	if err := s.interactionRepo.RecordView(ctx, userID, productID, engagement); err != nil {
+		return fmt.Errorf("record view: %w", err)
+	}
+
+	return nil
+}
+
+// RecordAnonymousView records a guest viewing a product, with the engagement the client reported
+func (s *interactionService) RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error {
+	if err := engagement.Validate(); err != nil {
+		return err
+	}
+
+	// Verify product exists
+	_, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return fmt.Errorf("product not found")
+		}
+		return fmt.Errorf("verify product: %w", err)
+	}
+
+	if err := s.interactionRepo.RecordAnonymousView(ctx, anonymousID, productID, engagement); err != nil {
 		return fmt.Errorf("record view: %w", err)
 	}
 
@@ -63,17 +120,20 @@ func (s *interactionService) RecordProductView(ctx context.Context, userID, prod
 }
 
 // GetUserViewHistory retrieves the user's view history
-func (s *interactionService) GetUserViewHistory(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error) {
+func (s *interactionService) GetUserViewHistory(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50 // Default limit
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	views, err := s.interactionRepo.GetUserViews(ctx, userID, limit)
+	views, total, err := s.interactionRepo.GetUserViews(ctx, userID, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("get view history: %w", err)
+		return nil, 0, fmt.Errorf("get view history: %w", err)
 	}
 
-	return views, nil
+	return views, total, nil
 }
 
 // LikeProduct records a user liking a product
@@ -108,17 +168,20 @@ func (s *interactionService) UnlikeProduct(ctx context.Context, userID, productI
 }
 
 // GetUserLikedProducts retrieves products the user has liked
-func (s *interactionService) GetUserLikedProducts(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error) {
+func (s *interactionService) GetUserLikedProducts(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50 // Default limit
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	likes, err := s.interactionRepo.GetUserLikes(ctx, userID, limit)
+	likes, total, err := s.interactionRepo.GetUserLikes(ctx, userID, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("get liked products: %w", err)
+		return nil, 0, fmt.Errorf("get liked products: %w", err)
 	}
 
-	return likes, nil
+	return likes, total, nil
 }
 
 // IsProductLiked checks if the user has liked a product
@@ -141,8 +204,28 @@ func (s *interactionService) GetUserInteractionSummary(ctx context.Context, user
 	return summary, nil
 }
 
-// PurchaseProduct records a user purchasing a product
-func (s *interactionService) PurchaseProduct(ctx context.Context, userID, productID int, quantity int) error {
+// PurchaseProduct records a user purchasing a product, shipped from warehouseID or, if 0, from
+// the warehouse with the most stock
+func (s *interactionService) PurchaseProduct(ctx context.Context, userID, productID int, quantity int, warehouseID int, client domain.ClientInfo) error {
+	buyer := domain.RiskCheck{UserID: userID, Client: client}
+	return s.purchase(ctx, buyer, productID, quantity, warehouseID, func(price, cost domain.Money) error {
+		return s.interactionRepo.RecordPurchase(ctx, userID, productID, quantity, price, cost)
+	})
+}
+
+// PurchaseProductAsGuest records a guest checkout and updates stock
+func (s *interactionService) PurchaseProductAsGuest(ctx context.Context, anonymousID string, productID int, quantity int, warehouseID int, client domain.ClientInfo) error {
+	buyer := domain.RiskCheck{AnonymousID: anonymousID, Client: client}
+	return s.purchase(ctx, buyer, productID, quantity, warehouseID, func(price, cost domain.Money) error {
+		return s.interactionRepo.RecordAnonymousPurchase(ctx, anonymousID, productID, quantity, price, cost)
+	})
+}
+
+// purchase reduces stock, recording it in the stock ledger, and records the purchase with its
+// cost price. The price is the current one, or that of a signed-in buyer's customer group,
+// lowered by the volume price the quantity reaches. The purchase is then screened for risk;
+// buyer identifies the buyer and their client.
+func (s *interactionService) purchase(ctx context.Context, buyer domain.RiskCheck, productID int, quantity int, warehouseID int, record func(price, cost domain.Money) error) error {
 	if quantity <= 0 {
 		return fmt.Errorf("quantity must be greater than 0")
 	}
@@ -155,38 +238,187 @@ func (s *interactionService) PurchaseProduct(ctx context.Context, userID, produc
 		}
 		return fmt.Errorf("verify product: %w", err)
 	}
+	if product.FlashSale != nil && product.FlashSale.Active(time.Now()) {
+		return errFlashSaleActive(productID)
+	}
+	if err := product.CheckQuantity(quantity); err != nil {
+		return err
+	}
+	if buyer.UserID != 0 {
+		if err := applyGroupPrices(ctx, s.groupRepo, buyer.UserID, product); err != nil {
+			return err
+		}
+	}
+	product.Price = product.UnitPrice(quantity)
 
 	// Check stock availability
 	if product.Stock < quantity {
 		return fmt.Errorf("insufficient stock: requested %d, available %d", quantity, product.Stock)
 	}
 
-	// Record the purchase
-	if err := s.interactionRepo.RecordPurchase(ctx, userID, productID, quantity, product.Price); err != nil {
-		return fmt.Errorf("record purchase: %w", err)
+	locations, err := s.stockRepo.GetLocations(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("get stock locations: %w", err)
+	}
+	warehouseID, err = pickWarehouse(product, locations, quantity, warehouseID)
+	if err != nil {
+		return err
+	}
+
+	// Count the purchase against the buyer's limit before taking stock, so concurrent purchases
+	// cannot exceed it either
+	releaseLimit, err := consumePurchaseLimit(ctx, s.limitRepo, product, buyer.UserID, quantity)
+	if err != nil {
+		return err
 	}
 
-	// Update stock (reduce by purchased quantity)
-	product.Stock -= quantity
-	if err := s.productRepo.Update(ctx, product); err != nil {
+	// Reduce stock first, so concurrent purchases cannot oversell
+	updated, err := s.stockRepo.Adjust(ctx, &domain.StockAdjustment{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Delta:       -quantity,
+		Reason:      domain.StockReasonPurchase,
+		ActorID:     buyer.UserID,
+	})
+	if err != nil {
+		releaseLimit()
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return fmt.Errorf("insufficient stock: requested %d, available %d", quantity, product.Stock)
+		}
 		return fmt.Errorf("update product stock: %w", err)
 	}
 
+	// Record the purchase, giving the stock back if it fails
+	if err := record(product.Price, product.CostPrice); err != nil {
+		releaseLimit()
+		if updated, restoreErr := s.stockRepo.Adjust(ctx, &domain.StockAdjustment{
+			ProductID:   productID,
+			WarehouseID: warehouseID,
+			Delta:       quantity,
+			Reason:      domain.StockReasonCorrection,
+			Note:        "purchase failed",
+			ActorID:     buyer.UserID,
+		}); restoreErr == nil {
+			s.stockFeed.Publish(updated)
+		}
+		return fmt.Errorf("record purchase: %w", err)
+	}
+
+	s.stockFeed.Publish(updated)
+
+	buyer.Source = domain.PurchaseSourceProduct
+	buyer.SourceID = productID
+	buyer.Lines = []domain.PurchaseLine{{ProductID: productID, Quantity: quantity, Price: product.Price}}
+	screenPurchase(ctx, s.risk, buyer)
 	return nil
 }
 
+// pickWarehouse chooses the warehouse a purchase ships from: the requested one, which must be
+// active and hold the whole quantity, or else the active warehouse with the most stock that
+// does. Products not stocked at any warehouse, or whose unallocated stock covers the purchase
+// when no warehouse can, ship from unallocated stock (0).
+func pickWarehouse(product *domain.Product, locations []domain.LocationStock, quantity int, requested int) (int, error) {
+	unallocated := product.Stock
+	best := -1
+	for i, location := range locations {
+		unallocated -= location.Quantity
+		if location.WarehouseID == requested {
+			if !location.IsActive || location.Quantity < quantity {
+				return 0, fmt.Errorf("insufficient stock at warehouse %d: requested %d, available %d", requested, quantity, location.Quantity)
+			}
+			return requested, nil
+		}
+		if location.IsActive && location.Quantity >= quantity && (best < 0 || location.Quantity > locations[best].Quantity) {
+			best = i
+		}
+	}
+
+	if requested != 0 {
+		return 0, fmt.Errorf("insufficient stock at warehouse %d: requested %d, available 0", requested, quantity)
+	}
+	if best >= 0 {
+		return locations[best].WarehouseID, nil
+	}
+	if unallocated >= quantity {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("insufficient stock: no warehouse has %d in stock", quantity)
+}
+
+// consumePurchaseLimit counts a purchase against the buyer's purchase limit of the product,
+// returning a *domain.PurchaseLimitError if it would exceed it, and domain.ErrSignInRequired
+// for guests, who have no limit to count against. The returned func gives the quantity back
+// if the purchase fails; it does nothing for products without a limit.
+func consumePurchaseLimit(ctx context.Context, limitRepo repository.PurchaseLimitRepository, product *domain.Product, userID, quantity int) (func(), error) {
+	if product.PurchaseLimit == nil {
+		return func() {}, nil
+	}
+	if userID == 0 {
+		return nil, fmt.Errorf("product %d is limited per customer: %w", product.ID, domain.ErrSignInRequired)
+	}
+
+	limit := *product.PurchaseLimit
+	now := time.Now()
+	windowStart, windowEnd := limit.Window(now)
+	used, ok, err := limitRepo.Consume(ctx, product.ID, userID, quantity, limit, now)
+	if err != nil {
+		return nil, fmt.Errorf("check purchase limit: %w", err)
+	}
+	if !ok {
+		return nil, &domain.PurchaseLimitError{
+			ProductID: product.ID,
+			Quantity:  quantity,
+			Limit:     limit,
+			Remaining: max(limit.Quantity-used, 0),
+			ResetsAt:  windowEnd,
+		}
+	}
+
+	return func() {
+		if err := limitRepo.Release(ctx, product.ID, userID, quantity, windowStart); err != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("interaction").WithError(err).Error("Failed to release purchase limit", "product_id", product.ID, "user_id", userID)
+		}
+	}, nil
+}
+
+// restoreStock gives back the stock taken by purchase adjustments that could not be completed
+func restoreStock(ctx context.Context, stockRepo repository.StockRepository, stockFeed StockFeed, adjustments []*domain.StockAdjustment, actorID int) {
+	restores := make([]*domain.StockAdjustment, len(adjustments))
+	for i, adjustment := range adjustments {
+		restores[i] = &domain.StockAdjustment{
+			ProductID:   adjustment.ProductID,
+			WarehouseID: adjustment.WarehouseID,
+			Delta:       -adjustment.Delta,
+			Reason:      domain.StockReasonCorrection,
+			Note:        "purchase failed",
+			ActorID:     actorID,
+		}
+	}
+
+	updated, err := stockRepo.AdjustMany(ctx, restores)
+	if err != nil {
+		return
+	}
+	for _, product := range updated {
+		stockFeed.Publish(product)
+	}
+}
+
 // GetUserPurchaseHistory retrieves the user's purchase history
-func (s *interactionService) GetUserPurchaseHistory(ctx context.Context, userID int, limit int) ([]domain.ProductInteraction, error) {
+func (s *interactionService) GetUserPurchaseHistory(ctx context.Context, userID int, limit, offset int) ([]domain.ProductInteraction, int64, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 50 // Default limit
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	purchases, err := s.interactionRepo.GetUserPurchases(ctx, userID, limit)
+	purchases, total, err := s.interactionRepo.GetUserPurchases(ctx, userID, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("get purchase history: %w", err)
+		return nil, 0, fmt.Errorf("get purchase history: %w", err)
 	}
 
-	return purchases, nil
+	return purchases, total, nil
 }
 
 // HasPurchasedProduct checks if the user has purchased a product
@@ -198,3 +430,129 @@ func (s *interactionService) HasPurchasedProduct(ctx context.Context, userID, pr
 
 	return purchased, nil
 }
+
+// MergeAnonymousSession reassigns a guest's views and purchases to the user who signed in,
+// so they count towards the user's history and recommendations
+func (s *interactionService) MergeAnonymousSession(ctx context.Context, anonymousID string, userID int) (int64, error) {
+	merged, err := s.interactionRepo.MergeAnonymous(ctx, anonymousID, userID)
+	if err != nil {
+		return merged, fmt.Errorf("merge anonymous session: %w", err)
+	}
+
+	return merged, nil
+}
+
+// PersonalizeProducts sets the user's liked and purchased status on the products
+func (s *interactionService) PersonalizeProducts(ctx context.Context, userID int, products []*domain.ProductWithCategory) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]int, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+
+	likedIDs, err := s.interactionRepo.GetLikedProductIDs(ctx, userID, productIDs)
+	if err != nil {
+		return fmt.Errorf("get liked products: %w", err)
+	}
+	purchasedIDs, err := s.interactionRepo.GetPurchasedProductIDs(ctx, userID, productIDs)
+	if err != nil {
+		return fmt.Errorf("get purchased products: %w", err)
+	}
+
+	liked := make(map[int]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	purchased := make(map[int]bool, len(purchasedIDs))
+	for _, id := range purchasedIDs {
+		purchased[id] = true
+	}
+
+	for _, product := range products {
+		isLiked, isPurchased := liked[product.ID], purchased[product.ID]
+		product.Liked = &isLiked
+		product.Purchased = &isPurchased
+	}
+
+	return nil
+}
+
+// ExportEvents retrieves a batch of interaction events for offline processing.
+// Events are ordered by time; pass NextCursor back to continue where the batch ended.
+func (s *interactionService) ExportEvents(ctx context.Context, filter domain.InteractionExportFilter) (*domain.InteractionExportBatch, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 1000 // Default batch size
+	}
+	if filter.Limit > 10000 {
+		filter.Limit = 10000
+	}
+
+	if len(filter.EventTypes) == 0 {
+		filter.EventTypes = []string{domain.EventTypeView, domain.EventTypeLike, domain.EventTypePurchase}
+	}
+	for _, eventType := range filter.EventTypes {
+		if eventType != domain.EventTypeView && eventType != domain.EventTypeLike && eventType != domain.EventTypePurchase {
+			return nil, fmt.Errorf("unknown event type %q: %w", eventType, domain.ErrValidation)
+		}
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("from must be before to: %w", domain.ErrValidation)
+	}
+
+	if filter.Cursor != "" {
+		afterTime, afterID, err := decodeExportCursor(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("decode cursor: %w", domain.ErrValidation)
+		}
+		filter.AfterTime = &afterTime
+		filter.AfterID = afterID
+	}
+
+	events, err := s.interactionRepo.ExportEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("export events: %w", err)
+	}
+
+	batch := &domain.InteractionExportBatch{
+		Events: events,
+		Count:  len(events),
+	}
+
+	// A full batch means there may be more events to fetch
+	if len(events) == filter.Limit {
+		last := events[len(events)-1]
+		batch.NextCursor = encodeExportCursor(last.OccurredAt, last.EventID)
+	}
+
+	return batch, nil
+}
+
+// encodeExportCursor builds an opaque cursor from the position of the last exported event
+func encodeExportCursor(occurredAt time.Time, eventID string) string {
+	raw := occurredAt.UTC().Format(time.RFC3339Nano) + "|" + eventID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeExportCursor parses a cursor produced by encodeExportCursor
+func decodeExportCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+
+	occurredAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	return occurredAt, parts[1], nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/broker"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// interactionsTopic is the outbox topic of interactions waiting to be streamed
+const interactionsTopic = "interactions"
+
+// streamingInteractionRepository records each view, like and purchase together with an outbox
+// event, so every service writing interactions streams them without knowing about the broker
+type streamingInteractionRepository struct {
+	repository.InteractionRepository
+	tx     repository.Transactor
+	outbox Outbox
+}
+
+func newStreamingInteractionRepository(interactionRepo repository.InteractionRepository, tx repository.Transactor, outbox Outbox) repository.InteractionRepository {
+	return &streamingInteractionRepository{
+		InteractionRepository: interactionRepo,
+		tx:                    tx,
+		outbox:                outbox,
+	}
+}
+
+func (r *streamingInteractionRepository) RecordView(ctx context.Context, userID, productID int, engagement domain.ViewEngagement) error {
+	return r.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.InteractionRepository.RecordView(ctx, userID, productID, engagement); err != nil {
+			return err
+		}
+		return r.record(ctx, viewEvent(userID, "", productID, engagement))
+	})
+}
+
+func (r *streamingInteractionRepository) RecordAnonymousView(ctx context.Context, anonymousID string, productID int, engagement domain.ViewEngagement) error {
+	return r.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.InteractionRepository.RecordAnonymousView(ctx, anonymousID, productID, engagement); err != nil {
+			return err
+		}
+		return r.record(ctx, viewEvent(0, anonymousID, productID, engagement))
+	})
+}
+
+func (r *streamingInteractionRepository) RecordLike(ctx context.Context, userID, productID int) error {
+	return r.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		// Liking a product again changes nothing, so there is nothing to stream
+		liked, err := r.InteractionRepository.HasLiked(ctx, userID, productID)
+		if err != nil {
+			return err
+		}
+		if liked {
+			return nil
+		}
+
+		if err := r.InteractionRepository.RecordLike(ctx, userID, productID); err != nil {
+			return err
+		}
+		return r.record(ctx, domain.StreamedInteraction{
+			InteractionEvent: domain.InteractionEvent{EventType: domain.EventTypeLike, UserID: userID, ProductID: productID},
+		})
+	})
+}
+
+func (r *streamingInteractionRepository) RecordPurchase(ctx context.Context, userID, productID int, quantity int, price, cost domain.Money) error {
+	return r.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.InteractionRepository.RecordPurchase(ctx, userID, productID, quantity, price, cost); err != nil {
+			return err
+		}
+		return r.record(ctx, purchaseEvent(userID, "", productID, quantity, price))
+	})
+}
+
+func (r *streamingInteractionRepository) RecordAnonymousPurchase(ctx context.Context, anonymousID string, productID int, quantity int, price, cost domain.Money) error {
+	return r.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.InteractionRepository.RecordAnonymousPurchase(ctx, anonymousID, productID, quantity, price, cost); err != nil {
+			return err
+		}
+		return r.record(ctx, purchaseEvent(0, anonymousID, productID, quantity, price))
+	})
+}
+
+// record adds the event to the outbox, stamped with the current time, trace and tenant
+func (r *streamingInteractionRepository) record(ctx context.Context, event domain.StreamedInteraction) error {
+	event.OccurredAt = time.Now().UTC()
+	event.SetTrace(domain.InteractionTraceFrom(ctx))
+	event.Tenant = tenant.ID(ctx)
+	return r.outbox.Record(ctx, interactionsTopic, event)
+}
+
+// viewEvent is a view by a user, or by a guest if userID is 0, with the engagement if reported
+func viewEvent(userID int, anonymousID string, productID int, engagement domain.ViewEngagement) domain.StreamedInteraction {
+	event := domain.StreamedInteraction{
+		InteractionEvent: domain.InteractionEvent{EventType: domain.EventTypeView, UserID: userID, ProductID: productID},
+		AnonymousID:      anonymousID,
+	}
+	if engagement != (domain.ViewEngagement{}) {
+		event.Engagement = &engagement
+	}
+	return event
+}
+
+// purchaseEvent is a purchase by a user, or by a guest if userID is 0
+func purchaseEvent(userID int, anonymousID string, productID, quantity int, price domain.Money) domain.StreamedInteraction {
+	return domain.StreamedInteraction{
+		InteractionEvent: domain.InteractionEvent{
+			EventType: domain.EventTypePurchase,
+			UserID:    userID,
+			ProductID: productID,
+			Quantity:  quantity,
+			Price:     price,
+		},
+		AnonymousID: anonymousID,
+	}
+}
+
+// streamInteractions returns an outbox handler publishing interactions to the broker topic of
+// their type. The outbox entry ID becomes the event ID, which stays the same when an event is
+// delivered again, so consumers can drop duplicates.
+func streamInteractions(publisher broker.Publisher, topics *config.StreamingTopics) OutboxHandler {
+	topicOf := map[string]string{
+		domain.EventTypeView:     topics.Views,
+		domain.EventTypeLike:     topics.Likes,
+		domain.EventTypePurchase: topics.Purchases,
+	}
+
+	return func(ctx context.Context, entry *domain.OutboxEntry) error {
+		var event domain.StreamedInteraction
+		if err := json.Unmarshal(entry.Payload, &event); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		event.EventID = entry.ID
+
+		topic, ok := topicOf[event.EventType]
+		if !ok {
+			return fmt.Errorf("unknown interaction type %q", event.EventType)
+		}
+
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode event: %w", err)
+		}
+
+		// Keep the interactions of a user in order
+		key := event.AnonymousID
+		if event.UserID != 0 {
+			key = strconv.Itoa(event.UserID)
+		}
+
+		return publisher.Publish(ctx, broker.Message{Topic: topic, Key: key, ID: entry.ID, Value: value})
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type InventoryService interface {
+	// Warehouses
+	CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+	GetWarehouse(ctx context.Context, id int) (*domain.Warehouse, error)
+	ListWarehouses(ctx context.Context) ([]domain.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+	DeleteWarehouse(ctx context.Context, id int) error
+
+	// Inventory per warehouse
+	ListWarehouseInventory(ctx context.Context, warehouseID int, limit, offset int) ([]domain.InventoryLevel, int64, error)
+	TransferStock(ctx context.Context, transfer *domain.StockTransfer) ([]domain.StockAdjustment, error)
+	GetAvailability(ctx context.Context, productID int) (*domain.ProductAvailability, error)
+}
+
+type inventoryService struct {
+	warehouseRepo repository.WarehouseRepository
+	stockRepo     repository.StockRepository
+	productRepo   repository.ProductRepository
+}
+
+func NewInventoryService(
+	warehouseRepo repository.WarehouseRepository,
+	stockRepo repository.StockRepository,
+	productRepo repository.ProductRepository,
+) InventoryService {
+	return &inventoryService{
+		warehouseRepo: warehouseRepo,
+		stockRepo:     stockRepo,
+		productRepo:   productRepo,
+	}
+}
+
+// CreateWarehouse creates a new active warehouse
+func (s *inventoryService) CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	if err := warehouse.Validate(); err != nil {
+		return err
+	}
+
+	warehouse.IsActive = true
+	if err := s.warehouseRepo.Create(ctx, warehouse); err != nil {
+		if errors.Is(err, domain.ErrAlreadyExists) {
+			return err
+		}
+		return fmt.Errorf("create warehouse: %w", err)
+	}
+
+	return nil
+}
+
+// GetWarehouse retrieves a warehouse by ID
+func (s *inventoryService) GetWarehouse(ctx context.Context, id int) (*domain.Warehouse, error) {
+	return s.warehouseRepo.GetByID(ctx, id)
+}
+
+// ListWarehouses retrieves all warehouses ordered by code
+func (s *inventoryService) ListWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	warehouses, err := s.warehouseRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+// UpdateWarehouse updates a warehouse. An inactive warehouse keeps its stock but purchases
+// no longer ship from it.
+func (s *inventoryService) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	if err := warehouse.Validate(); err != nil {
+		return err
+	}
+
+	return s.warehouseRepo.Update(ctx, warehouse)
+}
+
+// DeleteWarehouse deletes a warehouse that holds no stock; transfer its stock elsewhere first
+func (s *inventoryService) DeleteWarehouse(ctx context.Context, id int) error {
+	if _, err := s.warehouseRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	hasInventory, err := s.warehouseRepo.HasInventory(ctx, id)
+	if err != nil {
+		return fmt.Errorf("check warehouse inventory: %w", err)
+	}
+	if hasInventory {
+		return domain.ErrWarehouseNotEmpty
+	}
+
+	return s.warehouseRepo.Delete(ctx, id)
+}
+
+// ListWarehouseInventory retrieves a page of the products held at a warehouse
+func (s *inventoryService) ListWarehouseInventory(ctx context.Context, warehouseID int, limit, offset int) ([]domain.InventoryLevel, int64, error) {
+	if _, err := s.warehouseRepo.GetByID(ctx, warehouseID); err != nil {
+		return nil, 0, err
+	}
+
+	levels, total, err := s.stockRepo.ListInventory(ctx, warehouseID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list inventory: %w", err)
+	}
+
+	return levels, total, nil
+}
+
+// TransferStock moves stock of a product between warehouses, or allocates unallocated stock
+// to a warehouse. The destination must be active.
+func (s *inventoryService) TransferStock(ctx context.Context, transfer *domain.StockTransfer) ([]domain.StockAdjustment, error) {
+	if err := transfer.Validate(); err != nil {
+		return nil, err
+	}
+
+	to, err := s.warehouseRepo.GetByID(ctx, transfer.ToWarehouseID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("warehouse %d not found: %w", transfer.ToWarehouseID, domain.ErrValidation)
+		}
+		return nil, fmt.Errorf("get warehouse: %w", err)
+	}
+	if !to.IsActive {
+		return nil, fmt.Errorf("warehouse %s is inactive: %w", to.Code, domain.ErrValidation)
+	}
+	if transfer.FromWarehouseID != 0 {
+		if _, err := s.warehouseRepo.GetByID(ctx, transfer.FromWarehouseID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("warehouse %d not found: %w", transfer.FromWarehouseID, domain.ErrValidation)
+			}
+			return nil, fmt.Errorf("get warehouse: %w", err)
+		}
+	}
+
+	adjustments, err := s.stockRepo.Transfer(ctx, transfer)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrInsufficientStock) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("transfer stock: %w", err)
+	}
+
+	return adjustments, nil
+}
+
+// GetAvailability breaks the product's stock down by active warehouse
+func (s *inventoryService) GetAvailability(ctx context.Context, productID int) (*domain.ProductAvailability, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.stockRepo.GetLocations(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("get stock locations: %w", err)
+	}
+
+	availability := &domain.ProductAvailability{
+		ProductID:   productID,
+		Total:       product.Stock,
+		Unallocated: product.Stock,
+		Locations:   make([]domain.LocationStock, 0, len(locations)),
+	}
+	for _, location := range locations {
+		availability.Unallocated -= location.Quantity
+		if location.IsActive {
+			availability.Locations = append(availability.Locations, location)
+		}
+	}
+
+	return availability, nil
+}
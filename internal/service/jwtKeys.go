@@ -0,0 +1,191 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// jwtKeySet holds the keys used to sign and verify tokens.
+// Tokens are signed with a single active key and verified by their kid header,
+// so old keys can stay in the set until tokens signed with them expire.
+type jwtKeySet struct {
+	method       jwt.SigningMethod
+	signingKeyID string
+	signingKey   interface{}
+	verifyKeys   map[string]interface{}
+}
+
+func newJWTKeySet(cfg *config.JWT) (*jwtKeySet, error) {
+	if cfg.Algorithm == "HS256" {
+		secret := []byte(cfg.Secret)
+		return &jwtKeySet{
+			method:     jwt.SigningMethodHS256,
+			signingKey: secret,
+			verifyKeys: map[string]interface{}{"": secret},
+		}, nil
+	}
+
+	keySet := &jwtKeySet{
+		verifyKeys: make(map[string]interface{}),
+	}
+	switch cfg.Algorithm {
+	case "RS256":
+		keySet.method = jwt.SigningMethodRS256
+	case "EdDSA":
+		keySet.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", cfg.Algorithm)
+	}
+
+	privateKeys := make(map[string]interface{})
+	for _, key := range cfg.Keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("jwt key without id")
+		}
+		if _, ok := keySet.verifyKeys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+
+		privatePEM, err := readPEM(key.PrivateKey, key.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read private key %q: %w", key.ID, err)
+		}
+		publicPEM, err := readPEM(key.PublicKey, key.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read public key %q: %w", key.ID, err)
+		}
+
+		var private, public interface{}
+		switch cfg.Algorithm {
+		case "RS256":
+			if privatePEM != nil {
+				rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+				if err != nil {
+					return nil, fmt.Errorf("parse private key %q: %w", key.ID, err)
+				}
+				private, public = rsaKey, &rsaKey.PublicKey
+			} else if publicPEM != nil {
+				public, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+				if err != nil {
+					return nil, fmt.Errorf("parse public key %q: %w", key.ID, err)
+				}
+			}
+		case "EdDSA":
+			if privatePEM != nil {
+				edKey, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+				if err != nil {
+					return nil, fmt.Errorf("parse private key %q: %w", key.ID, err)
+				}
+				private, public = edKey, edKey.(crypto.Signer).Public()
+			} else if publicPEM != nil {
+				public, err = jwt.ParseEdPublicKeyFromPEM(publicPEM)
+				if err != nil {
+					return nil, fmt.Errorf("parse public key %q: %w", key.ID, err)
+				}
+			}
+		}
+		if public == nil {
+			return nil, fmt.Errorf("jwt key %q has neither private nor public key", key.ID)
+		}
+
+		keySet.verifyKeys[key.ID] = public
+		if private != nil {
+			privateKeys[key.ID] = private
+		}
+	}
+
+	// Sign with the configured key, or the first key that has a private part
+	keySet.signingKeyID = cfg.SigningKeyID
+	if keySet.signingKeyID == "" {
+		for _, key := range cfg.Keys {
+			if _, ok := privateKeys[key.ID]; ok {
+				keySet.signingKeyID = key.ID
+				break
+			}
+		}
+	}
+	signingKey, ok := privateKeys[keySet.signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("no private key for signing key id %q", keySet.signingKeyID)
+	}
+	keySet.signingKey = signingKey
+
+	return keySet, nil
+}
+
+// sign creates a signed token with the active key
+func (k *jwtKeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.signingKeyID != "" {
+		token.Header["kid"] = k.signingKeyID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// keyFunc resolves the verification key of a token by its kid header
+func (k *jwtKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// jwks returns the public keys of the set. Shared secrets are never published.
+func (k *jwtKeySet) jwks() domain.JSONWebKeySet {
+	set := domain.JSONWebKeySet{Keys: make([]domain.JSONWebKey, 0, len(k.verifyKeys))}
+
+	for kid, key := range k.verifyKeys {
+		switch pub := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, domain.JSONWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, domain.JSONWebKey{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: k.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
+
+// readPEM returns inline PEM data or the contents of file; nil if neither is set
+func readPEM(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(file)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// NewListingSettings parses the configured settings of each product listing
+func NewListingSettings(cfg *config.Config) (map[string]domain.ListingSettings, error) {
+	listings := make(map[string]domain.ListingSettings, len(cfg.Listing.Endpoints))
+	for name, endpoint := range cfg.Listing.Endpoints {
+		sortFields, err := listingSortFields(endpoint.SortFields)
+		if err != nil {
+			return nil, fmt.Errorf("%s sort fields: %w", name, err)
+		}
+		sort, err := domain.ParseSort(endpoint.DefaultSort, sortFields)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s default sort: %w", name, err)
+		}
+		listings[name] = domain.ListingSettings{
+			DefaultLimit: endpoint.DefaultLimit,
+			MaxLimit:     endpoint.MaxLimit,
+			DefaultSort:  sort,
+			SortFields:   sortFields,
+
+			EstimateCountsAbove: endpoint.EstimateCountsAbove,
+		}
+	}
+	return listings, nil
+}
+
+// listingSortFields returns the product sort keys of names with their document fields, or all of
+// them without names. Only keys of domain.ProductSortFields can be configured, so a listing can't
+// be made to sort by an arbitrary field.
+func listingSortFields(names []string) (map[string]string, error) {
+	if len(names) == 0 {
+		return domain.ProductSortFields, nil
+	}
+
+	fields := make(map[string]string, len(names))
+	for _, name := range names {
+		field, ok := domain.ProductSortFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", name)
+		}
+		fields[name] = field
+	}
+	return fields, nil
+}
+
+// listingSettings returns the settings of the listing, or the defaults if it isn't configured
+func listingSettings(listings map[string]domain.ListingSettings, name string) domain.ListingSettings {
+	if listing, ok := listings[name]; ok {
+		return listing
+	}
+	return domain.DefaultListingSettings
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/eventbus"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// errorWindow is the period HTTP error rates are computed over
+const errorWindow = 60 * time.Second
+
+// LiveMetrics publishes dashboard metrics (orders, revenue, error rates) to live subscribers.
+// Orders are purchase records. Orders and revenue are those of the tenant of the context;
+// error rates are those of the instance, which serves every tenant.
+type LiveMetrics interface {
+	// RecordRequest counts a handled HTTP request for the error rate
+	RecordRequest(status int)
+	Subscribe(ctx context.Context, topic string) (<-chan domain.MetricsUpdate, func())
+	Snapshot(ctx context.Context, topic string) (*domain.MetricsUpdate, error)
+	// Run publishes snapshots of subscribed topics of each tenant periodically until ctx is
+	// cancelled
+	Run(ctx context.Context) error
+}
+
+type liveMetrics struct {
+	interactionRepo repository.InteractionRepository
+	tenancy         *config.Tenancy
+	bus             *eventbus.Bus[domain.MetricsUpdate]
+	interval        time.Duration
+
+	mu      sync.Mutex
+	buckets [int(errorWindow / time.Second)]requestBucket
+}
+
+// requestBucket counts the requests of one second
+type requestBucket struct {
+	second   int64
+	requests int64
+	errors   int64
+}
+
+func NewLiveMetrics(interactionRepo repository.InteractionRepository, cfg *config.Config) (LiveMetrics, error) {
+	interval, err := time.ParseDuration(cfg.Realtime.MetricsInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse realtime metrics interval: %w", err)
+	}
+
+	return &liveMetrics{
+		interactionRepo: interactionRepo,
+		tenancy:         &cfg.Tenancy,
+		bus:             eventbus.New[domain.MetricsUpdate](eventbus.DefaultBufferSize),
+		interval:        interval,
+	}, nil
+}
+
+// RecordRequest counts a request and whether it failed with a server error
+func (m *liveMetrics) RecordRequest(status int) {
+	now := time.Now().Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bucket := &m.buckets[now%int64(len(m.buckets))]
+	if bucket.second != now {
+		*bucket = requestBucket{second: now}
+	}
+	bucket.requests++
+	if status >= http.StatusInternalServerError {
+		bucket.errors++
+	}
+}
+
+// Subscribe returns a channel of updates for a topic of the tenant of ctx and a function to
+// stop receiving them
+func (m *liveMetrics) Subscribe(ctx context.Context, topic string) (<-chan domain.MetricsUpdate, func()) {
+	return m.bus.Subscribe(tenantTopic(ctx, topic))
+}
+
+// Snapshot computes the current value of a topic
+func (m *liveMetrics) Snapshot(ctx context.Context, topic string) (*domain.MetricsUpdate, error) {
+	now := time.Now()
+	update := &domain.MetricsUpdate{Topic: topic, At: now}
+
+	switch topic {
+	case domain.MetricsTopicOrders, domain.MetricsTopicRevenue:
+		today, err := m.interactionRepo.GetPurchaseTotals(ctx, startOfDay(now))
+		if err != nil {
+			return nil, fmt.Errorf("get purchase totals: %w", err)
+		}
+		lastMinute, err := m.interactionRepo.GetPurchaseTotals(ctx, now.Add(-time.Minute))
+		if err != nil {
+			return nil, fmt.Errorf("get purchase totals: %w", err)
+		}
+
+		if topic == domain.MetricsTopicOrders {
+			update.Data = domain.OrderMetrics{Today: today.Count, LastMinute: lastMinute.Count}
+		} else {
+			update.Data = domain.RevenueMetrics{Today: today.Revenue, LastMinute: lastMinute.Revenue}
+		}
+
+	case domain.MetricsTopicErrors:
+		update.Data = m.errorMetrics(now)
+
+	default:
+		return nil, fmt.Errorf("unknown topic %q: %w", topic, domain.ErrValidation)
+	}
+
+	return update, nil
+}
+
+// Run publishes a snapshot of every topic that has subscribers on each tick
+func (m *liveMetrics) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for _, tenantCtx := range tenant.Contexts(ctx, m.tenancy) {
+			for _, topic := range domain.MetricsTopics {
+				key := tenantTopic(tenantCtx, topic)
+				if m.bus.Subscribers(key) == 0 {
+					continue
+				}
+
+				update, err := m.Snapshot(tenantCtx, topic)
+				if err != nil {
+					logger.GetLoggerFromContext(ctx).WithComponent("realtime").WithError(err).Error("Failed to compute live metrics")
+					continue
+				}
+				m.bus.Publish(key, *update)
+			}
+		}
+	}
+}
+
+// tenantTopic is the bus topic of a metrics topic of the tenant of ctx
+func tenantTopic(ctx context.Context, topic string) string {
+	if id := tenant.ID(ctx); id != "" {
+		return id + "/" + topic
+	}
+	return topic
+}
+
+func (m *liveMetrics) errorMetrics(now time.Time) domain.ErrorMetrics {
+	since := now.Add(-errorWindow).Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var metrics domain.ErrorMetrics
+	for _, bucket := range m.buckets {
+		if bucket.second > since {
+			metrics.Requests += bucket.requests
+			metrics.Errors += bucket.errors
+		}
+	}
+	if metrics.Requests > 0 {
+		metrics.ErrorRate = float64(metrics.Errors) / float64(metrics.Requests)
+	}
+	return metrics
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
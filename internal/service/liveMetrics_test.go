@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository/mocks"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+func TestLiveMetricsKeepsTenantsApart(t *testing.T) {
+	cfg := &config.Config{
+		Realtime: config.Realtime{MetricsInterval: "10ms"},
+		Tenancy: config.Tenancy{Enabled: true, Tenants: []config.Tenant{
+			{ID: "books", Currency: "USD"},
+			{ID: "games", Currency: "EUR"},
+		}},
+	}
+	totals := map[string]*domain.PurchaseTotals{
+		"books": {Count: 3, Revenue: domain.NewMoney(4500, "USD")},
+		"games": {Count: 1, Revenue: domain.NewMoney(6000, "EUR")},
+	}
+
+	interactionRepo := mocks.NewMockInteractionRepository(gomock.NewController(t))
+	interactionRepo.EXPECT().GetPurchaseTotals(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, _ time.Time) (*domain.PurchaseTotals, error) {
+			return totals[tenant.ID(ctx)], nil
+		})
+
+	metrics, err := NewLiveMetrics(interactionRepo, cfg)
+	if err != nil {
+		t.Fatalf("new live metrics: %v", err)
+	}
+	books := tenant.NewContext(context.Background(), &cfg.Tenancy.Tenants[0])
+	games := tenant.NewContext(context.Background(), &cfg.Tenancy.Tenants[1])
+
+	for _, tenantCtx := range []context.Context{books, games} {
+		id := tenant.ID(tenantCtx)
+		update, err := metrics.Snapshot(tenantCtx, domain.MetricsTopicRevenue)
+		if err != nil {
+			t.Fatalf("snapshot of %s: %v", id, err)
+		}
+		if revenue := update.Data.(domain.RevenueMetrics); revenue.Today != totals[id].Revenue {
+			t.Errorf("revenue of %s = %+v, want %+v", id, revenue.Today, totals[id].Revenue)
+		}
+	}
+
+	// Subscribers of one tenant receive only its orders
+	booksUpdates, stopBooks := metrics.Subscribe(books, domain.MetricsTopicOrders)
+	defer stopBooks()
+	gamesUpdates, stopGames := metrics.Subscribe(games, domain.MetricsTopicOrders)
+	defer stopGames()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = metrics.Run(ctx) }()
+
+	for id, updates := range map[string]<-chan domain.MetricsUpdate{"books": booksUpdates, "games": gamesUpdates} {
+		select {
+		case update := <-updates:
+			if orders := update.Data.(domain.OrderMetrics); orders.Today != totals[id].Count {
+				t.Errorf("orders of %s = %d, want %d", id, orders.Today, totals[id].Count)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no orders update for %s", id)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// MaintenanceService holds the read-only mode of the API. The switch is stored in the database
+// so all instances follow it; each reloads it periodically and answers Status from memory.
+type MaintenanceService interface {
+	// Status returns the maintenance mode as last loaded, with the configured override applied
+	Status() domain.Maintenance
+
+	// SetMaintenance switches maintenance mode; retryAfter of 0 uses the configured default.
+	// Other instances follow within the refresh interval.
+	SetMaintenance(ctx context.Context, enabled bool, retryAfter int) (*domain.Maintenance, error)
+
+	// Run reloads the switch every refresh interval until ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type maintenanceService struct {
+	maintenanceRepo repository.MaintenanceRepository
+	cfg             *config.Maintenance
+	refreshInterval time.Duration
+
+	// current is the switch as last loaded from the database
+	current atomic.Pointer[domain.Maintenance]
+}
+
+func NewMaintenanceService(maintenanceRepo repository.MaintenanceRepository, cfg *config.Config) (MaintenanceService, error) {
+	refreshInterval, err := time.ParseDuration(cfg.Maintenance.RefreshInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse maintenance refresh interval: %w", err)
+	}
+
+	s := &maintenanceService{
+		maintenanceRepo: maintenanceRepo,
+		cfg:             &cfg.Maintenance,
+		refreshInterval: refreshInterval,
+	}
+	s.current.Store(&domain.Maintenance{})
+	return s, nil
+}
+
+func (s *maintenanceService) Status() domain.Maintenance {
+	status := *s.current.Load()
+	if s.cfg.Enabled {
+		status.Enabled = true
+		status.Forced = true
+	}
+	if status.RetryAfter <= 0 {
+		status.RetryAfter = s.cfg.RetryAfter
+	}
+	return status
+}
+
+func (s *maintenanceService) SetMaintenance(ctx context.Context, enabled bool, retryAfter int) (*domain.Maintenance, error) {
+	maintenance := &domain.Maintenance{
+		Enabled:    enabled,
+		RetryAfter: retryAfter,
+		UpdatedAt:  time.Now().UTC(),
+	}
+	if err := s.maintenanceRepo.Set(ctx, maintenance); err != nil {
+		return nil, fmt.Errorf("set maintenance: %w", err)
+	}
+	s.current.Store(maintenance)
+
+	logger.GetLoggerFromContext(ctx).WithComponent("maintenance").WithFields(logger.Fields{
+		"enabled":     enabled,
+		"retry_after": retryAfter,
+	}).Info("Maintenance mode switched")
+
+	status := s.Status()
+	return &status, nil
+}
+
+func (s *maintenanceService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		maintenance, err := s.maintenanceRepo.Get(ctx)
+		if err != nil {
+			// Keep the last known state rather than flipping modes on a failed read
+			if ctx.Err() == nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("maintenance").WithError(err).Error("Failed to load maintenance mode")
+			}
+		} else {
+			s.current.Store(maintenance)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
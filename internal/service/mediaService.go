@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// privateMediaPath is where the REST API serves private files
+const privateMediaPath = "/api/v1/media/private/"
+
+// MediaService signs time-limited URLs to private files and verifies them when the files are
+// requested. A URL is signed for the storefront it was issued by and can't be used on another.
+type MediaService interface {
+	// SignURL returns a URL to a private file valid for ttl, or for the configured default if
+	// ttl is 0
+	SignURL(ctx context.Context, path string, ttl time.Duration) (*domain.SignedURL, error)
+
+	// Open verifies the expiry and signature of a URL and returns the location of the file on
+	// disk. It returns ErrInvalidSignature for a tampered or expired URL.
+	Open(ctx context.Context, path string, expires int64, signature string) (string, error)
+}
+
+type mediaService struct {
+	dir       string
+	secret    []byte
+	baseURL   string
+	urlTTL    time.Duration
+	maxURLTTL time.Duration
+}
+
+func NewMediaService(cfg *config.Config) (MediaService, error) {
+	urlTTL, err := time.ParseDuration(cfg.Media.URLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse media url ttl: %w", err)
+	}
+
+	maxURLTTL, err := time.ParseDuration(cfg.Media.MaxURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse media max url ttl: %w", err)
+	}
+
+	return &mediaService{
+		dir:       cfg.Media.PrivateDir,
+		secret:    []byte(cfg.Media.SigningSecret),
+		baseURL:   cfg.Media.BaseURL,
+		urlTTL:    urlTTL,
+		maxURLTTL: maxURLTTL,
+	}, nil
+}
+
+func (s *mediaService) SignURL(ctx context.Context, path string, ttl time.Duration) (*domain.SignedURL, error) {
+	if len(s.secret) == 0 {
+		return nil, domain.ErrMediaDisabled
+	}
+
+	path, err := domain.CleanMediaPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl == 0 {
+		ttl = s.urlTTL
+	}
+	if ttl < 0 || ttl > s.maxURLTTL {
+		return nil, fmt.Errorf("ttl must be between 0 and %s: %w", s.maxURLTTL, domain.ErrValidation)
+	}
+
+	// Links to missing files would only ever answer 404
+	if _, err := os.Stat(s.location(ctx, path)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("stat media file: %w", err)
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	expires := expiresAt.Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(ctx, path, expires))
+	link := url.URL{Path: privateMediaPath + path, RawQuery: query.Encode()}
+
+	return &domain.SignedURL{
+		Path:      path,
+		URL:       s.baseURL + link.String(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *mediaService) Open(ctx context.Context, path string, expires int64, signature string) (string, error) {
+	if len(s.secret) == 0 {
+		return "", domain.ErrMediaDisabled
+	}
+
+	path, err := domain.CleanMediaPath(path)
+	if err != nil {
+		return "", domain.ErrInvalidSignature
+	}
+
+	if time.Now().Unix() > expires {
+		return "", domain.ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(ctx, path, expires))) {
+		return "", domain.ErrInvalidSignature
+	}
+
+	location := s.location(ctx, path)
+	info, err := os.Stat(location)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", domain.ErrNotFound
+		}
+		return "", fmt.Errorf("stat media file: %w", err)
+	}
+	if info.IsDir() {
+		return "", domain.ErrNotFound
+	}
+
+	return location, nil
+}
+
+// sign returns the URL-safe HMAC-SHA256 of the tenant, path and expiry
+func (s *mediaService) sign(ctx context.Context, path string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d", tenant.ID(ctx), path, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// location returns where a private file of the request's storefront is kept
+func (s *mediaService) location(ctx context.Context, path string) string {
+	return filepath.Join(s.dir, tenant.ID(ctx), filepath.FromSlash(path))
+}
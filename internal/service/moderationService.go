@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/moderation"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// ModerationService screens user-generated text against the banned terms and the moderation
+// API, and quarantines what they flag until a moderator approves or rejects it
+type ModerationService interface {
+	// Screen checks the text fields of a subject written by a user. The flagged fields are
+	// quarantined together in one item, which is returned, or nil if nothing was flagged.
+	// Callers save only the fields that weren't flagged.
+	Screen(ctx context.Context, subject string, subjectID, userID int, fields map[string]string) (*domain.ModerationItem, error)
+
+	// Quarantine queue
+	ListItems(ctx context.Context, status string, limit, offset int) ([]*domain.ModerationItem, int64, error)
+
+	// ResolveItem approves or rejects a quarantined item. Approving saves its text to the
+	// subject as it was written.
+	ResolveItem(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.ModerationItem, error)
+}
+
+type moderationService struct {
+	moderationRepo repository.ModerationRepository
+	profileRepo    repository.ProfileRepository
+	transactor     repository.Transactor
+	moderator      moderation.Moderator
+	failOpen       bool
+
+	// bannedTerms are the words of each term as matched; the last may end with *
+	bannedTerms [][]string
+}
+
+func NewModerationService(
+	moderationRepo repository.ModerationRepository,
+	profileRepo repository.ProfileRepository,
+	transactor repository.Transactor,
+	cfg *config.Config,
+) (ModerationService, error) {
+	terms := cfg.Moderation.BannedTerms
+	if cfg.Moderation.BannedTermsFile != "" {
+		fileTerms, err := readBannedTerms(cfg.Moderation.BannedTermsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read banned terms: %w", err)
+		}
+		terms = append(terms, fileTerms...)
+	}
+
+	moderator, err := moderation.New(&cfg.Moderation)
+	if err != nil {
+		return nil, fmt.Errorf("create moderator: %w", err)
+	}
+
+	s := &moderationService{
+		moderationRepo: moderationRepo,
+		profileRepo:    profileRepo,
+		transactor:     transactor,
+		moderator:      moderator,
+		failOpen:       cfg.Moderation.Webhook.FailOpen,
+	}
+	for _, term := range terms {
+		words := moderationWords(strings.TrimSuffix(term, "*"))
+		if len(words) == 0 {
+			continue
+		}
+		if strings.HasSuffix(term, "*") {
+			words[len(words)-1] += "*"
+		}
+		s.bannedTerms = append(s.bannedTerms, words)
+	}
+	return s, nil
+}
+
+// readBannedTerms reads one term per line, skipping blank lines and # comments
+func readBannedTerms(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, scanner.Err()
+}
+
+func (s *moderationService) Screen(ctx context.Context, subject string, subjectID, userID int, fields map[string]string) (*domain.ModerationItem, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var flags []domain.ModerationFlag
+	for _, name := range names {
+		if reason := s.check(ctx, fields[name]); reason != "" {
+			flags = append(flags, domain.ModerationFlag{Field: name, Reason: reason})
+		}
+	}
+	if len(flags) == 0 {
+		return nil, nil
+	}
+
+	item := &domain.ModerationItem{
+		Subject:   subject,
+		SubjectID: subjectID,
+		UserID:    userID,
+		Fields:    make(map[string]string, len(flags)),
+		Flags:     flags,
+	}
+	for _, flag := range flags {
+		item.Fields[flag.Field] = fields[flag.Field]
+	}
+	if err := s.moderationRepo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+
+	logger.GetLoggerFromContext(ctx).WithComponent("moderation").WithFields(logger.Fields{
+		"item_id": item.ID,
+		"subject": subject,
+		"user_id": userID,
+	}).Info("Content quarantined")
+
+	return item, nil
+}
+
+// check returns why the text is flagged, or "" if it isn't. A text with a banned term isn't
+// sent to the moderation API.
+func (s *moderationService) check(ctx context.Context, text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	if term := s.bannedTerm(text); term != "" {
+		return fmt.Sprintf("banned term %q", term)
+	}
+
+	verdict, err := s.moderator.Moderate(ctx, text)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("moderation").WithError(err).Warn("Moderation API failed")
+		if s.failOpen {
+			return ""
+		}
+		return "moderation API unavailable"
+	}
+	if !verdict.Flagged {
+		return ""
+	}
+	if len(verdict.Categories) == 0 {
+		return "flagged by the moderation API"
+	}
+	return "flagged for " + strings.Join(verdict.Categories, ", ")
+}
+
+// bannedTerm returns the first banned term the text contains as whole words, or ""
+func (s *moderationService) bannedTerm(text string) string {
+	words := moderationWords(text)
+	for _, term := range s.bannedTerms {
+		for start := 0; start+len(term) <= len(words); start++ {
+			if matchTermAt(words[start:], term) {
+				return strings.Join(term, " ")
+			}
+		}
+	}
+	return ""
+}
+
+func matchTermAt(words, term []string) bool {
+	for i, termWord := range term {
+		if prefix, ok := strings.CutSuffix(termWord, "*"); ok {
+			if !strings.HasPrefix(words[i], prefix) {
+				return false
+			}
+		} else if words[i] != termWord {
+			return false
+		}
+	}
+	return true
+}
+
+// leetReplacer undoes the common letter substitutions used to slip past word lists
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+
+// moderationWords splits text into lowercase words for matching, with digits and symbols
+// standing in for letters read as those letters
+func moderationWords(text string) []string {
+	return domain.NameWords(leetReplacer.Replace(strings.ToLower(text)))
+}
+
+func (s *moderationService) ListItems(ctx context.Context, status string, limit, offset int) ([]*domain.ModerationItem, int64, error) {
+	if status != "" && status != domain.ModerationQuarantined && status != domain.ModerationApproved && status != domain.ModerationRejected {
+		return nil, 0, fmt.Errorf("unknown moderation status %q: %w", status, domain.ErrValidation)
+	}
+
+	items, total, err := s.moderationRepo.ListItems(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list moderation items: %w", err)
+	}
+
+	return items, total, nil
+}
+
+func (s *moderationService) ResolveItem(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.ModerationItem, error) {
+	if status != domain.ModerationApproved && status != domain.ModerationRejected {
+		return nil, fmt.Errorf("content must be approved or rejected: %w", domain.ErrValidation)
+	}
+
+	var item *domain.ModerationItem
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		item, err = s.moderationRepo.Resolve(ctx, id, status, reviewerID, note)
+		if err != nil || status != domain.ModerationApproved {
+			return err
+		}
+		return s.apply(ctx, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// apply saves the text of an approved item to its subject
+func (s *moderationService) apply(ctx context.Context, item *domain.ModerationItem) error {
+	switch item.Subject {
+	case domain.ModerationSubjectProfile:
+		profile, err := s.profileRepo.GetByUserID(ctx, item.SubjectID)
+		if err != nil {
+			return fmt.Errorf("get profile: %w", err)
+		}
+		before := *profile
+		for field, text := range item.Fields {
+			setProfileTextField(profile, field, &text)
+		}
+		if err := s.profileRepo.Update(ctx, profile); err != nil {
+			return fmt.Errorf("update profile: %w", err)
+		}
+		recordProfileChange(ctx, s.profileRepo, item.SubjectID, changedProfileFields(&before, profile)...)
+		return nil
+	default:
+		return fmt.Errorf("unknown moderation subject %q", item.Subject)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/mailer"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/sms"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// NotificationService delivers messages to users by email or SMS. Marketing messages
+// respect the user's opt-ins and are silently skipped on channels the user didn't opt in to.
+type NotificationService interface {
+	// Notify reports whether the message was sent
+	Notify(ctx context.Context, userID int, notification domain.Notification) (bool, error)
+}
+
+type notificationService struct {
+	userRepo    repository.UserRepository
+	profileRepo repository.ProfileRepository
+	mailer      mailer.Mailer
+	sms         sms.Sender
+}
+
+func NewNotificationService(
+	userRepo repository.UserRepository,
+	profileRepo repository.ProfileRepository,
+	mailSender mailer.Mailer,
+	smsSender sms.Sender,
+) NotificationService {
+	return &notificationService{
+		userRepo:    userRepo,
+		profileRepo: profileRepo,
+		mailer:      mailSender,
+		sms:         smsSender,
+	}
+}
+
+func (s *notificationService) Notify(ctx context.Context, userID int, notification domain.Notification) (bool, error) {
+	if notification.Kind == domain.NotificationMarketing {
+		preferences, err := loadPreferences(ctx, s.profileRepo, userID)
+		if err != nil {
+			return false, err
+		}
+		if !preferences.AllowsMarketing(notification.Channel) {
+			logger.GetLoggerFromContext(ctx).WithComponent("notification").Debug("Marketing notification skipped, user not opted in",
+				"user_id", userID, "channel", notification.Channel)
+			return false, nil
+		}
+	}
+
+	switch notification.Channel {
+	case domain.NotificationChannelEmail:
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("get user: %w", err)
+		}
+		msg := mailer.Message{To: user.Email, Subject: notification.Subject, Body: notification.Body}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			return false, fmt.Errorf("send email: %w", err)
+		}
+
+	case domain.NotificationChannelSMS:
+		profile, err := s.profileRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return false, fmt.Errorf("get profile: %w", err)
+		}
+		// Only verified numbers receive messages
+		if profile.Phone == nil || !profile.PhoneVerified {
+			return false, nil
+		}
+		if err := s.sms.Send(ctx, *profile.Phone, notification.Body); err != nil {
+			return false, fmt.Errorf("send sms: %w", err)
+		}
+
+	default:
+		return false, fmt.Errorf("unknown notification channel %q: %w", notification.Channel, domain.ErrValidation)
+	}
+
+	return true, nil
+}
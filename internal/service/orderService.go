@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// OrderService lists and exports orders (purchase records) for staff
+type OrderService interface {
+	// ListOrders retrieves a page of the orders matching the filter, newest first
+	ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, int64, error)
+
+	// StreamOrders calls fn for each order matching the filter, newest first, as it is read
+	StreamOrders(ctx context.Context, filter domain.OrderFilter, fn func(*domain.Order) error) error
+}
+
+type orderService struct {
+	orderRepo repository.OrderRepository
+}
+
+func NewOrderService(orderRepo repository.OrderRepository) OrderService {
+	return &orderService{orderRepo: orderRepo}
+}
+
+func (s *orderService) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	orders, total, err := s.orderRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+func (s *orderService) StreamOrders(ctx context.Context, filter domain.OrderFilter, fn func(*domain.Order) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	return s.orderRepo.Stream(ctx, filter, fn)
+}
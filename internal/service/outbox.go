@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/eventbus"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// outboxHandlerTimeout bounds publishing a single event
+const outboxHandlerTimeout = 30 * time.Second
+
+// OutboxHandler publishes an event recorded on its topic. The entry ID stays the same across
+// redeliveries, so handlers can pass it on for consumers to drop duplicates.
+type OutboxHandler func(ctx context.Context, entry *domain.OutboxEntry) error
+
+// Outbox records events in the transaction of the change they report and relays them to the
+// handler of their topic afterwards, so an event is published if and only if its change is
+// committed. Delivery is at least once: an event whose handler fails, or whose relay stops
+// before marking it delivered, is published again.
+type Outbox interface {
+	// Record adds event on topic to the outbox. Call it inside Transactor.WithTransaction
+	// together with the change the event reports.
+	Record(ctx context.Context, topic string, event any) error
+
+	// Run relays recorded events every poll interval until ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type outbox struct {
+	outboxRepo   repository.OutboxRepository
+	handlers     map[string]OutboxHandler
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	retention    time.Duration
+}
+
+func NewOutbox(outboxRepo repository.OutboxRepository, handlers map[string]OutboxHandler, cfg *config.Config) (Outbox, error) {
+	pollInterval, err := time.ParseDuration(cfg.Outbox.PollInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse outbox poll interval: %w", err)
+	}
+
+	retention, err := time.ParseDuration(cfg.Outbox.Retention)
+	if err != nil {
+		return nil, fmt.Errorf("parse outbox retention: %w", err)
+	}
+
+	return &outbox{
+		outboxRepo:   outboxRepo,
+		handlers:     handlers,
+		pollInterval: pollInterval,
+		batchSize:    cfg.Outbox.BatchSize,
+		maxAttempts:  cfg.Outbox.MaxAttempts,
+		retention:    retention,
+	}, nil
+}
+
+// publishTo returns a handler publishing events of type T on topic of bus
+func publishTo[T any](bus *eventbus.Bus[T], topic string) OutboxHandler {
+	return func(ctx context.Context, entry *domain.OutboxEntry) error {
+		var event T
+		if err := json.Unmarshal(entry.Payload, &event); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		bus.Publish(topic, event)
+		return nil
+	}
+}
+
+func (o *outbox) Record(ctx context.Context, topic string, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	if err := o.outboxRepo.Add(ctx, &domain.OutboxEntry{Topic: topic, Payload: payload}); err != nil {
+		return fmt.Errorf("record event: %w", err)
+	}
+	return nil
+}
+
+func (o *outbox) Run(ctx context.Context) error {
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep relaying while full batches come back, then wait for new events
+		for {
+			relayed, err := o.relay(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("outbox").WithError(err).Error("Failed to relay events")
+			}
+			if err != nil || relayed < o.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay claims a batch of events, publishes them and returns how many were claimed
+func (o *outbox) relay(ctx context.Context) (int, error) {
+	// An event is locked long enough for its handler to finish or time out
+	lease := 2 * outboxHandlerTimeout
+	entries, err := o.outboxRepo.Claim(ctx, o.batchSize, o.maxAttempts, lease)
+	if err != nil {
+		return len(entries), fmt.Errorf("claim events: %w", err)
+	}
+
+	for _, entry := range entries {
+		if err := o.publish(ctx, entry); err != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("outbox").WithError(err).WithFields(logger.Fields{
+				"event":    entry.ID,
+				"topic":    entry.Topic,
+				"attempts": entry.Attempts + 1,
+			}).Warn("Failed to publish event")
+
+			if err := o.outboxRepo.MarkFailed(ctx, entry.ID, err.Error()); err != nil {
+				return len(entries), fmt.Errorf("mark event failed: %w", err)
+			}
+			continue
+		}
+
+		if err := o.outboxRepo.MarkDelivered(ctx, entry.ID, o.retention); err != nil {
+			return len(entries), fmt.Errorf("mark event delivered: %w", err)
+		}
+	}
+
+	return len(entries), nil
+}
+
+func (o *outbox) publish(ctx context.Context, entry *domain.OutboxEntry) error {
+	handler, ok := o.handlers[entry.Topic]
+	if !ok {
+		return fmt.Errorf("no handler for topic %q", entry.Topic)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outboxHandlerTimeout)
+	defer cancel()
+	return handler(ctx, entry)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/hibp"
+)
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+type PasswordPolicy interface {
+	// Validate returns an error wrapping domain.ErrWeakPassword that describes the first violated rule
+	Validate(ctx context.Context, password string) error
+}
+
+type passwordPolicy struct {
+	cfg     config.Password
+	banned  map[string]bool
+	breach  *hibp.Client
+	minSeen int
+}
+
+func NewPasswordPolicy(cfg *config.Config) (PasswordPolicy, error) {
+	policy := &passwordPolicy{
+		cfg:    cfg.Password,
+		banned: make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswords))
+	for scanner.Scan() {
+		if p := strings.TrimSpace(scanner.Text()); p != "" {
+			policy.banned[strings.ToLower(p)] = true
+		}
+	}
+	for _, p := range cfg.Password.BannedPasswords {
+		policy.banned[strings.ToLower(p)] = true
+	}
+
+	if cfg.Password.BreachCheck.Enabled {
+		timeout, err := time.ParseDuration(cfg.Password.BreachCheck.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("parse breach check timeout: %w", err)
+		}
+		policy.breach = hibp.New(timeout)
+		policy.minSeen = cfg.Password.BreachCheck.MinCount
+	}
+
+	return policy, nil
+}
+
+// Validate checks the password against the configured complexity rules, the banned
+// password list and, if enabled, the Have I Been Pwned breach corpus
+func (p *passwordPolicy) Validate(ctx context.Context, password string) error {
+	if len([]rune(password)) < p.cfg.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters long", domain.ErrWeakPassword, p.cfg.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+
+	if p.cfg.RequireUpper && !hasUpper {
+		return fmt.Errorf("%w: must contain an uppercase letter", domain.ErrWeakPassword)
+	}
+	if p.cfg.RequireLower && !hasLower {
+		return fmt.Errorf("%w: must contain a lowercase letter", domain.ErrWeakPassword)
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		return fmt.Errorf("%w: must contain a digit", domain.ErrWeakPassword)
+	}
+	if p.cfg.RequireSpecial && !hasSpecial {
+		return fmt.Errorf("%w: must contain a special character", domain.ErrWeakPassword)
+	}
+
+	if p.banned[strings.ToLower(password)] {
+		return fmt.Errorf("%w: password is too common", domain.ErrWeakPassword)
+	}
+
+	if p.breach != nil {
+		count, err := p.breach.BreachCount(ctx, password)
+		if err != nil {
+			// The breach check is best effort: an unavailable API must not block signups
+			return nil
+		}
+		if count >= p.minSeen {
+			return fmt.Errorf("%w: password has appeared in a data breach", domain.ErrWeakPassword)
+		}
+	}
+
+	return nil
+}
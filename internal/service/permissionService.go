@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+type PermissionService interface {
+	ListPermissions(ctx context.Context) ([]domain.Permission, error)
+	CreatePermission(ctx context.Context, permission *domain.Permission) error
+	DeletePermission(ctx context.Context, id int) error
+
+	// Roles
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	GetRole(ctx context.Context, id int) (*domain.Role, error)
+	CreateRole(ctx context.Context, role *domain.Role) error
+	UpdateRole(ctx context.Context, role *domain.Role) error
+	DeleteRole(ctx context.Context, id int) error
+
+	// Role mapping
+	GetRolePermissions(ctx context.Context, roleID int) ([]domain.Permission, error)
+	GrantPermission(ctx context.Context, roleID, permissionID int) error
+	RevokePermission(ctx context.Context, roleID, permissionID int) error
+
+	// AssignRole assigns the role with the name to a user. Roles are carried in access tokens,
+	// so the user gets it with their next token.
+	AssignRole(ctx context.Context, userID int, roleName string) error
+}
+
+type permissionService struct {
+	permissionRepo repository.PermissionRepository
+	roleRepo       repository.RoleRepository
+	roles          RoleCatalog
+	tenancy        *config.Tenancy
+}
+
+func NewPermissionService(permissionRepo repository.PermissionRepository, roleRepo repository.RoleRepository, roles RoleCatalog, tenancy *config.Tenancy) PermissionService {
+	return &permissionService{
+		permissionRepo: permissionRepo,
+		roleRepo:       roleRepo,
+		roles:          roles,
+		tenancy:        tenancy,
+	}
+}
+
+// checkAdminTenant allows changes to roles and permissions, which all tenants share, only from
+// the admin tenant, so a storefront's admins can't change what another storefront's users may do
+func (s *permissionService) checkAdminTenant(ctx context.Context) error {
+	if !s.tenancy.Enabled {
+		return nil
+	}
+	if s.tenancy.AdminTenant == "" || tenant.ID(ctx) != s.tenancy.AdminTenant {
+		return domain.ErrAdminTenantOnly
+	}
+	return nil
+}
+
+// ListPermissions retrieves all permissions
+func (s *permissionService) ListPermissions(ctx context.Context) ([]domain.Permission, error) {
+	permissions, err := s.permissionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// CreatePermission creates a new resource:action permission
+func (s *permissionService) CreatePermission(ctx context.Context, permission *domain.Permission) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	if !domain.IsValidPermissionPart(permission.Resource) || !domain.IsValidPermissionPart(permission.Action) {
+		return fmt.Errorf("resource and action must be lowercase identifiers or *: %w", domain.ErrValidation)
+	}
+
+	if err := s.permissionRepo.Create(ctx, permission); err != nil {
+		if err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("create permission: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePermission deletes a permission and removes it from all roles
+func (s *permissionService) DeletePermission(ctx context.Context, id int) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	if err := s.permissionRepo.Delete(ctx, id); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("delete permission: %w", err)
+	}
+
+	s.reloadRoles(ctx)
+	return nil
+}
+
+// ListRoles retrieves all roles
+func (s *permissionService) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetRole retrieves a role by ID
+func (s *permissionService) GetRole(ctx context.Context, id int) (*domain.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get role: %w", err)
+	}
+
+	return role, nil
+}
+
+// CreateRole creates a role inheriting the permissions of its parent roles
+func (s *permissionService) CreateRole(ctx context.Context, role *domain.Role) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	if !domain.IsValidRoleName(role.Name) {
+		return fmt.Errorf("role name must be a lowercase identifier: %w", domain.ErrValidation)
+	}
+
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("get roles: %w", err)
+	}
+	if err := checkRoleInherits(roles, role); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		if err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("create role: %w", err)
+	}
+
+	s.reloadRoles(ctx)
+	return nil
+}
+
+// UpdateRole changes the description and parent roles of a role. Its name can't change, as
+// issued access tokens carry it.
+func (s *permissionService) UpdateRole(ctx context.Context, role *domain.Role) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("get roles: %w", err)
+	}
+
+	var existing *domain.Role
+	for i := range roles {
+		if roles[i].ID == role.ID {
+			existing = &roles[i]
+		}
+	}
+	if existing == nil {
+		return domain.ErrNotFound
+	}
+
+	role.Name = existing.Name
+	role.CreatedAt = existing.CreatedAt
+	if err := checkRoleInherits(roles, role); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("update role: %w", err)
+	}
+
+	s.reloadRoles(ctx)
+	return nil
+}
+
+// DeleteRole deletes a role that is neither assigned to users nor inherited by other roles,
+// together with its permission grants. Built-in roles can't be deleted.
+func (s *permissionService) DeleteRole(ctx context.Context, id int) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("get roles: %w", err)
+	}
+
+	var role *domain.Role
+	for i := range roles {
+		if roles[i].ID == id {
+			role = &roles[i]
+		}
+		for _, parentID := range roles[i].Inherits {
+			if parentID == id {
+				return domain.ErrRoleInUse
+			}
+		}
+	}
+	if role == nil {
+		return domain.ErrNotFound
+	}
+	if domain.IsBuiltinRole(role.Name) {
+		return fmt.Errorf("built-in roles can't be deleted: %w", domain.ErrValidation)
+	}
+
+	users, err := s.roleRepo.CountUsers(ctx, id)
+	if err != nil {
+		return fmt.Errorf("count role users: %w", err)
+	}
+	if users > 0 {
+		return domain.ErrRoleInUse
+	}
+
+	if err := s.roleRepo.Delete(ctx, id); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("delete role: %w", err)
+	}
+
+	s.reloadRoles(ctx)
+	return nil
+}
+
+// checkRoleInherits checks that the parent roles of role exist and that inheriting them
+// doesn't make role its own ancestor
+func checkRoleInherits(roles []domain.Role, role *domain.Role) error {
+	parents := make(map[int][]int, len(roles))
+	for _, existing := range roles {
+		parents[existing.ID] = existing.Inherits
+	}
+
+	seen := make(map[int]bool, len(role.Inherits))
+	for _, parentID := range role.Inherits {
+		if _, ok := parents[parentID]; !ok {
+			return fmt.Errorf("inherited role %d does not exist: %w", parentID, domain.ErrValidation)
+		}
+		if seen[parentID] {
+			return fmt.Errorf("role %d is inherited twice: %w", parentID, domain.ErrValidation)
+		}
+		seen[parentID] = true
+	}
+
+	// A new role has no ID yet, so nothing can inherit it
+	if role.ID == 0 {
+		return nil
+	}
+	parents[role.ID] = role.Inherits
+
+	visited := make(map[int]bool)
+	queue := append([]int(nil), role.Inherits...)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == role.ID {
+			return fmt.Errorf("role can't inherit itself: %w", domain.ErrValidation)
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		queue = append(queue, parents[current]...)
+	}
+
+	return nil
+}
+
+// reloadRoles applies a role change to this instance at once; other instances pick it up
+// within the refresh interval
+func (s *permissionService) reloadRoles(ctx context.Context) {
+	if err := s.roles.Reload(ctx); err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("roles").WithError(err).Warn("Failed to reload roles")
+	}
+}
+
+// GetRolePermissions retrieves permissions granted to a role
+func (s *permissionService) GetRolePermissions(ctx context.Context, roleID int) ([]domain.Permission, error) {
+	if _, err := s.roleRepo.GetByID(ctx, roleID); err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get role: %w", err)
+	}
+
+	permissions, err := s.permissionRepo.GetByRoleID(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("get role permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// GrantPermission grants a permission to a role and the roles inheriting it
+func (s *permissionService) GrantPermission(ctx context.Context, roleID, permissionID int) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	if _, err := s.roleRepo.GetByID(ctx, roleID); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("get role: %w", err)
+	}
+
+	if _, err := s.permissionRepo.GetByID(ctx, permissionID); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("get permission: %w", err)
+	}
+
+	if err := s.permissionRepo.AssignToRole(ctx, roleID, permissionID); err != nil {
+		if err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("assign permission: %w", err)
+	}
+
+	s.reloadRoles(ctx)
+	return nil
+}
+
+// RevokePermission revokes a permission from a role
+func (s *permissionService) RevokePermission(ctx context.Context, roleID, permissionID int) error {
+	if err := s.checkAdminTenant(ctx); err != nil {
+		return err
+	}
+
+	if err := s.permissionRepo.RemoveFromRole(ctx, roleID, permissionID); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("remove permission: %w", err)
+	}
+
+	s.reloadRoles(ctx)
+	return nil
+}
+
+// AssignRole assigns a role by name to a user
+func (s *permissionService) AssignRole(ctx context.Context, userID int, roleName string) error {
+	roles, err := s.roleRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("get roles: %w", err)
+	}
+
+	for _, role := range roles {
+		if role.Name == roleName {
+			if err := s.roleRepo.AssignToUser(ctx, userID, role.ID); err != nil {
+				return fmt.Errorf("assign role: %w", err)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("role %q: %w", roleName, domain.ErrNotFound)
+}
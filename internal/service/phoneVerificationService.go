@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/sms"
+)
+
+const phoneCodeDigits = 6
+
+type PhoneVerificationService interface {
+	SendCode(ctx context.Context, userID int) error
+	VerifyCode(ctx context.Context, userID int, code string) error
+}
+
+type phoneVerificationService struct {
+	profileRepo      repository.ProfileRepository
+	verificationRepo repository.PhoneVerificationRepository
+	sender           sms.Sender
+	codeTTL          time.Duration
+	resendInterval   time.Duration
+	maxAttempts      int
+}
+
+func NewPhoneVerificationService(
+	profileRepo repository.ProfileRepository,
+	verificationRepo repository.PhoneVerificationRepository,
+	sender sms.Sender,
+	cfg *config.Config,
+) (PhoneVerificationService, error) {
+	codeTTL, err := time.ParseDuration(cfg.PhoneVerification.CodeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse code ttl: %w", err)
+	}
+
+	resendInterval, err := time.ParseDuration(cfg.PhoneVerification.ResendInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse resend interval: %w", err)
+	}
+
+	return &phoneVerificationService{
+		profileRepo:      profileRepo,
+		verificationRepo: verificationRepo,
+		sender:           sender,
+		codeTTL:          codeTTL,
+		resendInterval:   resendInterval,
+		maxAttempts:      cfg.PhoneVerification.MaxAttempts,
+	}, nil
+}
+
+// SendCode sends a verification code to the phone number on the user's profile
+func (s *phoneVerificationService) SendCode(ctx context.Context, userID int) error {
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if err != nil && err != domain.ErrNotFound {
+		return fmt.Errorf("get profile: %w", err)
+	}
+	if profile == nil || profile.Phone == nil || *profile.Phone == "" {
+		return fmt.Errorf("phone number is not set: %w", domain.ErrValidation)
+	}
+	if profile.PhoneVerified {
+		return fmt.Errorf("phone number is already verified: %w", domain.ErrValidation)
+	}
+
+	// Throttle resends to limit SMS costs and abuse
+	pending, err := s.verificationRepo.GetByUserID(ctx, userID)
+	if err != nil && err != domain.ErrNotFound {
+		return fmt.Errorf("get pending verification: %w", err)
+	}
+	if pending != nil && time.Since(pending.CreatedAt) < s.resendInterval {
+		return domain.ErrRateLimited
+	}
+
+	code, err := generateNumericCode(phoneCodeDigits)
+	if err != nil {
+		return err
+	}
+
+	verification := &domain.PhoneVerification{
+		UserID:    userID,
+		Phone:     *profile.Phone,
+		CodeHash:  hashToken(strconv.Itoa(userID) + ":" + code),
+		ExpiresAt: time.Now().Add(s.codeTTL),
+	}
+	if err := s.verificationRepo.Save(ctx, verification); err != nil {
+		return fmt.Errorf("save verification: %w", err)
+	}
+
+	body := fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.codeTTL)
+	if err := s.sender.Send(ctx, verification.Phone, body); err != nil {
+		return fmt.Errorf("send sms: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyCode checks the code and marks the profile phone as verified
+func (s *phoneVerificationService) VerifyCode(ctx context.Context, userID int, code string) error {
+	pending, err := s.verificationRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return domain.ErrInvalidToken
+		}
+		return fmt.Errorf("get pending verification: %w", err)
+	}
+
+	if time.Now().After(pending.ExpiresAt) || pending.Attempts >= s.maxAttempts {
+		if err := s.verificationRepo.Delete(ctx, userID); err != nil {
+			return fmt.Errorf("delete verification: %w", err)
+		}
+		return domain.ErrInvalidToken
+	}
+
+	expected := []byte(pending.CodeHash)
+	actual := []byte(hashToken(strconv.Itoa(userID) + ":" + code))
+	if subtle.ConstantTimeCompare(expected, actual) != 1 {
+		if err := s.verificationRepo.IncrementAttempts(ctx, userID); err != nil {
+			return fmt.Errorf("record failed attempt: %w", err)
+		}
+		return domain.ErrInvalidToken
+	}
+
+	// Fails if the phone number was changed after the code was sent
+	if err := s.profileRepo.MarkPhoneVerified(ctx, userID, pending.Phone); err != nil {
+		if err == domain.ErrNotFound {
+			return domain.ErrInvalidToken
+		}
+		return fmt.Errorf("mark phone verified: %w", err)
+	}
+
+	if err := s.verificationRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("delete verification: %w", err)
+	}
+
+	recordProfileChange(ctx, s.profileRepo, userID, "phone_verified")
+
+	return nil
+}
+
+// generateNumericCode returns a random code of the given number of digits
+func generateNumericCode(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+type PreferenceService interface {
+	GetPreferences(ctx context.Context, userID int) (*domain.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID int, update domain.PreferencesUpdate) (*domain.Preferences, error)
+}
+
+type preferenceService struct {
+	profileRepo repository.ProfileRepository
+	productRepo repository.ProductRepository
+}
+
+func NewPreferenceService(profileRepo repository.ProfileRepository, productRepo repository.ProductRepository) PreferenceService {
+	return &preferenceService{
+		profileRepo: profileRepo,
+		productRepo: productRepo,
+	}
+}
+
+// GetPreferences returns the user's preferences, or the defaults if they have no profile yet
+func (s *preferenceService) GetPreferences(ctx context.Context, userID int) (*domain.Preferences, error) {
+	preferences, err := loadPreferences(ctx, s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if preferences.FavoriteCategoryIDs == nil {
+		preferences.FavoriteCategoryIDs = []int{}
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences applies a partial update. Users without a profile get one created.
+func (s *preferenceService) UpdatePreferences(ctx context.Context, userID int, update domain.PreferencesUpdate) (*domain.Preferences, error) {
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	exists := err == nil
+
+	var before domain.Preferences
+	if exists {
+		before = profile.Preferences
+	}
+
+	preferences := before
+	if update.Locale != nil {
+		preferences.Locale = *update.Locale
+	}
+	if update.Currency != nil {
+		preferences.Currency = *update.Currency
+	}
+	if update.Timezone != nil {
+		preferences.Timezone = *update.Timezone
+	}
+	if update.FavoriteCategoryIDs != nil {
+		preferences.FavoriteCategoryIDs = uniqueInts(*update.FavoriteCategoryIDs)
+	}
+	if update.MarketingEmail != nil {
+		preferences.MarketingOptIns.Email = *update.MarketingEmail
+	}
+	if update.MarketingSMS != nil {
+		preferences.MarketingOptIns.SMS = *update.MarketingSMS
+	}
+	if update.PromotionsOptOut != nil {
+		preferences.PromotionsOptOut = *update.PromotionsOptOut
+	}
+
+	if err := preferences.Validate(); err != nil {
+		return nil, err
+	}
+	if update.FavoriteCategoryIDs != nil {
+		if err := s.checkCategories(ctx, preferences.FavoriteCategoryIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if exists {
+		if err := s.profileRepo.UpdatePreferences(ctx, userID, &preferences); err != nil {
+			return nil, fmt.Errorf("update preferences: %w", err)
+		}
+	} else {
+		if err := s.profileRepo.Create(ctx, &domain.Profile{UserID: userID, Preferences: preferences}); err != nil {
+			return nil, fmt.Errorf("create profile: %w", err)
+		}
+	}
+
+	recordProfileChange(ctx, s.profileRepo, userID, changedPreferenceFields(&before, &preferences)...)
+
+	if preferences.FavoriteCategoryIDs == nil {
+		preferences.FavoriteCategoryIDs = []int{}
+	}
+	return &preferences, nil
+}
+
+func (s *preferenceService) checkCategories(ctx context.Context, categoryIDs []int) error {
+	for _, categoryID := range categoryIDs {
+		if _, err := s.productRepo.GetCategoryByID(ctx, categoryID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("category %d does not exist: %w", categoryID, domain.ErrValidation)
+			}
+			return fmt.Errorf("get category: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadPreferences returns the preferences stored on the user's profile, or the defaults
+// if the user has no profile
+func loadPreferences(ctx context.Context, profileRepo repository.ProfileRepository, userID int) (*domain.Preferences, error) {
+	profile, err := profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return &domain.Preferences{}, nil
+		}
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	return &profile.Preferences, nil
+}
+
+// changedPreferenceFields returns the names of the preferences that differ, for the activity timeline
+func changedPreferenceFields(before, after *domain.Preferences) []string {
+	var fields []string
+	if before.Locale != after.Locale {
+		fields = append(fields, "preferences.locale")
+	}
+	if before.Currency != after.Currency {
+		fields = append(fields, "preferences.currency")
+	}
+	if before.Timezone != after.Timezone {
+		fields = append(fields, "preferences.timezone")
+	}
+	if !equalInts(before.FavoriteCategoryIDs, after.FavoriteCategoryIDs) {
+		fields = append(fields, "preferences.favorite_category_ids")
+	}
+	if before.MarketingOptIns != after.MarketingOptIns {
+		fields = append(fields, "preferences.marketing_opt_ins")
+	}
+	if before.PromotionsOptOut != after.PromotionsOptOut {
+		fields = append(fields, "preferences.promotions_opt_out")
+	}
+	return fields
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := make([]int, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// productImagesTopic is the outbox topic of uploaded product images waiting for their variants
+const productImagesTopic = "product_images"
+
+// maxImagePixels bounds the size of uploaded images, which are decoded whole
+const maxImagePixels = 40_000_000
+
+// variantJPEGQuality is the quality of the JPEG variants of JPEG uploads
+const variantJPEGQuality = 85
+
+// imageExtensions are the file extensions of the accepted upload formats
+var imageExtensions = map[string]string{
+	"jpeg": "jpg",
+	"png":  "png",
+	"gif":  "gif",
+}
+
+// ProductImageService accepts product image uploads. The image's variants are generated in the
+// background and set on the product once ready.
+type ProductImageService interface {
+	// Upload keeps the image as the original of the product's image and queues generating its
+	// variants. It returns ErrValidation for files that aren't JPEG, PNG or GIF images.
+	Upload(ctx context.Context, productID int, data []byte) (*domain.ProductImageJob, error)
+}
+
+type productImageService struct {
+	productRepo repository.ProductRepository
+	outbox      Outbox
+	privateDir  string
+}
+
+func NewProductImageService(productRepo repository.ProductRepository, outbox Outbox, cfg *config.Config) ProductImageService {
+	return &productImageService{
+		productRepo: productRepo,
+		outbox:      outbox,
+		privateDir:  cfg.Media.PrivateDir,
+	}
+}
+
+func (s *productImageService) Upload(ctx context.Context, productID int, data []byte) (*domain.ProductImageJob, error) {
+	// Check the size before decoding the whole image
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image must be a JPEG, PNG or GIF file: %w", domain.ErrValidation)
+	}
+	ext, ok := imageExtensions[format]
+	if !ok {
+		return nil, fmt.Errorf("image must be a JPEG, PNG or GIF file: %w", domain.ErrValidation)
+	}
+	if imageConfig.Width*imageConfig.Height > maxImagePixels {
+		return nil, fmt.Errorf("image must have at most %d pixels: %w", maxImagePixels, domain.ErrValidation)
+	}
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	// Uploads are named by their content, so uploading the same image again reuses the file
+	sum := sha256.Sum256(data)
+	original := path.Join(
+		domain.MediaOriginals, "products", strconv.Itoa(productID),
+		hex.EncodeToString(sum[:8])+"."+ext,
+	)
+	location := filepath.Join(s.privateDir, tenant.ID(ctx), filepath.FromSlash(original))
+	if err := writeFile(location, data); err != nil {
+		return nil, fmt.Errorf("save original image: %w", err)
+	}
+
+	job := &domain.ProductImageJob{
+		ProductID:  productID,
+		Tenant:     tenant.ID(ctx),
+		Original:   original,
+		UploadedAt: time.Now().UTC(),
+	}
+	if err := s.outbox.Record(ctx, productImagesTopic, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// generateImageVariants returns a handler generating the variants of uploaded product images and
+// setting them on their product. The original's variants replace the product's image unless an
+// image uploaded later is set.
+func generateImageVariants(productRepo repository.ProductRepository, cfg *config.Config) OutboxHandler {
+	return func(ctx context.Context, entry *domain.OutboxEntry) error {
+		var job domain.ProductImageJob
+		if err := json.Unmarshal(entry.Payload, &job); err != nil {
+			return fmt.Errorf("decode job: %w", err)
+		}
+
+		// The relay works for every storefront, so the job names its own
+		if job.Tenant != "" {
+			storefront := findTenant(&cfg.Tenancy, job.Tenant)
+			if storefront == nil {
+				return fmt.Errorf("unknown tenant %q", job.Tenant)
+			}
+			ctx = tenant.NewContext(ctx, storefront)
+		}
+
+		src, err := os.Open(filepath.Join(cfg.Media.PrivateDir, job.Tenant, filepath.FromSlash(job.Original)))
+		if err != nil {
+			return fmt.Errorf("open original image: %w", err)
+		}
+		original, format, err := image.Decode(src)
+		src.Close()
+		if err != nil {
+			return fmt.Errorf("decode original image: %w", err)
+		}
+
+		// Variants of each upload get their own directory, so caches never serve a stale one
+		name := path.Base(job.Original)
+		dir := path.Join(job.Tenant, "products", strconv.Itoa(job.ProductID), name[:len(name)-len(path.Ext(name))])
+
+		variants := make(map[string]string, 2*len(domain.ImageVariantSizes))
+		for _, size := range domain.ImageVariantSizes {
+			variant := fitImage(original, size.Size)
+
+			var buf bytes.Buffer
+			ext := "png"
+			if format == "jpeg" {
+				ext = "jpg"
+				err = jpeg.Encode(&buf, variant, &jpeg.Options{Quality: variantJPEGQuality})
+			} else {
+				err = png.Encode(&buf, variant)
+			}
+			if err != nil {
+				return fmt.Errorf("encode %s variant: %w", size.Name, err)
+			}
+
+			file := path.Join(dir, size.Name+"."+ext)
+			location := filepath.Join(cfg.Media.PublicDir, filepath.FromSlash(file))
+			if err := writeFile(location, buf.Bytes()); err != nil {
+				return fmt.Errorf("save %s variant: %w", size.Name, err)
+			}
+			variants[size.Name] = cfg.Media.PublicURL + "/" + file
+
+			if cfg.Media.WebPEncoder == "" {
+				continue
+			}
+			webpFile := path.Join(dir, size.Name+".webp")
+			webpLocation := filepath.Join(cfg.Media.PublicDir, filepath.FromSlash(webpFile))
+			if err := encodeWebP(ctx, cfg.Media.WebPEncoder, location, webpLocation); err != nil {
+				return fmt.Errorf("encode %s webp variant: %w", size.Name, err)
+			}
+			variants[size.Name+domain.ImageVariantWebPSuffix] = cfg.Media.PublicURL + "/" + webpFile
+		}
+
+		largest := domain.ImageVariantSizes[len(domain.ImageVariantSizes)-1].Name
+		err = productRepo.SetImage(ctx, job.ProductID, variants[largest], variants, job.UploadedAt)
+		if err == domain.ErrNotFound {
+			// The product was deleted in the meantime
+			return nil
+		}
+		return err
+	}
+}
+
+// findTenant returns the tenant with the ID, or nil if there is none
+func findTenant(tenancy *config.Tenancy, id string) *config.Tenant {
+	for i := range tenancy.Tenants {
+		if tenancy.Tenants[i].ID == id {
+			return &tenancy.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// fitImage scales img down to fit in a size×size square, keeping its aspect ratio. Smaller images
+// are kept as they are. Each pixel is the average of the pixels it covers, which keeps thin lines
+// and text legible.
+func fitImage(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	dstWidth, dstHeight := size, size
+	if width > height {
+		dstHeight = max(height*size/width, 1)
+	} else {
+		dstWidth = max(width*size/height, 1)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := y*height/dstHeight, max((y+1)*height/dstHeight, y*height/dstHeight+1)
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := x*width/dstWidth, max((x+1)*width/dstWidth, x*width/dstWidth+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// encodeWebP converts the image at input to WebP at output with the configured encoder
+func encodeWebP(ctx context.Context, encoder, input, output string) error {
+	out, err := exec.CommandContext(ctx, encoder, input, "-o", output).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// writeFile writes data to a new file at location, creating its directory. The file is written
+// under a temporary name and renamed, so readers never see a partial file.
+func writeFile(location string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(location), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(location), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), location)
+}
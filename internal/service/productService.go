@@ -2,23 +2,35 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
 )
 
 type ProductService interface {
 	// Product operations
 	CreateProduct(ctx context.Context, product *domain.Product) error
 	GetProduct(ctx context.Context, id int) (*domain.Product, error)
-	GetProductWithCategory(ctx context.Context, id int) (*domain.ProductWithCategory, error)
+	GetProductWithCategory(ctx context.Context, id int, fields []string) (*domain.ProductWithCategory, error)
 	UpdateProduct(ctx context.Context, product *domain.Product) error
 	DeleteProduct(ctx context.Context, id int) error
 
+	// Translations
+	GetTranslations(ctx context.Context, productID int) (map[string]domain.ProductTranslation, error)
+	SetTranslation(ctx context.Context, productID int, locale string, translation domain.ProductTranslation) error
+	DeleteTranslation(ctx context.Context, productID int, locale string) error
+
 	// Product listing and search
 	ListProducts(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error)
-	ListProductsWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error)
+	ListProductsWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, domain.ListTotal, error)
+	StreamProductsWithCategories(ctx context.Context, filter domain.ProductFilter, fn func(*domain.ProductWithCategory) error) error
+	// ListAdminProducts lists active and inactive products, unless filtered, with their margins
+	ListAdminProducts(ctx context.Context, filter domain.ProductFilter) ([]*domain.AdminProduct, int64, error)
 	SearchProducts(ctx context.Context, query string, limit, offset int) ([]*domain.Product, int64, error)
 
 	// Category operations
@@ -27,24 +39,58 @@ type ProductService interface {
 	GetCategoryByName(ctx context.Context, name string) (*domain.Category, error)
 	ListCategories(ctx context.Context) ([]*domain.Category, error)
 	UpdateCategory(ctx context.Context, category *domain.Category) error
-	DeleteCategory(ctx context.Context, id int) error
+	DeleteCategory(ctx context.Context, id int, moveTo *int) error
+	MergeCategory(ctx context.Context, sourceID, targetID int) (*domain.CategoryMergeResult, error)
 
 	// Product statistics
 	GetProductStatistics(ctx context.Context, productID int) (*domain.ProductStatistics, error)
 	RefreshStatistics(ctx context.Context) error
 
 	// Stock management
-	UpdateStock(ctx context.Context, productID int, quantity int) error
+	AdjustStock(ctx context.Context, adjustment *domain.StockAdjustment) (*domain.Product, error)
+	ListStockAdjustments(ctx context.Context, productID int, limit, offset int) ([]domain.StockAdjustment, int64, error)
+	ReconcileStock(ctx context.Context, productID int) (*domain.StockReconciliation, error)
 	CheckStock(ctx context.Context, productID int, quantity int) (bool, error)
+
+	// Currency returns the currency prices of the storefront of ctx are in
+	Currency(ctx context.Context) string
+
+	// Listing returns the page size and order settings of a product listing (domain.Listing*)
+	Listing(name string) domain.ListingSettings
 }
 
 type productService struct {
-	productRepo repository.ProductRepository
+	productRepo   repository.ProductRepository
+	stockRepo     repository.StockRepository
+	warehouseRepo repository.WarehouseRepository
+	stockFeed     StockFeed
+	tx            repository.Transactor
+	outbox        Outbox
+	categoryTree  categoryTreeCache
+	counts        countCache
+	currency      string
+	listings      map[string]domain.ListingSettings
 }
 
-func NewProductService(productRepo repository.ProductRepository) ProductService {
+func NewProductService(
+	productRepo repository.ProductRepository,
+	stockRepo repository.StockRepository,
+	warehouseRepo repository.WarehouseRepository,
+	stockFeed StockFeed,
+	tx repository.Transactor,
+	outbox Outbox,
+	currency string,
+	listings map[string]domain.ListingSettings,
+) ProductService {
 	return &productService{
-		productRepo: productRepo,
+		productRepo:   productRepo,
+		stockRepo:     stockRepo,
+		warehouseRepo: warehouseRepo,
+		stockFeed:     stockFeed,
+		tx:            tx,
+		outbox:        outbox,
+		currency:      currency,
+		listings:      listings,
 	}
 }
 
@@ -54,6 +100,20 @@ func (s *productService) CreateProduct(ctx context.Context, product *domain.Prod
 	if err := s.validateProduct(product); err != nil {
 		return err
 	}
+	if err := product.CheckMargin(); err != nil {
+		return err
+	}
+	if err := product.CheckPriceTiers(); err != nil {
+		return err
+	}
+	if err := product.CheckOrderQuantityRules(); err != nil {
+		return err
+	}
+	if product.PurchaseLimit != nil {
+		if err := product.PurchaseLimit.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// Check if category exists if provided
 	if product.CategoryID != nil {
@@ -72,7 +132,32 @@ func (s *productService) CreateProduct(ctx context.Context, product *domain.Prod
 	}
 	product.IsActive = true
 
-	return s.productRepo.Create(ctx, product)
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Create(ctx, product); err != nil {
+			return err
+		}
+
+		// Open the stock ledger with the initial stock
+		if product.Stock > 0 {
+			err := s.stockRepo.Record(ctx, &domain.StockAdjustment{
+				ProductID:  product.ID,
+				Delta:      product.Stock,
+				Reason:     domain.StockReasonInitial,
+				StockAfter: product.Stock,
+			})
+			if err != nil {
+				return fmt.Errorf("record initial stock: %w", err)
+			}
+		}
+
+		return s.recordProductEvent(ctx, domain.ProductCreated, product.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	return nil
 }
 
 // GetProduct retrieves a product by ID
@@ -80,9 +165,9 @@ func (s *productService) GetProduct(ctx context.Context, id int) (*domain.Produc
 	return s.productRepo.GetByID(ctx, id)
 }
 
-// GetProductWithCategory retrieves a product with category information
-func (s *productService) GetProductWithCategory(ctx context.Context, id int) (*domain.ProductWithCategory, error) {
-	return s.productRepo.GetByIDWithCategory(ctx, id)
+// GetProductWithCategory retrieves a product with category information, limited to fields if given
+func (s *productService) GetProductWithCategory(ctx context.Context, id int, fields []string) (*domain.ProductWithCategory, error) {
+	return s.productRepo.GetByIDWithCategory(ctx, id, fields)
 }
 
 // UpdateProduct updates a product
@@ -91,6 +176,14 @@ func (s *productService) UpdateProduct(ctx context.Context, product *domain.Prod
 	if err := s.validateProduct(product); err != nil {
 		return err
 	}
+	if err := product.CheckOrderQuantityRules(); err != nil {
+		return err
+	}
+	if product.PurchaseLimit != nil {
+		if err := product.PurchaseLimit.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// Check if product exists
 	existingProduct, err := s.productRepo.GetByID(ctx, product.ID)
@@ -98,6 +191,19 @@ func (s *productService) UpdateProduct(ctx context.Context, product *domain.Prod
 		return err
 	}
 
+	// A price or tier already below cost isn't rejected again until the prices or cost price change
+	pricesChanged := product.Price != existingProduct.Price || product.CostPrice != existingProduct.CostPrice
+	if pricesChanged {
+		if err := product.CheckMargin(); err != nil {
+			return err
+		}
+	}
+	if pricesChanged || !slices.Equal(product.PriceTiers, existingProduct.PriceTiers) {
+		if err := product.CheckPriceTiers(); err != nil {
+			return err
+		}
+	}
+
 	// Check if category exists if changed
 	if product.CategoryID != nil && (existingProduct.CategoryID == nil || *product.CategoryID != *existingProduct.CategoryID) {
 		_, err := s.productRepo.GetCategoryByID(ctx, *product.CategoryID)
@@ -109,7 +215,32 @@ func (s *productService) UpdateProduct(ctx context.Context, product *domain.Prod
 		}
 	}
 
-	return s.productRepo.Update(ctx, product)
+	// Keep the price before the last change, for price drop alerts
+	if product.Price != existingProduct.Price {
+		previousPrice, changedAt := existingProduct.Price, time.Now().UTC()
+		product.PreviousPrice = &previousPrice
+		product.PriceChangedAt = &changedAt
+	} else {
+		product.PreviousPrice = existingProduct.PreviousPrice
+		product.PriceChangedAt = existingProduct.PriceChangedAt
+	}
+
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Update(ctx, product); err != nil {
+			return err
+		}
+		return s.recordProductEvent(ctx, domain.ProductUpdated, product.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if product.Stock != existingProduct.Stock {
+		s.stockFeed.Publish(product)
+	}
+	s.categoryTree.invalidate()
+
+	return nil
 }
 
 // DeleteProduct deletes a product
@@ -120,18 +251,85 @@ func (s *productService) DeleteProduct(ctx context.Context, id int) error {
 		return err
 	}
 
-	return s.productRepo.Delete(ctx, id)
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.recordProductEvent(ctx, domain.ProductDeleted, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	return nil
 }
 
-// ListProducts retrieves a list of products with filtering
-func (s *productService) ListProducts(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error) {
-	// Set default values
-	if filter.Limit <= 0 {
-		filter.Limit = 20
+// GetTranslations returns the product's translations by locale
+func (s *productService) GetTranslations(ctx context.Context, productID int) (map[string]domain.ProductTranslation, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
 	}
-	if filter.Limit > 100 {
-		filter.Limit = 100 // Max limit
+
+	if product.Translations == nil {
+		return map[string]domain.ProductTranslation{}, nil
 	}
+	return product.Translations, nil
+}
+
+// SetTranslation creates or replaces the product's translation into locale
+func (s *productService) SetTranslation(ctx context.Context, productID int, locale string, translation domain.ProductTranslation) error {
+	if !domain.ValidLocale(locale) {
+		return fmt.Errorf("invalid locale %q: %w", locale, domain.ErrValidation)
+	}
+	if translation.Name == "" {
+		return fmt.Errorf("name is required: %w", domain.ErrValidation)
+	}
+
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.SetTranslation(ctx, productID, locale, translation); err != nil {
+			return err
+		}
+		return s.recordProductEvent(ctx, domain.ProductUpdated, productID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	return nil
+}
+
+// DeleteTranslation removes the product's translation into locale
+func (s *productService) DeleteTranslation(ctx context.Context, productID int, locale string) error {
+	if !domain.ValidLocale(locale) {
+		return fmt.Errorf("invalid locale %q: %w", locale, domain.ErrValidation)
+	}
+
+	err := s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.DeleteTranslation(ctx, productID, locale); err != nil {
+			return err
+		}
+		return s.recordProductEvent(ctx, domain.ProductUpdated, productID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	return nil
+}
+
+// recordProductEvent announces a product change, e.g. to keep the search index in sync. Call it
+// in the transaction of the change; the event is published once that commits.
+func (s *productService) recordProductEvent(ctx context.Context, eventType domain.ProductEventType, productID int) error {
+	return s.outbox.Record(ctx, productEventsTopic, domain.ProductEvent{Type: eventType, ProductID: productID})
+}
+
+// ListProducts retrieves a list of products with filtering
+func (s *productService) ListProducts(ctx context.Context, filter domain.ProductFilter) ([]*domain.Product, int64, error) {
+	s.applyListing(domain.ListingProducts, &filter)
 
 	// Default to showing only active products for public listing
 	if filter.IsActive == nil {
@@ -142,14 +340,60 @@ func (s *productService) ListProducts(ctx context.Context, filter domain.Product
 	return s.productRepo.List(ctx, filter)
 }
 
-// ListProductsWithCategories retrieves products with category names
-func (s *productService) ListProductsWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, int64, error) {
-	// Set default values
-	if filter.Limit <= 0 {
-		filter.Limit = 20
+// ListProductsWithCategories retrieves products with category names. Totals above the
+// EstimateCountsAbove of the listing are reused as estimates until they expire.
+func (s *productService) ListProductsWithCategories(ctx context.Context, filter domain.ProductFilter) ([]*domain.ProductWithCategory, domain.ListTotal, error) {
+	s.applyListing(domain.ListingProducts, &filter)
+
+	// Default to showing only active products for public listing
+	if filter.IsActive == nil {
+		active := true
+		filter.IsActive = &active
+	}
+
+	threshold := s.Listing(domain.ListingProducts).EstimateCountsAbove
+	if threshold > 0 {
+		if total, ok := s.counts.get(ctx, filter); ok {
+			filter.SkipCount = true
+			products, _, err := s.productRepo.ListWithCategories(ctx, filter)
+			if err != nil {
+				return nil, domain.ListTotal{}, err
+			}
+			return products, domain.ListTotal{Count: total, Estimated: true}, nil
+		}
+	}
+
+	products, total, err := s.productRepo.ListWithCategories(ctx, filter)
+	if err != nil {
+		return nil, domain.ListTotal{}, err
 	}
-	if filter.Limit > 100 {
-		filter.Limit = 100 // Max limit
+	if threshold > 0 && total > threshold {
+		s.counts.put(ctx, filter, total)
+	}
+	return products, domain.ListTotal{Count: total}, nil
+}
+
+// ListAdminProducts retrieves products with category names, cost prices and margins
+func (s *productService) ListAdminProducts(ctx context.Context, filter domain.ProductFilter) ([]*domain.AdminProduct, int64, error) {
+	s.applyListing(domain.ListingAdminProducts, &filter)
+
+	products, total, err := s.productRepo.ListWithCategories(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	admin := make([]*domain.AdminProduct, len(products))
+	for i, product := range products {
+		admin[i] = domain.NewAdminProduct(product)
+	}
+	return admin, total, nil
+}
+
+// StreamProductsWithCategories calls fn for every product matching the filter, for exports.
+// Unlike listing, the limit is not capped; a zero limit streams all matching products.
+func (s *productService) StreamProductsWithCategories(ctx context.Context, filter domain.ProductFilter, fn func(*domain.ProductWithCategory) error) error {
+	if filter.Sort == nil {
+		filter.Sort = s.Listing(domain.ListingProducts).DefaultSort
 	}
 
 	// Default to showing only active products for public listing
@@ -158,7 +402,7 @@ func (s *productService) ListProductsWithCategories(ctx context.Context, filter
 		filter.IsActive = &active
 	}
 
-	return s.productRepo.ListWithCategories(ctx, filter)
+	return s.productRepo.StreamWithCategories(ctx, filter, fn)
 }
 
 // SearchProducts performs full-text search on products
@@ -167,16 +411,24 @@ func (s *productService) SearchProducts(ctx context.Context, query string, limit
 		return nil, 0, fmt.Errorf("search query cannot be empty")
 	}
 
-	if limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
+	limit = s.Listing(domain.ListingSearch).Limit(limit)
 	return s.productRepo.Search(ctx, query, limit, offset)
 }
 
+func (s *productService) Listing(name string) domain.ListingSettings {
+	return listingSettings(s.listings, name)
+}
+
+// applyListing fills in the page size and order of the listing the request didn't set, and caps
+// the page size
+func (s *productService) applyListing(name string, filter *domain.ProductFilter) {
+	listing := s.Listing(name)
+	filter.Limit = listing.Limit(filter.Limit)
+	if filter.Sort == nil {
+		filter.Sort = listing.DefaultSort
+	}
+}
+
 // CreateCategory creates a new category
 func (s *productService) CreateCategory(ctx context.Context, category *domain.Category) error {
 	// Validate category
@@ -204,12 +456,29 @@ func (s *productService) CreateCategory(ctx context.Context, category *domain.Ca
 		}
 	}
 
-	return s.productRepo.CreateCategory(ctx, category)
+	if err := s.productRepo.CreateCategory(ctx, category); err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	s.decorateCategories(ctx, category)
+	return nil
 }
 
 // GetCategory retrieves a category by ID
 func (s *productService) GetCategory(ctx context.Context, id int) (*domain.Category, error) {
-	return s.productRepo.GetCategoryByID(ctx, id)
+	category, err := s.productRepo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := s.categoryTree.get(ctx, s.productRepo)
+	if err != nil {
+		return nil, err
+	}
+	tree.decorate(category)
+
+	return category, nil
 }
 
 // GetCategoryByName retrieves a category by name
@@ -219,7 +488,34 @@ func (s *productService) GetCategoryByName(ctx context.Context, name string) (*d
 
 // ListCategories retrieves all categories
 func (s *productService) ListCategories(ctx context.Context) ([]*domain.Category, error) {
-	return s.productRepo.ListCategories(ctx)
+	categories, err := s.productRepo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := s.categoryTree.get(ctx, s.productRepo)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		tree.decorate(category)
+	}
+
+	return categories, nil
+}
+
+// decorateCategories refreshes the product counts and breadcrumbs of categories returned from
+// a write. The write already succeeded, so when the tree cannot be loaded they are left out.
+func (s *productService) decorateCategories(ctx context.Context, categories ...*domain.Category) {
+	tree, err := s.categoryTree.get(ctx, s.productRepo)
+	for _, category := range categories {
+		if err != nil {
+			category.ProductCount = nil
+			category.Breadcrumbs = nil
+			continue
+		}
+		tree.decorate(category)
+	}
 }
 
 // UpdateCategory updates a category
@@ -251,21 +547,98 @@ func (s *productService) UpdateCategory(ctx context.Context, category *domain.Ca
 		}
 	}
 
-	return s.productRepo.UpdateCategory(ctx, category)
+	if err := s.productRepo.UpdateCategory(ctx, category); err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	s.decorateCategories(ctx, category)
+	return nil
 }
 
-// DeleteCategory deletes a category
-func (s *productService) DeleteCategory(ctx context.Context, id int) error {
+// DeleteCategory deletes a category. A category with products or child categories is only
+// deleted when moveTo is given, after merging it into that category.
+func (s *productService) DeleteCategory(ctx context.Context, id int, moveTo *int) error {
+	if moveTo != nil {
+		_, err := s.MergeCategory(ctx, id, *moveTo)
+		return err
+	}
+
 	// Check if category exists
 	_, err := s.productRepo.GetCategoryByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	// TODO: Check if category has products or subcategories
-	// For now, database CASCADE will handle it
+	products, children, err := s.productRepo.CountCategoryContents(ctx, id)
+	if err != nil {
+		return err
+	}
+	if products > 0 || children > 0 {
+		return fmt.Errorf("category has %d products and %d subcategories: %w", products, children, domain.ErrCategoryNotEmpty)
+	}
+
+	if err := s.productRepo.DeleteCategory(ctx, id); err != nil {
+		return err
+	}
+
+	s.categoryTree.invalidate()
+	return nil
+}
+
+// MergeCategory moves all products and child categories of the source category into the
+// target and deletes the source
+func (s *productService) MergeCategory(ctx context.Context, sourceID, targetID int) (*domain.CategoryMergeResult, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a category into itself: %w", domain.ErrValidation)
+	}
+
+	if _, err := s.productRepo.GetCategoryByID(ctx, sourceID); err != nil {
+		return nil, err
+	}
+
+	// The source's children move under the target, so the target must not be one of them
+	categories, err := s.productRepo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[int]*int, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	if _, ok := parents[targetID]; !ok {
+		return nil, fmt.Errorf("target category not found: %w", domain.ErrValidation)
+	}
+	for id, depth := targetID, 0; depth < len(categories); depth++ {
+		parentID := parents[id]
+		if parentID == nil {
+			break
+		}
+		if *parentID == sourceID {
+			return nil, fmt.Errorf("cannot merge a category into its own subcategory: %w", domain.ErrValidation)
+		}
+		id = *parentID
+	}
+
+	var result *domain.CategoryMergeResult
+	err = s.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		result, err = s.productRepo.MergeCategory(ctx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		for _, productID := range result.MovedProductIDs {
+			if err := s.recordProductEvent(ctx, domain.ProductUpdated, productID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.categoryTree.invalidate()
 
-	return s.productRepo.DeleteCategory(ctx, id)
+	return result, nil
 }
 
 // GetProductStatistics retrieves statistics for a product
@@ -284,20 +657,72 @@ func (s *productService) RefreshStatistics(ctx context.Context) error {
 	return s.productRepo.RefreshProductStatistics(ctx)
 }
 
-// UpdateStock updates product stock
-func (s *productService) UpdateStock(ctx context.Context, productID int, quantity int) error {
+// AdjustStock makes a manual stock adjustment and records it in the stock ledger, returning
+// the updated product. With a WarehouseID the stock at that warehouse is adjusted. Stock
+// cannot go below zero.
+func (s *productService) AdjustStock(ctx context.Context, adjustment *domain.StockAdjustment) (*domain.Product, error) {
+	if err := adjustment.ValidateManual(); err != nil {
+		return nil, err
+	}
+
+	if adjustment.WarehouseID != 0 {
+		if _, err := s.warehouseRepo.GetByID(ctx, adjustment.WarehouseID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("warehouse %d not found: %w", adjustment.WarehouseID, domain.ErrValidation)
+			}
+			return nil, fmt.Errorf("get warehouse: %w", err)
+		}
+	}
+
+	product, err := s.stockRepo.Adjust(ctx, adjustment)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrInsufficientStock) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("adjust stock: %w", err)
+	}
+
+	s.stockFeed.Publish(product)
+	return product, nil
+}
+
+// ListStockAdjustments retrieves a page of the product's stock ledger, newest first
+func (s *productService) ListStockAdjustments(ctx context.Context, productID int, limit, offset int) ([]domain.StockAdjustment, int64, error) {
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return nil, 0, err
+	}
+
+	adjustments, total, err := s.stockRepo.List(ctx, productID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list stock adjustments: %w", err)
+	}
+
+	return adjustments, total, nil
+}
+
+// ReconcileStock compares the product's current stock with the sum of its ledger
+func (s *productService) ReconcileStock(ctx context.Context, productID int) (*domain.StockReconciliation, error) {
 	product, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	newStock := product.Stock + quantity
-	if newStock < 0 {
-		return fmt.Errorf("insufficient stock")
+	byReason, err := s.stockRepo.SumByReason(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("sum stock adjustments: %w", err)
 	}
 
-	product.Stock = newStock
-	return s.productRepo.Update(ctx, product)
+	reconciliation := &domain.StockReconciliation{
+		ProductID: productID,
+		Stock:     product.Stock,
+		ByReason:  byReason,
+	}
+	for _, delta := range byReason {
+		reconciliation.LedgerStock += delta
+	}
+	reconciliation.Unaccounted = reconciliation.Stock - reconciliation.LedgerStock
+
+	return reconciliation, nil
 }
 
 // CheckStock checks if sufficient stock is available
@@ -310,16 +735,33 @@ func (s *productService) CheckStock(ctx context.Context, productID int, quantity
 	return product.Stock >= quantity, nil
 }
 
+func (s *productService) Currency(ctx context.Context) string {
+	return storefrontCurrency(ctx, s.currency)
+}
+
+// storefrontCurrency returns the currency of the tenant of ctx, or fallback (payment.currency)
+// when it isn't scoped to one
+func storefrontCurrency(ctx context.Context, fallback string) string {
+	if storefront := tenant.FromContext(ctx); storefront != nil {
+		return storefront.Currency
+	}
+	return fallback
+}
+
 // validateProduct validates product data
 func (s *productService) validateProduct(product *domain.Product) error {
 	if product.Name == "" {
 		return fmt.Errorf("product name is required")
 	}
 
-	if product.Price < 0 {
+	if product.Price.IsNegative() {
 		return fmt.Errorf("product price cannot be negative")
 	}
 
+	if product.CostPrice.IsNegative() {
+		return fmt.Errorf("product cost price cannot be negative")
+	}
+
 	if product.Stock < 0 {
 		return fmt.Errorf("product stock cannot be negative")
 	}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository/memory"
+)
+
+// newMemoryProductService returns a product service on empty in-memory repositories
+func newMemoryProductService(t *testing.T) ProductService {
+	t.Helper()
+
+	repos := memory.NewStore().Repositories()
+	cfg := &config.Config{
+		Outbox:   config.Outbox{PollInterval: "1s", Retention: "168h"},
+		Realtime: config.Realtime{Source: realtimeSourceLocal, Heartbeat: "30s"},
+	}
+
+	outbox, err := NewOutbox(repos.Outbox, nil, cfg)
+	if err != nil {
+		t.Fatalf("new outbox: %v", err)
+	}
+	stockFeed, err := NewStockFeed(repos.Product, cfg)
+	if err != nil {
+		t.Fatalf("new stock feed: %v", err)
+	}
+	return NewProductService(repos.Product, repos.Stock, repos.Warehouse, stockFeed, repos.Transactor, outbox, "USD", nil)
+}
+
+func TestAdjustStockKeepsLedgerReconciled(t *testing.T) {
+	ctx := context.Background()
+	products := newMemoryProductService(t)
+
+	product := &domain.Product{Name: "Laptop", Price: domain.NewMoney(99999, "USD"), Stock: 10}
+	if err := products.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	adjustments := []struct {
+		name   string
+		delta  int
+		reason string
+		stock  int
+		err    error
+	}{
+		{"restock", 5, domain.StockReasonRestock, 15, nil},
+		{"damage", -3, domain.StockReasonDamage, 12, nil},
+		{"more than in stock", -20, domain.StockReasonDamage, 12, domain.ErrInsufficientStock},
+		{"not a manual reason", 1, domain.StockReasonPurchase, 12, domain.ErrValidation},
+		{"zero delta", 0, domain.StockReasonCorrection, 12, domain.ErrValidation},
+	}
+	for _, tt := range adjustments {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := products.AdjustStock(ctx, &domain.StockAdjustment{ProductID: product.ID, Delta: tt.delta, Reason: tt.reason})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("adjust stock: err = %v, want %v", err, tt.err)
+			}
+			if err == nil && updated.Stock != tt.stock {
+				t.Errorf("stock = %d, want %d", updated.Stock, tt.stock)
+			}
+
+			current, err := products.GetProduct(ctx, product.ID)
+			if err != nil {
+				t.Fatalf("get product: %v", err)
+			}
+			if current.Stock != tt.stock {
+				t.Errorf("stored stock = %d, want %d", current.Stock, tt.stock)
+			}
+		})
+	}
+
+	reconciliation, err := products.ReconcileStock(ctx, product.ID)
+	if err != nil {
+		t.Fatalf("reconcile stock: %v", err)
+	}
+	if reconciliation.Unaccounted != 0 || reconciliation.LedgerStock != 12 {
+		t.Errorf("reconciliation = %+v, want ledger stock 12 and nothing unaccounted", reconciliation)
+	}
+	want := map[string]int{domain.StockReasonInitial: 10, domain.StockReasonRestock: 5, domain.StockReasonDamage: -3}
+	for reason, delta := range want {
+		if reconciliation.ByReason[reason] != delta {
+			t.Errorf("ledger %s = %d, want %d", reason, reconciliation.ByReason[reason], delta)
+		}
+	}
+
+	if _, err := products.AdjustStock(ctx, &domain.StockAdjustment{ProductID: 999, Delta: 1, Reason: domain.StockReasonRestock}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("adjust stock of a missing product: err = %v, want %v", err, domain.ErrNotFound)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// couponCodeBytes is the randomness of a coupon code, encoded as 8 characters
+const couponCodeBytes = 5
+
+// PromotionService manages the templates of birthday and anniversary promotions and grants
+// their coupons in the background
+type PromotionService interface {
+	CreatePromotion(ctx context.Context, template *domain.PromotionTemplate) error
+	GetPromotion(ctx context.Context, id int) (*domain.PromotionTemplate, error)
+	ListPromotions(ctx context.Context) ([]*domain.PromotionTemplate, error)
+	UpdatePromotion(ctx context.Context, template *domain.PromotionTemplate) error
+	DeletePromotion(ctx context.Context, id int) error
+
+	// ListUserCoupons retrieves the coupons granted to a user, newest first
+	ListUserCoupons(ctx context.Context, userID int) ([]*domain.Coupon, error)
+
+	// Run grants the coupons of the active promotions to the users with an occasion today, in
+	// their time zone, each check interval until ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type promotionService struct {
+	promotionRepo repository.PromotionRepository
+	notifications NotificationService
+	location      *time.Location
+	checkInterval time.Duration
+	tenancy       *config.Tenancy
+}
+
+func NewPromotionService(
+	promotionRepo repository.PromotionRepository,
+	notifications NotificationService,
+	cfg *config.Config,
+) (PromotionService, error) {
+	location, err := time.LoadLocation(cfg.Promotions.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load promotions timezone: %w", err)
+	}
+
+	checkInterval, err := time.ParseDuration(cfg.Promotions.CheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse promotions check interval: %w", err)
+	}
+
+	return &promotionService{
+		promotionRepo: promotionRepo,
+		notifications: notifications,
+		location:      location,
+		checkInterval: checkInterval,
+		tenancy:       &cfg.Tenancy,
+	}, nil
+}
+
+// CreatePromotion creates a promotion template; an active one grants coupons from the next check
+func (s *promotionService) CreatePromotion(ctx context.Context, template *domain.PromotionTemplate) error {
+	if err := template.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.promotionRepo.Create(ctx, template); err != nil {
+		return fmt.Errorf("create promotion: %w", err)
+	}
+
+	return nil
+}
+
+// GetPromotion retrieves a promotion template by ID
+func (s *promotionService) GetPromotion(ctx context.Context, id int) (*domain.PromotionTemplate, error) {
+	template, err := s.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get promotion: %w", err)
+	}
+
+	return template, nil
+}
+
+// ListPromotions retrieves all promotion templates
+func (s *promotionService) ListPromotions(ctx context.Context) ([]*domain.PromotionTemplate, error) {
+	templates, err := s.promotionRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list promotions: %w", err)
+	}
+
+	return templates, nil
+}
+
+// UpdatePromotion changes a promotion template. Coupons already granted keep their discount
+// and expiry.
+func (s *promotionService) UpdatePromotion(ctx context.Context, template *domain.PromotionTemplate) error {
+	if err := template.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.GetPromotion(ctx, template.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.promotionRepo.Update(ctx, template); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("update promotion: %w", err)
+	}
+
+	template.CreatedAt = existing.CreatedAt
+	return nil
+}
+
+// DeletePromotion deletes a promotion template; the coupons it granted can still be redeemed
+func (s *promotionService) DeletePromotion(ctx context.Context, id int) error {
+	if err := s.promotionRepo.Delete(ctx, id); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("delete promotion: %w", err)
+	}
+
+	return nil
+}
+
+func (s *promotionService) ListUserCoupons(ctx context.Context, userID int) ([]*domain.Coupon, error) {
+	coupons, err := s.promotionRepo.ListUserCoupons(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list coupons: %w", err)
+	}
+
+	return coupons, nil
+}
+
+func (s *promotionService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, tenantCtx := range tenant.Contexts(ctx, s.tenancy) {
+			for _, occasion := range []string{domain.PromotionBirthday, domain.PromotionAnniversary} {
+				if err := s.grantDue(tenantCtx, occasion, time.Now()); err != nil && ctx.Err() == nil {
+					logger.GetLoggerFromContext(ctx).WithComponent("promotions").WithError(err).WithFields(logger.Fields{
+						"tenant":   tenant.ID(tenantCtx),
+						"occasion": occasion,
+					}).Error("Failed to grant promotions")
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// grantDue grants the coupons of the occasion's active templates to the users whose occasion is
+// today in their time zone. Every check grants the users it finds, so a missed check is made up
+// by the next one the same day; a coupon is granted once per template and year.
+func (s *promotionService) grantDue(ctx context.Context, occasion string, now time.Time) error {
+	templates, err := s.promotionRepo.ListActive(ctx, occasion)
+	if err != nil {
+		return fmt.Errorf("list promotions: %w", err)
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+
+	// Today in any time zone is yesterday, today or tomorrow in UTC. A February 29 occasion
+	// falls on February 28 in other years.
+	var days []string
+	for _, offset := range []int{-1, 0, 1} {
+		day := now.UTC().AddDate(0, 0, offset).Format("01-02")
+		days = append(days, day)
+		if day == "02-28" {
+			days = append(days, "02-29")
+		}
+	}
+
+	recipients, err := s.promotionRepo.ListRecipients(ctx, occasion, days)
+	if err != nil {
+		return fmt.Errorf("list recipients: %w", err)
+	}
+
+	log := logger.GetLoggerFromContext(ctx).WithComponent("promotions")
+	var granted, failed int
+	for i := range recipients {
+		recipient := &recipients[i]
+		if ctx.Err() != nil {
+			return nil
+		}
+		if recipient.Preferences.PromotionsOptOut {
+			continue
+		}
+
+		today := now.In(recipient.Preferences.Location(s.location))
+		year := recipient.OccasionYear(occasion, today)
+		if year == 0 {
+			continue
+		}
+
+		for _, template := range templates {
+			ok, err := s.grant(ctx, template, recipient, year, now)
+			switch {
+			case err != nil:
+				failed++
+				log.WithError(err).Warn("Failed to grant promotion", "user_id", recipient.UserID, "promotion_id", template.ID)
+			case ok:
+				granted++
+			}
+		}
+	}
+
+	if granted > 0 || failed > 0 {
+		log.WithFields(logger.Fields{
+			"tenant":   tenant.ID(ctx),
+			"occasion": occasion,
+			"granted":  granted,
+			"failed":   failed,
+		}).Info("Granted promotions")
+	}
+
+	return nil
+}
+
+// grant gives the recipient the template's coupon for the year and notifies them, reporting
+// whether the coupon is new
+func (s *promotionService) grant(ctx context.Context, template *domain.PromotionTemplate, recipient *domain.PromotionRecipient, year int, now time.Time) (bool, error) {
+	code, err := generateCouponCode()
+	if err != nil {
+		return false, err
+	}
+
+	coupon := &domain.Coupon{
+		Code:       code,
+		UserID:     recipient.UserID,
+		TemplateID: template.ID,
+		Occasion:   template.Occasion,
+		Year:       year,
+		PercentOff: template.PercentOff,
+		IssuedAt:   now.UTC(),
+		ExpiresAt:  now.UTC().AddDate(0, 0, template.ValidDays),
+	}
+	granted, err := s.promotionRepo.GrantCoupon(ctx, coupon)
+	if err != nil || !granted {
+		return false, err
+	}
+
+	// The coupon stays granted if the notification fails; it is listed with the user's coupons
+	if _, err := s.notifications.Notify(ctx, recipient.UserID, template.Notification(recipient.FirstName, coupon)); err != nil {
+		return true, fmt.Errorf("notify: %w", err)
+	}
+
+	return true, nil
+}
+
+// generateCouponCode returns a random code of upper-case letters and digits
+func generateCouponCode() (string, error) {
+	b := make([]byte, couponCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate coupon code: %w", err)
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
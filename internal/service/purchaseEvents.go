@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// purchasesTopic is the outbox and event bus topic purchases of signed-in users are published on
+const purchasesTopic = "purchases"
+
+// purchaseEventsInteractionRepository records each purchase of a signed-in user together with an
+// outbox event, so purchases made through any service reach the recommendations
+type purchaseEventsInteractionRepository struct {
+	repository.InteractionRepository
+	tx     repository.Transactor
+	outbox Outbox
+}
+
+func newPurchaseEventsInteractionRepository(interactionRepo repository.InteractionRepository, tx repository.Transactor, outbox Outbox) repository.InteractionRepository {
+	return &purchaseEventsInteractionRepository{
+		InteractionRepository: interactionRepo,
+		tx:                    tx,
+		outbox:                outbox,
+	}
+}
+
+func (r *purchaseEventsInteractionRepository) RecordPurchase(ctx context.Context, userID, productID int, quantity int, price, cost domain.Money) error {
+	return r.tx.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.InteractionRepository.RecordPurchase(ctx, userID, productID, quantity, price, cost); err != nil {
+			return err
+		}
+		return r.outbox.Record(ctx, purchasesTopic, domain.PurchaseEvent{
+			UserID:    userID,
+			ProductID: productID,
+			Tenant:    tenant.ID(ctx),
+		})
+	})
+}
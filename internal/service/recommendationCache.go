@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// recommendationCache caches the recommendations of each user, per limit, until they expire or
+// the user's entries are invalidated. With a zero ttl nothing is cached.
+type recommendationCache struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	users      map[recommendationCacheUser]map[int]*cachedRecommendations
+	generation uint64
+	sweptAt    time.Time
+}
+
+type recommendationCacheUser struct {
+	tenant string
+	userID int
+}
+
+type cachedRecommendations struct {
+	response  *domain.RecommendationResponse
+	expiresAt time.Time
+}
+
+// get returns a copy of the cached recommendations of the user of the tenant of ctx, or nil and
+// the generation to pass to put once they are computed
+func (c *recommendationCache) get(ctx context.Context, userID, limit int) (*domain.RecommendationResponse, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := recommendationCacheUser{tenant: tenant.ID(ctx), userID: userID}
+	if cached := c.users[key][limit]; cached != nil && time.Now().Before(cached.expiresAt) {
+		response := *cached.response
+		response.Recommendations = slices.Clone(response.Recommendations)
+		return &response, 0
+	}
+	return nil, c.generation
+}
+
+// stale returns a copy of the cached recommendations of the user even if they have expired, or
+// nil if there are none, to serve when they can't be computed
+func (c *recommendationCache) stale(ctx context.Context, userID, limit int) *domain.RecommendationResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := c.users[recommendationCacheUser{tenant: tenant.ID(ctx), userID: userID}][limit]
+	if cached == nil {
+		return nil
+	}
+	response := *cached.response
+	response.Recommendations = slices.Clone(response.Recommendations)
+	return &response
+}
+
+// put caches recommendations computed since get returned generation. Recommendations computed
+// while the cache was invalidated may already be stale, so they are not stored.
+func (c *recommendationCache) put(ctx context.Context, userID, limit int, response *domain.RecommendationResponse, generation uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	now := time.Now().UTC()
+	c.sweep(now)
+
+	if c.users == nil {
+		c.users = make(map[recommendationCacheUser]map[int]*cachedRecommendations)
+	}
+	key := recommendationCacheUser{tenant: tenant.ID(ctx), userID: userID}
+	if c.users[key] == nil {
+		c.users[key] = make(map[int]*cachedRecommendations)
+	}
+	c.users[key][limit] = &cachedRecommendations{response: response, expiresAt: now.Add(c.ttl)}
+}
+
+// invalidate drops the cached recommendations of a user of a tenant
+func (c *recommendationCache) invalidate(tenantID string, userID int) {
+	c.mu.Lock()
+	delete(c.users, recommendationCacheUser{tenant: tenantID, userID: userID})
+	c.generation++
+	c.mu.Unlock()
+}
+
+// sweep drops expired entries, at most once per ttl. Call it with mu held.
+func (c *recommendationCache) sweep(now time.Time) {
+	if now.Sub(c.sweptAt) < c.ttl {
+		return
+	}
+	c.sweptAt = now
+
+	for key, entries := range c.users {
+		for limit, cached := range entries {
+			if !now.Before(cached.expiresAt) {
+				delete(entries, limit)
+			}
+		}
+		if len(entries) == 0 {
+			delete(c.users, key)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+)
+
+// recentPurchasesLimit bounds how many of the user's latest purchases post-purchase rules look at
+const recentPurchasesLimit = 20
+
+// postPurchaseParams are the post-purchase rules applied to recommendations
+type postPurchaseParams struct {
+	window      time.Duration
+	accessories int
+}
+
+// accessory is a product bought by the given share of the other buyers of a recent purchase
+type accessory struct {
+	productID   int
+	purchasedID int
+	share       float64
+}
+
+// recentPurchases returns the user's purchases within the post-purchase window, most recent first
+func (s *recommendationService) recentPurchases(ctx context.Context, userID int) ([]domain.ProductInteraction, error) {
+	purchases, _, err := s.interactionRepo.GetUserPurchases(ctx, userID, recentPurchasesLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get recent purchases: %w", err)
+	}
+
+	since := time.Now().Add(-s.postPurchase.window)
+	for i, purchase := range purchases {
+		if purchase.InteractedAt.Before(since) {
+			return purchases[:i], nil
+		}
+	}
+	return purchases, nil
+}
+
+// applyPostPurchase drops the recommendations in the categories of the user's recent purchases,
+// which would mostly be alternatives to what they just bought, and adds the products other
+// buyers bought together with them
+func (s *recommendationService) applyPostPurchase(ctx context.Context, userID int, resp *domain.RecommendationResponse, recent []domain.ProductInteraction, names *catalogNames) error {
+	boughtCategories := make(map[int]bool, len(recent))
+	for _, purchase := range recent {
+		if purchase.CategoryID != 0 {
+			boughtCategories[purchase.CategoryID] = true
+		}
+	}
+
+	kept := resp.Recommendations[:0]
+	for _, recommendation := range resp.Recommendations {
+		if !boughtCategories[recommendation.CategoryID] {
+			kept = append(kept, recommendation)
+		}
+	}
+	resp.Recommendations = kept
+
+	accessories, err := s.accessories(ctx, userID, recent, boughtCategories, names)
+	if err != nil {
+		return err
+	}
+
+	// An accessory every other buyer bought ranks with the top recommendation
+	top := 1.0
+	positions := make(map[int]int, len(resp.Recommendations))
+	for i, recommendation := range resp.Recommendations {
+		top = math.Max(top, recommendation.Score)
+		positions[recommendation.ProductID] = i
+	}
+
+	for _, accessory := range accessories {
+		source := reasonSource{kind: domain.ReasonAccessory, productID: accessory.purchasedID}
+		score := accessory.share * top
+
+		if i, ok := positions[accessory.productID]; ok {
+			names.addScoreReason(ctx, &resp.Recommendations[i], source, score)
+			continue
+		}
+
+		product := names.product(ctx, accessory.productID)
+		categoryID := 0
+		if product.CategoryID != nil {
+			categoryID = *product.CategoryID
+		}
+
+		recommendation := domain.ProductRecommendation{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			CategoryID:  categoryID,
+			Price:       product.Price,
+		}
+		names.addScoreReason(ctx, &recommendation, source, score)
+		resp.Recommendations = append(resp.Recommendations, recommendation)
+	}
+
+	return nil
+}
+
+// accessories returns the active products other buyers of the recent purchases most often bought
+// too, outside the categories of the recent purchases and excluding what the user already bought
+// or dismissed
+func (s *recommendationService) accessories(ctx context.Context, userID int, recent []domain.ProductInteraction, boughtCategories map[int]bool, names *catalogNames) ([]accessory, error) {
+	allPurchases, err := s.interactionRepo.GetAllUserPurchases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all purchases: %w", err)
+	}
+
+	excludedProducts, err := s.getExcludedProducts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recentProducts := make(map[int]bool, len(recent))
+	for _, purchase := range recent {
+		recentProducts[purchase.ProductID] = true
+	}
+
+	// Who bought the recent purchases, and what each of them bought
+	buyers := make(map[int]map[int]bool)
+	bought := make(map[int]map[int]bool)
+	for _, purchase := range allPurchases {
+		switch {
+		case purchase.UserID == userID:
+			excludedProducts[purchase.ProductID] = true
+			continue
+		case purchase.UserID == 0:
+			// Guest checkouts can't be told apart
+			continue
+		}
+
+		if bought[purchase.UserID] == nil {
+			bought[purchase.UserID] = make(map[int]bool)
+		}
+		bought[purchase.UserID][purchase.ProductID] = true
+
+		if recentProducts[purchase.ProductID] {
+			if buyers[purchase.ProductID] == nil {
+				buyers[purchase.ProductID] = make(map[int]bool)
+			}
+			buyers[purchase.ProductID][purchase.UserID] = true
+		}
+	}
+
+	// Each product is attributed to the recent purchase whose buyers bought it most often
+	best := make(map[int]accessory)
+	for _, purchase := range recent {
+		purchaseBuyers := buyers[purchase.ProductID]
+		if len(purchaseBuyers) == 0 {
+			continue
+		}
+
+		counts := make(map[int]int)
+		for buyer := range purchaseBuyers {
+			for productID := range bought[buyer] {
+				if !excludedProducts[productID] && !recentProducts[productID] {
+					counts[productID]++
+				}
+			}
+		}
+
+		for productID, count := range counts {
+			share := float64(count) / float64(len(purchaseBuyers))
+			if share > best[productID].share {
+				best[productID] = accessory{productID: productID, purchasedID: purchase.ProductID, share: share}
+			}
+		}
+	}
+
+	candidates := make([]accessory, 0, len(best))
+	for _, candidate := range best {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].share != candidates[j].share {
+			return candidates[i].share > candidates[j].share
+		}
+		return candidates[i].productID < candidates[j].productID
+	})
+
+	accessories := make([]accessory, 0, s.postPurchase.accessories)
+	for _, candidate := range candidates {
+		if len(accessories) == s.postPurchase.accessories {
+			break
+		}
+
+		product := names.product(ctx, candidate.productID)
+		if product == nil || !product.IsActive {
+			continue
+		}
+		if product.CategoryID != nil && boughtCategories[*product.CategoryID] {
+			continue
+		}
+		accessories = append(accessories, candidate)
+	}
+
+	return accessories, nil
+}
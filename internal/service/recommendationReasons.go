@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// maxRecommendationReasons caps the reasons returned with each recommendation
+const maxRecommendationReasons = 3
+
+// userHistory is what a user bought, liked and viewed, each most recent first
+type userHistory struct {
+	purchased, liked, viewed          []int
+	purchasedSet, likedSet, viewedSet map[int]bool
+}
+
+func newUserHistory() *userHistory {
+	return &userHistory{
+		purchasedSet: make(map[int]bool),
+		likedSet:     make(map[int]bool),
+		viewedSet:    make(map[int]bool),
+	}
+}
+
+// isEmpty reports whether the user has no interactions
+func (h *userHistory) isEmpty() bool {
+	return len(h.purchased) == 0 && len(h.liked) == 0 && len(h.viewed) == 0
+}
+
+// newUserHistories builds the histories of the given users from all interactions, which the
+// repository returns most recent first
+func newUserHistories(userIDs map[int]bool, purchases []domain.UserProductPurchase, likes []domain.UserProductLike, views []domain.UserProductView) map[int]*userHistory {
+	histories := make(map[int]*userHistory, len(userIDs))
+	for userID := range userIDs {
+		histories[userID] = newUserHistory()
+	}
+
+	for _, purchase := range purchases {
+		if h := histories[purchase.UserID]; h != nil && !h.purchasedSet[purchase.ProductID] {
+			h.purchasedSet[purchase.ProductID] = true
+			h.purchased = append(h.purchased, purchase.ProductID)
+		}
+	}
+	for _, like := range likes {
+		if h := histories[like.UserID]; h != nil && !h.likedSet[like.ProductID] {
+			h.likedSet[like.ProductID] = true
+			h.liked = append(h.liked, like.ProductID)
+		}
+	}
+	for _, view := range views {
+		if h := histories[view.UserID]; h != nil && !h.viewedSet[view.ProductID] {
+			h.viewedSet[view.ProductID] = true
+			h.viewed = append(h.viewed, view.ProductID)
+		}
+	}
+
+	return histories
+}
+
+// anchor returns why the choices of a similar user are recommended: the user's most recent
+// product the other user also bought, else also liked, else also viewed
+func (h *userHistory) anchor(other *userHistory) reasonSource {
+	if other != nil {
+		for _, productID := range h.purchased {
+			if other.purchasedSet[productID] {
+				return reasonSource{kind: domain.ReasonBoughtSimilar, productID: productID}
+			}
+		}
+		for _, productID := range h.liked {
+			if other.likedSet[productID] {
+				return reasonSource{kind: domain.ReasonLikedSimilar, productID: productID}
+			}
+		}
+		for _, productID := range h.viewed {
+			if other.viewedSet[productID] {
+				return reasonSource{kind: domain.ReasonViewedSimilar, productID: productID}
+			}
+		}
+	}
+	return reasonSource{kind: domain.ReasonSimilarUsers}
+}
+
+// kindOf returns the reason kind of one of the user's products, by the strongest interaction
+func (h *userHistory) kindOf(productID int) string {
+	switch {
+	case h.purchasedSet[productID]:
+		return domain.ReasonBoughtSimilar
+	case h.likedSet[productID]:
+		return domain.ReasonLikedSimilar
+	default:
+		return domain.ReasonViewedSimilar
+	}
+}
+
+// reasonSource identifies a reason before it is explained in words
+type reasonSource struct {
+	kind       string
+	productID  int
+	categoryID int
+	users      int
+}
+
+// scoreReasons collects, per recommended product, how much each reason added to its score
+type scoreReasons map[int]map[reasonSource]float64
+
+func (r scoreReasons) add(productID int, source reasonSource, score float64) {
+	if r[productID] == nil {
+		r[productID] = make(map[reasonSource]float64)
+	}
+	r[productID][source] += score
+}
+
+// catalogNames looks up and caches the product and category names reasons refer to
+type catalogNames struct {
+	productRepo repository.ProductRepository
+	products    map[int]*domain.Product
+	categories  map[int]string
+}
+
+func newCatalogNames(productRepo repository.ProductRepository) *catalogNames {
+	return &catalogNames{
+		productRepo: productRepo,
+		products:    make(map[int]*domain.Product),
+		categories:  make(map[int]string),
+	}
+}
+
+// product returns the product, or nil if it can't be loaded
+func (n *catalogNames) product(ctx context.Context, id int) *domain.Product {
+	if product, ok := n.products[id]; ok {
+		return product
+	}
+	product, err := n.productRepo.GetByID(ctx, id)
+	if err != nil {
+		product = nil
+	}
+	n.products[id] = product
+	return product
+}
+
+// category returns the category name, or "" if it can't be loaded
+func (n *catalogNames) category(ctx context.Context, id int) string {
+	if name, ok := n.categories[id]; ok {
+		return name
+	}
+	name := ""
+	if category, err := n.productRepo.GetCategoryByID(ctx, id); err == nil {
+		name = category.Name
+	}
+	n.categories[id] = name
+	return name
+}
+
+// browsedCategories returns the categories of the products in the user's history
+func (n *catalogNames) browsedCategories(ctx context.Context, history *userHistory) map[int]bool {
+	categories := make(map[int]bool)
+	for _, products := range [][]int{history.purchased, history.liked, history.viewed} {
+		for _, productID := range products {
+			if product := n.product(ctx, productID); product != nil && product.CategoryID != nil {
+				categories[*product.CategoryID] = true
+			}
+		}
+	}
+	return categories
+}
+
+// explain turns the contributions to a product's score into its strongest reasons, each
+// weighted by its share of the score
+func (n *catalogNames) explain(ctx context.Context, contributions map[reasonSource]float64) []domain.RecommendationReason {
+	total := 0.0
+	for _, score := range contributions {
+		total += score
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	reasons := make([]domain.RecommendationReason, 0, len(contributions))
+	for source, score := range contributions {
+		reason := n.reason(ctx, source)
+		reason.Weight = score / total
+		reasons = append(reasons, reason)
+	}
+
+	return sortReasons(reasons)
+}
+
+// reason describes a reason source in words
+func (n *catalogNames) reason(ctx context.Context, source reasonSource) domain.RecommendationReason {
+	reason := domain.RecommendationReason{
+		Type:       source.kind,
+		ProductID:  source.productID,
+		CategoryID: source.categoryID,
+		Users:      source.users,
+	}
+
+	if source.productID != 0 {
+		if product := n.product(ctx, source.productID); product != nil {
+			reason.ProductName = product.Name
+		}
+	}
+	if source.categoryID != 0 {
+		reason.CategoryName = n.category(ctx, source.categoryID)
+	}
+
+	switch {
+	case source.kind == domain.ReasonBoughtSimilar && reason.ProductName != "":
+		reason.Text = fmt.Sprintf("Because you bought %s", reason.ProductName)
+	case source.kind == domain.ReasonLikedSimilar && reason.ProductName != "":
+		reason.Text = fmt.Sprintf("Because you liked %s", reason.ProductName)
+	case source.kind == domain.ReasonViewedSimilar && reason.ProductName != "":
+		reason.Text = fmt.Sprintf("Because you viewed %s", reason.ProductName)
+	case source.kind == domain.ReasonAccessory && reason.ProductName != "":
+		reason.Text = fmt.Sprintf("Goes with %s you bought", reason.ProductName)
+	case source.kind == domain.ReasonPopularInCategory && reason.CategoryName != "":
+		reason.Text = fmt.Sprintf("Popular in %s you browse", reason.CategoryName)
+	case source.kind == domain.ReasonFavoriteCategory && reason.CategoryName != "":
+		reason.Text = fmt.Sprintf("In %s, one of your favorite categories", reason.CategoryName)
+	case source.kind == domain.ReasonPopular || source.kind == domain.ReasonPopularInCategory:
+		reason.Text = fmt.Sprintf("Popular choice - %d users liked this", source.users)
+	case source.kind == domain.ReasonInteractionPattern:
+		reason.Text = "Matches your browsing and purchase patterns"
+	case source.kind == domain.ReasonFavoriteCategory:
+		reason.Text = "In one of your favorite categories"
+	case source.kind == domain.ReasonAccessory:
+		reason.Text = "Often bought together with your recent purchase"
+	default:
+		// The product the reason refers to is gone
+		reason.Text = "Users with similar interests liked this"
+	}
+
+	return reason
+}
+
+// addScoreReason adds score to a recommendation for another reason, scaling its other reasons
+// down to their share of the new score
+func (n *catalogNames) addScoreReason(ctx context.Context, recommendation *domain.ProductRecommendation, source reasonSource, score float64) {
+	total := recommendation.Score + score
+	if total <= 0 {
+		return
+	}
+
+	reasons := make([]domain.RecommendationReason, 0, len(recommendation.Reasons)+1)
+	for _, reason := range recommendation.Reasons {
+		reason.Weight *= recommendation.Score / total
+		reasons = append(reasons, reason)
+	}
+
+	added := n.reason(ctx, source)
+	added.Weight = score / total
+	reasons = append(reasons, added)
+
+	recommendation.Score = total
+	setReasons(recommendation, sortReasons(reasons))
+}
+
+// sortReasons orders reasons strongest first and keeps the top few
+func sortReasons(reasons []domain.RecommendationReason) []domain.RecommendationReason {
+	sort.SliceStable(reasons, func(i, j int) bool {
+		if reasons[i].Weight != reasons[j].Weight {
+			return reasons[i].Weight > reasons[j].Weight
+		}
+		if reasons[i].Type != reasons[j].Type {
+			return reasons[i].Type < reasons[j].Type
+		}
+		return reasons[i].ProductID < reasons[j].ProductID
+	})
+	if len(reasons) > maxRecommendationReasons {
+		reasons = reasons[:maxRecommendationReasons]
+	}
+	return reasons
+}
+
+// setReasons attaches reasons to a recommendation, with the strongest as its Reason text
+func setReasons(rec *domain.ProductRecommendation, reasons []domain.RecommendationReason) {
+	rec.Reasons = reasons
+	if len(reasons) > 0 {
+		rec.Reason = reasons[0].Text
+	}
+}
+
+// closestInHistory returns the reason a product ranked by learned factors is recommended: the
+// product in the user's history whose factors are closest to its own. Without one close enough,
+// the product just matches the user's factors.
+func closestInHistory(history *userHistory, itemFactors map[int][]float64, productID int) reasonSource {
+	source := reasonSource{kind: domain.ReasonInteractionPattern}
+
+	factors, ok := itemFactors[productID]
+	if !ok {
+		return source
+	}
+
+	best := 0.0
+	for _, products := range [][]int{history.purchased, history.liked, history.viewed} {
+		for _, historyID := range products {
+			other, ok := itemFactors[historyID]
+			if !ok {
+				continue
+			}
+			if similarity := factorSimilarity(factors, other); similarity > best {
+				best = similarity
+				source = reasonSource{kind: history.kindOf(historyID), productID: historyID}
+			}
+		}
+	}
+
+	return source
+}
+
+// factorSimilarity returns the cosine of the angle between two factor vectors
+func factorSimilarity(a, b []float64) float64 {
+	normA := math.Sqrt(dotProduct(a, a))
+	normB := math.Sqrt(dotProduct(b, b))
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dotProduct(a, b) / (normA * normB)
+}
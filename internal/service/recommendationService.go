@@ -7,36 +7,187 @@ import (
 	"sort"
 	"time"
 
+	"github.com/PrimeraAizen/e-comm/config"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/eventbus"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
 )
 
 type RecommendationService interface {
 	GetRecommendations(ctx context.Context, userID int, limit int) (*domain.RecommendationResponse, error)
 	GetSimilarUsers(ctx context.Context, userID int, limit int) ([]domain.UserSimilarity, error)
+
+	// Recommendation quality
+	SubmitFeedback(ctx context.Context, userID, productID int, feedbackType string) error
+	RecordClick(ctx context.Context, userID, productID int, algorithm string, position int) error
+
+	// Matrix factorization model
+	TrainFactorModel(ctx context.Context) (*domain.FactorModelStats, error)
+
+	// Run drops the cached recommendations of users as their purchases are published, until ctx
+	// is cancelled. Purchases reach one instance; other instances catch up when the cache expires.
+	Run(ctx context.Context) error
 }
 
 type recommendationService struct {
-	interactionRepo repository.InteractionRepository
-	productRepo     repository.ProductRepository
+	interactionRepo    repository.InteractionRepository
+	productRepo        repository.ProductRepository
+	recommendationRepo repository.RecommendationRepository
+	profileRepo        repository.ProfileRepository
+	algorithm          string
+	als                alsParams
+	engagement         config.Engagement
+	postPurchase       postPurchaseParams
+	purchaseEvents     *eventbus.Bus[domain.PurchaseEvent]
+	cache              *recommendationCache
 }
 
 func NewRecommendationService(
 	interactionRepo repository.InteractionRepository,
 	productRepo repository.ProductRepository,
-) RecommendationService {
+	recommendationRepo repository.RecommendationRepository,
+	profileRepo repository.ProfileRepository,
+	purchaseEvents *eventbus.Bus[domain.PurchaseEvent],
+	cfg *config.Config,
+) (RecommendationService, error) {
+	cacheTTL, err := time.ParseDuration(cfg.Recommendation.CacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse recommendation cache ttl: %w", err)
+	}
+
+	postPurchaseWindow, err := time.ParseDuration(cfg.Recommendation.PostPurchase.Window)
+	if err != nil {
+		return nil, fmt.Errorf("parse recommendation post-purchase window: %w", err)
+	}
+
 	return &recommendationService{
-		interactionRepo: interactionRepo,
-		productRepo:     productRepo,
+		interactionRepo:    interactionRepo,
+		productRepo:        productRepo,
+		recommendationRepo: recommendationRepo,
+		profileRepo:        profileRepo,
+		algorithm:          cfg.Recommendation.Algorithm,
+		als: alsParams{
+			factors:        cfg.Recommendation.ALS.Factors,
+			iterations:     cfg.Recommendation.ALS.Iterations,
+			regularization: cfg.Recommendation.ALS.Regularization,
+			alpha:          cfg.Recommendation.ALS.Alpha,
+		},
+		engagement: cfg.Recommendation.Engagement,
+		postPurchase: postPurchaseParams{
+			window:      postPurchaseWindow,
+			accessories: cfg.Recommendation.PostPurchase.Accessories,
+		},
+		purchaseEvents: purchaseEvents,
+		cache:          &recommendationCache{ttl: cacheTTL},
+	}, nil
+}
+
+// viewWeight is how many plain views a view counts as: more when the user engaged deeply
+func (s *recommendationService) viewWeight(view domain.UserProductView) float64 {
+	if view.IsDeep(s.engagement.DeepDwellSeconds, s.engagement.DeepScrollDepth) {
+		return s.engagement.DeepViewWeight
 	}
+	return 1
 }
 
-// GetRecommendations generates product recommendations using collaborative filtering
+const (
+	// favoriteCategoryBoost multiplies the score of products in the user's favorite categories
+	favoriteCategoryBoost = 1.5
+	// candidateFactor widens the candidate pool so boosted products ranked just below the cut,
+	// or the ones left after post-purchase rules drop some, can still make it into the result
+	candidateFactor = 2
+)
+
+// GetRecommendations returns the user's cached recommendations, generating them when missing
+// or expired. If they can't be generated, expired ones not yet swept from the cache are served.
 func (s *recommendationService) GetRecommendations(ctx context.Context, userID int, limit int) (*domain.RecommendationResponse, error) {
 	if limit <= 0 || limit > 50 {
 		limit = 10 // Default limit
 	}
 
+	cached, generation := s.cache.get(ctx, userID, limit)
+	if cached != nil {
+		return cached, nil
+	}
+
+	resp, err := s.generate(ctx, userID, limit)
+	if err != nil {
+		if stale := s.cache.stale(ctx, userID, limit); stale != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("recommendations").WithError(err).Warn("Serving stale recommendations")
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	s.cache.put(ctx, userID, limit, resp, generation)
+	return resp, nil
+}
+
+// generate generates product recommendations using collaborative filtering, ranking products in
+// the user's favorite categories higher and applying the post-purchase rules
+func (s *recommendationService) generate(ctx context.Context, userID int, limit int) (*domain.RecommendationResponse, error) {
+	preferences, err := loadPreferences(ctx, s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recentPurchases, err := s.recentPurchases(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(preferences.FavoriteCategoryIDs) == 0 && len(recentPurchases) == 0 {
+		return s.recommend(ctx, userID, limit)
+	}
+
+	resp, err := s.recommend(ctx, userID, limit*candidateFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	names := newCatalogNames(s.productRepo)
+	for i := range resp.Recommendations {
+		recommendation := &resp.Recommendations[i]
+		if preferences.IsFavoriteCategory(recommendation.CategoryID) {
+			source := reasonSource{kind: domain.ReasonFavoriteCategory, categoryID: recommendation.CategoryID}
+			names.addScoreReason(ctx, recommendation, source, recommendation.Score*(favoriteCategoryBoost-1))
+		}
+	}
+
+	if len(recentPurchases) > 0 {
+		if err := s.applyPostPurchase(ctx, userID, resp, recentPurchases, names); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(resp.Recommendations, func(i, j int) bool {
+		return resp.Recommendations[i].Score > resp.Recommendations[j].Score
+	})
+	if len(resp.Recommendations) > limit {
+		resp.Recommendations = resp.Recommendations[:limit]
+	}
+
+	return resp, nil
+}
+
+func (s *recommendationService) Run(ctx context.Context) error {
+	events, unsubscribe := s.purchaseEvents.Subscribe(purchasesTopic)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			s.cache.invalidate(event.Tenant, event.UserID)
+		}
+	}
+}
+
+// recommend ranks up to limit products for the user
+func (s *recommendationService) recommend(ctx context.Context, userID int, limit int) (*domain.RecommendationResponse, error) {
 	// Get all interactions
 	allLikes, err := s.interactionRepo.GetAllUserLikes(ctx)
 	if err != nil {
@@ -53,30 +204,41 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 		return nil, fmt.Errorf("get all purchases: %w", err)
 	}
 
-	// Create sets for current user's interactions
-	userLikedProducts := make(map[int]bool)
-	userViewedProducts := make(map[int]bool)
-	userPurchasedProducts := make(map[int]bool)
+	// The current user's interactions, most recent first
+	history := newUserHistories(map[int]bool{userID: true}, allPurchases, allLikes, allViews)[userID]
+	userLikedProducts := history.likedSet
+	userPurchasedProducts := history.purchasedSet
 
-	for _, like := range allLikes {
-		if like.UserID == userID {
-			userLikedProducts[like.ProductID] = true
-		}
+	// Products the user gave feedback on are never recommended again
+	excludedProducts, err := s.getExcludedProducts(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	for _, view := range allViews {
-		if view.UserID == userID {
-			userViewedProducts[view.ProductID] = true
-		}
+
+	names := newCatalogNames(s.productRepo)
+
+	// If user has no interactions, return popular products
+	if history.isEmpty() {
+		return s.getPopularProducts(ctx, limit, excludedProducts, nil, names)
 	}
-	for _, purchase := range allPurchases {
-		if purchase.UserID == userID {
-			userPurchasedProducts[purchase.ProductID] = true
+
+	// Rank by learned factors when the matrix factorization model is enabled
+	if s.algorithm == domain.AlgorithmMatrixFactorization {
+		skipProducts := make(map[int]bool)
+		for _, set := range []map[int]bool{userPurchasedProducts, userLikedProducts, excludedProducts} {
+			for productID := range set {
+				skipProducts[productID] = true
+			}
 		}
-	}
 
-	// If user has no interactions, return popular products
-	if len(userLikedProducts) == 0 && len(userViewedProducts) == 0 && len(userPurchasedProducts) == 0 {
-		return s.getPopularProducts(ctx, limit)
+		resp, err := s.getFactorRecommendations(ctx, userID, limit, skipProducts, history, names)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			return resp, nil
+		}
+		// User is not covered by the last training run, fall back to collaborative filtering
 	}
 
 	// Find similar users based on collaborative filtering
@@ -87,13 +249,25 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 
 	// If no similar users, return popular products
 	if len(similarUsers) == 0 {
-		return s.getPopularProducts(ctx, limit)
+		return s.getPopularProducts(ctx, limit, excludedProducts, names.browsedCategories(ctx, history), names)
 	}
 
 	// Aggregate recommendations from similar users
 	productScores := make(map[int]float64)
 	productDetails := make(map[int]*domain.Product)
 
+	// Each similar user's choices are explained by what the user has in common with them
+	similarUserIDs := make(map[int]bool, len(similarUsers))
+	for _, simUser := range similarUsers {
+		similarUserIDs[simUser.UserID] = true
+	}
+	similarHistories := newUserHistories(similarUserIDs, allPurchases, allLikes, allViews)
+	anchors := make(map[int]reasonSource, len(similarUsers))
+	for _, simUser := range similarUsers {
+		anchors[simUser.UserID] = history.anchor(similarHistories[simUser.UserID])
+	}
+	reasons := make(scoreReasons)
+
 	// Score from similar users' purchases (strongest signal - weight 3.0)
 	for _, simUser := range similarUsers {
 		for _, purchase := range allPurchases {
@@ -101,8 +275,8 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 				continue
 			}
 
-			// Skip products the user already purchased
-			if userPurchasedProducts[purchase.ProductID] {
+			// Skip products the user already purchased or dismissed
+			if userPurchasedProducts[purchase.ProductID] || excludedProducts[purchase.ProductID] {
 				continue
 			}
 
@@ -117,6 +291,7 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 
 			// Weight by user similarity score and boost for purchases
 			productScores[purchase.ProductID] += simUser.SimilarityScore * 3.0
+			reasons.add(purchase.ProductID, anchors[simUser.UserID], simUser.SimilarityScore*3.0)
 		}
 	}
 
@@ -127,8 +302,8 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 				continue
 			}
 
-			// Skip products the user already liked or purchased
-			if userLikedProducts[like.ProductID] || userPurchasedProducts[like.ProductID] {
+			// Skip products the user already liked, purchased or dismissed
+			if userLikedProducts[like.ProductID] || userPurchasedProducts[like.ProductID] || excludedProducts[like.ProductID] {
 				continue
 			}
 
@@ -143,6 +318,39 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 
 			// Weight by user similarity score
 			productScores[like.ProductID] += simUser.SimilarityScore * 1.5
+			reasons.add(like.ProductID, anchors[simUser.UserID], simUser.SimilarityScore*1.5)
+		}
+	}
+
+	// Score from similar users' deeply engaged views (weak signal - weight 0.5 per plain view
+	// the deep view counts as); plain views alone say too little to recommend a product
+	for _, simUser := range similarUsers {
+		for _, view := range allViews {
+			if view.UserID != simUser.UserID {
+				continue
+			}
+
+			weight := s.viewWeight(view)
+			if weight <= 1 {
+				continue
+			}
+
+			// Skip products the user already purchased or dismissed
+			if userPurchasedProducts[view.ProductID] || excludedProducts[view.ProductID] {
+				continue
+			}
+
+			// Get product details if not cached
+			if productDetails[view.ProductID] == nil {
+				product, err := s.productRepo.GetByID(ctx, view.ProductID)
+				if err != nil {
+					continue
+				}
+				productDetails[view.ProductID] = product
+			}
+
+			productScores[view.ProductID] += simUser.SimilarityScore * 0.5 * weight
+			reasons.add(view.ProductID, anchors[simUser.UserID], simUser.SimilarityScore*0.5*weight)
 		}
 	}
 
@@ -159,14 +367,15 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 			categoryID = *product.CategoryID
 		}
 
-		recommendations = append(recommendations, domain.ProductRecommendation{
+		recommendation := domain.ProductRecommendation{
 			ProductID:   productID,
 			ProductName: product.Name,
 			CategoryID:  categoryID,
 			Price:       product.Price,
 			Score:       score,
-			Reason:      "Users with similar interests liked this",
-		})
+		}
+		setReasons(&recommendation, names.explain(ctx, reasons[productID]))
+		recommendations = append(recommendations, recommendation)
 	}
 
 	// Sort by score descending
@@ -181,14 +390,14 @@ func (s *recommendationService) GetRecommendations(ctx context.Context, userID i
 
 	// If still no recommendations, fallback to popular products
 	if len(recommendations) == 0 {
-		return s.getPopularProducts(ctx, limit)
+		return s.getPopularProducts(ctx, limit, excludedProducts, names.browsedCategories(ctx, history), names)
 	}
 
 	return &domain.RecommendationResponse{
 		UserID:          userID,
 		Recommendations: recommendations,
-		Algorithm:       "collaborative_filtering",
-		GeneratedAt:     time.Now().Format(time.RFC3339),
+		Algorithm:       domain.AlgorithmCollaborativeFiltering,
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
 	}, nil
 }
 
@@ -350,8 +559,9 @@ func (s *recommendationService) GetSimilarUsers(ctx context.Context, userID int,
 	return similarities, nil
 }
 
-// getPopularProducts returns most liked products as fallback
-func (s *recommendationService) getPopularProducts(ctx context.Context, limit int) (*domain.RecommendationResponse, error) {
+// getPopularProducts returns most liked products as fallback, explained as popular in the
+// user's browsed categories where they are in one
+func (s *recommendationService) getPopularProducts(ctx context.Context, limit int, excludedProducts, browsedCategories map[int]bool, names *catalogNames) (*domain.RecommendationResponse, error) {
 	// Get all likes
 	allLikes, err := s.interactionRepo.GetAllUserLikes(ctx)
 	if err != nil {
@@ -361,6 +571,9 @@ func (s *recommendationService) getPopularProducts(ctx context.Context, limit in
 	// Count likes per product
 	likeCounts := make(map[int]int)
 	for _, like := range allLikes {
+		if excludedProducts[like.ProductID] {
+			continue
+		}
 		likeCounts[like.ProductID]++
 	}
 
@@ -404,24 +617,232 @@ func (s *recommendationService) getPopularProducts(ctx context.Context, limit in
 			categoryID = *product.CategoryID
 		}
 
-		recommendations = append(recommendations, domain.ProductRecommendation{
+		source := reasonSource{kind: domain.ReasonPopular, users: pc.count}
+		if browsedCategories[categoryID] {
+			source = reasonSource{kind: domain.ReasonPopularInCategory, categoryID: categoryID, users: pc.count}
+		}
+
+		recommendation := domain.ProductRecommendation{
 			ProductID:   pc.productID,
 			ProductName: product.Name,
 			CategoryID:  categoryID,
 			Price:       product.Price,
 			Score:       score,
-			Reason:      fmt.Sprintf("Popular choice - %d users liked this", pc.count),
-		})
+		}
+		setReasons(&recommendation, names.explain(ctx, map[reasonSource]float64{source: 1}))
+		recommendations = append(recommendations, recommendation)
 	}
 
 	return &domain.RecommendationResponse{
 		UserID:          0,
 		Recommendations: recommendations,
-		Algorithm:       "popularity_based",
-		GeneratedAt:     time.Now().Format(time.RFC3339),
+		Algorithm:       domain.AlgorithmPopularityBased,
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// SubmitFeedback stores user feedback on a recommended product
+func (s *recommendationService) SubmitFeedback(ctx context.Context, userID, productID int, feedbackType string) error {
+	if !domain.IsValidFeedbackType(feedbackType) {
+		return domain.ErrValidation
+	}
+
+	// Verify product exists
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return err
+	}
+
+	feedback := &domain.RecommendationFeedback{
+		UserID:    userID,
+		ProductID: productID,
+		Type:      feedbackType,
+	}
+
+	if err := s.recommendationRepo.SaveFeedback(ctx, feedback); err != nil {
+		return fmt.Errorf("save feedback: %w", err)
+	}
+
+	// Stop recommending the product right away
+	s.cache.invalidate(tenant.ID(ctx), userID)
+
+	return nil
+}
+
+// RecordClick records a click-through on a recommended product
+func (s *recommendationService) RecordClick(ctx context.Context, userID, productID int, algorithm string, position int) error {
+	// Verify product exists
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		return err
+	}
+
+	click := &domain.RecommendationClick{
+		UserID:    userID,
+		ProductID: productID,
+		Algorithm: algorithm,
+		Position:  position,
+	}
+
+	if err := s.recommendationRepo.RecordClick(ctx, click); err != nil {
+		return fmt.Errorf("record click: %w", err)
+	}
+
+	return nil
+}
+
+// TrainFactorModel trains the implicit-feedback ALS model on all interactions
+// and stores the resulting user and item factors
+func (s *recommendationService) TrainFactorModel(ctx context.Context) (*domain.FactorModelStats, error) {
+	allViews, err := s.interactionRepo.GetAllUserViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all views: %w", err)
+	}
+
+	allLikes, err := s.interactionRepo.GetAllUserLikes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all likes: %w", err)
+	}
+
+	allPurchases, err := s.interactionRepo.GetAllUserPurchases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all purchases: %w", err)
+	}
+
+	// Build the implicit feedback matrix (user -> product -> strength)
+	ratings := make(map[int]map[int]float64)
+	addFeedback := func(userID, productID int, weight float64) {
+		if ratings[userID] == nil {
+			ratings[userID] = make(map[int]float64)
+		}
+		ratings[userID][productID] += weight
+	}
+
+	for _, view := range allViews {
+		addFeedback(view.UserID, view.ProductID, viewFeedbackWeight*s.viewWeight(view))
+	}
+	for _, like := range allLikes {
+		addFeedback(like.UserID, like.ProductID, likeFeedbackWeight)
+	}
+	for _, purchase := range allPurchases {
+		addFeedback(purchase.UserID, purchase.ProductID, purchaseFeedbackWeight)
+	}
+
+	userFactors, itemFactors := trainImplicitALS(ratings, s.als)
+
+	trainedAt := time.Now().UTC()
+	if err := s.recommendationRepo.SaveFactors(ctx, toFactorVectors(userFactors), toFactorVectors(itemFactors), trainedAt); err != nil {
+		return nil, fmt.Errorf("save factors: %w", err)
+	}
+
+	return &domain.FactorModelStats{
+		Users:        len(userFactors),
+		Items:        len(itemFactors),
+		Interactions: len(allViews) + len(allLikes) + len(allPurchases),
+		Factors:      s.als.factors,
+		Iterations:   s.als.iterations,
+		TrainedAt:    trainedAt,
+	}, nil
+}
+
+// getFactorRecommendations ranks products by the dot product of user and item factors, each
+// explained by the product in the user's history its factors are closest to.
+// Returns nil if the user has no trained factors.
+func (s *recommendationService) getFactorRecommendations(ctx context.Context, userID int, limit int, skipProducts map[int]bool, history *userHistory, names *catalogNames) (*domain.RecommendationResponse, error) {
+	userFactors, err := s.recommendationRepo.GetUserFactors(ctx, userID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get user factors: %w", err)
+	}
+
+	itemFactors, err := s.recommendationRepo.GetAllItemFactors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get item factors: %w", err)
+	}
+
+	type productScore struct {
+		productID int
+		score     float64
+	}
+
+	itemFactorsByID := make(map[int][]float64, len(itemFactors))
+	scores := make([]productScore, 0, len(itemFactors))
+	for _, item := range itemFactors {
+		itemFactorsByID[item.ID] = item.Factors
+		if skipProducts[item.ID] {
+			continue
+		}
+		scores = append(scores, productScore{item.ID, dotProduct(userFactors.Factors, item.Factors)})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+
+	recommendations := make([]domain.ProductRecommendation, 0, limit)
+	for _, ps := range scores {
+		if len(recommendations) >= limit {
+			break
+		}
+
+		product, err := s.productRepo.GetByID(ctx, ps.productID)
+		if err != nil {
+			continue
+		}
+
+		categoryID := 0
+		if product.CategoryID != nil {
+			categoryID = *product.CategoryID
+		}
+
+		recommendation := domain.ProductRecommendation{
+			ProductID:   ps.productID,
+			ProductName: product.Name,
+			CategoryID:  categoryID,
+			Price:       product.Price,
+			Score:       ps.score,
+		}
+		source := closestInHistory(history, itemFactorsByID, ps.productID)
+		setReasons(&recommendation, names.explain(ctx, map[reasonSource]float64{source: 1}))
+		recommendations = append(recommendations, recommendation)
+	}
+
+	if len(recommendations) == 0 {
+		return nil, nil
+	}
+
+	return &domain.RecommendationResponse{
+		UserID:          userID,
+		Recommendations: recommendations,
+		Algorithm:       domain.AlgorithmMatrixFactorization,
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
 	}, nil
 }
 
+// toFactorVectors converts a factor map into storable vectors
+func toFactorVectors(factors map[int][]float64) []domain.FactorVector {
+	vectors := make([]domain.FactorVector, 0, len(factors))
+	for id, f := range factors {
+		vectors = append(vectors, domain.FactorVector{ID: id, Factors: f})
+	}
+	return vectors
+}
+
+// getExcludedProducts returns the set of products the user doesn't want recommended
+func (s *recommendationService) getExcludedProducts(ctx context.Context, userID int) (map[int]bool, error) {
+	productIDs, err := s.recommendationRepo.GetFeedbackProductIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get feedback: %w", err)
+	}
+
+	excluded := make(map[int]bool, len(productIDs))
+	for _, productID := range productIDs {
+		excluded[productID] = true
+	}
+
+	return excluded, nil
+}
+
 // Helper function to calculate cosine similarity (alternative to Jaccard)
 func cosineSimilarity(a, b map[int]bool) float64 {
 	if len(a) == 0 || len(b) == 0 {
@@ -0,0 +1,619 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/mailer"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const (
+	searchReportDefaultLimit = 20
+	searchReportMaxLimit     = 100
+)
+
+// ReportService emails the scheduled reports to the admin recipients when they are due. A
+// report missed while no instance ran is sent when one starts. It also builds sales reports
+// on demand.
+type ReportService interface {
+	// Sales sums the revenue of the purchases in [from, to) grouped by period, category or
+	// product. Periods are in the time zone preference of the user the report is for, or the
+	// reports time zone if they have none.
+	Sales(ctx context.Context, userID int, groupBy string, from, to time.Time) (*domain.SalesReport, error)
+
+	// Retention builds the retention matrix of the users who signed up in [from, to), by week or
+	// month, in the time zone of the user the report is for. from is moved back to the start of
+	// its period.
+	Retention(ctx context.Context, userID int, period string, from, to time.Time) (*domain.RetentionReport, error)
+
+	// Inventory values the stock on hand per category and lists the products in stock with no
+	// sales in the last deadStockDays days, or the configured number if 0
+	Inventory(ctx context.Context, deadStockDays int) (*domain.InventoryReport, error)
+
+	// Search sums the searches in [from, to) and lists the limit most searched terms, and the
+	// limit most searched terms without results, with their click-through rates
+	Search(ctx context.Context, from, to time.Time, limit int) (*domain.SearchReport, error)
+
+	// Run sends the due reports of every tenant each check interval until ctx is cancelled.
+	// It returns at once when no recipients or reports are configured.
+	Run(ctx context.Context) error
+}
+
+// reportSchedule is when a report is due: daily at a time of day, or weekly on a weekday
+type reportSchedule struct {
+	name    string
+	hour    int
+	minute  int
+	weekly  bool
+	weekday time.Weekday
+}
+
+// lastDue returns the latest time the report was due at or before now
+func (s reportSchedule) lastDue(now time.Time) time.Time {
+	due := time.Date(now.Year(), now.Month(), now.Day(), s.hour, s.minute, 0, 0, now.Location())
+	period := 1
+	if s.weekly {
+		period = 7
+		due = due.AddDate(0, 0, -((int(now.Weekday()) - int(s.weekday) + 7) % 7))
+	}
+	if due.After(now) {
+		due = due.AddDate(0, 0, -period)
+	}
+	return due
+}
+
+type reportService struct {
+	reportRepo        repository.ReportRepository
+	profileRepo       repository.ProfileRepository
+	mailer            mailer.Mailer
+	recipients        []string
+	location          *time.Location
+	checkInterval     time.Duration
+	schedules         []reportSchedule
+	topProductsLimit  int
+	lowStockThreshold int
+	deadStockDays     int
+	deadStockLimit    int
+	tenancy           *config.Tenancy
+}
+
+func NewReportService(reportRepo repository.ReportRepository, profileRepo repository.ProfileRepository, mailSender mailer.Mailer, cfg *config.Config) (ReportService, error) {
+	location, err := time.LoadLocation(cfg.Reports.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load reports timezone: %w", err)
+	}
+
+	checkInterval, err := time.ParseDuration(cfg.Reports.CheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse reports check interval: %w", err)
+	}
+
+	var schedules []reportSchedule
+	for _, report := range []struct {
+		name     string
+		schedule *config.ReportSchedule
+		weekly   bool
+	}{
+		{domain.ReportDailySales, &cfg.Reports.DailySales, false},
+		{domain.ReportWeeklyTopProducts, &cfg.Reports.WeeklyTopProducts, true},
+		{domain.ReportLowStock, &cfg.Reports.LowStock, false},
+	} {
+		if !report.schedule.Enabled {
+			continue
+		}
+
+		schedule := reportSchedule{name: report.name, weekly: report.weekly}
+		at, err := time.Parse("15:04", report.schedule.At)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s time: %w", report.name, err)
+		}
+		schedule.hour, schedule.minute = at.Hour(), at.Minute()
+
+		if report.weekly {
+			weekday, ok := parseWeekday(report.schedule.Weekday)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s weekday %q", report.name, report.schedule.Weekday)
+			}
+			schedule.weekday = weekday
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return &reportService{
+		reportRepo:        reportRepo,
+		profileRepo:       profileRepo,
+		mailer:            mailSender,
+		recipients:        cfg.Reports.Recipients,
+		location:          location,
+		checkInterval:     checkInterval,
+		schedules:         schedules,
+		topProductsLimit:  cfg.Reports.TopProductsLimit,
+		lowStockThreshold: cfg.Reports.LowStockThreshold,
+		deadStockDays:     cfg.Reports.DeadStockDays,
+		deadStockLimit:    cfg.Reports.DeadStockLimit,
+		tenancy:           &cfg.Tenancy,
+	}, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func (s *reportService) Sales(ctx context.Context, userID int, groupBy string, from, to time.Time) (*domain.SalesReport, error) {
+	switch groupBy {
+	case domain.SalesGroupDay, domain.SalesGroupWeek, domain.SalesGroupMonth, domain.SalesGroupCategory, domain.SalesGroupProduct:
+	default:
+		return nil, fmt.Errorf("group_by must be day, week, month, category or product: %w", domain.ErrValidation)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to: %w", domain.ErrValidation)
+	}
+	location, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.reportRepo.SalesGrouped(ctx, groupBy, from.UTC(), to.UTC(), location.String())
+	if err != nil {
+		return nil, fmt.Errorf("get sales: %w", err)
+	}
+
+	report := &domain.SalesReport{
+		GroupBy:  groupBy,
+		From:     from.In(location),
+		To:       to.In(location),
+		Timezone: location.String(),
+		Groups:   groups,
+	}
+	for i := range groups {
+		if groups[i].Period != nil {
+			period := groups[i].Period.In(location)
+			groups[i].Period = &period
+		}
+
+		report.Totals.Purchases += groups[i].Purchases
+		report.Totals.Units += groups[i].Units
+		report.Totals.GrossRevenue = report.Totals.GrossRevenue.Add(groups[i].GrossRevenue)
+		report.Totals.NetRevenue = report.Totals.NetRevenue.Add(groups[i].NetRevenue)
+		report.Totals.CostOfGoods = report.Totals.CostOfGoods.Add(groups[i].CostOfGoods)
+		report.Totals.GrossMargin = report.Totals.GrossMargin.Add(groups[i].GrossMargin)
+		report.Totals.UncostedPurchases += groups[i].UncostedPurchases
+		groups[i].SetMarginRate()
+	}
+	report.Totals.SetMarginRate()
+
+	return report, nil
+}
+
+func (s *reportService) Retention(ctx context.Context, userID int, period string, from, to time.Time) (*domain.RetentionReport, error) {
+	if period != domain.RetentionPeriodWeek && period != domain.RetentionPeriodMonth {
+		return nil, fmt.Errorf("period must be week or month: %w", domain.ErrValidation)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to: %w", domain.ErrValidation)
+	}
+	location, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	from = periodStart(from.In(location), period)
+	to = to.In(location)
+
+	sizes, err := s.reportRepo.CohortSizes(ctx, period, from, to, location.String())
+	if err != nil {
+		return nil, fmt.Errorf("get cohort sizes: %w", err)
+	}
+	activity, err := s.reportRepo.CohortActivity(ctx, period, from, to, location.String())
+	if err != nil {
+		return nil, fmt.Errorf("get cohort activity: %w", err)
+	}
+
+	type cell struct {
+		cohort int64
+		offset int
+	}
+	active := make(map[cell]int, len(activity))
+	for _, count := range activity {
+		active[cell{count.Cohort.Unix(), count.Offset}] = count.Users
+	}
+
+	report := &domain.RetentionReport{
+		Period:   period,
+		From:     from,
+		To:       to,
+		Timezone: location.String(),
+		Cohorts:  make([]domain.RetentionCohort, 0, len(sizes)),
+	}
+	for _, size := range sizes {
+		cohort := domain.RetentionCohort{Start: size.Cohort.In(location), Size: size.Users}
+
+		// A column for each period of the cohort that started before to
+		for start := cohort.Start; start.Before(to); start = nextPeriod(start, period) {
+			users := active[cell{size.Cohort.Unix(), len(cohort.Active)}]
+			cohort.Active = append(cohort.Active, users)
+			cohort.Retention = append(cohort.Retention, math.Round(float64(users)/float64(size.Users)*10000)/10000)
+		}
+
+		report.Periods = max(report.Periods, len(cohort.Active))
+		report.Cohorts = append(report.Cohorts, cohort)
+	}
+
+	return report, nil
+}
+
+// userLocation returns the time zone preference of the user, or the reports time zone
+func (s *reportService) userLocation(ctx context.Context, userID int) (*time.Location, error) {
+	preferences, err := loadPreferences(ctx, s.profileRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	return preferences.Location(s.location), nil
+}
+
+func (s *reportService) Inventory(ctx context.Context, deadStockDays int) (*domain.InventoryReport, error) {
+	if deadStockDays < 0 {
+		return nil, fmt.Errorf("dead_stock_days cannot be negative: %w", domain.ErrValidation)
+	}
+	if deadStockDays == 0 {
+		deadStockDays = s.deadStockDays
+	}
+
+	now := time.Now().UTC()
+
+	categories, err := s.reportRepo.InventoryByCategory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get inventory: %w", err)
+	}
+	deadStock, err := s.reportRepo.DeadStock(ctx, now.AddDate(0, 0, -deadStockDays), s.deadStockLimit)
+	if err != nil {
+		return nil, fmt.Errorf("get dead stock: %w", err)
+	}
+
+	report := &domain.InventoryReport{
+		GeneratedAt:   now,
+		Categories:    categories,
+		DeadStockDays: deadStockDays,
+		DeadStock:     *deadStock,
+	}
+	for _, category := range categories {
+		report.Totals.Products += category.Products
+		report.Totals.InStock += category.InStock
+		report.Totals.Units += category.Units
+		report.Totals.RetailValue = report.Totals.RetailValue.Add(category.RetailValue)
+		report.Totals.CostValue = report.Totals.CostValue.Add(category.CostValue)
+		report.Totals.UncostedProducts += category.UncostedProducts
+	}
+
+	return report, nil
+}
+
+func (s *reportService) Search(ctx context.Context, from, to time.Time, limit int) (*domain.SearchReport, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to: %w", domain.ErrValidation)
+	}
+	if limit <= 0 {
+		limit = searchReportDefaultLimit
+	}
+	limit = min(limit, searchReportMaxLimit)
+
+	totals, top, zeroResults, err := s.reportRepo.SearchStats(ctx, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get search stats: %w", err)
+	}
+
+	totals.ClickThroughRate = clickThroughRate(totals)
+	for i := range top {
+		top[i].ClickThroughRate = clickThroughRate(top[i])
+	}
+	for i := range zeroResults {
+		zeroResults[i].ClickThroughRate = clickThroughRate(zeroResults[i])
+	}
+
+	return &domain.SearchReport{
+		From:              from,
+		To:                to,
+		Totals:            totals,
+		TopQueries:        top,
+		ZeroResultQueries: zeroResults,
+	}, nil
+}
+
+func clickThroughRate(stats domain.SearchTermStats) float64 {
+	if stats.Searches == 0 {
+		return 0
+	}
+	return float64(stats.Clicks) / float64(stats.Searches)
+}
+
+// periodStart returns the start of the week (Monday) or month of t, in the location of t
+func periodStart(t time.Time, period string) time.Time {
+	if period == domain.RetentionPeriodMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// nextPeriod returns the start of the week or month after the one starting at start
+func nextPeriod(start time.Time, period string) time.Time {
+	if period == domain.RetentionPeriodMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+func (s *reportService) Run(ctx context.Context) error {
+	if len(s.recipients) == 0 || len(s.schedules) == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, tenantCtx := range tenant.Contexts(ctx, s.tenancy) {
+			for _, schedule := range s.schedules {
+				if err := s.sendIfDue(tenantCtx, schedule); err != nil && ctx.Err() == nil {
+					logger.GetLoggerFromContext(ctx).WithComponent("reports").WithError(err).WithFields(logger.Fields{
+						"tenant": tenant.ID(tenantCtx),
+						"report": schedule.name,
+					}).Error("Failed to send report")
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendIfDue sends the last due run of the report unless it was already sent. The run is
+// claimed first so only one instance sends it, and released again if sending fails.
+func (s *reportService) sendIfDue(ctx context.Context, schedule reportSchedule) error {
+	due := schedule.lastDue(time.Now().In(s.location))
+
+	key := schedule.name + ":" + due.Format(time.RFC3339)
+	if tenantID := tenant.ID(ctx); tenantID != "" {
+		key = tenantID + ":" + key
+	}
+
+	claimed, err := s.reportRepo.ClaimRun(ctx, key)
+	if err != nil || !claimed {
+		return err
+	}
+
+	if err := s.send(ctx, schedule.name, due); err != nil {
+		if releaseErr := s.reportRepo.ReleaseRun(ctx, key); releaseErr != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("reports").WithError(releaseErr).Warn("Failed to release report run", "key", key)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// send generates the report due at the given time and emails it to every recipient
+func (s *reportService) send(ctx context.Context, name string, due time.Time) error {
+	report, err := s.generate(ctx, name, due)
+	if err != nil {
+		return err
+	}
+	// The recipients read the report in the reports time zone
+	report.GeneratedAt = report.GeneratedAt.In(s.location)
+
+	html, err := renderReportHTML(report)
+	if err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+	data, err := renderReportCSV(report)
+	if err != nil {
+		return fmt.Errorf("render report csv: %w", err)
+	}
+
+	subject := report.Title
+	if tenantID := tenant.ID(ctx); tenantID != "" {
+		subject = "[" + tenantID + "] " + subject
+	}
+
+	for _, recipient := range s.recipients {
+		msg := mailer.Message{
+			To:      recipient,
+			Subject: subject,
+			Body:    renderReportText(report),
+			HTML:    html,
+			Attachments: []mailer.Attachment{{
+				Filename:    name + "-" + due.Format("2006-01-02") + ".csv",
+				ContentType: "text/csv; charset=UTF-8",
+				Data:        data,
+			}},
+		}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			return fmt.Errorf("send report to %s: %w", recipient, err)
+		}
+	}
+
+	logger.GetLoggerFromContext(ctx).WithComponent("reports").WithFields(logger.Fields{
+		"report":     name,
+		"due":        due,
+		"rows":       len(report.Rows),
+		"recipients": len(s.recipients),
+	}).Info("Sent report")
+
+	return nil
+}
+
+// generate builds the report due at the given time from the period before it
+func (s *reportService) generate(ctx context.Context, name string, due time.Time) (*domain.Report, error) {
+	day := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, due.Location())
+
+	switch name {
+	case domain.ReportDailySales:
+		from := day.AddDate(0, 0, -1)
+		sales, err := s.reportRepo.SalesByProduct(ctx, from, day)
+		if err != nil {
+			return nil, err
+		}
+		return salesReport(name, "Daily sales "+from.Format("2006-01-02"), sales), nil
+
+	case domain.ReportWeeklyTopProducts:
+		from := day.AddDate(0, 0, -7)
+		sales, err := s.reportRepo.SalesByProduct(ctx, from, day)
+		if err != nil {
+			return nil, err
+		}
+		sortSalesByUnits(sales)
+		if len(sales) > s.topProductsLimit {
+			sales = sales[:s.topProductsLimit]
+		}
+		title := fmt.Sprintf("Top products %s to %s", from.Format("2006-01-02"), day.AddDate(0, 0, -1).Format("2006-01-02"))
+		return salesReport(name, title, sales), nil
+
+	case domain.ReportLowStock:
+		products, err := s.reportRepo.ListLowStock(ctx, s.lowStockThreshold)
+		if err != nil {
+			return nil, err
+		}
+		return lowStockReport(name, "Low stock "+day.Format("2006-01-02"), s.lowStockThreshold, products), nil
+	}
+
+	return nil, fmt.Errorf("unknown report %q", name)
+}
+
+func salesReport(name, title string, sales []domain.ProductSales) *domain.Report {
+	report := &domain.Report{
+		Name:        name,
+		Title:       title,
+		GeneratedAt: time.Now().UTC(),
+		Columns:     []string{"product_id", "name", "purchases", "units", "revenue", "gross_margin"},
+		Rows:        make([][]string, len(sales)),
+	}
+
+	var purchases, units int
+	var revenue, margin domain.Money
+	for i, product := range sales {
+		report.Rows[i] = []string{
+			strconv.Itoa(product.ProductID),
+			product.Name,
+			strconv.Itoa(product.Purchases),
+			strconv.Itoa(product.Units),
+			product.Revenue.String(),
+			product.GrossMargin.String(),
+		}
+		purchases += product.Purchases
+		units += product.Units
+		revenue = revenue.Add(product.Revenue)
+		margin = margin.Add(product.GrossMargin)
+	}
+
+	report.Summary = []domain.ReportFigure{
+		{Label: "Products", Value: strconv.Itoa(len(sales))},
+		{Label: "Purchases", Value: strconv.Itoa(purchases)},
+		{Label: "Units", Value: strconv.Itoa(units)},
+		{Label: "Revenue", Value: revenue.String()},
+		{Label: "Gross margin", Value: margin.String()},
+	}
+
+	return report
+}
+
+func lowStockReport(name, title string, threshold int, products []*domain.Product) *domain.Report {
+	report := &domain.Report{
+		Name:        name,
+		Title:       title,
+		GeneratedAt: time.Now().UTC(),
+		Columns:     []string{"product_id", "name", "stock"},
+		Rows:        make([][]string, len(products)),
+		Summary: []domain.ReportFigure{
+			{Label: "Threshold", Value: strconv.Itoa(threshold)},
+			{Label: "Products", Value: strconv.Itoa(len(products))},
+		},
+	}
+
+	outOfStock := 0
+	for i, product := range products {
+		report.Rows[i] = []string{strconv.Itoa(product.ID), product.Name, strconv.Itoa(product.Stock)}
+		if product.Stock <= 0 {
+			outOfStock++
+		}
+	}
+	report.Summary = append(report.Summary, domain.ReportFigure{Label: "Out of stock", Value: strconv.Itoa(outOfStock)})
+
+	return report
+}
+
+// sortSalesByUnits orders sales by units sold, then revenue, highest first
+func sortSalesByUnits(sales []domain.ProductSales) {
+	sort.SliceStable(sales, func(i, j int) bool {
+		if sales[i].Units != sales[j].Units {
+			return sales[i].Units > sales[j].Units
+		}
+		return sales[i].Revenue.Amount > sales[j].Revenue.Amount
+	})
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>{{.Title}}</h2>
+<p>{{range .Summary}}<strong>{{.Label}}:</strong> {{.Value}}<br>{{end}}</p>
+{{if .Rows}}<table cellpadding="4" cellspacing="0" border="1" style="border-collapse: collapse">
+<tr>{{range .Columns}}<th align="left">{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{else}}<p>Nothing to report.</p>{{end}}
+<p style="color: #888">Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
+
+func renderReportHTML(report *domain.Report) (string, error) {
+	var b bytes.Buffer
+	if err := reportTemplate.Execute(&b, report); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func renderReportCSV(report *domain.Report) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(report.Columns); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(report.Rows); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// renderReportText is the plain-text body for clients without HTML
+func renderReportText(report *domain.Report) string {
+	var b strings.Builder
+	b.WriteString(report.Title + "\n\n")
+	for _, figure := range report.Summary {
+		fmt.Fprintf(&b, "%s: %s\n", figure.Label, figure.Value)
+	}
+	b.WriteString("\nThe full report is attached as CSV.\n")
+	return b.String()
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+type RiskService interface {
+	// Screen scores a completed checkout and queues it for review if its score reaches the
+	// review threshold, returning the review, or nil if the checkout was not flagged
+	Screen(ctx context.Context, check domain.RiskCheck) (*domain.RiskReview, error)
+
+	// Review queue
+	ListReviews(ctx context.Context, status string, limit, offset int) ([]*domain.RiskReview, int64, error)
+	ResolveReview(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.RiskReview, error)
+}
+
+type riskService struct {
+	riskRepo             repository.RiskRepository
+	profileRepo          repository.ProfileRepository
+	reviewScore          int
+	countryMismatchScore int
+	velocityWindow       time.Duration
+	velocityMax          int
+	velocityScore        int
+	largeQuantity        int
+	largeQuantityScore   int
+}
+
+func NewRiskService(riskRepo repository.RiskRepository, profileRepo repository.ProfileRepository, cfg *config.Config) (RiskService, error) {
+	velocityWindow, err := time.ParseDuration(cfg.Risk.Velocity.Window)
+	if err != nil {
+		return nil, fmt.Errorf("parse velocity window: %w", err)
+	}
+
+	return &riskService{
+		riskRepo:             riskRepo,
+		profileRepo:          profileRepo,
+		reviewScore:          cfg.Risk.ReviewScore,
+		countryMismatchScore: cfg.Risk.CountryMismatchScore,
+		velocityWindow:       velocityWindow,
+		velocityMax:          cfg.Risk.Velocity.MaxPurchases,
+		velocityScore:        cfg.Risk.Velocity.Score,
+		largeQuantity:        cfg.Risk.LargeQuantity.Quantity,
+		largeQuantityScore:   cfg.Risk.LargeQuantity.Score,
+	}, nil
+}
+
+// Screen scores a completed checkout on its signals and queues it for review if flagged.
+// The checkout's own purchases are already recorded, so they count toward its velocity.
+func (s *riskService) Screen(ctx context.Context, check domain.RiskCheck) (*domain.RiskReview, error) {
+	var signals []domain.RiskSignal
+
+	since := time.Now().Add(-s.velocityWindow)
+	purchases, err := s.riskRepo.CountPurchases(ctx, check.UserID, check.AnonymousID, since)
+	if err != nil {
+		return nil, err
+	}
+	if purchases > int64(s.velocityMax) {
+		signals = append(signals, domain.RiskSignal{
+			Name:   domain.RiskSignalVelocity,
+			Score:  s.velocityScore,
+			Detail: fmt.Sprintf("%d purchases in %s", purchases, s.velocityWindow),
+		})
+	}
+
+	for _, line := range check.Lines {
+		if line.Quantity >= s.largeQuantity {
+			signals = append(signals, domain.RiskSignal{
+				Name:   domain.RiskSignalLargeQuantity,
+				Score:  s.largeQuantityScore,
+				Detail: fmt.Sprintf("%d units of product %d", line.Quantity, line.ProductID),
+			})
+			break
+		}
+	}
+
+	if signal, err := s.countryMismatch(ctx, check); err != nil {
+		return nil, err
+	} else if signal != nil {
+		signals = append(signals, *signal)
+	}
+
+	score := 0
+	for _, signal := range signals {
+		score += signal.Score
+	}
+	if score < s.reviewScore {
+		return nil, nil
+	}
+
+	review := &domain.RiskReview{
+		UserID:      check.UserID,
+		AnonymousID: check.AnonymousID,
+		Source:      check.Source,
+		SourceID:    check.SourceID,
+		Lines:       check.Lines,
+		Amount:      check.Amount(),
+		Country:     check.Client.Country,
+		IPAddress:   check.Client.IPAddress,
+		Score:       score,
+		Signals:     signals,
+	}
+	if err := s.riskRepo.CreateReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	logger.GetLoggerFromContext(ctx).WithComponent("risk").WithFields(logger.Fields{
+		"review_id": review.ID,
+		"score":     score,
+	}).Warn("Checkout flagged for review")
+
+	return review, nil
+}
+
+// countryMismatch compares the country the request comes from with a user's profile country.
+// Guests, unknown client countries and profile countries that are not ISO codes are not scored.
+func (s *riskService) countryMismatch(ctx context.Context, check domain.RiskCheck) (*domain.RiskSignal, error) {
+	clientCountry := check.Client.Country
+	if check.UserID == 0 || len(clientCountry) != 2 || strings.EqualFold(clientCountry, "XX") {
+		return nil, nil
+	}
+
+	profile, err := s.profileRepo.GetByUserID(ctx, check.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	if profile.Country == nil || len(*profile.Country) != 2 || strings.EqualFold(*profile.Country, clientCountry) {
+		return nil, nil
+	}
+
+	return &domain.RiskSignal{
+		Name:   domain.RiskSignalCountryMismatch,
+		Score:  s.countryMismatchScore,
+		Detail: fmt.Sprintf("request from %s, profile country %s", strings.ToUpper(clientCountry), strings.ToUpper(*profile.Country)),
+	}, nil
+}
+
+// ListReviews retrieves a page of reviews in the given status, oldest first
+func (s *riskService) ListReviews(ctx context.Context, status string, limit, offset int) ([]*domain.RiskReview, int64, error) {
+	if status != "" && status != domain.RiskReviewPending && status != domain.RiskReviewApproved && status != domain.RiskReviewRejected {
+		return nil, 0, fmt.Errorf("unknown review status %q: %w", status, domain.ErrValidation)
+	}
+
+	reviews, total, err := s.riskRepo.ListReviews(ctx, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list risk reviews: %w", err)
+	}
+
+	return reviews, total, nil
+}
+
+// ResolveReview approves or rejects a pending review
+func (s *riskService) ResolveReview(ctx context.Context, id int, status string, reviewerID int, note string) (*domain.RiskReview, error) {
+	if status != domain.RiskReviewApproved && status != domain.RiskReviewRejected {
+		return nil, fmt.Errorf("review must be approved or rejected: %w", domain.ErrValidation)
+	}
+
+	return s.riskRepo.ResolveReview(ctx, id, status, reviewerID, note)
+}
+
+// screenPurchase scores a completed checkout. A failed screening is logged rather than
+// failing the purchase, which has already been made.
+func screenPurchase(ctx context.Context, risk RiskService, check domain.RiskCheck) {
+	if risk == nil {
+		return
+	}
+	if _, err := risk.Screen(ctx, check); err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("risk").WithError(err).Error("Failed to screen checkout")
+	}
+}
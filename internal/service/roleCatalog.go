@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// RoleCatalog caches the role hierarchy and the permissions of every role, so requests are
+// authorized by the current roles rather than the permissions their token was issued with.
+// Role changes take effect without a restart or new tokens.
+type RoleCatalog interface {
+	// Resolve returns the given roles together with the roles they inherit, and the permissions
+	// all of them grant. Unknown roles are dropped. ok is false until the catalog is loaded.
+	Resolve(roles []string) (resolved, permissions []string, ok bool)
+
+	// Reload reads the roles and their permissions from the database
+	Reload(ctx context.Context) error
+
+	// Run reloads the catalog every refresh interval until ctx is cancelled, so changes made
+	// on other instances take effect
+	Run(ctx context.Context) error
+}
+
+// resolvedRole is a role with everything it inherits
+type resolvedRole struct {
+	roles       []string
+	permissions []string
+}
+
+type roleCatalog struct {
+	roleRepo        repository.RoleRepository
+	permissionRepo  repository.PermissionRepository
+	refreshInterval time.Duration
+
+	roles atomic.Pointer[map[string]resolvedRole]
+}
+
+func NewRoleCatalog(roleRepo repository.RoleRepository, permissionRepo repository.PermissionRepository, cfg *config.Config) (RoleCatalog, error) {
+	refreshInterval, err := time.ParseDuration(cfg.Roles.RefreshInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse roles refresh interval: %w", err)
+	}
+
+	return &roleCatalog{
+		roleRepo:        roleRepo,
+		permissionRepo:  permissionRepo,
+		refreshInterval: refreshInterval,
+	}, nil
+}
+
+func (c *roleCatalog) Resolve(roles []string) ([]string, []string, bool) {
+	catalog := c.roles.Load()
+	if catalog == nil {
+		return nil, nil, false
+	}
+
+	resolvedRoles := make(map[string]bool)
+	permissions := make(map[string]bool)
+	for _, name := range roles {
+		role, ok := (*catalog)[name]
+		if !ok {
+			continue
+		}
+		for _, inherited := range role.roles {
+			resolvedRoles[inherited] = true
+		}
+		for _, permission := range role.permissions {
+			permissions[permission] = true
+		}
+	}
+
+	return sortedKeys(resolvedRoles), sortedKeys(permissions), true
+}
+
+func (c *roleCatalog) Reload(ctx context.Context) error {
+	roles, err := c.roleRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("get roles: %w", err)
+	}
+
+	grants, err := c.permissionRepo.GetNamesByRole(ctx)
+	if err != nil {
+		return fmt.Errorf("get role permissions: %w", err)
+	}
+
+	byID := make(map[int]domain.Role, len(roles))
+	for _, role := range roles {
+		byID[role.ID] = role
+	}
+
+	catalog := make(map[string]resolvedRole, len(roles))
+	for _, role := range roles {
+		// Walk the ancestors; visited guards against cycles written to the database directly
+		visited := map[int]bool{role.ID: true}
+		queue := []int{role.ID}
+		resolvedRoles := make(map[string]bool)
+		permissions := make(map[string]bool)
+		for len(queue) > 0 {
+			current := byID[queue[0]]
+			queue = queue[1:]
+
+			resolvedRoles[current.Name] = true
+			for _, permission := range grants[current.ID] {
+				permissions[permission] = true
+			}
+			for _, parentID := range current.Inherits {
+				if _, ok := byID[parentID]; ok && !visited[parentID] {
+					visited[parentID] = true
+					queue = append(queue, parentID)
+				}
+			}
+		}
+
+		catalog[role.Name] = resolvedRole{roles: sortedKeys(resolvedRoles), permissions: sortedKeys(permissions)}
+	}
+
+	c.roles.Store(&catalog)
+	return nil
+}
+
+func (c *roleCatalog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep the last known roles rather than denying everything on a failed read
+		if err := c.Reload(ctx); err != nil && ctx.Err() == nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("roles").WithError(err).Error("Failed to load roles")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
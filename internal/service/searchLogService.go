@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// SearchLogService records catalog searches and the products opened from their results, for
+// the search report
+type SearchLogService interface {
+	// Record logs a search and the result it got, returning the ID clients send back with the
+	// view of a product opened from the results
+	Record(ctx context.Context, log *domain.SearchLog) (string, error)
+
+	// RecordClick records the first product opened from the results of a search
+	RecordClick(ctx context.Context, searchID string, productID int) error
+}
+
+type searchLogService struct {
+	searchLogRepo repository.SearchLogRepository
+}
+
+func NewSearchLogService(searchLogRepo repository.SearchLogRepository) SearchLogService {
+	return &searchLogService{searchLogRepo: searchLogRepo}
+}
+
+func (s *searchLogService) Record(ctx context.Context, log *domain.SearchLog) (string, error) {
+	log.Term = domain.SearchTerm(log.Query)
+	log.SearchedAt = time.Now().UTC()
+	if err := s.searchLogRepo.Create(ctx, log); err != nil {
+		return "", fmt.Errorf("log search: %w", err)
+	}
+	return log.ID, nil
+}
+
+func (s *searchLogService) RecordClick(ctx context.Context, searchID string, productID int) error {
+	return s.searchLogRepo.RecordClick(ctx, searchID, productID, time.Now().UTC())
+}
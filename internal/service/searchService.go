@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/elasticsearch"
+	"github.com/PrimeraAizen/e-comm/pkg/eventbus"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+const (
+	searchProviderMongo         = "mongo"
+	searchProviderElasticsearch = "elasticsearch"
+
+	// productEventsTopic is the event bus topic product changes are published on
+	productEventsTopic = "products"
+
+	searchIndexBatchSize = 500
+)
+
+// SearchService ranks catalog products by relevance to a text query
+type SearchService interface {
+	Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResult, error)
+	// Run keeps the search index in sync with product changes until ctx is cancelled.
+	// It returns immediately for backends that query the database directly.
+	Run(ctx context.Context) error
+	// Reindex rebuilds the search index from the database once. It does nothing for backends
+	// that query the database directly.
+	Reindex(ctx context.Context) error
+}
+
+// NewSearchService creates the search service for the configured provider. Queries that find
+// nothing are corrected by spellingSearch.
+func NewSearchService(
+	productRepo repository.ProductRepository,
+	interactionRepo repository.InteractionRepository,
+	productEvents *eventbus.Bus[domain.ProductEvent],
+	listing domain.ListingSettings,
+	cfg *config.Config,
+) (SearchService, error) {
+	var search SearchService
+	switch cfg.Search.Provider {
+	case searchProviderElasticsearch:
+		var err error
+		search, err = newElasticsearchSearch(productRepo, interactionRepo, productEvents, listing, &cfg.Search.Elasticsearch)
+		if err != nil {
+			return nil, err
+		}
+	case searchProviderMongo:
+		search = &mongoSearch{productRepo: productRepo, listing: listing}
+	default:
+		return nil, fmt.Errorf("unknown search provider: %s", cfg.Search.Provider)
+	}
+	return &spellingSearch{SearchService: search, productRepo: productRepo, listing: listing}, nil
+}
+
+// normalizeSearchQuery validates the query and applies the page size limits of the listing
+func normalizeSearchQuery(query *domain.SearchQuery, listing domain.ListingSettings) error {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return fmt.Errorf("search query is required: %w", domain.ErrValidation)
+	}
+	query.Limit = listing.Limit(query.Limit)
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	return nil
+}
+
+// mongoSearch uses the products $text index. It has no typo tolerance, relevance tuning or
+// highlighting; results are ordered newest first.
+type mongoSearch struct {
+	productRepo repository.ProductRepository
+	listing     domain.ListingSettings
+}
+
+func (s *mongoSearch) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResult, error) {
+	if err := normalizeSearchQuery(&query, s.listing); err != nil {
+		return nil, err
+	}
+
+	active := true
+	products, total, err := s.productRepo.ListWithCategories(ctx, domain.ProductFilter{
+		SearchQuery: query.Query,
+		CategoryID:  query.CategoryID,
+		IsActive:    &active,
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search products: %w", err)
+	}
+
+	hits := make([]domain.SearchHit, len(products))
+	for i, product := range products {
+		hits[i] = domain.SearchHit{Product: product}
+	}
+
+	return &domain.SearchResult{Hits: hits, Total: total}, nil
+}
+
+func (s *mongoSearch) Run(ctx context.Context) error {
+	return nil
+}
+
+func (s *mongoSearch) Reindex(ctx context.Context) error {
+	return nil
+}
+
+// elasticsearchSearch queries an Elasticsearch/OpenSearch index with fuzzy matching, boosts
+// results by popularity and highlights matches. The index holds only what is needed for
+// ranking; hits are loaded from the database so prices and stock are always current.
+type elasticsearchSearch struct {
+	client          *elasticsearch.Client
+	index           string
+	productRepo     repository.ProductRepository
+	interactionRepo repository.InteractionRepository
+	productEvents   *eventbus.Bus[domain.ProductEvent]
+	reindexInterval time.Duration
+	popularityBoost float64
+	listing         domain.ListingSettings
+}
+
+// searchDocument is the indexed form of a product
+type searchDocument struct {
+	Name         string                               `json:"name"`
+	Description  string                               `json:"description"`
+	Translations map[string]domain.ProductTranslation `json:"translations,omitempty"`
+	CategoryID   *int                                 `json:"category_id,omitempty"`
+	CategoryName string                               `json:"category_name,omitempty"`
+	IsActive     bool                                 `json:"is_active"`
+	Popularity   float64                              `json:"popularity"`
+	IndexedAt    time.Time                            `json:"indexed_at"`
+}
+
+// productIndex defines the field types of the product index
+var productIndex = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name":          map[string]string{"type": "text"},
+			"description":   map[string]string{"type": "text"},
+			"category_id":   map[string]string{"type": "integer"},
+			"category_name": map[string]string{"type": "text"},
+			"is_active":     map[string]string{"type": "boolean"},
+			"popularity":    map[string]string{"type": "double"},
+			"indexed_at":    map[string]string{"type": "date"},
+			"translations":  map[string]string{"type": "object"},
+		},
+	},
+}
+
+func newElasticsearchSearch(
+	productRepo repository.ProductRepository,
+	interactionRepo repository.InteractionRepository,
+	productEvents *eventbus.Bus[domain.ProductEvent],
+	listing domain.ListingSettings,
+	cfg *config.Elasticsearch,
+) (*elasticsearchSearch, error) {
+	client, err := elasticsearch.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	reindexInterval, err := time.ParseDuration(cfg.ReindexInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse elasticsearch reindex interval: %w", err)
+	}
+
+	return &elasticsearchSearch{
+		client:          client,
+		index:           cfg.Index,
+		productRepo:     productRepo,
+		interactionRepo: interactionRepo,
+		productEvents:   productEvents,
+		reindexInterval: reindexInterval,
+		popularityBoost: cfg.PopularityBoost,
+		listing:         listing,
+	}, nil
+}
+
+func (s *elasticsearchSearch) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResult, error) {
+	if err := normalizeSearchQuery(&query, s.listing); err != nil {
+		return nil, err
+	}
+
+	filters := []interface{}{
+		map[string]interface{}{"term": map[string]interface{}{"is_active": true}},
+	}
+	if query.CategoryID != nil {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"category_id": *query.CategoryID}})
+	}
+
+	body := map[string]interface{}{
+		"from":    query.Offset,
+		"size":    query.Limit,
+		"_source": false,
+		"query": map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": map[string]interface{}{
+					"bool": map[string]interface{}{
+						"must": map[string]interface{}{
+							"multi_match": map[string]interface{}{
+								"query":         query.Query,
+								"fields":        []string{"name^3", "translations.*.name^3", "category_name^2", "description", "translations.*.description"},
+								"fuzziness":     "AUTO",
+								"prefix_length": 1,
+							},
+						},
+						"filter": filters,
+					},
+				},
+				// Adds popularity_boost * ln(1 + popularity) to the text score
+				"functions": []interface{}{
+					map[string]interface{}{
+						"field_value_factor": map[string]interface{}{
+							"field":    "popularity",
+							"modifier": "ln1p",
+							"missing":  0,
+						},
+						"weight": s.popularityBoost,
+					},
+				},
+				"boost_mode": "sum",
+			},
+		},
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]interface{}{
+				"name":        map[string]interface{}{"number_of_fragments": 0},
+				"description": map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+			},
+		},
+	}
+
+	response, err := s.client.Search(ctx, s.index, body)
+	if err != nil {
+		return nil, fmt.Errorf("search products: %w", err)
+	}
+
+	ids := make([]interface{}, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		id, err := strconv.Atoi(hit.ID)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return &domain.SearchResult{Hits: []domain.SearchHit{}, Total: response.Hits.Total.Value}, nil
+	}
+
+	products, _, err := s.productRepo.ListWithCategories(ctx, domain.ProductFilter{
+		Conditions: []domain.FilterCondition{{Field: "id", Operator: domain.FilterIn, Values: ids}},
+		Limit:      len(ids),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load search hits: %w", err)
+	}
+	byID := make(map[int]*domain.ProductWithCategory, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	// Keep the ranking order; hits deleted since they were indexed are skipped
+	hits := make([]domain.SearchHit, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		id, _ := strconv.Atoi(hit.ID)
+		product, ok := byID[id]
+		if !ok {
+			continue
+		}
+		hits = append(hits, domain.SearchHit{
+			Product:    product,
+			Score:      hit.Score,
+			Highlights: hit.Highlight,
+		})
+	}
+
+	return &domain.SearchResult{Hits: hits, Total: response.Hits.Total.Value}, nil
+}
+
+// Run creates the index, fully reindexes on start and every reindex interval, and applies
+// product change events in between. Events missed while the indexer was busy are picked up
+// by the next full reindex.
+func (s *elasticsearchSearch) Run(ctx context.Context) error {
+	events, unsubscribe := s.productEvents.Subscribe(productEventsTopic)
+	defer unsubscribe()
+
+	log := logger.GetLoggerFromContext(ctx).WithComponent("search")
+
+	if err := s.client.EnsureIndex(ctx, s.index, productIndex); err != nil {
+		log.WithError(err).Error("Failed to create search index")
+	} else if err := s.reindex(ctx); err != nil && ctx.Err() == nil {
+		log.WithError(err).Error("Failed to reindex products")
+	}
+
+	ticker := time.NewTicker(s.reindexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if err := s.apply(ctx, event); err != nil && ctx.Err() == nil {
+				log.WithError(err).Error("Failed to update search index")
+			}
+		case <-ticker.C:
+			if err := s.client.EnsureIndex(ctx, s.index, productIndex); err != nil {
+				log.WithError(err).Error("Failed to create search index")
+				continue
+			}
+			if err := s.reindex(ctx); err != nil && ctx.Err() == nil {
+				log.WithError(err).Error("Failed to reindex products")
+			}
+		}
+	}
+}
+
+func (s *elasticsearchSearch) Reindex(ctx context.Context) error {
+	if err := s.client.EnsureIndex(ctx, s.index, productIndex); err != nil {
+		return fmt.Errorf("create search index: %w", err)
+	}
+	return s.reindex(ctx)
+}
+
+// apply indexes or removes the product a change event refers to
+func (s *elasticsearchSearch) apply(ctx context.Context, event domain.ProductEvent) error {
+	id := strconv.Itoa(event.ProductID)
+	if event.Type == domain.ProductDeleted {
+		return s.client.Delete(ctx, s.index, id)
+	}
+
+	product, err := s.productRepo.GetByIDWithCategory(ctx, event.ProductID, nil)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return s.client.Delete(ctx, s.index, id)
+		}
+		return fmt.Errorf("get product: %w", err)
+	}
+
+	counts, err := s.interactionRepo.GetInteractionCounts(ctx, []int{event.ProductID})
+	if err != nil {
+		return fmt.Errorf("get interaction counts: %w", err)
+	}
+
+	return s.client.Index(ctx, s.index, id, newSearchDocument(product, counts[product.ID], time.Now().UTC()))
+}
+
+// reindex writes every product to the index in batches, then removes documents of products
+// that no longer exist
+func (s *elasticsearchSearch) reindex(ctx context.Context) error {
+	// Millisecond precision matches the index date type
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	counts, err := s.interactionRepo.GetInteractionCounts(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("get interaction counts: %w", err)
+	}
+
+	batch := make([]elasticsearch.Document, 0, searchIndexBatchSize)
+	err = s.productRepo.StreamWithCategories(ctx, domain.ProductFilter{}, func(product *domain.ProductWithCategory) error {
+		batch = append(batch, elasticsearch.Document{
+			ID:     strconv.Itoa(product.ID),
+			Source: newSearchDocument(product, counts[product.ID], startedAt),
+		})
+		if len(batch) < searchIndexBatchSize {
+			return nil
+		}
+		err := s.client.Bulk(ctx, s.index, batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("index products: %w", err)
+	}
+	if err := s.client.Bulk(ctx, s.index, batch); err != nil {
+		return fmt.Errorf("index products: %w", err)
+	}
+
+	return s.client.DeleteByQuery(ctx, s.index, map[string]interface{}{
+		"range": map[string]interface{}{"indexed_at": map[string]interface{}{"lt": startedAt}},
+	})
+}
+
+func newSearchDocument(product *domain.ProductWithCategory, counts domain.InteractionCounts, indexedAt time.Time) searchDocument {
+	return searchDocument{
+		Name:         product.Name,
+		Description:  product.Description,
+		CategoryID:   product.CategoryID,
+		CategoryName: product.CategoryName,
+		IsActive:     product.IsActive,
+		Translations: product.Translations,
+		Popularity:   counts.Popularity(),
+		IndexedAt:    indexedAt,
+	}
+}
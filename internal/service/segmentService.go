@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// SegmentService manages user segments. Membership is evaluated in the background and stored,
+// so promotions and notifications can target a segment without evaluating its rules.
+type SegmentService interface {
+	CreateSegment(ctx context.Context, segment *domain.Segment) error
+	GetSegment(ctx context.Context, id int) (*domain.Segment, error)
+	ListSegments(ctx context.Context) ([]*domain.Segment, error)
+	UpdateSegment(ctx context.Context, segment *domain.Segment) error
+	DeleteSegment(ctx context.Context, id int) error
+
+	// EvaluateSegment recomputes the members of a segment and returns it with the new count
+	EvaluateSegment(ctx context.Context, id int) (*domain.Segment, error)
+	ListMembers(ctx context.Context, id int, limit, offset int) ([]int, int64, error)
+
+	// UserSegmentIDs returns the segments a user was a member of at their last evaluation
+	UserSegmentIDs(ctx context.Context, userID int) ([]int, error)
+
+	// NotifySegment starts sending a marketing notification to the members of a segment and
+	// returns how many there are. Sending continues after the call returns and its outcome is
+	// logged; members who didn't opt in to the channel are skipped.
+	NotifySegment(ctx context.Context, id int, notification domain.Notification) (int64, error)
+
+	// Run evaluates the segments of every tenant each evaluate interval until ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type segmentService struct {
+	segmentRepo      repository.SegmentRepository
+	notifications    NotificationService
+	tenancy          *config.Tenancy
+	evaluateInterval time.Duration
+	notifyBatchSize  int
+}
+
+func NewSegmentService(
+	segmentRepo repository.SegmentRepository,
+	notifications NotificationService,
+	cfg *config.Config,
+) (SegmentService, error) {
+	evaluateInterval, err := time.ParseDuration(cfg.Segments.EvaluateInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse segments evaluate interval: %w", err)
+	}
+
+	return &segmentService{
+		segmentRepo:      segmentRepo,
+		notifications:    notifications,
+		tenancy:          &cfg.Tenancy,
+		evaluateInterval: evaluateInterval,
+		notifyBatchSize:  cfg.Segments.NotifyBatchSize,
+	}, nil
+}
+
+// CreateSegment creates a segment and starts evaluating its members
+func (s *segmentService) CreateSegment(ctx context.Context, segment *domain.Segment) error {
+	if err := segment.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.segmentRepo.Create(ctx, segment); err != nil {
+		if err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("create segment: %w", err)
+	}
+
+	s.evaluateLater(ctx, segment)
+	return nil
+}
+
+// GetSegment retrieves a segment by ID
+func (s *segmentService) GetSegment(ctx context.Context, id int) (*domain.Segment, error) {
+	segment, err := s.segmentRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get segment: %w", err)
+	}
+
+	return segment, nil
+}
+
+// ListSegments retrieves all segments
+func (s *segmentService) ListSegments(ctx context.Context) ([]*domain.Segment, error) {
+	segments, err := s.segmentRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list segments: %w", err)
+	}
+
+	return segments, nil
+}
+
+// UpdateSegment changes the name, description and rules of a segment and starts evaluating
+// its members; the previous members stay until the evaluation is done
+func (s *segmentService) UpdateSegment(ctx context.Context, segment *domain.Segment) error {
+	if err := segment.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.GetSegment(ctx, segment.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.segmentRepo.Update(ctx, segment); err != nil {
+		if err == domain.ErrNotFound || err == domain.ErrAlreadyExists {
+			return err
+		}
+		return fmt.Errorf("update segment: %w", err)
+	}
+
+	segment.MemberCount = existing.MemberCount
+	segment.EvaluatedAt = existing.EvaluatedAt
+	segment.CreatedAt = existing.CreatedAt
+
+	s.evaluateLater(ctx, segment)
+	return nil
+}
+
+// DeleteSegment deletes a segment and its members. Bundles targeted only at it are no longer
+// offered to anyone.
+func (s *segmentService) DeleteSegment(ctx context.Context, id int) error {
+	if err := s.segmentRepo.Delete(ctx, id); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("delete segment: %w", err)
+	}
+
+	return nil
+}
+
+func (s *segmentService) EvaluateSegment(ctx context.Context, id int) (*domain.Segment, error) {
+	segment, err := s.GetSegment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.evaluate(ctx, segment); err != nil {
+		return nil, err
+	}
+
+	return s.GetSegment(ctx, id)
+}
+
+// ListMembers retrieves a page of the IDs of a segment's members
+func (s *segmentService) ListMembers(ctx context.Context, id int, limit, offset int) ([]int, int64, error) {
+	if _, err := s.GetSegment(ctx, id); err != nil {
+		return nil, 0, err
+	}
+
+	members, total, err := s.segmentRepo.ListMembers(ctx, id, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list segment members: %w", err)
+	}
+
+	return members, total, nil
+}
+
+func (s *segmentService) UserSegmentIDs(ctx context.Context, userID int) ([]int, error) {
+	segmentIDs, err := s.segmentRepo.GetUserSegmentIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user segments: %w", err)
+	}
+
+	return segmentIDs, nil
+}
+
+func (s *segmentService) NotifySegment(ctx context.Context, id int, notification domain.Notification) (int64, error) {
+	switch notification.Channel {
+	case domain.NotificationChannelEmail, domain.NotificationChannelSMS:
+	default:
+		return 0, fmt.Errorf("unknown notification channel %q: %w", notification.Channel, domain.ErrValidation)
+	}
+	if notification.Body == "" {
+		return 0, fmt.Errorf("notification body is required: %w", domain.ErrValidation)
+	}
+	notification.Kind = domain.NotificationMarketing
+
+	segment, err := s.GetSegment(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	// Sending outlives the request
+	notifyCtx := context.WithoutCancel(ctx)
+	go s.notify(notifyCtx, segment, notification)
+
+	return segment.MemberCount, nil
+}
+
+// notify sends the notification to the members of the segment a batch at a time
+func (s *segmentService) notify(ctx context.Context, segment *domain.Segment, notification domain.Notification) {
+	log := logger.GetLoggerFromContext(ctx).WithComponent("segments").WithFields(logger.Fields{
+		"segment_id": segment.ID,
+		"channel":    notification.Channel,
+	})
+	started := time.Now()
+
+	var sent, skipped, failed int
+	for offset := 0; ; offset += s.notifyBatchSize {
+		members, _, err := s.segmentRepo.ListMembers(ctx, segment.ID, s.notifyBatchSize, offset)
+		if err != nil {
+			log.WithError(err).Error("Failed to list segment members")
+			return
+		}
+
+		for _, userID := range members {
+			delivered, err := s.notifications.Notify(ctx, userID, notification)
+			switch {
+			case err != nil:
+				failed++
+				log.WithError(err).Warn("Failed to notify segment member", "user_id", userID)
+			case delivered:
+				sent++
+			default:
+				skipped++
+			}
+		}
+
+		if len(members) < s.notifyBatchSize {
+			break
+		}
+	}
+
+	log.WithDuration(time.Since(started)).WithFields(logger.Fields{
+		"sent":    sent,
+		"skipped": skipped,
+		"failed":  failed,
+	}).Info("Notified segment")
+}
+
+func (s *segmentService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.evaluateInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, tenantCtx := range tenant.Contexts(ctx, s.tenancy) {
+			if err := s.evaluateStale(tenantCtx); err != nil && ctx.Err() == nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("segments").WithError(err).WithFields(logger.Fields{
+					"tenant": tenant.ID(tenantCtx),
+				}).Error("Failed to evaluate segments")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluateStale evaluates the segments not evaluated in the last half interval; more recent
+// ones were just evaluated by another instance or after a change
+func (s *segmentService) evaluateStale(ctx context.Context) error {
+	segments, err := s.segmentRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list segments: %w", err)
+	}
+
+	fresh := time.Now().Add(-s.evaluateInterval / 2)
+	for _, segment := range segments {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if segment.EvaluatedAt != nil && segment.EvaluatedAt.After(fresh) {
+			continue
+		}
+		if err := s.evaluate(ctx, segment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evaluateLater evaluates a segment in the background after it was created or changed
+func (s *segmentService) evaluateLater(ctx context.Context, segment *domain.Segment) {
+	evaluateCtx := context.WithoutCancel(ctx)
+	go func() {
+		if err := s.evaluate(evaluateCtx, segment); err != nil {
+			logger.GetLoggerFromContext(evaluateCtx).WithComponent("segments").WithError(err).WithFields(logger.Fields{
+				"segment_id": segment.ID,
+			}).Error("Failed to evaluate segment")
+		}
+	}()
+}
+
+// evaluate stores the users matching all rules of the segment as its members
+func (s *segmentService) evaluate(ctx context.Context, segment *domain.Segment) error {
+	now := time.Now().UTC()
+
+	users, err := s.segmentRepo.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+
+	// Interaction rules compare one aggregate per user, loaded once for all users
+	aggregates := make([]map[int]float64, len(segment.Rules))
+	for i := range segment.Rules {
+		rule := &segment.Rules[i]
+		if !rule.IsInteractionRule() {
+			continue
+		}
+		aggregates[i], err = s.segmentRepo.AggregateInteractions(ctx, rule.Attribute, rule.Since(now))
+		if err != nil {
+			return fmt.Errorf("aggregate interactions: %w", err)
+		}
+	}
+
+	members := make([]int, 0)
+	for i := range users {
+		if matchesSegment(segment, &users[i], aggregates) {
+			members = append(members, users[i].UserID)
+		}
+	}
+
+	if err := s.segmentRepo.ReplaceMembers(ctx, segment.ID, members, now); err != nil {
+		return fmt.Errorf("store segment members: %w", err)
+	}
+
+	logger.GetLoggerFromContext(ctx).WithComponent("segments").WithDuration(time.Since(now)).WithFields(logger.Fields{
+		"segment_id": segment.ID,
+		"members":    len(members),
+	}).Debug("Evaluated segment")
+
+	return nil
+}
+
+// matchesSegment reports whether the user matches every rule of the segment
+func matchesSegment(segment *domain.Segment, user *domain.SegmentUser, aggregates []map[int]float64) bool {
+	for i := range segment.Rules {
+		rule := &segment.Rules[i]
+		if rule.IsInteractionRule() {
+			if !rule.MatchAggregate(aggregates[i][user.UserID]) {
+				return false
+			}
+			continue
+		}
+		if !rule.MatchProfile(user) {
+			return false
+		}
+	}
+	return true
+}
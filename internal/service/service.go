@@ -2,37 +2,321 @@ package service
 
 import (
 	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/broker"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/mailer"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/payment"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/sms"
+	"github.com/PrimeraAizen/e-comm/pkg/eventbus"
 )
 
 type Service struct {
 	ExampleService        Example
 	HealthService         Health
 	AuthService           AuthService
+	PasswordPolicy        PasswordPolicy
 	UserService           UserService
+	PhoneVerification     PhoneVerificationService
+	PermissionService     PermissionService
+	RoleCatalog           RoleCatalog
 	ProductService        ProductService
+	ProductImageService   ProductImageService
+	InventoryService      InventoryService
+	BundleService         BundleService
+	SubscriptionService   SubscriptionService
 	InteractionService    InteractionService
 	RecommendationService RecommendationService
+	BoughtTogetherService BoughtTogetherService
+	FeedService           FeedService
+	StockFeed             StockFeed
+	LiveMetrics           LiveMetrics
+	SearchService         SearchService
+	GlobalSearchService   GlobalSearchService
+	SearchLogService      SearchLogService
+	ActivityService       ActivityService
+	PreferenceService     PreferenceService
+	NotificationService   NotificationService
+	CartService           CartService
+	FlashSaleService      FlashSaleService
+	RiskService           RiskService
+	ModerationService     ModerationService
+	SegmentService        SegmentService
+	PromotionService      PromotionService
+	CustomerGroupService  CustomerGroupService
+	SupportNoteService    SupportNoteService
+	OrderService          OrderService
+	ReportService         ReportService
+	IndexService          IndexService
+	IntegrityService      IntegrityService
+	BackupService         BackupService
+	MediaService          MediaService
+	MaintenanceService    MaintenanceService
+	UsageService          UsageService
+	Outbox                Outbox
+
+	// CartEvents publishes abandoned and recovered carts on CartEventsTopic
+	CartEvents *eventbus.Bus[domain.CartEvent]
 }
 
 type Deps struct {
 	Repos  *repository.Repository
 	Config *config.Config
+
+	// Publisher streams interaction events to the message broker; nil leaves streaming off
+	Publisher broker.Publisher
 }
 
 func NewServices(deps Deps) *Service {
-	authService, err := NewAuthService(deps.Repos.User, deps.Config)
+	// Domain events are recorded with their changes and published by the outbox relay
+	productEvents := eventbus.New[domain.ProductEvent](eventbus.DefaultBufferSize)
+	cartEvents := eventbus.New[domain.CartEvent](eventbus.DefaultBufferSize)
+	purchaseEvents := eventbus.New[domain.PurchaseEvent](eventbus.DefaultBufferSize)
+	outboxHandlers := map[string]OutboxHandler{
+		productEventsTopic: publishTo(productEvents, productEventsTopic),
+		CartEventsTopic:    publishTo(cartEvents, CartEventsTopic),
+		purchasesTopic:     publishTo(purchaseEvents, purchasesTopic),
+		productImagesTopic: generateImageVariants(deps.Repos.Product, deps.Config),
+	}
+
+	// Interactions are streamed by every service recording them
+	interactionRepo := deps.Repos.Interaction
+	if deps.Publisher != nil {
+		outboxHandlers[interactionsTopic] = streamInteractions(deps.Publisher, &deps.Config.Streaming.Topics)
+	}
+
+	outbox, err := NewOutbox(deps.Repos.Outbox, outboxHandlers, deps.Config)
+	if err != nil {
+		panic("failed to create outbox: " + err.Error())
+	}
+	// Purchases made through any service update the recommendations of their buyer
+	interactionRepo = newPurchaseEventsInteractionRepository(interactionRepo, deps.Repos.Transactor, outbox)
+	if deps.Publisher != nil {
+		interactionRepo = newStreamingInteractionRepository(interactionRepo, deps.Repos.Transactor, outbox)
+	}
+
+	roleCatalog, err := NewRoleCatalog(deps.Repos.Role, deps.Repos.Permission, deps.Config)
+	if err != nil {
+		panic("failed to create role catalog: " + err.Error())
+	}
+
+	authService, err := NewAuthService(deps.Repos.User, deps.Repos.Session, deps.Repos.Role, roleCatalog, deps.Config)
 	if err != nil {
 		panic("failed to create auth service: " + err.Error())
 	}
 
+	passwordPolicy, err := NewPasswordPolicy(deps.Config)
+	if err != nil {
+		panic("failed to create password policy: " + err.Error())
+	}
+
+	mailSender, err := mailer.New(&deps.Config.Mail)
+	if err != nil {
+		panic("failed to create mailer: " + err.Error())
+	}
+
+	moderationService, err := NewModerationService(deps.Repos.Moderation, deps.Repos.Profile, deps.Repos.Transactor, deps.Config)
+	if err != nil {
+		panic("failed to create moderation service: " + err.Error())
+	}
+
+	userService, err := NewUserService(
+		deps.Repos.User,
+		deps.Repos.Profile,
+		deps.Repos.Session,
+		deps.Repos.EmailChange,
+		passwordPolicy,
+		moderationService,
+		mailSender,
+		deps.Config,
+	)
+	if err != nil {
+		panic("failed to create user service: " + err.Error())
+	}
+
+	smsSender, err := sms.New(&deps.Config.SMS)
+	if err != nil {
+		panic("failed to create sms sender: " + err.Error())
+	}
+
+	phoneVerificationService, err := NewPhoneVerificationService(deps.Repos.Profile, deps.Repos.PhoneVerification, smsSender, deps.Config)
+	if err != nil {
+		panic("failed to create phone verification service: " + err.Error())
+	}
+
+	stockFeed, err := NewStockFeed(deps.Repos.Product, deps.Config)
+	if err != nil {
+		panic("failed to create stock feed: " + err.Error())
+	}
+
+	liveMetrics, err := NewLiveMetrics(interactionRepo, deps.Config)
+	if err != nil {
+		panic("failed to create live metrics: " + err.Error())
+	}
+
+	maintenanceService, err := NewMaintenanceService(deps.Repos.Maintenance, deps.Config)
+	if err != nil {
+		panic("failed to create maintenance service: " + err.Error())
+	}
+
+	usageService, err := NewUsageService(deps.Repos.Usage, deps.Config)
+	if err != nil {
+		panic("failed to create usage service: " + err.Error())
+	}
+
+	listings, err := NewListingSettings(deps.Config)
+	if err != nil {
+		panic("failed to create listing settings: " + err.Error())
+	}
+
+	searchService, err := NewSearchService(deps.Repos.Product, interactionRepo, productEvents, listingSettings(listings, domain.ListingSearch), deps.Config)
+	if err != nil {
+		panic("failed to create search service: " + err.Error())
+	}
+
+	riskService, err := NewRiskService(deps.Repos.Risk, deps.Repos.Profile, deps.Config)
+	if err != nil {
+		panic("failed to create risk service: " + err.Error())
+	}
+
+	paymentGateway, err := payment.New(&deps.Config.Payment)
+	if err != nil {
+		panic("failed to create payment gateway: " + err.Error())
+	}
+
+	subscriptionService, err := NewSubscriptionService(
+		deps.Repos.Subscription,
+		deps.Repos.Product,
+		deps.Repos.Stock,
+		interactionRepo,
+		paymentGateway,
+		stockFeed,
+		deps.Config,
+	)
+	if err != nil {
+		panic("failed to create subscription service: " + err.Error())
+	}
+
+	notificationService := NewNotificationService(deps.Repos.User, deps.Repos.Profile, mailSender, smsSender)
+
+	segmentService, err := NewSegmentService(deps.Repos.Segment, notificationService, deps.Config)
+	if err != nil {
+		panic("failed to create segment service: " + err.Error())
+	}
+
+	promotionService, err := NewPromotionService(deps.Repos.Promotion, notificationService, deps.Config)
+	if err != nil {
+		panic("failed to create promotion service: " + err.Error())
+	}
+
+	reportService, err := NewReportService(deps.Repos.Report, deps.Repos.Profile, mailSender, deps.Config)
+	if err != nil {
+		panic("failed to create report service: " + err.Error())
+	}
+
+	recommendationService, err := NewRecommendationService(
+		interactionRepo,
+		deps.Repos.Product,
+		deps.Repos.Recommendation,
+		deps.Repos.Profile,
+		purchaseEvents,
+		deps.Config,
+	)
+	if err != nil {
+		panic("failed to create recommendation service: " + err.Error())
+	}
+
+	boughtTogetherService, err := NewBoughtTogetherService(deps.Repos.BoughtTogether, deps.Repos.Product, deps.Config)
+	if err != nil {
+		panic("failed to create bought together service: " + err.Error())
+	}
+
+	feedService, err := NewFeedService(deps.Repos.Feed, deps.Repos.Product, deps.Repos.Profile, recommendationService, deps.Config)
+	if err != nil {
+		panic("failed to create feed service: " + err.Error())
+	}
+
+	cartService, err := NewCartService(
+		deps.Repos.Cart,
+		deps.Repos.Product,
+		deps.Repos.Stock,
+		interactionRepo,
+		notificationService,
+		stockFeed,
+		deps.Repos.Transactor,
+		outbox,
+		riskService,
+		deps.Repos.Promotion,
+		deps.Repos.CustomerGroup,
+		deps.Repos.PurchaseLimit,
+		deps.Config,
+	)
+	if err != nil {
+		panic("failed to create cart service: " + err.Error())
+	}
+
+	flashSaleService, err := NewFlashSaleService(
+		deps.Repos.FlashSale,
+		deps.Repos.Product,
+		deps.Repos.Stock,
+		interactionRepo,
+		deps.Repos.PurchaseLimit,
+		stockFeed,
+		riskService,
+		deps.Config,
+	)
+	if err != nil {
+		panic("failed to create flash sale service: " + err.Error())
+	}
+
+	mediaService, err := NewMediaService(deps.Config)
+	if err != nil {
+		panic("failed to create media service: " + err.Error())
+	}
+
 	return &Service{
 		ExampleService:        NewExampleService(deps.Repos.Example),
 		HealthService:         NewHealthService(deps.Repos.Health),
 		AuthService:           authService,
-		UserService:           NewUserService(deps.Repos.User, deps.Repos.Profile),
-		ProductService:        NewProductService(deps.Repos.Product),
-		InteractionService:    NewInteractionService(deps.Repos.Interaction, deps.Repos.Product),
-		RecommendationService: NewRecommendationService(deps.Repos.Interaction, deps.Repos.Product),
+		PasswordPolicy:        passwordPolicy,
+		UserService:           userService,
+		PhoneVerification:     phoneVerificationService,
+		PermissionService:     NewPermissionService(deps.Repos.Permission, deps.Repos.Role, roleCatalog, &deps.Config.Tenancy),
+		RoleCatalog:           roleCatalog,
+		ProductService:        NewProductService(deps.Repos.Product, deps.Repos.Stock, deps.Repos.Warehouse, stockFeed, deps.Repos.Transactor, outbox, deps.Config.Payment.Currency, listings),
+		ProductImageService:   NewProductImageService(deps.Repos.Product, outbox, deps.Config),
+		InventoryService:      NewInventoryService(deps.Repos.Warehouse, deps.Repos.Stock, deps.Repos.Product),
+		BundleService:         NewBundleService(deps.Repos.Bundle, deps.Repos.Segment, deps.Repos.Product, deps.Repos.Stock, interactionRepo, stockFeed, riskService),
+		SubscriptionService:   subscriptionService,
+		InteractionService:    NewInteractionService(interactionRepo, deps.Repos.Product, deps.Repos.Stock, stockFeed, riskService, deps.Repos.CustomerGroup, deps.Repos.PurchaseLimit),
+		RecommendationService: recommendationService,
+		BoughtTogetherService: boughtTogetherService,
+		FeedService:           feedService,
+		StockFeed:             stockFeed,
+		LiveMetrics:           liveMetrics,
+		SearchService:         searchService,
+		GlobalSearchService:   NewGlobalSearchService(searchService, deps.Repos.Product, deps.Repos.User, deps.Repos.Order),
+		SearchLogService:      NewSearchLogService(deps.Repos.SearchLog),
+		ActivityService:       NewActivityService(deps.Repos.Activity),
+		PreferenceService:     NewPreferenceService(deps.Repos.Profile, deps.Repos.Product),
+		NotificationService:   notificationService,
+		CartService:           cartService,
+		FlashSaleService:      flashSaleService,
+		RiskService:           riskService,
+		ModerationService:     moderationService,
+		SegmentService:        segmentService,
+		PromotionService:      promotionService,
+		CustomerGroupService:  NewCustomerGroupService(deps.Repos.CustomerGroup, deps.Repos.Product),
+		SupportNoteService:    NewSupportNoteService(deps.Repos.SupportNote, deps.Repos.User),
+		OrderService:          NewOrderService(deps.Repos.Order),
+		ReportService:         reportService,
+		IndexService:          NewIndexService(deps.Repos.Index),
+		IntegrityService:      NewIntegrityService(deps.Repos.Integrity),
+		BackupService:         NewBackupService(deps.Repos.Backup, deps.Config),
+		MediaService:          mediaService,
+		MaintenanceService:    maintenanceService,
+		UsageService:          usageService,
+		Outbox:                outbox,
+		CartEvents:            cartEvents,
 	}
 }
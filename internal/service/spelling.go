@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const (
+	// vocabularyTTL bounds how long words of new product names go unsuggested
+	vocabularyTTL = 10 * time.Minute
+
+	// maxSearchSuggestions is how many did-you-mean queries a search without results returns
+	maxSearchSuggestions = 3
+
+	// minCorrectedWordLength is the length below which query words are neither corrected nor
+	// relaxed, since almost any word is a typo or two away from them
+	minCorrectedWordLength = 3
+)
+
+// spellingSearch corrects queries that find nothing. It searches again for the best
+// did-you-mean suggestion, and if that finds nothing either, relaxes the query to products
+// with a name word starting like a word of the query or suggestion.
+type spellingSearch struct {
+	SearchService
+	productRepo repository.ProductRepository
+	listing     domain.ListingSettings
+	vocabulary  vocabularyCache
+}
+
+func (s *spellingSearch) Search(ctx context.Context, query domain.SearchQuery) (*domain.SearchResult, error) {
+	result, err := s.SearchService.Search(ctx, query)
+	if err != nil || result.Total > 0 {
+		return result, err
+	}
+
+	vocabulary, err := s.vocabulary.get(ctx, s.productRepo)
+	if err != nil {
+		return nil, fmt.Errorf("load search vocabulary: %w", err)
+	}
+
+	original := strings.TrimSpace(query.Query)
+	correction := &domain.SearchCorrection{
+		OriginalQuery: original,
+		Query:         original,
+		Suggestions:   suggestSpellings(original, vocabulary, maxSearchSuggestions),
+	}
+
+	if len(correction.Suggestions) > 0 {
+		corrected := query
+		corrected.Query = correction.Suggestions[0]
+		retried, err := s.SearchService.Search(ctx, corrected)
+		if err != nil {
+			return nil, err
+		}
+		if retried.Total > 0 {
+			correction.Query = corrected.Query
+			retried.Correction = correction
+			return retried, nil
+		}
+	}
+
+	relaxed, err := s.relaxedSearch(ctx, query, correction.Suggestions)
+	if err != nil {
+		return nil, err
+	}
+	if relaxed.Total > 0 {
+		correction.Relaxed = true
+		relaxed.Correction = correction
+		return relaxed, nil
+	}
+
+	if len(correction.Suggestions) > 0 {
+		result.Correction = correction
+	}
+	return result, nil
+}
+
+// relaxedSearch finds active products with a name word starting with the first half, and at
+// least minCorrectedWordLength letters, of a word of the query or the best suggestion
+func (s *spellingSearch) relaxedSearch(ctx context.Context, query domain.SearchQuery, suggestions []string) (*domain.SearchResult, error) {
+	if err := normalizeSearchQuery(&query, s.listing); err != nil {
+		return nil, err
+	}
+
+	words := domain.NameWords(query.Query)
+	if len(suggestions) > 0 {
+		words = append(words, domain.NameWords(suggestions[0])...)
+	}
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) < minCorrectedWordLength {
+			continue
+		}
+		prefix := string(runes[:max(minCorrectedWordLength, len(runes)/2)])
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return &domain.SearchResult{Hits: []domain.SearchHit{}}, nil
+	}
+
+	active := true
+	products, total, err := s.productRepo.ListWithCategories(ctx, domain.ProductFilter{
+		NamePrefixes: prefixes,
+		CategoryID:   query.CategoryID,
+		IsActive:     &active,
+		Limit:        query.Limit,
+		Offset:       query.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search products by name prefix: %w", err)
+	}
+
+	hits := make([]domain.SearchHit, len(products))
+	for i, product := range products {
+		hits[i] = domain.SearchHit{Product: product}
+	}
+	return &domain.SearchResult{Hits: hits, Total: total}, nil
+}
+
+// suggestSpellings returns up to limit queries with the words of the query that aren't in the
+// vocabulary replaced by their closest words that are, or nil if every word is known or has no
+// close match. The first suggestion takes the best replacement of every word.
+func suggestSpellings(query string, vocabulary map[string]int64, limit int) []string {
+	words := domain.NameWords(query)
+	candidates := make([][]string, len(words))
+	corrected := false
+	for i, word := range words {
+		candidates[i] = []string{word}
+		if vocabulary[word] > 0 || len([]rune(word)) < minCorrectedWordLength {
+			continue
+		}
+		if closest := closestWords(word, vocabulary, limit); len(closest) > 0 {
+			candidates[i] = closest
+			corrected = true
+		}
+	}
+	if !corrected {
+		return nil
+	}
+
+	var suggestions []string
+	seen := make(map[string]bool)
+	for rank := 0; rank < limit; rank++ {
+		chosen := make([]string, len(words))
+		for i, options := range candidates {
+			chosen[i] = options[min(rank, len(options)-1)]
+		}
+		suggestion := strings.Join(chosen, " ")
+		if !seen[suggestion] {
+			seen[suggestion] = true
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// closestWords returns up to limit vocabulary words within one edit of a word of up to four
+// letters, or two edits of a longer one, nearest first and then most frequent first
+func closestWords(word string, vocabulary map[string]int64, limit int) []string {
+	target := []rune(word)
+	maxDistance := 1
+	if len(target) > 4 {
+		maxDistance = 2
+	}
+
+	type match struct {
+		word     string
+		distance int
+		count    int64
+	}
+	var matches []match
+	for candidate, count := range vocabulary {
+		runes := []rune(candidate)
+		if abs(len(runes)-len(target)) > maxDistance {
+			continue
+		}
+		if distance := editDistance(target, runes); distance <= maxDistance {
+			matches = append(matches, match{candidate, distance, count})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		if matches[i].count != matches[j].count {
+			return matches[i].count > matches[j].count
+		}
+		return matches[i].word < matches[j].word
+	})
+
+	words := make([]string, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		words = append(words, m.word)
+	}
+	return words
+}
+
+// editDistance is the number of insertions, deletions, substitutions and transpositions of
+// adjacent letters that turn a into b (the optimal string alignment distance)
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// vocabularyCache caches the product name vocabulary of each tenant until it expires
+type vocabularyCache struct {
+	mu           sync.Mutex
+	vocabularies map[string]*cachedVocabulary
+}
+
+type cachedVocabulary struct {
+	words     map[string]int64
+	expiresAt time.Time
+}
+
+// get returns the cached vocabulary of the tenant of ctx, loading it when missing or expired.
+// When it can't be loaded the last one is served stale.
+func (c *vocabularyCache) get(ctx context.Context, productRepo repository.ProductRepository) (map[string]int64, error) {
+	tenantID := tenant.ID(ctx)
+
+	c.mu.Lock()
+	cached := c.vocabularies[tenantID]
+	if cached != nil && time.Now().Before(cached.expiresAt) {
+		c.mu.Unlock()
+		return cached.words, nil
+	}
+	c.mu.Unlock()
+
+	words, err := productRepo.NameVocabulary(ctx)
+	if err != nil {
+		if cached != nil {
+			return cached.words, nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.vocabularies == nil {
+		c.vocabularies = make(map[string]*cachedVocabulary)
+	}
+	c.vocabularies[tenantID] = &cachedVocabulary{words: words, expiresAt: time.Now().Add(vocabularyTTL)}
+	c.mu.Unlock()
+
+	return words, nil
+}
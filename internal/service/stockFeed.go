@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/eventbus"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+const (
+	realtimeSourceLocal        = "local"
+	realtimeSourceChangeStream = "change_stream"
+)
+
+// StockFeed pushes product stock changes to live subscribers
+type StockFeed interface {
+	Subscribe(productID int) (<-chan domain.StockEvent, func())
+	// Publish announces the product's current stock. It is a no-op when events come from
+	// change streams.
+	Publish(product *domain.Product)
+	// Run follows the change stream until ctx is cancelled when configured to; otherwise it
+	// returns immediately.
+	Run(ctx context.Context) error
+	Heartbeat() time.Duration
+}
+
+type stockFeed struct {
+	productRepo repository.ProductRepository
+	bus         *eventbus.Bus[domain.StockEvent]
+	source      string
+	heartbeat   time.Duration
+}
+
+func NewStockFeed(productRepo repository.ProductRepository, cfg *config.Config) (StockFeed, error) {
+	heartbeat, err := time.ParseDuration(cfg.Realtime.Heartbeat)
+	if err != nil {
+		return nil, fmt.Errorf("parse realtime heartbeat: %w", err)
+	}
+
+	return &stockFeed{
+		productRepo: productRepo,
+		bus:         eventbus.New[domain.StockEvent](eventbus.DefaultBufferSize),
+		source:      cfg.Realtime.Source,
+		heartbeat:   heartbeat,
+	}, nil
+}
+
+// Subscribe returns a channel of stock events for a product and a function to stop receiving them
+func (f *stockFeed) Subscribe(productID int) (<-chan domain.StockEvent, func()) {
+	return f.bus.Subscribe(stockTopic(productID))
+}
+
+// Publish announces the product's current stock to subscribers
+func (f *stockFeed) Publish(product *domain.Product) {
+	if f.source != realtimeSourceLocal {
+		return
+	}
+
+	f.bus.Publish(stockTopic(product.ID), domain.StockEvent{
+		ProductID: product.ID,
+		Stock:     product.Stock,
+		InStock:   product.Stock > 0,
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+// Run follows the products change stream and republishes stock changes, retrying after errors
+func (f *stockFeed) Run(ctx context.Context) error {
+	if f.source != realtimeSourceChangeStream {
+		return nil
+	}
+
+	for {
+		err := f.productRepo.WatchStock(ctx, func(event domain.StockEvent) {
+			f.bus.Publish(stockTopic(event.ProductID), event)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("realtime").WithError(err).Error("Stock change stream failed, retrying")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// Heartbeat returns the keep-alive interval for open streams
+func (f *stockFeed) Heartbeat() time.Duration {
+	return f.heartbeat
+}
+
+func stockTopic(productID int) string {
+	return "stock:" + strconv.Itoa(productID)
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/payment"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+const (
+	// renewalBatchSize limits the due subscriptions renewed per check
+	renewalBatchSize = 100
+
+	// renewalLease is how long a claimed renewal is hidden from other instances; a renewal
+	// interrupted by a crash is retried after it
+	renewalLease = 10 * time.Minute
+)
+
+type SubscriptionService interface {
+	// Plans
+	CreatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error
+	ListPlans(ctx context.Context, productID int) ([]*domain.SubscriptionPlan, error)
+	DeactivatePlan(ctx context.Context, id int) error
+
+	// Subscriptions of a user
+	Subscribe(ctx context.Context, userID, planID, quantity int) (*domain.Subscription, error)
+	ListSubscriptions(ctx context.Context, userID, limit, offset int) ([]*domain.Subscription, int64, error)
+	Pause(ctx context.Context, userID, id int) (*domain.Subscription, error)
+	Resume(ctx context.Context, userID, id int) (*domain.Subscription, error)
+	Cancel(ctx context.Context, userID, id int) (*domain.Subscription, error)
+
+	// Run renews due subscriptions every check interval until ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type subscriptionService struct {
+	subscriptionRepo repository.SubscriptionRepository
+	productRepo      repository.ProductRepository
+	stockRepo        repository.StockRepository
+	interactionRepo  repository.InteractionRepository
+	gateway          payment.Gateway
+	stockFeed        StockFeed
+	tenancy          *config.Tenancy
+	checkInterval    time.Duration
+	retryDelay       time.Duration
+	maxAttempts      int
+}
+
+func NewSubscriptionService(
+	subscriptionRepo repository.SubscriptionRepository,
+	productRepo repository.ProductRepository,
+	stockRepo repository.StockRepository,
+	interactionRepo repository.InteractionRepository,
+	gateway payment.Gateway,
+	stockFeed StockFeed,
+	cfg *config.Config,
+) (SubscriptionService, error) {
+	checkInterval, err := time.ParseDuration(cfg.Subscriptions.CheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse check interval: %w", err)
+	}
+
+	retryDelay, err := time.ParseDuration(cfg.Subscriptions.RetryDelay)
+	if err != nil {
+		return nil, fmt.Errorf("parse retry delay: %w", err)
+	}
+
+	return &subscriptionService{
+		subscriptionRepo: subscriptionRepo,
+		productRepo:      productRepo,
+		stockRepo:        stockRepo,
+		interactionRepo:  interactionRepo,
+		gateway:          gateway,
+		stockFeed:        stockFeed,
+		tenancy:          &cfg.Tenancy,
+		checkInterval:    checkInterval,
+		retryDelay:       retryDelay,
+		maxAttempts:      cfg.Subscriptions.MaxAttempts,
+	}, nil
+}
+
+// CreatePlan offers an existing product as a recurring purchase
+func (s *subscriptionService) CreatePlan(ctx context.Context, plan *domain.SubscriptionPlan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.productRepo.GetByID(ctx, plan.ProductID); err != nil {
+		return err
+	}
+
+	plan.IsActive = true
+	if err := s.subscriptionRepo.CreatePlan(ctx, plan); err != nil {
+		return fmt.Errorf("create subscription plan: %w", err)
+	}
+
+	return nil
+}
+
+// ListPlans retrieves the plans a product can be subscribed to, cheapest first
+func (s *subscriptionService) ListPlans(ctx context.Context, productID int) ([]*domain.SubscriptionPlan, error) {
+	plans, err := s.subscriptionRepo.ListPlans(ctx, productID, true)
+	if err != nil {
+		return nil, fmt.Errorf("list subscription plans: %w", err)
+	}
+	return plans, nil
+}
+
+// DeactivatePlan stops new subscriptions to a plan; existing ones keep renewing at their price
+func (s *subscriptionService) DeactivatePlan(ctx context.Context, id int) error {
+	return s.subscriptionRepo.DeactivatePlan(ctx, id)
+}
+
+// Subscribe subscribes a user to a plan, charging the first period right away. If the first
+// renewal fails the subscription is cancelled and the error returned.
+func (s *subscriptionService) Subscribe(ctx context.Context, userID, planID, quantity int) (*domain.Subscription, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0: %w", domain.ErrValidation)
+	}
+
+	plan, err := s.subscriptionRepo.GetPlan(ctx, planID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, fmt.Errorf("subscription plan %d not found: %w", planID, domain.ErrValidation)
+		}
+		return nil, fmt.Errorf("get subscription plan: %w", err)
+	}
+	if !plan.IsActive {
+		return nil, fmt.Errorf("subscription plan %d is not available: %w", planID, domain.ErrValidation)
+	}
+
+	// The first period is renewed here, so the renewal job starts with the second
+	now := time.Now().UTC()
+	subscription := &domain.Subscription{
+		UserID:        userID,
+		ProductID:     plan.ProductID,
+		PlanID:        plan.ID,
+		Interval:      plan.Interval,
+		Price:         plan.Price,
+		Quantity:      quantity,
+		Status:        domain.SubscriptionActive,
+		NextRenewalAt: domain.NextRenewal(plan.Interval, now),
+	}
+	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("create subscription: %w", err)
+	}
+
+	chargeID, err := s.renew(ctx, subscription, subscription.CreatedAt)
+	if err != nil {
+		subscription.LastError = err.Error()
+		subscription.FailedAttempts = 1
+		if saveErr := s.subscriptionRepo.SaveRenewal(ctx, subscription); saveErr != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("subscriptions").WithError(saveErr).Error("Failed to save subscription renewal")
+		}
+		if _, cancelErr := s.subscriptionRepo.UpdateStatus(ctx, subscription.ID, []string{domain.SubscriptionActive}, domain.SubscriptionCancelled); cancelErr != nil {
+			logger.GetLoggerFromContext(ctx).WithComponent("subscriptions").WithError(cancelErr).Error("Failed to cancel subscription")
+		}
+		return nil, err
+	}
+
+	subscription.LastRenewedAt = &now
+	subscription.LastChargeID = chargeID
+	if err := s.subscriptionRepo.SaveRenewal(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions retrieves a page of a user's subscriptions, newest first
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, userID, limit, offset int) ([]*domain.Subscription, int64, error) {
+	subscriptions, total, err := s.subscriptionRepo.ListByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list subscriptions: %w", err)
+	}
+	return subscriptions, total, nil
+}
+
+// Pause stops renewals of an active or past due subscription until it is resumed
+func (s *subscriptionService) Pause(ctx context.Context, userID, id int) (*domain.Subscription, error) {
+	return s.setStatus(ctx, userID, id, []string{domain.SubscriptionActive, domain.SubscriptionPastDue}, domain.SubscriptionPaused)
+}
+
+// Resume restarts renewals of a paused subscription. A renewal missed while paused is made on
+// the next check, and the following ones are scheduled from then.
+func (s *subscriptionService) Resume(ctx context.Context, userID, id int) (*domain.Subscription, error) {
+	return s.setStatus(ctx, userID, id, []string{domain.SubscriptionPaused}, domain.SubscriptionActive)
+}
+
+// Cancel ends a subscription for good
+func (s *subscriptionService) Cancel(ctx context.Context, userID, id int) (*domain.Subscription, error) {
+	return s.setStatus(ctx, userID, id, []string{domain.SubscriptionActive, domain.SubscriptionPastDue, domain.SubscriptionPaused}, domain.SubscriptionCancelled)
+}
+
+// setStatus changes the status of one of the user's subscriptions. Subscriptions of other
+// users are reported as not found.
+func (s *subscriptionService) setStatus(ctx context.Context, userID, id int, from []string, to string) (*domain.Subscription, error) {
+	subscription, err := s.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+
+	updated, err := s.subscriptionRepo.UpdateStatus(ctx, id, from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			return nil, fmt.Errorf("cannot change a subscription from %s to %s: %w", subscription.Status, to, err)
+		}
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// Run renews due subscriptions of every tenant each check interval until ctx is cancelled
+func (s *subscriptionService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, tenantCtx := range tenant.Contexts(ctx, s.tenancy) {
+			if err := s.renewDue(tenantCtx); err != nil && ctx.Err() == nil {
+				logger.GetLoggerFromContext(ctx).WithComponent("subscriptions").WithError(err).WithFields(logger.Fields{
+					"tenant": tenant.ID(tenantCtx),
+				}).Error("Failed to renew subscriptions")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renewDue renews the subscriptions due now, up to a batch per call
+func (s *subscriptionService) renewDue(ctx context.Context) error {
+	due, err := s.subscriptionRepo.ListDue(ctx, time.Now(), renewalBatchSize)
+	if err != nil {
+		return fmt.Errorf("list due subscriptions: %w", err)
+	}
+
+	for _, subscription := range due {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := s.renewSubscription(ctx, subscription); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renewSubscription renews a due subscription and schedules the next renewal. A failed
+// renewal makes the subscription past due and is retried after the retry delay, until
+// max attempts failures in a row cancel it.
+func (s *subscriptionService) renewSubscription(ctx context.Context, subscription *domain.Subscription) error {
+	log := logger.GetLoggerFromContext(ctx).WithComponent("subscriptions").WithFields(logger.Fields{
+		"subscription_id": subscription.ID,
+	})
+
+	now := time.Now().UTC()
+	due := subscription.NextRenewalAt
+	claimed, err := s.subscriptionRepo.ClaimRenewal(ctx, subscription.ID, due, now.Add(renewalLease))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Renewed by another instance, or paused or cancelled since it was listed
+		return nil
+	}
+
+	status := domain.SubscriptionActive
+	chargeID, err := s.renew(ctx, subscription, due)
+	if err == nil {
+		subscription.NextRenewalAt = domain.NextRenewal(subscription.Interval, due)
+		if subscription.NextRenewalAt.Before(now) {
+			// Renewals missed while paused are not made up for
+			subscription.NextRenewalAt = domain.NextRenewal(subscription.Interval, now)
+		}
+		subscription.LastRenewedAt = &now
+		subscription.LastChargeID = chargeID
+		subscription.FailedAttempts = 0
+		subscription.LastError = ""
+	} else {
+		log.WithError(err).Warn("Subscription renewal failed")
+
+		subscription.NextRenewalAt = now.Add(s.retryDelay)
+		subscription.FailedAttempts++
+		subscription.LastError = err.Error()
+		status = domain.SubscriptionPastDue
+		if subscription.FailedAttempts >= s.maxAttempts {
+			status = domain.SubscriptionCancelled
+		}
+	}
+
+	if err := s.subscriptionRepo.SaveRenewal(ctx, subscription); err != nil {
+		return err
+	}
+
+	// Keep a status set by the user while renewing, such as pausing
+	_, err = s.subscriptionRepo.UpdateStatus(ctx, subscription.ID, []string{domain.SubscriptionActive, domain.SubscriptionPastDue}, status)
+	if err != nil && !errors.Is(err, domain.ErrInvalidTransition) {
+		return err
+	}
+
+	return nil
+}
+
+// renew makes the purchase of one period: it takes the stock, charges the price and records
+// the purchase. The stock is given back if the charge fails. period identifies the renewal in
+// the idempotency key of the charge.
+func (s *subscriptionService) renew(ctx context.Context, subscription *domain.Subscription, period time.Time) (string, error) {
+	product, err := s.productRepo.GetByID(ctx, subscription.ProductID)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return "", fmt.Errorf("product %d not found", subscription.ProductID)
+		}
+		return "", fmt.Errorf("get product: %w", err)
+	}
+
+	locations, err := s.stockRepo.GetLocations(ctx, product.ID)
+	if err != nil {
+		return "", fmt.Errorf("get stock locations: %w", err)
+	}
+	warehouseID, err := pickWarehouse(product, locations, subscription.Quantity, 0)
+	if err != nil {
+		return "", fmt.Errorf("product %d: %w", product.ID, domain.ErrInsufficientStock)
+	}
+
+	adjustment := &domain.StockAdjustment{
+		ProductID:   product.ID,
+		WarehouseID: warehouseID,
+		Delta:       -subscription.Quantity,
+		Reason:      domain.StockReasonPurchase,
+		Note:        fmt.Sprintf("subscription %d", subscription.ID),
+		ActorID:     subscription.UserID,
+	}
+	updated, err := s.stockRepo.Adjust(ctx, adjustment)
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return "", fmt.Errorf("product %d: %w", product.ID, err)
+		}
+		return "", fmt.Errorf("update product stock: %w", err)
+	}
+
+	amount := subscription.Price.Mul(subscription.Quantity)
+	receipt, err := s.gateway.Charge(ctx, payment.Charge{
+		CustomerID:     strconv.Itoa(subscription.UserID),
+		Amount:         amount.Amount,
+		Currency:       amount.Currency,
+		Description:    fmt.Sprintf("Subscription %d: %s x%d", subscription.ID, product.Name, subscription.Quantity),
+		IdempotencyKey: fmt.Sprintf("subscription-%d-%d", subscription.ID, period.Unix()),
+	})
+	if err != nil {
+		if restored, restoreErr := s.stockRepo.Adjust(ctx, &domain.StockAdjustment{
+			ProductID:   adjustment.ProductID,
+			WarehouseID: adjustment.WarehouseID,
+			Delta:       subscription.Quantity,
+			Reason:      domain.StockReasonCorrection,
+			Note:        "payment failed",
+			ActorID:     subscription.UserID,
+		}); restoreErr == nil {
+			s.stockFeed.Publish(restored)
+		}
+		return "", fmt.Errorf("%w: %v", domain.ErrPaymentFailed, err)
+	}
+
+	// The customer has paid at this point, so a failure to record the purchase only loses
+	// it from the interaction history
+	if err := s.interactionRepo.RecordPurchase(ctx, subscription.UserID, product.ID, subscription.Quantity, subscription.Price, product.CostPrice); err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("subscriptions").WithError(err).Error("Failed to record subscription purchase")
+	}
+
+	s.stockFeed.Publish(updated)
+	return receipt.ID, nil
+}
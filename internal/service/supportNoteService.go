@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+)
+
+// SupportNoteService manages the internal notes customer support keeps on users and orders
+type SupportNoteService interface {
+	// ListNotes retrieves a page of the notes on a user or order, pinned ones first, or
+	// ErrNotFound if the subject doesn't exist
+	ListNotes(ctx context.Context, subject, subjectID string, limit, offset int) ([]*domain.SupportNote, int64, error)
+
+	// CreateNote attaches a note to an existing user or order
+	CreateNote(ctx context.Context, note *domain.SupportNote) error
+
+	// UpdateNote changes the text and pinned flag of a note; nil leaves a field unchanged
+	UpdateNote(ctx context.Context, id int, text *string, pinned *bool) (*domain.SupportNote, error)
+	DeleteNote(ctx context.Context, id int) error
+}
+
+type supportNoteService struct {
+	noteRepo repository.SupportNoteRepository
+	userRepo repository.UserRepository
+}
+
+func NewSupportNoteService(noteRepo repository.SupportNoteRepository, userRepo repository.UserRepository) SupportNoteService {
+	return &supportNoteService{
+		noteRepo: noteRepo,
+		userRepo: userRepo,
+	}
+}
+
+func (s *supportNoteService) ListNotes(ctx context.Context, subject, subjectID string, limit, offset int) ([]*domain.SupportNote, int64, error) {
+	if err := s.checkSubject(ctx, subject, subjectID); err != nil {
+		return nil, 0, err
+	}
+
+	notes, total, err := s.noteRepo.List(ctx, subject, subjectID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list support notes: %w", err)
+	}
+
+	return notes, total, nil
+}
+
+func (s *supportNoteService) CreateNote(ctx context.Context, note *domain.SupportNote) error {
+	if err := note.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.checkSubject(ctx, note.Subject, note.SubjectID); err != nil {
+		return err
+	}
+
+	if err := s.noteRepo.Create(ctx, note); err != nil {
+		return fmt.Errorf("create support note: %w", err)
+	}
+
+	return nil
+}
+
+func (s *supportNoteService) UpdateNote(ctx context.Context, id int, text *string, pinned *bool) (*domain.SupportNote, error) {
+	note, err := s.noteRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get support note: %w", err)
+	}
+
+	if text != nil {
+		note.Text = *text
+	}
+	if pinned != nil {
+		note.Pinned = *pinned
+	}
+
+	if err := note.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.noteRepo.Update(ctx, note); err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("update support note: %w", err)
+	}
+
+	return note, nil
+}
+
+// DeleteNote removes a note
+func (s *supportNoteService) DeleteNote(ctx context.Context, id int) error {
+	if err := s.noteRepo.Delete(ctx, id); err != nil {
+		if err == domain.ErrNotFound {
+			return err
+		}
+		return fmt.Errorf("delete support note: %w", err)
+	}
+
+	return nil
+}
+
+// checkSubject returns ErrNotFound unless the user or order a note is on exists
+func (s *supportNoteService) checkSubject(ctx context.Context, subject, subjectID string) error {
+	switch subject {
+	case domain.NoteSubjectUser:
+		userID, err := strconv.Atoi(subjectID)
+		if err != nil {
+			return domain.ErrNotFound
+		}
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			if err == domain.ErrNotFound {
+				return err
+			}
+			return fmt.Errorf("get user: %w", err)
+		}
+
+	case domain.NoteSubjectOrder:
+		exists, err := s.noteRepo.OrderExists(ctx, subjectID)
+		if err != nil {
+			return fmt.Errorf("check order: %w", err)
+		}
+		if !exists {
+			return domain.ErrNotFound
+		}
+
+	default:
+		return fmt.Errorf("unknown note subject %q: %w", subject, domain.ErrValidation)
+	}
+
+	return nil
+}
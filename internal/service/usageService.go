@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/internal/domain"
+	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// UsageService counts the API requests of each user per calendar month and enforces the
+// monthly quotas of their roles. Requests are counted in memory and saved every flush
+// interval, so instances see each other's requests only after they are saved.
+type UsageService interface {
+	// Consume counts a request of the user and returns their usage including it. A request
+	// over the quota isn't counted; Consume returns the usage with domain.ErrRateLimited.
+	Consume(ctx context.Context, userID int, roles []string) (*domain.APIUsage, error)
+
+	// Usage returns the requests of the user this month against their quota
+	Usage(ctx context.Context, userID int, roles []string) (*domain.APIUsage, error)
+
+	// Run saves counted requests every flush interval, and once more when ctx is cancelled
+	Run(ctx context.Context) error
+}
+
+type usageService struct {
+	usageRepo     repository.UsageRepository
+	quotas        map[string]int64
+	flushInterval time.Duration
+
+	mu       sync.Mutex
+	counters map[usageKey]*usageCounter
+}
+
+type usageKey struct {
+	tenantID string
+	userID   int
+	period   string
+}
+
+// usageCounter holds the requests of a user in a period: saved as last read or written, and
+// pending as counted here since
+type usageCounter struct {
+	tenant  *config.Tenant
+	saved   int64
+	pending int64
+	loaded  bool
+}
+
+func NewUsageService(usageRepo repository.UsageRepository, cfg *config.Config) (UsageService, error) {
+	flushInterval, err := time.ParseDuration(cfg.Quotas.FlushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse quota flush interval: %w", err)
+	}
+
+	return &usageService{
+		usageRepo:     usageRepo,
+		quotas:        cfg.Quotas.Roles,
+		flushInterval: flushInterval,
+		counters:      make(map[usageKey]*usageCounter),
+	}, nil
+}
+
+// limit returns the largest quota of the roles, or nil if none has one
+func (s *usageService) limit(roles []string) *int64 {
+	var limit *int64
+	for _, role := range roles {
+		if quota, ok := s.quotas[role]; ok && (limit == nil || quota > *limit) {
+			limit = &quota
+		}
+	}
+	return limit
+}
+
+func (s *usageService) Consume(ctx context.Context, userID int, roles []string) (*domain.APIUsage, error) {
+	period, resetsAt := domain.UsagePeriod(time.Now())
+	key := usageKey{tenantID: tenant.ID(ctx), userID: userID, period: period}
+	limit := s.limit(roles)
+
+	// Requests of users with a quota are checked against what was saved before this instance
+	// started counting them
+	if limit != nil {
+		if err := s.load(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	counter := s.counter(ctx, key)
+	requests := counter.saved + counter.pending
+	var err error
+	if limit != nil && requests >= *limit {
+		err = domain.ErrRateLimited
+	} else {
+		counter.pending++
+		requests++
+	}
+	s.mu.Unlock()
+
+	return newAPIUsage(userID, period, requests, limit, resetsAt), err
+}
+
+// counter returns the counter of the key, adding it if missing. Call it with mu held.
+func (s *usageService) counter(ctx context.Context, key usageKey) *usageCounter {
+	counter := s.counters[key]
+	if counter == nil {
+		counter = &usageCounter{tenant: tenant.FromContext(ctx)}
+		s.counters[key] = counter
+	}
+	return counter
+}
+
+// load reads the saved requests of the key once, for instances that haven't saved any yet
+func (s *usageService) load(ctx context.Context, key usageKey) error {
+	s.mu.Lock()
+	loaded := s.counters[key] != nil && s.counters[key].loaded
+	s.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	saved, err := s.usageRepo.Get(ctx, key.userID, key.period)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	counter := s.counter(ctx, key)
+	counter.saved = max(counter.saved, saved)
+	counter.loaded = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *usageService) Usage(ctx context.Context, userID int, roles []string) (*domain.APIUsage, error) {
+	period, resetsAt := domain.UsagePeriod(time.Now())
+
+	saved, err := s.usageRepo.Get(ctx, userID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if counter := s.counters[usageKey{tenantID: tenant.ID(ctx), userID: userID, period: period}]; counter != nil {
+		saved += counter.pending
+	}
+	s.mu.Unlock()
+
+	return newAPIUsage(userID, period, saved, s.limit(roles), resetsAt), nil
+}
+
+func newAPIUsage(userID int, period string, requests int64, limit *int64, resetsAt time.Time) *domain.APIUsage {
+	usage := &domain.APIUsage{
+		UserID:   userID,
+		Period:   period,
+		Requests: requests,
+		Limit:    limit,
+		ResetsAt: resetsAt,
+	}
+	if limit != nil {
+		remaining := max(*limit-requests, 0)
+		usage.Remaining = &remaining
+	}
+	return usage
+}
+
+func (s *usageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.flush(context.WithoutCancel(ctx))
+			return nil
+		case <-ticker.C:
+			s.flush(ctx)
+		}
+	}
+}
+
+// flush saves the pending requests of every counter and drops counters of past periods once
+// they have nothing left to save
+func (s *usageService) flush(ctx context.Context) {
+	period, _ := domain.UsagePeriod(time.Now())
+
+	type pendingUsage struct {
+		key      usageKey
+		tenant   *config.Tenant
+		requests int64
+	}
+	var batch []pendingUsage
+	s.mu.Lock()
+	for key, counter := range s.counters {
+		if counter.pending > 0 {
+			batch = append(batch, pendingUsage{key: key, tenant: counter.tenant, requests: counter.pending})
+		} else if key.period != period {
+			delete(s.counters, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, usage := range batch {
+		tenantCtx := ctx
+		if usage.tenant != nil {
+			tenantCtx = tenant.NewContext(ctx, usage.tenant)
+		}
+		saved, err := s.usageRepo.Add(tenantCtx, usage.key.userID, usage.key.period, usage.requests)
+		if err != nil {
+			// The requests stay pending for the next flush
+			logger.GetLoggerFromContext(ctx).WithComponent("usage").WithError(err).Error("Failed to save API usage")
+			continue
+		}
+
+		s.mu.Lock()
+		if counter := s.counters[usage.key]; counter != nil {
+			counter.saved = saved
+			counter.pending -= usage.requests
+			counter.loaded = true
+		}
+		s.mu.Unlock()
+	}
+}
@@ -2,42 +2,109 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/PrimeraAizen/e-comm/config"
 	"github.com/PrimeraAizen/e-comm/internal/domain"
 	"github.com/PrimeraAizen/e-comm/internal/repository"
+	"github.com/PrimeraAizen/e-comm/pkg/adapter/mailer"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
 )
 
 type UserService interface {
-	GetProfile(ctx context.Context, userID int) (*domain.User, *domain.Profile, error)
-	UpdateProfile(ctx context.Context, userID int, profileData *domain.Profile) (*domain.Profile, error)
+	GetProfile(ctx context.Context, userID int, fields []string) (*domain.User, *domain.Profile, error)
+	// UpdateProfile saves the fields set in profileData. Free-text fields flagged by moderation
+	// are quarantined instead of saved, and returned in the moderation item.
+	UpdateProfile(ctx context.Context, userID int, profileData *domain.Profile) (*domain.Profile, *domain.ModerationItem, error)
 	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error
 	DeleteAccount(ctx context.Context, userID int) error
+
+	// Email change
+	RequestEmailChange(ctx context.Context, userID int, password, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+
+	// Admin
+	ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int64, error)
+	GetCustomerValue(ctx context.Context, userID int) (*domain.CustomerValue, error)
+
+	// ChangeStatus moves a user to another status on behalf of an admin. Suspending or deleting
+	// a user revokes their sessions and tokens; reactivating one lets them sign in again.
+	ChangeStatus(ctx context.Context, userID, actorID int, status, reason string) (*domain.User, error)
+
+	// Operations
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	// CreateUser creates an active user with a password checked against the policy, without
+	// signing them in
+	CreateUser(ctx context.Context, email, password string) (*domain.User, error)
+	// ResetPassword sets a new password without the current one, and revokes the user's
+	// sessions and tokens
+	ResetPassword(ctx context.Context, userID int, newPassword string) error
 }
 
 type userService struct {
-	userRepo    repository.UserRepository
-	profileRepo repository.ProfileRepository
+	userRepo       repository.UserRepository
+	profileRepo    repository.ProfileRepository
+	passwordPolicy PasswordPolicy
+	moderation     ModerationService
+
+	sessionRepo     repository.SessionRepository
+	emailChangeRepo repository.EmailChangeRepository
+	mailer          mailer.Mailer
+	confirmURL      string
+	emailTokenTTL   time.Duration
 }
 
-func NewUserService(userRepo repository.UserRepository, profileRepo repository.ProfileRepository) UserService {
-	return &userService{
-		userRepo:    userRepo,
-		profileRepo: profileRepo,
+func NewUserService(
+	userRepo repository.UserRepository,
+	profileRepo repository.ProfileRepository,
+	sessionRepo repository.SessionRepository,
+	emailChangeRepo repository.EmailChangeRepository,
+	passwordPolicy PasswordPolicy,
+	moderation ModerationService,
+	mailSender mailer.Mailer,
+	cfg *config.Config,
+) (UserService, error) {
+	tokenTTL, err := time.ParseDuration(cfg.EmailChange.TokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("parse email change token ttl: %w", err)
 	}
+
+	return &userService{
+		userRepo:        userRepo,
+		profileRepo:     profileRepo,
+		passwordPolicy:  passwordPolicy,
+		moderation:      moderation,
+		sessionRepo:     sessionRepo,
+		emailChangeRepo: emailChangeRepo,
+		mailer:          mailSender,
+		confirmURL:      cfg.EmailChange.ConfirmURL,
+		emailTokenTTL:   tokenTTL,
+	}, nil
 }
 
-// GetProfile retrieves user and profile by ID
-func (s *userService) GetProfile(ctx context.Context, userID int) (*domain.User, *domain.Profile, error) {
+// GetProfile retrieves user and profile by ID. If fields is not empty, only the selected
+// profile fields are loaded, and the profile is not loaded at all if none of them is stored on it.
+func (s *userService) GetProfile(ctx context.Context, userID int, fields []string) (*domain.User, *domain.Profile, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("get user by id: %w", err)
 	}
 
-	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if len(fields) > 0 && !selectsProfileField(fields) {
+		return user, nil, nil
+	}
+
+	profile, err := s.profileRepo.GetByUserIDWithFields(ctx, userID, fields)
 	if err != nil {
 		if err == domain.ErrNotFound {
 			// Profile doesn't exist yet, return user with nil profile
@@ -49,8 +116,29 @@ func (s *userService) GetProfile(ctx context.Context, userID int) (*domain.User,
 	return user, profile, nil
 }
 
+// selectsProfileField reports whether any of the fields is stored on the profile document
+func selectsProfileField(fields []string) bool {
+	for _, field := range fields {
+		if domain.ProfileFields[field] != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateProfile updates user profile information (partial update supported)
-func (s *userService) UpdateProfile(ctx context.Context, userID int, profileData *domain.Profile) (*domain.Profile, error) {
+func (s *userService) UpdateProfile(ctx context.Context, userID int, profileData *domain.Profile) (*domain.Profile, *domain.ModerationItem, error) {
+	// Flagged text is withheld until a moderator approves it
+	quarantined, err := s.moderation.Screen(ctx, domain.ModerationSubjectProfile, userID, userID, profileTextFields(profileData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("moderate profile: %w", err)
+	}
+	if quarantined != nil {
+		for field := range quarantined.Fields {
+			setProfileTextField(profileData, field, nil)
+		}
+	}
+
 	// Get existing profile
 	profile, err := s.profileRepo.GetByUserID(ctx, userID)
 	if err != nil {
@@ -58,13 +146,16 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int, profileData
 			// Create new profile
 			profileData.UserID = userID
 			if err := s.profileRepo.Create(ctx, profileData); err != nil {
-				return nil, fmt.Errorf("create profile: %w", err)
+				return nil, nil, fmt.Errorf("create profile: %w", err)
 			}
-			return profileData, nil
+			recordProfileChange(ctx, s.profileRepo, userID, changedProfileFields(&domain.Profile{}, profileData)...)
+			return profileData, quarantined, nil
 		}
-		return nil, fmt.Errorf("get profile: %w", err)
+		return nil, nil, fmt.Errorf("get profile: %w", err)
 	}
 
+	before := *profile
+
 	// Update only provided fields (partial update)
 	if profileData.FirstName != "" {
 		profile.FirstName = profileData.FirstName
@@ -82,6 +173,11 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int, profileData
 		profile.Gender = profileData.Gender
 	}
 	if profileData.Phone != nil {
+		// A new number has to be verified again
+		if profile.Phone == nil || *profile.Phone != *profileData.Phone {
+			profile.PhoneVerified = false
+			profile.PhoneVerifiedAt = nil
+		}
 		profile.Phone = profileData.Phone
 	}
 	if profileData.Address != nil {
@@ -98,10 +194,12 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int, profileData
 	}
 
 	if err := s.profileRepo.Update(ctx, profile); err != nil {
-		return nil, fmt.Errorf("update profile: %w", err)
+		return nil, nil, fmt.Errorf("update profile: %w", err)
 	}
 
-	return profile, nil
+	recordProfileChange(ctx, s.profileRepo, userID, changedProfileFields(&before, profile)...)
+
+	return profile, quarantined, nil
 }
 
 // ChangePassword changes user password
@@ -117,6 +215,11 @@ func (s *userService) ChangePassword(ctx context.Context, userID int, currentPas
 		return fmt.Errorf("invalid current password")
 	}
 
+	// Check new password against the policy
+	if err := s.passwordPolicy.Validate(ctx, newPassword); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -125,28 +228,391 @@ func (s *userService) ChangePassword(ctx context.Context, userID int, currentPas
 
 	// Update password
 	user.PasswordHash = string(hashedPassword)
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = time.Now().UTC()
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return fmt.Errorf("update user: %w", err)
 	}
 
+	recordProfileChange(ctx, s.profileRepo, userID, "password")
+
 	return nil
 }
 
-// DeleteAccount marks user account as inactive (soft delete)
+// ResetPassword replaces the password of a user who can't change it themselves
+func (s *userService) ResetPassword(ctx context.Context, userID int, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+
+	if err := s.passwordPolicy.Validate(ctx, newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	user.PasswordHash = string(hashedPassword)
+	user.UpdatedAt = time.Now().UTC()
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("update user: %w", err)
+	}
+
+	// Whoever had the old password is signed out
+	if err := s.revokeAccess(ctx, userID); err != nil {
+		return err
+	}
+
+	recordProfileChange(ctx, s.profileRepo, userID, "password")
+
+	return nil
+}
+
+// DeleteAccount marks user account as inactive (soft delete) and signs it out everywhere
 func (s *userService) DeleteAccount(ctx context.Context, userID int) error {
+	change := domain.UserStatusChange{
+		Status:    domain.UserStatusDeleted,
+		Reason:    "Deleted by the user",
+		ChangedBy: userID,
+	}
+	if _, err := s.userRepo.UpdateStatus(ctx, userID, []string{domain.UserStatusActive}, change); err != nil {
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			return fmt.Errorf("account is not active: %w", err)
+		}
+		return fmt.Errorf("update user status: %w", err)
+	}
+
+	return s.revokeAccess(ctx, userID)
+}
+
+// RequestEmailChange sends a confirmation link to the new address.
+// The account keeps its current email until the link is confirmed.
+func (s *userService) RequestEmailChange(ctx context.Context, userID int, password, newEmail string) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("get user by id: %w", err)
 	}
 
-	user.Status = "deleted"
-	user.UpdatedAt = time.Now()
+	// Require the password so a hijacked access token can't take over the account
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return domain.ErrInvalidCredentials
+	}
+
+	if newEmail == user.Email {
+		return fmt.Errorf("new email is the same as the current one: %w", domain.ErrValidation)
+	}
+
+	existingUser, err := s.userRepo.GetByEmail(ctx, newEmail)
+	if err != nil && err != domain.ErrNotFound {
+		return fmt.Errorf("check existing user: %w", err)
+	}
+	if existingUser != nil {
+		return domain.ErrAlreadyExists
+	}
+
+	token, err := newTokenID()
+	if err != nil {
+		return err
+	}
+
+	req := &domain.EmailChangeRequest{
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.emailTokenTTL),
+	}
+	if err := s.emailChangeRepo.Save(ctx, req); err != nil {
+		return fmt.Errorf("save email change request: %w", err)
+	}
 
+	link, err := url.Parse(s.confirmURL)
+	if err != nil {
+		return fmt.Errorf("parse confirm url: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg := mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Someone requested to use this address for their account.\n\n"+
+				"Open the link below to confirm the change. It expires in %s.\n\n%s\n\n"+
+				"If you didn't request this, you can ignore this email.\n",
+			s.emailTokenTTL, link.String(),
+		),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send confirmation email: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange switches the account to the new email and revokes all sessions
+// issued for the old one
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) error {
+	req, err := s.emailChangeRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return domain.ErrInvalidToken
+		}
+		return fmt.Errorf("get email change request: %w", err)
+	}
+
+	// The TTL index removes expired requests only periodically
+	if time.Now().After(req.ExpiresAt) {
+		return domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+
+	user.Email = req.NewEmail
 	if err := s.userRepo.Update(ctx, user); err != nil {
+		if err == domain.ErrAlreadyExists {
+			return err
+		}
 		return fmt.Errorf("update user: %w", err)
 	}
 
+	if err := s.emailChangeRepo.Delete(ctx, user.ID); err != nil {
+		return fmt.Errorf("delete email change request: %w", err)
+	}
+
+	if err := s.revokeAccess(ctx, user.ID); err != nil {
+		return err
+	}
+
+	recordProfileChange(ctx, s.profileRepo, user.ID, "email")
+
+	return nil
+}
+
+// revokeAccess revokes all of the user's sessions and invalidates the tokens issued so far
+func (s *userService) revokeAccess(ctx context.Context, userID int) error {
+	if _, err := s.sessionRepo.RevokeAll(ctx, userID); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return fmt.Errorf("increment token version: %w", err)
+	}
+
 	return nil
 }
+
+// recordProfileChange adds the changed fields to the user's activity timeline. The change itself
+// has already been saved, so a failure is only logged.
+func recordProfileChange(ctx context.Context, profileRepo repository.ProfileRepository, userID int, fields ...string) {
+	if len(fields) == 0 {
+		return
+	}
+
+	if err := profileRepo.RecordChange(ctx, &domain.ProfileChange{UserID: userID, Fields: fields}); err != nil {
+		logger.GetLoggerFromContext(ctx).WithComponent("profile").WithError(err).Warn("Failed to record profile change")
+	}
+}
+
+// profileTextFields returns the free-text fields set in the profile, which are moderated, by
+// JSON name
+func profileTextFields(profile *domain.Profile) map[string]string {
+	fields := make(map[string]string)
+	set := func(name string, value *string) {
+		if value != nil && *value != "" {
+			fields[name] = *value
+		}
+	}
+	set("first_name", &profile.FirstName)
+	set("last_name", &profile.LastName)
+	set("middle_name", profile.MiddleName)
+	set("address", profile.Address)
+	set("city", profile.City)
+	return fields
+}
+
+// setProfileTextField sets a field named by profileTextFields, or unsets it if value is nil
+func setProfileTextField(profile *domain.Profile, field string, value *string) {
+	switch field {
+	case "first_name":
+		profile.FirstName = ""
+		if value != nil {
+			profile.FirstName = *value
+		}
+	case "last_name":
+		profile.LastName = ""
+		if value != nil {
+			profile.LastName = *value
+		}
+	case "middle_name":
+		profile.MiddleName = value
+	case "address":
+		profile.Address = value
+	case "city":
+		profile.City = value
+	}
+}
+
+// changedProfileFields returns the JSON names of the profile fields that differ
+func changedProfileFields(before, after *domain.Profile) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+
+	add("first_name", before.FirstName != after.FirstName)
+	add("last_name", before.LastName != after.LastName)
+	add("middle_name", !equalStringPtr(before.MiddleName, after.MiddleName))
+	add("date_of_birth", !equalTimePtr(before.DateOfBirth, after.DateOfBirth))
+	add("gender", !equalStringPtr(before.Gender, after.Gender))
+	add("phone", !equalStringPtr(before.Phone, after.Phone))
+	add("address", !equalStringPtr(before.Address, after.Address))
+	add("city", !equalStringPtr(before.City, after.City))
+	add("country", !equalStringPtr(before.Country, after.Country))
+	add("postal_code", !equalStringPtr(before.PostalCode, after.PostalCode))
+
+	return fields
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// hashToken returns the SHA-256 hash of a token for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListUsers retrieves a page of users with their lifetime value
+func (s *userService) ListUsers(ctx context.Context, filter domain.UserFilter) ([]domain.AdminUser, int64, error) {
+	users, total, err := s.userRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetUserByEmail retrieves a user by email
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get user by email: %w", err)
+	}
+	return user, nil
+}
+
+// CreateUser creates a user with the email and password
+func (s *userService) CreateUser(ctx context.Context, email, password string) (*domain.User, error) {
+	if err := s.passwordPolicy.Validate(ctx, password); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, fmt.Errorf("check existing user: %w", err)
+	}
+	if existingUser != nil {
+		return nil, domain.ErrAlreadyExists
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+
+	user := &domain.User{Email: email, PasswordHash: string(hashedPassword)}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	return user, nil
+}
+
+// GetCustomerValue computes the lifetime value of a user
+func (s *userService) GetCustomerValue(ctx context.Context, userID int) (*domain.CustomerValue, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if err == domain.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	value, err := s.userRepo.GetCustomerValue(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get customer value: %w", err)
+	}
+	return value, nil
+}
+
+// ChangeStatus checks the reason and the transition, then updates the user only if their status
+// hasn't changed in the meantime
+func (s *userService) ChangeStatus(ctx context.Context, userID, actorID int, status, reason string) (*domain.User, error) {
+	if _, ok := domain.UserStatusTransitions[status]; !ok {
+		return nil, fmt.Errorf("invalid status %q: %w", status, domain.ErrValidation)
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required: %w", domain.ErrValidation)
+	}
+	if utf8.RuneCountInString(reason) > domain.MaxStatusReasonLength {
+		return nil, fmt.Errorf("reason must be at most %d characters: %w", domain.MaxStatusReasonLength, domain.ErrValidation)
+	}
+
+	// An admin locking themselves out would need another admin to get back in
+	if userID == actorID {
+		return nil, fmt.Errorf("cannot change your own status: %w", domain.ErrValidation)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The statuses the user can be moved to the new one from
+	var from []string
+	for current, targets := range domain.UserStatusTransitions {
+		if slices.Contains(targets, status) {
+			from = append(from, current)
+		}
+	}
+
+	change := domain.UserStatusChange{Status: status, Reason: reason, ChangedBy: actorID}
+	updated, err := s.userRepo.UpdateStatus(ctx, userID, from, change)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			return nil, fmt.Errorf("cannot change a user from %s to %s: %w", user.Status, status, err)
+		}
+		return nil, err
+	}
+
+	if status != domain.UserStatusActive {
+		if err := s.revokeAccess(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,545 @@
+package testutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/spec"
+)
+
+// SpecPath is the generated OpenAPI spec relative to the module root, written by `make swagger`
+const SpecPath = "docs/swagger.json"
+
+// Contract checks HTTP responses against the generated OpenAPI spec, so tests catch drift
+// between the swagger annotations and what the handlers actually return:
+//
+//	func TestProductContract(t *testing.T) {
+//		contract := testutil.NewContract(t)
+//		router := handler.Init(cfg)
+//
+//		contract.Serve(t, router, httptest.NewRequest(http.MethodGet, "/api/v1/products", nil))
+//		contract.Serve(t, router, httptest.NewRequest(http.MethodGet, "/api/v1/products/999", nil))
+//
+//		for _, route := range contract.Undocumented(router.Routes()) {
+//			t.Errorf("route %s is missing from the spec", route)
+//		}
+//	}
+//
+// A response fails the contract when its status code is not documented for the operation or
+// its JSON body does not match the documented schema: wrong types, missing required fields,
+// values outside an enum and fields the schema does not list. Only paths under the spec's
+// basePath are checked; the v2 envelope and other routes are left alone.
+type Contract struct {
+	swagger *spec.Swagger
+	paths   []contractPath
+
+	mu        sync.Mutex
+	exercised map[string]bool
+}
+
+// contractPath is a spec path split into segments, where parameters are empty strings
+type contractPath struct {
+	template string
+	segments []string
+	params   int
+	item     spec.PathItem
+}
+
+// NewContract loads the spec at SpecPath in the module root, failing the test if it cannot
+func NewContract(t testing.TB) *Contract {
+	t.Helper()
+
+	root, err := moduleRoot()
+	if err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+
+	contract, err := LoadContract(filepath.Join(root, SpecPath))
+	if err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+	return contract
+}
+
+// LoadContract reads a Swagger 2.0 spec in JSON
+func LoadContract(path string) (*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spec: %w", err)
+	}
+
+	var swagger spec.Swagger
+	if err := json.Unmarshal(data, &swagger); err != nil {
+		return nil, fmt.Errorf("parse spec %s: %w", path, err)
+	}
+
+	contract := &Contract{swagger: &swagger, exercised: make(map[string]bool)}
+	if swagger.Paths != nil {
+		for template, item := range swagger.Paths.Paths {
+			path := contractPath{template: template, item: item}
+			for _, segment := range splitPath(template) {
+				if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+					segment = ""
+					path.params++
+				}
+				path.segments = append(path.segments, segment)
+			}
+			contract.paths = append(contract.paths, path)
+		}
+	}
+
+	// Literal segments win over parameters, like in the router: /products/search before /products/{id}
+	sort.Slice(contract.paths, func(i, j int) bool {
+		if contract.paths[i].params != contract.paths[j].params {
+			return contract.paths[i].params < contract.paths[j].params
+		}
+		return contract.paths[i].template < contract.paths[j].template
+	})
+
+	return contract, nil
+}
+
+// Serve sends the request to handler and checks the response against the contract, reporting
+// violations as test errors. The recorded response is returned for further assertions.
+func (c *Contract) Serve(t testing.TB, handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body, err := responseBody(rec)
+	if err != nil {
+		t.Errorf("testutil: %s %s: %v", req.Method, req.URL.Path, err)
+		return rec
+	}
+	if err := c.Validate(req.Method, req.URL.Path, rec.Code, body); err != nil {
+		t.Errorf("testutil: contract violation: %v", err)
+	}
+	return rec
+}
+
+// Validate checks one response. Requests outside the spec's basePath are not part of the
+// contract and always pass.
+func (c *Contract) Validate(method, path string, status int, body []byte) error {
+	apiPath, ok := c.apiPath(path)
+	if !ok {
+		return nil
+	}
+
+	match, ok := c.match(apiPath)
+	if !ok {
+		return fmt.Errorf("%s %s: path is not documented", method, path)
+	}
+
+	operation := operationFor(match.item, method)
+	if operation == nil {
+		return fmt.Errorf("%s %s: method is not documented for %s", method, path, match.template)
+	}
+	c.markExercised(method, match.template)
+
+	response, ok := responseFor(operation, status)
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not documented", method, path, status)
+	}
+	if response.Schema == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%s %s: status %d: body is not JSON: %w", method, path, status, err)
+	}
+
+	var violations []error
+	c.validate(response.Schema, value, "$", &violations, false)
+	if len(violations) > 0 {
+		return fmt.Errorf("%s %s: status %d: %w", method, path, status, errors.Join(violations...))
+	}
+	return nil
+}
+
+// Undocumented lists the router's routes under the basePath that have no operation in the spec
+func (c *Contract) Undocumented(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		apiPath, ok := c.apiPath(ginTemplate(route.Path))
+		if !ok {
+			continue
+		}
+		if match, ok := c.match(apiPath); ok && operationFor(match.item, route.Method) != nil {
+			continue
+		}
+		missing = append(missing, route.Method+" "+route.Path)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Unexercised lists the spec operations no validated response has reached so far
+func (c *Contract) Unexercised() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var missing []string
+	for _, path := range c.paths {
+		for _, method := range httpMethods {
+			if operationFor(path.item, method) == nil {
+				continue
+			}
+			if key := method + " " + path.template; !c.exercised[key] {
+				missing = append(missing, key)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Operation is a documented method of a spec path
+type Operation struct {
+	Method string
+	Path   string // the spec's path template under the basePath, such as /products/{id}
+	Secure bool   // the operation documents a security requirement
+}
+
+// Operations lists the spec's operations, in the order the router matches their paths, so tests
+// can walk the whole spec
+func (c *Contract) Operations() []Operation {
+	var operations []Operation
+	for _, path := range c.paths {
+		for _, method := range httpMethods {
+			operation := operationFor(path.item, method)
+			if operation == nil {
+				continue
+			}
+			operations = append(operations, Operation{
+				Method: method,
+				Path:   path.template,
+				Secure: len(operation.Security) > 0 || len(c.swagger.Security) > 0,
+			})
+		}
+	}
+	return operations
+}
+
+var httpMethods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch,
+}
+
+func (c *Contract) markExercised(method, template string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exercised[strings.ToUpper(method)+" "+template] = true
+}
+
+// apiPath strips the basePath, reporting false for paths outside it
+func (c *Contract) apiPath(path string) (string, bool) {
+	base := strings.TrimSuffix(c.swagger.BasePath, "/")
+	if base == "" {
+		return path, true
+	}
+	if path != base && !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(path, base), true
+}
+
+func (c *Contract) match(path string) (contractPath, bool) {
+	segments := splitPath(path)
+	for _, candidate := range c.paths {
+		if len(candidate.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, segment := range candidate.segments {
+			if segment != "" && segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return candidate, true
+		}
+	}
+	return contractPath{}, false
+}
+
+func operationFor(item spec.PathItem, method string) *spec.Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPost:
+		return item.Post
+	case http.MethodDelete:
+		return item.Delete
+	case http.MethodOptions:
+		return item.Options
+	case http.MethodHead:
+		return item.Head
+	case http.MethodPatch:
+		return item.Patch
+	}
+	return nil
+}
+
+func responseFor(operation *spec.Operation, status int) (spec.Response, bool) {
+	if operation.Responses == nil {
+		return spec.Response{}, false
+	}
+	if response, ok := operation.Responses.StatusCodeResponses[status]; ok {
+		return response, true
+	}
+	if operation.Responses.Default != nil {
+		return *operation.Responses.Default, true
+	}
+	return spec.Response{}, false
+}
+
+// validate appends the ways value breaks schema to violations, naming fields by JSON path.
+// A partial schema is one allOf part, so fields documented by the other parts are not flagged.
+func (c *Contract) validate(schema *spec.Schema, value interface{}, at string, violations *[]error, partial bool) {
+	schema, err := c.resolve(schema)
+	if err != nil {
+		*violations = append(*violations, fmt.Errorf("%s: %w", at, err))
+		return
+	}
+
+	for i := range schema.AllOf {
+		c.validate(&schema.AllOf[i], value, at, violations, true)
+	}
+
+	if value == nil {
+		if nullable, _ := schema.Extensions.GetBool("x-nullable"); !nullable && len(schema.Type) > 0 {
+			*violations = append(*violations, fmt.Errorf("%s: null where %s is documented", at, strings.Join(schema.Type, " or ")))
+		}
+		return
+	}
+
+	if len(schema.Type) > 0 && !matchesType(schema.Type, value) {
+		*violations = append(*violations, fmt.Errorf("%s: %s where %s is documented", at, jsonType(value), strings.Join(schema.Type, " or ")))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		*violations = append(*violations, fmt.Errorf("%s: %v is not one of %v", at, value, schema.Enum))
+	}
+
+	if s, ok := value.(string); ok && schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			*violations = append(*violations, fmt.Errorf("%s: %q is not a date-time", at, s))
+		}
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if schema.Items == nil {
+			return
+		}
+		for i, element := range v {
+			items := schema.Items.Schema
+			if items == nil && i < len(schema.Items.Schemas) {
+				items = &schema.Items.Schemas[i]
+			}
+			if items != nil {
+				c.validate(items, element, fmt.Sprintf("%s[%d]", at, i), violations, false)
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				*violations = append(*violations, fmt.Errorf("%s: required field %q is missing", at, name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			field := at + "." + name
+			if property, ok := schema.Properties[name]; ok {
+				c.validate(&property, v[name], field, violations, false)
+				continue
+			}
+			if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+				c.validate(schema.AdditionalProperties.Schema, v[name], field, violations, false)
+				continue
+			}
+			if !partial && !c.documents(schema, name) {
+				*violations = append(*violations, fmt.Errorf("%s: field is not documented", field))
+			}
+		}
+	}
+}
+
+// documents reports whether the object schema or one of its allOf parts lists the property.
+// Objects without any listed properties, like map[string]interface{}, take any field.
+func (c *Contract) documents(schema *spec.Schema, name string) bool {
+	if len(schema.Properties) == 0 && len(schema.AllOf) == 0 {
+		return true
+	}
+	if _, ok := schema.Properties[name]; ok {
+		return true
+	}
+	if schema.AdditionalProperties != nil && (schema.AdditionalProperties.Allows || schema.AdditionalProperties.Schema != nil) {
+		return true
+	}
+	for i := range schema.AllOf {
+		part, err := c.resolve(&schema.AllOf[i])
+		if err == nil && len(part.Properties) > 0 && c.documents(part, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve follows $ref into the spec's definitions
+func (c *Contract) resolve(schema *spec.Schema) (*spec.Schema, error) {
+	for seen := 0; schema.Ref.String() != ""; seen++ {
+		if seen > 32 {
+			return nil, fmt.Errorf("reference cycle at %s", schema.Ref.String())
+		}
+		name, ok := strings.CutPrefix(schema.Ref.String(), "#/definitions/")
+		if !ok {
+			return nil, fmt.Errorf("unsupported reference %s", schema.Ref.String())
+		}
+		definition, ok := c.swagger.Definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("undefined reference %s", schema.Ref.String())
+		}
+		schema = &definition
+	}
+	return schema, nil
+}
+
+func matchesType(types spec.StringOrArray, value interface{}) bool {
+	for _, typ := range types {
+		switch v := value.(type) {
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case json.Number:
+			if typ == "number" {
+				return true
+			}
+			if typ == "integer" {
+				f, err := v.Float64()
+				if err == nil && f == math.Trunc(f) {
+					return true
+				}
+			}
+		case []interface{}:
+			if typ == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if typ == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseBody returns the recorded body, decompressed when the Compress middleware gzipped it
+func responseBody(rec *httptest.ResponseRecorder) ([]byte, error) {
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		return rec.Body.Bytes(), nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("decompress body: %w", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// ginTemplate rewrites gin route parameters (:id, *path) as spec parameters ({id})
+func ginTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// moduleRoot finds the directory holding go.mod, starting from the test's working directory
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("get working directory: %w", err)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod not found above the working directory")
+		}
+		dir = parent
+	}
+}
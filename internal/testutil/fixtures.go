@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.yaml.in/yaml/v3"
+
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// LoadFixtures inserts the documents of fixture files into their collections. A fixture file
+// maps collection names to lists of documents:
+//
+//	categories:
+//	  - _id: 1
+//	    name: Electronics
+//	products:
+//	  - _id: 1
+//	    name: Laptop
+//	    category_id: 1
+//	    created_at: 2025-01-01T00:00:00Z
+//
+// YAML (.yaml, .yml) timestamps become dates. JSON (.json) files are read as MongoDB Extended
+// JSON, so dates are written as {"$date": "2025-01-01T00:00:00Z"}.
+func LoadFixtures(t testing.TB, db *mongodb.MongoDB, paths ...string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, path := range paths {
+		collections, err := readFixture(path)
+		if err != nil {
+			t.Fatalf("testutil: %v", err)
+		}
+
+		for name, documents := range collections {
+			if len(documents) == 0 {
+				continue
+			}
+			if _, err := db.Collection(name).InsertMany(ctx, documents); err != nil {
+				t.Fatalf("testutil: load %s into %s: %v", path, name, err)
+			}
+		}
+	}
+}
+
+// Truncate removes all documents from the collections but keeps their indexes, for tests that
+// reuse one database across subtests
+func Truncate(t testing.TB, db *mongodb.MongoDB, collections ...string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, name := range collections {
+		if _, err := db.Collection(name).DeleteMany(ctx, bson.M{}); err != nil {
+			t.Fatalf("testutil: truncate %s: %v", name, err)
+		}
+	}
+}
+
+// readFixture decodes a fixture file into documents by collection
+func readFixture(path string) (map[string][]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+
+	collections := make(map[string][]interface{})
+	switch filepath.Ext(path) {
+	case ".json":
+		var decoded map[string][]bson.D
+		if err := bson.UnmarshalExtJSON(data, false, &decoded); err != nil {
+			return nil, fmt.Errorf("decode fixture %s: %w", path, err)
+		}
+		for name, documents := range decoded {
+			for _, document := range documents {
+				collections[name] = append(collections[name], document)
+			}
+		}
+	case ".yaml", ".yml":
+		var decoded map[string][]map[string]interface{}
+		if err := yaml.Unmarshal(data, &decoded); err != nil {
+			return nil, fmt.Errorf("decode fixture %s: %w", path, err)
+		}
+		for name, documents := range decoded {
+			for _, document := range documents {
+				collections[name] = append(collections[name], document)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixture format %q, use .yaml, .yml or .json", path)
+	}
+
+	return collections, nil
+}
@@ -0,0 +1,165 @@
+// Package testutil runs repository and service tests against a real MongoDB.
+//
+// A test package starts one server in TestMain and every test gets its own database:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testutil.RunWithMongo(m))
+//	}
+//
+//	func TestCreateProduct(t *testing.T) {
+//		db := testutil.NewDatabase(t)
+//		testutil.LoadFixtures(t, db, "testdata/catalog.yaml")
+//		repo := repository.NewProductRepository(db)
+//		...
+//	}
+//
+// The server is a MongoDB container started with testcontainers, or the server at
+// TEST_MONGODB_URI when that is set. Without either, tests that need a database are skipped.
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tcmongo "github.com/testcontainers/testcontainers-go/modules/mongodb"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	mongodb "github.com/PrimeraAizen/e-comm/pkg/adapter/mongodb"
+)
+
+// MongoImage is the image the test server runs, the same as in docker-compose.yml
+const MongoImage = "mongo:7"
+
+// URIEnv names the environment variable pointing tests at an existing server instead of a container
+const URIEnv = "TEST_MONGODB_URI"
+
+// Mongo is a MongoDB server shared by the tests of a package
+type Mongo struct {
+	URI       string
+	container *tcmongo.MongoDBContainer
+}
+
+// shared is the server started by RunWithMongo
+var shared *Mongo
+
+// StartMongo starts a single-node replica set in a container, so transactions work as in
+// production, or connects to the server at TEST_MONGODB_URI
+func StartMongo(ctx context.Context) (_ *Mongo, err error) {
+	if uri := os.Getenv(URIEnv); uri != "" {
+		return &Mongo{URI: uri}, nil
+	}
+
+	// testcontainers panics rather than failing when it finds no Docker host
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("start mongo container: %v", r)
+		}
+	}()
+
+	container, err := tcmongo.Run(ctx, MongoImage, tcmongo.WithReplicaSet("rs0"))
+	if err != nil {
+		return nil, fmt.Errorf("start mongo container: %w", err)
+	}
+
+	uri, err := container.ConnectionString(ctx)
+	if err != nil {
+		_ = container.Terminate(context.Background())
+		return nil, fmt.Errorf("get mongo connection string: %w", err)
+	}
+
+	return &Mongo{URI: uri, container: container}, nil
+}
+
+// Stop removes the container. A server given by TEST_MONGODB_URI is left running.
+func (m *Mongo) Stop(ctx context.Context) error {
+	if m.container == nil {
+		return nil
+	}
+	return m.container.Terminate(ctx)
+}
+
+// RunWithMongo starts the shared server, runs the tests and stops the server. It returns the
+// exit code for os.Exit. When no server can be started the tests still run and NewDatabase
+// skips the ones that need it.
+func RunWithMongo(m *testing.M) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	server, err := StartMongo(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("testutil: MongoDB unavailable, database tests will be skipped: %v", err)
+	} else {
+		shared = server
+		defer func() {
+			if err := server.Stop(context.Background()); err != nil {
+				log.Printf("testutil: failed to stop MongoDB: %v", err)
+			}
+		}()
+	}
+
+	return m.Run()
+}
+
+// NewDatabase returns a connection to a new, empty database on the shared server with the
+// application's indexes created. The database is dropped when the test finishes.
+func NewDatabase(t testing.TB) *mongodb.MongoDB {
+	t.Helper()
+
+	if shared == nil {
+		t.Skip("MongoDB is not available; call testutil.RunWithMongo from TestMain and run Docker or set " + URIEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := mongodb.New(ctx, &config.MongoDB{
+		URI:             shared.URI,
+		Database:        databaseName(t),
+		MaxPoolSize:     10,
+		MaxConnIdleTime: 60,
+	})
+	if err != nil {
+		t.Fatalf("testutil: connect to MongoDB: %v", err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := db.Database.Drop(ctx); err != nil {
+			t.Errorf("testutil: drop database %s: %v", db.Database.Name(), err)
+		}
+		if err := db.Close(ctx); err != nil {
+			t.Errorf("testutil: close MongoDB connection: %v", err)
+		}
+	})
+
+	return db
+}
+
+// databaseName builds a unique database name from the test name. MongoDB limits names to
+// 63 bytes and forbids characters such as / and . in them.
+func databaseName(t testing.TB) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatalf("testutil: generate database name: %v", err)
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, t.Name())
+	if len(name) > 45 {
+		name = name[:45]
+	}
+
+	return "test_" + name + "_" + hex.EncodeToString(suffix)
+}
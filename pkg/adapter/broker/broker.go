@@ -0,0 +1,42 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// Message is an event published on a topic
+type Message struct {
+	Topic string
+
+	// Key orders messages: those with the same key are kept in order by the broker
+	Key string
+
+	// ID identifies the message, so consumers and brokers can drop redelivered copies
+	ID string
+
+	Value []byte
+}
+
+// Publisher publishes messages to a message broker. Publish returns once the broker has
+// acknowledged the message, so a message it returned nil for is not lost.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// New creates a publisher for the configured provider
+func New(ctx context.Context, cfg *config.Streaming) (Publisher, error) {
+	switch cfg.Provider {
+	case "kafka":
+		return NewKafka(&cfg.Kafka), nil
+	case "nats":
+		return NewNATS(ctx, &cfg.NATS, []string{cfg.Topics.Views, cfg.Topics.Likes, cfg.Topics.Purchases})
+	case "noop":
+		return NewNoop(), nil
+	default:
+		return nil, fmt.Errorf("unknown streaming provider: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,44 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// Kafka publishes messages to Kafka topics, waiting for all in-sync replicas to acknowledge
+// each one
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+func NewKafka(cfg *config.Kafka) *Kafka {
+	return &Kafka{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(cfg.Brokers...),
+			// Messages with the same key land on the same partition and stay in order
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+}
+
+func (k *Kafka) Publish(ctx context.Context, msg Message) error {
+	err := k.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     []byte(msg.Key),
+		Value:   msg.Value,
+		Headers: []kafka.Header{{Key: "message-id", Value: []byte(msg.ID)}},
+	})
+	if err != nil {
+		return fmt.Errorf("kafka publish: %w", err)
+	}
+	return nil
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,57 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// NATS publishes messages to JetStream subjects. The message ID is passed as Nats-Msg-Id, so
+// the stream drops a message redelivered within its duplicate window.
+type NATS struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// NewNATS connects to the server and, when a stream is configured, creates or updates it to
+// capture subjects
+func NewNATS(ctx context.Context, cfg *config.NATS, subjects []string) (*NATS, error) {
+	conn, err := nats.Connect(cfg.URL, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("nats connect: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("nats jetstream: %w", err)
+	}
+
+	if cfg.Stream != "" {
+		_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:     cfg.Stream,
+			Subjects: subjects,
+		})
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("nats stream %s: %w", cfg.Stream, err)
+		}
+	}
+
+	return &NATS{conn: conn, js: js}, nil
+}
+
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	if _, err := n.js.Publish(ctx, msg.Topic, msg.Value, jetstream.WithMsgID(msg.ID)); err != nil {
+		return fmt.Errorf("nats publish: %w", err)
+	}
+	return nil
+}
+
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,18 @@
+package broker
+
+import "context"
+
+// Noop drops messages. Used when streaming is off.
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Publish(ctx context.Context, msg Message) error {
+	return nil
+}
+
+func (n *Noop) Close() error {
+	return nil
+}
@@ -0,0 +1,225 @@
+// Package elasticsearch is a minimal client for the Elasticsearch REST API. It uses only
+// endpoints shared with OpenSearch, so it works with either.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Client talks to a single Elasticsearch or OpenSearch endpoint
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func New(cfg *config.Elasticsearch) (*Client, error) {
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse elasticsearch timeout: %w", err)
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.URL, "/"),
+		username:   cfg.Username,
+		password:   cfg.Password,
+		httpClient: &http.Client{Timeout: timeout, Transport: logger.PropagateRequestID(nil)},
+	}, nil
+}
+
+// Document is a document to index in bulk
+type Document struct {
+	ID     string
+	Source interface{}
+}
+
+// SearchResponse is the part of a search response the application reads
+type SearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []Hit `json:"hits"`
+	} `json:"hits"`
+}
+
+// Hit is a single search result
+type Hit struct {
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    json.RawMessage     `json:"_source,omitempty"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+}
+
+// EnsureIndex creates the index with the given settings and mappings unless it exists
+func (c *Client) EnsureIndex(ctx context.Context, index string, body interface{}) error {
+	resp, err := c.do(ctx, http.MethodHead, "/"+url.PathEscape(index), "", nil)
+	if err != nil {
+		return fmt.Errorf("check index: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("check index: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := c.call(ctx, http.MethodPut, "/"+url.PathEscape(index), body, nil); err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+	return nil
+}
+
+// Index creates or replaces a document
+func (c *Client) Index(ctx context.Context, index, id string, document interface{}) error {
+	if err := c.call(ctx, http.MethodPut, "/"+url.PathEscape(index)+"/_doc/"+url.PathEscape(id), document, nil); err != nil {
+		return fmt.Errorf("index document: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a document. Deleting a missing document is not an error.
+func (c *Client) Delete(ctx context.Context, index, id string) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/"+url.PathEscape(index)+"/_doc/"+url.PathEscape(id), "", nil)
+	if err != nil {
+		return fmt.Errorf("delete document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete document: %w", readError(resp))
+	}
+	return nil
+}
+
+// Bulk creates or replaces documents in one request
+func (c *Client) Bulk(ctx context.Context, index string, documents []Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, document := range documents {
+		action := map[string]interface{}{"index": map[string]string{"_index": index, "_id": document.ID}}
+		if err := encoder.Encode(action); err != nil {
+			return fmt.Errorf("encode bulk action: %w", err)
+		}
+		if err := encoder.Encode(document.Source); err != nil {
+			return fmt.Errorf("encode bulk document: %w", err)
+		}
+	}
+
+	resp, err := c.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", &body)
+	if err != nil {
+		return fmt.Errorf("bulk index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("bulk index: %w", readError(resp))
+	}
+
+	// A bulk request succeeds as a whole even when single items fail
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error,omitempty"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode bulk response: %w", err)
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, status := range item {
+				if len(status.Error) > 0 {
+					return fmt.Errorf("bulk index document %s: %s", status.ID, status.Error)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeleteByQuery removes all documents matching the query
+func (c *Client) DeleteByQuery(ctx context.Context, index string, query interface{}) error {
+	body := map[string]interface{}{"query": query}
+	if err := c.call(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_delete_by_query?conflicts=proceed", body, nil); err != nil {
+		return fmt.Errorf("delete by query: %w", err)
+	}
+	return nil
+}
+
+// Search runs a search request body against the index
+func (c *Client) Search(ctx context.Context, index string, body interface{}) (*SearchResponse, error) {
+	var result SearchResponse
+	if err := c.call(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", body, &result); err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	return &result, nil
+}
+
+// call sends body as JSON and decodes a successful response into result when it is not nil
+func (c *Client) call(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	resp, err := c.do(ctx, method, path, "application/json", reader)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return readError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+func readError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+}
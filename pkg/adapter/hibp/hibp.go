@@ -0,0 +1,72 @@
+package hibp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultBaseURL = "https://api.pwnedpasswords.com"
+
+// Client queries the Have I Been Pwned "Pwned Passwords" range API.
+// Only the first 5 characters of the password's SHA-1 hash leave the process (k-anonymity).
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func New(timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    defaultBaseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// BreachCount returns how many times the password appears in known data breaches
+func (c *Client) BreachCount(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	// Padding hides the real number of matching suffixes from observers
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("query range: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("query range: unexpected status %d", resp.StatusCode)
+	}
+
+	// Response lines have the form "SUFFIX:COUNT"
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || candidate != suffix {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("parse count: %w", err)
+		}
+		return n, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("read response: %w", err)
+	}
+
+	return 0, nil
+}
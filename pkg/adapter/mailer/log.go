@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Log writes messages to the application log instead of sending them.
+// Intended for local development.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (m *Log) Send(ctx context.Context, msg Message) error {
+	attachments := make([]string, len(msg.Attachments))
+	for i, attachment := range msg.Attachments {
+		attachments[i] = attachment.Filename
+	}
+
+	logger.GetLoggerFromContext(ctx).WithComponent("mailer").WithFields(logger.Fields{
+		"to":          msg.To,
+		"subject":     msg.Subject,
+		"body":        msg.Body,
+		"html":        msg.HTML != "",
+		"attachments": attachments,
+	}).Info("Email not sent (log provider)")
+	return nil
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// Message is an email with a plain-text body. HTML, when set, is sent as an alternative to
+// Body for clients that display it.
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates a mailer for the configured provider
+func New(cfg *config.Mail) (Mailer, error) {
+	switch cfg.Provider {
+	case "smtp":
+		return NewSMTP(cfg), nil
+	case "log":
+		return NewLog(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail provider: %s", cfg.Provider)
+	}
+}
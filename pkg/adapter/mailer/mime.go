@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// base64LineLength is the longest line of a base64 body allowed by RFC 2045
+const base64LineLength = 76
+
+// writeBody writes the Content-Type header and body of the message: plain text alone, or a
+// multipart body with the HTML alternative and the attachments
+func writeBody(b *bytes.Buffer, msg Message) error {
+	if msg.HTML == "" && len(msg.Attachments) == 0 {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(msg.Body)
+		return nil
+	}
+
+	// The text and its HTML alternative come first, then the attachments
+	var content bytes.Buffer
+	contentType := "text/plain; charset=UTF-8"
+	if msg.HTML != "" {
+		alternative := multipart.NewWriter(&content)
+		if err := writePart(alternative, "text/plain; charset=UTF-8", "", []byte(msg.Body)); err != nil {
+			return err
+		}
+		if err := writePart(alternative, "text/html; charset=UTF-8", "", []byte(msg.HTML)); err != nil {
+			return err
+		}
+		if err := alternative.Close(); err != nil {
+			return err
+		}
+		contentType = "multipart/alternative; boundary=" + alternative.Boundary()
+	} else {
+		content.WriteString(msg.Body)
+	}
+
+	if len(msg.Attachments) == 0 {
+		fmt.Fprintf(b, "Content-Type: %s\r\n\r\n", contentType)
+		b.Write(content.Bytes())
+		return nil
+	}
+
+	var mixedBody bytes.Buffer
+	mixed := multipart.NewWriter(&mixedBody)
+	header := textproto.MIMEHeader{"Content-Type": {contentType}}
+	part, err := mixed.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(content.Bytes()); err != nil {
+		return err
+	}
+	for _, attachment := range msg.Attachments {
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+		if err := writePart(mixed, attachment.ContentType, disposition, attachment.Data); err != nil {
+			return err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mixed.Boundary())
+	b.Write(mixedBody.Bytes())
+	return nil
+}
+
+// writePart writes a base64-encoded part, as an attachment when disposition is set
+func writePart(w *multipart.Writer, contentType, disposition string, data []byte) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if disposition != "" {
+		header.Set("Content-Disposition", disposition)
+	}
+
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > base64LineLength {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:base64LineLength]); err != nil {
+			return err
+		}
+		encoded = encoded[base64LineLength:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
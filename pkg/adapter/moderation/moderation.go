@@ -0,0 +1,31 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// Verdict is what a moderation API decided about a text
+type Verdict struct {
+	Flagged    bool
+	Categories []string // why it was flagged, e.g. "harassment"
+}
+
+// Moderator screens text with an external moderation API
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Verdict, error)
+}
+
+// New creates a moderator for the configured provider
+func New(cfg *config.Moderation) (Moderator, error) {
+	switch cfg.Provider {
+	case "webhook":
+		return NewWebhook(&cfg.Webhook)
+	case "none":
+		return NewNoop(), nil
+	default:
+		return nil, fmt.Errorf("unknown moderation provider: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,14 @@
+package moderation
+
+import "context"
+
+// Noop flags nothing, leaving moderation to the banned terms
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Moderate(ctx context.Context, text string) (Verdict, error) {
+	return Verdict{}, nil
+}
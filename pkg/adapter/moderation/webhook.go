@@ -0,0 +1,68 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// Webhook asks an HTTP moderation API, which can front a hosted service or an in-house model
+type Webhook struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewWebhook(cfg *config.ModerationWebhook) (*Webhook, error) {
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse moderation webhook timeout: %w", err)
+	}
+
+	return &Webhook{
+		url:        cfg.URL,
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (w *Webhook) Moderate(ctx context.Context, text string) (Verdict, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return Verdict{}, fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("moderate text: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("moderate text: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Flagged    bool     `json:"flagged"`
+		Categories []string `json:"categories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Verdict{}, fmt.Errorf("decode response: %w", err)
+	}
+
+	return Verdict{Flagged: result.Flagged, Categories: result.Categories}, nil
+}
@@ -0,0 +1,198 @@
+package mongodb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// restoreBatchSize is the number of documents inserted at once on restore
+	restoreBatchSize = 1000
+
+	// maxBackupLine bounds a line of a backup; a 16 MB document grows in Extended JSON
+	maxBackupLine = 64 << 20
+
+	duplicateKeyCode = 11000
+)
+
+// backupLine is a line of a backup: a document in canonical Extended JSON, which keeps BSON types
+// such as ObjectIDs, dates and integer widths intact
+type backupLine struct {
+	Collection string          `json:"collection"`
+	Document   json.RawMessage `json:"document"`
+}
+
+// RestoreOptions select what Restore writes
+type RestoreOptions struct {
+	Collections []string // restore only these; all in the backup by default
+	Drop        bool     // drop each collection before restoring it
+}
+
+// Backup writes the documents of collections, or of every collection when none are given, to w
+// as gzip-compressed NDJSON and returns the number of documents per collection. It reads the
+// collections directly, so a backup holds the data of all tenants.
+func (m *MongoDB) Backup(ctx context.Context, w io.Writer, collections []string) (map[string]int64, error) {
+	if len(collections) == 0 {
+		names, err := m.Database.ListCollectionNames(ctx, bson.D{{Key: "type", Value: "collection"}})
+		if err != nil {
+			return nil, fmt.Errorf("list collections: %w", err)
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, "system.") {
+				collections = append(collections, name)
+			}
+		}
+		sort.Strings(collections)
+	}
+
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	encoder.SetEscapeHTML(false)
+
+	counts := make(map[string]int64, len(collections))
+	for _, name := range collections {
+		count, err := backupCollection(ctx, m.Database.Collection(name), encoder)
+		if err != nil {
+			return counts, fmt.Errorf("back up %s: %w", name, err)
+		}
+		counts[name] = count
+	}
+
+	if err := gz.Close(); err != nil {
+		return counts, fmt.Errorf("close backup: %w", err)
+	}
+	return counts, nil
+}
+
+func backupCollection(ctx context.Context, collection *mongo.Collection, encoder *json.Encoder) (int64, error) {
+	cursor, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		return 0, fmt.Errorf("find documents: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var count int64
+	for cursor.Next(ctx) {
+		document, err := bson.MarshalExtJSON(cursor.Current, true, false)
+		if err != nil {
+			return count, fmt.Errorf("encode document: %w", err)
+		}
+		if err := encoder.Encode(backupLine{Collection: collection.Name(), Document: document}); err != nil {
+			return count, fmt.Errorf("write document: %w", err)
+		}
+		count++
+	}
+	return count, cursor.Err()
+}
+
+// Restore inserts the documents of a backup written by Backup and returns the number inserted
+// per collection. Documents that already exist, by _id or a unique index, are skipped, so
+// restoring into a database that still holds part of the data fills in the rest. Dropped
+// collections lose their indexes; BuildMissingIndexes recreates them.
+func (m *MongoDB) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) (map[string]int64, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open backup: %w", err)
+	}
+	defer gz.Close()
+
+	wanted := make(map[string]bool, len(opts.Collections))
+	for _, name := range opts.Collections {
+		wanted[name] = true
+	}
+
+	counts := make(map[string]int64)
+	batch := make([]interface{}, 0, restoreBatchSize)
+	var current string
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted, err := restoreBatch(ctx, m.Database.Collection(current), batch)
+		counts[current] += inserted
+		batch = batch[:0]
+		if err != nil {
+			return fmt.Errorf("restore %s: %w", current, err)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxBackupLine)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line backupLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return counts, fmt.Errorf("parse line %d: %w", lineNumber, err)
+		}
+		if len(wanted) > 0 && !wanted[line.Collection] {
+			continue
+		}
+
+		if line.Collection != current {
+			if err := flush(); err != nil {
+				return counts, err
+			}
+			current = line.Collection
+
+			if _, seen := counts[current]; !seen {
+				counts[current] = 0
+				if opts.Drop {
+					if err := m.Database.Collection(current).Drop(ctx); err != nil {
+						return counts, fmt.Errorf("drop %s: %w", current, err)
+					}
+				}
+			}
+		}
+
+		var document bson.D
+		if err := bson.UnmarshalExtJSON(line.Document, true, &document); err != nil {
+			return counts, fmt.Errorf("parse line %d: %w", lineNumber, err)
+		}
+		batch = append(batch, document)
+
+		if len(batch) >= restoreBatchSize {
+			if err := flush(); err != nil {
+				return counts, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return counts, fmt.Errorf("read backup: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return counts, err
+	}
+	return counts, nil
+}
+
+// restoreBatch inserts documents and returns how many were inserted, skipping duplicates
+func restoreBatch(ctx context.Context, collection *mongo.Collection, documents []interface{}) (int64, error) {
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return int64(len(documents)), nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return 0, err
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != duplicateKeyCode {
+			return int64(len(documents) - len(bulkErr.WriteErrors)), err
+		}
+	}
+	return int64(len(documents) - len(bulkErr.WriteErrors)), nil
+}
@@ -0,0 +1,226 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// ErrCircuitOpen is returned instead of running an operation while the circuit breaker is open
+var ErrCircuitOpen = errors.New("database unavailable: circuit breaker open")
+
+// States of the circuit breaker
+const (
+	CircuitClosed   = "closed"    // operations run
+	CircuitOpen     = "open"      // operations fail at once
+	CircuitHalfOpen = "half_open" // one operation runs to test the database, the others fail
+)
+
+// Circuit is the state of the circuit breaker and the health score it is based on
+type Circuit struct {
+	State   string    `json:"state"`
+	Health  float64   `json:"health"`             // share of the operations in the window that didn't fail, 1 without any
+	RetryAt time.Time `json:"retry_at,omitempty"` // when an open breaker lets an operation through
+}
+
+// breaker fails operations fast while the database is degraded. It keeps the outcomes of the
+// operations of the last window in one bucket per second; when at least minRequests of them
+// ran and failureRate of those failed, it opens for openFor. After that, the next operation is
+// let through: if it succeeds the breaker closes, if it fails the breaker opens again.
+type breaker struct {
+	enabled     bool
+	minRequests int
+	failureRate float64
+	openFor     time.Duration
+
+	mu      sync.Mutex
+	buckets []breakerBucket
+	state   string
+	retryAt time.Time
+	probing bool
+}
+
+type breakerBucket struct {
+	second    int64
+	succeeded int
+	failed    int
+}
+
+func newBreaker(cfg *config.MongoBreaker) *breaker {
+	return &breaker{
+		enabled:     cfg.Enabled,
+		minRequests: cfg.MinRequests,
+		failureRate: cfg.FailureRate,
+		openFor:     time.Duration(cfg.OpenFor) * time.Second,
+		buckets:     make([]breakerBucket, max(cfg.Window, 1)),
+		state:       CircuitClosed,
+	}
+}
+
+// allow returns ErrCircuitOpen if an operation may not run now
+func (b *breaker) allow() error {
+	if b == nil || !b.enabled {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Now().Before(b.retryAt) {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+	default:
+		return nil
+	}
+	b.probing = true
+	return nil
+}
+
+// record counts the outcome of an operation that was allowed to run. Operations cancelled by
+// their caller say nothing about the database and aren't counted.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b == nil || !b.enabled {
+		return
+	}
+	failed := isUnavailable(err)
+	ignored := !failed && errors.Is(err, context.Canceled)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.state == CircuitHalfOpen {
+		if ignored {
+			b.probing = false
+			return
+		}
+		b.probing = false
+		if failed {
+			b.open(ctx, now)
+			return
+		}
+		b.state = CircuitClosed
+		for i := range b.buckets {
+			b.buckets[i] = breakerBucket{}
+		}
+		logger.GetLoggerFromContext(ctx).WithComponent("mongodb").Info("Database circuit breaker closed")
+		return
+	}
+	if ignored || b.state != CircuitClosed {
+		return
+	}
+
+	bucket := b.bucket(now)
+	if !failed {
+		bucket.succeeded++
+		return
+	}
+	bucket.failed++
+
+	succeeded, failures := b.counts(now)
+	if total := succeeded + failures; total >= b.minRequests && float64(failures) >= b.failureRate*float64(total) {
+		b.open(ctx, now)
+	}
+}
+
+// open opens the breaker for openFor. Call it with mu held.
+func (b *breaker) open(ctx context.Context, now time.Time) {
+	b.state = CircuitOpen
+	b.retryAt = now.Add(b.openFor)
+	succeeded, failed := b.counts(now)
+	logger.GetLoggerFromContext(ctx).WithComponent("mongodb").WithFields(logger.Fields{
+		"succeeded": succeeded,
+		"failed":    failed,
+		"retry_at":  b.retryAt,
+	}).Warn("Database circuit breaker opened")
+}
+
+// bucket returns the bucket of the second of now, emptying it if it held an older second.
+// Call it with mu held.
+func (b *breaker) bucket(now time.Time) *breakerBucket {
+	second := now.Unix()
+	bucket := &b.buckets[second%int64(len(b.buckets))]
+	if bucket.second != second {
+		*bucket = breakerBucket{second: second}
+	}
+	return bucket
+}
+
+// counts returns the outcomes of the operations of the window. Call it with mu held.
+func (b *breaker) counts(now time.Time) (succeeded, failed int) {
+	oldest := now.Unix() - int64(len(b.buckets))
+	for _, bucket := range b.buckets {
+		if bucket.second > oldest {
+			succeeded += bucket.succeeded
+			failed += bucket.failed
+		}
+	}
+	return succeeded, failed
+}
+
+// circuit returns the state of the breaker
+func (b *breaker) circuit() Circuit {
+	if b == nil || !b.enabled {
+		return Circuit{State: CircuitClosed, Health: 1}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	circuit := Circuit{State: b.state, Health: 1}
+	if succeeded, failed := b.counts(now); succeeded+failed > 0 {
+		circuit.Health = float64(succeeded) / float64(succeeded+failed)
+	}
+	if b.state == CircuitOpen {
+		circuit.RetryAt = b.retryAt
+	}
+	return circuit
+}
+
+// isUnavailable reports whether err means the database is unreachable or too slow, rather than
+// that the operation itself failed
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var selection topology.ServerSelectionError
+	return IsTimeout(err) || mongo.IsNetworkError(err) || errors.As(err, &selection)
+}
+
+// Circuit returns the state of the circuit breaker of the database
+func (m *MongoDB) Circuit() Circuit {
+	return m.breaker.circuit()
+}
+
+type rejectionsKey struct{}
+
+// TrackRejections returns a copy of ctx that records whether the circuit breaker refused any
+// operation run with it. The HTTP layer uses it to answer 503 instead of 500.
+func TrackRejections(ctx context.Context) (context.Context, *atomic.Bool) {
+	rejected := new(atomic.Bool)
+	return context.WithValue(ctx, rejectionsKey{}, rejected), rejected
+}
+
+// noteRejected records err in the tracker of ctx, and returns it
+func noteRejected(ctx context.Context, err error) error {
+	if rejected, ok := ctx.Value(rejectionsKey{}).(*atomic.Bool); ok {
+		rejected.Store(true)
+	}
+	return err
+}
@@ -0,0 +1,600 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PrimeraAizen/e-comm/pkg/tenant"
+)
+
+// TenantField is the field holding the tenant of a document
+const TenantField = "tenant_id"
+
+// sharedCollections hold data of the whole deployment rather than of a tenant. Their documents
+// are found by IDs that are unique across tenants, such as the integer IDs from counters.
+var sharedCollections = map[string]bool{
+	"counters":         true,
+	"roles":            true,
+	"permissions":      true,
+	"role_permissions": true,
+	"user_factors":     true,
+	"item_factors":     true,
+	"settings":         true,
+	"outbox":           true,
+}
+
+// Collection is a MongoDB collection whose operations are scoped to the tenant of their
+// context: filters and pipelines only match the tenant's documents and inserted documents
+// are tagged with it. Without a tenant in the context, as when tenancy is disabled, operations
+// see all documents. $lookup stages are not scoped; they join by IDs unique across tenants.
+//
+// Operations are also bounded by the query timeout, or the aggregate timeout for aggregations,
+// and carry the ID of the HTTP request they run for as their $comment.
+type Collection struct {
+	collection *mongo.Collection
+	shared     bool
+	scoped     bool // tenancy enabled; prefixes indexes with the tenant
+	timeouts   timeouts
+	breaker    *breaker
+}
+
+// Name returns the name of the collection
+func (c *Collection) Name() string {
+	return c.collection.Name()
+}
+
+// tenantID returns the tenant to scope an operation to, or "" to leave it unscoped
+func (c *Collection) tenantID(ctx context.Context) string {
+	if c.shared {
+		return ""
+	}
+	return tenant.ID(ctx)
+}
+
+// Find runs the query for at most the query timeout on the server, so iterating the cursor
+// is not cut short by a deadline of its own
+func (c *Collection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if c.timeouts.query > 0 {
+		opts = append([]*options.FindOptions{options.Find().SetMaxTime(c.timeouts.query)}, opts...)
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.FindOptions{options.Find().SetComment(comment)}, opts...)
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	cursor, err := c.collection.Find(ctx, filter, opts...)
+	return cursor, c.done(ctx, err)
+}
+
+func (c *Collection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.FindOneOptions{options.FindOne().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, noteRejected(ctx, err), nil)
+	}
+	result := c.collection.FindOne(ctx, filter, opts...)
+	c.done(ctx, result.Err())
+	return result
+}
+
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.FindOneAndUpdateOptions{options.FindOneAndUpdate().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, noteRejected(ctx, err), nil)
+	}
+	result := c.collection.FindOneAndUpdate(ctx, filter, update, opts...)
+	c.done(ctx, result.Err())
+	return result
+}
+
+func (c *Collection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return 0, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.CountOptions{options.Count().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return 0, noteRejected(ctx, err)
+	}
+	count, err := c.collection.CountDocuments(ctx, filter, opts...)
+	return count, c.done(ctx, err)
+}
+
+func (c *Collection) Distinct(ctx context.Context, fieldName string, filter interface{}, opts ...*options.DistinctOptions) ([]interface{}, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.DistinctOptions{options.Distinct().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	values, err := c.collection.Distinct(ctx, fieldName, filter, opts...)
+	return values, c.done(ctx, err)
+}
+
+func (c *Collection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	document, err := scopeDocument(document, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.InsertOneOptions{options.InsertOne().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.InsertOne(ctx, document, opts...)
+	return result, c.done(ctx, err)
+}
+
+func (c *Collection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	if tenantID := c.tenantID(ctx); tenantID != "" {
+		scoped := make([]interface{}, len(documents))
+		for i, document := range documents {
+			var err error
+			if scoped[i], err = scopeDocument(document, tenantID); err != nil {
+				return nil, err
+			}
+		}
+		documents = scoped
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.InsertManyOptions{options.InsertMany().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.InsertMany(ctx, documents, opts...)
+	return result, c.done(ctx, err)
+}
+
+func (c *Collection) UpdateOne(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.UpdateOptions{options.Update().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.UpdateOne(ctx, filter, update, opts...)
+	return result, c.done(ctx, err)
+}
+
+func (c *Collection) UpdateMany(ctx context.Context, filter, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.UpdateOptions{options.Update().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.UpdateMany(ctx, filter, update, opts...)
+	return result, c.done(ctx, err)
+}
+
+func (c *Collection) ReplaceOne(ctx context.Context, filter, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	tenantID := c.tenantID(ctx)
+	filter, err := scopeFilter(filter, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err = scopeDocument(replacement, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.ReplaceOptions{options.Replace().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.ReplaceOne(ctx, filter, replacement, opts...)
+	return result, c.done(ctx, err)
+}
+
+func (c *Collection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.DeleteOptions{options.Delete().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.DeleteOne(ctx, filter, opts...)
+	return result, c.done(ctx, err)
+}
+
+func (c *Collection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	filter, err := scopeFilter(filter, c.tenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.DeleteOptions{options.Delete().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.DeleteMany(ctx, filter, opts...)
+	return result, c.done(ctx, err)
+}
+
+// BulkWrite scopes the filters and documents of insert, update, replace and delete models
+func (c *Collection) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	if tenantID := c.tenantID(ctx); tenantID != "" {
+		scoped := make([]mongo.WriteModel, len(models))
+		for i, model := range models {
+			var err error
+			if scoped[i], err = scopeWriteModel(model, tenantID); err != nil {
+				return nil, err
+			}
+		}
+		models = scoped
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.BulkWriteOptions{options.BulkWrite().SetComment(comment)}, opts...)
+	}
+	ctx, cancel := c.timeouts.withQuery(ctx)
+	defer cancel()
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	result, err := c.collection.BulkWrite(ctx, models, opts...)
+	return result, c.done(ctx, err)
+}
+
+// Aggregate scopes the first stage of the pipeline and the pipelines of $unionWith stages.
+// Like Find, the pipeline runs for at most the aggregate timeout on the server.
+func (c *Collection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	pipeline, err := scopePipeline(pipeline, c.tenantID(ctx), true)
+	if err != nil {
+		return nil, err
+	}
+	if c.timeouts.aggregate > 0 {
+		opts = append([]*options.AggregateOptions{options.Aggregate().SetMaxTime(c.timeouts.aggregate)}, opts...)
+	}
+	if comment := requestComment(ctx); comment != "" {
+		opts = append([]*options.AggregateOptions{options.Aggregate().SetComment(comment)}, opts...)
+	}
+	if err := c.breaker.allow(); err != nil {
+		return nil, noteRejected(ctx, err)
+	}
+	cursor, err := c.collection.Aggregate(ctx, pipeline, opts...)
+	return cursor, c.done(ctx, err)
+}
+
+// Watch opens a change stream on the collection. Change streams are not scoped; they follow
+// documents by IDs unique across tenants. They run until ctx is done, without a timeout.
+func (c *Collection) Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
+	return c.collection.Watch(ctx, pipeline, opts...)
+}
+
+// done counts the outcome of an operation in the circuit breaker and notes a timeout
+func (c *Collection) done(ctx context.Context, err error) error {
+	c.breaker.record(ctx, err)
+	return noteTimeout(ctx, err)
+}
+
+// AssignTenant tags the documents without a tenant in all tenant collections with the given
+// tenant, returning the number of documents changed. Use it to move a database created before
+// tenancy was enabled, or seeded data, to a tenant.
+func (m *MongoDB) AssignTenant(ctx context.Context, tenantID string) (int64, error) {
+	names, err := m.Database.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return 0, fmt.Errorf("list collections: %w", err)
+	}
+
+	var assigned int64
+	for _, name := range names {
+		if sharedCollections[name] || strings.HasPrefix(name, "system.") {
+			continue
+		}
+		result, err := m.Database.Collection(name).UpdateMany(ctx,
+			bson.M{TenantField: bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{TenantField: tenantID}},
+		)
+		if err != nil {
+			return assigned, fmt.Errorf("assign %s to tenant: %w", name, err)
+		}
+		assigned += result.ModifiedCount
+	}
+
+	return assigned, nil
+}
+
+// Indexes returns the index view of the collection
+func (c *Collection) Indexes() IndexView {
+	return IndexView{view: c.collection.Indexes(), scoped: c.scoped && !c.shared}
+}
+
+// IndexView creates indexes. When tenancy is enabled every index but TTL indexes, which must
+// have a single field, is prefixed with the tenant, so unique indexes are unique per tenant.
+type IndexView struct {
+	view   mongo.IndexView
+	scoped bool
+}
+
+func (v IndexView) CreateOne(ctx context.Context, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
+	return v.view.CreateOne(ctx, v.scopeModel(model), opts...)
+}
+
+func (v IndexView) CreateMany(ctx context.Context, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	scoped := make([]mongo.IndexModel, len(models))
+	for i, model := range models {
+		scoped[i] = v.scopeModel(model)
+	}
+	return v.view.CreateMany(ctx, scoped, opts...)
+}
+
+func (v IndexView) scopeModel(model mongo.IndexModel) mongo.IndexModel {
+	if !v.scoped || model.Options != nil && model.Options.ExpireAfterSeconds != nil {
+		return model
+	}
+	if keys, ok := model.Keys.(bson.D); ok && (len(keys) == 0 || keys[0].Key != TenantField) {
+		model.Keys = append(bson.D{{Key: TenantField, Value: 1}}, keys...)
+	}
+	return model
+}
+
+// scopeFilter returns a copy of the filter that also matches the tenant, or the filter
+// itself if tenantID is empty
+func scopeFilter(filter interface{}, tenantID string) (interface{}, error) {
+	if tenantID == "" {
+		return filter, nil
+	}
+
+	switch f := filter.(type) {
+	case nil:
+		return bson.D{{Key: TenantField, Value: tenantID}}, nil
+	case bson.M:
+		scoped := make(bson.M, len(f)+1)
+		for key, value := range f {
+			scoped[key] = value
+		}
+		scoped[TenantField] = tenantID
+		return scoped, nil
+	case bson.D:
+		scoped := make(bson.D, len(f), len(f)+1)
+		copy(scoped, f)
+		return append(scoped, bson.E{Key: TenantField, Value: tenantID}), nil
+	default:
+		return bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: TenantField, Value: tenantID}}}}}, nil
+	}
+}
+
+// scopeDocument returns a copy of the document tagged with the tenant, or the document itself
+// if tenantID is empty
+func scopeDocument(document interface{}, tenantID string) (interface{}, error) {
+	if tenantID == "" {
+		return document, nil
+	}
+
+	var doc bson.D
+	switch d := document.(type) {
+	case bson.D:
+		doc = make(bson.D, len(d), len(d)+1)
+		copy(doc, d)
+	default:
+		raw, err := bson.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("marshal document: %w", err)
+		}
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return nil, fmt.Errorf("unmarshal document: %w", err)
+		}
+	}
+
+	for i, e := range doc {
+		if e.Key == TenantField {
+			doc[i].Value = tenantID
+			return doc, nil
+		}
+	}
+	return append(doc, bson.E{Key: TenantField, Value: tenantID}), nil
+}
+
+func scopeWriteModel(model mongo.WriteModel, tenantID string) (mongo.WriteModel, error) {
+	var err error
+	switch m := model.(type) {
+	case *mongo.InsertOneModel:
+		scoped := *m
+		scoped.Document, err = scopeDocument(m.Document, tenantID)
+		return &scoped, err
+	case *mongo.UpdateOneModel:
+		scoped := *m
+		scoped.Filter, err = scopeFilter(m.Filter, tenantID)
+		return &scoped, err
+	case *mongo.UpdateManyModel:
+		scoped := *m
+		scoped.Filter, err = scopeFilter(m.Filter, tenantID)
+		return &scoped, err
+	case *mongo.ReplaceOneModel:
+		scoped := *m
+		if scoped.Filter, err = scopeFilter(m.Filter, tenantID); err != nil {
+			return nil, err
+		}
+		scoped.Replacement, err = scopeDocument(m.Replacement, tenantID)
+		return &scoped, err
+	case *mongo.DeleteOneModel:
+		scoped := *m
+		scoped.Filter, err = scopeFilter(m.Filter, tenantID)
+		return &scoped, err
+	case *mongo.DeleteManyModel:
+		scoped := *m
+		scoped.Filter, err = scopeFilter(m.Filter, tenantID)
+		return &scoped, err
+	default:
+		return nil, fmt.Errorf("unsupported write model %T", model)
+	}
+}
+
+// scopePipeline returns a copy of the pipeline that only sees the tenant's documents. A
+// leading $match gets the tenant added, so $text searches stay the first stage; otherwise
+// one is prepended. matchFirst is false for $unionWith pipelines of shared collections.
+func scopePipeline(pipeline interface{}, tenantID string, matchFirst bool) (interface{}, error) {
+	if tenantID == "" {
+		return pipeline, nil
+	}
+
+	var stages []interface{}
+	switch p := pipeline.(type) {
+	case mongo.Pipeline:
+		for _, stage := range p {
+			stages = append(stages, stage)
+		}
+	case []bson.D:
+		for _, stage := range p {
+			stages = append(stages, stage)
+		}
+	case []bson.M:
+		for _, stage := range p {
+			stages = append(stages, stage)
+		}
+	case bson.A:
+		stages = append(stages, p...)
+	case []interface{}:
+		stages = append(stages, p...)
+	default:
+		return nil, fmt.Errorf("unsupported pipeline %T", pipeline)
+	}
+
+	scoped := make(bson.A, 0, len(stages)+1)
+	for i, stage := range stages {
+		name, value, ok := stageOperator(stage)
+		if !ok {
+			return nil, fmt.Errorf("unsupported pipeline stage %T", stage)
+		}
+
+		switch {
+		case i == 0 && matchFirst && name == "$match":
+			match, err := scopeFilter(value, tenantID)
+			if err != nil {
+				return nil, err
+			}
+			stage = bson.D{{Key: "$match", Value: match}}
+		case name == "$unionWith":
+			union, err := scopeUnionWith(value, tenantID)
+			if err != nil {
+				return nil, err
+			}
+			stage = bson.D{{Key: "$unionWith", Value: union}}
+		}
+		if i == 0 && matchFirst && name != "$match" {
+			scoped = append(scoped, bson.D{{Key: "$match", Value: bson.D{{Key: TenantField, Value: tenantID}}}})
+		}
+		scoped = append(scoped, stage)
+	}
+	if len(stages) == 0 && matchFirst {
+		scoped = append(scoped, bson.D{{Key: "$match", Value: bson.D{{Key: TenantField, Value: tenantID}}}})
+	}
+
+	return scoped, nil
+}
+
+// scopeUnionWith scopes the pipeline of a $unionWith stage to the tenant
+func scopeUnionWith(value interface{}, tenantID string) (bson.M, error) {
+	var union bson.M
+	switch v := value.(type) {
+	case string:
+		union = bson.M{"coll": v}
+	case bson.M:
+		union = make(bson.M, len(v))
+		for key, field := range v {
+			union[key] = field
+		}
+	case bson.D:
+		union = make(bson.M, len(v))
+		for _, field := range v {
+			union[field.Key] = field.Value
+		}
+	default:
+		return nil, fmt.Errorf("unsupported $unionWith %T", value)
+	}
+
+	coll, _ := union["coll"].(string)
+	pipeline := union["pipeline"]
+	if pipeline == nil {
+		pipeline = bson.A{}
+	}
+
+	scoped, err := scopePipeline(pipeline, tenantID, !sharedCollections[coll])
+	if err != nil {
+		return nil, err
+	}
+	union["pipeline"] = scoped
+	return union, nil
+}
+
+// stageOperator returns the operator and argument of a single-operator pipeline stage
+func stageOperator(stage interface{}) (string, interface{}, bool) {
+	switch s := stage.(type) {
+	case bson.D:
+		if len(s) == 1 {
+			return s[0].Key, s[0].Value, true
+		}
+	case bson.M:
+		if len(s) == 1 {
+			for key, value := range s {
+				return key, value, true
+			}
+		}
+	}
+	return "", nil, false
+}
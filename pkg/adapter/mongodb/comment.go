@@ -0,0 +1,17 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// requestComment returns the $comment tagging an operation with the ID of the HTTP request it
+// runs for, so it can be found in the profiler, currentOp and slow query logs; "" outside of
+// a request
+func requestComment(ctx context.Context) string {
+	if requestID := logger.RequestID(ctx); requestID != "" {
+		return "request_id:" + requestID
+	}
+	return ""
+}
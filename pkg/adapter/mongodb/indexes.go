@@ -0,0 +1,539 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errCodeNamespaceNotFound is returned for collections that do not exist yet
+const errCodeNamespaceNotFound = 26
+
+// collectionIndexes are the indexes a collection is expected to have
+type collectionIndexes struct {
+	collection string
+	indexes    []mongo.IndexModel
+}
+
+// expectedIndexes are created on start and verified by IndexReport
+var expectedIndexes = []collectionIndexes{
+	{"users", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "customer_group_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}},
+	{"products", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		},
+		{
+			Keys: bson.D{{Key: "category_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "price.amount", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "is_active", Value: 1}},
+		},
+	}},
+	{"categories", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "parent_id", Value: 1}},
+		},
+	}},
+	{"roles", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	{"user_roles", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "role_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	{"permissions", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "resource", Value: 1}, {Key: "action", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	{"role_permissions", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "role_id", Value: 1}, {Key: "permission_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "permission_id", Value: 1}},
+		},
+	}},
+	{"orders", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}},
+		},
+	}},
+	// A view is one event of a user or guest on a product at a time; the unique index keeps a
+	// view batched twice from being recorded twice and leads with user_id and product_id. Guests
+	// all have user_id 0, so their anonymous_id sets them apart.
+	{"user_product_views", []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "product_id", Value: 1},
+				{Key: "viewed_at", Value: -1},
+				{Key: "anonymous_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "product_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "viewed_at", Value: -1}},
+		},
+	}},
+	{"user_product_likes", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "product_id", Value: 1}},
+		},
+	}},
+	{"recommendation_feedback", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	{"recommendation_clicks", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "product_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "clicked_at", Value: -1}},
+		},
+	}},
+	// Expired sessions are removed by the TTL index
+	{"sessions", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_used_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}},
+	// Expired email change requests are removed by the TTL index
+	{"email_change_requests", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}},
+	// Phone verification codes expire via TTL index
+	{"phone_verifications", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}},
+	// Profile change log, read newest first by the activity timeline
+	{"profile_changes", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "changed_at", Value: -1}}},
+	}},
+	// Stock ledger, read newest first per product
+	{"stock_adjustments", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	}},
+	// Warehouses are looked up by their unique code
+	{"warehouses", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	// One inventory record per product and warehouse
+	{"inventory", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "warehouse_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "warehouse_id", Value: 1}, {Key: "product_id", Value: 1}},
+		},
+	}},
+	// Bundles are listed newest first, optionally by status
+	{"bundles", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "is_active", Value: 1}, {Key: "created_at", Value: -1}}},
+	}},
+	// Segments have unique names; members are looked up per segment and per user
+	{"segments", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	{"segment_members", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "segment_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "segment_id", Value: 1}, {Key: "evaluated_at", Value: 1}}},
+	}},
+	// Subscription plans are listed per product
+	{"subscription_plans", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "is_active", Value: 1}}},
+	}},
+	// Subscriptions are listed per user; the renewal job looks up due ones by status
+	{"subscriptions", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_renewal_at", Value: 1}}},
+	}},
+	// The abandoned cart job looks up carts by idle time
+	{"carts", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "updated_at", Value: 1}}},
+	}},
+	// Recovery stats are computed over a range of abandonment times
+	{"abandoned_carts", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "abandoned_at", Value: 1}}},
+	}},
+	// One waiting room counter per flash sale
+	{"flash_sale_queues", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "starts_at", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	// One waiting room place per user per flash sale
+	{"flash_sale_places", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "starts_at", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	// Each waiting room position buys once; removed by the TTL index once its token expires
+	{"flash_sale_tickets", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "starts_at", Value: 1}, {Key: "position", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}},
+	// One allowance per product, user and purchase limit window, removed by the TTL index
+	// once the window ends
+	{"purchase_allowances", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "window_start", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}},
+	// Recommendations read a user's purchases of products; risk scoring counts a buyer's recent
+	// purchases; reports and totals read a range of them. Purchases aren't unique: the same user
+	// can buy the same product twice within a millisecond.
+	{"user_product_purchases", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purchased_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "anonymous_id", Value: 1}, {Key: "purchased_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "purchased_at", Value: -1}},
+		},
+	}},
+	// Stale bought together lists are deleted, and the latest one found, by computation time
+	{"bought_together", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "computed_at", Value: 1}}},
+	}},
+	// Notes are listed per user or order, pinned first, then newest first
+	{"support_notes", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subject", Value: 1}, {Key: "subject_id", Value: 1}, {Key: "pinned", Value: -1}, {Key: "created_at", Value: -1}}},
+	}},
+	// The search report groups the searches of a period
+	{"searches", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "searched_at", Value: -1}}},
+	}},
+	// The review queue is listed by status, oldest first
+	{"risk_reviews", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	}},
+	// API usage is counted per user and month
+	{"api_usage", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "period", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	// The moderation queue is listed by status, oldest first
+	{"moderation_items", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	}},
+	// The promotion job reads the active templates of an occasion
+	{"promotion_templates", []mongo.IndexModel{
+		{Keys: bson.D{{Key: "occasion", Value: 1}, {Key: "is_active", Value: 1}}},
+	}},
+	// Coupons are redeemed by code and listed per user; a template grants one per user and year
+	{"coupons", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "template_id", Value: 1}, {Key: "year", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "issued_at", Value: -1}}},
+	}},
+	{"customer_groups", []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}},
+	// The relay claims pending events oldest first; delivered ones are removed by the TTL index
+	{"outbox", []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "delivered_at", Value: 1}, {Key: "_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}},
+}
+
+// createIndexes creates all necessary indexes for the database
+func (m *MongoDB) createIndexes(ctx context.Context) error {
+	for _, expected := range expectedIndexes {
+		if _, err := m.Collection(expected.collection).Indexes().CreateMany(ctx, expected.indexes); err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", expected.collection, err)
+		}
+	}
+	return nil
+}
+
+// IndexInfo describes an index of a collection
+type IndexInfo struct {
+	Name               string
+	Keys               bson.D
+	Unique             bool
+	ExpireAfterSeconds *int32
+	Size               int64 // in bytes
+}
+
+// IndexReport compares the indexes of a collection with the expected ones
+type IndexReport struct {
+	Collection string
+	Indexes    []IndexInfo
+	Missing    []string // expected indexes the collection lacks
+	Unexpected []string // indexes that are not expected, besides the one on _id
+}
+
+// IndexReports lists the indexes of every collection with expected indexes and how they differ
+// from the expected ones. Collections that do not exist yet report all their indexes missing.
+func (m *MongoDB) IndexReports(ctx context.Context) ([]IndexReport, error) {
+	reports := make([]IndexReport, 0, len(expectedIndexes))
+	for _, expected := range expectedIndexes {
+		report, err := m.indexReport(ctx, expected)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+// BuildMissingIndexes creates the expected indexes that are missing, returning them as
+// collection.index names. Indexes are built in the background on servers before 4.2; newer
+// servers never block the collection while building.
+func (m *MongoDB) BuildMissingIndexes(ctx context.Context) ([]string, error) {
+	var built []string
+	for _, expected := range expectedIndexes {
+		report, err := m.indexReport(ctx, expected)
+		if err != nil {
+			return built, err
+		}
+		if len(report.Missing) == 0 {
+			continue
+		}
+
+		missing := make(map[string]bool, len(report.Missing))
+		for _, name := range report.Missing {
+			missing[name] = true
+		}
+
+		indexes := m.Collection(expected.collection).Indexes()
+		var models []mongo.IndexModel
+		for _, model := range expected.indexes {
+			if !missing[indexName(indexes.scopeModel(model))] {
+				continue
+			}
+			opts := options.Index()
+			if model.Options != nil {
+				copied := *model.Options
+				opts = &copied
+			}
+			models = append(models, mongo.IndexModel{Keys: model.Keys, Options: opts.SetBackground(true)})
+		}
+
+		names, err := indexes.CreateMany(ctx, models)
+		if err != nil {
+			return built, fmt.Errorf("build %s indexes: %w", expected.collection, err)
+		}
+		for _, name := range names {
+			built = append(built, expected.collection+"."+name)
+		}
+	}
+	return built, nil
+}
+
+func (m *MongoDB) indexReport(ctx context.Context, expected collectionIndexes) (*IndexReport, error) {
+	collection := m.Database.Collection(expected.collection)
+
+	var specs []struct {
+		Name               string `bson:"name"`
+		Key                bson.D `bson:"key"`
+		Unique             bool   `bson:"unique"`
+		ExpireAfterSeconds *int32 `bson:"expireAfterSeconds"`
+	}
+	cursor, err := collection.Indexes().List(ctx)
+	if err == nil {
+		err = cursor.All(ctx, &specs)
+	}
+	if err != nil && !isNamespaceNotFound(err) {
+		return nil, fmt.Errorf("list %s indexes: %w", expected.collection, err)
+	}
+
+	sizes, err := indexSizes(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IndexReport{Collection: expected.collection, Indexes: make([]IndexInfo, 0, len(specs))}
+	present := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		present[spec.Name] = true
+		report.Indexes = append(report.Indexes, IndexInfo{
+			Name:               spec.Name,
+			Keys:               spec.Key,
+			Unique:             spec.Unique,
+			ExpireAfterSeconds: spec.ExpireAfterSeconds,
+			Size:               sizes[spec.Name],
+		})
+	}
+
+	indexes := m.Collection(expected.collection).Indexes()
+	wanted := map[string]bool{"_id_": true}
+	for _, model := range expected.indexes {
+		name := indexName(indexes.scopeModel(model))
+		wanted[name] = true
+		if !present[name] {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+	for _, spec := range specs {
+		if !wanted[spec.Name] {
+			report.Unexpected = append(report.Unexpected, spec.Name)
+		}
+	}
+	sort.Strings(report.Unexpected)
+
+	return report, nil
+}
+
+// indexSizes returns the size in bytes of each index of the collection, summed over shards
+func indexSizes(ctx context.Context, collection *mongo.Collection) (map[string]int64, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$collStats", Value: bson.D{{Key: "storageStats", Value: bson.D{}}}}},
+	})
+	if err != nil {
+		if isNamespaceNotFound(err) {
+			return map[string]int64{}, nil
+		}
+		return nil, fmt.Errorf("get %s stats: %w", collection.Name(), err)
+	}
+
+	var stats []struct {
+		StorageStats struct {
+			IndexSizes map[string]float64 `bson:"indexSizes"`
+		} `bson:"storageStats"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, fmt.Errorf("decode %s stats: %w", collection.Name(), err)
+	}
+
+	sizes := make(map[string]int64)
+	for _, shard := range stats {
+		for name, size := range shard.StorageStats.IndexSizes {
+			sizes[name] += int64(size)
+		}
+	}
+	return sizes, nil
+}
+
+// indexName returns the name the driver gives an index without an explicit name
+func indexName(model mongo.IndexModel) string {
+	if model.Options != nil && model.Options.Name != nil {
+		return *model.Options.Name
+	}
+
+	keys, _ := model.Keys.(bson.D)
+	parts := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func isNamespaceNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == errCodeNamespaceNotFound
+}
@@ -16,6 +16,20 @@ import (
 type MongoDB struct {
 	Client   *mongo.Client
 	Database *mongo.Database
+
+	// transactions is set when the deployment is a replica set or sharded cluster
+	transactions bool
+
+	// tenantScoped prefixes indexes with the tenant
+	tenantScoped bool
+
+	// analytics is the read preference of AnalyticsCollection
+	analytics *readpref.ReadPref
+
+	timeouts timeouts
+
+	// breaker fails operations fast while the database is degraded
+	breaker *breaker
 }
 
 func New(ctx context.Context, cfg *config.MongoDB) (*MongoDB, error) {
@@ -39,156 +53,130 @@ func New(ctx context.Context, cfg *config.MongoDB) (*MongoDB, error) {
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
+	analytics, err := analyticsReadPref(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	db := client.Database(cfg.Database)
 
-	// Create indexes
-	if err := createIndexes(ctx, db); err != nil {
-		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	m := &MongoDB{
+		Client:       client,
+		Database:     db,
+		transactions: supportsTransactions(ctx, db),
+		tenantScoped: cfg.TenantScoped,
+		analytics:    analytics,
+		timeouts: timeouts{
+			query:     time.Duration(cfg.QueryTimeout) * time.Second,
+			aggregate: time.Duration(cfg.AggregateTimeout) * time.Second,
+		},
+		breaker: newBreaker(&cfg.Breaker),
 	}
 
-	return &MongoDB{
-		Client:   client,
-		Database: db,
-	}, nil
-}
-
-func (m *MongoDB) Close(ctx context.Context) error {
-	if m.Client != nil {
-		return m.Client.Disconnect(ctx)
+	// Create indexes
+	if !cfg.SkipIndexCreation {
+		if err := m.createIndexes(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create indexes: %w", err)
+		}
 	}
-	return nil
-}
 
-// Collection returns a collection by name
-func (m *MongoDB) Collection(name string) *mongo.Collection {
-	return m.Database.Collection(name)
+	return m, nil
 }
 
-// createIndexes creates all necessary indexes for the database
-func createIndexes(ctx context.Context, db *mongo.Database) error {
-	// Users collection indexes
-	usersCollection := db.Collection("users")
-	_, err := usersCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "email", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{{Key: "created_at", Value: -1}},
-		},
-	})
+// analyticsReadPref builds the read preference of analytics reads from the config
+func analyticsReadPref(cfg *config.MongoDB) (*readpref.ReadPref, error) {
+	mode, err := readpref.ModeFromString(cfg.AnalyticsReadPreference)
 	if err != nil {
-		return fmt.Errorf("failed to create users indexes: %w", err)
+		return nil, fmt.Errorf("parse analytics read preference: %w", err)
 	}
 
-	// Products collection indexes
-	productsCollection := db.Collection("products")
-	_, err = productsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
-		},
-		{
-			Keys: bson.D{{Key: "category_id", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "price", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "created_at", Value: -1}},
-		},
-		{
-			Keys: bson.D{{Key: "is_active", Value: 1}},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create products indexes: %w", err)
+	var opts []readpref.Option
+	if cfg.AnalyticsMaxStaleness > 0 {
+		opts = append(opts, readpref.WithMaxStaleness(time.Duration(cfg.AnalyticsMaxStaleness)*time.Second))
 	}
 
-	// Categories collection indexes
-	categoriesCollection := db.Collection("categories")
-	_, err = categoriesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "name", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{{Key: "parent_id", Value: 1}},
-		},
-	})
+	analytics, err := readpref.New(mode, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to create categories indexes: %w", err)
+		return nil, fmt.Errorf("create analytics read preference: %w", err)
 	}
+	return analytics, nil
+}
 
-	// Roles collection index
-	rolesCollection := db.Collection("roles")
-	_, err = rolesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "name", Value: 1}},
-		Options: options.Index().SetUnique(true),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create roles indexes: %w", err)
+// supportsTransactions reports whether the server is a replica set member or mongos
+func supportsTransactions(ctx context.Context, db *mongo.Database) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
 	}
-
-	// User roles collection indexes
-	userRolesCollection := db.Collection("user_roles")
-	_, err = userRolesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "role_id", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create user_roles indexes: %w", err)
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false
 	}
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
 
-	// Orders collection indexes
-	ordersCollection := db.Collection("orders")
-	_, err = ordersCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys: bson.D{{Key: "user_id", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "status", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "created_at", Value: -1}},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create orders indexes: %w", err)
+// SupportsTransactions reports whether the deployment is a replica set or sharded cluster, so
+// WithTransaction runs fn in a transaction
+func (m *MongoDB) SupportsTransactions() bool {
+	return m.transactions
+}
+
+// WithTransaction runs fn in a transaction when the deployment supports them. A standalone
+// server has no transactions, so there fn runs on its own and its writes should be safe to retry.
+// Called within a transaction, fn joins it. fn must use the context it is given.
+func (m *MongoDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !m.transactions || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
 	}
 
-	// User product views indexes
-	viewsCollection := db.Collection("user_product_views")
-	_, err = viewsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "product_id", Value: 1}},
-		},
-		{
-			Keys: bson.D{{Key: "viewed_at", Value: -1}},
-		},
-	})
+	session, err := m.Client.StartSession()
 	if err != nil {
-		return fmt.Errorf("failed to create user_product_views indexes: %w", err)
+		return fmt.Errorf("start session: %w", err)
 	}
+	defer session.EndSession(ctx)
 
-	// User product likes indexes
-	likesCollection := db.Collection("user_product_likes")
-	_, err = likesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{{Key: "product_id", Value: 1}},
-		},
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
 	})
-	if err != nil {
-		return fmt.Errorf("failed to create user_product_likes indexes: %w", err)
-	}
+	return err
+}
 
+func (m *MongoDB) Close(ctx context.Context) error {
+	if m.Client != nil {
+		return m.Client.Disconnect(ctx)
+	}
 	return nil
 }
+
+// Collection returns a collection by name, scoped to the tenant of each operation's context
+func (m *MongoDB) Collection(name string) *Collection {
+	return m.collection(m.Database.Collection(name))
+}
+
+// AllTenantsCollection returns a collection like Collection whose operations see the documents
+// of every tenant. Use it only for checks that span tenants, such as whether a role shared by
+// all of them is still assigned anywhere.
+func (m *MongoDB) AllTenantsCollection(name string) *Collection {
+	collection := m.collection(m.Database.Collection(name))
+	collection.shared = true
+	return collection
+}
+
+// AnalyticsCollection returns a collection like Collection whose reads use the analytics read
+// preference and whose operations all get the aggregate timeout. Use it for heavy aggregations
+// and scans that tolerate slightly stale data, and never inside a transaction, which must read
+// from the primary.
+func (m *MongoDB) AnalyticsCollection(name string) *Collection {
+	collection := m.collection(m.Database.Collection(name, options.Collection().SetReadPreference(m.analytics)))
+	collection.timeouts.query = m.timeouts.aggregate
+	return collection
+}
+
+func (m *MongoDB) collection(collection *mongo.Collection) *Collection {
+	return &Collection{
+		collection: collection,
+		shared:     sharedCollections[collection.Name()],
+		scoped:     m.tenantScoped,
+		timeouts:   m.timeouts,
+		breaker:    m.breaker,
+	}
+}
@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// timeouts bound how long a single operation may run; zero leaves it unbounded
+type timeouts struct {
+	query     time.Duration
+	aggregate time.Duration
+}
+
+// withQuery returns a copy of ctx that expires after the query timeout
+func (t timeouts) withQuery(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.query <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, t.query)
+}
+
+type timeoutsKey struct{}
+
+// TrackTimeouts returns a copy of ctx that records whether any operation run with it timed
+// out. The HTTP layer uses it to answer 504 instead of 500 when a query was too slow, however
+// the error was wrapped or handled on the way up.
+func TrackTimeouts(ctx context.Context) (context.Context, *atomic.Bool) {
+	timedOut := new(atomic.Bool)
+	return context.WithValue(ctx, timeoutsKey{}, timedOut), timedOut
+}
+
+// IsTimeout reports whether err is an operation running out of time, by its context deadline
+// or the server's time limit
+func IsTimeout(err error) bool {
+	return err != nil && mongo.IsTimeout(err)
+}
+
+// noteTimeout records err in the tracker of ctx if it is a timeout, and returns it
+func noteTimeout(ctx context.Context, err error) error {
+	if IsTimeout(err) {
+		if timedOut, ok := ctx.Value(timeoutsKey{}).(*atomic.Bool); ok {
+			timedOut.Store(true)
+		}
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Noop logs charges and approves them without collecting money.
+// Intended for local development.
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Charge(ctx context.Context, charge Charge) (*Receipt, error) {
+	logger.GetLoggerFromContext(ctx).WithComponent("payment").WithFields(logger.Fields{
+		"customer": charge.CustomerID,
+		"amount":   charge.Amount,
+		"currency": charge.Currency,
+		"key":      charge.IdempotencyKey,
+	}).Info("Charge approved (noop provider)")
+	return &Receipt{ID: "noop_" + charge.IdempotencyKey}, nil
+}
@@ -0,0 +1,43 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// ErrDeclined is returned when the provider refuses a charge
+var ErrDeclined = errors.New("payment declined")
+
+// Charge is an amount to collect from a customer
+type Charge struct {
+	CustomerID  string
+	Amount      int64 // in minor units of Currency, e.g. cents
+	Currency    string
+	Description string
+
+	// IdempotencyKey identifies the charge, so retrying it never collects twice
+	IdempotencyKey string
+}
+
+// Receipt confirms a successful charge
+type Receipt struct {
+	ID string
+}
+
+// Gateway charges customers through a payment provider
+type Gateway interface {
+	Charge(ctx context.Context, charge Charge) (*Receipt, error)
+}
+
+// New creates a gateway for the configured provider
+func New(cfg *config.Payment) (Gateway, error) {
+	switch cfg.Provider {
+	case "noop":
+		return NewNoop(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", cfg.Provider)
+	}
+}
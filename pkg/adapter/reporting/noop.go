@@ -0,0 +1,17 @@
+package reporting
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Noop drops reports. Used when error reporting is off.
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Report(record slog.Record) {}
+
+func (n *Noop) Flush(timeout time.Duration) {}
@@ -0,0 +1,33 @@
+package reporting
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Reporter sends logged errors to an error tracker
+type Reporter interface {
+	// Report sends an error record; install it with logger.SetHook
+	Report(record slog.Record)
+
+	// Flush waits up to timeout for queued reports to be sent
+	Flush(timeout time.Duration)
+}
+
+// New creates a reporter for the configured DSN, tagged with the release and environment of the
+// logger config. Without a DSN, or with a sample rate of zero for the environment, reports are
+// dropped.
+func New(cfg *config.ErrorReporting, logCfg *logger.Config) (Reporter, error) {
+	sampleRate := cfg.SampleRate
+	if rate, ok := cfg.SampleRates[logCfg.Environment]; ok {
+		sampleRate = rate
+	}
+
+	if cfg.DSN == "" || sampleRate <= 0 {
+		return NewNoop(), nil
+	}
+	return NewSentry(cfg, logCfg, sampleRate)
+}
@@ -0,0 +1,100 @@
+package reporting
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/PrimeraAizen/e-comm/config"
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// taggedFields are the log fields sent as tags. Other fields are left out so reports carry IDs
+// but no personal data such as emails, phone numbers or IP addresses.
+var taggedFields = map[string]bool{
+	"component":      true,
+	"operation":      true,
+	"request_id":     true,
+	"correlation_id": true,
+	"http_method":    true,
+	"http_path":      true,
+	"tenant":         true,
+}
+
+// Sentry reports errors to Sentry or a compatible service such as GlitchTip
+type Sentry struct {
+	client  *sentry.Client
+	service string
+}
+
+func NewSentry(cfg *config.ErrorReporting, logCfg *logger.Config, sampleRate float64) (*Sentry, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         cfg.DSN,
+		Release:     logCfg.Service + "@" + logCfg.Version,
+		Environment: logCfg.Environment,
+		SampleRate:  sampleRate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create sentry client: %w", err)
+	}
+
+	return &Sentry{client: client, service: logCfg.Service}, nil
+}
+
+func (s *Sentry) Report(record slog.Record) {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = record.Message
+	event.Timestamp = record.Time
+	event.Tags["service"] = s.service
+
+	exception := sentry.Exception{Type: record.Message, Stacktrace: callerStacktrace()}
+	record.Attrs(func(attr slog.Attr) bool {
+		switch {
+		case attr.Key == logger.UserIDKey:
+			event.User.ID = attr.Value.String()
+		case attr.Key == "error":
+			exception.Value = attr.Value.String()
+		case attr.Key == "panic":
+			exception.Type = "panic"
+			exception.Value = attr.Value.String()
+			event.Level = sentry.LevelFatal
+		case taggedFields[attr.Key]:
+			event.Tags[attr.Key] = attr.Value.String()
+		}
+		return true
+	})
+	if exception.Value != "" {
+		event.Exception = []sentry.Exception{exception}
+	}
+
+	s.client.CaptureEvent(event, nil, nil)
+}
+
+func (s *Sentry) Flush(timeout time.Duration) {
+	s.client.Flush(timeout)
+}
+
+// callerStacktrace returns the stack of the code that logged the error, without the logging
+// frames on top of it. Logged from a recovered panic, it includes the frames that panicked.
+func callerStacktrace() *sentry.Stacktrace {
+	stacktrace := sentry.NewStacktrace()
+	if stacktrace == nil {
+		return nil
+	}
+
+	// Frames run from the outermost call to the innermost
+	frames := stacktrace.Frames
+	for len(frames) > 0 {
+		module := frames[len(frames)-1].Module
+		if module != "log/slog" && !strings.HasSuffix(module, "/pkg/logger") && !strings.HasSuffix(module, "/pkg/adapter/reporting") {
+			break
+		}
+		frames = frames[:len(frames)-1]
+	}
+	stacktrace.Frames = frames
+	return stacktrace
+}
@@ -0,0 +1,23 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/pkg/logger"
+)
+
+// Noop logs messages instead of sending them.
+// Intended for local development.
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Send(ctx context.Context, to, body string) error {
+	logger.GetLoggerFromContext(ctx).WithComponent("sms").WithFields(logger.Fields{
+		"to":   to,
+		"body": body,
+	}).Info("SMS not sent (noop provider)")
+	return nil
+}
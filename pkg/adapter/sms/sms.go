@@ -0,0 +1,25 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+// Sender delivers text messages to phone numbers
+type Sender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// New creates a sender for the configured provider
+func New(cfg *config.SMS) (Sender, error) {
+	switch cfg.Provider {
+	case "twilio":
+		return NewTwilio(&cfg.Twilio), nil
+	case "noop":
+		return NewNoop(), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %s", cfg.Provider)
+	}
+}
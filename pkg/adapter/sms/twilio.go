@@ -0,0 +1,60 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// Twilio sends messages through the Twilio Programmable Messaging API
+type Twilio struct {
+	accountSID string
+	authToken  string
+	from       string
+	httpClient *http.Client
+}
+
+func NewTwilio(cfg *config.Twilio) *Twilio {
+	return &Twilio{
+		accountSID: cfg.AccountSID,
+		authToken:  cfg.AuthToken,
+		from:       cfg.From,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *Twilio) Send(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.from)
+	form.Set("Body", body)
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioBaseURL, t.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("send message: unexpected status %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+// Package eventbus is an in-process publish/subscribe bus for pushing events to live connections.
+package eventbus
+
+import "sync"
+
+// DefaultBufferSize is the number of events buffered per subscriber
+const DefaultBufferSize = 16
+
+// Bus delivers events published on a topic to every current subscriber of that topic.
+// Publishing never blocks: a subscriber whose buffer is full misses the event.
+type Bus[T any] struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan T]struct{}
+	bufferSize  int
+}
+
+// New creates a bus with the given per-subscriber buffer size
+func New[T any](bufferSize int) *Bus[T] {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Bus[T]{
+		subscribers: make(map[string]map[chan T]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe returns a channel receiving events published on topic and a function that
+// cancels the subscription and closes the channel
+func (b *Bus[T]) Subscribe(topic string) (<-chan T, func()) {
+	ch := make(chan T, b.bufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan T]struct{})
+	}
+	b.subscribers[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[topic], ch)
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends event to the subscribers of topic
+func (b *Bus[T]) Publish(topic string, event T) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of subscribers of topic
+func (b *Bus[T]) Subscribers(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers[topic])
+}
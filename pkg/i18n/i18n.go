@@ -0,0 +1,203 @@
+// Package i18n translates API messages. English is the source language: catalogs map English
+// messages to their translations, so untranslated messages are returned unchanged.
+//
+// A catalog key may contain {} placeholders that match any text, e.g.
+//
+//	"{} is required": "{} обязательно"
+//
+// The matched text is substituted into the translation in order.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the source language of all messages
+const DefaultLocale = "en"
+
+const placeholder = "{}"
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+// Bundle holds the message catalogs of all supported locales
+type Bundle struct {
+	catalogs map[string]*catalog
+	locales  []string
+}
+
+type catalog struct {
+	exact    map[string]string
+	patterns []pattern
+}
+
+type pattern struct {
+	re          *regexp.Regexp
+	translation []string
+}
+
+// New loads the embedded catalogs (locales/<locale>.json)
+func New() (*Bundle, error) {
+	files, err := catalogFiles.ReadDir("locales")
+	if err != nil {
+		return nil, fmt.Errorf("read catalogs: %w", err)
+	}
+
+	b := &Bundle{
+		catalogs: make(map[string]*catalog, len(files)),
+		locales:  []string{DefaultLocale},
+	}
+	for _, file := range files {
+		locale := strings.TrimSuffix(file.Name(), ".json")
+		data, err := catalogFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read catalog %s: %w", locale, err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("parse catalog %s: %w", locale, err)
+		}
+
+		b.catalogs[locale] = newCatalog(messages)
+		b.locales = append(b.locales, locale)
+	}
+
+	return b, nil
+}
+
+func newCatalog(messages map[string]string) *catalog {
+	c := &catalog{exact: make(map[string]string, len(messages))}
+	for key, translation := range messages {
+		if !strings.Contains(key, placeholder) {
+			c.exact[key] = translation
+			continue
+		}
+
+		parts := strings.Split(key, placeholder)
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		c.patterns = append(c.patterns, pattern{
+			re:          regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$"),
+			translation: strings.Split(translation, placeholder),
+		})
+	}
+
+	// Longer keys are more specific, try them first
+	sort.Slice(c.patterns, func(i, j int) bool {
+		return len(c.patterns[i].re.String()) > len(c.patterns[j].re.String())
+	})
+
+	return c
+}
+
+// Locales returns the supported locales, the default first
+func (b *Bundle) Locales() []string {
+	return b.locales
+}
+
+// Supports reports whether the locale has a catalog or is the default
+func (b *Bundle) Supports(locale string) bool {
+	_, ok := b.catalogs[locale]
+	return ok || locale == DefaultLocale
+}
+
+// Match picks the supported locale that best fits an Accept-Language header,
+// e.g. "ru-RU,ru;q=0.9,en;q=0.8". Regional variants fall back to their language.
+func (b *Bundle) Match(acceptLanguage string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{tag: strings.ToLower(tag), quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return DefaultLocale
+		}
+		if b.Supports(c.tag) {
+			return c.tag
+		}
+		if language, _, ok := strings.Cut(c.tag, "-"); ok && b.Supports(language) {
+			return language
+		}
+	}
+
+	return DefaultLocale
+}
+
+// Translate returns the message in the given locale. Messages made of ": "-separated
+// parts, as produced by wrapped errors, are translated part by part. Anything without a
+// translation stays in English.
+func (b *Bundle) Translate(locale, message string) string {
+	c, ok := b.catalogs[locale]
+	if !ok {
+		return message
+	}
+
+	// Some messages contain ": " themselves
+	if translated, ok := c.exact[message]; ok {
+		return translated
+	}
+
+	parts := strings.Split(message, ": ")
+	for i, part := range parts {
+		if translated, ok := c.translate(part); ok {
+			parts[i] = translated
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+func (c *catalog) translate(message string) (string, bool) {
+	if translated, ok := c.exact[message]; ok {
+		return translated, true
+	}
+
+	for _, p := range c.patterns {
+		match := p.re.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+
+		var sb strings.Builder
+		for i, text := range p.translation {
+			sb.WriteString(text)
+			if i+1 < len(match) && i < len(p.translation)-1 {
+				sb.WriteString(match[i+1])
+			}
+		}
+		return sb.String(), true
+	}
+
+	return "", false
+}
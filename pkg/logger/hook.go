@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Hook receives every record logged at error level, with the fields of the logger that wrote it,
+// e.g. to forward it to an error tracker
+type Hook func(record slog.Record)
+
+var hook atomic.Pointer[Hook]
+
+// SetHook installs hook for all loggers, including those created before the call; nil removes it
+func SetHook(h Hook) {
+	if h == nil {
+		hook.Store(nil)
+		return
+	}
+	hook.Store(&h)
+}
+
+// hookHandler passes error records to the installed hook before handling them
+type hookHandler struct {
+	slog.Handler
+	attrs []slog.Attr
+}
+
+func (h *hookHandler) Handle(ctx context.Context, record slog.Record) error {
+	if current := hook.Load(); current != nil && record.Level >= slog.LevelError {
+		withFields := record.Clone()
+		withFields.AddAttrs(h.attrs...)
+		(*current)(withFields)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *hookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &hookHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		attrs:   append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+	}
+}
+
+func (h *hookHandler) WithGroup(name string) slog.Handler {
+	return &hookHandler{Handler: h.Handler.WithGroup(name), attrs: h.attrs}
+}
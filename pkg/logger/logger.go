@@ -100,6 +100,7 @@ func New(config *Config) (*Logger, error) {
 	} else {
 		handler = slog.NewJSONHandler(output, opts)
 	}
+	handler = &hookHandler{Handler: handler}
 
 	// Create logger with service context
 	logger := slog.New(handler).With(
@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,18 +15,69 @@ const (
 	RequestIDKey     = "request_id"
 	CorrelationIDKey = "correlation_id"
 	UserIDKey        = "user_id"
+
+	// RequestIDHeader carries the request ID in requests and responses
+	RequestIDHeader = "X-Request-ID"
 )
 
-// RequestIDMiddleware adds a unique request ID to each request
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware adds a request ID to each request and its response. An ID sent by the
+// client or a proxy in X-Request-ID is kept, so a request can be traced across services.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := generateRequestID()
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = generateRequestID()
+		}
 		c.Set(RequestIDKey, requestID)
-		c.Header("X-Request-ID", requestID)
+		c.Header(RequestIDHeader, requestID)
 		c.Next()
 	}
 }
 
+// RequestID returns the ID of the request ctx belongs to, or "" outside a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// PropagateRequestID wraps an HTTP transport to send the ID of the request the call is made for
+// in X-Request-ID. A nil next uses http.DefaultTransport.
+func PropagateRequestID(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return requestIDTransport{next: next}
+}
+
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if requestID := RequestID(req.Context()); requestID != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// validRequestID reports whether a client-sent ID is safe to log and echo: short and made of
+// letters, digits and -_.:
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
 // LoggingMiddleware logs HTTP requests and responses
 func LoggingMiddleware(logger *Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
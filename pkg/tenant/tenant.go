@@ -0,0 +1,44 @@
+// Package tenant carries the storefront a request or background job works for in its context.
+// The MongoDB adapter scopes every query to the tenant of the context it is given.
+package tenant
+
+import (
+	"context"
+
+	"github.com/PrimeraAizen/e-comm/config"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx scoped to the tenant
+func NewContext(ctx context.Context, tenant *config.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant of ctx, or nil if it is not scoped to one
+func FromContext(ctx context.Context) *config.Tenant {
+	tenant, _ := ctx.Value(contextKey{}).(*config.Tenant)
+	return tenant
+}
+
+// ID returns the ID of the tenant of ctx, or an empty string if it is not scoped to one
+func ID(ctx context.Context) string {
+	if tenant := FromContext(ctx); tenant != nil {
+		return tenant.ID
+	}
+	return ""
+}
+
+// Contexts returns a copy of ctx for each tenant, or ctx itself when tenancy is disabled.
+// Background jobs run once per context so the data they write belongs to a tenant.
+func Contexts(ctx context.Context, tenancy *config.Tenancy) []context.Context {
+	if !tenancy.Enabled {
+		return []context.Context{ctx}
+	}
+
+	contexts := make([]context.Context, len(tenancy.Tenants))
+	for i := range tenancy.Tenants {
+		contexts[i] = NewContext(ctx, &tenancy.Tenants[i])
+	}
+	return contexts
+}